@@ -345,6 +345,21 @@ type CloudBackupInfo struct {
 	Metadata map[string]string
 	// Status indicates the status of the backup
 	Status string
+	// Verification is the result of the last verification of the backup,
+	// nil if the backup was never verified
+	Verification *CloudBackupVerification
+}
+
+// CloudBackupVerification is the result of the verification of a backup
+type CloudBackupVerification struct {
+	// Timestamp is the time at which the verification completed
+	Timestamp time.Time
+	// Status is Done if the backup is intact, Failed otherwise
+	Status CloudBackupStatusType
+	// TestRestore indicates if the backup was restored to a temporary volume
+	TestRestore bool
+	// Info describes the problems found in the backup
+	Info []string
 }
 
 type CloudBackupEnumerateRequest struct {
@@ -385,6 +400,19 @@ type CloudBackupOpType string
 const (
 	CloudBackupOp  = CloudBackupOpType("Backup")
 	CloudRestoreOp = CloudBackupOpType("Restore")
+	// CloudBackupDeleteOp is the deletion of a backup by its retention policy
+	CloudBackupDeleteOp = CloudBackupOpType("Delete")
+	// CloudBackupVerifyOp is the verification of a backup
+	CloudBackupVerifyOp = CloudBackupOpType("Verify")
+)
+
+const (
+	// AlertTypeCloudBackupCorrupted is raised on the source volume of a cloud
+	// backup which failed its verification
+	AlertTypeCloudBackupCorrupted = int64(1001)
+	// AlertTypeVolumeUsageHigh is raised on a volume using more than the
+	// usage alert threshold of its size
+	AlertTypeVolumeUsageHigh = int64(1002)
 )
 
 type CloudBackupStatusType string
@@ -465,6 +493,13 @@ type CloudBackupHistoryItem struct {
 	Timestamp time.Time
 	// Status indicates whether backup was completed/failed
 	Status string
+	// ID is the ID of the backup
+	ID string
+	// OpType indicates if this is a backup or the deletion of a backup by
+	// its retention policy
+	OpType CloudBackupOpType
+	// Info describes the operation, such as the reason of a deletion
+	Info []string
 }
 
 type CloudBackupHistoryResponse struct {
@@ -497,6 +532,95 @@ type CloudBackupScheduleInfo struct {
 	Labels map[string]string
 	// Full indicates if scheduled backups must be full always
 	Full bool
+	// Retention is the retention policy of the backups of this schedule. It
+	// takes precedence over MaxBackups and the policy of the credential.
+	Retention *CloudBackupRetentionPolicy
+}
+
+// CloudBackupRetentionPolicy defines which backups of a volume are kept. When
+// any of the Keep rules is set, a backup is kept if it is selected by one of
+// them, otherwise all backups are kept until they expire.
+type CloudBackupRetentionPolicy struct {
+	// KeepLast is the number of most recent backups kept
+	KeepLast uint
+	// KeepDaily is the number of most recent days for which the latest
+	// backup is kept
+	KeepDaily uint
+	// KeepWeekly is the number of most recent weeks for which the latest
+	// backup is kept
+	KeepWeekly uint
+	// KeepMonthly is the number of most recent months for which the latest
+	// backup is kept
+	KeepMonthly uint
+	// ExpireDays is the age in days after which backups are deleted, even if
+	// selected by a Keep rule. Zero never expires backups on their age.
+	ExpireDays uint
+	// HoldLabels put the backups having any of these labels on legal hold.
+	// Backups on hold are never deleted. An empty value matches any value.
+	HoldLabels map[string]string
+}
+
+type CloudBackupRetentionUpdateRequest struct {
+	// CredentialUUID is the credential the backups are stored with
+	CredentialUUID string
+	// Retention is the retention policy of the backups stored with the
+	// credential. The policy is removed if nil.
+	Retention *CloudBackupRetentionPolicy
+}
+
+type CloudBackupRetentionInspectRequest struct {
+	// CredentialUUID is the credential the backups are stored with
+	CredentialUUID string
+}
+
+type CloudBackupRetentionInspectResponse struct {
+	// Retention is the retention policy of the credential, nil if none
+	Retention *CloudBackupRetentionPolicy
+}
+
+type CloudBackupVerifyRequest struct {
+	// ID is the ID of the cloud backup
+	ID string
+	// CredentialUUID is the credential of the backup
+	CredentialUUID string
+	// TestRestore restores the backup to a temporary volume, which is deleted
+	// once the restore completes
+	TestRestore bool
+	// Name is an optional unique name of the verification task
+	Name string
+}
+
+type CloudBackupVerifyResponse struct {
+	// Name of the task verifying the backup
+	Name string
+}
+
+// CloudBackupScrubPolicy defines the periodic verification of the backups
+// stored with a credential
+type CloudBackupScrubPolicy struct {
+	// Schedule is the frequency of the verifications, in the format of the
+	// backup schedules
+	Schedule string
+	// TestRestore restores each backup to a temporary volume
+	TestRestore bool
+}
+
+type CloudBackupScrubUpdateRequest struct {
+	// CredentialUUID is the credential the backups are stored with
+	CredentialUUID string
+	// Scrub is the scrub policy of the backups. Backups are no longer
+	// scrubbed if nil.
+	Scrub *CloudBackupScrubPolicy
+}
+
+type CloudBackupScrubInspectRequest struct {
+	// CredentialUUID is the credential the backups are stored with
+	CredentialUUID string
+}
+
+type CloudBackupScrubInspectResponse struct {
+	// Scrub is the scrub policy of the credential, nil if none
+	Scrub *CloudBackupScrubPolicy
 }
 
 type CloudBackupSchedCreateRequest struct {
@@ -852,6 +976,14 @@ func (b *CloudBackupInfo) ToSdkCloudBackupInfo() *SdkCloudBackupInfo {
 
 	info.Timestamp, _ = ptypes.TimestampProto(b.Timestamp)
 	info.Status = StringToSdkCloudBackupStatusType(b.Status)
+	if b.Verification != nil {
+		info.Verification = &SdkCloudBackupVerification{
+			Status:      CloudBackupStatusTypeToSdkCloudBackupStatusType(b.Verification.Status),
+			TestRestore: b.Verification.TestRestore,
+			Info:        b.Verification.Info,
+		}
+		info.Verification.Timestamp, _ = ptypes.TimestampProto(b.Verification.Timestamp)
+	}
 
 	return info
 }
@@ -874,6 +1006,10 @@ func CloudBackupOpTypeToSdkCloudBackupOpType(t CloudBackupOpType) SdkCloudBackup
 		return SdkCloudBackupOpType_SdkCloudBackupOpTypeBackupOp
 	case CloudRestoreOp:
 		return SdkCloudBackupOpType_SdkCloudBackupOpTypeRestoreOp
+	case CloudBackupDeleteOp:
+		return SdkCloudBackupOpType_SdkCloudBackupOpTypeDeleteOp
+	case CloudBackupVerifyOp:
+		return SdkCloudBackupOpType_SdkCloudBackupOpTypeVerifyOp
 	default:
 		return SdkCloudBackupOpType_SdkCloudBackupOpTypeUnknown
 	}
@@ -919,6 +1055,9 @@ func (h CloudBackupHistoryItem) ToSdkCloudBackupHistoryItem() *SdkCloudBackupHis
 	item := &SdkCloudBackupHistoryItem{
 		SrcVolumeId: h.SrcVolumeID,
 		Status:      StringToSdkCloudBackupStatusType(h.Status),
+		BackupId:    h.ID,
+		Optype:      CloudBackupOpTypeToSdkCloudBackupOpType(h.OpType),
+		Info:        h.Info,
 	}
 
 	item.Timestamp, _ = ptypes.TimestampProto(h.Timestamp)
@@ -936,3 +1075,31 @@ func (r *CloudBackupHistoryResponse) ToSdkCloudBackupHistoryResponse() *SdkCloud
 
 	return resp
 }
+
+func (p *CloudBackupRetentionPolicy) ToSdkCloudBackupRetentionPolicy() *SdkCloudBackupRetentionPolicy {
+	if p == nil {
+		return nil
+	}
+	return &SdkCloudBackupRetentionPolicy{
+		KeepLast:    uint64(p.KeepLast),
+		KeepDaily:   uint64(p.KeepDaily),
+		KeepWeekly:  uint64(p.KeepWeekly),
+		KeepMonthly: uint64(p.KeepMonthly),
+		ExpireDays:  uint64(p.ExpireDays),
+		HoldLabels:  p.HoldLabels,
+	}
+}
+
+func (p *SdkCloudBackupRetentionPolicy) ToCloudBackupRetentionPolicy() *CloudBackupRetentionPolicy {
+	if p == nil {
+		return nil
+	}
+	return &CloudBackupRetentionPolicy{
+		KeepLast:    uint(p.GetKeepLast()),
+		KeepDaily:   uint(p.GetKeepDaily()),
+		KeepWeekly:  uint(p.GetKeepWeekly()),
+		KeepMonthly: uint(p.GetKeepMonthly()),
+		ExpireDays:  uint(p.GetExpireDays()),
+		HoldLabels:  p.GetHoldLabels(),
+	}
+}
@@ -265,6 +265,26 @@ func (c *clusterClient) Remove(nodes []api.Node, forceRemove bool) error {
 func (c *clusterClient) NodeRemoveDone(nodeID string, result error) {
 }
 
+func (c *clusterClient) NodeRemoveBlockers(nodeID string) ([]cluster.NodeRemoveBlocker, error) {
+	return nil, cluster.ErrNotImplemented
+}
+
+func (c *clusterClient) NodeRemoveStatus(nodeID string) (*cluster.NodeRemoveStatus, error) {
+	return nil, cluster.ErrNotImplemented
+}
+
+func (c *clusterClient) CancelNodeRemove(nodeID string) error {
+	return cluster.ErrNotImplemented
+}
+
+func (c *clusterClient) EnterMaintenance(nodeID string) error {
+	return cluster.ErrNotImplemented
+}
+
+func (c *clusterClient) ExitMaintenance(nodeID string) error {
+	return cluster.ErrNotImplemented
+}
+
 func (c *clusterClient) Shutdown() error {
 	return nil
 }
@@ -365,6 +385,16 @@ func (c *clusterClient) SecretSet(secretID string, secretValue interface{}) erro
 	return nil
 }
 
+// SecretDelete removes the value/data for given key
+func (c *clusterClient) SecretDelete(secretID string) error {
+	path := clusterPath + secretPath
+	resp := c.c.Delete().Resource(path).Instance(secretID).Do()
+	if resp.Error() != nil {
+		return resp.FormatError()
+	}
+	return nil
+}
+
 // SecretGet retrieves the value/data for given key
 func (c *clusterClient) SecretGet(secretID string) (interface{}, error) {
 	var secResp interface{}
@@ -403,6 +433,11 @@ func (c *clusterClient) SecretLogin(secretType string, secretConfig map[string]s
 	return nil
 }
 
+// SecretRotateMasterKey is only available through the SDK
+func (c *clusterClient) SecretRotateMasterKey() (string, error) {
+	return "", cluster.ErrNotImplemented
+}
+
 // SchedPolicyEnumerate enumerates all configured policies
 func (c *clusterClient) SchedPolicyEnumerate() ([]*sched.SchedPolicy, error) {
 	var schedPolicies []*sched.SchedPolicy
@@ -459,6 +494,29 @@ func (c *clusterClient) SchedPolicyDelete(name string) error {
 	return nil
 }
 
+// SchedPolicyForceDelete deletes a policy with given name even if it is in use
+func (c *clusterClient) SchedPolicyForceDelete(name string) error {
+	req := c.c.Delete().Resource(clusterPath+SchedPath).Instance(name).
+		QueryOption("force", "true")
+	res := req.Do()
+
+	if res.Error() != nil {
+		return res.FormatError()
+	}
+
+	return nil
+}
+
+// SchedPolicyTrack is not supported by the REST client
+func (c *clusterClient) SchedPolicyTrack(ref sched.Reference, names []string) error {
+	return sched.ErrNotImplemented
+}
+
+// SchedPolicyReferences is not supported by the REST client
+func (c *clusterClient) SchedPolicyReferences(name string) ([]sched.Reference, error) {
+	return nil, sched.ErrNotImplemented
+}
+
 // SchedPolicyGet returns schedule policy matching given name.
 func (c *clusterClient) SchedPolicyGet(name string) (*sched.SchedPolicy, error) {
 	policy := new(sched.SchedPolicy)
@@ -71,3 +71,67 @@ func (c *clusterClient) DeleteNodeConf(nodeID string) error {
 	}
 	return nil
 }
+
+// osdconfig.ConfigWatcher interface compliance. Watches are not available
+// through the REST API.
+
+func (c *clusterClient) WatchCluster(name string, cb func(config *osdconfig.ClusterConfig) error) error {
+	return osdconfig.ErrNotImplemented
+}
+
+func (c *clusterClient) WatchNode(name string, cb func(config *osdconfig.NodeConfig) error) error {
+	return osdconfig.ErrNotImplemented
+}
+
+func (c *clusterClient) UnwatchCluster(name string) error {
+	return osdconfig.ErrNotImplemented
+}
+
+func (c *clusterClient) UnwatchNode(name string) error {
+	return osdconfig.ErrNotImplemented
+}
+
+// osdconfig.ConfigVersioner interface compliance. Revisions are not
+// available through the REST API.
+
+func (c *clusterClient) SetClusterConfWithInfo(config *osdconfig.ClusterConfig, info *osdconfig.ChangeInfo) error {
+	return c.SetClusterConf(config)
+}
+
+func (c *clusterClient) SetNodeConfWithInfo(config *osdconfig.NodeConfig, info *osdconfig.ChangeInfo) error {
+	return c.SetNodeConf(config)
+}
+
+// The REST API cannot update a configuration atomically.
+
+func (c *clusterClient) UpdateClusterConfWithInfo(update func(current *osdconfig.ClusterConfig) (*osdconfig.ClusterConfig, error), info *osdconfig.ChangeInfo) error {
+	return osdconfig.ErrNotImplemented
+}
+
+func (c *clusterClient) UpdateNodeConfWithInfo(nodeID string, update func(current *osdconfig.NodeConfig) (*osdconfig.NodeConfig, error), info *osdconfig.ChangeInfo) error {
+	return osdconfig.ErrNotImplemented
+}
+
+func (c *clusterClient) EnumerateClusterConfRevisions() ([]*osdconfig.Revision, error) {
+	return nil, osdconfig.ErrNotImplemented
+}
+
+func (c *clusterClient) EnumerateNodeConfRevisions(nodeID string) ([]*osdconfig.Revision, error) {
+	return nil, osdconfig.ErrNotImplemented
+}
+
+func (c *clusterClient) DiffClusterConf(from, to uint64) ([]*osdconfig.Difference, error) {
+	return nil, osdconfig.ErrNotImplemented
+}
+
+func (c *clusterClient) DiffNodeConf(nodeID string, from, to uint64) ([]*osdconfig.Difference, error) {
+	return nil, osdconfig.ErrNotImplemented
+}
+
+func (c *clusterClient) RollbackClusterConf(version uint64, info *osdconfig.ChangeInfo) error {
+	return osdconfig.ErrNotImplemented
+}
+
+func (c *clusterClient) RollbackNodeConf(nodeID string, version uint64, info *osdconfig.ChangeInfo) error {
+	return osdconfig.ErrNotImplemented
+}
@@ -810,3 +810,79 @@ func (v *volumeClient) Catalog(id, subfolder, maxDepth string) (api.CatalogRespo
 
 	return catalog, nil
 }
+
+// CloudBackupRetentionUpdate sets the retention policy of the backups stored
+// with a credential
+func (v *volumeClient) CloudBackupRetentionUpdate(
+	input *api.CloudBackupRetentionUpdateRequest,
+) error {
+	req := v.c.Put().Resource(api.OsdBackupPath + "/retention").Body(input)
+	response := req.Do()
+	if response.Error() != nil {
+		return response.FormatError()
+	}
+	return nil
+}
+
+// CloudBackupRetentionInspect returns the retention policy of the backups
+// stored with a credential
+func (v *volumeClient) CloudBackupRetentionInspect(
+	input *api.CloudBackupRetentionInspectRequest,
+) (*api.CloudBackupRetentionInspectResponse, error) {
+	inspectResponse := &api.CloudBackupRetentionInspectResponse{}
+	req := v.c.Get().Resource(api.OsdBackupPath + "/retention").Body(input)
+	response := req.Do()
+	if response.Error() != nil {
+		return nil, response.FormatError()
+	}
+	if err := response.Unmarshal(inspectResponse); err != nil {
+		return nil, err
+	}
+	return inspectResponse, nil
+}
+
+// CloudBackupVerify starts a task verifying that a backup can be restored
+func (v *volumeClient) CloudBackupVerify(
+	input *api.CloudBackupVerifyRequest,
+) (*api.CloudBackupVerifyResponse, error) {
+	verifyResponse := &api.CloudBackupVerifyResponse{}
+	req := v.c.Post().Resource(api.OsdBackupPath + "/verify").Body(input)
+	response := req.Do()
+	if response.Error() != nil {
+		return nil, response.FormatError()
+	}
+	if err := response.Unmarshal(verifyResponse); err != nil {
+		return nil, err
+	}
+	return verifyResponse, nil
+}
+
+// CloudBackupScrubUpdate sets the schedule at which the backups stored with a
+// credential are verified
+func (v *volumeClient) CloudBackupScrubUpdate(
+	input *api.CloudBackupScrubUpdateRequest,
+) error {
+	req := v.c.Put().Resource(api.OsdBackupPath + "/scrub").Body(input)
+	response := req.Do()
+	if response.Error() != nil {
+		return response.FormatError()
+	}
+	return nil
+}
+
+// CloudBackupScrubInspect returns the scrub schedule of the backups stored
+// with a credential
+func (v *volumeClient) CloudBackupScrubInspect(
+	input *api.CloudBackupScrubInspectRequest,
+) (*api.CloudBackupScrubInspectResponse, error) {
+	inspectResponse := &api.CloudBackupScrubInspectResponse{}
+	req := v.c.Get().Resource(api.OsdBackupPath + "/scrub").Body(input)
+	response := req.Do()
+	if response.Error() != nil {
+		return nil, response.FormatError()
+	}
+	if err := response.Unmarshal(inspectResponse); err != nil {
+		return nil, err
+	}
+	return inspectResponse, nil
+}
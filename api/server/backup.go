@@ -2,9 +2,12 @@ package server
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/libopenstorage/openstorage/api"
+	clustermanager "github.com/libopenstorage/openstorage/cluster/manager"
+	"github.com/libopenstorage/openstorage/pkg/sched"
 	"github.com/libopenstorage/openstorage/volume"
 )
 
@@ -278,6 +281,11 @@ func (vd *volAPI) cloudBackupSchedCreate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if err := checkCloudBackupSchedPolicies(backupSchedReq.Schedule); err != nil {
+		vd.sendError(method, backupSchedReq.SrcVolumeID, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	backupSchedResp, err := d.CloudBackupSchedCreate(backupSchedReq)
 	if err != nil {
 		vd.sendError(method, backupSchedReq.SrcVolumeID, w, err.Error(), http.StatusInternalServerError)
@@ -330,6 +338,26 @@ func (vd *volAPI) cloudBackupSchedDelete(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 }
 
+// checkCloudBackupSchedPolicies returns an error if any of the policies in
+// the policy tags of a cloud backup schedule does not exist. The volume
+// drivers track the policies used by the schedules they create.
+func checkCloudBackupSchedPolicies(schedule string) error {
+	_, policies, err := sched.ParseScheduleAndPolicies(schedule)
+	if err != nil || policies == nil {
+		return err
+	}
+	inst, err := clustermanager.Inst()
+	if err != nil {
+		return err
+	}
+	for _, name := range policies.Names {
+		if _, err := inst.SchedPolicyGet(name); err != nil {
+			return fmt.Errorf("Error accessing schedule policy %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
 func (vd *volAPI) cloudBackupSchedEnumerate(w http.ResponseWriter, r *http.Request) {
 	method := "cloudBackupSchedEnumerate"
 	d, err := vd.getVolDriver(r)
@@ -344,3 +372,113 @@ func (vd *volAPI) cloudBackupSchedEnumerate(w http.ResponseWriter, r *http.Reque
 	}
 	json.NewEncoder(w).Encode(schedules)
 }
+
+func (vd *volAPI) cloudBackupRetentionUpdate(w http.ResponseWriter, r *http.Request) {
+	method := "cloudBackupRetentionUpdate"
+	updateReq := &api.CloudBackupRetentionUpdateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(updateReq); err != nil {
+		vd.sendError(method, "", w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	d, err := vd.getVolDriver(r)
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+
+	err = d.CloudBackupRetentionUpdate(updateReq)
+	if err != nil {
+		vd.sendError(method, updateReq.CredentialUUID, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (vd *volAPI) cloudBackupRetentionInspect(w http.ResponseWriter, r *http.Request) {
+	method := "cloudBackupRetentionInspect"
+	inspectReq := &api.CloudBackupRetentionInspectRequest{}
+	if err := json.NewDecoder(r.Body).Decode(inspectReq); err != nil {
+		vd.sendError(method, "", w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	d, err := vd.getVolDriver(r)
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+
+	retention, err := d.CloudBackupRetentionInspect(inspectReq)
+	if err != nil {
+		vd.sendError(method, inspectReq.CredentialUUID, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(retention)
+}
+
+func (vd *volAPI) cloudBackupVerify(w http.ResponseWriter, r *http.Request) {
+	method := "cloudBackupVerify"
+	verifyReq := &api.CloudBackupVerifyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(verifyReq); err != nil {
+		vd.sendError(method, "", w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	d, err := vd.getVolDriver(r)
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+
+	verifyResp, err := d.CloudBackupVerify(verifyReq)
+	if err != nil {
+		vd.sendError(method, verifyReq.ID, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(verifyResp)
+}
+
+func (vd *volAPI) cloudBackupScrubUpdate(w http.ResponseWriter, r *http.Request) {
+	method := "cloudBackupScrubUpdate"
+	updateReq := &api.CloudBackupScrubUpdateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(updateReq); err != nil {
+		vd.sendError(method, "", w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	d, err := vd.getVolDriver(r)
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+
+	err = d.CloudBackupScrubUpdate(updateReq)
+	if err != nil {
+		vd.sendError(method, updateReq.CredentialUUID, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (vd *volAPI) cloudBackupScrubInspect(w http.ResponseWriter, r *http.Request) {
+	method := "cloudBackupScrubInspect"
+	inspectReq := &api.CloudBackupScrubInspectRequest{}
+	if err := json.NewDecoder(r.Body).Decode(inspectReq); err != nil {
+		vd.sendError(method, "", w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	d, err := vd.getVolDriver(r)
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+
+	scrub, err := d.CloudBackupScrubInspect(inspectReq)
+	if err != nil {
+		vd.sendError(method, inspectReq.CredentialUUID, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(scrub)
+}
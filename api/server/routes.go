@@ -33,6 +33,7 @@ func (c *clusterApi) Routes() []*Route {
 		{verb: "GET", path: clusterSecretPath("/verify", cluster.APIVersion), fn: c.secretLoginCheck},
 		{verb: "GET", path: clusterSecretPath("", cluster.APIVersion), fn: c.getSecret},
 		{verb: "PUT", path: clusterSecretPath("", cluster.APIVersion), fn: c.setSecret},
+		{verb: "DELETE", path: clusterSecretPath("/{id}", cluster.APIVersion), fn: c.deleteSecret},
 		{verb: "GET", path: clusterSecretPath("/defaultsecretkey", cluster.APIVersion), fn: c.getDefaultSecretKey},
 		{verb: "PUT", path: clusterSecretPath("/defaultsecretkey", cluster.APIVersion), fn: c.setDefaultSecretKey},
 		{verb: "POST", path: clusterSecretPath("/login", cluster.APIVersion), fn: c.secretsLogin},
@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	clustermanager "github.com/libopenstorage/openstorage/cluster/manager"
@@ -192,9 +193,16 @@ func (c *clusterApi) schedPolicyUpdate(w http.ResponseWriter, r *http.Request) {
 //   description: policy name and schedule to delete
 //   required: true
 //   type: string
+// - name: force
+//   in: query
+//   description: delete the policy even if it is in use
+//   required: false
+//   type: boolean
 // responses:
 //   '200':
 //     description: success
+//   '409':
+//     description: policy is in use
 func (c *clusterApi) schedPolicyDelete(w http.ResponseWriter, r *http.Request) {
 	method := "schedPolicyDelete"
 	vars := mux.Vars(r)
@@ -211,8 +219,16 @@ func (c *clusterApi) schedPolicyDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = inst.SchedPolicyDelete(schedName)
+	if force, _ := strconv.ParseBool(r.URL.Query().Get("force")); force {
+		err = inst.SchedPolicyForceDelete(schedName)
+	} else {
+		err = inst.SchedPolicyDelete(schedName)
+	}
 	if err != nil {
+		if _, ok := err.(*sched.PolicyInUseError); ok {
+			c.sendError(c.name, method, w, err.Error(), http.StatusConflict)
+			return
+		}
 		c.sendError(c.name, method, w, err.Error(), http.StatusInternalServerError)
 		return
 	}
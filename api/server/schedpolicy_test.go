@@ -192,6 +192,43 @@ func TestSchedPolicyDeleteFailed(t *testing.T) {
 	assert.Contains(t, err.Error(), "Not Implemented")
 }
 
+func TestSchedPolicyDeleteInUse(t *testing.T) {
+
+	// Create a new global test cluster
+	ts, tc := testClusterServer(t)
+	defer ts.Close()
+	defer tc.Finish()
+
+	name := "testsp1"
+	// mock the cluster schedulePolicy response
+	tc.MockCluster().
+		EXPECT().
+		SchedPolicyDelete(name).
+		Return(&sched.PolicyInUseError{
+			Name: name,
+			References: []sched.Reference{
+				{Type: sched.ReferenceVolume, ID: "vol1"},
+			},
+		})
+	tc.MockCluster().
+		EXPECT().
+		SchedPolicyForceDelete(name).
+		Return(nil)
+
+	// create a cluster client to make the REST call
+	c, err := clusterclient.NewClusterClient(ts.URL, "v1")
+	assert.NoError(t, err)
+
+	// make the REST call
+	restClient := clusterclient.ClusterManager(c)
+	err = restClient.SchedPolicyDelete(name)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "volume vol1")
+
+	err = restClient.SchedPolicyForceDelete(name)
+	assert.NoError(t, err)
+}
+
 func TestSchedPolicyEnumerateSuccess(t *testing.T) {
 
 	// Create a new global test cluster
@@ -0,0 +1,265 @@
+/*
+Package sdk is the gRPC implementation of the SDK gRPC server
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sdk
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/chaos"
+)
+
+const (
+	// chaosPkg is the package of the chaos points of the SDK handlers
+	chaosPkg = "sdk"
+	// chaosServiceName is the name of the chaos service, which has no
+	// chaos points so that chaos can always be disabled
+	chaosServiceName = "openstorage.api.OpenStorageChaos"
+)
+
+var (
+	// chaosPoints are the chaos points of the SDK handlers indexed by full
+	// gRPC method name
+	chaosPoints = struct {
+		sync.RWMutex
+		ids map[string]chaos.ID
+	}{
+		ids: make(map[string]chaos.ID),
+	}
+)
+
+// ChaosServer is an implementation of the gRPC OpenStorageChaos interface
+type ChaosServer struct {
+	server serverAccessor
+}
+
+// canAccessChaos returns true if the caller may use the chaos service
+func (s *ChaosServer) canAccessChaos(ctx context.Context) bool {
+	return hasPrivilege(ctx, s.server.roleManager(), privilegeChaos)
+}
+
+// addChaosPoints adds a chaos point for each method of the services of
+// grpcServer, named after the service and the method, e.g.
+// sdk/OpenStorageVolume.Create
+func addChaosPoints(grpcServer *grpc.Server) {
+	chaosPoints.Lock()
+	defer chaosPoints.Unlock()
+
+	for service, info := range grpcServer.GetServiceInfo() {
+		if service == chaosServiceName {
+			continue
+		}
+		name := strings.TrimPrefix(service, "openstorage.api.")
+		for _, method := range info.Methods {
+			fullMethod := "/" + service + "/" + method.Name
+			chaosPoints.ids[fullMethod] = chaos.Add(
+				chaosPkg,
+				name+"."+method.Name,
+				"SDK call "+fullMethod)
+		}
+	}
+}
+
+// chaosNow triggers the chaos point of method if it is enabled
+func chaosNow(method string) error {
+	chaosPoints.RLock()
+	id, ok := chaosPoints.ids[method]
+	chaosPoints.RUnlock()
+	if !ok {
+		return nil
+	}
+	if err := chaos.Now(id); err != nil {
+		return status.Errorf(codes.Unavailable, "%s: %v", method, err)
+	}
+	return nil
+}
+
+// chaosActions converts the actions of a chaos point
+func chaosActions(what chaos.Action) []api.SdkChaosAction {
+	actions := make([]api.SdkChaosAction, 0, 3)
+	if what&chaos.Error != 0 {
+		actions = append(actions, api.SdkChaosAction_SdkChaosActionError)
+	}
+	if what&chaos.Crash != 0 {
+		actions = append(actions, api.SdkChaosAction_SdkChaosActionPanic)
+	}
+	if what&chaos.Delay != 0 {
+		actions = append(actions, api.SdkChaosAction_SdkChaosActionDelay)
+	}
+	return actions
+}
+
+// chaosWhen converts when a chaos point triggers
+func chaosWhen(when chaos.When) api.SdkChaosWhen {
+	switch when {
+	case chaos.Once:
+		return api.SdkChaosWhen_SdkChaosWhenOnce
+	case chaos.Random:
+		return api.SdkChaosWhen_SdkChaosWhenRandom
+	case chaos.Always:
+		return api.SdkChaosWhen_SdkChaosWhenAlways
+	}
+	return api.SdkChaosWhen_SdkChaosWhenUnknown
+}
+
+// chaosOptions returns the options to enable a chaos point with
+func chaosOptions(req *api.SdkChaosEnableRequest) (chaos.Options, error) {
+	opts := chaos.Options{
+		Probability: req.GetProbability(),
+		Count:       int(req.GetCount()),
+		Delay:       time.Duration(req.GetDelayMs()) * time.Millisecond,
+	}
+	switch req.GetWhen() {
+	case api.SdkChaosWhen_SdkChaosWhenOnce:
+		opts.When = chaos.Once
+	case api.SdkChaosWhen_SdkChaosWhenRandom:
+		opts.When = chaos.Random
+	case api.SdkChaosWhen_SdkChaosWhenAlways:
+		opts.When = chaos.Always
+	default:
+		return opts, status.Errorf(codes.InvalidArgument,
+			"Invalid chaos trigger %v", req.GetWhen())
+	}
+	for _, action := range req.GetActions() {
+		switch action {
+		case api.SdkChaosAction_SdkChaosActionError:
+			opts.What |= chaos.Error
+		case api.SdkChaosAction_SdkChaosActionPanic:
+			opts.What |= chaos.Crash
+		case api.SdkChaosAction_SdkChaosActionDelay:
+			opts.What |= chaos.Delay
+		default:
+			return opts, status.Errorf(codes.InvalidArgument,
+				"Invalid chaos action %v", action)
+		}
+	}
+	return opts, nil
+}
+
+// Enumerate returns the chaos points of the server
+func (s *ChaosServer) Enumerate(
+	ctx context.Context,
+	req *api.SdkChaosEnumerateRequest,
+) (*api.SdkChaosEnumerateResponse, error) {
+	if !s.canAccessChaos(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "Access to chaos points denied")
+	}
+
+	points := chaos.Enumerate(req.GetPkg())
+	resp := &api.SdkChaosEnumerateResponse{
+		Activated: chaos.Activated(),
+		Points:    make([]*api.SdkChaosPoint, 0, len(points)),
+	}
+	for _, c := range points {
+		point := &api.SdkChaosPoint{
+			Id:          c.Name(),
+			Pkg:         c.Pkg,
+			Fn:          c.Fn,
+			Description: c.Desc,
+			Enabled:     c.Enabled,
+		}
+		if c.Enabled {
+			point.Actions = chaosActions(c.What)
+			point.When = chaosWhen(c.When)
+			point.Probability = c.Probability
+			point.Count = int64(c.Count)
+			point.DelayMs = int64(c.Delay / time.Millisecond)
+			point.Calls = int64(c.Calls)
+			point.Triggered = int64(c.Triggered)
+		}
+		resp.Points = append(resp.Points, point)
+	}
+	return resp, nil
+}
+
+// Enable enables a chaos point
+func (s *ChaosServer) Enable(
+	ctx context.Context,
+	req *api.SdkChaosEnableRequest,
+) (*api.SdkChaosEnableResponse, error) {
+	if !s.canAccessChaos(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "Access to chaos points denied")
+	}
+	if len(req.GetPoint()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a chaos point")
+	} else if len(req.GetActions()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply at least one action")
+	}
+	if !chaos.Activated() {
+		return nil, status.Error(codes.FailedPrecondition,
+			"Chaos is not activated on this server")
+	}
+
+	id, err := chaos.Find(req.GetPoint())
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound,
+			"Chaos point %s not found", req.GetPoint())
+	}
+	opts, err := chaosOptions(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := chaos.EnableWithOptions(id, opts); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"Failed to enable chaos point %s: %v", req.GetPoint(), err)
+	}
+	return &api.SdkChaosEnableResponse{}, nil
+}
+
+// Disable disables a chaos point
+func (s *ChaosServer) Disable(
+	ctx context.Context,
+	req *api.SdkChaosDisableRequest,
+) (*api.SdkChaosDisableResponse, error) {
+	if !s.canAccessChaos(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "Access to chaos points denied")
+	}
+	if len(req.GetPoint()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a chaos point")
+	}
+
+	id, err := chaos.Find(req.GetPoint())
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound,
+			"Chaos point %s not found", req.GetPoint())
+	}
+	if err := chaos.Disable(id); err != nil {
+		return nil, status.Errorf(codes.Internal,
+			"Failed to disable chaos point %s: %v", req.GetPoint(), err)
+	}
+	return &api.SdkChaosDisableResponse{}, nil
+}
+
+// DisableAll disables all the chaos points
+func (s *ChaosServer) DisableAll(
+	ctx context.Context,
+	req *api.SdkChaosDisableAllRequest,
+) (*api.SdkChaosDisableAllResponse, error) {
+	if !s.canAccessChaos(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "Access to chaos points denied")
+	}
+
+	chaos.DisableAll()
+	return &api.SdkChaosDisableAllResponse{}, nil
+}
@@ -0,0 +1,136 @@
+/*
+Package sdk is the gRPC implementation of the SDK gRPC server
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sdk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/chaos"
+)
+
+func TestSdkChaosAccess(t *testing.T) {
+	s := &ChaosServer{server: &secretsTestAccessor{}}
+	ctx := contextWithRole("system.view")
+
+	_, err := s.Enumerate(ctx, &api.SdkChaosEnumerateRequest{})
+	assert.Equal(t, codes.PermissionDenied, grpc.Code(err))
+	_, err = s.Enable(ctx, &api.SdkChaosEnableRequest{})
+	assert.Equal(t, codes.PermissionDenied, grpc.Code(err))
+	_, err = s.Disable(ctx, &api.SdkChaosDisableRequest{})
+	assert.Equal(t, codes.PermissionDenied, grpc.Code(err))
+	_, err = s.DisableAll(ctx, &api.SdkChaosDisableAllRequest{})
+	assert.Equal(t, codes.PermissionDenied, grpc.Code(err))
+
+	_, err = s.Enumerate(contextWithRole("system.admin"), &api.SdkChaosEnumerateRequest{})
+	assert.NoError(t, err)
+}
+
+func TestSdkChaosEnable(t *testing.T) {
+	s := &ChaosServer{server: &secretsTestAccessor{}}
+	ctx := context.Background()
+	defer chaos.DisableAll()
+
+	// Add the chaos points of the identity service
+	grpcServer := grpc.NewServer()
+	api.RegisterOpenStorageIdentityServer(grpcServer, &IdentityServer{})
+	api.RegisterOpenStorageChaosServer(grpcServer, s)
+	addChaosPoints(grpcServer)
+
+	resp, err := s.Enumerate(ctx, &api.SdkChaosEnumerateRequest{Pkg: "sdk"})
+	require.NoError(t, err)
+	names := make([]string, 0, len(resp.GetPoints()))
+	for _, p := range resp.GetPoints() {
+		names = append(names, p.GetId())
+	}
+	assert.Contains(t, names, "sdk/OpenStorageIdentity.Version")
+	for _, name := range names {
+		assert.NotContains(t, name, "OpenStorageChaos")
+	}
+
+	req := &api.SdkChaosEnableRequest{
+		Point:   "sdk/OpenStorageIdentity.Version",
+		Actions: []api.SdkChaosAction{api.SdkChaosAction_SdkChaosActionError},
+		When:    api.SdkChaosWhen_SdkChaosWhenAlways,
+		Count:   2,
+	}
+
+	// Chaos must be activated
+	_, err = s.Enable(ctx, req)
+	assert.Equal(t, codes.FailedPrecondition, grpc.Code(err))
+	chaos.Activate(true)
+	defer chaos.Activate(false)
+
+	// Validation
+	_, err = s.Enable(ctx, &api.SdkChaosEnableRequest{Point: req.Point})
+	assert.Equal(t, codes.InvalidArgument, grpc.Code(err))
+	_, err = s.Enable(ctx, &api.SdkChaosEnableRequest{Point: req.Point, Actions: req.Actions})
+	assert.Equal(t, codes.InvalidArgument, grpc.Code(err))
+	_, err = s.Enable(ctx, &api.SdkChaosEnableRequest{
+		Point:       req.Point,
+		Actions:     req.Actions,
+		When:        api.SdkChaosWhen_SdkChaosWhenRandom,
+		Probability: 2,
+	})
+	assert.Equal(t, codes.InvalidArgument, grpc.Code(err))
+	_, err = s.Enable(ctx, &api.SdkChaosEnableRequest{
+		Point:   "sdk/missing",
+		Actions: req.Actions,
+		When:    req.When,
+	})
+	assert.Equal(t, codes.NotFound, grpc.Code(err))
+
+	_, err = s.Enable(ctx, req)
+	assert.NoError(t, err)
+	method := "/openstorage.api.OpenStorageIdentity/Version"
+	for i := 0; i < 2; i++ {
+		err = chaosNow(method)
+		assert.Equal(t, codes.Unavailable, grpc.Code(err))
+	}
+	assert.NoError(t, chaosNow(method))
+	assert.NoError(t, chaosNow("/openstorage.api.OpenStorageChaos/Enumerate"))
+
+	resp, err = s.Enumerate(ctx, &api.SdkChaosEnumerateRequest{Pkg: "sdk"})
+	require.NoError(t, err)
+	assert.True(t, resp.GetActivated())
+	for _, p := range resp.GetPoints() {
+		if p.GetId() == req.Point {
+			assert.False(t, p.GetEnabled())
+		}
+	}
+
+	// Disable
+	_, err = s.Enable(ctx, req)
+	assert.NoError(t, err)
+	_, err = s.Disable(ctx, &api.SdkChaosDisableRequest{Point: req.Point})
+	assert.NoError(t, err)
+	assert.NoError(t, chaosNow(method))
+	_, err = s.Disable(ctx, &api.SdkChaosDisableRequest{Point: "sdk/missing"})
+	assert.Equal(t, codes.NotFound, grpc.Code(err))
+
+	_, err = s.Enable(ctx, req)
+	assert.NoError(t, err)
+	_, err = s.DisableAll(ctx, &api.SdkChaosDisableAllRequest{})
+	assert.NoError(t, err)
+	assert.NoError(t, chaosNow(method))
+}
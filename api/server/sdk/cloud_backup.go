@@ -321,10 +321,19 @@ func (s *CloudBackupServer) SchedCreate(
 	bkpRequest.Schedule = string(sched)
 	bkpRequest.MaxBackups = uint(req.GetCloudSchedInfo().GetMaxBackups())
 	bkpRequest.Full = req.GetCloudSchedInfo().GetFull()
+	bkpRequest.Retention = req.GetCloudSchedInfo().GetRetention().ToCloudBackupRetentionPolicy()
 
 	// Create the backup
 	schedResp, err := s.driver().CloudBackupSchedCreate(&bkpRequest)
-	if err != nil {
+	if err == volume.ErrNotSupported {
+		// The retention policy of the schedule cannot be applied either,
+		// the retention policy of the credential must be used instead
+		return nil, status.Errorf(
+			codes.Unimplemented,
+			"Cloud backup schedules and their retention policies are not supported "+
+				"by this driver, set the retention policy of credential %s instead",
+			bkpRequest.CredentialUUID)
+	} else if err != nil {
 		return nil, status.Errorf(codes.Internal, "Failed to create backup: %v", err)
 	}
 
@@ -374,6 +383,164 @@ func (s *CloudBackupServer) SchedEnumerate(
 	return ToSdkCloudBackupSchedEnumerateResponse(r), nil
 }
 
+// RetentionUpdate sets the retention policy of the backups stored with a
+// credential
+func (s *CloudBackupServer) RetentionUpdate(
+	ctx context.Context,
+	req *api.SdkCloudBackupRetentionUpdateRequest,
+) (*api.SdkCloudBackupRetentionUpdateResponse, error) {
+	if s.driver() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+
+	if len(req.GetCredentialId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must provide credential uuid")
+	}
+
+	err := s.driver().CloudBackupRetentionUpdate(&api.CloudBackupRetentionUpdateRequest{
+		CredentialUUID: req.GetCredentialId(),
+		Retention:      req.GetRetention().ToCloudBackupRetentionPolicy(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to update retention policy: %v", err)
+	}
+
+	return &api.SdkCloudBackupRetentionUpdateResponse{}, nil
+}
+
+// RetentionInspect returns the retention policy of the backups stored with a
+// credential
+func (s *CloudBackupServer) RetentionInspect(
+	ctx context.Context,
+	req *api.SdkCloudBackupRetentionInspectRequest,
+) (*api.SdkCloudBackupRetentionInspectResponse, error) {
+	if s.driver() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+
+	if len(req.GetCredentialId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must provide credential uuid")
+	}
+
+	r, err := s.driver().CloudBackupRetentionInspect(&api.CloudBackupRetentionInspectRequest{
+		CredentialUUID: req.GetCredentialId(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to inspect retention policy: %v", err)
+	}
+
+	return &api.SdkCloudBackupRetentionInspectResponse{
+		Retention: r.Retention.ToSdkCloudBackupRetentionPolicy(),
+	}, nil
+}
+
+// Verify checks that the chunks of a backup are present and intact,
+// optionally restoring it to a temporary volume
+func (s *CloudBackupServer) Verify(
+	ctx context.Context,
+	req *api.SdkCloudBackupVerifyRequest,
+) (*api.SdkCloudBackupVerifyResponse, error) {
+	if s.driver() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+
+	if len(req.GetBackupId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must provide backup id")
+	} else if len(req.GetCredentialId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must provide credential uuid")
+	}
+
+	r, err := s.driver().CloudBackupVerify(&api.CloudBackupVerifyRequest{
+		ID:             req.GetBackupId(),
+		CredentialUUID: req.GetCredentialId(),
+		TestRestore:    req.GetTestRestore(),
+		Name:           req.GetTaskId(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to verify backup: %v", err)
+	}
+
+	return &api.SdkCloudBackupVerifyResponse{
+		TaskId: r.Name,
+	}, nil
+}
+
+// ScrubUpdate sets the schedule at which the backups stored with a
+// credential are verified
+func (s *CloudBackupServer) ScrubUpdate(
+	ctx context.Context,
+	req *api.SdkCloudBackupScrubUpdateRequest,
+) (*api.SdkCloudBackupScrubUpdateResponse, error) {
+	if s.driver() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+
+	if len(req.GetCredentialId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must provide credential uuid")
+	}
+
+	var policy *api.CloudBackupScrubPolicy
+	if req.GetScrub() != nil {
+		if len(req.GetScrub().GetSchedules()) == 0 {
+			return nil, status.Error(codes.InvalidArgument, "Must supply Schedule")
+		}
+		sched, err := sdkSchedToRetainInternalSpecYamlByte(req.GetScrub().GetSchedules())
+		if err != nil {
+			return nil, err
+		}
+		policy = &api.CloudBackupScrubPolicy{
+			Schedule:    string(sched),
+			TestRestore: req.GetScrub().GetTestRestore(),
+		}
+	}
+
+	err := s.driver().CloudBackupScrubUpdate(&api.CloudBackupScrubUpdateRequest{
+		CredentialUUID: req.GetCredentialId(),
+		Scrub:          policy,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to update scrub schedule: %v", err)
+	}
+
+	return &api.SdkCloudBackupScrubUpdateResponse{}, nil
+}
+
+// ScrubInspect returns the schedule at which the backups stored with a
+// credential are verified
+func (s *CloudBackupServer) ScrubInspect(
+	ctx context.Context,
+	req *api.SdkCloudBackupScrubInspectRequest,
+) (*api.SdkCloudBackupScrubInspectResponse, error) {
+	if s.driver() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+
+	if len(req.GetCredentialId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must provide credential uuid")
+	}
+
+	r, err := s.driver().CloudBackupScrubInspect(&api.CloudBackupScrubInspectRequest{
+		CredentialUUID: req.GetCredentialId(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to inspect scrub schedule: %v", err)
+	}
+	if r.Scrub == nil {
+		return &api.SdkCloudBackupScrubInspectResponse{}, nil
+	}
+
+	schedules, err := retainInternalSpecYamlByteToSdkSched([]byte(r.Scrub.Schedule))
+	if err != nil {
+		return nil, err
+	}
+	return &api.SdkCloudBackupScrubInspectResponse{
+		Scrub: &api.SdkCloudBackupScrubPolicy{
+			Schedules:   schedules,
+			TestRestore: r.Scrub.TestRestore,
+		},
+	}, nil
+}
+
 func ToSdkCloudBackupSchedEnumerateResponse(r *api.CloudBackupSchedEnumerateResponse) *api.SdkCloudBackupSchedEnumerateResponse {
 	resp := &api.SdkCloudBackupSchedEnumerateResponse{
 		CloudSchedList: make(map[string]*api.SdkCloudBackupScheduleInfo),
@@ -400,6 +567,7 @@ func ToSdkCloudBackupdScheduleInfo(s api.CloudBackupScheduleInfo) *api.SdkCloudB
 		// converting uint to uint64
 		MaxBackups: uint64(s.MaxBackups),
 		Full:       s.Full,
+		Retention:  s.Retention.ToSdkCloudBackupRetentionPolicy(),
 	}
 	return cloudSched
 }
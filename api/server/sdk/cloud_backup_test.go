@@ -22,8 +22,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/volume"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -737,6 +739,49 @@ func TestSdkCloudBackupSchedCreate(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestSdkCloudBackupSchedCreateNotSupported(t *testing.T) {
+
+	// Create server and client connection
+	s := newTestServer(t)
+	defer s.Stop()
+	req := &api.SdkCloudBackupSchedCreateRequest{
+		CloudSchedInfo: &api.SdkCloudBackupScheduleInfo{
+			SrcVolumeId:  "test-id",
+			CredentialId: "uuid",
+			Schedules: []*api.SdkSchedulePolicyInterval{
+				&api.SdkSchedulePolicyInterval{
+					Retain: 1,
+					PeriodType: &api.SdkSchedulePolicyInterval_Daily{
+						Daily: &api.SdkSchedulePolicyIntervalDaily{
+							Hour:   0,
+							Minute: 30,
+						},
+					},
+				},
+			},
+			Retention: &api.SdkCloudBackupRetentionPolicy{
+				KeepLast: 3,
+			},
+		},
+	}
+
+	s.MockDriver().
+		EXPECT().
+		CloudBackupSchedCreate(gomock.Any()).
+		Return(nil, volume.ErrNotSupported).
+		Times(1)
+
+	// Setup client
+	c := api.NewOpenStorageCloudBackupClient(s.Conn())
+
+	// The retention of the schedule is rejected with the schedule
+	_, err := c.SchedCreate(context.Background(), req)
+	serverError, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.Unimplemented, serverError.Code())
+	assert.Contains(t, serverError.Message(), "retention policy of credential uuid")
+}
+
 func TestSdkCloudBackupSchedCreateBadArguments(t *testing.T) {
 
 	// Create server and client connection
@@ -816,3 +861,194 @@ func TestSdkCloudBackupSchedEnumerate(t *testing.T) {
 		assert.Equal(t, v.GetSchedules()[0].GetDaily().GetMinute(), int32(30))
 	}
 }
+
+func TestSdkCloudBackupRetentionUpdate(t *testing.T) {
+
+	// Create server and client connection
+	s := newTestServer(t)
+	defer s.Stop()
+
+	req := &api.SdkCloudBackupRetentionUpdateRequest{
+		CredentialId: "test-uuid",
+		Retention: &api.SdkCloudBackupRetentionPolicy{
+			KeepDaily:  7,
+			KeepWeekly: 4,
+			HoldLabels: map[string]string{"legal": ""},
+		},
+	}
+
+	// Create response
+	s.MockDriver().
+		EXPECT().
+		CloudBackupRetentionUpdate(&api.CloudBackupRetentionUpdateRequest{
+			CredentialUUID: "test-uuid",
+			Retention: &api.CloudBackupRetentionPolicy{
+				KeepDaily:  7,
+				KeepWeekly: 4,
+				HoldLabels: map[string]string{"legal": ""},
+			},
+		}).
+		Return(nil).
+		Times(1)
+
+	// Setup client
+	c := api.NewOpenStorageCloudBackupClient(s.Conn())
+
+	_, err := c.RetentionUpdate(context.Background(), req)
+	assert.NoError(t, err)
+
+	// Missing credential
+	_, err = c.RetentionUpdate(context.Background(), &api.SdkCloudBackupRetentionUpdateRequest{})
+	assert.Error(t, err)
+	serverError, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, serverError.Code(), codes.InvalidArgument)
+}
+
+func TestSdkCloudBackupRetentionInspect(t *testing.T) {
+
+	// Create server and client connection
+	s := newTestServer(t)
+	defer s.Stop()
+
+	req := &api.SdkCloudBackupRetentionInspectRequest{
+		CredentialId: "test-uuid",
+	}
+
+	// Create response
+	s.MockDriver().
+		EXPECT().
+		CloudBackupRetentionInspect(&api.CloudBackupRetentionInspectRequest{
+			CredentialUUID: "test-uuid",
+		}).
+		Return(&api.CloudBackupRetentionInspectResponse{
+			Retention: &api.CloudBackupRetentionPolicy{
+				KeepLast:   3,
+				ExpireDays: 30,
+			},
+		}, nil).
+		Times(1)
+
+	// Setup client
+	c := api.NewOpenStorageCloudBackupClient(s.Conn())
+
+	r, err := c.RetentionInspect(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), r.GetRetention().GetKeepLast())
+	assert.Equal(t, uint64(30), r.GetRetention().GetExpireDays())
+}
+
+func TestSdkCloudBackupVerify(t *testing.T) {
+
+	// Create server and client connection
+	s := newTestServer(t)
+	defer s.Stop()
+
+	req := &api.SdkCloudBackupVerifyRequest{
+		BackupId:     "backup-id",
+		CredentialId: "test-uuid",
+		TestRestore:  true,
+	}
+
+	// Create response
+	s.MockDriver().
+		EXPECT().
+		CloudBackupVerify(&api.CloudBackupVerifyRequest{
+			ID:             "backup-id",
+			CredentialUUID: "test-uuid",
+			TestRestore:    true,
+		}).
+		Return(&api.CloudBackupVerifyResponse{Name: "verify-task"}, nil).
+		Times(1)
+
+	// Setup client
+	c := api.NewOpenStorageCloudBackupClient(s.Conn())
+
+	r, err := c.Verify(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, "verify-task", r.GetTaskId())
+
+	// Missing credential uuid
+	_, err = c.Verify(context.Background(), &api.SdkCloudBackupVerifyRequest{
+		BackupId: "backup-id",
+	})
+	serverError, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, serverError.Code(), codes.InvalidArgument)
+	assert.Contains(t, serverError.Message(), "credential uuid")
+}
+
+func TestSdkCloudBackupScrubUpdate(t *testing.T) {
+
+	// Create server and client connection
+	s := newTestServer(t)
+	defer s.Stop()
+
+	req := &api.SdkCloudBackupScrubUpdateRequest{
+		CredentialId: "test-uuid",
+		Scrub: &api.SdkCloudBackupScrubPolicy{
+			Schedules: []*api.SdkSchedulePolicyInterval{
+				&api.SdkSchedulePolicyInterval{
+					PeriodType: &api.SdkSchedulePolicyInterval_Daily{
+						Daily: &api.SdkSchedulePolicyIntervalDaily{
+							Hour: 2,
+						},
+					},
+				},
+			},
+			TestRestore: true,
+		},
+	}
+
+	// Create response
+	s.MockDriver().
+		EXPECT().
+		CloudBackupScrubUpdate(&api.CloudBackupScrubUpdateRequest{
+			CredentialUUID: "test-uuid",
+			Scrub: &api.CloudBackupScrubPolicy{
+				Schedule:    "- freq: daily\n  hour: 2\n",
+				TestRestore: true,
+			},
+		}).
+		Return(nil).
+		Times(1)
+
+	// Setup client
+	c := api.NewOpenStorageCloudBackupClient(s.Conn())
+
+	_, err := c.ScrubUpdate(context.Background(), req)
+	assert.NoError(t, err)
+}
+
+func TestSdkCloudBackupScrubInspect(t *testing.T) {
+
+	// Create server and client connection
+	s := newTestServer(t)
+	defer s.Stop()
+
+	req := &api.SdkCloudBackupScrubInspectRequest{
+		CredentialId: "test-uuid",
+	}
+
+	// Create response
+	s.MockDriver().
+		EXPECT().
+		CloudBackupScrubInspect(&api.CloudBackupScrubInspectRequest{
+			CredentialUUID: "test-uuid",
+		}).
+		Return(&api.CloudBackupScrubInspectResponse{
+			Scrub: &api.CloudBackupScrubPolicy{
+				Schedule: "- freq: daily\n  hour: 2\n",
+			},
+		}, nil).
+		Times(1)
+
+	// Setup client
+	c := api.NewOpenStorageCloudBackupClient(s.Conn())
+
+	r, err := c.ScrubInspect(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, r.GetScrub().GetSchedules(), 1)
+	assert.Equal(t, int32(2), r.GetScrub().GetSchedules()[0].GetDaily().GetHour())
+	assert.False(t, r.GetScrub().GetTestRestore())
+}
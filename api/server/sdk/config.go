@@ -0,0 +1,804 @@
+/*
+Package sdk is the gRPC implementation of the SDK gRPC server
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	sdk_auth "github.com/libopenstorage/openstorage-sdk-auth/pkg/auth"
+	"github.com/pborman/uuid"
+	"github.com/portworx/kvdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/cluster"
+	"github.com/libopenstorage/openstorage/osdconfig"
+	"github.com/libopenstorage/openstorage/pkg/proto/time"
+)
+
+const (
+	// configWatchBuffer is the number of changes buffered for a watcher
+	configWatchBuffer = 16
+)
+
+// ConfigServer is an implementation of the gRPC OpenStorageConfig interface
+type ConfigServer struct {
+	server serverAccessor
+}
+
+func (s *ConfigServer) cluster() cluster.Cluster {
+	return s.server.cluster()
+}
+
+// canAccessSecrets returns true if the caller may see and change the
+// secrets in the configuration
+func (s *ConfigServer) canAccessSecrets(ctx context.Context) bool {
+	return hasPrivilege(ctx, s.server.roleManager(), privilegeConfigSecrets)
+}
+
+// configChangeInfo describes a change of the configuration by the caller
+func configChangeInfo(ctx context.Context, reason string) *osdconfig.ChangeInfo {
+	info := &osdconfig.ChangeInfo{
+		Reason: reason,
+	}
+	if claims, ok := ctx.Value(InterceptorContextTokenKey).(*sdk_auth.Claims); ok {
+		info.Author = claims.Name
+		if len(claims.Email) != 0 {
+			info.Author += " <" + claims.Email + ">"
+		}
+	}
+	return info
+}
+
+// configError converts an error of osdconfig to a gRPC status
+func configError(err error, format string, args ...interface{}) error {
+	if _, ok := status.FromError(err); ok {
+		// The errors of the update mask are already reported
+		return err
+	}
+	msg := fmt.Sprintf(format, args...)
+	if _, ok := err.(*osdconfig.ValidationError); ok {
+		return status.Errorf(codes.InvalidArgument, "%s: %v", msg, err)
+	}
+	switch err {
+	case kvdb.ErrNotFound, osdconfig.ErrRevisionNotFound:
+		return status.Errorf(codes.NotFound, "%s: %v", msg, err)
+	case osdconfig.ErrNotImplemented:
+		return status.Errorf(codes.Unimplemented, "%s: %v", msg, err)
+	case osdconfig.ErrConfigConflict:
+		return status.Errorf(codes.Aborted, "%s: %v", msg, err)
+	}
+	return status.Errorf(codes.Internal, "%s: %v", msg, err)
+}
+
+// InspectCluster returns the configuration of the cluster
+func (s *ConfigServer) InspectCluster(
+	ctx context.Context,
+	req *api.SdkConfigInspectClusterRequest,
+) (*api.SdkConfigInspectClusterResponse, error) {
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+
+	config, err := s.cluster().GetClusterConf()
+	if err != nil {
+		return nil, configError(err, "Failed to get cluster configuration")
+	}
+	if !s.canAccessSecrets(ctx) {
+		config = redactClusterConfig(config)
+	}
+	return &api.SdkConfigInspectClusterResponse{
+		Config: clusterConfigToSdk(config),
+	}, nil
+}
+
+// UpdateCluster updates the fields of the configuration of the cluster
+// listed in the update mask
+func (s *ConfigServer) UpdateCluster(
+	ctx context.Context,
+	req *api.SdkConfigUpdateClusterRequest,
+) (*api.SdkConfigUpdateClusterResponse, error) {
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	} else if req.GetConfig() == nil {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a configuration")
+	}
+
+	// The mask is applied to the latest configuration, again if another
+	// node changes it concurrently.
+	var config *osdconfig.ClusterConfig
+	err := s.cluster().UpdateClusterConfWithInfo(func(current *osdconfig.ClusterConfig) (*osdconfig.ClusterConfig, error) {
+		if current == nil {
+			current = &osdconfig.ClusterConfig{}
+		}
+		updated := clusterConfigToSdk(current)
+		if err := applyUpdateMask(updated, req.GetConfig(), req.GetUpdateMask()); err != nil {
+			return nil, err
+		}
+
+		config = clusterConfigFromSdk(updated)
+		config.Private = current.Private
+		if !s.canAccessSecrets(ctx) {
+			// Only the admin can change secrets, which the other roles
+			// cannot read either.
+			osdconfig.KeepHidden(config, current)
+		}
+		return config, nil
+	}, configChangeInfo(ctx, req.GetReason()))
+	if err != nil {
+		return nil, configError(err, "Failed to update cluster configuration")
+	}
+	if !s.canAccessSecrets(ctx) {
+		config = redactClusterConfig(config)
+	}
+	return &api.SdkConfigUpdateClusterResponse{
+		Config: clusterConfigToSdk(config),
+	}, nil
+}
+
+// InspectNode returns the configuration of a node
+func (s *ConfigServer) InspectNode(
+	ctx context.Context,
+	req *api.SdkConfigInspectNodeRequest,
+) (*api.SdkConfigInspectNodeResponse, error) {
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	} else if len(req.GetNodeId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a node id")
+	}
+
+	config, err := s.cluster().GetNodeConf(req.GetNodeId())
+	if err != nil {
+		return nil, configError(err, "Failed to get configuration of node %s", req.GetNodeId())
+	}
+	return &api.SdkConfigInspectNodeResponse{
+		Config: nodeConfigToSdk(config),
+	}, nil
+}
+
+// EnumerateNodes returns the configuration of all the nodes
+func (s *ConfigServer) EnumerateNodes(
+	ctx context.Context,
+	req *api.SdkConfigEnumerateNodesRequest,
+) (*api.SdkConfigEnumerateNodesResponse, error) {
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+
+	configs, err := s.cluster().EnumerateNodeConf()
+	if err != nil {
+		return nil, configError(err, "Failed to enumerate node configurations")
+	}
+	resp := &api.SdkConfigEnumerateNodesResponse{
+		Configs: make([]*api.SdkNodeConfig, 0, len(*configs)),
+	}
+	for _, config := range *configs {
+		resp.Configs = append(resp.Configs, nodeConfigToSdk(config))
+	}
+	return resp, nil
+}
+
+// UpdateNode updates the fields of the configuration of a node listed in
+// the update mask
+func (s *ConfigServer) UpdateNode(
+	ctx context.Context,
+	req *api.SdkConfigUpdateNodeRequest,
+) (*api.SdkConfigUpdateNodeResponse, error) {
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	} else if len(req.GetNodeId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a node id")
+	} else if req.GetConfig() == nil {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a configuration")
+	} else if len(req.GetConfig().GetNodeId()) != 0 && req.GetConfig().GetNodeId() != req.GetNodeId() {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"Node id %s of the configuration does not match node id %s",
+			req.GetConfig().GetNodeId(), req.GetNodeId())
+	}
+
+	// The mask is applied to the latest configuration, again if another
+	// node changes it concurrently.
+	var updated *api.SdkNodeConfig
+	err := s.cluster().UpdateNodeConfWithInfo(req.GetNodeId(), func(current *osdconfig.NodeConfig) (*osdconfig.NodeConfig, error) {
+		if current == nil {
+			current = &osdconfig.NodeConfig{}
+		}
+		updated = nodeConfigToSdk(current)
+		if err := applyUpdateMask(updated, req.GetConfig(), req.GetUpdateMask()); err != nil {
+			return nil, err
+		}
+		updated.NodeId = req.GetNodeId()
+
+		config := nodeConfigFromSdk(updated)
+		config.Private = current.Private
+		return config, nil
+	}, configChangeInfo(ctx, req.GetReason()))
+	if err != nil {
+		return nil, configError(err, "Failed to update configuration of node %s", req.GetNodeId())
+	}
+	return &api.SdkConfigUpdateNodeResponse{
+		Config: updated,
+	}, nil
+}
+
+// DeleteNode removes the configuration of a node
+func (s *ConfigServer) DeleteNode(
+	ctx context.Context,
+	req *api.SdkConfigDeleteNodeRequest,
+) (*api.SdkConfigDeleteNodeResponse, error) {
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	} else if len(req.GetNodeId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a node id")
+	}
+
+	if err := s.cluster().DeleteNodeConf(req.GetNodeId()); err != nil && err != kvdb.ErrNotFound {
+		return nil, configError(err, "Failed to delete configuration of node %s", req.GetNodeId())
+	}
+	return &api.SdkConfigDeleteNodeResponse{}, nil
+}
+
+// EnumerateClusterRevisions returns the revisions of the configuration of
+// the cluster
+func (s *ConfigServer) EnumerateClusterRevisions(
+	ctx context.Context,
+	req *api.SdkConfigEnumerateClusterRevisionsRequest,
+) (*api.SdkConfigEnumerateClusterRevisionsResponse, error) {
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+
+	revisions, err := s.cluster().EnumerateClusterConfRevisions()
+	if err != nil {
+		return nil, configError(err, "Failed to enumerate cluster configuration revisions")
+	}
+	redact := !s.canAccessSecrets(ctx)
+	resp := &api.SdkConfigEnumerateClusterRevisionsResponse{
+		Revisions: make([]*api.SdkConfigRevision, 0, len(revisions)),
+	}
+	for _, revision := range revisions {
+		r := revisionToSdk(revision)
+		if redact && revision.Cluster != nil {
+			r.Cluster = clusterConfigToSdk(redactClusterConfig(revision.Cluster))
+		}
+		resp.Revisions = append(resp.Revisions, r)
+	}
+	return resp, nil
+}
+
+// EnumerateNodeRevisions returns the revisions of the configuration of a
+// node
+func (s *ConfigServer) EnumerateNodeRevisions(
+	ctx context.Context,
+	req *api.SdkConfigEnumerateNodeRevisionsRequest,
+) (*api.SdkConfigEnumerateNodeRevisionsResponse, error) {
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	} else if len(req.GetNodeId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a node id")
+	}
+
+	revisions, err := s.cluster().EnumerateNodeConfRevisions(req.GetNodeId())
+	if err != nil {
+		return nil, configError(err, "Failed to enumerate configuration revisions of node %s", req.GetNodeId())
+	}
+	resp := &api.SdkConfigEnumerateNodeRevisionsResponse{
+		Revisions: make([]*api.SdkConfigRevision, 0, len(revisions)),
+	}
+	for _, revision := range revisions {
+		resp.Revisions = append(resp.Revisions, revisionToSdk(revision))
+	}
+	return resp, nil
+}
+
+// DiffCluster returns the differences between two revisions of the
+// configuration of the cluster
+func (s *ConfigServer) DiffCluster(
+	ctx context.Context,
+	req *api.SdkConfigDiffClusterRequest,
+) (*api.SdkConfigDiffClusterResponse, error) {
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	} else if req.GetFromVersion() == 0 || req.GetToVersion() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply the versions to compare")
+	}
+
+	diffs, err := s.cluster().DiffClusterConf(req.GetFromVersion(), req.GetToVersion())
+	if err != nil {
+		return nil, configError(err, "Failed to compare cluster configuration revisions")
+	}
+	return &api.SdkConfigDiffClusterResponse{
+		Differences: differencesToSdk(diffs, !s.canAccessSecrets(ctx)),
+	}, nil
+}
+
+// DiffNode returns the differences between two revisions of the
+// configuration of a node
+func (s *ConfigServer) DiffNode(
+	ctx context.Context,
+	req *api.SdkConfigDiffNodeRequest,
+) (*api.SdkConfigDiffNodeResponse, error) {
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	} else if len(req.GetNodeId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a node id")
+	} else if req.GetFromVersion() == 0 || req.GetToVersion() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply the versions to compare")
+	}
+
+	diffs, err := s.cluster().DiffNodeConf(req.GetNodeId(), req.GetFromVersion(), req.GetToVersion())
+	if err != nil {
+		return nil, configError(err, "Failed to compare configuration revisions of node %s", req.GetNodeId())
+	}
+	return &api.SdkConfigDiffNodeResponse{
+		Differences: differencesToSdk(diffs, !s.canAccessSecrets(ctx)),
+	}, nil
+}
+
+// RollbackCluster sets the configuration of the cluster of a previous
+// revision
+func (s *ConfigServer) RollbackCluster(
+	ctx context.Context,
+	req *api.SdkConfigRollbackClusterRequest,
+) (*api.SdkConfigRollbackClusterResponse, error) {
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	} else if req.GetVersion() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a version")
+	}
+
+	if !s.canAccessSecrets(ctx) {
+		// Only the admin can change secrets
+		if err := s.checkRollbackSecrets(req.GetVersion()); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.cluster().RollbackClusterConf(req.GetVersion(), configChangeInfo(ctx, req.GetReason())); err != nil {
+		return nil, configError(err, "Failed to roll back cluster configuration to version %d", req.GetVersion())
+	}
+	return &api.SdkConfigRollbackClusterResponse{}, nil
+}
+
+// checkRollbackSecrets returns an error if rolling back the configuration
+// of the cluster to version would change its secrets
+func (s *ConfigServer) checkRollbackSecrets(version uint64) error {
+	revisions, err := s.cluster().EnumerateClusterConfRevisions()
+	if err != nil {
+		return configError(err, "Failed to enumerate cluster configuration revisions")
+	}
+	var target *osdconfig.ClusterConfig
+	for _, revision := range revisions {
+		if revision.Version == version {
+			target = revision.Cluster
+		}
+	}
+	if target == nil {
+		return configError(osdconfig.ErrRevisionNotFound, "Failed to roll back cluster configuration to version %d", version)
+	}
+	current, err := s.cluster().GetClusterConf()
+	if err != nil && err != kvdb.ErrNotFound {
+		return configError(err, "Failed to get cluster configuration")
+	}
+	for _, diff := range osdconfig.Diff(current, target) {
+		if diff.Hidden {
+			return status.Errorf(codes.PermissionDenied,
+				"Only the roles with access to the secrets can roll back changes of %s", diff.Path)
+		}
+	}
+	return nil
+}
+
+// RollbackNode sets the configuration of a node of a previous revision
+func (s *ConfigServer) RollbackNode(
+	ctx context.Context,
+	req *api.SdkConfigRollbackNodeRequest,
+) (*api.SdkConfigRollbackNodeResponse, error) {
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	} else if len(req.GetNodeId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a node id")
+	} else if req.GetVersion() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a version")
+	}
+
+	if err := s.cluster().RollbackNodeConf(
+		req.GetNodeId(),
+		req.GetVersion(),
+		configChangeInfo(ctx, req.GetReason()),
+	); err != nil {
+		return nil, configError(err, "Failed to roll back configuration of node %s to version %d",
+			req.GetNodeId(), req.GetVersion())
+	}
+	return &api.SdkConfigRollbackNodeResponse{}, nil
+}
+
+// Watch streams the changes of the configuration until the client cancels
+// the call
+func (s *ConfigServer) Watch(
+	req *api.SdkConfigWatchRequest,
+	stream api.OpenStorageConfig_WatchServer,
+) error {
+	if s.cluster() == nil {
+		return status.Error(codes.Unavailable, "Resource has not been initialized")
+	} else if !req.GetCluster() && !req.GetNodes() && len(req.GetNodeId()) == 0 {
+		return status.Error(codes.InvalidArgument, "Must supply cluster, nodes or a node id to watch")
+	}
+
+	ctx := stream.Context()
+	changes := make(chan *api.SdkConfigWatchResponse, configWatchBuffer)
+	// The callbacks run in the osdconfig watch loop, which must not wait for
+	// a slow client. The stream ends once the client falls behind by more
+	// than the buffered changes.
+	overflow := make(chan struct{})
+	var overflowOnce sync.Once
+	notify := func(resp *api.SdkConfigWatchResponse) {
+		select {
+		case changes <- resp:
+		default:
+			overflowOnce.Do(func() { close(overflow) })
+		}
+	}
+
+	name := "sdk-watch-" + uuid.New()
+	redact := !s.canAccessSecrets(ctx)
+	if req.GetCluster() {
+		err := s.cluster().WatchCluster(name, func(config *osdconfig.ClusterConfig) error {
+			if redact {
+				config = redactClusterConfig(config)
+			}
+			notify(&api.SdkConfigWatchResponse{Cluster: clusterConfigToSdk(config)})
+			return nil
+		})
+		if err != nil {
+			return configError(err, "Failed to watch cluster configuration")
+		}
+		defer s.cluster().UnwatchCluster(name)
+	}
+	if req.GetNodes() || len(req.GetNodeId()) != 0 {
+		err := s.cluster().WatchNode(name, func(config *osdconfig.NodeConfig) error {
+			if len(req.GetNodeId()) != 0 && config.NodeId != req.GetNodeId() {
+				return nil
+			}
+			notify(&api.SdkConfigWatchResponse{Node: nodeConfigToSdk(config)})
+			return nil
+		})
+		if err != nil {
+			return configError(err, "Failed to watch node configuration")
+		}
+		defer s.cluster().UnwatchNode(name)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-overflow:
+			return status.Errorf(codes.ResourceExhausted,
+				"Watch client fell behind by more than %d configuration changes", configWatchBuffer)
+		case resp := <-changes:
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// redactClusterConfig returns a copy of a cluster configuration without
+// the fields tagged as hidden
+func redactClusterConfig(config *osdconfig.ClusterConfig) *osdconfig.ClusterConfig {
+	return osdconfig.Redact(config).(*osdconfig.ClusterConfig)
+}
+
+// applyUpdateMask copies the fields of src listed in paths to dst. Paths
+// are the proto names of the fields separated by dots, such as
+// secrets.vault.address. All the fields are copied if paths is empty.
+func applyUpdateMask(dst, src proto.Message, paths []string) error {
+	if len(paths) == 0 {
+		dst.Reset()
+		proto.Merge(dst, src)
+		return nil
+	}
+	for _, path := range paths {
+		if err := applyUpdatePath(
+			reflect.ValueOf(dst).Elem(),
+			reflect.ValueOf(src).Elem(),
+			strings.Split(path, "."),
+		); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid update mask path %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// applyUpdatePath copies the field at path of the src struct to dst. A
+// missing message in src is handled as an empty one.
+func applyUpdatePath(dst, src reflect.Value, path []string) error {
+	field, ok := protoFieldByName(dst.Type(), path[0])
+	if !ok {
+		return fmt.Errorf("unknown field %s", path[0])
+	}
+	dstField := dst.FieldByIndex(field.Index)
+	var srcField reflect.Value
+	if src.IsValid() {
+		srcField = src.FieldByIndex(field.Index)
+	}
+
+	if len(path) == 1 {
+		if !srcField.IsValid() {
+			dstField.Set(reflect.Zero(dstField.Type()))
+		} else if msg, ok := srcField.Interface().(proto.Message); ok && !srcField.IsNil() {
+			dstField.Set(reflect.ValueOf(proto.Clone(msg)))
+		} else {
+			dstField.Set(srcField)
+		}
+		return nil
+	}
+
+	if dstField.Kind() != reflect.Ptr || dstField.Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("field %s has no fields", path[0])
+	}
+	var next reflect.Value
+	if srcField.IsValid() && !srcField.IsNil() {
+		next = srcField.Elem()
+	}
+	if dstField.IsNil() {
+		if !next.IsValid() {
+			// Nothing to clear, but the rest of the path must be valid
+			return applyUpdatePath(reflect.New(dstField.Type().Elem()).Elem(), next, path[1:])
+		}
+		dstField.Set(reflect.New(dstField.Type().Elem()))
+	}
+	return applyUpdatePath(dstField.Elem(), next, path[1:])
+}
+
+// protoFieldByName returns the field of a generated message struct with
+// the given proto name
+func protoFieldByName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		for _, opt := range strings.Split(field.Tag.Get("protobuf"), ",") {
+			if opt == "name="+name {
+				return field, true
+			}
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func clusterConfigToSdk(config *osdconfig.ClusterConfig) *api.SdkClusterConfig {
+	resp := &api.SdkClusterConfig{
+		Description: config.Description,
+		Mode:        config.Mode,
+		Version:     config.Version,
+		ClusterId:   config.ClusterId,
+		Domain:      config.Domain,
+	}
+	if !config.Created.IsZero() {
+		resp.Created = prototime.TimeToTimestamp(config.Created)
+	}
+	if config.Secrets != nil {
+		resp.Secrets = &api.SdkSecretsConfig{
+			SecretType:       config.Secrets.SecretType,
+			ClusterSecretKey: config.Secrets.ClusterSecretKey,
+		}
+		if vault := config.Secrets.Vault; vault != nil {
+			resp.Secrets.Vault = &api.SdkVaultConfig{
+				Token:         vault.Token,
+				Address:       vault.Address,
+				CaCert:        vault.CACert,
+				CaPath:        vault.CAPath,
+				ClientCert:    vault.ClientCert,
+				ClientKey:     vault.ClientKey,
+				TlsSkipVerify: vault.TLSSkipVerify,
+				TlsServerName: vault.TLSServerName,
+				BasePath:      vault.BasePath,
+				BackendPath:   vault.BackendPath,
+			}
+		}
+		if aws := config.Secrets.Aws; aws != nil {
+			resp.Secrets.Aws = &api.SdkAwsConfig{
+				AccessKeyId:     aws.AccessKeyId,
+				SecretAccessKey: aws.SecretAccessKey,
+				SecretTokenKey:  aws.SecretTokenKey,
+				Cmk:             aws.Cmk,
+				Region:          aws.Region,
+			}
+		}
+	}
+	if kv := config.Kvdb; kv != nil {
+		resp.Kvdb = &api.SdkKvdbConfig{
+			Name:               kv.Name,
+			Username:           kv.Username,
+			Password:           kv.Password,
+			CaFile:             kv.CAFile,
+			CertFile:           kv.CertFile,
+			CertKeyFile:        kv.CertKeyFile,
+			TrustedCaFile:      kv.TrustedCAFile,
+			ClientCertAuth:     kv.ClientCertAuth,
+			AclToken:           kv.AclToken,
+			CaAuthAddress:      kv.CAAuthAddress,
+			InsecureSkipVerify: kv.InsecureSkipVerify,
+			TransportScheme:    kv.TransportScheme,
+			Discovery:          kv.Discovery,
+		}
+	}
+	return resp
+}
+
+func clusterConfigFromSdk(config *api.SdkClusterConfig) *osdconfig.ClusterConfig {
+	resp := &osdconfig.ClusterConfig{
+		Description: config.GetDescription(),
+		Mode:        config.GetMode(),
+		Version:     config.GetVersion(),
+		ClusterId:   config.GetClusterId(),
+		Domain:      config.GetDomain(),
+	}
+	if config.GetCreated() != nil {
+		resp.Created = prototime.TimestampToTime(config.GetCreated())
+	}
+	if secrets := config.GetSecrets(); secrets != nil {
+		resp.Secrets = &osdconfig.SecretsConfig{
+			SecretType:       secrets.GetSecretType(),
+			ClusterSecretKey: secrets.GetClusterSecretKey(),
+		}
+		if vault := secrets.GetVault(); vault != nil {
+			resp.Secrets.Vault = &osdconfig.VaultConfig{
+				Token:         vault.GetToken(),
+				Address:       vault.GetAddress(),
+				CACert:        vault.GetCaCert(),
+				CAPath:        vault.GetCaPath(),
+				ClientCert:    vault.GetClientCert(),
+				ClientKey:     vault.GetClientKey(),
+				TLSSkipVerify: vault.GetTlsSkipVerify(),
+				TLSServerName: vault.GetTlsServerName(),
+				BasePath:      vault.GetBasePath(),
+				BackendPath:   vault.GetBackendPath(),
+			}
+		}
+		if aws := secrets.GetAws(); aws != nil {
+			resp.Secrets.Aws = &osdconfig.AWSConfig{
+				AccessKeyId:     aws.GetAccessKeyId(),
+				SecretAccessKey: aws.GetSecretAccessKey(),
+				SecretTokenKey:  aws.GetSecretTokenKey(),
+				Cmk:             aws.GetCmk(),
+				Region:          aws.GetRegion(),
+			}
+		}
+	}
+	if kv := config.GetKvdb(); kv != nil {
+		resp.Kvdb = &osdconfig.KvdbConfig{
+			Name:               kv.GetName(),
+			Username:           kv.GetUsername(),
+			Password:           kv.GetPassword(),
+			CAFile:             kv.GetCaFile(),
+			CertFile:           kv.GetCertFile(),
+			CertKeyFile:        kv.GetCertKeyFile(),
+			TrustedCAFile:      kv.GetTrustedCaFile(),
+			ClientCertAuth:     kv.GetClientCertAuth(),
+			AclToken:           kv.GetAclToken(),
+			CAAuthAddress:      kv.GetCaAuthAddress(),
+			InsecureSkipVerify: kv.GetInsecureSkipVerify(),
+			TransportScheme:    kv.GetTransportScheme(),
+			Discovery:          kv.GetDiscovery(),
+		}
+	}
+	return resp
+}
+
+func nodeConfigToSdk(config *osdconfig.NodeConfig) *api.SdkNodeConfig {
+	resp := &api.SdkNodeConfig{
+		NodeId:      config.NodeId,
+		CsiEndpoint: config.CSIEndpoint,
+	}
+	if network := config.Network; network != nil {
+		resp.Network = &api.SdkNetworkConfig{
+			MgtIface:  network.MgtIface,
+			DataIface: network.DataIface,
+		}
+	}
+	if storage := config.Storage; storage != nil {
+		resp.Storage = &api.SdkStorageConfig{
+			DevicesMd:        storage.DevicesMd,
+			Devices:          storage.Devices,
+			MaxCount:         storage.MaxCount,
+			MaxDriveSetCount: storage.MaxDriveSetCount,
+			RaidLevel:        storage.RaidLevel,
+			RaidLevelMd:      storage.RaidLevelMd,
+		}
+	}
+	if geo := config.Geo; geo != nil {
+		resp.Geo = &api.SdkGeoConfig{
+			Rack:   geo.Rack,
+			Zone:   geo.Zone,
+			Region: geo.Region,
+		}
+	}
+	return resp
+}
+
+func nodeConfigFromSdk(config *api.SdkNodeConfig) *osdconfig.NodeConfig {
+	resp := &osdconfig.NodeConfig{
+		NodeId:      config.GetNodeId(),
+		CSIEndpoint: config.GetCsiEndpoint(),
+	}
+	if network := config.GetNetwork(); network != nil {
+		resp.Network = &osdconfig.NetworkConfig{
+			MgtIface:  network.GetMgtIface(),
+			DataIface: network.GetDataIface(),
+		}
+	}
+	if storage := config.GetStorage(); storage != nil {
+		resp.Storage = &osdconfig.StorageConfig{
+			DevicesMd:        storage.GetDevicesMd(),
+			Devices:          storage.GetDevices(),
+			MaxCount:         storage.GetMaxCount(),
+			MaxDriveSetCount: storage.GetMaxDriveSetCount(),
+			RaidLevel:        storage.GetRaidLevel(),
+			RaidLevelMd:      storage.GetRaidLevelMd(),
+		}
+	}
+	if geo := config.GetGeo(); geo != nil {
+		resp.Geo = &osdconfig.GeoConfig{
+			Rack:   geo.GetRack(),
+			Zone:   geo.GetZone(),
+			Region: geo.GetRegion(),
+		}
+	}
+	return resp
+}
+
+func revisionToSdk(revision *osdconfig.Revision) *api.SdkConfigRevision {
+	resp := &api.SdkConfigRevision{
+		Version:   revision.Version,
+		Author:    revision.Author,
+		Timestamp: prototime.TimeToTimestamp(revision.Timestamp),
+		Reason:    revision.Reason,
+	}
+	if revision.Cluster != nil {
+		resp.Cluster = clusterConfigToSdk(revision.Cluster)
+	}
+	if revision.Node != nil {
+		resp.Node = nodeConfigToSdk(revision.Node)
+	}
+	return resp
+}
+
+// differencesToSdk converts differences, clearing the values of the hidden
+// fields if redact is set
+func differencesToSdk(diffs []*osdconfig.Difference, redact bool) []*api.SdkConfigDifference {
+	resp := make([]*api.SdkConfigDifference, 0, len(diffs))
+	for _, diff := range diffs {
+		d := &api.SdkConfigDifference{
+			Path:     diff.Path,
+			OldValue: diff.Old,
+			NewValue: diff.New,
+			Hidden:   diff.Hidden,
+		}
+		if redact && diff.Hidden {
+			d.OldValue = ""
+			d.NewValue = ""
+		}
+		resp = append(resp, d)
+	}
+	return resp
+}
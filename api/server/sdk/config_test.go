@@ -0,0 +1,532 @@
+/*
+Package sdk is the gRPC implementation of the SDK gRPC server
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kubernetes-csi/csi-test/utils"
+	sdk_auth "github.com/libopenstorage/openstorage-sdk-auth/pkg/auth"
+	"github.com/portworx/kvdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/libopenstorage/openstorage/api"
+	mockcluster "github.com/libopenstorage/openstorage/cluster/mock"
+	"github.com/libopenstorage/openstorage/osdconfig"
+)
+
+func newTestClusterConfig() *osdconfig.ClusterConfig {
+	return &osdconfig.ClusterConfig{
+		Description: "cluster",
+		ClusterId:   "clusterid",
+		Created:     time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+		Secrets: &osdconfig.SecretsConfig{
+			SecretType:       "vault",
+			ClusterSecretKey: "clusterkey",
+			Vault: &osdconfig.VaultConfig{
+				Token:   "vaulttoken",
+				Address: "http://127.0.0.1:8200",
+			},
+		},
+		Kvdb: &osdconfig.KvdbConfig{
+			Username:  "user",
+			Password:  "kvdbpassword",
+			Discovery: []string{"http://127.0.0.1:2379"},
+		},
+		Private: "private",
+	}
+}
+
+func contextWithRole(role string) context.Context {
+	return context.WithValue(context.Background(), InterceptorContextTokenKey, &sdk_auth.Claims{
+		Name: "test",
+		Role: role,
+	})
+}
+
+// configTestCluster updates the configurations with the GetClusterConf,
+// GetNodeConf, SetClusterConfWithInfo and SetNodeConfWithInfo of the mock
+type configTestCluster struct {
+	*mockcluster.MockCluster
+}
+
+func (c *configTestCluster) UpdateClusterConfWithInfo(
+	update func(*osdconfig.ClusterConfig) (*osdconfig.ClusterConfig, error),
+	info *osdconfig.ChangeInfo,
+) error {
+	current, err := c.GetClusterConf()
+	if err == kvdb.ErrNotFound {
+		current = nil
+	} else if err != nil {
+		return err
+	}
+	config, err := update(current)
+	if err != nil {
+		return err
+	}
+	return c.SetClusterConfWithInfo(config, info)
+}
+
+func (c *configTestCluster) UpdateNodeConfWithInfo(
+	nodeID string,
+	update func(*osdconfig.NodeConfig) (*osdconfig.NodeConfig, error),
+	info *osdconfig.ChangeInfo,
+) error {
+	current, err := c.GetNodeConf(nodeID)
+	if err == kvdb.ErrNotFound {
+		current = nil
+	} else if err != nil {
+		return err
+	}
+	config, err := update(current)
+	if err != nil {
+		return err
+	}
+	return c.SetNodeConfWithInfo(config, info)
+}
+
+func newTestConfigServer(t *testing.T) (*ConfigServer, *mockcluster.MockCluster, *gomock.Controller) {
+	mc := gomock.NewController(&utils.SafeGoroutineTester{})
+	c := mockcluster.NewMockCluster(mc)
+	return &ConfigServer{server: &secretsTestAccessor{c: &configTestCluster{MockCluster: c}}}, c, mc
+}
+
+func TestSdkConfigInspectCluster(t *testing.T) {
+	s, c, mc := newTestConfigServer(t)
+	defer mc.Finish()
+	c.EXPECT().
+		GetClusterConf().
+		Return(newTestClusterConfig(), nil).
+		Times(3)
+
+	// Without authentication and for the admin the secrets are returned
+	for _, ctx := range []context.Context{context.Background(), contextWithRole("system.admin")} {
+		r, err := s.InspectCluster(ctx, &api.SdkConfigInspectClusterRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, "cluster", r.GetConfig().GetDescription())
+		assert.Equal(t, int64(1514764800), r.GetConfig().GetCreated().GetSeconds())
+		assert.Equal(t, "vaulttoken", r.GetConfig().GetSecrets().GetVault().GetToken())
+		assert.Equal(t, "clusterkey", r.GetConfig().GetSecrets().GetClusterSecretKey())
+		assert.Equal(t, "kvdbpassword", r.GetConfig().GetKvdb().GetPassword())
+	}
+
+	r, err := s.InspectCluster(contextWithRole("system.view"), &api.SdkConfigInspectClusterRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:8200", r.GetConfig().GetSecrets().GetVault().GetAddress())
+	assert.Equal(t, "user", r.GetConfig().GetKvdb().GetUsername())
+	assert.Empty(t, r.GetConfig().GetSecrets().GetVault().GetToken())
+	assert.Empty(t, r.GetConfig().GetSecrets().GetClusterSecretKey())
+	assert.Empty(t, r.GetConfig().GetKvdb().GetPassword())
+	// Redaction does not add the missing sections
+	assert.Nil(t, r.GetConfig().GetSecrets().GetAws())
+}
+
+func TestSdkConfigUpdateCluster(t *testing.T) {
+	s, c, mc := newTestConfigServer(t)
+	defer mc.Finish()
+
+	c.EXPECT().
+		GetClusterConf().
+		Return(newTestClusterConfig(), nil).
+		AnyTimes()
+
+	// Only the fields of the mask are updated
+	expected := newTestClusterConfig()
+	expected.Description = "updated"
+	expected.Secrets.Vault.Address = "http://vault:8200"
+	expected.Kvdb = nil
+	c.EXPECT().
+		SetClusterConfWithInfo(expected, gomock.Any()).
+		Return(nil)
+	r, err := s.UpdateCluster(context.Background(), &api.SdkConfigUpdateClusterRequest{
+		Config: &api.SdkClusterConfig{
+			Description: "updated",
+			Domain:      "ignored",
+			Secrets: &api.SdkSecretsConfig{
+				Vault: &api.SdkVaultConfig{
+					Address: "http://vault:8200",
+				},
+			},
+		},
+		UpdateMask: []string{"description", "secrets.vault.address", "kvdb"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "updated", r.GetConfig().GetDescription())
+	assert.Empty(t, r.GetConfig().GetDomain())
+	assert.Equal(t, "vaulttoken", r.GetConfig().GetSecrets().GetVault().GetToken())
+
+	// Unknown paths are rejected
+	for _, path := range []string{"unknown", "secrets.vault.address.more", "kvdb.unknown"} {
+		_, err = s.UpdateCluster(context.Background(), &api.SdkConfigUpdateClusterRequest{
+			Config:     &api.SdkClusterConfig{},
+			UpdateMask: []string{path},
+		})
+		assert.Error(t, err, path)
+		serverError, ok := status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, serverError.Code())
+	}
+
+	// Roles other than the admin cannot change secrets
+	expected = newTestClusterConfig()
+	expected.Description = "view"
+	expected.Secrets.Vault.Address = ""
+	expected.Kvdb.Username = ""
+	expected.Kvdb.Discovery = nil
+	c.EXPECT().
+		SetClusterConfWithInfo(expected, gomock.Any()).
+		Return(nil)
+	r, err = s.UpdateCluster(contextWithRole("system.view"), &api.SdkConfigUpdateClusterRequest{
+		Config: &api.SdkClusterConfig{
+			Description: "view",
+			ClusterId:   "clusterid",
+			Created:     clusterConfigToSdk(newTestClusterConfig()).GetCreated(),
+			Secrets: &api.SdkSecretsConfig{
+				SecretType: "vault",
+				Vault: &api.SdkVaultConfig{
+					Token: "othertoken",
+				},
+			},
+			Kvdb: &api.SdkKvdbConfig{
+				Password: "otherpassword",
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, r.GetConfig().GetSecrets().GetVault().GetToken())
+}
+
+func TestSdkConfigUpdateNode(t *testing.T) {
+	s, c, mc := newTestConfigServer(t)
+	defer mc.Finish()
+
+	c.EXPECT().
+		GetNodeConf("node1").
+		Return(nil, kvdb.ErrNotFound)
+	c.EXPECT().
+		SetNodeConfWithInfo(&osdconfig.NodeConfig{
+			NodeId: "node1",
+			Geo:    &osdconfig.GeoConfig{Rack: "rack1"},
+		}, &osdconfig.ChangeInfo{Author: "test <test@example.com>", Reason: "move"}).
+		Return(nil)
+
+	_, err := s.UpdateNode(context.Background(), &api.SdkConfigUpdateNodeRequest{
+		Config: &api.SdkNodeConfig{},
+	})
+	assert.Error(t, err)
+	_, err = s.UpdateNode(context.Background(), &api.SdkConfigUpdateNodeRequest{
+		NodeId: "node1",
+		Config: &api.SdkNodeConfig{NodeId: "node2"},
+	})
+	assert.Error(t, err)
+	serverError, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, serverError.Code())
+
+	ctx := context.WithValue(context.Background(), InterceptorContextTokenKey, &sdk_auth.Claims{
+		Name:  "test",
+		Email: "test@example.com",
+		Role:  "system.admin",
+	})
+	r, err := s.UpdateNode(ctx, &api.SdkConfigUpdateNodeRequest{
+		NodeId: "node1",
+		Reason: "move",
+		Config: &api.SdkNodeConfig{
+			CsiEndpoint: "ignored",
+			Geo: &api.SdkGeoConfig{
+				Rack: "rack1",
+				Zone: "ignored",
+			},
+		},
+		UpdateMask: []string{"geo.rack"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "node1", r.GetConfig().GetNodeId())
+	assert.Equal(t, "rack1", r.GetConfig().GetGeo().GetRack())
+	assert.Empty(t, r.GetConfig().GetCsiEndpoint())
+}
+
+func TestSdkConfigInspectNode(t *testing.T) {
+	s, c, mc := newTestConfigServer(t)
+	defer mc.Finish()
+
+	c.EXPECT().
+		GetNodeConf("missing").
+		Return(nil, kvdb.ErrNotFound)
+	c.EXPECT().
+		EnumerateNodeConf().
+		Return(&osdconfig.NodesConfig{
+			&osdconfig.NodeConfig{
+				NodeId:  "node1",
+				Network: &osdconfig.NetworkConfig{MgtIface: "eth0"},
+			},
+		}, nil)
+
+	_, err := s.InspectNode(context.Background(), &api.SdkConfigInspectNodeRequest{NodeId: "missing"})
+	assert.Error(t, err)
+	serverError, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.NotFound, serverError.Code())
+
+	r, err := s.EnumerateNodes(context.Background(), &api.SdkConfigEnumerateNodesRequest{})
+	assert.NoError(t, err)
+	require.Len(t, r.GetConfigs(), 1)
+	assert.Equal(t, "eth0", r.GetConfigs()[0].GetNetwork().GetMgtIface())
+}
+
+func TestSdkConfigRevisions(t *testing.T) {
+	s, c, mc := newTestConfigServer(t)
+	defer mc.Finish()
+
+	first := newTestClusterConfig()
+	second := newTestClusterConfig()
+	second.Secrets.Vault.Token = "othertoken"
+	revisions := []*osdconfig.Revision{
+		{Version: 1, Author: "admin", Reason: "create", Cluster: first},
+		{Version: 2, Author: "admin", Reason: "rotate", Cluster: second},
+	}
+	c.EXPECT().
+		EnumerateClusterConfRevisions().
+		Return(revisions, nil).
+		AnyTimes()
+	c.EXPECT().
+		GetClusterConf().
+		Return(second, nil).
+		AnyTimes()
+	c.EXPECT().
+		DiffClusterConf(uint64(1), uint64(2)).
+		Return(osdconfig.Diff(first, second), nil).
+		Times(2)
+
+	r, err := s.EnumerateClusterRevisions(contextWithRole("system.view"), &api.SdkConfigEnumerateClusterRevisionsRequest{})
+	assert.NoError(t, err)
+	require.Len(t, r.GetRevisions(), 2)
+	assert.Equal(t, uint64(2), r.GetRevisions()[1].GetVersion())
+	assert.Equal(t, "rotate", r.GetRevisions()[1].GetReason())
+	assert.Empty(t, r.GetRevisions()[1].GetCluster().GetSecrets().GetVault().GetToken())
+
+	d, err := s.DiffCluster(contextWithRole("system.admin"), &api.SdkConfigDiffClusterRequest{
+		FromVersion: 1,
+		ToVersion:   2,
+	})
+	assert.NoError(t, err)
+	require.Len(t, d.GetDifferences(), 1)
+	assert.Equal(t, "secrets.vault.token", d.GetDifferences()[0].GetPath())
+	assert.Equal(t, `"othertoken"`, d.GetDifferences()[0].GetNewValue())
+	d, err = s.DiffCluster(contextWithRole("system.view"), &api.SdkConfigDiffClusterRequest{
+		FromVersion: 1,
+		ToVersion:   2,
+	})
+	assert.NoError(t, err)
+	require.Len(t, d.GetDifferences(), 1)
+	assert.True(t, d.GetDifferences()[0].GetHidden())
+	assert.Empty(t, d.GetDifferences()[0].GetNewValue())
+
+	// Only the admin can roll back changes of secrets
+	_, err = s.RollbackCluster(contextWithRole("custom"), &api.SdkConfigRollbackClusterRequest{Version: 1})
+	assert.Error(t, err)
+	serverError, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.PermissionDenied, serverError.Code())
+
+	c.EXPECT().
+		RollbackClusterConf(uint64(1), &osdconfig.ChangeInfo{Author: "test", Reason: "undo"}).
+		Return(nil)
+	c.EXPECT().
+		RollbackClusterConf(uint64(5), gomock.Any()).
+		Return(osdconfig.ErrRevisionNotFound)
+	_, err = s.RollbackCluster(contextWithRole("system.admin"), &api.SdkConfigRollbackClusterRequest{
+		Version: 1,
+		Reason:  "undo",
+	})
+	assert.NoError(t, err)
+	_, err = s.RollbackCluster(context.Background(), &api.SdkConfigRollbackClusterRequest{Version: 5})
+	assert.Error(t, err)
+	serverError, ok = status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.NotFound, serverError.Code())
+}
+
+func TestSdkConfigValidation(t *testing.T) {
+	s, c, mc := newTestConfigServer(t)
+	defer mc.Finish()
+
+	c.EXPECT().
+		GetClusterConf().
+		Return(newTestClusterConfig(), nil)
+	c.EXPECT().
+		SetClusterConfWithInfo(gomock.Any(), gomock.Any()).
+		Return(&osdconfig.ValidationError{Field: "kvdb", Reason: "cannot be changed once set"})
+
+	_, err := s.UpdateCluster(context.Background(), &api.SdkConfigUpdateClusterRequest{
+		Config:     &api.SdkClusterConfig{},
+		UpdateMask: []string{"kvdb"},
+	})
+	assert.Error(t, err)
+	serverError, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, serverError.Code())
+}
+
+type configWatchTestStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *api.SdkConfigWatchResponse
+	// release, if set, blocks the sends until it is closed
+	release chan struct{}
+}
+
+func (s *configWatchTestStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *configWatchTestStream) Send(resp *api.SdkConfigWatchResponse) error {
+	if s.release != nil {
+		<-s.release
+	}
+	s.sent <- resp
+	return nil
+}
+
+func TestSdkConfigWatch(t *testing.T) {
+	s, c, mc := newTestConfigServer(t)
+	defer mc.Finish()
+
+	var clusterCb func(*osdconfig.ClusterConfig) error
+	var nodeCb func(*osdconfig.NodeConfig) error
+	registered := make(chan struct{}, 2)
+	c.EXPECT().
+		WatchCluster(gomock.Any(), gomock.Any()).
+		Do(func(name string, cb func(*osdconfig.ClusterConfig) error) {
+			clusterCb = cb
+			registered <- struct{}{}
+		}).
+		Return(nil)
+	c.EXPECT().
+		WatchNode(gomock.Any(), gomock.Any()).
+		Do(func(name string, cb func(*osdconfig.NodeConfig) error) {
+			nodeCb = cb
+			registered <- struct{}{}
+		}).
+		Return(nil)
+	c.EXPECT().
+		UnwatchCluster(gomock.Any()).
+		Return(nil)
+	c.EXPECT().
+		UnwatchNode(gomock.Any()).
+		Return(nil)
+
+	err := s.Watch(&api.SdkConfigWatchRequest{}, &configWatchTestStream{ctx: context.Background()})
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithCancel(contextWithRole("system.view"))
+	stream := &configWatchTestStream{
+		ctx:  ctx,
+		sent: make(chan *api.SdkConfigWatchResponse),
+	}
+	done := make(chan error)
+	go func() {
+		done <- s.Watch(&api.SdkConfigWatchRequest{
+			Cluster: true,
+			NodeId:  "node1",
+		}, stream)
+	}()
+	<-registered
+	<-registered
+
+	go clusterCb(newTestClusterConfig())
+	resp := <-stream.sent
+	assert.Equal(t, "cluster", resp.GetCluster().GetDescription())
+	assert.Empty(t, resp.GetCluster().GetSecrets().GetVault().GetToken())
+
+	// Changes of other nodes are filtered out
+	nodeCb(&osdconfig.NodeConfig{NodeId: "node2"})
+	go nodeCb(&osdconfig.NodeConfig{NodeId: "node1"})
+	resp = <-stream.sent
+	assert.Equal(t, "node1", resp.GetNode().GetNodeId())
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestSdkConfigWatchOverflow(t *testing.T) {
+	s, c, mc := newTestConfigServer(t)
+	defer mc.Finish()
+
+	var clusterCb func(*osdconfig.ClusterConfig) error
+	registered := make(chan struct{})
+	c.EXPECT().
+		WatchCluster(gomock.Any(), gomock.Any()).
+		Do(func(name string, cb func(*osdconfig.ClusterConfig) error) {
+			clusterCb = cb
+			close(registered)
+		}).
+		Return(nil)
+	c.EXPECT().
+		UnwatchCluster(gomock.Any()).
+		Return(nil)
+
+	// The client does not read any change until released
+	stream := &configWatchTestStream{
+		ctx:     context.Background(),
+		sent:    make(chan *api.SdkConfigWatchResponse, 2*configWatchBuffer+2),
+		release: make(chan struct{}),
+	}
+	done := make(chan error)
+	go func() {
+		done <- s.Watch(&api.SdkConfigWatchRequest{Cluster: true}, stream)
+	}()
+	<-registered
+
+	// The callbacks do not block
+	for i := 0; i < 2*configWatchBuffer+2; i++ {
+		assert.NoError(t, clusterCb(newTestClusterConfig()))
+	}
+	close(stream.release)
+
+	err := <-done
+	assert.Error(t, err)
+	serverError, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.ResourceExhausted, serverError.Code())
+}
+
+func TestApplyUpdateMask(t *testing.T) {
+	dst := &api.SdkClusterConfig{}
+	src := &api.SdkClusterConfig{
+		Kvdb: &api.SdkKvdbConfig{Name: "etcd"},
+	}
+	assert.NoError(t, applyUpdateMask(dst, src, []string{"kvdb"}))
+	assert.Equal(t, "etcd", dst.GetKvdb().GetName())
+	// Messages are copied
+	src.Kvdb.Name = "consul"
+	assert.Equal(t, "etcd", dst.GetKvdb().GetName())
+
+	assert.NoError(t, applyUpdateMask(dst, &api.SdkClusterConfig{}, []string{"kvdb.name"}))
+	assert.NotNil(t, dst.GetKvdb())
+	assert.Empty(t, dst.GetKvdb().GetName())
+
+	assert.NoError(t, applyUpdateMask(dst, src, nil))
+	assert.Equal(t, "consul", dst.GetKvdb().GetName())
+}
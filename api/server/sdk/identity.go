@@ -125,6 +125,27 @@ func (s *IdentityServer) Capabilities(
 			},
 		},
 	}
+	capSecrets := &api.SdkServiceCapability{
+		Type: &api.SdkServiceCapability_Service{
+			Service: &api.SdkServiceCapability_OpenStorageService{
+				Type: api.SdkServiceCapability_OpenStorageService_SECRETS,
+			},
+		},
+	}
+	capConfig := &api.SdkServiceCapability{
+		Type: &api.SdkServiceCapability_Service{
+			Service: &api.SdkServiceCapability_OpenStorageService{
+				Type: api.SdkServiceCapability_OpenStorageService_CONFIG,
+			},
+		},
+	}
+	capChaos := &api.SdkServiceCapability{
+		Type: &api.SdkServiceCapability_Service{
+			Service: &api.SdkServiceCapability_OpenStorageService{
+				Type: api.SdkServiceCapability_OpenStorageService_CHAOS,
+			},
+		},
+	}
 
 	return &api.SdkIdentityCapabilitiesResponse{
 		Capabilities: []*api.SdkServiceCapability{
@@ -140,6 +161,9 @@ func (s *IdentityServer) Capabilities(
 			capRole,
 			capClusterPair,
 			capMigrate,
+			capSecrets,
+			capConfig,
+			capChaos,
 		},
 	}, nil
 }
@@ -21,6 +21,7 @@ import (
 
 	"github.com/libopenstorage/openstorage/api"
 	"github.com/libopenstorage/openstorage/cluster"
+	"github.com/libopenstorage/openstorage/volume"
 	"github.com/portworx/kvdb"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -35,6 +36,10 @@ func (s *NodeServer) cluster() cluster.Cluster {
 	return s.server.cluster()
 }
 
+func (s *NodeServer) driver() volume.VolumeDriver {
+	return s.server.driver()
+}
+
 // Enumerate returns the ids of all the nodes in the cluster
 func (s *NodeServer) Enumerate(
 	ctx context.Context,
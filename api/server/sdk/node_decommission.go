@@ -0,0 +1,282 @@
+/*
+Package sdk is the gRPC implementation of the SDK gRPC server
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sdk
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/cluster"
+)
+
+var (
+	// decommissionPollInterval is how often the progress of a decommission
+	// is checked
+	decommissionPollInterval = 2 * time.Second
+	// decommissionProgressInterval is how often the progress of a
+	// decommission is sent while it does not change
+	decommissionProgressInterval = 30 * time.Second
+)
+
+// canForceDecommission returns true if the caller may force the decommission
+// of a node
+func (s *NodeServer) canForceDecommission(ctx context.Context) bool {
+	return hasPrivilege(ctx, s.server.roleManager(), privilegeForceDecommission)
+}
+
+// decommissionError converts the errors of the cluster removal calls
+func decommissionError(nodeID string, err error, msg string) error {
+	switch err {
+	case cluster.ErrNodeNotFound:
+		return status.Errorf(codes.NotFound, "Node %s not found", nodeID)
+	case cluster.ErrNodeRemoveNotPending:
+		return status.Errorf(codes.FailedPrecondition,
+			"Node %s is not being decommissioned", nodeID)
+	}
+	return status.Errorf(codes.Internal, "%s %s: %v", msg, nodeID, err)
+}
+
+// decommissionStatus converts the status of the removal of a node
+func decommissionStatus(
+	nodeID string,
+	removeStatus *cluster.NodeRemoveStatus,
+	blockers []cluster.NodeRemoveBlocker,
+) *api.SdkNodeDecommissionStatus {
+	s := &api.SdkNodeDecommissionStatus{
+		NodeId:   nodeID,
+		Blockers: make([]*api.SdkNodeDecommissionBlocker, 0, len(blockers)),
+	}
+	switch removeStatus.State {
+	case cluster.NodeRemoveStateNotStarted:
+		s.State = api.SdkNodeDecommissionState_SdkNodeDecommissionStateNotStarted
+		if len(blockers) != 0 {
+			s.State = api.SdkNodeDecommissionState_SdkNodeDecommissionStateBlocked
+		}
+	case cluster.NodeRemoveStateInProgress:
+		s.State = api.SdkNodeDecommissionState_SdkNodeDecommissionStateInProgress
+	case cluster.NodeRemoveStateDone:
+		s.State = api.SdkNodeDecommissionState_SdkNodeDecommissionStateDone
+	case cluster.NodeRemoveStateFailed:
+		s.State = api.SdkNodeDecommissionState_SdkNodeDecommissionStateFailed
+	}
+	if removeStatus.Error != nil {
+		s.Error = removeStatus.Error.Error()
+	}
+	for _, b := range blockers {
+		s.Blockers = append(s.Blockers, &api.SdkNodeDecommissionBlocker{
+			Component: b.Listener,
+			Reason:    b.Reason.Error(),
+			Message:   b.Message,
+			Forcible:  b.Forcible(),
+		})
+	}
+	return s
+}
+
+// blockersError returns an error listing the reasons which prevent the
+// removal of a node, nil if it can be removed
+func blockersError(nodeID string, blockers []cluster.NodeRemoveBlocker, force bool) error {
+	reasons := make([]string, 0, len(blockers))
+	for _, b := range blockers {
+		if force && b.Forcible() {
+			continue
+		}
+		reason := b.Reason.Error()
+		if len(b.Message) != 0 {
+			reason += " " + b.Message
+		}
+		reasons = append(reasons, reason)
+	}
+	if len(reasons) == 0 {
+		return nil
+	}
+	return status.Errorf(codes.FailedPrecondition,
+		"Node %s cannot be decommissioned: %s",
+		nodeID, strings.Join(reasons, "; "))
+}
+
+// Decommission removes a node from the cluster and streams the progress of
+// its removal
+func (s *NodeServer) Decommission(
+	req *api.SdkNodeDecommissionRequest,
+	stream api.OpenStorageNode_DecommissionServer,
+) error {
+	ctx := stream.Context()
+	if s.cluster() == nil {
+		return status.Error(codes.Unavailable, "Resource has not been initialized")
+	} else if len(req.GetNodeId()) == 0 {
+		return status.Error(codes.InvalidArgument, "Must supply a node id")
+	} else if req.GetForce() && !s.canForceDecommission(ctx) {
+		return status.Error(codes.PermissionDenied,
+			"Role is not allowed to force the decommission of a node")
+	}
+	nodeID := req.GetNodeId()
+
+	removeStatus, err := s.cluster().NodeRemoveStatus(nodeID)
+	if err != nil {
+		return decommissionError(nodeID, err, "Failed to get the decommission status of node")
+	}
+
+	// A decommission in progress is only followed
+	if removeStatus.State != cluster.NodeRemoveStateInProgress {
+		blockers, err := s.cluster().NodeRemoveBlockers(nodeID)
+		if err != nil {
+			return decommissionError(nodeID, err, "Failed to check the decommission of node")
+		}
+		if err := blockersError(nodeID, blockers, req.GetForce()); err != nil {
+			return err
+		}
+
+		err = s.cluster().Remove([]api.Node{{Id: nodeID}}, req.GetForce())
+		if err != nil && err != cluster.ErrNodeRemovePending {
+			return decommissionError(nodeID, err, "Failed to decommission node")
+		}
+	}
+
+	var (
+		last     *api.SdkNodeDecommissionStatus
+		lastSent time.Time
+	)
+	for {
+		removeStatus, err := s.cluster().NodeRemoveStatus(nodeID)
+		if err != nil {
+			return decommissionError(nodeID, err, "Failed to get the decommission status of node")
+		}
+		inProgress := removeStatus.State == cluster.NodeRemoveStateInProgress
+		var blockers []cluster.NodeRemoveBlocker
+		if inProgress {
+			blockers = s.decommissionBlockers(nodeID)
+		}
+		current := decommissionStatus(nodeID, removeStatus, blockers)
+		if inProgress {
+			s.countDecommissionVolumes(current)
+		}
+
+		// Changes are sent right away, and the progress is sent
+		// periodically so that the client knows the decommission is alive
+		if last == nil || !proto.Equal(current, last) ||
+			time.Since(lastSent) >= decommissionProgressInterval {
+			if err := stream.Send(&api.SdkNodeDecommissionResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+			lastSent = time.Now()
+		}
+		if !inProgress {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(decommissionPollInterval):
+		}
+	}
+}
+
+// decommissionBlockers returns the reasons a decommission in progress is
+// still waiting for. Failures to get them are only logged since the
+// progress is sent anyway.
+func (s *NodeServer) decommissionBlockers(nodeID string) []cluster.NodeRemoveBlocker {
+	blockers, err := s.cluster().NodeRemoveBlockers(nodeID)
+	if err != nil {
+		logrus.Warnf("Failed to get the decommission blockers of node %s: %v", nodeID, err)
+		return nil
+	}
+	return blockers
+}
+
+// countDecommissionVolumes sets in the status of a decommission in progress
+// the number of volumes with a replica on the node and attached on it
+func (s *NodeServer) countDecommissionVolumes(status *api.SdkNodeDecommissionStatus) {
+	if s.driver() == nil {
+		return
+	}
+	vols, err := s.driver().Enumerate(&api.VolumeLocator{}, nil)
+	if err != nil {
+		logrus.Warnf("Failed to count the volumes of node %s: %v", status.GetNodeId(), err)
+		return
+	}
+	for _, vol := range vols {
+		if vol.GetAttachedOn() == status.GetNodeId() {
+			status.AttachedVolumes++
+		}
+	replicas:
+		for _, replicaSet := range vol.GetReplicaSets() {
+			for _, node := range replicaSet.GetNodes() {
+				if node == status.GetNodeId() {
+					status.Volumes++
+					break replicas
+				}
+			}
+		}
+	}
+}
+
+// DecommissionStatus returns the status of the removal of a node
+func (s *NodeServer) DecommissionStatus(
+	ctx context.Context,
+	req *api.SdkNodeDecommissionStatusRequest,
+) (*api.SdkNodeDecommissionStatusResponse, error) {
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	} else if len(req.GetNodeId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a node id")
+	}
+	nodeID := req.GetNodeId()
+
+	removeStatus, err := s.cluster().NodeRemoveStatus(nodeID)
+	if err != nil {
+		return nil, decommissionError(nodeID, err, "Failed to get the decommission status of node")
+	}
+	var blockers []cluster.NodeRemoveBlocker
+	if removeStatus.State == cluster.NodeRemoveStateNotStarted {
+		blockers, err = s.cluster().NodeRemoveBlockers(nodeID)
+		if err != nil {
+			return nil, decommissionError(nodeID, err, "Failed to check the decommission of node")
+		}
+	}
+
+	return &api.SdkNodeDecommissionStatusResponse{
+		Status: decommissionStatus(nodeID, removeStatus, blockers),
+	}, nil
+}
+
+// CancelDecommission puts a node whose removal is in progress back in the
+// cluster
+func (s *NodeServer) CancelDecommission(
+	ctx context.Context,
+	req *api.SdkNodeCancelDecommissionRequest,
+) (*api.SdkNodeCancelDecommissionResponse, error) {
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	} else if len(req.GetNodeId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a node id")
+	}
+
+	if err := s.cluster().CancelNodeRemove(req.GetNodeId()); err != nil {
+		return nil, decommissionError(req.GetNodeId(), err, "Failed to cancel the decommission of node")
+	}
+	return &api.SdkNodeCancelDecommissionResponse{}, nil
+}
@@ -0,0 +1,345 @@
+/*
+Package sdk is the gRPC implementation of the SDK gRPC server
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/cluster"
+)
+
+type decommissionTestStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []*api.SdkNodeDecommissionStatus
+}
+
+func (s *decommissionTestStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *decommissionTestStream) Send(resp *api.SdkNodeDecommissionResponse) error {
+	s.sent = append(s.sent, resp.GetStatus())
+	return nil
+}
+
+var (
+	testDataLossBlocker = cluster.NodeRemoveBlocker{
+		Listener: "storage",
+		Reason:   cluster.ErrRemoveCausesDataLoss,
+		Message:  "Volume vol1 has a single replica on node2",
+	}
+)
+
+func TestSdkNodeDecommissionBlocked(t *testing.T) {
+	s, c, _, mc := newTestNodeServer()
+	defer mc.Finish()
+
+	err := s.Decommission(&api.SdkNodeDecommissionRequest{},
+		&decommissionTestStream{ctx: context.Background()})
+	assert.Equal(t, codes.InvalidArgument, grpc.Code(err))
+
+	// Only admins can force
+	err = s.Decommission(&api.SdkNodeDecommissionRequest{NodeId: "node2", Force: true},
+		&decommissionTestStream{ctx: contextWithRole("system.user")})
+	assert.Equal(t, codes.PermissionDenied, grpc.Code(err))
+
+	c.EXPECT().
+		NodeRemoveStatus("node2").
+		Return(&cluster.NodeRemoveStatus{State: cluster.NodeRemoveStateNotStarted}, nil)
+	c.EXPECT().
+		NodeRemoveBlockers("node2").
+		Return([]cluster.NodeRemoveBlocker{testDataLossBlocker}, nil)
+	stream := &decommissionTestStream{ctx: context.Background()}
+	err = s.Decommission(&api.SdkNodeDecommissionRequest{NodeId: "node2"}, stream)
+	assert.Equal(t, codes.FailedPrecondition, grpc.Code(err))
+	assert.Contains(t, err.Error(), testDataLossBlocker.Message)
+	assert.Empty(t, stream.sent)
+
+	// Force does not override every reason
+	c.EXPECT().
+		NodeRemoveStatus("node2").
+		Return(&cluster.NodeRemoveStatus{State: cluster.NodeRemoveStateNotStarted}, nil)
+	c.EXPECT().
+		NodeRemoveBlockers("node2").
+		Return([]cluster.NodeRemoveBlocker{
+			{Reason: errors.New("Node node2 must be offline")},
+		}, nil)
+	err = s.Decommission(&api.SdkNodeDecommissionRequest{NodeId: "node2", Force: true},
+		&decommissionTestStream{ctx: contextWithRole("system.admin")})
+	assert.Equal(t, codes.FailedPrecondition, grpc.Code(err))
+}
+
+func TestSdkNodeDecommission(t *testing.T) {
+	s, c, d, mc := newTestNodeServer()
+	defer mc.Finish()
+
+	interval := decommissionPollInterval
+	decommissionPollInterval = time.Millisecond
+	defer func() {
+		decommissionPollInterval = interval
+	}()
+
+	inProgress := &cluster.NodeRemoveStatus{State: cluster.NodeRemoveStateInProgress}
+	gomock.InOrder(
+		c.EXPECT().
+			NodeRemoveStatus("node2").
+			Return(&cluster.NodeRemoveStatus{State: cluster.NodeRemoveStateNotStarted}, nil),
+		c.EXPECT().
+			NodeRemoveBlockers("node2").
+			Return([]cluster.NodeRemoveBlocker{testDataLossBlocker}, nil),
+		c.EXPECT().
+			Remove([]api.Node{{Id: "node2"}}, true).
+			Return(cluster.ErrNodeRemovePending),
+		c.EXPECT().
+			NodeRemoveStatus("node2").
+			Return(inProgress, nil).
+			Times(2),
+		c.EXPECT().
+			NodeRemoveStatus("node2").
+			Return(&cluster.NodeRemoveStatus{State: cluster.NodeRemoveStateDone}, nil),
+	)
+
+	// The progress has neither blockers nor volumes
+	c.EXPECT().
+		NodeRemoveBlockers("node2").
+		Return(nil, nil).
+		AnyTimes()
+	d.EXPECT().
+		Enumerate(&api.VolumeLocator{}, nil).
+		Return(nil, nil).
+		AnyTimes()
+
+	stream := &decommissionTestStream{ctx: contextWithRole("system.admin")}
+	err := s.Decommission(&api.SdkNodeDecommissionRequest{NodeId: "node2", Force: true}, stream)
+	assert.NoError(t, err)
+	require.Len(t, stream.sent, 2)
+	assert.Equal(t, api.SdkNodeDecommissionState_SdkNodeDecommissionStateInProgress,
+		stream.sent[0].GetState())
+	assert.Equal(t, api.SdkNodeDecommissionState_SdkNodeDecommissionStateDone,
+		stream.sent[1].GetState())
+
+	// A decommission in progress is followed until it fails
+	removeErr := errors.New("volumes are still in use")
+	gomock.InOrder(
+		c.EXPECT().
+			NodeRemoveStatus("node2").
+			Return(inProgress, nil).
+			Times(2),
+		c.EXPECT().
+			NodeRemoveStatus("node2").
+			Return(&cluster.NodeRemoveStatus{
+				State: cluster.NodeRemoveStateFailed,
+				Error: removeErr,
+			}, nil),
+	)
+	stream = &decommissionTestStream{ctx: context.Background()}
+	err = s.Decommission(&api.SdkNodeDecommissionRequest{NodeId: "node2"}, stream)
+	assert.NoError(t, err)
+	require.Len(t, stream.sent, 2)
+	assert.Equal(t, api.SdkNodeDecommissionState_SdkNodeDecommissionStateFailed,
+		stream.sent[1].GetState())
+	assert.Equal(t, removeErr.Error(), stream.sent[1].GetError())
+}
+
+func TestSdkNodeDecommissionProgress(t *testing.T) {
+	s, c, d, mc := newTestNodeServer()
+	defer mc.Finish()
+
+	pollInterval, progressInterval := decommissionPollInterval, decommissionProgressInterval
+	decommissionPollInterval = time.Millisecond
+	decommissionProgressInterval = time.Hour
+	defer func() {
+		decommissionPollInterval = pollInterval
+		decommissionProgressInterval = progressInterval
+	}()
+
+	inProgress := &cluster.NodeRemoveStatus{State: cluster.NodeRemoveStateInProgress}
+	waiting := cluster.NodeRemoveBlocker{
+		Listener: "storage",
+		Reason:   errors.New("Volumes are being moved"),
+		Message:  "2 volumes left",
+	}
+	onNode := []*api.Volume{
+		{
+			Id:          "vol1",
+			AttachedOn:  "node2",
+			ReplicaSets: []*api.ReplicaSet{{Nodes: []string{"node1", "node2"}}},
+		},
+		{
+			Id:          "vol2",
+			ReplicaSets: []*api.ReplicaSet{{Nodes: []string{"node2"}}},
+		},
+		{
+			Id:          "vol3",
+			AttachedOn:  "node1",
+			ReplicaSets: []*api.ReplicaSet{{Nodes: []string{"node1"}}},
+		},
+	}
+	// The status is checked once more before following the decommission
+	gomock.InOrder(
+		c.EXPECT().
+			NodeRemoveStatus("node2").
+			Return(inProgress, nil).
+			Times(5),
+		c.EXPECT().
+			NodeRemoveStatus("node2").
+			Return(&cluster.NodeRemoveStatus{State: cluster.NodeRemoveStateDone}, nil),
+	)
+	gomock.InOrder(
+		c.EXPECT().
+			NodeRemoveBlockers("node2").
+			Return([]cluster.NodeRemoveBlocker{waiting}, nil).
+			Times(3),
+		c.EXPECT().
+			NodeRemoveBlockers("node2").
+			Return(nil, nil),
+	)
+	gomock.InOrder(
+		d.EXPECT().
+			Enumerate(&api.VolumeLocator{}, nil).
+			Return(onNode, nil).
+			Times(2),
+		d.EXPECT().
+			Enumerate(&api.VolumeLocator{}, nil).
+			Return(onNode[1:], nil),
+		d.EXPECT().
+			Enumerate(&api.VolumeLocator{}, nil).
+			Return(nil, nil),
+	)
+
+	stream := &decommissionTestStream{ctx: context.Background()}
+	err := s.Decommission(&api.SdkNodeDecommissionRequest{NodeId: "node2"}, stream)
+	assert.NoError(t, err)
+
+	// Only the changes are sent
+	require.Len(t, stream.sent, 4)
+	assert.Equal(t, uint64(2), stream.sent[0].GetVolumes())
+	assert.Equal(t, uint64(1), stream.sent[0].GetAttachedVolumes())
+	require.Len(t, stream.sent[0].GetBlockers(), 1)
+	assert.Equal(t, "storage", stream.sent[0].GetBlockers()[0].GetComponent())
+	assert.Equal(t, waiting.Message, stream.sent[0].GetBlockers()[0].GetMessage())
+	assert.Equal(t, uint64(1), stream.sent[1].GetVolumes())
+	assert.Equal(t, uint64(0), stream.sent[1].GetAttachedVolumes())
+	assert.Empty(t, stream.sent[2].GetBlockers())
+	assert.Equal(t, uint64(0), stream.sent[2].GetVolumes())
+	assert.Equal(t, api.SdkNodeDecommissionState_SdkNodeDecommissionStateInProgress,
+		stream.sent[2].GetState())
+	assert.Equal(t, api.SdkNodeDecommissionState_SdkNodeDecommissionStateDone,
+		stream.sent[3].GetState())
+
+	// The progress is sent periodically even if it does not change
+	decommissionProgressInterval = 0
+	gomock.InOrder(
+		c.EXPECT().
+			NodeRemoveStatus("node2").
+			Return(inProgress, nil).
+			Times(4),
+		c.EXPECT().
+			NodeRemoveStatus("node2").
+			Return(&cluster.NodeRemoveStatus{State: cluster.NodeRemoveStateDone}, nil),
+	)
+	c.EXPECT().
+		NodeRemoveBlockers("node2").
+		Return(nil, errors.New("kvdb unavailable")).
+		Times(3)
+	d.EXPECT().
+		Enumerate(&api.VolumeLocator{}, nil).
+		Return(onNode, nil).
+		Times(3)
+	stream = &decommissionTestStream{ctx: context.Background()}
+	err = s.Decommission(&api.SdkNodeDecommissionRequest{NodeId: "node2"}, stream)
+	assert.NoError(t, err)
+	require.Len(t, stream.sent, 4)
+	for _, sent := range stream.sent[:3] {
+		assert.Equal(t, uint64(2), sent.GetVolumes())
+		assert.Empty(t, sent.GetBlockers())
+	}
+}
+
+func TestSdkNodeDecommissionStatus(t *testing.T) {
+	s, c, _, mc := newTestNodeServer()
+	defer mc.Finish()
+	ctx := context.Background()
+
+	c.EXPECT().
+		NodeRemoveStatus("node2").
+		Return(&cluster.NodeRemoveStatus{State: cluster.NodeRemoveStateNotStarted}, nil)
+	c.EXPECT().
+		NodeRemoveBlockers("node2").
+		Return([]cluster.NodeRemoveBlocker{testDataLossBlocker}, nil)
+	resp, err := s.DecommissionStatus(ctx, &api.SdkNodeDecommissionStatusRequest{NodeId: "node2"})
+	assert.NoError(t, err)
+	assert.Equal(t, &api.SdkNodeDecommissionStatus{
+		NodeId: "node2",
+		State:  api.SdkNodeDecommissionState_SdkNodeDecommissionStateBlocked,
+		Blockers: []*api.SdkNodeDecommissionBlocker{
+			{
+				Component: "storage",
+				Reason:    cluster.ErrRemoveCausesDataLoss.Error(),
+				Message:   testDataLossBlocker.Message,
+				Forcible:  true,
+			},
+		},
+	}, resp.GetStatus())
+
+	c.EXPECT().
+		NodeRemoveStatus("node2").
+		Return(&cluster.NodeRemoveStatus{State: cluster.NodeRemoveStateInProgress}, nil)
+	resp, err = s.DecommissionStatus(ctx, &api.SdkNodeDecommissionStatusRequest{NodeId: "node2"})
+	assert.NoError(t, err)
+	assert.Equal(t, api.SdkNodeDecommissionState_SdkNodeDecommissionStateInProgress,
+		resp.GetStatus().GetState())
+	assert.Empty(t, resp.GetStatus().GetBlockers())
+
+	c.EXPECT().
+		NodeRemoveStatus("node3").
+		Return(nil, cluster.ErrNodeNotFound)
+	_, err = s.DecommissionStatus(ctx, &api.SdkNodeDecommissionStatusRequest{NodeId: "node3"})
+	assert.Equal(t, codes.NotFound, grpc.Code(err))
+}
+
+func TestSdkNodeCancelDecommission(t *testing.T) {
+	s, c, _, mc := newTestNodeServer()
+	defer mc.Finish()
+	ctx := context.Background()
+
+	_, err := s.CancelDecommission(ctx, &api.SdkNodeCancelDecommissionRequest{})
+	assert.Equal(t, codes.InvalidArgument, grpc.Code(err))
+
+	c.EXPECT().
+		CancelNodeRemove("node2").
+		Return(nil)
+	_, err = s.CancelDecommission(ctx, &api.SdkNodeCancelDecommissionRequest{NodeId: "node2"})
+	assert.NoError(t, err)
+
+	c.EXPECT().
+		CancelNodeRemove("node2").
+		Return(cluster.ErrNodeRemoveNotPending)
+	_, err = s.CancelDecommission(ctx, &api.SdkNodeCancelDecommissionRequest{NodeId: "node2"})
+	assert.Equal(t, codes.FailedPrecondition, grpc.Code(err))
+}
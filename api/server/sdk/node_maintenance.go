@@ -0,0 +1,256 @@
+/*
+Package sdk is the gRPC implementation of the SDK gRPC server
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sdk
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/cluster"
+	mountattachoptions "github.com/libopenstorage/openstorage/pkg/options"
+)
+
+const (
+	// defaultMaintenanceTimeout is how long entering maintenance waits for
+	// the volume operations in progress
+	defaultMaintenanceTimeout = 60 * time.Second
+)
+
+var (
+	// maintenanceMethods are the calls which place or attach volumes on
+	// this node and which are rejected while it is in maintenance
+	maintenanceMethods = map[string]bool{
+		"/openstorage.api.OpenStorageVolume/Create":      true,
+		"/openstorage.api.OpenStorageVolume/Clone":       true,
+		"/openstorage.api.OpenStorageMountAttach/Attach": true,
+		"/openstorage.api.OpenStorageMountAttach/Mount":  true,
+	}
+
+	// nodeMaintenance is shared by the SDK servers of this node
+	nodeMaintenance = newMaintenanceGuard()
+)
+
+// maintenanceGuard rejects the volume operations of maintenanceMethods while
+// this node is in maintenance, and tracks the ones in progress so that
+// entering maintenance can wait for them.
+type maintenanceGuard struct {
+	lock     sync.Mutex
+	inflight int
+	// idle is closed when no operation is in progress
+	idle chan struct{}
+}
+
+func newMaintenanceGuard() *maintenanceGuard {
+	idle := make(chan struct{})
+	close(idle)
+	return &maintenanceGuard{idle: idle}
+}
+
+// begin starts an operation unless this node is in maintenance
+func (g *maintenanceGuard) begin(c cluster.Cluster) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if c != nil {
+		if s, err := c.NodeStatus(); err == nil && s == api.Status_STATUS_MAINTENANCE {
+			return status.Error(codes.FailedPrecondition, "Node is in maintenance")
+		}
+	}
+	if g.inflight == 0 {
+		g.idle = make(chan struct{})
+	}
+	g.inflight++
+	return nil
+}
+
+// end ends an operation started with begin
+func (g *maintenanceGuard) end() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.inflight--
+	if g.inflight == 0 {
+		close(g.idle)
+	}
+}
+
+// enter puts this node in maintenance and returns a channel closed once the
+// operations in progress are done. No operation can start in between.
+func (g *maintenanceGuard) enter(c cluster.Cluster, nodeID string) (<-chan struct{}, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if err := c.EnterMaintenance(nodeID); err != nil {
+		return nil, err
+	}
+	return g.idle, nil
+}
+
+// checkLocalNode returns an error if nodeID is not the id of this node
+func (s *NodeServer) checkLocalNode(nodeID string) error {
+	if len(nodeID) == 0 {
+		return status.Error(codes.InvalidArgument, "Must supply a node id")
+	}
+	c, err := s.cluster().Enumerate()
+	if err != nil {
+		return status.Errorf(codes.Internal, "Failed to determine node id: %v", err)
+	}
+	if c.NodeId != nodeID {
+		return status.Errorf(codes.FailedPrecondition,
+			"Node %s must be connected to for its maintenance", nodeID)
+	}
+	return nil
+}
+
+// maintenanceError converts the errors of the cluster maintenance calls
+func maintenanceError(nodeID string, err error) error {
+	switch err {
+	case cluster.ErrNodeNotFound:
+		return status.Errorf(codes.NotFound, "Node %s not found", nodeID)
+	case cluster.ErrNodeDecommissioned:
+		return status.Errorf(codes.FailedPrecondition,
+			"Node %s is being decommissioned", nodeID)
+	}
+	return status.Errorf(codes.Internal,
+		"Failed to update the maintenance of node %s: %v", nodeID, err)
+}
+
+// EnterMaintenance puts this node in maintenance and detaches its volumes
+func (s *NodeServer) EnterMaintenance(
+	ctx context.Context,
+	req *api.SdkNodeEnterMaintenanceRequest,
+) (*api.SdkNodeEnterMaintenanceResponse, error) {
+	if s.cluster() == nil || s.driver() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+	if req.GetTimeoutSeconds() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "Timeout must not be negative")
+	}
+	if err := s.checkLocalNode(req.GetNodeId()); err != nil {
+		return nil, err
+	}
+
+	idle, err := nodeMaintenance.enter(s.cluster(), req.GetNodeId())
+	if err != nil {
+		return nil, maintenanceError(req.GetNodeId(), err)
+	}
+
+	// Wait for the volume operations in progress
+	timeout := defaultMaintenanceTimeout
+	if req.GetTimeoutSeconds() != 0 {
+		timeout = time.Duration(req.GetTimeoutSeconds()) * time.Second
+	}
+	select {
+	case <-idle:
+	case <-ctx.Done():
+		return nil, status.Errorf(codes.DeadlineExceeded,
+			"Node %s is in maintenance but its volumes were not detached: %v",
+			req.GetNodeId(), ctx.Err())
+	case <-time.After(timeout):
+		return nil, status.Errorf(codes.DeadlineExceeded,
+			"Node %s is in maintenance but volume operations are still in progress "+
+				"after %v, its volumes were not detached",
+			req.GetNodeId(), timeout)
+	}
+
+	return s.drain(req.GetNodeId())
+}
+
+// drain detaches the volumes attached to this node. Drivers which can move
+// a volume to another node do it when detaching the volume.
+func (s *NodeServer) drain(nodeID string) (*api.SdkNodeEnterMaintenanceResponse, error) {
+	node, err := s.cluster().Inspect(nodeID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal,
+			"Failed to inspect node %s: %v", nodeID, err)
+	}
+	vols, err := s.driver().Enumerate(&api.VolumeLocator{}, nil)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal,
+			"Failed to enumerate volumes: %v", err)
+	}
+
+	resp := &api.SdkNodeEnterMaintenanceResponse{
+		DetachedVolumeIds: []string{},
+		AttachedVolumeIds: []string{},
+	}
+	for _, v := range vols {
+		if !attachedOnNode(v, &node) {
+			continue
+		}
+		if err := s.detach(v); err != nil {
+			logrus.Warnf("Volume %s stays attached to node %s in maintenance: %v",
+				v.GetId(), nodeID, err)
+			resp.AttachedVolumeIds = append(resp.AttachedVolumeIds, v.GetId())
+			continue
+		}
+		resp.DetachedVolumeIds = append(resp.DetachedVolumeIds, v.GetId())
+	}
+	return resp, nil
+}
+
+// attachedOnNode returns true if v is attached to node, which drivers record
+// with the node id or one of its addresses
+func attachedOnNode(v *api.Volume, node *api.Node) bool {
+	if v.GetState() != api.VolumeState_VOLUME_STATE_ATTACHED ||
+		v.GetAttachedState() == api.AttachState_ATTACH_STATE_INTERNAL {
+		return false
+	}
+	switch v.GetAttachedOn() {
+	case "":
+		return false
+	case node.Id, node.MgmtIp, node.DataIp, node.Hostname:
+		return true
+	}
+	return false
+}
+
+// detach unmounts and detaches v
+func (s *NodeServer) detach(v *api.Volume) error {
+	for _, path := range v.GetAttachPath() {
+		if err := s.driver().Unmount(v.GetId(), path, nil); err != nil {
+			return err
+		}
+	}
+	return s.driver().Detach(v.GetId(), map[string]string{
+		mountattachoptions.OptionsRedirectDetach: "true",
+	})
+}
+
+// ExitMaintenance puts this node back in service
+func (s *NodeServer) ExitMaintenance(
+	ctx context.Context,
+	req *api.SdkNodeExitMaintenanceRequest,
+) (*api.SdkNodeExitMaintenanceResponse, error) {
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+	if err := s.checkLocalNode(req.GetNodeId()); err != nil {
+		return nil, err
+	}
+
+	if err := s.cluster().ExitMaintenance(req.GetNodeId()); err != nil {
+		return nil, maintenanceError(req.GetNodeId(), err)
+	}
+	return &api.SdkNodeExitMaintenanceResponse{}, nil
+}
@@ -0,0 +1,155 @@
+/*
+Package sdk is the gRPC implementation of the SDK gRPC server
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sdk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kubernetes-csi/csi-test/utils"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/libopenstorage/openstorage/alerts"
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/cluster"
+	mockcluster "github.com/libopenstorage/openstorage/cluster/mock"
+	"github.com/libopenstorage/openstorage/pkg/role"
+	"github.com/libopenstorage/openstorage/volume"
+	mockdriver "github.com/libopenstorage/openstorage/volume/drivers/mock"
+)
+
+type nodeTestAccessor struct {
+	c cluster.Cluster
+	d volume.VolumeDriver
+}
+
+func (a *nodeTestAccessor) alert() alerts.FilterDeleter   { return nil }
+func (a *nodeTestAccessor) cluster() cluster.Cluster      { return a.c }
+func (a *nodeTestAccessor) driver() volume.VolumeDriver   { return a.d }
+func (a *nodeTestAccessor) roleManager() role.RoleManager { return testRoleManager() }
+
+func newTestNodeServer() (*NodeServer, *mockcluster.MockCluster, *mockdriver.MockVolumeDriver, *gomock.Controller) {
+	mc := gomock.NewController(&utils.SafeGoroutineTester{})
+	c := mockcluster.NewMockCluster(mc)
+	d := mockdriver.NewMockVolumeDriver(mc)
+	c.EXPECT().
+		Enumerate().
+		Return(api.Cluster{NodeId: "node1"}, nil).
+		AnyTimes()
+	return &NodeServer{server: &nodeTestAccessor{c: c, d: d}}, c, d, mc
+}
+
+func TestSdkNodeEnterMaintenance(t *testing.T) {
+	s, c, d, mc := newTestNodeServer()
+	defer mc.Finish()
+	ctx := context.Background()
+
+	_, err := s.EnterMaintenance(ctx, &api.SdkNodeEnterMaintenanceRequest{})
+	assert.Equal(t, codes.InvalidArgument, grpc.Code(err))
+	_, err = s.EnterMaintenance(ctx, &api.SdkNodeEnterMaintenanceRequest{NodeId: "node2"})
+	assert.Equal(t, codes.FailedPrecondition, grpc.Code(err))
+
+	attached := func(id, on string, paths ...string) *api.Volume {
+		return &api.Volume{
+			Id:         id,
+			State:      api.VolumeState_VOLUME_STATE_ATTACHED,
+			AttachedOn: on,
+			AttachPath: paths,
+		}
+	}
+	c.EXPECT().
+		EnterMaintenance("node1").
+		Return(nil)
+	c.EXPECT().
+		Inspect("node1").
+		Return(api.Node{Id: "node1", MgmtIp: "10.0.0.1"}, nil)
+	d.EXPECT().
+		Enumerate(&api.VolumeLocator{}, nil).
+		Return([]*api.Volume{
+			attached("vol1", "node1", "/mnt/vol1"),
+			attached("vol2", "10.0.0.1"),
+			attached("vol3", "node2"),
+			{Id: "vol4", State: api.VolumeState_VOLUME_STATE_DETACHED},
+		}, nil)
+	gomock.InOrder(
+		d.EXPECT().
+			Unmount("vol1", "/mnt/vol1", nil).
+			Return(nil),
+		d.EXPECT().
+			Detach("vol1", gomock.Any()).
+			Return(nil),
+	)
+	d.EXPECT().
+		Detach("vol2", gomock.Any()).
+		Return(volume.ErrNotSupported)
+
+	resp, err := s.EnterMaintenance(ctx, &api.SdkNodeEnterMaintenanceRequest{NodeId: "node1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vol1"}, resp.GetDetachedVolumeIds())
+	assert.Equal(t, []string{"vol2"}, resp.GetAttachedVolumeIds())
+
+	c.EXPECT().
+		EnterMaintenance("node1").
+		Return(cluster.ErrNodeDecommissioned)
+	_, err = s.EnterMaintenance(ctx, &api.SdkNodeEnterMaintenanceRequest{NodeId: "node1"})
+	assert.Equal(t, codes.FailedPrecondition, grpc.Code(err))
+}
+
+func TestSdkNodeEnterMaintenanceInProgress(t *testing.T) {
+	s, c, _, mc := newTestNodeServer()
+	defer mc.Finish()
+
+	// An operation in progress is waited for
+	assert.NoError(t, nodeMaintenance.begin(nil))
+	c.EXPECT().
+		EnterMaintenance("node1").
+		Return(nil)
+	_, err := s.EnterMaintenance(context.Background(), &api.SdkNodeEnterMaintenanceRequest{
+		NodeId:         "node1",
+		TimeoutSeconds: 1,
+	})
+	assert.Equal(t, codes.DeadlineExceeded, grpc.Code(err))
+	nodeMaintenance.end()
+
+	// No operation starts on a node in maintenance
+	c.EXPECT().
+		NodeStatus().
+		Return(api.Status_STATUS_MAINTENANCE, nil)
+	err = nodeMaintenance.begin(c)
+	assert.Equal(t, codes.FailedPrecondition, grpc.Code(err))
+}
+
+func TestSdkNodeExitMaintenance(t *testing.T) {
+	s, c, _, mc := newTestNodeServer()
+	defer mc.Finish()
+	ctx := context.Background()
+
+	c.EXPECT().
+		ExitMaintenance("node1").
+		Return(nil)
+	_, err := s.ExitMaintenance(ctx, &api.SdkNodeExitMaintenanceRequest{NodeId: "node1"})
+	assert.NoError(t, err)
+
+	c.EXPECT().
+		ExitMaintenance("node1").
+		Return(cluster.ErrNodeNotFound)
+	_, err = s.ExitMaintenance(ctx, &api.SdkNodeExitMaintenanceRequest{NodeId: "node1"})
+	assert.Equal(t, codes.NotFound, grpc.Code(err))
+}
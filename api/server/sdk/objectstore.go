@@ -22,6 +22,7 @@ import (
 
 	"github.com/libopenstorage/openstorage/api"
 	"github.com/libopenstorage/openstorage/cluster"
+	"github.com/libopenstorage/openstorage/objectstore"
 	"github.com/portworx/kvdb"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -101,6 +102,8 @@ func (s *ObjectstoreServer) Update(
 				codes.NotFound,
 				"Id %s not found",
 				req.GetObjectstoreId())
+		} else if err == objectstore.ErrObjectStoreServedElsewhere {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
 		}
 		return nil, status.Errorf(
 			codes.Internal,
@@ -121,7 +124,9 @@ func (s *ObjectstoreServer) Delete(
 	}
 
 	err := s.cluster().ObjectStoreDelete(req.GetObjectstoreId())
-	if err != nil && err != kvdb.ErrNotFound {
+	if err == objectstore.ErrObjectStoreServedElsewhere {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	} else if err != nil && err != kvdb.ErrNotFound {
 		return nil, status.Errorf(
 			codes.Internal,
 			"Failed to delete objectstore: %v",
@@ -0,0 +1,52 @@
+/*
+Package sdk is the gRPC implementation of the SDK gRPC server
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sdk
+
+import (
+	"context"
+
+	sdk_auth "github.com/libopenstorage/openstorage-sdk-auth/pkg/auth"
+
+	"github.com/libopenstorage/openstorage/pkg/role"
+)
+
+// Privileged operations of the services. The roles are verified against
+// them like against gRPC methods, so only the roles with a rule matching
+// both the service and the operation, such as system.admin, may run them.
+// None of the operations match the rules of system.view or system.user.
+const (
+	// privilegeConfigSecrets allows seeing and changing the secrets in the
+	// configuration
+	privilegeConfigSecrets = "/openstorage.api.OpenStorageConfig/AccessSecrets"
+	// privilegeChaos allows using the chaos service
+	privilegeChaos = "/openstorage.api.OpenStorageChaos/ManageChaos"
+	// privilegeForceDecommission allows forcing the decommission of a node
+	privilegeForceDecommission = "/openstorage.api.OpenStorageNode/ForceDecommission"
+)
+
+// hasPrivilege returns true if the role of the caller may run the
+// privileged operation. Without authentication every caller can.
+func hasPrivilege(ctx context.Context, roles role.RoleManager, operation string) bool {
+	claims, ok := ctx.Value(InterceptorContextTokenKey).(*sdk_auth.Claims)
+	if !ok {
+		return true
+	}
+	if roles == nil {
+		return false
+	}
+	return roles.Verify(ctx, claims.Role, operation) == nil
+}
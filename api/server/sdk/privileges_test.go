@@ -0,0 +1,87 @@
+/*
+Package sdk is the gRPC implementation of the SDK gRPC server
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sdk
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/portworx/kvdb"
+	"github.com/portworx/kvdb/mem"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/role"
+)
+
+var (
+	testRoles     *role.SdkRoleManager
+	testRolesOnce sync.Once
+)
+
+// testRoleManager returns a role manager with the default roles for the
+// servers tested without a gRPC server
+func testRoleManager() role.RoleManager {
+	testRolesOnce.Do(func() {
+		kv, err := kvdb.New(mem.Name, "sdk_roles_test", []string{}, nil, logrus.Panicf)
+		if err != nil {
+			logrus.Panicf("Failed to initialize KVDB: %v", err)
+		}
+		testRoles, err = role.NewSdkRoleManager(kv)
+		if err != nil {
+			logrus.Panicf("Failed to create role manager: %v", err)
+		}
+	})
+	return testRoles
+}
+
+func TestHasPrivilege(t *testing.T) {
+	roles := testRoleManager()
+	_, err := roles.Create(context.Background(), &api.SdkRoleCreateRequest{
+		Role: &api.SdkRole{
+			Name: "configadmin",
+			Rules: []*api.SdkRule{
+				{
+					Services: []string{"config"},
+					Apis:     []string{"*"},
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	// Without authentication every caller is privileged
+	assert.True(t, hasPrivilege(context.Background(), nil, privilegeConfigSecrets))
+
+	for _, operation := range []string{
+		privilegeConfigSecrets,
+		privilegeChaos,
+		privilegeForceDecommission,
+	} {
+		assert.True(t, hasPrivilege(contextWithRole("system.admin"), roles, operation), operation)
+		assert.False(t, hasPrivilege(contextWithRole("system.view"), roles, operation), operation)
+		assert.False(t, hasPrivilege(contextWithRole("system.user"), roles, operation), operation)
+		assert.False(t, hasPrivilege(contextWithRole("unknown"), roles, operation), operation)
+		assert.False(t, hasPrivilege(contextWithRole("system.admin"), nil, operation), operation)
+	}
+
+	// Custom roles get the privileges of the services they have access to
+	assert.True(t, hasPrivilege(contextWithRole("configadmin"), roles, privilegeConfigSecrets))
+	assert.False(t, hasPrivilege(contextWithRole("configadmin"), roles, privilegeChaos))
+}
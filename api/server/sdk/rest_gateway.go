@@ -138,6 +138,9 @@ func (s *sdkRestGateway) restServerSetupHandlers() (*http.ServeMux, error) {
 		api.RegisterOpenStorageClusterPairHandler,
 		api.RegisterOpenStorageMigrateHandler,
 		api.RegisterOpenStorageRoleHandler,
+		api.RegisterOpenStorageSecretsHandler,
+		api.RegisterOpenStorageConfigHandler,
+		api.RegisterOpenStorageChaosHandler,
 	}
 
 	// Register the REST Gateway handlers
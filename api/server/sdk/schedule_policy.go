@@ -22,6 +22,8 @@ import (
 
 	"github.com/libopenstorage/openstorage/api"
 	"github.com/libopenstorage/openstorage/cluster"
+	"github.com/libopenstorage/openstorage/pkg/sched"
+	"github.com/libopenstorage/openstorage/schedpolicy"
 	"github.com/portworx/kvdb"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -61,6 +63,9 @@ func (s *SchedulePolicyServer) Create(
 
 	err = s.cluster().SchedPolicyCreate(req.GetSchedulePolicy().GetName(), string(out))
 	if err != nil {
+		if _, ok := err.(*schedpolicy.InvalidPolicyError); ok {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 		return nil, status.Errorf(
 			codes.Internal,
 			"failed to create schedule policy: %v",
@@ -99,6 +104,8 @@ func (s *SchedulePolicyServer) Update(
 				codes.NotFound,
 				"Schedule policy %s not found",
 				req.GetSchedulePolicy().GetName())
+		} else if _, ok := err.(*schedpolicy.InvalidPolicyError); ok {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
 		return nil, status.Errorf(
 			codes.Internal,
@@ -122,8 +129,18 @@ func (s *SchedulePolicyServer) Delete(
 		return nil, status.Error(codes.InvalidArgument, "Must supply Schedule name")
 	}
 
-	err := s.cluster().SchedPolicyDelete(req.GetName())
-	if err != nil && err != kvdb.ErrNotFound {
+	var err error
+	if req.GetForce() {
+		err = s.cluster().SchedPolicyForceDelete(req.GetName())
+	} else {
+		err = s.cluster().SchedPolicyDelete(req.GetName())
+	}
+	if _, ok := err.(*schedpolicy.PolicyInUseError); ok {
+		return nil, status.Errorf(
+			codes.FailedPrecondition,
+			"%v. Use force to delete it anyway",
+			err)
+	} else if err != nil && err != kvdb.ErrNotFound {
 		return nil, status.Errorf(
 			codes.Internal,
 			"Failed to delete schedule policy: %v",
@@ -207,3 +224,25 @@ func (s *SchedulePolicyServer) Inspect(
 
 	return &api.SdkSchedulePolicyInspectResponse{Policy: sdkpolicy}, err
 }
+
+// schedulePolicyNames returns the names in the policy tags of schedule
+func schedulePolicyNames(schedule string) []string {
+	_, policies, err := sched.ParseScheduleAndPolicies(schedule)
+	if err != nil || policies == nil {
+		return nil
+	}
+	return policies.Names
+}
+
+// checkSchedulePolicies returns an error if any of the policies does not exist
+func checkSchedulePolicies(c cluster.Cluster, names []string) error {
+	for _, name := range names {
+		if _, err := c.SchedPolicyGet(name); err != nil {
+			return status.Errorf(
+				codes.Aborted,
+				"Error accessing schedule policy %s: %v",
+				name, err)
+		}
+	}
+	return nil
+}
@@ -23,7 +23,9 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/kubernetes-csi/csi-test/utils"
 	"github.com/libopenstorage/openstorage/api"
+	mockcluster "github.com/libopenstorage/openstorage/cluster/mock"
 	"github.com/libopenstorage/openstorage/pkg/sched"
 	"github.com/libopenstorage/openstorage/schedpolicy"
 	"github.com/stretchr/testify/assert"
@@ -426,6 +428,66 @@ func TestSdkSchedulePolicyDeleteFailed(t *testing.T) {
 	assert.Contains(t, serverError.Message(), "Failed to delete schedule policy")
 }
 
+func TestSdkSchedulePolicyDeleteInUse(t *testing.T) {
+	mc := gomock.NewController(&utils.SafeGoroutineTester{})
+	defer mc.Finish()
+	c := mockcluster.NewMockCluster(mc)
+	s := &SchedulePolicyServer{server: &secretsTestAccessor{c: c}}
+
+	name := "dummy-schedule-name"
+	c.EXPECT().
+		SchedPolicyDelete(name).
+		Return(&schedpolicy.PolicyInUseError{
+			Name: name,
+			References: []schedpolicy.Reference{
+				{Type: schedpolicy.ReferenceVolume, ID: "vol1"},
+			},
+		})
+	_, err := s.Delete(context.Background(), &api.SdkSchedulePolicyDeleteRequest{
+		Name: name,
+	})
+	serverError, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.FailedPrecondition, serverError.Code())
+	assert.Contains(t, serverError.Message(), "volume vol1")
+
+	c.EXPECT().
+		SchedPolicyForceDelete(name).
+		Return(nil)
+	_, err = s.Delete(context.Background(), &api.SdkSchedulePolicyDeleteRequest{
+		Name:  name,
+		Force: true,
+	})
+	assert.NoError(t, err)
+}
+
+func TestSdkSchedulePolicyCreateInvalid(t *testing.T) {
+	mc := gomock.NewController(&utils.SafeGoroutineTester{})
+	defer mc.Finish()
+	c := mockcluster.NewMockCluster(mc)
+	s := &SchedulePolicyServer{server: &secretsTestAccessor{c: c}}
+
+	c.EXPECT().
+		SchedPolicyCreate("bad/name", gomock.Any()).
+		Return(&schedpolicy.InvalidPolicyError{Name: "bad/name", Reason: "invalid name"})
+	_, err := s.Create(context.Background(), &api.SdkSchedulePolicyCreateRequest{
+		SchedulePolicy: &api.SdkSchedulePolicy{
+			Name: "bad/name",
+			Schedules: []*api.SdkSchedulePolicyInterval{
+				{
+					Retain: 1,
+					PeriodType: &api.SdkSchedulePolicyInterval_Daily{
+						Daily: &api.SdkSchedulePolicyIntervalDaily{Hour: 1},
+					},
+				},
+			},
+		},
+	})
+	serverError, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, serverError.Code())
+}
+
 func TestSdkSchedulePolicyDeleteBadArgument(t *testing.T) {
 
 	// Create server and client connection
@@ -90,6 +90,12 @@ func newTestServer(t *testing.T) *testServer {
 	tester.c = mockcluster.NewMockCluster(tester.mc)
 	tester.a = mockalerts.NewMockFilterDeleter(tester.mc)
 
+	// Volume placement and attachments check if the node is in maintenance
+	tester.c.EXPECT().
+		NodeStatus().
+		Return(api.Status_STATUS_OK, nil).
+		AnyTimes()
+
 	setupMockDriver(tester, t)
 
 	var err error
@@ -0,0 +1,282 @@
+/*
+Package sdk is the gRPC implementation of the SDK gRPC server
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	sdk_auth "github.com/libopenstorage/openstorage-sdk-auth/pkg/auth"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/cluster"
+	"github.com/libopenstorage/openstorage/secrets"
+)
+
+// SecretsServer is an implementation of the gRPC OpenStorageSecrets interface
+type SecretsServer struct {
+	server serverAccessor
+	// auditLog records every access to the secrets. Values of secrets are
+	// never recorded.
+	auditLog *logrus.Logger
+}
+
+// newSecretsServer returns a SecretsServer recording the accesses to the
+// secrets in auditOutput, which may be nil to not record them.
+func newSecretsServer(server serverAccessor, auditOutput io.Writer) *SecretsServer {
+	if auditOutput == nil {
+		auditOutput = ioutil.Discard
+	}
+	auditLog := logrus.New()
+	auditLog.Out = auditOutput
+	return &SecretsServer{
+		server:   server,
+		auditLog: auditLog,
+	}
+}
+
+func (s *SecretsServer) cluster() cluster.Cluster {
+	return s.server.cluster()
+}
+
+// audit records an access to the secrets and its result
+func (s *SecretsServer) audit(ctx context.Context, method, secretID string, err error) {
+	fields := logrus.Fields{
+		"method": "/openstorage.api.OpenStorageSecrets/" + method,
+	}
+	if len(secretID) != 0 {
+		fields["secret_id"] = secretID
+	}
+	if claims, ok := ctx.Value(InterceptorContextTokenKey).(*sdk_auth.Claims); ok {
+		fields["name"] = claims.Name
+		fields["email"] = claims.Email
+		fields["role"] = claims.Role
+	}
+	logger := s.auditLog.WithFields(fields)
+	if err != nil {
+		logger.Infof("Secrets access failed: %v", err)
+	} else {
+		logger.Info("Secrets access succeeded")
+	}
+}
+
+// secretsError converts an error of the secret store to a gRPC status
+func secretsError(err error, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	switch err {
+	case secrets.ErrNotImplemented:
+		return status.Errorf(codes.Unimplemented, "%s: %v", msg, err)
+	case secrets.ErrNotAuthenticated, secrets.ErrSecretStoreConfigured, secrets.ErrMasterKeyNotShared,
+		secrets.ErrNodeLocalLogin:
+		return status.Errorf(codes.FailedPrecondition, "%s: %v", msg, err)
+	case secrets.ErrInvalidSecretId:
+		return status.Errorf(codes.NotFound, "%s: %v", msg, err)
+	case secrets.ErrSecretExists:
+		return status.Errorf(codes.AlreadyExists, "%s: %v", msg, err)
+	case secrets.ErrKeyEmpty, secrets.ErrEmptySecretData, secrets.ErrInvalidSecretPath:
+		return status.Errorf(codes.InvalidArgument, "%s: %v", msg, err)
+	}
+	return status.Errorf(codes.Internal, "%s: %v", msg, err)
+}
+
+// secretString returns a value of the secret store as a string
+func secretString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Login creates a session with a secret store
+func (s *SecretsServer) Login(
+	ctx context.Context,
+	req *api.SdkSecretsLoginRequest,
+) (resp *api.SdkSecretsLoginResponse, err error) {
+	defer func() { s.audit(ctx, "Login", "", err) }()
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+	if len(req.GetSecretType()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a secret type")
+	}
+
+	if err := s.cluster().SecretLogin(req.GetSecretType(), req.GetSecretConfig()); err != nil {
+		return nil, secretsError(err, "Failed to login to secret store %s", req.GetSecretType())
+	}
+	return &api.SdkSecretsLoginResponse{}, nil
+}
+
+// CheckLogin returns an error if there is no valid session with a secret store
+func (s *SecretsServer) CheckLogin(
+	ctx context.Context,
+	req *api.SdkSecretsCheckLoginRequest,
+) (resp *api.SdkSecretsCheckLoginResponse, err error) {
+	defer func() { s.audit(ctx, "CheckLogin", "", err) }()
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+
+	if err := s.cluster().SecretCheckLogin(); err != nil {
+		return nil, secretsError(err, "Not logged in to a secret store")
+	}
+	return &api.SdkSecretsCheckLoginResponse{}, nil
+}
+
+// Set stores a value for a secret id
+func (s *SecretsServer) Set(
+	ctx context.Context,
+	req *api.SdkSecretsSetRequest,
+) (resp *api.SdkSecretsSetResponse, err error) {
+	defer func() { s.audit(ctx, "Set", req.GetSecretId(), err) }()
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+	if len(req.GetSecretId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a secret id")
+	}
+	if len(req.GetValue()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a value")
+	}
+
+	if err := s.cluster().SecretSet(req.GetSecretId(), req.GetValue()); err != nil {
+		return nil, secretsError(err, "Failed to set secret %s", req.GetSecretId())
+	}
+	return &api.SdkSecretsSetResponse{}, nil
+}
+
+// Get returns the value of a secret
+func (s *SecretsServer) Get(
+	ctx context.Context,
+	req *api.SdkSecretsGetRequest,
+) (resp *api.SdkSecretsGetResponse, err error) {
+	defer func() { s.audit(ctx, "Get", req.GetSecretId(), err) }()
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+	if len(req.GetSecretId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a secret id")
+	}
+
+	value, err := s.cluster().SecretGet(req.GetSecretId())
+	if err != nil {
+		return nil, secretsError(err, "Failed to get secret %s", req.GetSecretId())
+	}
+	str, err := secretString(value)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to encode secret %s: %v", req.GetSecretId(), err)
+	}
+	return &api.SdkSecretsGetResponse{
+		Value: str,
+	}, nil
+}
+
+// Delete removes a secret from the secret store
+func (s *SecretsServer) Delete(
+	ctx context.Context,
+	req *api.SdkSecretsDeleteRequest,
+) (resp *api.SdkSecretsDeleteResponse, err error) {
+	defer func() { s.audit(ctx, "Delete", req.GetSecretId(), err) }()
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+	if len(req.GetSecretId()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a secret id")
+	}
+
+	err = s.cluster().SecretDelete(req.GetSecretId())
+	if err != nil && err != secrets.ErrInvalidSecretId {
+		return nil, secretsError(err, "Failed to delete secret %s", req.GetSecretId())
+	}
+	return &api.SdkSecretsDeleteResponse{}, nil
+}
+
+// SetDefaultSecretKey sets the cluster wide secret key
+func (s *SecretsServer) SetDefaultSecretKey(
+	ctx context.Context,
+	req *api.SdkSecretsSetDefaultSecretKeyRequest,
+) (resp *api.SdkSecretsSetDefaultSecretKeyResponse, err error) {
+	defer func() { s.audit(ctx, "SetDefaultSecretKey", secrets.DefaultSecretKey, err) }()
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+	if len(req.GetSecretKey()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must supply a secret key")
+	}
+
+	if err := s.cluster().SecretSetDefaultSecretKey(req.GetSecretKey(), req.GetOverride()); err != nil {
+		return nil, secretsError(err, "Failed to set the cluster secret key")
+	}
+	return &api.SdkSecretsSetDefaultSecretKeyResponse{}, nil
+}
+
+// GetDefaultSecretKey returns the cluster wide secret key
+func (s *SecretsServer) GetDefaultSecretKey(
+	ctx context.Context,
+	req *api.SdkSecretsGetDefaultSecretKeyRequest,
+) (resp *api.SdkSecretsGetDefaultSecretKeyResponse, err error) {
+	defer func() { s.audit(ctx, "GetDefaultSecretKey", secrets.DefaultSecretKey, err) }()
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+
+	value, err := s.cluster().SecretGetDefaultSecretKey()
+	if err != nil {
+		return nil, secretsError(err, "Failed to get the cluster secret key")
+	}
+	key, err := secretString(value)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to encode the cluster secret key: %v", err)
+	}
+	return &api.SdkSecretsGetDefaultSecretKeyResponse{
+		SecretKey: key,
+	}, nil
+}
+
+// RotateMasterKey creates a new master key and wraps the keys of the
+// secrets with it
+func (s *SecretsServer) RotateMasterKey(
+	ctx context.Context,
+	req *api.SdkSecretsRotateMasterKeyRequest,
+) (resp *api.SdkSecretsRotateMasterKeyResponse, err error) {
+	defer func() { s.audit(ctx, "RotateMasterKey", "", err) }()
+	if s.cluster() == nil {
+		return nil, status.Error(codes.Unavailable, "Resource has not been initialized")
+	}
+
+	id, err := s.cluster().SecretRotateMasterKey()
+	if err != nil {
+		return nil, secretsError(err, "Failed to rotate the master key")
+	}
+	return &api.SdkSecretsRotateMasterKeyResponse{
+		MasterKeyId: id,
+	}, nil
+}
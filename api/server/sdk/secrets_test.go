@@ -0,0 +1,217 @@
+/*
+Package sdk is the gRPC implementation of the SDK gRPC server
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kubernetes-csi/csi-test/utils"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/libopenstorage/openstorage/alerts"
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/cluster"
+	mockcluster "github.com/libopenstorage/openstorage/cluster/mock"
+	"github.com/libopenstorage/openstorage/pkg/role"
+	"github.com/libopenstorage/openstorage/secrets"
+	"github.com/libopenstorage/openstorage/volume"
+)
+
+func TestSdkSecretsLogin(t *testing.T) {
+	// Create server and client connection
+	s := newTestServer(t)
+	defer s.Stop()
+
+	config := map[string]string{"VAULT_ADDR": "http://127.0.0.1:8200"}
+	s.MockCluster().
+		EXPECT().
+		SecretLogin(secrets.TypeVault, config).
+		Return(nil)
+	s.MockCluster().
+		EXPECT().
+		SecretCheckLogin().
+		Return(secrets.ErrNotAuthenticated)
+
+	// Setup client
+	c := api.NewOpenStorageSecretsClient(s.Conn())
+
+	_, err := c.Login(context.Background(), &api.SdkSecretsLoginRequest{})
+	assert.Error(t, err)
+	serverError, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, serverError.Code(), codes.InvalidArgument)
+
+	_, err = c.Login(context.Background(), &api.SdkSecretsLoginRequest{
+		SecretType:   secrets.TypeVault,
+		SecretConfig: config,
+	})
+	assert.NoError(t, err)
+
+	_, err = c.CheckLogin(context.Background(), &api.SdkSecretsCheckLoginRequest{})
+	assert.Error(t, err)
+	serverError, ok = status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, serverError.Code(), codes.FailedPrecondition)
+}
+
+func TestSdkSecretsSetGetDelete(t *testing.T) {
+	// Create server and client connection
+	s := newTestServer(t)
+	defer s.Stop()
+
+	id := "mysecret"
+	s.MockCluster().
+		EXPECT().
+		SecretSet(id, "value").
+		Return(nil)
+	s.MockCluster().
+		EXPECT().
+		SecretGet(id).
+		Return(map[string]interface{}{"key": "value"}, nil)
+	s.MockCluster().
+		EXPECT().
+		SecretDelete(id).
+		Return(nil)
+	s.MockCluster().
+		EXPECT().
+		SecretGet("missing").
+		Return(nil, secrets.ErrInvalidSecretId)
+
+	// Setup client
+	c := api.NewOpenStorageSecretsClient(s.Conn())
+
+	_, err := c.Set(context.Background(), &api.SdkSecretsSetRequest{SecretId: id})
+	assert.Error(t, err)
+	serverError, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, serverError.Code(), codes.InvalidArgument)
+
+	_, err = c.Set(context.Background(), &api.SdkSecretsSetRequest{
+		SecretId: id,
+		Value:    "value",
+	})
+	assert.NoError(t, err)
+
+	r, err := c.Get(context.Background(), &api.SdkSecretsGetRequest{SecretId: id})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"key":"value"}`, r.GetValue())
+
+	_, err = c.Get(context.Background(), &api.SdkSecretsGetRequest{SecretId: "missing"})
+	assert.Error(t, err)
+	serverError, ok = status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, serverError.Code(), codes.NotFound)
+
+	_, err = c.Delete(context.Background(), &api.SdkSecretsDeleteRequest{SecretId: id})
+	assert.NoError(t, err)
+}
+
+func TestSdkSecretsDefaultSecretKey(t *testing.T) {
+	// Create server and client connection
+	s := newTestServer(t)
+	defer s.Stop()
+
+	s.MockCluster().
+		EXPECT().
+		SecretSetDefaultSecretKey("clusterkey", true).
+		Return(nil)
+	s.MockCluster().
+		EXPECT().
+		SecretGetDefaultSecretKey().
+		Return("clusterkey", nil)
+
+	// Setup client
+	c := api.NewOpenStorageSecretsClient(s.Conn())
+
+	_, err := c.SetDefaultSecretKey(context.Background(), &api.SdkSecretsSetDefaultSecretKeyRequest{
+		SecretKey: "clusterkey",
+		Override:  true,
+	})
+	assert.NoError(t, err)
+
+	r, err := c.GetDefaultSecretKey(context.Background(), &api.SdkSecretsGetDefaultSecretKeyRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "clusterkey", r.GetSecretKey())
+}
+
+type secretsTestAccessor struct {
+	c cluster.Cluster
+}
+
+func (a *secretsTestAccessor) alert() alerts.FilterDeleter   { return nil }
+func (a *secretsTestAccessor) cluster() cluster.Cluster      { return a.c }
+func (a *secretsTestAccessor) driver() volume.VolumeDriver   { return nil }
+func (a *secretsTestAccessor) roleManager() role.RoleManager { return testRoleManager() }
+
+func TestSdkSecretsAudit(t *testing.T) {
+	mc := gomock.NewController(&utils.SafeGoroutineTester{})
+	defer mc.Finish()
+	c := mockcluster.NewMockCluster(mc)
+	c.EXPECT().
+		SecretSet("mysecret", "topsecretvalue").
+		Return(nil)
+	c.EXPECT().
+		SecretGet("mysecret").
+		Return("topsecretvalue", nil)
+
+	var audit bytes.Buffer
+	s := newSecretsServer(&secretsTestAccessor{c: c}, &audit)
+	_, err := s.Set(context.Background(), &api.SdkSecretsSetRequest{
+		SecretId: "mysecret",
+		Value:    "topsecretvalue",
+	})
+	assert.NoError(t, err)
+	r, err := s.Get(context.Background(), &api.SdkSecretsGetRequest{SecretId: "mysecret"})
+	assert.NoError(t, err)
+	assert.Equal(t, "topsecretvalue", r.GetValue())
+	_, err = s.Get(context.Background(), &api.SdkSecretsGetRequest{})
+	assert.Error(t, err)
+
+	log := audit.String()
+	assert.Contains(t, log, "OpenStorageSecrets/Set")
+	assert.Contains(t, log, "OpenStorageSecrets/Get")
+	assert.Contains(t, log, "secret_id=mysecret")
+	assert.Contains(t, log, "Secrets access failed")
+	assert.NotContains(t, log, "topsecretvalue")
+}
+
+func TestSdkSecretsRotateMasterKey(t *testing.T) {
+	mc := gomock.NewController(&utils.SafeGoroutineTester{})
+	defer mc.Finish()
+	c := mockcluster.NewMockCluster(mc)
+	s := newSecretsServer(&secretsTestAccessor{c: c}, nil)
+
+	c.EXPECT().
+		SecretRotateMasterKey().
+		Return("newkey", nil)
+	r, err := s.RotateMasterKey(context.Background(), &api.SdkSecretsRotateMasterKeyRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "newkey", r.GetMasterKeyId())
+
+	c.EXPECT().
+		SecretRotateMasterKey().
+		Return("", secrets.ErrNotImplemented)
+	_, err = s.RotateMasterKey(context.Background(), &api.SdkSecretsRotateMasterKeyRequest{})
+	serverError, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.Unimplemented, serverError.Code())
+}
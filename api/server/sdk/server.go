@@ -108,6 +108,7 @@ type serverAccessor interface {
 	alert() alerts.FilterDeleter
 	cluster() cluster.Cluster
 	driver() volume.VolumeDriver
+	roleManager() role.RoleManager
 }
 
 type logger struct {
@@ -143,6 +144,9 @@ type sdkGrpcServer struct {
 	cloudBackupServer    *CloudBackupServer
 	credentialServer     *CredentialServer
 	identityServer       *IdentityServer
+	secretsServer        *SecretsServer
+	configServer         *ConfigServer
+	chaosServer          *ChaosServer
 	roleServer           role.RoleManager
 	alertsServer         api.OpenStorageAlertsServer
 }
@@ -365,6 +369,13 @@ func newSdkGrpcServer(config *ServerConfig) (*sdkGrpcServer, error) {
 	s.clusterPairServer = &ClusterPairServer{
 		server: s,
 	}
+	s.secretsServer = newSecretsServer(s, config.AuditOutput)
+	s.configServer = &ConfigServer{
+		server: s,
+	}
+	s.chaosServer = &ChaosServer{
+		server: s,
+	}
 	s.roleServer = config.Role
 
 	return s, nil
@@ -395,12 +406,28 @@ func (s *sdkGrpcServer) Start() error {
 				grpc_auth.UnaryServerInterceptor(s.auth),
 				s.authorizationServerInterceptor,
 				s.loggerServerInterceptor,
+				s.chaosServerInterceptor,
+				s.maintenanceServerInterceptor,
+			)))
+		opts = append(opts, grpc.StreamInterceptor(
+			grpc_middleware.ChainStreamServer(
+				grpc_auth.StreamServerInterceptor(s.auth),
+				s.authorizationServerStreamInterceptor,
+				s.loggerServerStreamInterceptor,
+				s.chaosServerStreamInterceptor,
 			)))
 	} else {
 		opts = append(opts, grpc.UnaryInterceptor(
 			grpc_middleware.ChainUnaryServer(
 				s.rwlockIntercepter,
 				s.loggerServerInterceptor,
+				s.chaosServerInterceptor,
+				s.maintenanceServerInterceptor,
+			)))
+		opts = append(opts, grpc.StreamInterceptor(
+			grpc_middleware.ChainStreamServer(
+				s.loggerServerStreamInterceptor,
+				s.chaosServerStreamInterceptor,
 			)))
 	}
 
@@ -420,10 +447,15 @@ func (s *sdkGrpcServer) Start() error {
 		api.RegisterOpenStorageMountAttachServer(grpcServer, s.volumeServer)
 		api.RegisterOpenStorageAlertsServer(grpcServer, s.alertsServer)
 		api.RegisterOpenStorageClusterPairServer(grpcServer, s.clusterPairServer)
+		api.RegisterOpenStorageSecretsServer(grpcServer, s.secretsServer)
+		api.RegisterOpenStorageConfigServer(grpcServer, s.configServer)
+		api.RegisterOpenStorageChaosServer(grpcServer, s.chaosServer)
 
 		if s.config.Role != nil {
 			api.RegisterOpenStorageRoleServer(grpcServer, s.roleServer)
 		}
+
+		addChaosPoints(grpcServer)
 		return grpcServer
 	})
 	if err != nil {
@@ -466,3 +498,7 @@ func (s *sdkGrpcServer) cluster() cluster.Cluster {
 func (s *sdkGrpcServer) alert() alerts.FilterDeleter {
 	return s.alertHandler
 }
+
+func (s *sdkGrpcServer) roleManager() role.RoleManager {
+	return s.roleServer
+}
@@ -19,6 +19,7 @@ package sdk
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"time"
 
 	sdk_auth "github.com/libopenstorage/openstorage-sdk-auth/pkg/auth"
@@ -29,6 +30,8 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	"github.com/libopenstorage/openstorage/volume"
 )
 
 // Keys to store data in gRPC context. Use these keys to retrieve
@@ -86,17 +89,90 @@ func (s *sdkGrpcServer) loggerServerInterceptor(
 	info *grpc.UnaryServerInfo,
 	handler grpc.UnaryHandler,
 ) (interface{}, error) {
+	var i interface{}
+	err := s.logCall(info.FullMethod, func() error {
+		var err error
+		i, err = handler(ctx, req)
+		return err
+	})
+	return i, err
+}
+
+func (s *sdkGrpcServer) loggerServerStreamInterceptor(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	return s.logCall(info.FullMethod, func() error {
+		return handler(srv, stream)
+	})
+}
+
+// chaosServerInterceptor triggers the chaos point of the called method
+func (s *sdkGrpcServer) chaosServerInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if err := chaosNow(info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// chaosServerStreamInterceptor triggers the chaos point of the called method
+func (s *sdkGrpcServer) chaosServerStreamInterceptor(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if err := chaosNow(info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// maintenanceServerInterceptor rejects the calls which place or attach
+// volumes on this node while it is in maintenance
+func (s *sdkGrpcServer) maintenanceServerInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if !maintenanceMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+	if err := nodeMaintenance.begin(s.cluster()); err != nil {
+		return nil, err
+	}
+	defer nodeMaintenance.end()
+	resp, err := handler(ctx, req)
+	// The volume drivers also check the maintenance of the nodes in the
+	// cluster database, which covers the calls from the other nodes
+	if err != nil && strings.Contains(err.Error(), volume.ErrNodeInMaintenance.Error()) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return resp, err
+}
+
+// logCall records the start, the duration and the result of a call in the
+// access log
+func (s *sdkGrpcServer) logCall(method string, call func() error) error {
 	reqid := uuid.New()
 	log := logrus.New()
 	log.Out = s.accessLogOutput
 	logger := log.WithFields(logrus.Fields{
-		"method": info.FullMethod,
+		"method": method,
 		"reqid":  reqid,
 	})
 
 	logger.Info("Start")
 	ts := time.Now()
-	i, err := handler(ctx, req)
+	err := call()
 	duration := time.Now().Sub(ts)
 	if err != nil {
 		logger.WithFields(logrus.Fields{"duration": duration}).Infof("Failed: %v", err)
@@ -104,7 +180,7 @@ func (s *sdkGrpcServer) loggerServerInterceptor(
 		logger.WithFields(logrus.Fields{"duration": duration}).Info("Successful")
 	}
 
-	return i, err
+	return err
 }
 
 func (s *sdkGrpcServer) authorizationServerInterceptor(
@@ -113,9 +189,30 @@ func (s *sdkGrpcServer) authorizationServerInterceptor(
 	info *grpc.UnaryServerInfo,
 	handler grpc.UnaryHandler,
 ) (interface{}, error) {
+	if err := s.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *sdkGrpcServer) authorizationServerStreamInterceptor(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if err := s.authorize(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// authorize verifies that the role in the token claims of the context can
+// call method, and records the decision in the audit log
+func (s *sdkGrpcServer) authorize(ctx context.Context, method string) error {
 	claims, ok := ctx.Value(InterceptorContextTokenKey).(*sdk_auth.Claims)
 	if !ok {
-		return nil, status.Errorf(codes.Internal, "Authorization called without token")
+		return status.Errorf(codes.Internal, "Authorization called without token")
 	}
 
 	// Setup auditor log
@@ -130,18 +227,18 @@ func (s *sdkGrpcServer) authorizationServerInterceptor(
 		"email":  claims.Email,
 		"role":   claims.Role,
 		"claims": string(claimsJSON),
-		"method": info.FullMethod,
+		"method": method,
 	})
 
 	// Authorize
-	if err := s.roleServer.Verify(ctx, claims.Role, info.FullMethod); err != nil {
+	if err := s.roleServer.Verify(ctx, claims.Role, method); err != nil {
 		logger.Infof("Access denied")
-		return nil, status.Errorf(
+		return status.Errorf(
 			codes.PermissionDenied,
 			"Access to %s denied: %v",
-			info.FullMethod, err)
+			method, err)
 	}
 
 	logger.Info("Authorized")
-	return handler(ctx, req)
+	return nil
 }
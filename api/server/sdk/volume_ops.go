@@ -126,6 +126,13 @@ func (s *VolumeServer) Create(
 		return nil, status.Errorf(codes.Internal, "Failed to get replicat set information: %v", err)
 	}
 
+	// Check the schedule policies used by the snapshot schedule, the
+	// driver tracks them
+	policies := schedulePolicyNames(spec.GetSnapshotSchedule())
+	if err := checkSchedulePolicies(s.cluster(), policies); err != nil {
+		return nil, err
+	}
+
 	// Create volume
 	id, err := s.create(locator, source, spec)
 	if err != nil {
@@ -323,6 +330,15 @@ func (s *VolumeServer) Update(
 	}
 	spec := s.mergeVolumeSpecs(resp.GetVolume().GetSpec(), req.GetSpec())
 
+	// Check the schedule policies used by a new snapshot schedule, the
+	// driver tracks them
+	if req.GetSpec().GetSnapshotScheduleOpt() != nil {
+		policies := schedulePolicyNames(spec.GetSnapshotSchedule())
+		if err := checkSchedulePolicies(s.cluster(), policies); err != nil {
+			return nil, err
+		}
+	}
+
 	// Send to driver
 	if err := s.driver().Set(req.GetVolumeId(), req.GetLocator(), spec); err != nil {
 		return nil, status.Errorf(codes.Internal, "Failed to update volume: %v", err)
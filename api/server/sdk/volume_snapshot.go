@@ -167,14 +167,8 @@ func (s *VolumeServer) SnapshotScheduleUpdate(
 	}
 
 	// Determine if they exist
-	for _, name := range req.GetSnapshotScheduleNames() {
-		_, err := s.cluster().SchedPolicyGet(name)
-		if err != nil {
-			return nil, status.Errorf(
-				codes.Aborted,
-				"Error accessing schedule policy %s: %v",
-				name, err)
-		}
+	if err := checkSchedulePolicies(s.cluster(), req.GetSnapshotScheduleNames()); err != nil {
+		return nil, err
 	}
 
 	// Get volume specification
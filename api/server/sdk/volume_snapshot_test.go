@@ -263,7 +263,7 @@ func TestSdkVolumeSnapshotScheduleUpdate(t *testing.T) {
 		EXPECT().
 		SchedPolicyGet("mypolicy").
 		Return(nil, nil).
-		Times(1)
+		Times(2)
 	s.MockDriver().
 		EXPECT().
 		Set(volid, nil, &api.VolumeSpec{
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/gorilla/mux"
+
 	clustermanager "github.com/libopenstorage/openstorage/cluster/manager"
 	"github.com/libopenstorage/openstorage/secrets"
 )
@@ -235,6 +237,47 @@ func (c *clusterApi) getSecret(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(secResp)
 }
 
+// swagger:operation DELETE /cluster/secrets/{id} secrets deleteSecret
+//
+// Delete secret
+//
+// This will remove the value/data stored against the key
+//
+// ---
+// produces:
+// - application/json
+// parameters:
+// - name: id
+//   in: path
+//   description: secret id/key to be removed
+//   type: string
+//   required: true
+// responses:
+//   '200':
+//     description: success
+func (c *clusterApi) deleteSecret(w http.ResponseWriter, r *http.Request) {
+	method := "deleteSecret"
+	secretID := mux.Vars(r)[secrets.SecretKey]
+
+	if secretID == "" {
+		c.sendError(c.name, method, w, "Missing secret ID", http.StatusBadRequest)
+		return
+	}
+
+	inst, err := clustermanager.Inst()
+	if err != nil {
+		c.sendError(c.name, method, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := inst.SecretDelete(secretID); err != nil {
+		c.sendError(c.name, method, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
 // swagger:operation GET /cluster/secrets/verify secrets secretLoginCheck
 //
 // Validates session with secret store
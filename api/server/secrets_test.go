@@ -348,3 +348,28 @@ func TestSecretLoginAccessDenied(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "Not authenticated")
 }
+
+func TestDeleteSuccess(t *testing.T) {
+
+	// Create a new global test cluster
+	ts, tc := testClusterServer(t)
+	defer ts.Close()
+	defer tc.Finish()
+
+	secretID := "testsecretkey"
+	// mock the cluster secrets response
+	tc.MockCluster().
+		EXPECT().
+		SecretDelete(secretID).
+		Return(nil)
+
+	// create a cluster client to make the REST call
+	c, err := clusterclient.NewClusterClient(ts.URL, "v1")
+	assert.NoError(t, err)
+
+	// make the REST call
+	restClient := clusterclient.ClusterManager(c)
+	err = restClient.SecretDelete(secretID)
+
+	assert.NoError(t, err)
+}
@@ -18,6 +18,7 @@ import (
 	sdkauth "github.com/libopenstorage/openstorage-sdk-auth/pkg/auth"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/libopenstorage/openstorage/api"
 	"github.com/libopenstorage/openstorage/api/server/sdk"
 	"github.com/libopenstorage/openstorage/cluster"
 	clustermanager "github.com/libopenstorage/openstorage/cluster/manager"
@@ -120,7 +121,14 @@ func newTestServerSdk(t *testing.T) *testServer {
 	// Add driver to registry
 	tester.mc = gomock.NewController(&utils.SafeGoroutineTester{})
 	tester.m = mockdriver.NewMockVolumeDriver(tester.mc)
-	tester.c = mockcluster.NewMockCluster(tester.mc)
+	mc := mockcluster.NewMockCluster(tester.mc)
+	tester.c = mc
+
+	// Volume placement and attachments check if the node is in maintenance
+	mc.EXPECT().
+		NodeStatus().
+		Return(api.Status_STATUS_OK, nil).
+		AnyTimes()
 
 	// Create a role manager
 	kv, err := kvdb.New(mem.Name, "role", []string{}, nil, logrus.Panicf)
@@ -1157,6 +1157,11 @@ func (vd *volAPI) Routes() []*Route {
 		{verb: "POST", path: backupPath("/schedgroup", volume.APIVersion), fn: vd.cloudBackupGroupSchedCreate},
 		{verb: "DELETE", path: backupPath("/sched", volume.APIVersion), fn: vd.cloudBackupSchedDelete},
 		{verb: "GET", path: backupPath("/sched", volume.APIVersion), fn: vd.cloudBackupSchedEnumerate},
+		{verb: "PUT", path: backupPath("/retention", volume.APIVersion), fn: vd.cloudBackupRetentionUpdate},
+		{verb: "GET", path: backupPath("/retention", volume.APIVersion), fn: vd.cloudBackupRetentionInspect},
+		{verb: "POST", path: backupPath("/verify", volume.APIVersion), fn: vd.cloudBackupVerify},
+		{verb: "PUT", path: backupPath("/scrub", volume.APIVersion), fn: vd.cloudBackupScrubUpdate},
+		{verb: "GET", path: backupPath("/scrub", volume.APIVersion), fn: vd.cloudBackupScrubInspect},
 		{verb: "POST", path: migratePath(api.OsdMigrateStartPath, volume.APIVersion), fn: vd.cloudMigrateStart},
 		{verb: "POST", path: migratePath(api.OsdMigrateCancelPath, volume.APIVersion), fn: vd.cloudMigrateCancel},
 		{verb: "GET", path: migratePath(api.OsdMigrateStatusPath, volume.APIVersion), fn: vd.cloudMigrateStatus},
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/codegangsta/cli"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/chaos"
+)
+
+type chaosClient struct {
+	client api.OpenStorageChaosClient
+	token  string
+}
+
+func (c *chaosClient) chaosOptions(context *cli.Context, fn string) {
+	c.client = api.NewOpenStorageChaosClient(sdkConnect(context, fn))
+	c.token = context.GlobalString("token")
+}
+
+// ctx returns the context of a call to the SDK, with the token if any
+func (c *chaosClient) ctx() context.Context {
+	return sdkContext(c.token)
+}
+
+func (c *chaosClient) list(context *cli.Context) {
+	fn := "list"
+	c.chaosOptions(context, fn)
+
+	resp, err := c.client.Enumerate(c.ctx(), &api.SdkChaosEnumerateRequest{
+		Pkg: context.String("pkg"),
+	})
+	if err != nil {
+		cmdError(context, fn, err)
+		return
+	}
+	if context.GlobalBool("json") {
+		cmdOutputProto(resp, context.GlobalBool("raw"))
+		return
+	}
+
+	if !resp.GetActivated() {
+		fmt.Println("Chaos is not activated on this server")
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 12, 12, 1, ' ', 0)
+	fmt.Fprintln(w, "POINT\t ENABLED\t ACTIONS\t WHEN\t CALLS\t TRIGGERED\t DESCRIPTION")
+	for _, p := range resp.GetPoints() {
+		actions := ""
+		for i, a := range p.GetActions() {
+			if i != 0 {
+				actions += "|"
+			}
+			actions += chaosActionName(a)
+		}
+		fmt.Fprintln(w, p.GetId(), "\t", p.GetEnabled(), "\t", actions, "\t",
+			chaosWhenName(p.GetWhen()), "\t", p.GetCalls(), "\t",
+			p.GetTriggered(), "\t", p.GetDescription())
+	}
+	w.Flush()
+}
+
+func (c *chaosClient) enable(context *cli.Context) {
+	fn := "enable"
+	if len(context.Args()) != 1 {
+		missingParameter(context, fn, "point", "Invalid number of arguments")
+		return
+	}
+	point := chaos.ScenarioPoint{
+		Point:       context.Args()[0],
+		Action:      context.String("action"),
+		When:        context.String("when"),
+		Probability: context.Float64("probability"),
+		Count:       context.Int("count"),
+		Delay:       context.String("delay"),
+	}
+	req, err := point.SdkEnableRequest()
+	if err != nil {
+		badParameter(context, fn, "point", err.Error())
+		return
+	}
+	c.chaosOptions(context, fn)
+
+	if _, err := c.client.Enable(c.ctx(), req); err != nil {
+		cmdError(context, fn, err)
+		return
+	}
+	fmtOutput(context, &Format{UUID: []string{point.Point}})
+}
+
+func (c *chaosClient) disable(context *cli.Context) {
+	fn := "disable"
+	if len(context.Args()) != 1 {
+		missingParameter(context, fn, "point", "Invalid number of arguments")
+		return
+	}
+	c.chaosOptions(context, fn)
+
+	point := context.Args()[0]
+	if _, err := c.client.Disable(c.ctx(), &api.SdkChaosDisableRequest{
+		Point: point,
+	}); err != nil {
+		cmdError(context, fn, err)
+		return
+	}
+	fmtOutput(context, &Format{UUID: []string{point}})
+}
+
+func (c *chaosClient) reset(context *cli.Context) {
+	fn := "reset"
+	c.chaosOptions(context, fn)
+
+	if _, err := c.client.DisableAll(c.ctx(), &api.SdkChaosDisableAllRequest{}); err != nil {
+		cmdError(context, fn, err)
+		return
+	}
+	fmtOutput(context, &Format{Result: "All chaos points disabled"})
+}
+
+func chaosActionName(a api.SdkChaosAction) string {
+	switch a {
+	case api.SdkChaosAction_SdkChaosActionError:
+		return "error"
+	case api.SdkChaosAction_SdkChaosActionPanic:
+		return "crash"
+	case api.SdkChaosAction_SdkChaosActionDelay:
+		return "delay"
+	}
+	return ""
+}
+
+func chaosWhenName(w api.SdkChaosWhen) string {
+	switch w {
+	case api.SdkChaosWhen_SdkChaosWhenOnce:
+		return "once"
+	case api.SdkChaosWhen_SdkChaosWhenRandom:
+		return "random"
+	case api.SdkChaosWhen_SdkChaosWhenAlways:
+		return "always"
+	}
+	return ""
+}
+
+// ChaosCommands exports the list of CLI chaos subcommands.
+func ChaosCommands() []cli.Command {
+	c := &chaosClient{}
+
+	commands := []cli.Command{
+		{
+			Name:    "list",
+			Aliases: []string{"l"},
+			Usage:   "List the chaos points of the server",
+			Action:  c.list,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "pkg,p",
+					Usage: "Only list the chaos points of this package, e.g. sdk, kvdb, fake",
+					Value: "",
+				},
+			},
+		},
+		{
+			Name:      "enable",
+			Aliases:   []string{"e"},
+			Usage:     "Enable a chaos point",
+			ArgsUsage: "point",
+			Action:    c.enable,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "action,a",
+					Usage: "error, crash or delay. Actions can be combined with a '|', e.g. delay|error",
+					Value: "error",
+				},
+				cli.StringFlag{
+					Name:  "when,w",
+					Usage: "once, random or always. Random if a probability is given, always otherwise",
+					Value: "",
+				},
+				cli.Float64Flag{
+					Name:  "probability,p",
+					Usage: "Probability of triggering at each call when random",
+				},
+				cli.IntFlag{
+					Name:  "count,c",
+					Usage: "Number of times the point triggers, 0 for no limit",
+				},
+				cli.StringFlag{
+					Name:  "delay,d",
+					Usage: "Delay of the delay action, e.g. 500ms",
+					Value: "",
+				},
+			},
+		},
+		{
+			Name:      "disable",
+			Aliases:   []string{"d"},
+			Usage:     "Disable a chaos point",
+			ArgsUsage: "point",
+			Action:    c.disable,
+		},
+		{
+			Name:   "reset",
+			Usage:  "Disable all the chaos points",
+			Action: c.reset,
+		},
+	}
+	return commands
+}
@@ -205,6 +205,12 @@ func ClusterCommands() []cli.Command {
 				},
 			},
 		},
+		{
+			Name:        "secrets",
+			Usage:       "Manage the secrets of the cluster",
+			Flags:       SdkFlags(),
+			Subcommands: SecretsCommands(),
+		},
 		{
 			Name:   "shutdown",
 			Usage:  "Shutdown a cluster or a specific machine",
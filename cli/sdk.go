@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/codegangsta/cli"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/libopenstorage/openstorage/pkg/grpcserver"
+)
+
+const (
+	defaultSdkEndpoint = "localhost:9100"
+)
+
+// SdkFlags are the global flags of the commands using the SDK.
+func SdkFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "sdk-endpoint",
+			Usage: "SDK gRPC endpoint of the server",
+			Value: defaultSdkEndpoint,
+		},
+		cli.StringFlag{
+			Name:  "token",
+			Usage: "Token of the caller if the SDK has authentication enabled",
+			Value: "",
+		},
+	}
+}
+
+// sdkConnect connects to the SDK endpoint of the sdk-endpoint flag
+func sdkConnect(context *cli.Context, fn string) *grpc.ClientConn {
+	conn, err := grpcserver.Connect(
+		context.GlobalString("sdk-endpoint"),
+		[]grpc.DialOption{grpc.WithInsecure()})
+	if err != nil {
+		cmdError(context, fn, fmt.Errorf("Failed to connect to the SDK: %v", err))
+		return nil
+	}
+	return conn
+}
+
+// sdkContext returns the context of a call to the SDK, with the token if any
+func sdkContext(token string) context.Context {
+	ctx := context.Background()
+	if len(token) == 0 {
+		return ctx
+	}
+	md := metadata.New(map[string]string{
+		"authorization": "bearer " + token,
+	})
+	return metadata.NewOutgoingContext(ctx, md)
+}
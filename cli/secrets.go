@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/codegangsta/cli"
+
+	"github.com/libopenstorage/openstorage/api"
+)
+
+type secretsClient struct {
+	client api.OpenStorageSecretsClient
+	token  string
+}
+
+func (c *secretsClient) secretsOptions(context *cli.Context, fn string) {
+	c.client = api.NewOpenStorageSecretsClient(sdkConnect(context, fn))
+	c.token = context.GlobalString("token")
+}
+
+func (c *secretsClient) rotateMasterKey(context *cli.Context) {
+	fn := "rotate-master-key"
+	c.secretsOptions(context, fn)
+
+	resp, err := c.client.RotateMasterKey(sdkContext(c.token), &api.SdkSecretsRotateMasterKeyRequest{})
+	if err != nil {
+		cmdError(context, fn, err)
+		return
+	}
+	if context.GlobalBool("json") {
+		cmdOutputProto(resp, context.GlobalBool("raw"))
+		return
+	}
+	fmt.Printf("Rotated the master key of the secrets to %s\n", resp.GetMasterKeyId())
+}
+
+// SecretsCommands exports the list of CLI secrets subcommands.
+func SecretsCommands() []cli.Command {
+	c := &secretsClient{}
+
+	commands := []cli.Command{
+		{
+			Name:   "rotate-master-key",
+			Usage:  "Create a new master key and wrap the keys of all the secrets with it",
+			Action: c.rotateMasterKey,
+		},
+	}
+	return commands
+}
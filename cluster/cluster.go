@@ -30,6 +30,8 @@ var (
 	ErrNodeDecommissioned   = errors.New("Node is decomissioned.")
 	ErrRemoveCausesDataLoss = errors.New("Cannot remove node without data loss")
 	ErrNotImplemented       = errors.New("Not Implemented")
+	ErrNodeNotFound         = errors.New("Node not found in the cluster")
+	ErrNodeRemoveNotPending = errors.New("Node remove is not pending")
 )
 
 // ClusterServerConfiguration holds manager implementation
@@ -60,6 +62,47 @@ type NodeEntry struct {
 	GossipPort        string
 }
 
+// NodeRemoveBlocker is a reason why a node cannot be removed from the cluster
+type NodeRemoveBlocker struct {
+	// Listener is the name of the listener which does not allow the removal,
+	// empty if the cluster manager does not
+	Listener string
+	// Reason is why the node cannot be removed
+	Reason error
+	// Message gives details about the reason, such as the volumes which
+	// would lose data
+	Message string
+}
+
+// Forcible returns true if the node can still be removed by forcing it
+func (b *NodeRemoveBlocker) Forcible() bool {
+	return b.Reason == ErrRemoveCausesDataLoss
+}
+
+// NodeRemoveState is the state of the removal of a node
+type NodeRemoveState int
+
+const (
+	// NodeRemoveStateNotStarted is the state of a node whose removal was
+	// not requested, or was cancelled
+	NodeRemoveStateNotStarted NodeRemoveState = iota
+	// NodeRemoveStateInProgress is the state of a node being removed
+	NodeRemoveStateInProgress
+	// NodeRemoveStateDone is the state of a node removed from the cluster
+	NodeRemoveStateDone
+	// NodeRemoveStateFailed is the state of a node that a listener failed
+	// to remove
+	NodeRemoveStateFailed
+)
+
+// NodeRemoveStatus is the status of the removal of a node
+type NodeRemoveStatus struct {
+	// State of the removal
+	State NodeRemoveState
+	// Error of the listener which failed to remove the node
+	Error error
+}
+
 // ClusterInfo is the basic info about the cluster and its nodes
 type ClusterInfo struct {
 	Size        int
@@ -247,6 +290,24 @@ type ClusterRemove interface {
 	Remove(nodes []api.Node, forceRemove bool) error
 	// NodeRemoveDone notify cluster manager NodeRemove is done.
 	NodeRemoveDone(nodeID string, result error)
+	// NodeRemoveBlockers asks the listeners if a node can be removed and
+	// returns the reasons why it cannot, if any.
+	NodeRemoveBlockers(nodeID string) ([]NodeRemoveBlocker, error)
+	// NodeRemoveStatus returns the status of the removal of a node.
+	NodeRemoveStatus(nodeID string) (*NodeRemoveStatus, error)
+	// CancelNodeRemove puts a node whose removal is pending back in the
+	// cluster.
+	CancelNodeRemove(nodeID string) error
+}
+
+// ClusterMaintenance interface provides apis to take a node out of service
+// without removing it from the cluster
+type ClusterMaintenance interface {
+	// EnterMaintenance marks the node in maintenance in the cluster database.
+	// Volumes must not be placed on or attached to a node in maintenance.
+	EnterMaintenance(nodeID string) error
+	// ExitMaintenance puts a node in maintenance back in service.
+	ExitMaintenance(nodeID string) error
 }
 
 type ClusterAlerts interface {
@@ -318,10 +379,11 @@ type Cluster interface {
 
 	ClusterData
 	ClusterRemove
+	ClusterMaintenance
 	ClusterStatus
 	ClusterAlerts
 	ClusterPair
-	osdconfig.ConfigCaller
+	osdconfig.ConfigManager
 	secrets.Secrets
 	sched.SchedulePolicyProvider
 	objectstore.ObjectStore
@@ -16,10 +16,11 @@ import (
 type NullClusterManager struct {
 	NullClusterData
 	NullClusterRemove
+	NullClusterMaintenance
 	NullClusterStatus
 	NullClusterAlerts
 	NullClusterPair
-	osdconfig.NullConfigCaller
+	osdconfig.NullConfigManager
 	secrets.NullSecrets
 	schedpolicy.NullSchedMgr
 	objectstore.NullObjectStoreMgr
@@ -45,6 +46,15 @@ func NewDefaultClusterRemove() ClusterRemove {
 	return &NullClusterRemove{}
 }
 
+// NullClusterMaintenance is a NULL implementation of the ClusterMaintenance
+// interface
+type NullClusterMaintenance struct {
+}
+
+func NewDefaultClusterMaintenance() ClusterMaintenance {
+	return &NullClusterMaintenance{}
+}
+
 // NullClusterStatus is a NULL implementation of the ClusterStatus interface
 type NullClusterStatus struct {
 }
@@ -164,6 +174,33 @@ func (m *NullClusterRemove) NodeRemoveDone(arg0 string, arg1 error) {
 	return
 }
 
+// NodeRemoveBlockers
+func (m *NullClusterRemove) NodeRemoveBlockers(arg0 string) ([]NodeRemoveBlocker, error) {
+	return nil, ErrNotImplemented
+}
+
+// NodeRemoveStatus
+func (m *NullClusterRemove) NodeRemoveStatus(arg0 string) (*NodeRemoveStatus, error) {
+	return nil, ErrNotImplemented
+}
+
+// CancelNodeRemove
+func (m *NullClusterRemove) CancelNodeRemove(arg0 string) error {
+	return ErrNotImplemented
+}
+
+// NullClusterMaintenance implementations
+
+// EnterMaintenance
+func (m *NullClusterMaintenance) EnterMaintenance(arg0 string) error {
+	return ErrNotImplemented
+}
+
+// ExitMaintenance
+func (m *NullClusterMaintenance) ExitMaintenance(arg0 string) error {
+	return ErrNotImplemented
+}
+
 // NullClusterStatus implementations
 
 // Nodestatus
@@ -15,8 +15,22 @@ import (
 const (
 	// ClusterDBKey is the key at which cluster info is store in kvdb
 	ClusterDBKey = "cluster/database"
+	// NodeRemovalKey is the prefix of the keys at which the removals of
+	// the nodes are stored in kvdb
+	NodeRemovalKey = "cluster/removals/"
+	// nodeRemovalDoneTTL is how long, in seconds, a removal is kept in kvdb
+	// once it is done
+	nodeRemovalDoneTTL = 3600
 )
 
+// nodeRemoval is the progress of the removal of a node
+type nodeRemoval struct {
+	// Done is true once the node is removed
+	Done bool `json:"done,omitempty"`
+	// Error is the error of the listener which failed to remove the node
+	Error string `json:"error,omitempty"`
+}
+
 func snapAndReadClusterInfo() (*cluster.ClusterInitState, error) {
 	kv := kvdb.Instance()
 
@@ -123,3 +137,35 @@ func writeClusterInfo(db *cluster.ClusterInfo) (*kvdb.KVPair, error) {
 	}
 	return kvp, nil
 }
+
+// readNodeRemoval returns the removal of a node, nil if it is not removed
+func readNodeRemoval(nodeID string) (*nodeRemoval, error) {
+	var removal nodeRemoval
+	if _, err := kvdb.Instance().GetVal(NodeRemovalKey+nodeID, &removal); err != nil {
+		if err == kvdb.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &removal, nil
+}
+
+// writeNodeRemoval stores the removal of a node. Removals which are done are
+// deleted after nodeRemovalDoneTTL.
+func writeNodeRemoval(nodeID string, removal *nodeRemoval) error {
+	var ttl uint64
+	if removal.Done {
+		ttl = nodeRemovalDoneTTL
+	}
+	_, err := kvdb.Instance().Put(NodeRemovalKey+nodeID, removal, ttl)
+	return err
+}
+
+// deleteNodeRemoval deletes the removal of a node
+func deleteNodeRemoval(nodeID string) error {
+	_, err := kvdb.Instance().Delete(NodeRemovalKey + nodeID)
+	if err == kvdb.ErrNotFound {
+		return nil
+	}
+	return err
+}
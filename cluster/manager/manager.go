@@ -22,8 +22,10 @@ import (
 	"github.com/libopenstorage/openstorage/config"
 	"github.com/libopenstorage/openstorage/objectstore"
 	"github.com/libopenstorage/openstorage/osdconfig"
+	"github.com/libopenstorage/openstorage/pkg/chaos"
 	sched "github.com/libopenstorage/openstorage/schedpolicy"
 	"github.com/libopenstorage/openstorage/secrets"
+	"github.com/libopenstorage/openstorage/volume"
 	"github.com/libopenstorage/systemutils"
 	"github.com/portworx/kvdb"
 	"github.com/sirupsen/logrus"
@@ -42,6 +44,9 @@ var (
 	errClusterInitialized    = errors.New("openstorage.cluster: already initialized")
 	errClusterNotInitialized = errors.New("openstorage.cluster: not initialized")
 
+	koJoin      = chaos.Add("cluster", "join", "node joining the cluster")
+	koHeartbeat = chaos.Add("cluster", "heartbeat", "node heartbeat update")
+
 	inst *ClusterManager
 	// Inst returns an instance of an already instantiated cluster manager.
 	// This function can be overridden for testing purposes
@@ -67,10 +72,13 @@ type ClusterManager struct {
 	selfNode        api.Node
 	selfNodeLock    sync.Mutex // Lock that guards data and label of selfNode
 	system          systemutils.System
-	configManager   osdconfig.ConfigCaller
+	configManager   osdconfig.ConfigManager
 	schedManager    sched.SchedulePolicyProvider
 	objstoreManager objectstore.ObjectStore
 	secretsManager  secrets.Secrets
+	secretsLock     sync.RWMutex // Lock that guards secretsManager
+	maintenance     bool         // This node is in maintenance in the cluster database
+	maintenanceLock sync.Mutex   // Lock that guards maintenance
 }
 
 // Init instantiates a new cluster manager.
@@ -92,6 +100,8 @@ func Init(cfg config.ClusterConfig) error {
 		nodeCache:    make(map[string]api.Node),
 		nodeStatuses: make(map[string]api.Status),
 	}
+	volume.SetPlacementCheck(inst.checkPlacement)
+	volume.SetSchedulePolicyTrack(inst.trackSchedulePolicies)
 
 	return nil
 }
@@ -424,6 +434,7 @@ func (c *ClusterManager) watchDB(key string, opaque interface{},
 	}
 
 	for _, nodeEntry := range db.NodeEntries {
+		c.updateNodeMaintenance(nodeEntry)
 		if nodeEntry.Status == api.Status_STATUS_DECOMMISSION {
 			logrus.Infof("ClusterManager watchDB, node ID "+
 				"%s state is Decommission.",
@@ -462,6 +473,11 @@ func (c *ClusterManager) watchDB(key string, opaque interface{},
 		}
 	}
 
+	// We may have been put in or out of maintenance by another node
+	if selfEntry, ok := db.NodeEntries[c.selfNode.Id]; ok {
+		c.setMaintenance(selfEntry.Status == api.Status_STATUS_MAINTENANCE)
+	}
+
 	c.size = db.Size
 
 	c.gossip.UpdateCluster(c.getNonDecommisionedPeers(db))
@@ -501,7 +517,7 @@ func (c *ClusterManager) getLatestNodeConfig(nodeId string) *cluster.NodeEntry {
 }
 
 func (c *ClusterManager) initNode(db *cluster.ClusterInfo) (*api.Node, bool) {
-	_, exists := db.NodeEntries[c.selfNode.Id]
+	prevEntry, exists := db.NodeEntries[c.selfNode.Id]
 
 	// Add us into the database.
 	labels := make(map[string]string)
@@ -518,6 +534,12 @@ func (c *ClusterManager) initNode(db *cluster.ClusterInfo) (*api.Node, bool) {
 		NodeLabels:        labels,
 		GossipPort:        c.selfNode.GossipPort,
 	}
+	// Stay in maintenance across restarts
+	if exists && prevEntry.Status == api.Status_STATUS_MAINTENANCE {
+		logrus.Infof("Node %s is in maintenance", c.config.NodeId)
+		nodeEntry.Status = api.Status_STATUS_MAINTENANCE
+		c.maintenance = true
+	}
 
 	db.NodeEntries[c.config.NodeId] = nodeEntry
 
@@ -594,6 +616,10 @@ func (c *ClusterManager) joinCluster(
 	self *api.Node,
 	exist bool,
 ) error {
+	if err := chaos.Now(koJoin); err != nil {
+		return err
+	}
+
 	// Listeners may update initial state, so snap again.
 	// The cluster db may have diverged since we waited for quorum
 	// in between. Snapshot is created under cluster db lock to make
@@ -727,6 +753,10 @@ func (c *ClusterManager) startHeartBeat(clusterInfo *cluster.ClusterInfo) {
 			if diffTime > 10*time.Second {
 				logrus.Warnln("No gossip update for ", diffTime.Seconds(), "s")
 			}
+			if err := chaos.Now(koHeartbeat); err != nil {
+				// Miss the heartbeat
+				break
+			}
 			c.gossip.UpdateSelf(gossipStoreKey, *node)
 			lastUpdateTs = currTime
 		}
@@ -945,7 +975,13 @@ func (c *ClusterManager) waitForQuorum(exist bool) error {
 				return err
 			}
 			c.status = api.Status_STATUS_OK
-			c.selfNode.Status = api.Status_STATUS_OK
+			c.maintenanceLock.Lock()
+			if c.maintenance {
+				c.selfNode.Status = api.Status_STATUS_MAINTENANCE
+			} else {
+				c.selfNode.Status = api.Status_STATUS_OK
+			}
+			c.maintenanceLock.Unlock()
 			break
 		} else {
 			c.status = api.Status_STATUS_NOT_IN_QUORUM
@@ -1158,7 +1194,13 @@ func (c *ClusterManager) initializeAndStartHeartbeat(
 
 func (c *ClusterManager) setupManagers(config *cluster.ClusterServerConfiguration) {
 	if config.ConfigSchedManager == nil {
-		c.schedManager = sched.NewDefaultSchedulePolicy()
+		s, err := sched.NewKvdbSchedulePolicy(kvdb.Instance())
+		if err != nil {
+			logrus.Warnf("Failed to setup the kvdb schedule policies, policies are disabled: %v", err)
+			c.schedManager = sched.NewDefaultSchedulePolicy()
+		} else {
+			c.schedManager = s
+		}
 	} else {
 		c.schedManager = config.ConfigSchedManager
 	}
@@ -1171,6 +1213,24 @@ func (c *ClusterManager) setupManagers(config *cluster.ClusterServerConfiguratio
 
 	if config.ConfigSecretManager == nil {
 		c.secretsManager = secrets.NewDefaultSecrets()
+		if vaultConfig := secrets.VaultConfigFromEnv(); vaultConfig != nil {
+			// Clusters sharing a vault keep their secrets apart
+			if len(vaultConfig[secrets.VaultBasePath]) == 0 {
+				vaultConfig[secrets.VaultBasePath] = "openstorage/" + c.Uuid()
+			}
+			if s, err := secrets.NewVaultSecrets(vaultConfig); err != nil {
+				logrus.Warnf("Failed to login to vault %s, secrets are disabled: %v",
+					vaultConfig[secrets.VaultAddress], err)
+			} else {
+				c.secretsManager = s
+			}
+		} else if keys, err := secrets.NewKeyProviderFromEnv(); err != nil {
+			logrus.Infof("The kvdb secrets are disabled: %v", err)
+		} else if s, err := secrets.NewKvdbSecrets(kvdb.Instance(), keys); err != nil {
+			logrus.Warnf("Failed to setup the kvdb secrets, secrets are disabled: %v", err)
+		} else {
+			c.secretsManager = s
+		}
 	} else {
 		c.secretsManager = config.ConfigSecretManager
 	}
@@ -1204,7 +1264,7 @@ func (c *ClusterManager) StartWithConfiguration(
 
 	// osdconfig manager should be instantiated as soon as kv is ready
 	logrus.Info("initializing osdconfig manager")
-	c.configManager, err = osdconfig.NewCaller(kv)
+	c.configManager, err = osdconfig.NewManager(kv)
 	if err != nil {
 		return err
 	}
@@ -1529,9 +1589,144 @@ func (c *ClusterManager) markNodeDecommission(node api.Node) error {
 	if c.selfNode.Id == node.Id {
 		c.selfNode.Status = api.Status_STATUS_DECOMMISSION
 	}
-	_, err = writeClusterInfo(&db)
+	if _, err = writeClusterInfo(&db); err != nil {
+		return err
+	}
+	return writeNodeRemoval(node.Id, &nodeRemoval{})
+}
 
-	return err
+// EnterMaintenance marks a node in maintenance in the cluster database.
+func (c *ClusterManager) EnterMaintenance(nodeID string) error {
+	return c.markNodeMaintenance(nodeID, true)
+}
+
+// ExitMaintenance puts a node in maintenance back in service.
+func (c *ClusterManager) ExitMaintenance(nodeID string) error {
+	return c.markNodeMaintenance(nodeID, false)
+}
+
+func (c *ClusterManager) markNodeMaintenance(nodeID string, enter bool) error {
+	kvdb := kvdb.Instance()
+	kvlock, err := kvdb.LockWithID(clusterLockKey, c.config.NodeId)
+	if err != nil {
+		logrus.Warnln("Unable to obtain cluster lock for marking "+
+			"node maintenance",
+			err)
+		return err
+	}
+	defer kvdb.Unlock(kvlock)
+
+	db, _, err := readClusterInfo()
+	if err != nil {
+		return err
+	}
+
+	nodeEntry, ok := db.NodeEntries[nodeID]
+	if !ok {
+		return cluster.ErrNodeNotFound
+	}
+	if nodeEntry.Status == api.Status_STATUS_DECOMMISSION {
+		return cluster.ErrNodeDecommissioned
+	}
+
+	if (nodeEntry.Status == api.Status_STATUS_MAINTENANCE) != enter {
+		if enter {
+			nodeEntry.Status = api.Status_STATUS_MAINTENANCE
+		} else {
+			// Entries of the nodes in service carry no status
+			nodeEntry.Status = api.Status_STATUS_NONE
+		}
+		db.NodeEntries[nodeID] = nodeEntry
+		if _, err := writeClusterInfo(&db); err != nil {
+			return err
+		}
+	}
+
+	if nodeID == c.selfNode.Id {
+		c.setMaintenance(enter)
+	}
+	return nil
+}
+
+// setMaintenance puts this node in or out of maintenance and notifies the
+// listeners of its new status.
+func (c *ClusterManager) setMaintenance(enter bool) {
+	c.maintenanceLock.Lock()
+	defer c.maintenanceLock.Unlock()
+
+	if c.maintenance == enter {
+		return
+	}
+	c.maintenance = enter
+
+	// A node that has not joined the cluster yet gets its status
+	// once it does
+	if enter && c.selfNode.Status == api.Status_STATUS_OK {
+		logrus.Infof("Node %s entering maintenance", c.selfNode.Id)
+		c.selfNode.Status = api.Status_STATUS_MAINTENANCE
+	} else if !enter && c.selfNode.Status == api.Status_STATUS_MAINTENANCE {
+		logrus.Infof("Node %s exiting maintenance", c.selfNode.Id)
+		c.selfNode.Status = api.Status_STATUS_OK
+	} else {
+		return
+	}
+
+	node := c.getCurrentState()
+	c.putNodeCacheEntry(node.Id, *node)
+	for e := c.listeners.Front(); e != nil; e = e.Next() {
+		err := e.Value.(cluster.ClusterListener).Update(node.Copy())
+		if err != nil {
+			logrus.Warnln("Failed to notify ",
+				e.Value.(cluster.ClusterListener).String())
+		}
+	}
+}
+
+// updateNodeMaintenance puts a peer in or out of maintenance in the node
+// cache when another node changed its entry in the cluster database, until
+// the peer gossips its new status.
+func (c *ClusterManager) updateNodeMaintenance(nodeEntry cluster.NodeEntry) {
+	if nodeEntry.Id == c.selfNode.Id {
+		return
+	}
+	n, found := c.getNodeCacheEntry(nodeEntry.Id)
+	if !found {
+		return
+	}
+	switch {
+	case nodeEntry.Status == api.Status_STATUS_MAINTENANCE &&
+		n.Status == api.Status_STATUS_OK:
+		n.Status = api.Status_STATUS_MAINTENANCE
+	case nodeEntry.Status == api.Status_STATUS_NONE &&
+		n.Status == api.Status_STATUS_MAINTENANCE:
+		n.Status = api.Status_STATUS_OK
+	default:
+		return
+	}
+	c.putNodeCacheEntry(nodeEntry.Id, n)
+}
+
+// checkPlacement returns volume.ErrNodeInMaintenance if this node or any of
+// nodeIDs is in maintenance, so that the volume drivers do not place or
+// attach volumes there whichever API the call comes from. The status of
+// the peers is read from the node cache.
+func (c *ClusterManager) checkPlacement(nodeIDs []string) error {
+	c.maintenanceLock.Lock()
+	maintenance := c.maintenance
+	c.maintenanceLock.Unlock()
+	if maintenance {
+		return volume.ErrNodeInMaintenance
+	}
+	for _, id := range nodeIDs {
+		if id == c.selfNode.Id {
+			continue
+		}
+		if n, found := c.getNodeCacheEntry(id); found &&
+			n.Status == api.Status_STATUS_MAINTENANCE {
+			return volume.ErrNodeInMaintenance
+		}
+	}
+	return nil
 }
 
 func (c *ClusterManager) deleteNodeFromDB(nodeID string) error {
@@ -1584,24 +1779,9 @@ func (c *ClusterManager) Remove(nodes []api.Node, forceRemove bool) error {
 			}
 		}
 
-		// If removing node is self and node is not in maintenance mode,
-		// disallow node remove.
-		if nodes[i].Id == c.selfNode.Id &&
-			c.selfNode.Status != api.Status_STATUS_MAINTENANCE {
-			msg := fmt.Sprintf(decommissionErrMsg, nodes[i].Id)
-			logrus.Errorf(msg)
-			return errors.New(msg)
-		} else if nodes[i].Id != c.selfNode.Id && inQuorum {
-			nodeCacheStatus := node.Status
-			// If node is not down, do not remove it
-			if nodeCacheStatus != api.Status_STATUS_OFFLINE &&
-				nodeCacheStatus != api.Status_STATUS_MAINTENANCE &&
-				nodeCacheStatus != api.Status_STATUS_DECOMMISSION {
-
-				msg := fmt.Sprintf(decommissionErrMsg, nodes[i].Id)
-				logrus.Errorf(msg+", node status: %s", nodeCacheStatus)
-				return errors.New(msg)
-			}
+		if err := c.checkRemoveStatus(nodes[i].Id, node.Status); err != nil {
+			logrus.Errorf("%v Node status: %s", err, node.Status)
+			return err
 		}
 
 		if forceRemove {
@@ -1671,8 +1851,149 @@ func (c *ClusterManager) Remove(nodes []api.Node, forceRemove bool) error {
 	return resultErr
 }
 
+// checkRemoveStatus returns an error if a node cannot be removed in its
+// current status. This node must be in maintenance, and when in quorum the
+// other nodes must be down or in maintenance.
+func (c *ClusterManager) checkRemoveStatus(nodeID string, nodeStatus api.Status) error {
+	if nodeID == c.selfNode.Id {
+		if c.selfNode.Status != api.Status_STATUS_MAINTENANCE {
+			return fmt.Errorf(decommissionErrMsg, nodeID)
+		}
+	} else if c.selfNode.Status != api.Status_STATUS_NOT_IN_QUORUM {
+		if nodeStatus != api.Status_STATUS_OFFLINE &&
+			nodeStatus != api.Status_STATUS_MAINTENANCE &&
+			nodeStatus != api.Status_STATUS_DECOMMISSION {
+			return fmt.Errorf(decommissionErrMsg, nodeID)
+		}
+	}
+	return nil
+}
+
+// NodeRemoveBlockers asks the listeners if a node can be removed and returns
+// the reasons why it cannot, if any.
+func (c *ClusterManager) NodeRemoveBlockers(nodeID string) ([]cluster.NodeRemoveBlocker, error) {
+	node, exist := c.getNodeCacheEntry(nodeID)
+	if !exist {
+		var err error
+		node, err = c.getNodeInfoFromClusterDb(nodeID)
+		if err != nil {
+			return nil, cluster.ErrNodeNotFound
+		}
+	}
+
+	blockers := make([]cluster.NodeRemoveBlocker, 0)
+	if err := c.checkRemoveStatus(nodeID, node.Status); err != nil {
+		blockers = append(blockers, cluster.NodeRemoveBlocker{Reason: err})
+	}
+	for e := c.listeners.Front(); e != nil; e = e.Next() {
+		listener := e.Value.(cluster.ClusterListener)
+		msg, err := listener.CanNodeRemove(&api.Node{Id: nodeID})
+		if err != nil {
+			blockers = append(blockers, cluster.NodeRemoveBlocker{
+				Listener: listener.String(),
+				Reason:   err,
+				Message:  msg,
+			})
+		}
+	}
+	return blockers, nil
+}
+
+// NodeRemoveStatus returns the status of the removal of a node. Removals
+// which are done are known for nodeRemovalDoneTTL.
+func (c *ClusterManager) NodeRemoveStatus(nodeID string) (*cluster.NodeRemoveStatus, error) {
+	db, _, err := readClusterInfo()
+	if err != nil {
+		return nil, err
+	}
+	removal, err := readNodeRemoval(nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeEntry, ok := db.NodeEntries[nodeID]
+	switch {
+	case !ok && removal != nil && removal.Done:
+		return &cluster.NodeRemoveStatus{State: cluster.NodeRemoveStateDone}, nil
+	case !ok:
+		return nil, cluster.ErrNodeNotFound
+	case nodeEntry.Status != api.Status_STATUS_DECOMMISSION:
+		return &cluster.NodeRemoveStatus{State: cluster.NodeRemoveStateNotStarted}, nil
+	case removal != nil && removal.Error != "":
+		return &cluster.NodeRemoveStatus{
+			State: cluster.NodeRemoveStateFailed,
+			Error: errors.New(removal.Error),
+		}, nil
+	}
+	return &cluster.NodeRemoveStatus{State: cluster.NodeRemoveStateInProgress}, nil
+}
+
+// CancelNodeRemove puts a node whose removal is pending back in the cluster.
+// The listeners are notified of its new status.
+func (c *ClusterManager) CancelNodeRemove(nodeID string) error {
+	kvdb := kvdb.Instance()
+	kvlock, err := kvdb.LockWithID(clusterLockKey, c.config.NodeId)
+	if err != nil {
+		logrus.Warnln("Unable to obtain cluster lock for cancelling "+
+			"node decommission",
+			err)
+		return err
+	}
+	defer kvdb.Unlock(kvlock)
+
+	db, _, err := readClusterInfo()
+	if err != nil {
+		return err
+	}
+
+	nodeEntry, ok := db.NodeEntries[nodeID]
+	if !ok {
+		return cluster.ErrNodeNotFound
+	}
+	if nodeEntry.Status != api.Status_STATUS_DECOMMISSION {
+		return cluster.ErrNodeRemoveNotPending
+	}
+
+	logrus.Infof("Cancel decommission of node ID %s", nodeID)
+	nodeEntry.Status = api.Status_STATUS_NONE
+	db.NodeEntries[nodeID] = nodeEntry
+	if _, err := writeClusterInfo(&db); err != nil {
+		return err
+	}
+
+	if err := deleteNodeRemoval(nodeID); err != nil {
+		logrus.Warnf("Failed to delete the removal of node %s: %v", nodeID, err)
+	}
+
+	// The node is down until gossip hears from it
+	node, found := c.getNodeCacheEntry(nodeID)
+	if !found {
+		node = api.Node{Id: nodeID}
+	}
+	if node.Status == api.Status_STATUS_DECOMMISSION {
+		node.Status = api.Status_STATUS_OFFLINE
+		c.putNodeCacheEntry(nodeID, node)
+	}
+	for e := c.listeners.Front(); e != nil; e = e.Next() {
+		err := e.Value.(cluster.ClusterListener).Update(node.Copy())
+		if err != nil {
+			logrus.Warnln("Failed to notify ",
+				e.Value.(cluster.ClusterListener).String())
+		}
+	}
+	return nil
+}
+
 // NodeRemoveDone is called from the listeners when their job of Node removal is done.
 func (c *ClusterManager) NodeRemoveDone(nodeID string, result error) {
+	removal := &nodeRemoval{Done: result == nil}
+	if result != nil {
+		removal.Error = result.Error()
+	}
+	if err := writeNodeRemoval(nodeID, removal); err != nil {
+		logrus.Warnf("Failed to store the removal of node %s: %v", nodeID, err)
+	}
+
 	// XXX: only storage will make callback right now
 	if result != nil {
 		msg := fmt.Sprintf("Storage failed to decommission node %s, "+
@@ -1819,6 +2140,64 @@ func (c *ClusterManager) EnumerateNodeConf() (*osdconfig.NodesConfig, error) {
 	return c.configManager.EnumerateNodeConf()
 }
 
+// osdconfig.ConfigWatcher compliance
+func (c *ClusterManager) WatchCluster(name string, cb func(config *osdconfig.ClusterConfig) error) error {
+	return c.configManager.WatchCluster(name, cb)
+}
+
+func (c *ClusterManager) WatchNode(name string, cb func(config *osdconfig.NodeConfig) error) error {
+	return c.configManager.WatchNode(name, cb)
+}
+
+func (c *ClusterManager) UnwatchCluster(name string) error {
+	return c.configManager.UnwatchCluster(name)
+}
+
+func (c *ClusterManager) UnwatchNode(name string) error {
+	return c.configManager.UnwatchNode(name)
+}
+
+// osdconfig.ConfigVersioner compliance
+func (c *ClusterManager) SetClusterConfWithInfo(config *osdconfig.ClusterConfig, info *osdconfig.ChangeInfo) error {
+	return c.configManager.SetClusterConfWithInfo(config, info)
+}
+
+func (c *ClusterManager) SetNodeConfWithInfo(config *osdconfig.NodeConfig, info *osdconfig.ChangeInfo) error {
+	return c.configManager.SetNodeConfWithInfo(config, info)
+}
+
+func (c *ClusterManager) UpdateClusterConfWithInfo(update func(current *osdconfig.ClusterConfig) (*osdconfig.ClusterConfig, error), info *osdconfig.ChangeInfo) error {
+	return c.configManager.UpdateClusterConfWithInfo(update, info)
+}
+
+func (c *ClusterManager) UpdateNodeConfWithInfo(nodeID string, update func(current *osdconfig.NodeConfig) (*osdconfig.NodeConfig, error), info *osdconfig.ChangeInfo) error {
+	return c.configManager.UpdateNodeConfWithInfo(nodeID, update, info)
+}
+
+func (c *ClusterManager) EnumerateClusterConfRevisions() ([]*osdconfig.Revision, error) {
+	return c.configManager.EnumerateClusterConfRevisions()
+}
+
+func (c *ClusterManager) EnumerateNodeConfRevisions(nodeID string) ([]*osdconfig.Revision, error) {
+	return c.configManager.EnumerateNodeConfRevisions(nodeID)
+}
+
+func (c *ClusterManager) DiffClusterConf(from, to uint64) ([]*osdconfig.Difference, error) {
+	return c.configManager.DiffClusterConf(from, to)
+}
+
+func (c *ClusterManager) DiffNodeConf(nodeID string, from, to uint64) ([]*osdconfig.Difference, error) {
+	return c.configManager.DiffNodeConf(nodeID, from, to)
+}
+
+func (c *ClusterManager) RollbackClusterConf(version uint64, info *osdconfig.ChangeInfo) error {
+	return c.configManager.RollbackClusterConf(version, info)
+}
+
+func (c *ClusterManager) RollbackNodeConf(nodeID string, version uint64, info *osdconfig.ChangeInfo) error {
+	return c.configManager.RollbackNodeConf(nodeID, version, info)
+}
+
 // SchedPolicyCreate creates a policy with given name and schedule.
 func (c *ClusterManager) SchedPolicyCreate(name, sched string) error {
 	return c.schedManager.SchedPolicyCreate(name, sched)
@@ -1844,6 +2223,35 @@ func (c *ClusterManager) SchedPolicyGet(name string) (*sched.SchedPolicy, error)
 	return c.schedManager.SchedPolicyGet(name)
 }
 
+// SchedPolicyForceDelete deletes a policy with given name even if it is in use.
+func (c *ClusterManager) SchedPolicyForceDelete(name string) error {
+	return c.schedManager.SchedPolicyForceDelete(name)
+}
+
+// SchedPolicyTrack records ref as using the policies with given names.
+func (c *ClusterManager) SchedPolicyTrack(ref sched.Reference, names []string) error {
+	return c.schedManager.SchedPolicyTrack(ref, names)
+}
+
+// trackSchedulePolicies records the schedule policies used by the volumes
+// and the cloud backup schedules of the volume drivers. Nothing is tracked
+// until the cluster is started with a schedule policy manager.
+func (c *ClusterManager) trackSchedulePolicies(ref sched.Reference, names []string) error {
+	if c.schedManager == nil {
+		return nil
+	}
+	err := c.schedManager.SchedPolicyTrack(ref, names)
+	if err == sched.ErrNotImplemented {
+		return nil
+	}
+	return err
+}
+
+// SchedPolicyReferences returns the objects using policy with given name.
+func (c *ClusterManager) SchedPolicyReferences(name string) ([]sched.Reference, error) {
+	return c.schedManager.SchedPolicyReferences(name)
+}
+
 // ObjectStoreInspect returns status of objectstore
 func (c *ClusterManager) ObjectStoreInspect(objectstoreID string) (*api.ObjectstoreInfo, error) {
 	return c.objstoreManager.ObjectStoreInspect(objectstoreID)
@@ -1864,34 +2272,60 @@ func (c *ClusterManager) ObjectStoreDelete(objectstoreID string) error {
 	return c.objstoreManager.ObjectStoreDelete(objectstoreID)
 }
 
-// SecretLogin create session with secret store
+// secretStore returns the secrets manager
+func (c *ClusterManager) secretStore() secrets.Secrets {
+	c.secretsLock.RLock()
+	defer c.secretsLock.RUnlock()
+	return c.secretsManager
+}
+
+// SecretLogin checks the login to the kvdb secret store, which every node
+// shares. The sessions with the other secret stores are kept on each node,
+// so a login through the cluster would only configure the node it is sent
+// to and fails with secrets.ErrNodeLocalLogin: the other secret stores are
+// configured on every node at startup, vault with the VAULT_* environment
+// variables.
 func (c *ClusterManager) SecretLogin(secretType string, secretConfig map[string]string) error {
-	return c.secretsManager.SecretLogin(secretType, secretConfig)
+	if secretType != secrets.TypeKvdb {
+		return secrets.ErrNodeLocalLogin
+	}
+	return c.secretStore().SecretLogin(secretType, secretConfig)
 }
 
 // SecretSetDefaultSecretKey  sets the cluster wide secret key
 func (c *ClusterManager) SecretSetDefaultSecretKey(secretKey string, override bool) error {
-	return c.secretsManager.SecretSetDefaultSecretKey(secretKey, override)
+	return c.secretStore().SecretSetDefaultSecretKey(secretKey, override)
 }
 
 // SecretGetDefaultSecretKey returns cluster wide secret key
 func (c *ClusterManager) SecretGetDefaultSecretKey() (interface{}, error) {
-	return c.secretsManager.SecretGetDefaultSecretKey()
+	return c.secretStore().SecretGetDefaultSecretKey()
 }
 
 // SecretCheckLogin validates session with secret store
 func (c *ClusterManager) SecretCheckLogin() error {
-	return c.SecretCheckLogin()
+	return c.secretStore().SecretCheckLogin()
 }
 
 // SecretSet the given value/data against the key
 func (c *ClusterManager) SecretSet(secretKey string, secretValue interface{}) error {
-	return c.SecretSet(secretKey, secretValue)
+	return c.secretStore().SecretSet(secretKey, secretValue)
 }
 
 // SecretGet retrieves the value/data for given key
 func (c *ClusterManager) SecretGet(secretKey string) (interface{}, error) {
-	return c.SecretGet(secretKey)
+	return c.secretStore().SecretGet(secretKey)
+}
+
+// SecretDelete removes the value/data for given key
+func (c *ClusterManager) SecretDelete(secretKey string) error {
+	return c.secretStore().SecretDelete(secretKey)
+}
+
+// SecretRotateMasterKey creates a new master key and wraps the keys of the
+// secrets with it
+func (c *ClusterManager) SecretRotateMasterKey() (string, error) {
+	return c.secretStore().SecretRotateMasterKey()
 }
 
 // Uuid returns the unique id of the cluster
@@ -16,13 +16,19 @@ limitations under the License.
 package manager
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/cluster"
 	"github.com/libopenstorage/openstorage/config"
+	"github.com/libopenstorage/openstorage/secrets"
+	"github.com/libopenstorage/openstorage/volume"
 	"github.com/portworx/kvdb"
 	"github.com/portworx/kvdb/mem"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -85,3 +91,171 @@ func TestUpdateSchedulerNodeName(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "new-sched-name", node.SchedulerNodeName)
 }
+
+func TestNodeMaintenance(t *testing.T) {
+	nodeID := "node-alpha"
+	Init(config.ClusterConfig{
+		ClusterId:   testClusterId,
+		ClusterUuid: testClusterUuid,
+		NodeId:      nodeID,
+	})
+	if inst.selfNode.Id != nodeID {
+		require.NoError(t, inst.Start(1, false, "1001"))
+	}
+
+	err := inst.EnterMaintenance(nodeID)
+	assert.NoError(t, err)
+	status, err := inst.NodeStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, api.Status_STATUS_MAINTENANCE, status)
+	db, _, err := readClusterInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, api.Status_STATUS_MAINTENANCE, db.NodeEntries[nodeID].Status)
+
+	c, err := inst.Enumerate()
+	assert.NoError(t, err)
+	require.Len(t, c.Nodes, 1)
+	assert.Equal(t, api.Status_STATUS_MAINTENANCE, c.Nodes[0].Status)
+
+	// Entering maintenance again is a no-op
+	assert.NoError(t, inst.EnterMaintenance(nodeID))
+
+	err = inst.ExitMaintenance(nodeID)
+	assert.NoError(t, err)
+	status, err = inst.NodeStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, api.Status_STATUS_OK, status)
+	db, _, err = readClusterInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, api.Status_STATUS_NONE, db.NodeEntries[nodeID].Status)
+
+	assert.Equal(t, cluster.ErrNodeNotFound, inst.EnterMaintenance("node-missing"))
+}
+
+func TestCheckPlacement(t *testing.T) {
+	nodeID := "node-alpha"
+	Init(config.ClusterConfig{
+		ClusterId:   testClusterId,
+		ClusterUuid: testClusterUuid,
+		NodeId:      nodeID,
+	})
+	if inst.selfNode.Id != nodeID {
+		require.NoError(t, inst.Start(1, false, "1001"))
+	}
+
+	peerID := "node-gamma"
+	inst.putNodeCacheEntry(peerID, api.Node{Id: peerID, Status: api.Status_STATUS_OK})
+	assert.NoError(t, inst.checkPlacement([]string{peerID}))
+
+	// Placement on a peer put in maintenance in the cluster database
+	inst.updateNodeMaintenance(cluster.NodeEntry{
+		Id:     peerID,
+		Status: api.Status_STATUS_MAINTENANCE,
+	})
+	assert.Equal(t, volume.ErrNodeInMaintenance, inst.checkPlacement([]string{peerID}))
+	assert.NoError(t, inst.checkPlacement(nil))
+	inst.updateNodeMaintenance(cluster.NodeEntry{Id: peerID})
+	assert.NoError(t, inst.checkPlacement([]string{peerID}))
+
+	// Attach to this node in maintenance
+	require.NoError(t, inst.EnterMaintenance(nodeID))
+	assert.Equal(t, volume.ErrNodeInMaintenance, inst.checkPlacement(nil))
+	require.NoError(t, inst.ExitMaintenance(nodeID))
+	assert.NoError(t, inst.checkPlacement(nil))
+}
+
+func TestNodeRemoveStatus(t *testing.T) {
+	nodeID := "node-alpha"
+	Init(config.ClusterConfig{
+		ClusterId:   testClusterId,
+		ClusterUuid: testClusterUuid,
+		NodeId:      nodeID,
+	})
+	if inst.selfNode.Id != nodeID {
+		require.NoError(t, inst.Start(1, false, "1001"))
+	}
+
+	// This node must be in maintenance to be removed
+	blockers, err := inst.NodeRemoveBlockers(nodeID)
+	assert.NoError(t, err)
+	require.Len(t, blockers, 1)
+	assert.Empty(t, blockers[0].Listener)
+	assert.False(t, blockers[0].Forcible())
+	status, err := inst.NodeRemoveStatus(nodeID)
+	assert.NoError(t, err)
+	assert.Equal(t, cluster.NodeRemoveStateNotStarted, status.State)
+	assert.Equal(t, cluster.ErrNodeRemoveNotPending, inst.CancelNodeRemove(nodeID))
+	_, err = inst.NodeRemoveStatus("node-missing")
+	assert.Equal(t, cluster.ErrNodeNotFound, err)
+	_, err = inst.NodeRemoveBlockers("node-missing")
+	assert.Equal(t, cluster.ErrNodeNotFound, err)
+
+	// Remove a node in maintenance
+	peerID := "node-beta"
+	_, _, err = inst.updateNodeEntryDB(cluster.NodeEntry{
+		Id:     peerID,
+		Status: api.Status_STATUS_MAINTENANCE,
+	}, nil)
+	require.NoError(t, err)
+	blockers, err = inst.NodeRemoveBlockers(peerID)
+	assert.NoError(t, err)
+	assert.Empty(t, blockers)
+
+	peer := []api.Node{{Id: peerID}}
+	assert.NoError(t, inst.Remove(peer, false))
+	status, err = inst.NodeRemoveStatus(peerID)
+	assert.NoError(t, err)
+	assert.Equal(t, cluster.NodeRemoveStateInProgress, status.State)
+
+	// Cancel
+	assert.NoError(t, inst.CancelNodeRemove(peerID))
+	status, err = inst.NodeRemoveStatus(peerID)
+	assert.NoError(t, err)
+	assert.Equal(t, cluster.NodeRemoveStateNotStarted, status.State)
+
+	// Failure and completion reported by the listeners
+	assert.NoError(t, inst.EnterMaintenance(peerID))
+	assert.NoError(t, inst.Remove(peer, false))
+	removeErr := errors.New("volumes are still in use")
+	inst.NodeRemoveDone(peerID, removeErr)
+	status, err = inst.NodeRemoveStatus(peerID)
+	assert.NoError(t, err)
+	assert.Equal(t, cluster.NodeRemoveStateFailed, status.State)
+	assert.EqualError(t, status.Error, removeErr.Error())
+
+	// The other nodes know the status of the removal too
+	other := &ClusterManager{}
+	status, err = other.NodeRemoveStatus(peerID)
+	assert.NoError(t, err)
+	assert.Equal(t, cluster.NodeRemoveStateFailed, status.State)
+
+	inst.NodeRemoveDone(peerID, nil)
+	status, err = inst.NodeRemoveStatus(peerID)
+	assert.NoError(t, err)
+	assert.Equal(t, cluster.NodeRemoveStateDone, status.State)
+	status, err = other.NodeRemoveStatus(peerID)
+	assert.NoError(t, err)
+	assert.Equal(t, cluster.NodeRemoveStateDone, status.State)
+}
+
+func TestSecretLogin(t *testing.T) {
+	s, err := secrets.NewKvdbSecrets(kv, nil)
+	require.NoError(t, err)
+	c := &ClusterManager{secretsManager: s}
+
+	assert.NoError(t, c.SecretLogin(secrets.TypeKvdb, nil))
+	err = c.SecretLogin(secrets.TypeVault, map[string]string{
+		secrets.VaultAddress: "http://127.0.0.1:8200",
+	})
+	assert.Equal(t, secrets.ErrNodeLocalLogin, err)
+	assert.Equal(t, s, c.secretStore())
+
+	// Vault is not installed on one node only
+	c = &ClusterManager{secretsManager: secrets.NewDefaultSecrets()}
+	err = c.SecretLogin(secrets.TypeVault, map[string]string{
+		secrets.VaultAddress: "http://127.0.0.1:8200",
+		secrets.VaultToken:   "roottoken",
+	})
+	assert.Equal(t, secrets.ErrNodeLocalLogin, err)
+	assert.IsType(t, &secrets.NullSecrets{}, c.secretStore())
+}
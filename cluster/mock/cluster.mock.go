@@ -49,6 +49,18 @@ func (mr *MockClusterMockRecorder) AddEventListener(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddEventListener", reflect.TypeOf((*MockCluster)(nil).AddEventListener), arg0)
 }
 
+// CancelNodeRemove mocks base method
+func (m *MockCluster) CancelNodeRemove(arg0 string) error {
+	ret := m.ctrl.Call(m, "CancelNodeRemove", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelNodeRemove indicates an expected call of CancelNodeRemove
+func (mr *MockClusterMockRecorder) CancelNodeRemove(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelNodeRemove", reflect.TypeOf((*MockCluster)(nil).CancelNodeRemove), arg0)
+}
+
 // CreatePair mocks base method
 func (m *MockCluster) CreatePair(arg0 *api.ClusterPairCreateRequest) (*api.ClusterPairCreateResponse, error) {
 	ret := m.ctrl.Call(m, "CreatePair", arg0)
@@ -86,6 +98,32 @@ func (mr *MockClusterMockRecorder) DeletePair(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePair", reflect.TypeOf((*MockCluster)(nil).DeletePair), arg0)
 }
 
+// DiffClusterConf mocks base method
+func (m *MockCluster) DiffClusterConf(arg0 uint64, arg1 uint64) ([]*osdconfig.Difference, error) {
+	ret := m.ctrl.Call(m, "DiffClusterConf", arg0, arg1)
+	ret0, _ := ret[0].([]*osdconfig.Difference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiffClusterConf indicates an expected call of DiffClusterConf
+func (mr *MockClusterMockRecorder) DiffClusterConf(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiffClusterConf", reflect.TypeOf((*MockCluster)(nil).DiffClusterConf), arg0, arg1)
+}
+
+// DiffNodeConf mocks base method
+func (m *MockCluster) DiffNodeConf(arg0 string, arg1 uint64, arg2 uint64) ([]*osdconfig.Difference, error) {
+	ret := m.ctrl.Call(m, "DiffNodeConf", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*osdconfig.Difference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiffNodeConf indicates an expected call of DiffNodeConf
+func (mr *MockClusterMockRecorder) DiffNodeConf(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiffNodeConf", reflect.TypeOf((*MockCluster)(nil).DiffNodeConf), arg0, arg1, arg2)
+}
+
 // DisableUpdates mocks base method
 func (m *MockCluster) DisableUpdates() error {
 	ret := m.ctrl.Call(m, "DisableUpdates")
@@ -110,6 +148,18 @@ func (mr *MockClusterMockRecorder) EnableUpdates() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableUpdates", reflect.TypeOf((*MockCluster)(nil).EnableUpdates))
 }
 
+// EnterMaintenance mocks base method
+func (m *MockCluster) EnterMaintenance(arg0 string) error {
+	ret := m.ctrl.Call(m, "EnterMaintenance", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnterMaintenance indicates an expected call of EnterMaintenance
+func (mr *MockClusterMockRecorder) EnterMaintenance(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnterMaintenance", reflect.TypeOf((*MockCluster)(nil).EnterMaintenance), arg0)
+}
+
 // Enumerate mocks base method
 func (m *MockCluster) Enumerate() (api.Cluster, error) {
 	ret := m.ctrl.Call(m, "Enumerate")
@@ -136,6 +186,19 @@ func (mr *MockClusterMockRecorder) EnumerateAlerts(arg0, arg1, arg2 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnumerateAlerts", reflect.TypeOf((*MockCluster)(nil).EnumerateAlerts), arg0, arg1, arg2)
 }
 
+// EnumerateClusterConfRevisions mocks base method
+func (m *MockCluster) EnumerateClusterConfRevisions() ([]*osdconfig.Revision, error) {
+	ret := m.ctrl.Call(m, "EnumerateClusterConfRevisions")
+	ret0, _ := ret[0].([]*osdconfig.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnumerateClusterConfRevisions indicates an expected call of EnumerateClusterConfRevisions
+func (mr *MockClusterMockRecorder) EnumerateClusterConfRevisions() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnumerateClusterConfRevisions", reflect.TypeOf((*MockCluster)(nil).EnumerateClusterConfRevisions))
+}
+
 // EnumerateNodeConf mocks base method
 func (m *MockCluster) EnumerateNodeConf() (*osdconfig.NodesConfig, error) {
 	ret := m.ctrl.Call(m, "EnumerateNodeConf")
@@ -149,6 +212,19 @@ func (mr *MockClusterMockRecorder) EnumerateNodeConf() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnumerateNodeConf", reflect.TypeOf((*MockCluster)(nil).EnumerateNodeConf))
 }
 
+// EnumerateNodeConfRevisions mocks base method
+func (m *MockCluster) EnumerateNodeConfRevisions(arg0 string) ([]*osdconfig.Revision, error) {
+	ret := m.ctrl.Call(m, "EnumerateNodeConfRevisions", arg0)
+	ret0, _ := ret[0].([]*osdconfig.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnumerateNodeConfRevisions indicates an expected call of EnumerateNodeConfRevisions
+func (mr *MockClusterMockRecorder) EnumerateNodeConfRevisions(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnumerateNodeConfRevisions", reflect.TypeOf((*MockCluster)(nil).EnumerateNodeConfRevisions), arg0)
+}
+
 // EnumeratePairs mocks base method
 func (m *MockCluster) EnumeratePairs() (*api.ClusterPairsEnumerateResponse, error) {
 	ret := m.ctrl.Call(m, "EnumeratePairs")
@@ -174,6 +250,18 @@ func (mr *MockClusterMockRecorder) EraseAlert(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseAlert", reflect.TypeOf((*MockCluster)(nil).EraseAlert), arg0, arg1)
 }
 
+// ExitMaintenance mocks base method
+func (m *MockCluster) ExitMaintenance(arg0 string) error {
+	ret := m.ctrl.Call(m, "ExitMaintenance", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExitMaintenance indicates an expected call of ExitMaintenance
+func (mr *MockClusterMockRecorder) ExitMaintenance(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExitMaintenance", reflect.TypeOf((*MockCluster)(nil).ExitMaintenance), arg0)
+}
+
 // GetClusterConf mocks base method
 func (m *MockCluster) GetClusterConf() (*osdconfig.ClusterConfig, error) {
 	ret := m.ctrl.Call(m, "GetClusterConf")
@@ -277,6 +365,19 @@ func (mr *MockClusterMockRecorder) Inspect(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Inspect", reflect.TypeOf((*MockCluster)(nil).Inspect), arg0)
 }
 
+// NodeRemoveBlockers mocks base method
+func (m *MockCluster) NodeRemoveBlockers(arg0 string) ([]cluster.NodeRemoveBlocker, error) {
+	ret := m.ctrl.Call(m, "NodeRemoveBlockers", arg0)
+	ret0, _ := ret[0].([]cluster.NodeRemoveBlocker)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NodeRemoveBlockers indicates an expected call of NodeRemoveBlockers
+func (mr *MockClusterMockRecorder) NodeRemoveBlockers(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodeRemoveBlockers", reflect.TypeOf((*MockCluster)(nil).NodeRemoveBlockers), arg0)
+}
+
 // NodeRemoveDone mocks base method
 func (m *MockCluster) NodeRemoveDone(arg0 string, arg1 error) {
 	m.ctrl.Call(m, "NodeRemoveDone", arg0, arg1)
@@ -287,6 +388,19 @@ func (mr *MockClusterMockRecorder) NodeRemoveDone(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodeRemoveDone", reflect.TypeOf((*MockCluster)(nil).NodeRemoveDone), arg0, arg1)
 }
 
+// NodeRemoveStatus mocks base method
+func (m *MockCluster) NodeRemoveStatus(arg0 string) (*cluster.NodeRemoveStatus, error) {
+	ret := m.ctrl.Call(m, "NodeRemoveStatus", arg0)
+	ret0, _ := ret[0].(*cluster.NodeRemoveStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NodeRemoveStatus indicates an expected call of NodeRemoveStatus
+func (mr *MockClusterMockRecorder) NodeRemoveStatus(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodeRemoveStatus", reflect.TypeOf((*MockCluster)(nil).NodeRemoveStatus), arg0)
+}
+
 // NodeStatus mocks base method
 func (m *MockCluster) NodeStatus() (api.Status, error) {
 	ret := m.ctrl.Call(m, "NodeStatus")
@@ -400,6 +514,30 @@ func (mr *MockClusterMockRecorder) Remove(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockCluster)(nil).Remove), arg0, arg1)
 }
 
+// RollbackClusterConf mocks base method
+func (m *MockCluster) RollbackClusterConf(arg0 uint64, arg1 *osdconfig.ChangeInfo) error {
+	ret := m.ctrl.Call(m, "RollbackClusterConf", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RollbackClusterConf indicates an expected call of RollbackClusterConf
+func (mr *MockClusterMockRecorder) RollbackClusterConf(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollbackClusterConf", reflect.TypeOf((*MockCluster)(nil).RollbackClusterConf), arg0, arg1)
+}
+
+// RollbackNodeConf mocks base method
+func (m *MockCluster) RollbackNodeConf(arg0 string, arg1 uint64, arg2 *osdconfig.ChangeInfo) error {
+	ret := m.ctrl.Call(m, "RollbackNodeConf", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RollbackNodeConf indicates an expected call of RollbackNodeConf
+func (mr *MockClusterMockRecorder) RollbackNodeConf(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollbackNodeConf", reflect.TypeOf((*MockCluster)(nil).RollbackNodeConf), arg0, arg1, arg2)
+}
+
 // SchedPolicyCreate mocks base method
 func (m *MockCluster) SchedPolicyCreate(arg0, arg1 string) error {
 	ret := m.ctrl.Call(m, "SchedPolicyCreate", arg0, arg1)
@@ -437,6 +575,18 @@ func (mr *MockClusterMockRecorder) SchedPolicyEnumerate() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchedPolicyEnumerate", reflect.TypeOf((*MockCluster)(nil).SchedPolicyEnumerate))
 }
 
+// SchedPolicyForceDelete mocks base method
+func (m *MockCluster) SchedPolicyForceDelete(arg0 string) error {
+	ret := m.ctrl.Call(m, "SchedPolicyForceDelete", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SchedPolicyForceDelete indicates an expected call of SchedPolicyForceDelete
+func (mr *MockClusterMockRecorder) SchedPolicyForceDelete(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchedPolicyForceDelete", reflect.TypeOf((*MockCluster)(nil).SchedPolicyForceDelete), arg0)
+}
+
 // SchedPolicyGet mocks base method
 func (m *MockCluster) SchedPolicyGet(arg0 string) (*schedpolicy.SchedPolicy, error) {
 	ret := m.ctrl.Call(m, "SchedPolicyGet", arg0)
@@ -450,6 +600,31 @@ func (mr *MockClusterMockRecorder) SchedPolicyGet(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchedPolicyGet", reflect.TypeOf((*MockCluster)(nil).SchedPolicyGet), arg0)
 }
 
+// SchedPolicyReferences mocks base method
+func (m *MockCluster) SchedPolicyReferences(arg0 string) ([]schedpolicy.Reference, error) {
+	ret := m.ctrl.Call(m, "SchedPolicyReferences", arg0)
+	ret0, _ := ret[0].([]schedpolicy.Reference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SchedPolicyReferences indicates an expected call of SchedPolicyReferences
+func (mr *MockClusterMockRecorder) SchedPolicyReferences(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchedPolicyReferences", reflect.TypeOf((*MockCluster)(nil).SchedPolicyReferences), arg0)
+}
+
+// SchedPolicyTrack mocks base method
+func (m *MockCluster) SchedPolicyTrack(arg0 schedpolicy.Reference, arg1 []string) error {
+	ret := m.ctrl.Call(m, "SchedPolicyTrack", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SchedPolicyTrack indicates an expected call of SchedPolicyTrack
+func (mr *MockClusterMockRecorder) SchedPolicyTrack(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchedPolicyTrack", reflect.TypeOf((*MockCluster)(nil).SchedPolicyTrack), arg0, arg1)
+}
+
 // SchedPolicyUpdate mocks base method
 func (m *MockCluster) SchedPolicyUpdate(arg0, arg1 string) error {
 	ret := m.ctrl.Call(m, "SchedPolicyUpdate", arg0, arg1)
@@ -474,6 +649,18 @@ func (mr *MockClusterMockRecorder) SecretCheckLogin() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SecretCheckLogin", reflect.TypeOf((*MockCluster)(nil).SecretCheckLogin))
 }
 
+// SecretDelete mocks base method
+func (m *MockCluster) SecretDelete(arg0 string) error {
+	ret := m.ctrl.Call(m, "SecretDelete", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SecretDelete indicates an expected call of SecretDelete
+func (mr *MockClusterMockRecorder) SecretDelete(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SecretDelete", reflect.TypeOf((*MockCluster)(nil).SecretDelete), arg0)
+}
+
 // SecretGet mocks base method
 func (m *MockCluster) SecretGet(arg0 string) (interface{}, error) {
 	ret := m.ctrl.Call(m, "SecretGet", arg0)
@@ -512,6 +699,19 @@ func (mr *MockClusterMockRecorder) SecretLogin(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SecretLogin", reflect.TypeOf((*MockCluster)(nil).SecretLogin), arg0, arg1)
 }
 
+// SecretRotateMasterKey mocks base method
+func (m *MockCluster) SecretRotateMasterKey() (string, error) {
+	ret := m.ctrl.Call(m, "SecretRotateMasterKey")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SecretRotateMasterKey indicates an expected call of SecretRotateMasterKey
+func (mr *MockClusterMockRecorder) SecretRotateMasterKey() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SecretRotateMasterKey", reflect.TypeOf((*MockCluster)(nil).SecretRotateMasterKey))
+}
+
 // SecretSet mocks base method
 func (m *MockCluster) SecretSet(arg0 string, arg1 interface{}) error {
 	ret := m.ctrl.Call(m, "SecretSet", arg0, arg1)
@@ -548,6 +748,18 @@ func (mr *MockClusterMockRecorder) SetClusterConf(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetClusterConf", reflect.TypeOf((*MockCluster)(nil).SetClusterConf), arg0)
 }
 
+// SetClusterConfWithInfo mocks base method
+func (m *MockCluster) SetClusterConfWithInfo(arg0 *osdconfig.ClusterConfig, arg1 *osdconfig.ChangeInfo) error {
+	ret := m.ctrl.Call(m, "SetClusterConfWithInfo", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetClusterConfWithInfo indicates an expected call of SetClusterConfWithInfo
+func (mr *MockClusterMockRecorder) SetClusterConfWithInfo(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetClusterConfWithInfo", reflect.TypeOf((*MockCluster)(nil).SetClusterConfWithInfo), arg0, arg1)
+}
+
 // SetNodeConf mocks base method
 func (m *MockCluster) SetNodeConf(arg0 *osdconfig.NodeConfig) error {
 	ret := m.ctrl.Call(m, "SetNodeConf", arg0)
@@ -560,6 +772,18 @@ func (mr *MockClusterMockRecorder) SetNodeConf(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNodeConf", reflect.TypeOf((*MockCluster)(nil).SetNodeConf), arg0)
 }
 
+// SetNodeConfWithInfo mocks base method
+func (m *MockCluster) SetNodeConfWithInfo(arg0 *osdconfig.NodeConfig, arg1 *osdconfig.ChangeInfo) error {
+	ret := m.ctrl.Call(m, "SetNodeConfWithInfo", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNodeConfWithInfo indicates an expected call of SetNodeConfWithInfo
+func (mr *MockClusterMockRecorder) SetNodeConfWithInfo(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNodeConfWithInfo", reflect.TypeOf((*MockCluster)(nil).SetNodeConfWithInfo), arg0, arg1)
+}
+
 // SetSize mocks base method
 func (m *MockCluster) SetSize(arg0 int) error {
 	ret := m.ctrl.Call(m, "SetSize", arg0)
@@ -608,6 +832,42 @@ func (mr *MockClusterMockRecorder) StartWithConfiguration(arg0, arg1, arg2, arg3
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartWithConfiguration", reflect.TypeOf((*MockCluster)(nil).StartWithConfiguration), arg0, arg1, arg2, arg3)
 }
 
+// UnwatchCluster mocks base method
+func (m *MockCluster) UnwatchCluster(arg0 string) error {
+	ret := m.ctrl.Call(m, "UnwatchCluster", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnwatchCluster indicates an expected call of UnwatchCluster
+func (mr *MockClusterMockRecorder) UnwatchCluster(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnwatchCluster", reflect.TypeOf((*MockCluster)(nil).UnwatchCluster), arg0)
+}
+
+// UnwatchNode mocks base method
+func (m *MockCluster) UnwatchNode(arg0 string) error {
+	ret := m.ctrl.Call(m, "UnwatchNode", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnwatchNode indicates an expected call of UnwatchNode
+func (mr *MockClusterMockRecorder) UnwatchNode(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnwatchNode", reflect.TypeOf((*MockCluster)(nil).UnwatchNode), arg0)
+}
+
+// UpdateClusterConfWithInfo mocks base method
+func (m *MockCluster) UpdateClusterConfWithInfo(arg0 func(*osdconfig.ClusterConfig) (*osdconfig.ClusterConfig, error), arg1 *osdconfig.ChangeInfo) error {
+	ret := m.ctrl.Call(m, "UpdateClusterConfWithInfo", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateClusterConfWithInfo indicates an expected call of UpdateClusterConfWithInfo
+func (mr *MockClusterMockRecorder) UpdateClusterConfWithInfo(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateClusterConfWithInfo", reflect.TypeOf((*MockCluster)(nil).UpdateClusterConfWithInfo), arg0, arg1)
+}
+
 // UpdateData mocks base method
 func (m *MockCluster) UpdateData(arg0 map[string]interface{}) error {
 	ret := m.ctrl.Call(m, "UpdateData", arg0)
@@ -632,6 +892,18 @@ func (mr *MockClusterMockRecorder) UpdateLabels(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLabels", reflect.TypeOf((*MockCluster)(nil).UpdateLabels), arg0)
 }
 
+// UpdateNodeConfWithInfo mocks base method
+func (m *MockCluster) UpdateNodeConfWithInfo(arg0 string, arg1 func(*osdconfig.NodeConfig) (*osdconfig.NodeConfig, error), arg2 *osdconfig.ChangeInfo) error {
+	ret := m.ctrl.Call(m, "UpdateNodeConfWithInfo", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateNodeConfWithInfo indicates an expected call of UpdateNodeConfWithInfo
+func (mr *MockClusterMockRecorder) UpdateNodeConfWithInfo(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNodeConfWithInfo", reflect.TypeOf((*MockCluster)(nil).UpdateNodeConfWithInfo), arg0, arg1, arg2)
+}
+
 // UpdateSchedulerNodeName mocks base method
 func (m *MockCluster) UpdateSchedulerNodeName(arg0 string) error {
 	ret := m.ctrl.Call(m, "UpdateSchedulerNodeName", arg0)
@@ -667,3 +939,27 @@ func (m *MockCluster) ValidatePair(arg0 string) error {
 func (mr *MockClusterMockRecorder) ValidatePair(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidatePair", reflect.TypeOf((*MockCluster)(nil).ValidatePair), arg0)
 }
+
+// WatchCluster mocks base method
+func (m *MockCluster) WatchCluster(arg0 string, arg1 func(*osdconfig.ClusterConfig) error) error {
+	ret := m.ctrl.Call(m, "WatchCluster", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WatchCluster indicates an expected call of WatchCluster
+func (mr *MockClusterMockRecorder) WatchCluster(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchCluster", reflect.TypeOf((*MockCluster)(nil).WatchCluster), arg0, arg1)
+}
+
+// WatchNode mocks base method
+func (m *MockCluster) WatchNode(arg0 string, arg1 func(*osdconfig.NodeConfig) error) error {
+	ret := m.ctrl.Call(m, "WatchNode", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WatchNode indicates an expected call of WatchNode
+func (mr *MockClusterMockRecorder) WatchNode(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchNode", reflect.TypeOf((*MockCluster)(nil).WatchNode), arg0, arg1)
+}
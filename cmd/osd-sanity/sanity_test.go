@@ -32,6 +32,8 @@ var (
 	endpoint              string
 	driver                string
 	cloudBackupConfigPath string
+	sdkEndpoint           string
+	chaosScenarioPath     string
 	version               bool
 )
 
@@ -39,6 +41,8 @@ func init() {
 	flag.StringVar(&endpoint, prefix+"endpoint", "", "OSD endpoint")
 	flag.StringVar(&driver, prefix+"driver", "", "Volume Driver")
 	flag.StringVar(&cloudBackupConfigPath, prefix+"cloudbackupconfig", "", "Test Cloud Backup/Restore config file , optional")
+	flag.StringVar(&sdkEndpoint, prefix+"sdkendpoint", "", "OSD SDK gRPC endpoint, required to run a chaos scenario")
+	flag.StringVar(&chaosScenarioPath, prefix+"chaosscenario", "", "Chaos scenario enabled in OSD during the tests, optional")
 	flag.BoolVar(&version, prefix+"version", false, "Version of this program")
 	flag.Parse()
 }
@@ -58,5 +62,16 @@ func TestSanity(t *testing.T) {
 		t.Logf("No Cloud backup config file provided , Cloud Backup & Restore Tests will be skipped")
 	}
 
-	sanity.Test(t, endpoint, driver, cloudBackupConfigPath)
+	var chaosConfig *sanity.ChaosConfig
+	if len(chaosScenarioPath) != 0 {
+		if len(sdkEndpoint) == 0 {
+			t.Fatalf("--%ssdkendpoint must be provided to run a chaos scenario", prefix)
+		}
+		chaosConfig = &sanity.ChaosConfig{
+			SdkAddress:   sdkEndpoint,
+			ScenarioPath: chaosScenarioPath,
+		}
+	}
+
+	sanity.TestWithChaos(t, endpoint, driver, cloudBackupConfigPath, chaosConfig)
 }
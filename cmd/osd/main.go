@@ -48,8 +48,10 @@ import (
 	"github.com/libopenstorage/openstorage/graph/drivers"
 	"github.com/libopenstorage/openstorage/objectstore"
 	"github.com/libopenstorage/openstorage/pkg/auth"
+	"github.com/libopenstorage/openstorage/pkg/chaos"
 	"github.com/libopenstorage/openstorage/pkg/role"
 	"github.com/libopenstorage/openstorage/schedpolicy"
+	"github.com/libopenstorage/openstorage/secrets"
 	"github.com/libopenstorage/openstorage/volume"
 	"github.com/libopenstorage/openstorage/volume/drivers"
 	"github.com/portworx/kvdb"
@@ -114,6 +116,20 @@ func main() {
 			Usage: "Cluster id",
 			Value: "openstorage.cluster",
 		},
+		cli.StringFlag{
+			Name:  "secrets-key-dir",
+			Usage: "Directory with the master keys of the kvdb secrets, installed with the same keys on every node. The keys are kept in kvdb if not set",
+			Value: "",
+		},
+		cli.BoolFlag{
+			Name:  "chaos",
+			Usage: "Activate the chaos points, which can then be enabled with the SDK chaos service. Only for fault injection tests",
+		},
+		cli.StringFlag{
+			Name:  "chaos-scenario",
+			Usage: "File of a chaos scenario to apply at startup. Requires --chaos",
+			Value: "",
+		},
 	}
 	app.Action = wrapAction(start)
 	app.Commands = []cli.Command{
@@ -129,6 +145,12 @@ func main() {
 			Usage:       "Manage cluster",
 			Subcommands: osdcli.ClusterCommands(),
 		},
+		{
+			Name:        "chaos",
+			Usage:       "Manage chaos points",
+			Flags:       osdcli.SdkFlags(),
+			Subcommands: osdcli.ChaosCommands(),
+		},
 		{
 			Name:    "version",
 			Aliases: []string{"v"},
@@ -261,6 +283,19 @@ func start(c *cli.Context) error {
 	if err != nil {
 		return fmt.Errorf("Failed to initialize KVDB: %v (%v)\nSupported datastores: %v", scheme, err, datastores)
 	}
+	var chaosScenario *chaos.Scenario
+	if c.Bool("chaos") {
+		logrus.Warnf("Chaos points activated")
+		chaos.Activate(true)
+		kv = chaos.WrapKvdb(kv)
+		if path := c.String("chaos-scenario"); len(path) != 0 {
+			if chaosScenario, err = chaos.LoadScenario(path); err != nil {
+				return err
+			}
+		}
+	} else if len(c.String("chaos-scenario")) != 0 {
+		return fmt.Errorf("A chaos scenario requires --chaos")
+	}
 	if err := kvdb.SetInstance(kv); err != nil {
 		return fmt.Errorf("Failed to initialize KVDB: %v", err)
 	}
@@ -382,19 +417,51 @@ func start(c *cli.Context) error {
 		}
 	}
 
+	// Apply the chaos scenario once the chaos points of the SDK servers
+	// are known and before joining the cluster
+	if chaosScenario != nil {
+		if err := chaosScenario.Apply(); err != nil {
+			return fmt.Errorf("Unable to apply chaos scenario %s: %v", chaosScenario.Name, err)
+		}
+		logrus.Warnf("Chaos scenario %s applied", chaosScenario.Name)
+	}
+
 	if clusterInit {
 		cm, err := clustermanager.Inst()
 		if err != nil {
 			return fmt.Errorf("Unable to find cluster instance: %v", err)
 		}
+		schedManager, err := schedpolicy.NewKvdbSchedulePolicy(kv)
+		if err != nil {
+			return fmt.Errorf("Unable to setup schedule policies: %v", err)
+		}
+		clusterConfig := &cluster.ClusterServerConfiguration{
+			ConfigSchedManager:       schedManager,
+			ConfigObjectStoreManager: objectstore.NewfakeObjectstore(),
+		}
+		if d := cfg.Osd.ClusterConfig.DefaultDriver; d != "" {
+			clusterConfig.ConfigObjectStoreManager = objectstore.NewGatewayObjectStore(
+				kv,
+				volumeMountPath(d),
+				objectstore.GatewayConfig{
+					Host:       cfg.Osd.ClusterConfig.MgmtIp,
+					NodeID:     cfg.Osd.ClusterConfig.NodeId,
+					NodeOnline: nodeOnline(cm),
+				},
+			)
+		}
+		if dir := c.String("secrets-key-dir"); len(dir) != 0 {
+			s, err := secrets.NewKvdbSecrets(kv, secrets.NewFileKeyProvider(dir))
+			if err != nil {
+				return fmt.Errorf("Unable to setup secrets with the keys in %s: %v", dir, err)
+			}
+			clusterConfig.ConfigSecretManager = s
+		}
 		if err := cm.StartWithConfiguration(
 			0,
 			false,
 			"9002",
-			&cluster.ClusterServerConfiguration{
-				ConfigSchedManager:       schedpolicy.NewFakeScheduler(),
-				ConfigObjectStoreManager: objectstore.NewfakeObjectstore(),
-			},
+			clusterConfig,
 		); err != nil {
 			return fmt.Errorf("Unable to start cluster manager: %v", err)
 		}
@@ -404,6 +471,37 @@ func start(c *cli.Context) error {
 	select {}
 }
 
+// nodeOnline returns false for the nodes the cluster reports offline or
+// does not know, whose objectstores can be taken over
+func nodeOnline(cm cluster.Cluster) objectstore.NodeOnlineFunc {
+	return func(nodeID string) bool {
+		node, err := cm.Inspect(nodeID)
+		return err == nil && node.Status != api.Status_STATUS_OFFLINE
+	}
+}
+
+// volumeMountPath returns the resolver of the paths the volumes of driver
+// are mounted at, which objectstores are served from
+func volumeMountPath(driver string) objectstore.MountPathResolver {
+	return func(volumeID string) (string, error) {
+		d, err := volumedrivers.Get(driver)
+		if err != nil {
+			return "", err
+		}
+		vols, err := d.Inspect([]string{volumeID})
+		if err != nil {
+			return "", err
+		}
+		if len(vols) == 0 {
+			return "", fmt.Errorf("Volume %s not found", volumeID)
+		}
+		if len(vols[0].AttachPath) == 0 {
+			return "", fmt.Errorf("Volume %s is not mounted", volumeID)
+		}
+		return vols[0].AttachPath[0], nil
+	}
+}
+
 func showVersion(c *cli.Context) error {
 	fmt.Println("OSD Version:", config.Version)
 	fmt.Println("Go Version:", runtime.Version())
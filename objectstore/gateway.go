@@ -0,0 +1,445 @@
+/*
+Objectstore serving an S3 compatible API from volumes
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package objectstore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/pborman/uuid"
+	"github.com/portworx/kvdb"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	objectstoreKvPrefix = "/objectstore/"
+	// objectstoreLockKey is the kvdb lock of the objectstores of all the
+	// nodes, outside of objectstoreKvPrefix
+	objectstoreLockKey = "objectstore_lock"
+
+	// StatusRunning is the status of an objectstore serving requests
+	StatusRunning = "running"
+	// StatusStopped is the status of a disabled objectstore
+	StatusStopped = "stopped"
+	// StatusFailed is the status of an objectstore that failed to start
+	StatusFailed = "failed"
+
+	// DefaultGatewayPort is the port of the first objectstore
+	DefaultGatewayPort = 9010
+	// DefaultRegion is the region requests to the objectstores are signed
+	// for
+	DefaultRegion = "us-east-1"
+
+	accessKeyChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	accessKeyLength = 20
+	secretKeyLength = 30
+	shutdownTimeout = 10 * time.Second
+)
+
+var (
+	// ErrObjectStoreNotFound is returned for an unknown objectstore id
+	ErrObjectStoreNotFound = errors.New("Objectstore not found")
+	// ErrObjectStoreExists is returned when a volume already has an
+	// objectstore
+	ErrObjectStoreExists = errors.New("Volume already has an objectstore")
+	// ErrObjectStoreServedElsewhere is returned when an objectstore served
+	// by another node which is online is updated or deleted from this node
+	ErrObjectStoreServedElsewhere = errors.New(
+		"Objectstore is served by another node, update it from that node")
+
+	// resumeInterval is how often the objectstores this node served
+	// before it restarted are retried until their volumes are mounted
+	resumeInterval = 10 * time.Second
+)
+
+// MountPathResolver returns the path a volume is mounted at on this node,
+// or an error if it is not attached and mounted here.
+type MountPathResolver func(volumeID string) (string, error)
+
+// NodeOnlineFunc returns false if a node is offline or no longer in the
+// cluster, so that the objectstores it served can be taken over.
+type NodeOnlineFunc func(nodeID string) bool
+
+// GatewayConfig is the configuration of the objectstores of a node
+type GatewayConfig struct {
+	// Host is the address clients reach the objectstores of this node at
+	Host string
+	// BasePort is the port of the first objectstore, the next ones are
+	// given the next free ports
+	BasePort int
+	// Region is the region requests are signed for
+	Region string
+	// NodeID is the id of this node, the objectstores it serves are
+	// resumed when it restarts
+	NodeID string
+	// NodeOnline tells if the node serving an objectstore is online. The
+	// objectstores of the nodes which are not can be served, stopped or
+	// deleted from this node. All the nodes are online if it is nil.
+	NodeOnline NodeOnlineFunc
+}
+
+// gatewayMgr is an ObjectStore serving the S3 API from volumes. The
+// objectstores are kept in kvdb and are served by the node that enabled
+// them, where their volume is mounted. They are changed under the kvdb lock
+// so that the nodes do not give the same volume or port to two of them.
+type gatewayMgr struct {
+	// lock guards servers, the kvdb lock is taken after it
+	lock    sync.Mutex
+	kv      kvdb.Kvdb
+	resolve MountPathResolver
+	config  GatewayConfig
+	// servers are the objectstores served by this node
+	servers map[string]*gatewayServer
+	// resumed is closed once the objectstores of this node are resumed
+	resumed chan struct{}
+}
+
+// gatewayServer serves an objectstore. Its listener is closed when it is
+// stopped, even if it was stopped before it started serving.
+type gatewayServer struct {
+	*http.Server
+	listener net.Listener
+}
+
+// NewGatewayObjectStore returns an ObjectStore serving the S3 API from the
+// mount paths of volumes.
+func NewGatewayObjectStore(
+	kv kvdb.Kvdb,
+	resolve MountPathResolver,
+	config GatewayConfig,
+) ObjectStore {
+	if config.BasePort == 0 {
+		config.BasePort = DefaultGatewayPort
+	}
+	if config.Region == "" {
+		config.Region = DefaultRegion
+	}
+	m := &gatewayMgr{
+		kv:      kv,
+		resolve: resolve,
+		config:  config,
+		servers: make(map[string]*gatewayServer),
+		resumed: make(chan struct{}),
+	}
+	if config.NodeID != "" {
+		go m.resume()
+	} else {
+		close(m.resumed)
+	}
+	return m
+}
+
+func randomString(n int, chars string) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = chars[int(b[i])%len(chars)]
+	}
+	return string(b), nil
+}
+
+// newKeys generates the access key and the secret key of an objectstore
+func newKeys() (string, string, error) {
+	accessKey, err := randomString(accessKeyLength, accessKeyChars)
+	if err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, secretKeyLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	return accessKey, base64.StdEncoding.EncodeToString(secret), nil
+}
+
+func (m *gatewayMgr) enumerate() ([]*api.ObjectstoreInfo, error) {
+	kvps, err := m.kv.Enumerate(objectstoreKvPrefix)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]*api.ObjectstoreInfo, 0, len(kvps))
+	for _, kvp := range kvps {
+		var info api.ObjectstoreInfo
+		if err := json.Unmarshal(kvp.Value, &info); err != nil {
+			return nil, err
+		}
+		infos = append(infos, &info)
+	}
+	return infos, nil
+}
+
+func (m *gatewayMgr) get(objectstoreID string) (*api.ObjectstoreInfo, error) {
+	var info api.ObjectstoreInfo
+	if _, err := m.kv.GetVal(objectstoreKvPrefix+objectstoreID, &info); err == kvdb.ErrNotFound {
+		return nil, ErrObjectStoreNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// kvLock takes the kvdb lock of the objectstores and returns the function
+// releasing it
+func (m *gatewayMgr) kvLock() (func(), error) {
+	kvp, err := m.kv.LockWithID(objectstoreLockKey, m.config.NodeID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to lock the objectstores: %v", err)
+	}
+	return func() {
+		if err := m.kv.Unlock(kvp); err != nil {
+			logrus.Warnf("Failed to unlock the objectstores: %v", err)
+		}
+	}, nil
+}
+
+func (m *gatewayMgr) ObjectStoreCreate(volumeID string) (*api.ObjectstoreInfo, error) {
+	if volumeID == "" {
+		return nil, fmt.Errorf("Must supply a volume id")
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	unlock, err := m.kvLock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	infos, err := m.enumerate()
+	if err != nil {
+		return nil, err
+	}
+	port := m.config.BasePort
+	for _, info := range infos {
+		if info.VolumeId == volumeID {
+			return nil, ErrObjectStoreExists
+		}
+		if int(info.AccessPort) >= port {
+			port = int(info.AccessPort) + 1
+		}
+	}
+	accessKey, secretKey, err := newKeys()
+	if err != nil {
+		return nil, err
+	}
+	info := &api.ObjectstoreInfo{
+		Uuid:       uuid.New(),
+		VolumeId:   volumeID,
+		Enabled:    false,
+		Status:     StatusStopped,
+		AccessKey:  accessKey,
+		SecretKey:  secretKey,
+		AccessPort: int64(port),
+		Region:     m.config.Region,
+	}
+	if _, err := m.kv.Create(objectstoreKvPrefix+info.Uuid, info, 0); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (m *gatewayMgr) ObjectStoreInspect(objectstoreID string) (*api.ObjectstoreInfo, error) {
+	return m.get(objectstoreID)
+}
+
+// servedElsewhere returns true if the objectstore is enabled on another
+// node which is online, the only one that can stop serving it
+func (m *gatewayMgr) servedElsewhere(info *api.ObjectstoreInfo) bool {
+	if !info.Enabled || info.NodeId == m.config.NodeID {
+		return false
+	}
+	if m.config.NodeOnline != nil && !m.config.NodeOnline(info.NodeId) {
+		logrus.Infof("Node %s serving objectstore %s is offline, taking it over", info.NodeId, info.Uuid)
+		return false
+	}
+	return true
+}
+
+func (m *gatewayMgr) ObjectStoreUpdate(objectstoreID string, enable bool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	unlock, err := m.kvLock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	info, err := m.get(objectstoreID)
+	if err != nil {
+		return err
+	}
+	if m.servedElsewhere(info) {
+		return ErrObjectStoreServedElsewhere
+	}
+	if enable {
+		info.NodeId = m.config.NodeID
+		if err := m.start(info); err != nil {
+			info.Status = StatusFailed
+			m.kv.Put(objectstoreKvPrefix+info.Uuid, info, 0)
+			return err
+		}
+		info.Enabled = true
+		info.Status = StatusRunning
+	} else {
+		m.stop(info.Uuid)
+		info.Enabled = false
+		info.Status = StatusStopped
+		info.NodeId = ""
+		info.CurrentEndpoint = ""
+		info.Endpoints = nil
+	}
+	_, err = m.kv.Put(objectstoreKvPrefix+info.Uuid, info, 0)
+	return err
+}
+
+func (m *gatewayMgr) ObjectStoreDelete(objectstoreID string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	unlock, err := m.kvLock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	info, err := m.get(objectstoreID)
+	if err != nil {
+		return err
+	}
+	if m.servedElsewhere(info) {
+		return ErrObjectStoreServedElsewhere
+	}
+	m.stop(objectstoreID)
+	if _, err := m.kv.Delete(objectstoreKvPrefix + objectstoreID); err == kvdb.ErrNotFound {
+		return ErrObjectStoreNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// resume serves the objectstores this node served before it restarted,
+// retrying until their volumes are mounted on this node
+func (m *gatewayMgr) resume() {
+	defer close(m.resumed)
+	for {
+		pending, err := m.resumeOnce()
+		if err != nil {
+			logrus.Warnf("Failed to resume the objectstores: %v", err)
+		} else if pending == 0 {
+			return
+		}
+		time.Sleep(resumeInterval)
+	}
+}
+
+// resumeOnce tries to serve the objectstores enabled on this node and
+// returns how many of them could not be served yet
+func (m *gatewayMgr) resumeOnce() (int, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	unlock, err := m.kvLock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	infos, err := m.enumerate()
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, info := range infos {
+		if !info.Enabled || info.NodeId != m.config.NodeID {
+			continue
+		}
+		if _, ok := m.servers[info.Uuid]; ok {
+			continue
+		}
+		status := StatusRunning
+		if err := m.start(info); err != nil {
+			logrus.Warnf("Failed to resume objectstore %s: %v", info.Uuid, err)
+			status = StatusFailed
+			pending++
+		}
+		info.Status = status
+		if _, err := m.kv.Put(objectstoreKvPrefix+info.Uuid, info, 0); err != nil {
+			return 0, err
+		}
+	}
+	return pending, nil
+}
+
+// start serves the objectstore from the mount path of its volume and
+// records its endpoint in info.
+func (m *gatewayMgr) start(info *api.ObjectstoreInfo) error {
+	if _, ok := m.servers[info.Uuid]; ok {
+		return nil
+	}
+	root, err := m.resolve(info.VolumeId)
+	if err != nil {
+		return fmt.Errorf("Objectstore volume %s is not mounted on this node: %v", info.VolumeId, err)
+	}
+	l, err := net.Listen("tcp", ":"+strconv.FormatInt(info.AccessPort, 10))
+	if err != nil {
+		return fmt.Errorf("Failed to listen on port %d: %v", info.AccessPort, err)
+	}
+	server := &http.Server{
+		Handler: newS3Gateway(root, info.AccessKey, info.SecretKey, info.Region),
+	}
+	go func() {
+		if err := server.Serve(l); err != nil && err != http.ErrServerClosed {
+			logrus.Errorf("Objectstore %s stopped: %v", info.Uuid, err)
+		}
+	}()
+	m.servers[info.Uuid] = &gatewayServer{Server: server, listener: l}
+
+	host := m.config.Host
+	if host == "" {
+		host = "localhost"
+	}
+	endpoint := "http://" + net.JoinHostPort(host, strconv.FormatInt(info.AccessPort, 10))
+	info.CurrentEndpoint = endpoint
+	info.Endpoints = []string{endpoint}
+	logrus.Infof("Objectstore %s serving volume %s at %s", info.Uuid, info.VolumeId, endpoint)
+	return nil
+}
+
+// stop stops serving an objectstore from this node
+func (m *gatewayMgr) stop(objectstoreID string) {
+	server, ok := m.servers[objectstoreID]
+	if !ok {
+		return
+	}
+	delete(m.servers, objectstoreID)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		logrus.Warnf("Failed to stop objectstore %s: %v", objectstoreID, err)
+		server.Close()
+	}
+	server.listener.Close()
+}
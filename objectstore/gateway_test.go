@@ -0,0 +1,287 @@
+/*
+Tests the objectstore serving the S3 API from volumes
+Copyright 2018 Portworx
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+	http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/portworx/kvdb"
+	"github.com/portworx/kvdb/mem"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestGatewayObjectStore(t *testing.T) {
+	root, err := ioutil.TempDir("", "objectstore_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	kv, err := kvdb.New(mem.Name, "objectstore_test", []string{}, nil, logrus.Panicf)
+	require.NoError(t, err)
+	resolve := func(volumeID string) (string, error) {
+		if volumeID != "vol1" {
+			return "", fmt.Errorf("Volume %s is not attached", volumeID)
+		}
+		return root, nil
+	}
+	port := freePort(t)
+	m := NewGatewayObjectStore(kv, resolve, GatewayConfig{Host: "127.0.0.1", BasePort: port})
+
+	info, err := m.ObjectStoreCreate("vol1")
+	require.NoError(t, err)
+	assert.Equal(t, "vol1", info.VolumeId)
+	assert.False(t, info.Enabled)
+	assert.Equal(t, StatusStopped, info.Status)
+	assert.Len(t, info.AccessKey, accessKeyLength)
+	assert.NotEmpty(t, info.SecretKey)
+	assert.Equal(t, int64(port), info.AccessPort)
+	assert.Equal(t, DefaultRegion, info.Region)
+
+	_, err = m.ObjectStoreCreate("vol1")
+	assert.Equal(t, ErrObjectStoreExists, err)
+
+	// Volumes not mounted on this node cannot be served
+	other, err := m.ObjectStoreCreate("vol2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(port+1), other.AccessPort)
+	assert.Error(t, m.ObjectStoreUpdate(other.Uuid, true))
+	other, err = m.ObjectStoreInspect(other.Uuid)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, other.Status)
+	assert.NoError(t, m.ObjectStoreDelete(other.Uuid))
+
+	require.NoError(t, m.ObjectStoreUpdate(info.Uuid, true))
+	info, err = m.ObjectStoreInspect(info.Uuid)
+	require.NoError(t, err)
+	assert.True(t, info.Enabled)
+	assert.Equal(t, StatusRunning, info.Status)
+	assert.Equal(t, fmt.Sprintf("http://127.0.0.1:%d", port), info.CurrentEndpoint)
+	// Enabling a running objectstore is a no-op
+	require.NoError(t, m.ObjectStoreUpdate(info.Uuid, true))
+
+	c := &s3TestClient{
+		t:   t,
+		url: info.CurrentEndpoint,
+		creds: &credentials{
+			accessKey: info.AccessKey,
+			secretKey: info.SecretKey,
+			region:    info.Region,
+		},
+	}
+	c.expect(http.StatusOK, http.MethodPut, "/bucket", nil, nil)
+	c.expect(http.StatusOK, http.MethodPut, "/bucket/key", []byte("data"), nil)
+	b, err := ioutil.ReadFile(filepath.Join(root, "bucket", "key"))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(b))
+
+	require.NoError(t, m.ObjectStoreUpdate(info.Uuid, false))
+	info, err = m.ObjectStoreInspect(info.Uuid)
+	require.NoError(t, err)
+	assert.False(t, info.Enabled)
+	assert.Equal(t, StatusStopped, info.Status)
+	assert.Empty(t, info.CurrentEndpoint)
+	_, err = http.Get(fmt.Sprintf("http://127.0.0.1:%d/", port))
+	assert.Error(t, err)
+
+	assert.NoError(t, m.ObjectStoreDelete(info.Uuid))
+	_, err = m.ObjectStoreInspect(info.Uuid)
+	assert.Equal(t, ErrObjectStoreNotFound, err)
+	assert.Equal(t, ErrObjectStoreNotFound, m.ObjectStoreDelete(info.Uuid))
+
+	// The data stays on the volume
+	_, err = os.Stat(filepath.Join(root, "bucket", "key"))
+	assert.NoError(t, err)
+}
+
+func waitObjectStoreStatus(t *testing.T, m ObjectStore, id, status string) *api.ObjectstoreInfo {
+	for i := 0; i < 100; i++ {
+		info, err := m.ObjectStoreInspect(id)
+		require.NoError(t, err)
+		if info.Status == status {
+			return info
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Objectstore %s did not reach status %s", id, status)
+	return nil
+}
+
+func TestGatewayObjectStoreServingNode(t *testing.T) {
+	root, err := ioutil.TempDir("", "objectstore_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	interval := resumeInterval
+	resumeInterval = 10 * time.Millisecond
+	defer func() {
+		resumeInterval = interval
+	}()
+
+	kv, err := kvdb.New(mem.Name, "objectstore_node_test", []string{}, nil, logrus.Panicf)
+	require.NoError(t, err)
+	var mounted int32
+	resolve := func(volumeID string) (string, error) {
+		if atomic.LoadInt32(&mounted) == 0 {
+			return "", fmt.Errorf("Volume %s is not mounted", volumeID)
+		}
+		return root, nil
+	}
+	port := freePort(t)
+	config := GatewayConfig{Host: "127.0.0.1", BasePort: port, NodeID: "node1"}
+	m := NewGatewayObjectStore(kv, resolve, config)
+	peer := NewGatewayObjectStore(kv, resolve, GatewayConfig{NodeID: "node2"})
+	<-m.(*gatewayMgr).resumed
+	<-peer.(*gatewayMgr).resumed
+
+	atomic.StoreInt32(&mounted, 1)
+	info, err := m.ObjectStoreCreate("vol1")
+	require.NoError(t, err)
+	require.NoError(t, m.ObjectStoreUpdate(info.Uuid, true))
+	info, err = m.ObjectStoreInspect(info.Uuid)
+	require.NoError(t, err)
+	assert.Equal(t, "node1", info.NodeId)
+
+	// Only the serving node can stop or delete the objectstore
+	assert.Equal(t, ErrObjectStoreServedElsewhere, peer.ObjectStoreUpdate(info.Uuid, false))
+	assert.Equal(t, ErrObjectStoreServedElsewhere, peer.ObjectStoreDelete(info.Uuid))
+	info, err = peer.ObjectStoreInspect(info.Uuid)
+	require.NoError(t, err)
+	assert.Equal(t, StatusRunning, info.Status)
+
+	// The objectstore is served again once its volume is mounted after a
+	// restart of the node
+	m.(*gatewayMgr).stop(info.Uuid)
+	atomic.StoreInt32(&mounted, 0)
+	m = NewGatewayObjectStore(kv, resolve, config)
+	info = waitObjectStoreStatus(t, m, info.Uuid, StatusFailed)
+	atomic.StoreInt32(&mounted, 1)
+	info = waitObjectStoreStatus(t, m, info.Uuid, StatusRunning)
+	<-m.(*gatewayMgr).resumed
+	assert.True(t, info.Enabled)
+	assert.Equal(t, fmt.Sprintf("http://127.0.0.1:%d", port), info.CurrentEndpoint)
+
+	require.NoError(t, m.ObjectStoreUpdate(info.Uuid, false))
+	info, err = m.ObjectStoreInspect(info.Uuid)
+	require.NoError(t, err)
+	assert.Empty(t, info.NodeId)
+	assert.NoError(t, peer.ObjectStoreDelete(info.Uuid))
+}
+
+func TestGatewayObjectStoreTakeover(t *testing.T) {
+	root, err := ioutil.TempDir("", "objectstore_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	kv, err := kvdb.New(mem.Name, "objectstore_takeover_test", []string{}, nil, logrus.Panicf)
+	require.NoError(t, err)
+	resolve := func(volumeID string) (string, error) {
+		return root, nil
+	}
+	var online int32 = 1
+	nodeOnline := func(nodeID string) bool {
+		return nodeID != "node1" || atomic.LoadInt32(&online) != 0
+	}
+	m := NewGatewayObjectStore(kv, resolve, GatewayConfig{
+		Host:       "127.0.0.1",
+		BasePort:   freePort(t),
+		NodeID:     "node1",
+		NodeOnline: nodeOnline,
+	})
+	peer := NewGatewayObjectStore(kv, resolve, GatewayConfig{
+		Host:       "127.0.0.1",
+		NodeID:     "node2",
+		NodeOnline: nodeOnline,
+	})
+	<-m.(*gatewayMgr).resumed
+	<-peer.(*gatewayMgr).resumed
+
+	info, err := m.ObjectStoreCreate("vol1")
+	require.NoError(t, err)
+	require.NoError(t, m.ObjectStoreUpdate(info.Uuid, true))
+	assert.Equal(t, ErrObjectStoreServedElsewhere, peer.ObjectStoreUpdate(info.Uuid, true))
+
+	// The objectstore of a node which went down is served by the peer
+	m.(*gatewayMgr).stop(info.Uuid)
+	atomic.StoreInt32(&online, 0)
+	require.NoError(t, peer.ObjectStoreUpdate(info.Uuid, true))
+	defer peer.(*gatewayMgr).stop(info.Uuid)
+	info, err = peer.ObjectStoreInspect(info.Uuid)
+	require.NoError(t, err)
+	assert.Equal(t, "node2", info.NodeId)
+	assert.Equal(t, StatusRunning, info.Status)
+
+	// The node does not serve it again when it comes back
+	atomic.StoreInt32(&online, 1)
+	assert.Equal(t, ErrObjectStoreServedElsewhere, m.ObjectStoreDelete(info.Uuid))
+	pending, err := m.(*gatewayMgr).resumeOnce()
+	require.NoError(t, err)
+	assert.Zero(t, pending)
+	assert.Empty(t, m.(*gatewayMgr).servers)
+}
+
+func TestGatewayObjectStoreConcurrentCreate(t *testing.T) {
+	kv, err := kvdb.New(mem.Name, "objectstore_create_test", []string{}, nil, logrus.Panicf)
+	require.NoError(t, err)
+	resolve := func(volumeID string) (string, error) {
+		return "", fmt.Errorf("Volume %s is not mounted", volumeID)
+	}
+	nodes := make([]ObjectStore, 4)
+	for i := range nodes {
+		nodes[i] = NewGatewayObjectStore(kv, resolve, GatewayConfig{NodeID: fmt.Sprintf("node%d", i)})
+	}
+
+	// The nodes create objectstores for the same volumes at the same time
+	type result struct {
+		info *api.ObjectstoreInfo
+		err  error
+	}
+	results := make(chan result, 2*len(nodes))
+	for _, volumeID := range []string{"vol1", "vol2"} {
+		for _, node := range nodes {
+			go func(node ObjectStore, volumeID string) {
+				info, err := node.ObjectStoreCreate(volumeID)
+				results <- result{info, err}
+			}(node, volumeID)
+		}
+	}
+	ports := make(map[int64]string)
+	for i := 0; i < 2*len(nodes); i++ {
+		r := <-results
+		if r.err != nil {
+			assert.Equal(t, ErrObjectStoreExists, r.err)
+			continue
+		}
+		assert.NotContains(t, ports, r.info.AccessPort)
+		ports[r.info.AccessPort] = r.info.VolumeId
+	}
+	assert.Len(t, ports, 2)
+}
@@ -0,0 +1,1077 @@
+/*
+S3 compatible gateway serving the files of a directory
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package objectstore
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	s3Namespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+	// gatewayDir is the directory at the root of the volume where the
+	// gateway keeps the metadata of the objects and the multipart
+	// uploads. Bucket names cannot start with a dot, so it is never
+	// served as a bucket.
+	gatewayDir   = ".objectstore"
+	metaDir      = "meta"
+	uploadsDir   = "uploads"
+	tmpDir       = "tmp"
+	metaSuffix   = ".json"
+	uploadFile   = "upload.json"
+	userMetaHdr  = "X-Amz-Meta-"
+	maxKeyLength = 1024
+	maxListKeys  = 1000
+	maxParts     = 10000
+	// minPartSize is the smallest size of the parts of a multipart
+	// upload but the last one
+	minPartSize  = 5 * 1024 * 1024
+	defaultOwner = "objectstore"
+	defaultMIME  = "binary/octet-stream"
+	storageClass = "STANDARD"
+	iso8601      = "2006-01-02T15:04:05.000Z"
+)
+
+var bucketNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// objectMeta is the metadata of an object. The size and the modification
+// time of the file are recorded to detect files changed outside of the
+// gateway, whose ETag is then computed again.
+type objectMeta struct {
+	ETag        string            `json:"etag"`
+	ContentType string            `json:"content_type,omitempty"`
+	UserMeta    map[string]string `json:"user_meta,omitempty"`
+	Size        int64             `json:"size"`
+	ModTime     time.Time         `json:"mod_time"`
+}
+
+// multipartUpload is a multipart upload in progress
+type multipartUpload struct {
+	Bucket      string            `json:"bucket"`
+	Key         string            `json:"key"`
+	ContentType string            `json:"content_type,omitempty"`
+	UserMeta    map[string]string `json:"user_meta,omitempty"`
+	Initiated   time.Time         `json:"initiated"`
+}
+
+// s3Gateway serves the S3 API from the files of a directory. Buckets are
+// the top level directories and the keys of objects are paths in them.
+// Only path style requests signed with AWS Signature Version 4 are
+// supported.
+type s3Gateway struct {
+	root  string
+	creds credentials
+	// now is the clock requests are verified with
+	now func() time.Time
+}
+
+func newS3Gateway(root, accessKey, secretKey, region string) *s3Gateway {
+	return &s3Gateway{
+		root: root,
+		creds: credentials{
+			accessKey: accessKey,
+			secretKey: secretKey,
+			region:    region,
+		},
+		now: time.Now,
+	}
+}
+
+// s3Request is a verified request to the gateway
+type s3Request struct {
+	*http.Request
+	bucket string
+	key    string
+	// payloadHash is the signed hash of the body
+	payloadHash string
+}
+
+func (g *s3Gateway) writeError(w http.ResponseWriter, r *http.Request, err *s3Error) {
+	if err.status >= http.StatusInternalServerError {
+		logrus.Errorf("Objectstore request %s %s failed: %v", r.Method, r.URL.Path, err)
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(err.status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(&s3ErrorResponse{
+		Code:     err.code,
+		Message:  err.message,
+		Resource: r.URL.Path,
+	})
+}
+
+func (g *s3Gateway) writeXML(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	io.WriteString(w, xml.Header)
+	if err := xml.NewEncoder(w).Encode(v); err != nil {
+		logrus.Errorf("Failed to encode objectstore response: %v", err)
+	}
+}
+
+// internalError logs err and returns the S3 error it is reported as
+func internalError(err error) *s3Error {
+	if os.IsPermission(err) {
+		return errAccessDenied
+	}
+	logrus.Errorf("Objectstore error: %v", err)
+	return errInternalError
+}
+
+func (g *s3Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	payloadHash, err := verifySigV4(r, &g.creds, g.now())
+	if err != nil {
+		g.writeError(w, r, err)
+		return
+	}
+	req := &s3Request{Request: r, payloadHash: payloadHash}
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	req.bucket = parts[0]
+	if len(parts) == 2 {
+		req.key = parts[1]
+	}
+
+	query := r.URL.Query()
+	switch {
+	case req.bucket == "":
+		if r.Method != http.MethodGet {
+			err = errMethodNotAllowed
+			break
+		}
+		err = g.listBuckets(w, req)
+	case req.key == "":
+		if err = checkBucketName(req.bucket); err != nil {
+			break
+		}
+		switch r.Method {
+		case http.MethodPut:
+			err = g.createBucket(w, req)
+		case http.MethodHead:
+			err = g.headBucket(w, req)
+		case http.MethodDelete:
+			err = g.deleteBucket(w, req)
+		case http.MethodGet:
+			if _, ok := query["location"]; ok {
+				err = g.getBucketLocation(w, req)
+			} else if query.Get("list-type") == "2" {
+				err = g.listObjectsV2(w, req)
+			} else {
+				err = errNotImplemented
+			}
+		case http.MethodPost:
+			if _, ok := query["delete"]; ok {
+				err = g.deleteObjects(w, req)
+			} else {
+				err = errNotImplemented
+			}
+		default:
+			err = errMethodNotAllowed
+		}
+	default:
+		if err = checkBucketName(req.bucket); err != nil {
+			break
+		}
+		if err = checkKey(req.key); err != nil {
+			break
+		}
+		_, uploads := query["uploads"]
+		uploadID := query.Get("uploadId")
+		switch {
+		case r.Method == http.MethodPost && uploads:
+			err = g.createMultipartUpload(w, req)
+		case r.Method == http.MethodPut && uploadID != "":
+			err = g.uploadPart(w, req, uploadID)
+		case r.Method == http.MethodPost && uploadID != "":
+			err = g.completeMultipartUpload(w, req, uploadID)
+		case r.Method == http.MethodDelete && uploadID != "":
+			err = g.abortMultipartUpload(w, req, uploadID)
+		case r.Method == http.MethodPut:
+			err = g.putObject(w, req)
+		case r.Method == http.MethodGet || r.Method == http.MethodHead:
+			err = g.getObject(w, req)
+		case r.Method == http.MethodDelete:
+			err = g.deleteObject(w, req)
+		default:
+			err = errMethodNotAllowed
+		}
+	}
+	if err != nil {
+		g.writeError(w, r, err)
+	}
+}
+
+func checkBucketName(bucket string) *s3Error {
+	if !bucketNameRegexp.MatchString(bucket) || strings.Contains(bucket, "..") {
+		return errInvalidBucketName
+	}
+	return nil
+}
+
+// checkKey rejects the keys that cannot be stored as a path in a bucket
+func checkKey(key string) *s3Error {
+	if len(key) > maxKeyLength {
+		return errKeyTooLong
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return errInvalidArgument("Object keys with empty, '.' or '..' path segments are not supported")
+		}
+	}
+	if strings.ContainsRune(key, 0) {
+		return errInvalidArgument("Object keys cannot contain NUL characters")
+	}
+	return nil
+}
+
+func (g *s3Gateway) bucketPath(bucket string) string {
+	return filepath.Join(g.root, bucket)
+}
+
+func (g *s3Gateway) objectPath(bucket, key string) string {
+	return filepath.Join(g.root, bucket, filepath.FromSlash(key))
+}
+
+func (g *s3Gateway) metaPath(bucket, key string) string {
+	return filepath.Join(g.root, gatewayDir, metaDir, bucket, filepath.FromSlash(key)+metaSuffix)
+}
+
+func (g *s3Gateway) uploadPath(uploadID string) string {
+	return filepath.Join(g.root, gatewayDir, uploadsDir, uploadID)
+}
+
+// checkBucket returns an error if the bucket does not exist
+func (g *s3Gateway) checkBucket(bucket string) *s3Error {
+	fi, err := os.Stat(g.bucketPath(bucket))
+	if os.IsNotExist(err) || (err == nil && !fi.IsDir()) {
+		return errNoSuchBucket
+	} else if err != nil {
+		return internalError(err)
+	}
+	return nil
+}
+
+// tempFile creates a file on the volume that is renamed into place
+func (g *s3Gateway) tempFile() (*os.File, error) {
+	dir := filepath.Join(g.root, gatewayDir, tmpDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return ioutil.TempFile(dir, "object")
+}
+
+type listAllMyBucketsResult struct {
+	XMLName xml.Name `xml:"ListAllMyBucketsResult"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Owner   owner    `xml:"Owner"`
+	Buckets []bucket `xml:"Buckets>Bucket"`
+}
+
+type owner struct {
+	ID          string `xml:"ID"`
+	DisplayName string `xml:"DisplayName"`
+}
+
+type bucket struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+func (g *s3Gateway) listBuckets(w http.ResponseWriter, r *s3Request) *s3Error {
+	files, err := ioutil.ReadDir(g.root)
+	if err != nil {
+		return internalError(err)
+	}
+	result := &listAllMyBucketsResult{
+		Xmlns:   s3Namespace,
+		Owner:   owner{ID: defaultOwner, DisplayName: defaultOwner},
+		Buckets: []bucket{},
+	}
+	for _, f := range files {
+		if !f.IsDir() || checkBucketName(f.Name()) != nil {
+			continue
+		}
+		result.Buckets = append(result.Buckets, bucket{
+			Name:         f.Name(),
+			CreationDate: f.ModTime().UTC().Format(iso8601),
+		})
+	}
+	g.writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+func (g *s3Gateway) createBucket(w http.ResponseWriter, r *s3Request) *s3Error {
+	if err := os.Mkdir(g.bucketPath(r.bucket), 0755); os.IsExist(err) {
+		return errBucketAlreadyOwnedByYou
+	} else if err != nil {
+		return internalError(err)
+	}
+	w.Header().Set("Location", "/"+r.bucket)
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (g *s3Gateway) headBucket(w http.ResponseWriter, r *s3Request) *s3Error {
+	if err := g.checkBucket(r.bucket); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+type locationConstraint struct {
+	XMLName  xml.Name `xml:"LocationConstraint"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Location string   `xml:",chardata"`
+}
+
+func (g *s3Gateway) getBucketLocation(w http.ResponseWriter, r *s3Request) *s3Error {
+	if err := g.checkBucket(r.bucket); err != nil {
+		return err
+	}
+	g.writeXML(w, http.StatusOK, &locationConstraint{Xmlns: s3Namespace, Location: g.creds.region})
+	return nil
+}
+
+func (g *s3Gateway) deleteBucket(w http.ResponseWriter, r *s3Request) *s3Error {
+	if err := g.checkBucket(r.bucket); err != nil {
+		return err
+	}
+	if err := os.Remove(g.bucketPath(r.bucket)); err != nil {
+		if fis, readErr := ioutil.ReadDir(g.bucketPath(r.bucket)); readErr == nil && len(fis) != 0 {
+			return errBucketNotEmpty
+		}
+		return internalError(err)
+	}
+	os.RemoveAll(filepath.Join(g.root, gatewayDir, metaDir, r.bucket))
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// stat returns the file info and the metadata of an object
+func (g *s3Gateway) stat(bucket, key string) (os.FileInfo, *objectMeta, *s3Error) {
+	fi, err := os.Stat(g.objectPath(bucket, key))
+	if os.IsNotExist(err) || (err == nil && fi.IsDir()) || isNotDir(err) {
+		if berr := g.checkBucket(bucket); berr != nil {
+			return nil, nil, berr
+		}
+		return nil, nil, errNoSuchKey
+	} else if err != nil {
+		return nil, nil, internalError(err)
+	}
+	if !fi.Mode().IsRegular() || !g.contained(g.objectPath(bucket, key)) {
+		return nil, nil, errAccessDenied
+	}
+
+	meta := &objectMeta{}
+	b, err := ioutil.ReadFile(g.metaPath(bucket, key))
+	if err == nil {
+		err = json.Unmarshal(b, meta)
+	}
+	if err != nil || meta.Size != fi.Size() || !meta.ModTime.Equal(fi.ModTime()) {
+		// The file was not written by the gateway or changed since
+		etag, err := fileMD5(g.objectPath(bucket, key))
+		if err != nil {
+			return nil, nil, internalError(err)
+		}
+		meta.ETag = etag
+		meta.Size = fi.Size()
+		meta.ModTime = fi.ModTime()
+		if err := g.writeMeta(bucket, key, meta); err != nil {
+			logrus.Warnf("Failed to save the metadata of object %s/%s: %v", bucket, key, err)
+		}
+	}
+	return fi, meta, nil
+}
+
+func isNotDir(err error) bool {
+	if pe, ok := err.(*os.PathError); ok {
+		return pe.Err == syscall.ENOTDIR
+	}
+	return false
+}
+
+// contained returns true if path resolves to a path in the root of the
+// gateway, so that symbolic links on the volume cannot expose the files
+// of the node.
+func (g *s3Gateway) contained(path string) bool {
+	root, err := filepath.EvalSymlinks(g.root)
+	if err != nil {
+		return false
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	return resolved == root || strings.HasPrefix(resolved, root+string(filepath.Separator))
+}
+
+func fileMD5(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (g *s3Gateway) writeMeta(bucket, key string, meta *objectMeta) error {
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	path := g.metaPath(bucket, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// userMeta returns the x-amz-meta-* headers of a request
+func userMeta(h http.Header) map[string]string {
+	var meta map[string]string
+	for k, v := range h {
+		if strings.HasPrefix(k, userMetaHdr) && len(v) != 0 {
+			if meta == nil {
+				meta = make(map[string]string)
+			}
+			meta[strings.ToLower(strings.TrimPrefix(k, userMetaHdr))] = v[0]
+		}
+	}
+	return meta
+}
+
+// receive writes the body of a request to a temporary file and verifies
+// its signed SHA256 and its Content-MD5. It returns the file and the hex
+// MD5 of the body.
+func (g *s3Gateway) receive(r *s3Request) (string, string, *s3Error) {
+	var contentMD5 []byte
+	if h := r.Header.Get("Content-Md5"); h != "" {
+		b, err := base64.StdEncoding.DecodeString(h)
+		if err != nil || len(b) != md5.Size {
+			return "", "", errInvalidDigest
+		}
+		contentMD5 = b
+	}
+
+	f, err := g.tempFile()
+	if err != nil {
+		return "", "", internalError(err)
+	}
+	md5Hash := md5.New()
+	var shaHash hash.Hash
+	writers := []io.Writer{f, md5Hash}
+	if r.payloadHash != unsignedPayload {
+		shaHash = sha256.New()
+		writers = append(writers, shaHash)
+	}
+	_, err = io.Copy(io.MultiWriter(writers...), r.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", "", internalError(err)
+	}
+	if shaHash != nil && hex.EncodeToString(shaHash.Sum(nil)) != strings.ToLower(r.payloadHash) {
+		os.Remove(f.Name())
+		return "", "", errContentSHA256Mismatch
+	}
+	sum := md5Hash.Sum(nil)
+	if contentMD5 != nil && string(contentMD5) != string(sum) {
+		os.Remove(f.Name())
+		return "", "", errBadDigest
+	}
+	return f.Name(), hex.EncodeToString(sum), nil
+}
+
+// store moves a received file into place as an object
+func (g *s3Gateway) store(tmp, bucket, key string, meta *objectMeta) *s3Error {
+	path := g.objectPath(bucket, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errInvalidArgument("Object key conflicts with an existing object: " + err.Error())
+	}
+	if !g.contained(filepath.Dir(path)) {
+		return errAccessDenied
+	}
+	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+		return errInvalidArgument("Object key conflicts with the prefix of existing objects")
+	}
+	if err := os.Chmod(tmp, 0644); err != nil {
+		return internalError(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return internalError(err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return internalError(err)
+	}
+	meta.Size = fi.Size()
+	meta.ModTime = fi.ModTime()
+	if err := g.writeMeta(bucket, key, meta); err != nil {
+		return internalError(err)
+	}
+	return nil
+}
+
+func (g *s3Gateway) putObject(w http.ResponseWriter, r *s3Request) *s3Error {
+	if r.Header.Get("X-Amz-Copy-Source") != "" {
+		return errNotImplemented
+	}
+	if err := g.checkBucket(r.bucket); err != nil {
+		return err
+	}
+	tmp, etag, err := g.receive(r)
+	if err != nil {
+		return err
+	}
+	meta := &objectMeta{
+		ETag:        etag,
+		ContentType: r.Header.Get("Content-Type"),
+		UserMeta:    userMeta(r.Header),
+	}
+	if err := g.store(tmp, r.bucket, r.key, meta); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	w.Header().Set("ETag", strconv.Quote(etag))
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (g *s3Gateway) getObject(w http.ResponseWriter, r *s3Request) *s3Error {
+	_, meta, serr := g.stat(r.bucket, r.key)
+	if serr != nil {
+		return serr
+	}
+	f, err := os.Open(g.objectPath(r.bucket, r.key))
+	if os.IsNotExist(err) {
+		return errNoSuchKey
+	} else if err != nil {
+		return internalError(err)
+	}
+	defer f.Close()
+
+	h := w.Header()
+	h.Set("ETag", strconv.Quote(meta.ETag))
+	h.Set("Accept-Ranges", "bytes")
+	if meta.ContentType != "" {
+		h.Set("Content-Type", meta.ContentType)
+	} else {
+		h.Set("Content-Type", defaultMIME)
+	}
+	for k, v := range meta.UserMeta {
+		h.Set(userMetaHdr+k, v)
+	}
+	http.ServeContent(w, r.Request, "", meta.ModTime, f)
+	return nil
+}
+
+// removeObject removes an object and the directories of its key left
+// empty
+func (g *s3Gateway) removeObject(bucket, key string) error {
+	if !g.contained(filepath.Dir(g.objectPath(bucket, key))) {
+		return nil
+	}
+	if err := os.Remove(g.objectPath(bucket, key)); err != nil && !os.IsNotExist(err) && !isNotDir(err) {
+		return err
+	}
+	os.Remove(g.metaPath(bucket, key))
+	bucketPath := g.bucketPath(bucket)
+	metaBucketPath := filepath.Join(g.root, gatewayDir, metaDir, bucket)
+	for dir := filepath.Dir(key); dir != "."; dir = filepath.Dir(dir) {
+		if os.Remove(filepath.Join(bucketPath, dir)) != nil {
+			break
+		}
+		os.Remove(filepath.Join(metaBucketPath, dir))
+	}
+	return nil
+}
+
+func (g *s3Gateway) deleteObject(w http.ResponseWriter, r *s3Request) *s3Error {
+	if err := g.checkBucket(r.bucket); err != nil {
+		return err
+	}
+	if fi, err := os.Stat(g.objectPath(r.bucket, r.key)); err == nil && fi.IsDir() {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	if err := g.removeObject(r.bucket, r.key); err != nil {
+		return internalError(err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+type deleteRequest struct {
+	XMLName xml.Name `xml:"Delete"`
+	Quiet   bool     `xml:"Quiet"`
+	Objects []struct {
+		Key string `xml:"Key"`
+	} `xml:"Object"`
+}
+
+type deleteResult struct {
+	XMLName xml.Name        `xml:"DeleteResult"`
+	Xmlns   string          `xml:"xmlns,attr"`
+	Deleted []deletedObject `xml:"Deleted"`
+	Errors  []deleteError   `xml:"Error"`
+}
+
+type deletedObject struct {
+	Key string `xml:"Key"`
+}
+
+type deleteError struct {
+	Key     string `xml:"Key"`
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (g *s3Gateway) deleteObjects(w http.ResponseWriter, r *s3Request) *s3Error {
+	if err := g.checkBucket(r.bucket); err != nil {
+		return err
+	}
+	tmp, _, serr := g.receive(r)
+	if serr != nil {
+		return serr
+	}
+	defer os.Remove(tmp)
+	b, err := ioutil.ReadFile(tmp)
+	if err != nil {
+		return internalError(err)
+	}
+	var req deleteRequest
+	if err := xml.Unmarshal(b, &req); err != nil || len(req.Objects) > maxListKeys {
+		return errMalformedXML
+	}
+	result := &deleteResult{Xmlns: s3Namespace}
+	for _, o := range req.Objects {
+		if serr := checkKey(o.Key); serr != nil {
+			result.Errors = append(result.Errors, deleteError{o.Key, serr.code, serr.message})
+			continue
+		}
+		if err := g.removeObject(r.bucket, o.Key); err != nil {
+			serr := internalError(err)
+			result.Errors = append(result.Errors, deleteError{o.Key, serr.code, serr.message})
+			continue
+		}
+		if !req.Quiet {
+			result.Deleted = append(result.Deleted, deletedObject{o.Key})
+		}
+	}
+	g.writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+type listBucketResultV2 struct {
+	XMLName               xml.Name       `xml:"ListBucketResult"`
+	Xmlns                 string         `xml:"xmlns,attr"`
+	Name                  string         `xml:"Name"`
+	Prefix                string         `xml:"Prefix"`
+	Delimiter             string         `xml:"Delimiter,omitempty"`
+	MaxKeys               int            `xml:"MaxKeys"`
+	KeyCount              int            `xml:"KeyCount"`
+	IsTruncated           bool           `xml:"IsTruncated"`
+	EncodingType          string         `xml:"EncodingType,omitempty"`
+	ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	StartAfter            string         `xml:"StartAfter,omitempty"`
+	Contents              []object       `xml:"Contents"`
+	CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+}
+
+type object struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type commonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+// keys returns the sorted keys of the objects of a bucket
+func (g *s3Gateway) keys(bucket, prefix string) ([]string, error) {
+	bucketPath := g.bucketPath(bucket)
+	// Only walk the directory of the prefix
+	walkRoot := bucketPath
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		walkRoot = filepath.Join(bucketPath, filepath.FromSlash(prefix[:i]))
+	}
+	var keys []string
+	err := filepath.Walk(walkRoot, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) || isNotDir(err) {
+				return nil
+			}
+			return err
+		}
+		if fi.IsDir() || !fi.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(bucketPath, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (g *s3Gateway) listObjectsV2(w http.ResponseWriter, r *s3Request) *s3Error {
+	if err := g.checkBucket(r.bucket); err != nil {
+		return err
+	}
+	query := r.URL.Query()
+	result := &listBucketResultV2{
+		Xmlns:             s3Namespace,
+		Name:              r.bucket,
+		Prefix:            query.Get("prefix"),
+		Delimiter:         query.Get("delimiter"),
+		MaxKeys:           maxListKeys,
+		EncodingType:      query.Get("encoding-type"),
+		ContinuationToken: query.Get("continuation-token"),
+		StartAfter:        query.Get("start-after"),
+	}
+	if result.EncodingType != "" && result.EncodingType != "url" {
+		return errInvalidArgument("Invalid Encoding Method specified in Request")
+	}
+	if mk := query.Get("max-keys"); mk != "" {
+		n, err := strconv.Atoi(mk)
+		if err != nil || n < 0 {
+			return errInvalidArgument("Provided max-keys not an integer or within integer range")
+		}
+		if n < maxListKeys {
+			result.MaxKeys = n
+		}
+	}
+	marker := result.StartAfter
+	if result.ContinuationToken != "" {
+		b, err := base64.RawURLEncoding.DecodeString(result.ContinuationToken)
+		if err != nil {
+			return errInvalidArgument("The continuation token provided is incorrect")
+		}
+		marker = string(b)
+	}
+
+	keys, err := g.keys(r.bucket, result.Prefix)
+	if err != nil {
+		return internalError(err)
+	}
+	encode := func(s string) string {
+		if result.EncodingType == "url" {
+			return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+		}
+		return s
+	}
+	delim := result.Delimiter
+	last := ""
+	for _, key := range keys {
+		if marker != "" && (key <= marker ||
+			(delim != "" && strings.HasSuffix(marker, delim) && strings.HasPrefix(key, marker))) {
+			continue
+		}
+		prefix := ""
+		if delim != "" {
+			if i := strings.Index(key[len(result.Prefix):], delim); i >= 0 {
+				prefix = key[:len(result.Prefix)+i+len(delim)]
+			}
+		}
+		if prefix != "" && prefix == last {
+			continue
+		}
+		if result.KeyCount == result.MaxKeys {
+			result.IsTruncated = true
+			break
+		}
+		if prefix != "" {
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{encode(prefix)})
+			last = prefix
+		} else {
+			fi, meta, serr := g.stat(r.bucket, key)
+			if serr == errNoSuchKey {
+				continue
+			} else if serr != nil {
+				return serr
+			}
+			result.Contents = append(result.Contents, object{
+				Key:          encode(key),
+				LastModified: fi.ModTime().UTC().Format(iso8601),
+				ETag:         strconv.Quote(meta.ETag),
+				Size:         fi.Size(),
+				StorageClass: storageClass,
+			})
+			last = key
+		}
+		result.KeyCount++
+	}
+	if result.IsTruncated {
+		result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(last))
+	}
+	result.Prefix = encode(result.Prefix)
+	result.Delimiter = encode(result.Delimiter)
+	result.StartAfter = encode(result.StartAfter)
+	g.writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+type initiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadID string   `xml:"UploadId"`
+}
+
+func (g *s3Gateway) createMultipartUpload(w http.ResponseWriter, r *s3Request) *s3Error {
+	if err := g.checkBucket(r.bucket); err != nil {
+		return err
+	}
+	uploadID := uuid.New()
+	upload := &multipartUpload{
+		Bucket:      r.bucket,
+		Key:         r.key,
+		ContentType: r.Header.Get("Content-Type"),
+		UserMeta:    userMeta(r.Header),
+		Initiated:   time.Now(),
+	}
+	b, err := json.Marshal(upload)
+	if err != nil {
+		return internalError(err)
+	}
+	dir := g.uploadPath(uploadID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return internalError(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, uploadFile), b, 0600); err != nil {
+		os.RemoveAll(dir)
+		return internalError(err)
+	}
+	g.writeXML(w, http.StatusOK, &initiateMultipartUploadResult{
+		Xmlns:    s3Namespace,
+		Bucket:   r.bucket,
+		Key:      r.key,
+		UploadID: uploadID,
+	})
+	return nil
+}
+
+// loadUpload returns the multipart upload uploadID of the object of r
+func (g *s3Gateway) loadUpload(r *s3Request, uploadID string) (*multipartUpload, *s3Error) {
+	if uuid.Parse(uploadID) == nil {
+		return nil, errNoSuchUpload
+	}
+	b, err := ioutil.ReadFile(filepath.Join(g.uploadPath(uploadID), uploadFile))
+	if os.IsNotExist(err) {
+		return nil, errNoSuchUpload
+	} else if err != nil {
+		return nil, internalError(err)
+	}
+	upload := &multipartUpload{}
+	if err := json.Unmarshal(b, upload); err != nil {
+		return nil, internalError(err)
+	}
+	if upload.Bucket != r.bucket || upload.Key != r.key {
+		return nil, errNoSuchUpload
+	}
+	return upload, nil
+}
+
+func partPath(dir string, number int) string {
+	return filepath.Join(dir, fmt.Sprintf("part.%05d", number))
+}
+
+func (g *s3Gateway) uploadPart(w http.ResponseWriter, r *s3Request, uploadID string) *s3Error {
+	number, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || number < 1 || number > maxParts {
+		return errInvalidArgument("Part number must be an integer between 1 and 10000, inclusive")
+	}
+	if r.Header.Get("X-Amz-Copy-Source") != "" {
+		return errNotImplemented
+	}
+	if _, serr := g.loadUpload(r, uploadID); serr != nil {
+		return serr
+	}
+	tmp, etag, serr := g.receive(r)
+	if serr != nil {
+		return serr
+	}
+	if err := os.Rename(tmp, partPath(g.uploadPath(uploadID), number)); err != nil {
+		os.Remove(tmp)
+		if os.IsNotExist(err) {
+			return errNoSuchUpload
+		}
+		return internalError(err)
+	}
+	w.Header().Set("ETag", strconv.Quote(etag))
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name `xml:"CompleteMultipartUpload"`
+	Parts   []struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	} `xml:"Part"`
+}
+
+type completeMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Location string   `xml:"Location"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	ETag     string   `xml:"ETag"`
+}
+
+func (g *s3Gateway) completeMultipartUpload(w http.ResponseWriter, r *s3Request, uploadID string) *s3Error {
+	upload, serr := g.loadUpload(r, uploadID)
+	if serr != nil {
+		return serr
+	}
+	body, _, serr := g.receive(r)
+	if serr != nil {
+		return serr
+	}
+	b, err := ioutil.ReadFile(body)
+	os.Remove(body)
+	if err != nil {
+		return internalError(err)
+	}
+	var req completeMultipartUpload
+	if err := xml.Unmarshal(b, &req); err != nil || len(req.Parts) == 0 {
+		return errMalformedXML
+	}
+	for i := 1; i < len(req.Parts); i++ {
+		if req.Parts[i].PartNumber <= req.Parts[i-1].PartNumber {
+			return errInvalidPartOrder
+		}
+	}
+
+	dir := g.uploadPath(uploadID)
+	f, err := g.tempFile()
+	if err != nil {
+		return internalError(err)
+	}
+	tmp := f.Name()
+	fail := func(serr *s3Error) *s3Error {
+		f.Close()
+		os.Remove(tmp)
+		return serr
+	}
+	etags := md5.New()
+	for i, part := range req.Parts {
+		pf, err := os.Open(partPath(dir, part.PartNumber))
+		if os.IsNotExist(err) {
+			return fail(errInvalidPart)
+		} else if err != nil {
+			return fail(internalError(err))
+		}
+		h := md5.New()
+		n, err := io.Copy(io.MultiWriter(f, h), pf)
+		pf.Close()
+		if err != nil {
+			return fail(internalError(err))
+		}
+		sum := h.Sum(nil)
+		if strings.Trim(part.ETag, `"`) != hex.EncodeToString(sum) {
+			return fail(errInvalidPart)
+		}
+		if n < minPartSize && i != len(req.Parts)-1 {
+			return fail(errEntityTooSmall)
+		}
+		etags.Write(sum)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return internalError(err)
+	}
+	etag := fmt.Sprintf("%s-%d", hex.EncodeToString(etags.Sum(nil)), len(req.Parts))
+	meta := &objectMeta{
+		ETag:        etag,
+		ContentType: upload.ContentType,
+		UserMeta:    upload.UserMeta,
+	}
+	if serr := g.store(tmp, r.bucket, r.key, meta); serr != nil {
+		os.Remove(tmp)
+		return serr
+	}
+	os.RemoveAll(dir)
+	g.writeXML(w, http.StatusOK, &completeMultipartUploadResult{
+		Xmlns:    s3Namespace,
+		Location: "/" + r.bucket + "/" + r.key,
+		Bucket:   r.bucket,
+		Key:      r.key,
+		ETag:     strconv.Quote(etag),
+	})
+	return nil
+}
+
+func (g *s3Gateway) abortMultipartUpload(w http.ResponseWriter, r *s3Request, uploadID string) *s3Error {
+	if _, serr := g.loadUpload(r, uploadID); serr != nil {
+		return serr
+	}
+	if err := os.RemoveAll(g.uploadPath(uploadID)); err != nil {
+		return internalError(err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
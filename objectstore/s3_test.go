@@ -0,0 +1,373 @@
+/*
+Tests the S3 gateway
+Copyright 2018 Portworx
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+	http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// s3TestClient signs requests to a gateway like the AWS SDKs do
+type s3TestClient struct {
+	t     *testing.T
+	url   string
+	creds *credentials
+}
+
+func (c *s3TestClient) do(method, path string, body []byte, headers map[string]string) *http.Response {
+	r, err := http.NewRequest(method, c.url+path, bytes.NewReader(body))
+	require.NoError(c.t, err)
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	now := time.Now().UTC()
+	payloadHash := sha256Hex(string(body))
+	r.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	r.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	a := &sigV4Auth{
+		accessKey:     c.creds.accessKey,
+		date:          now.Format(amzShortDateFormat),
+		region:        c.creds.region,
+		service:       sigV4Service,
+		signedHeaders: signed,
+		amzDate:       now,
+	}
+	sig := signature(c.creds.secretKey, a, canonicalRequest(r, signed, payloadHash))
+	r.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, a.accessKey, a.scope(), strings.Join(signed, ";"), sig))
+	resp, err := http.DefaultClient.Do(r)
+	require.NoError(c.t, err)
+	return resp
+}
+
+func (c *s3TestClient) expect(status int, method, path string, body []byte, headers map[string]string) []byte {
+	resp := c.do(method, path, body, headers)
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	require.NoError(c.t, err)
+	require.Equal(c.t, status, resp.StatusCode, "%s %s: %s", method, path, string(b))
+	return b
+}
+
+func (c *s3TestClient) expectError(code string, method, path string, body []byte) {
+	resp := c.do(method, path, body, nil)
+	defer resp.Body.Close()
+	var e s3ErrorResponse
+	require.NoError(c.t, xml.NewDecoder(resp.Body).Decode(&e))
+	assert.Equal(c.t, code, e.Code, "%s %s", method, path)
+}
+
+func newTestGateway(t *testing.T) (*s3TestClient, string, func()) {
+	root, err := ioutil.TempDir("", "objectstore_test")
+	require.NoError(t, err)
+	g := newS3Gateway(root, "ACCESSKEY", "secretkey", DefaultRegion)
+	server := httptest.NewServer(g)
+	c := &s3TestClient{t: t, url: server.URL, creds: &g.creds}
+	return c, root, func() {
+		server.Close()
+		os.RemoveAll(root)
+	}
+}
+
+func TestS3Buckets(t *testing.T) {
+	c, root, cleanup := newTestGateway(t)
+	defer cleanup()
+
+	c.expect(http.StatusOK, http.MethodPut, "/bucket1", nil, nil)
+	c.expectError("BucketAlreadyOwnedByYou", http.MethodPut, "/bucket1", nil)
+	c.expectError("InvalidBucketName", http.MethodPut, "/Bad_Bucket", nil)
+	c.expectError("InvalidBucketName", http.MethodPut, "/.objectstore", nil)
+	c.expect(http.StatusOK, http.MethodHead, "/bucket1", nil, nil)
+	c.expect(http.StatusNotFound, http.MethodHead, "/bucket2", nil, nil)
+
+	// Top level directories of the volume are buckets
+	require.NoError(t, os.Mkdir(filepath.Join(root, "bucket2"), 0755))
+	var result listAllMyBucketsResult
+	require.NoError(t, xml.Unmarshal(c.expect(http.StatusOK, http.MethodGet, "/", nil, nil), &result))
+	require.Len(t, result.Buckets, 2)
+	assert.Equal(t, "bucket1", result.Buckets[0].Name)
+	assert.Equal(t, "bucket2", result.Buckets[1].Name)
+
+	c.expect(http.StatusOK, http.MethodPut, "/bucket1/key", []byte("data"), nil)
+	c.expectError("BucketNotEmpty", http.MethodDelete, "/bucket1", nil)
+	c.expect(http.StatusNoContent, http.MethodDelete, "/bucket1/key", nil, nil)
+	c.expect(http.StatusNoContent, http.MethodDelete, "/bucket1", nil, nil)
+	c.expectError("NoSuchBucket", http.MethodDelete, "/bucket1", nil)
+}
+
+func TestS3Objects(t *testing.T) {
+	c, root, cleanup := newTestGateway(t)
+	defer cleanup()
+
+	c.expectError("NoSuchBucket", http.MethodPut, "/bucket/key", []byte("data"))
+	c.expect(http.StatusOK, http.MethodPut, "/bucket", nil, nil)
+
+	data := []byte("hello objectstore")
+	sum := md5.Sum(data)
+	resp := c.do(http.MethodPut, "/bucket/dir/hello.txt", data, map[string]string{
+		"Content-Type":    "text/plain",
+		"X-Amz-Meta-Name": "value",
+	})
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"`+hex.EncodeToString(sum[:])+`"`, resp.Header.Get("ETag"))
+
+	// Objects are files of the bucket directory
+	b, err := ioutil.ReadFile(filepath.Join(root, "bucket", "dir", "hello.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, data, b)
+
+	resp = c.do(http.MethodGet, "/bucket/dir/hello.txt", nil, nil)
+	b, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, data, b)
+	assert.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "value", resp.Header.Get("X-Amz-Meta-Name"))
+	assert.Equal(t, `"`+hex.EncodeToString(sum[:])+`"`, resp.Header.Get("ETag"))
+
+	resp = c.do(http.MethodHead, "/bucket/dir/hello.txt", nil, nil)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int64(len(data)), resp.ContentLength)
+
+	b = c.expect(http.StatusPartialContent, http.MethodGet, "/bucket/dir/hello.txt", nil,
+		map[string]string{"Range": "bytes=0-4"})
+	assert.Equal(t, "hello", string(b))
+
+	c.expectError("NoSuchKey", http.MethodGet, "/bucket/missing", nil)
+	c.expectError("NoSuchKey", http.MethodGet, "/bucket/dir", nil)
+	c.expectError("InvalidArgument", http.MethodPut, "/bucket/a//b", data)
+	c.expectError("InvalidArgument", http.MethodPut, "/bucket/dir/hello.txt/sub", data)
+
+	// Files written to the volume directly are served
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "bucket", "direct"), []byte("direct"), 0644))
+	resp = c.do(http.MethodGet, "/bucket/direct", nil, nil)
+	resp.Body.Close()
+	direct := md5.Sum([]byte("direct"))
+	assert.Equal(t, `"`+hex.EncodeToString(direct[:])+`"`, resp.Header.Get("ETag"))
+
+	// Symbolic links out of the volume are not followed
+	require.NoError(t, os.Symlink("/etc", filepath.Join(root, "bucket", "etc")))
+	c.expectError("AccessDenied", http.MethodGet, "/bucket/etc/hostname", nil)
+	c.expectError("AccessDenied", http.MethodPut, "/bucket/etc/file", data)
+
+	// Deleting the last object of a prefix removes its directory
+	c.expect(http.StatusNoContent, http.MethodDelete, "/bucket/dir/hello.txt", nil, nil)
+	_, err = os.Stat(filepath.Join(root, "bucket", "dir"))
+	assert.True(t, os.IsNotExist(err))
+	c.expect(http.StatusNoContent, http.MethodDelete, "/bucket/dir/hello.txt", nil, nil)
+}
+
+func TestS3PayloadVerification(t *testing.T) {
+	c, _, cleanup := newTestGateway(t)
+	defer cleanup()
+	c.expect(http.StatusOK, http.MethodPut, "/bucket", nil, nil)
+
+	r, err := http.NewRequest(http.MethodPut, c.url+"/bucket/key", strings.NewReader("data"))
+	require.NoError(t, err)
+	now := time.Now().UTC()
+	// Sign the hash of other data
+	payloadHash := sha256Hex("other")
+	r.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	r.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	a := &sigV4Auth{
+		accessKey:     c.creds.accessKey,
+		date:          now.Format(amzShortDateFormat),
+		region:        c.creds.region,
+		service:       sigV4Service,
+		signedHeaders: signed,
+		amzDate:       now,
+	}
+	sig := signature(c.creds.secretKey, a, canonicalRequest(r, signed, payloadHash))
+	r.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, a.accessKey, a.scope(), strings.Join(signed, ";"), sig))
+	resp, err := http.DefaultClient.Do(r)
+	require.NoError(t, err)
+	var e s3ErrorResponse
+	require.NoError(t, xml.NewDecoder(resp.Body).Decode(&e))
+	resp.Body.Close()
+	assert.Equal(t, "XAmzContentSHA256Mismatch", e.Code)
+	c.expectError("NoSuchKey", http.MethodGet, "/bucket/key", nil)
+
+	resp = c.do(http.MethodPut, "/bucket/key", []byte("data"), map[string]string{
+		"Content-Md5": "AAAAAAAAAAAAAAAAAAAAAA==",
+	})
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// Requests signed with other keys are denied
+	other := *c.creds
+	other.secretKey = "other"
+	unauthorized := &s3TestClient{t: t, url: c.url, creds: &other}
+	unauthorized.expectError("SignatureDoesNotMatch", http.MethodGet, "/", nil)
+}
+
+func TestS3ListObjectsV2(t *testing.T) {
+	c, _, cleanup := newTestGateway(t)
+	defer cleanup()
+	c.expect(http.StatusOK, http.MethodPut, "/bucket", nil, nil)
+
+	keys := []string{"a-1", "a/b/c", "a/b/d", "a/e", "b", "c/d"}
+	for _, key := range keys {
+		c.expect(http.StatusOK, http.MethodPut, "/bucket/"+key, []byte(key), nil)
+	}
+	list := func(query string) *listBucketResultV2 {
+		var result listBucketResultV2
+		require.NoError(t, xml.Unmarshal(c.expect(http.StatusOK, http.MethodGet, "/bucket?list-type=2"+query, nil, nil), &result))
+		return &result
+	}
+	contents := func(result *listBucketResultV2) []string {
+		var keys []string
+		for _, o := range result.Contents {
+			keys = append(keys, o.Key)
+		}
+		for _, p := range result.CommonPrefixes {
+			keys = append(keys, p.Prefix)
+		}
+		return keys
+	}
+
+	result := list("")
+	assert.Equal(t, keys, contents(result))
+	assert.Equal(t, int64(3), result.Contents[0].Size)
+	assert.False(t, result.IsTruncated)
+
+	result = list("&prefix=a/")
+	assert.Equal(t, []string{"a/b/c", "a/b/d", "a/e"}, contents(result))
+
+	result = list("&delimiter=/")
+	assert.Equal(t, []string{"a-1", "b", "a/", "c/"}, contents(result))
+
+	result = list("&delimiter=/&prefix=a/")
+	assert.Equal(t, []string{"a/e", "a/b/"}, contents(result))
+
+	// Pages
+	var all []string
+	token := ""
+	for i := 0; ; i++ {
+		require.True(t, i < 10)
+		query := "&delimiter=/&max-keys=1"
+		if token != "" {
+			query += "&continuation-token=" + token
+		}
+		result = list(query)
+		all = append(all, contents(result)...)
+		if !result.IsTruncated {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+	assert.Equal(t, []string{"a-1", "a/", "b", "c/"}, all)
+
+	result = list("&start-after=a/e")
+	assert.Equal(t, []string{"b", "c/d"}, contents(result))
+
+	c.expectError("NoSuchBucket", http.MethodGet, "/missing?list-type=2", nil)
+}
+
+func TestS3DeleteObjects(t *testing.T) {
+	c, _, cleanup := newTestGateway(t)
+	defer cleanup()
+	c.expect(http.StatusOK, http.MethodPut, "/bucket", nil, nil)
+	c.expect(http.StatusOK, http.MethodPut, "/bucket/a", []byte("a"), nil)
+	c.expect(http.StatusOK, http.MethodPut, "/bucket/b/c", []byte("c"), nil)
+
+	body := []byte(`<Delete><Object><Key>a</Key></Object><Object><Key>b/c</Key></Object><Object><Key>../x</Key></Object></Delete>`)
+	var result deleteResult
+	require.NoError(t, xml.Unmarshal(c.expect(http.StatusOK, http.MethodPost, "/bucket?delete", body, nil), &result))
+	assert.Len(t, result.Deleted, 2)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "../x", result.Errors[0].Key)
+	c.expect(http.StatusNoContent, http.MethodDelete, "/bucket", nil, nil)
+}
+
+func TestS3MultipartUpload(t *testing.T) {
+	c, root, cleanup := newTestGateway(t)
+	defer cleanup()
+	c.expect(http.StatusOK, http.MethodPut, "/bucket", nil, nil)
+
+	var initiated initiateMultipartUploadResult
+	require.NoError(t, xml.Unmarshal(c.expect(http.StatusOK, http.MethodPost, "/bucket/big?uploads", nil,
+		map[string]string{"Content-Type": "application/data"}), &initiated))
+	uploadID := initiated.UploadID
+	assert.NotEmpty(t, uploadID)
+
+	part1 := bytes.Repeat([]byte("1"), minPartSize)
+	part2 := []byte("last part")
+	etags := make([]string, 4)
+	for i, part := range [][]byte{part1, part2, part2} {
+		resp := c.do(http.MethodPut, fmt.Sprintf("/bucket/big?partNumber=%d&uploadId=%s", i+1, uploadID), part, nil)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		etags[i+1] = resp.Header.Get("ETag")
+	}
+	c.expectError("NoSuchUpload", http.MethodPut, "/bucket/other?partNumber=1&uploadId="+uploadID, part2)
+
+	complete := func(parts ...int) []byte {
+		var b bytes.Buffer
+		b.WriteString("<CompleteMultipartUpload>")
+		for _, n := range parts {
+			fmt.Fprintf(&b, "<Part><PartNumber>%d</PartNumber><ETag>%s</ETag></Part>", n, etags[n])
+		}
+		b.WriteString("</CompleteMultipartUpload>")
+		return b.Bytes()
+	}
+	c.expectError("InvalidPartOrder", http.MethodPost, "/bucket/big?uploadId="+uploadID, complete(2, 1))
+	c.expectError("EntityTooSmall", http.MethodPost, "/bucket/big?uploadId="+uploadID, complete(2, 3))
+
+	var result completeMultipartUploadResult
+	require.NoError(t, xml.Unmarshal(c.expect(http.StatusOK, http.MethodPost, "/bucket/big?uploadId="+uploadID,
+		complete(1, 2), nil), &result))
+	sum1, sum2 := md5.Sum(part1), md5.Sum(part2)
+	all := md5.Sum(append(sum1[:], sum2[:]...))
+	assert.Equal(t, `"`+hex.EncodeToString(all[:])+`-2"`, result.ETag)
+
+	resp := c.do(http.MethodGet, "/bucket/big", nil, nil)
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, append(part1, part2...), b)
+	assert.Equal(t, result.ETag, resp.Header.Get("ETag"))
+	assert.Equal(t, "application/data", resp.Header.Get("Content-Type"))
+
+	// The upload is gone once completed
+	c.expectError("NoSuchUpload", http.MethodPost, "/bucket/big?uploadId="+uploadID, complete(1, 2))
+	files, err := ioutil.ReadDir(filepath.Join(root, gatewayDir, uploadsDir))
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	// Aborted uploads
+	require.NoError(t, xml.Unmarshal(c.expect(http.StatusOK, http.MethodPost, "/bucket/small?uploads", nil, nil), &initiated))
+	c.expect(http.StatusOK, http.MethodPut, "/bucket/small?partNumber=1&uploadId="+initiated.UploadID, part2, nil)
+	c.expect(http.StatusNoContent, http.MethodDelete, "/bucket/small?uploadId="+initiated.UploadID, nil, nil)
+	c.expectError("NoSuchUpload", http.MethodDelete, "/bucket/small?uploadId="+initiated.UploadID, nil)
+	c.expectError("NoSuchKey", http.MethodGet, "/bucket/small", nil)
+}
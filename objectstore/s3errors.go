@@ -0,0 +1,102 @@
+/*
+Errors of the S3 gateway
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package objectstore
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+// s3Error is an error response of the S3 API
+type s3Error struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *s3Error) Error() string {
+	return e.code + ": " + e.message
+}
+
+var (
+	errAccessDenied = &s3Error{http.StatusForbidden, "AccessDenied",
+		"Access Denied"}
+	errInvalidAccessKeyID = &s3Error{http.StatusForbidden, "InvalidAccessKeyId",
+		"The access key Id you provided does not exist in our records."}
+	errSignatureDoesNotMatch = &s3Error{http.StatusForbidden, "SignatureDoesNotMatch",
+		"The request signature we calculated does not match the signature you provided."}
+	errRequestTimeTooSkewed = &s3Error{http.StatusForbidden, "RequestTimeTooSkewed",
+		"The difference between the request time and the server's time is too large."}
+	errExpiredPresignRequest = &s3Error{http.StatusForbidden, "AccessDenied",
+		"Request has expired"}
+	errSignatureVersionNotSupported = &s3Error{http.StatusBadRequest, "InvalidRequest",
+		"The authorization mechanism you have provided is not supported. Please use AWS4-HMAC-SHA256."}
+	errAuthorizationHeaderMalformed = &s3Error{http.StatusBadRequest, "AuthorizationHeaderMalformed",
+		"The authorization header is malformed."}
+	errAuthorizationQueryParametersError = &s3Error{http.StatusBadRequest, "AuthorizationQueryParametersError",
+		"The authorization query parameters are malformed."}
+	errContentSHA256Mismatch = &s3Error{http.StatusBadRequest, "XAmzContentSHA256Mismatch",
+		"The provided 'x-amz-content-sha256' header does not match what was computed."}
+	errBadDigest = &s3Error{http.StatusBadRequest, "BadDigest",
+		"The Content-MD5 you specified did not match what we received."}
+	errInvalidDigest = &s3Error{http.StatusBadRequest, "InvalidDigest",
+		"The Content-MD5 you specified is not valid."}
+	errNoSuchBucket = &s3Error{http.StatusNotFound, "NoSuchBucket",
+		"The specified bucket does not exist"}
+	errNoSuchKey = &s3Error{http.StatusNotFound, "NoSuchKey",
+		"The specified key does not exist."}
+	errNoSuchUpload = &s3Error{http.StatusNotFound, "NoSuchUpload",
+		"The specified multipart upload does not exist."}
+	errBucketAlreadyOwnedByYou = &s3Error{http.StatusConflict, "BucketAlreadyOwnedByYou",
+		"Your previous request to create the named bucket succeeded and you already own it."}
+	errBucketNotEmpty = &s3Error{http.StatusConflict, "BucketNotEmpty",
+		"The bucket you tried to delete is not empty"}
+	errInvalidBucketName = &s3Error{http.StatusBadRequest, "InvalidBucketName",
+		"The specified bucket is not valid."}
+	errKeyTooLong = &s3Error{http.StatusBadRequest, "KeyTooLongError",
+		"Your key is too long"}
+	errInvalidPart = &s3Error{http.StatusBadRequest, "InvalidPart",
+		"One or more of the specified parts could not be found."}
+	errInvalidPartOrder = &s3Error{http.StatusBadRequest, "InvalidPartOrder",
+		"The list of parts was not in ascending order."}
+	errEntityTooSmall = &s3Error{http.StatusBadRequest, "EntityTooSmall",
+		"Your proposed upload is smaller than the minimum allowed object size."}
+	errMalformedXML = &s3Error{http.StatusBadRequest, "MalformedXML",
+		"The XML you provided was not well-formed or did not validate against our published schema."}
+	errMethodNotAllowed = &s3Error{http.StatusMethodNotAllowed, "MethodNotAllowed",
+		"The specified method is not allowed against this resource."}
+	errNotImplemented = &s3Error{http.StatusNotImplemented, "NotImplemented",
+		"A header you provided implies functionality that is not implemented."}
+	errInternalError = &s3Error{http.StatusInternalServerError, "InternalError",
+		"We encountered an internal error. Please try again."}
+)
+
+func errInvalidArgument(message string) *s3Error {
+	return &s3Error{http.StatusBadRequest, "InvalidArgument", message}
+}
+
+func errInvalidRequest(message string) *s3Error {
+	return &s3Error{http.StatusBadRequest, "InvalidRequest", message}
+}
+
+// s3ErrorResponse is the XML body of an error response
+type s3ErrorResponse struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string   `xml:"Code"`
+	Message  string   `xml:"Message"`
+	Resource string   `xml:"Resource,omitempty"`
+}
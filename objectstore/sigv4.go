@@ -0,0 +1,296 @@
+/*
+AWS Signature Version 4 verification for the S3 gateway
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package objectstore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm     = "AWS4-HMAC-SHA256"
+	sigV4Terminator    = "aws4_request"
+	sigV4Service       = "s3"
+	amzDateFormat      = "20060102T150405Z"
+	amzShortDateFormat = "20060102"
+	unsignedPayload    = "UNSIGNED-PAYLOAD"
+	streamingPayload   = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	emptySHA256        = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	// maxClockSkew is how far the time of a signed request may be from
+	// the time of the gateway
+	maxClockSkew = 15 * time.Minute
+	// maxPresignExpiry is the longest validity of a presigned URL
+	maxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// credentials are the keys requests to a gateway are signed with
+type credentials struct {
+	accessKey string
+	secretKey string
+	region    string
+}
+
+// sigV4Auth is the signature of a request
+type sigV4Auth struct {
+	accessKey     string
+	date          string
+	region        string
+	service       string
+	signedHeaders []string
+	signature     string
+	amzDate       time.Time
+	// payloadHash is the hex SHA256 of the body, or unsignedPayload
+	payloadHash string
+	presigned   bool
+	expires     time.Duration
+}
+
+func (a *sigV4Auth) scope() string {
+	return strings.Join([]string{a.date, a.region, a.service, sigV4Terminator}, "/")
+}
+
+// parseCredential parses <access key>/<date>/<region>/<service>/aws4_request
+func (a *sigV4Auth) parseCredential(credential string) *s3Error {
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 || parts[4] != sigV4Terminator {
+		return errAuthorizationHeaderMalformed
+	}
+	a.accessKey, a.date, a.region, a.service = parts[0], parts[1], parts[2], parts[3]
+	if a.service != sigV4Service {
+		return errAuthorizationHeaderMalformed
+	}
+	return nil
+}
+
+// parseSigV4 returns the signature of a request signed in the
+// Authorization header or in the query of a presigned URL.
+func parseSigV4(r *http.Request) (*sigV4Auth, *s3Error) {
+	a := &sigV4Auth{}
+	query := r.URL.Query()
+	if query.Get("X-Amz-Algorithm") != "" {
+		if query.Get("X-Amz-Algorithm") != sigV4Algorithm {
+			return nil, errSignatureVersionNotSupported
+		}
+		if err := a.parseCredential(query.Get("X-Amz-Credential")); err != nil {
+			return nil, err
+		}
+		a.presigned = true
+		a.signedHeaders = strings.Split(query.Get("X-Amz-SignedHeaders"), ";")
+		a.signature = query.Get("X-Amz-Signature")
+		date, err := time.Parse(amzDateFormat, query.Get("X-Amz-Date"))
+		if err != nil {
+			return nil, errAuthorizationQueryParametersError
+		}
+		a.amzDate = date
+		expires, err := strconv.ParseInt(query.Get("X-Amz-Expires"), 10, 64)
+		if err != nil || expires < 0 || time.Duration(expires)*time.Second > maxPresignExpiry {
+			return nil, errAuthorizationQueryParametersError
+		}
+		a.expires = time.Duration(expires) * time.Second
+		a.payloadHash = unsignedPayload
+		if hash := r.Header.Get("X-Amz-Content-Sha256"); hash != "" {
+			a.payloadHash = hash
+		}
+		return a, nil
+	}
+
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		return nil, errAccessDenied
+	}
+	if !strings.HasPrefix(auth, sigV4Algorithm+" ") {
+		return nil, errSignatureVersionNotSupported
+	}
+	for _, field := range strings.Split(strings.TrimPrefix(auth, sigV4Algorithm+" "), ",") {
+		kv := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(kv) != 2 {
+			return nil, errAuthorizationHeaderMalformed
+		}
+		switch kv[0] {
+		case "Credential":
+			if err := a.parseCredential(kv[1]); err != nil {
+				return nil, err
+			}
+		case "SignedHeaders":
+			a.signedHeaders = strings.Split(kv[1], ";")
+		case "Signature":
+			a.signature = kv[1]
+		}
+	}
+	if a.accessKey == "" || len(a.signedHeaders) == 0 || a.signature == "" {
+		return nil, errAuthorizationHeaderMalformed
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	if amzDate == "" {
+		amzDate = r.Header.Get("Date")
+	}
+	date, err := time.Parse(amzDateFormat, amzDate)
+	if err != nil {
+		if date, err = http.ParseTime(amzDate); err != nil {
+			return nil, errAccessDenied
+		}
+	}
+	a.amzDate = date
+	a.payloadHash = r.Header.Get("X-Amz-Content-Sha256")
+	if a.payloadHash == "" {
+		return nil, errInvalidRequest("Missing required header for this request: x-amz-content-sha256")
+	}
+	return a, nil
+}
+
+// uriEncode encodes s as in the canonical requests of SigV4, where all
+// but the unreserved characters are percent encoded.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteString("%")
+		b.WriteString(strings.ToUpper(hex.EncodeToString([]byte{c})))
+	}
+	return b.String()
+}
+
+// canonicalRequest returns the canonical form of r that is signed
+func canonicalRequest(r *http.Request, signedHeaders []string, payloadHash string) string {
+	query := r.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		if k == "X-Amz-Signature" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, k := range keys {
+		values := append([]string{}, query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			params = append(params, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+
+	var headers strings.Builder
+	for _, h := range signedHeaders {
+		var value string
+		if h == "host" {
+			value = r.Host
+		} else {
+			var values []string
+			for _, v := range r.Header[http.CanonicalHeaderKey(h)] {
+				values = append(values, strings.Join(strings.Fields(v), " "))
+			}
+			value = strings.Join(values, ",")
+		}
+		headers.WriteString(h + ":" + value + "\n")
+	}
+
+	path := r.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	return strings.Join([]string{
+		r.Method,
+		uriEncode(path, false),
+		strings.Join(params, "&"),
+		headers.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data string) string {
+	h := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(h[:])
+}
+
+// signature returns the hex signature of a canonical request
+func signature(secretKey string, a *sigV4Auth, canonical string) string {
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		a.amzDate.UTC().Format(amzDateFormat),
+		a.scope(),
+		sha256Hex(canonical),
+	}, "\n")
+	key := hmacSHA256([]byte("AWS4"+secretKey), a.date)
+	key = hmacSHA256(key, a.region)
+	key = hmacSHA256(key, a.service)
+	key = hmacSHA256(key, sigV4Terminator)
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// verifySigV4 verifies the signature of r with creds. It returns the
+// signed hash of the payload, which the caller must check against the
+// body unless it is unsignedPayload.
+func verifySigV4(r *http.Request, creds *credentials, now time.Time) (string, *s3Error) {
+	a, err := parseSigV4(r)
+	if err != nil {
+		return "", err
+	}
+	if subtle.ConstantTimeCompare([]byte(a.accessKey), []byte(creds.accessKey)) != 1 {
+		return "", errInvalidAccessKeyID
+	}
+	if a.region != creds.region {
+		return "", errAuthorizationHeaderMalformed
+	}
+	if a.date != a.amzDate.UTC().Format(amzShortDateFormat) {
+		return "", errSignatureDoesNotMatch
+	}
+	if a.presigned {
+		if now.Before(a.amzDate.Add(-maxClockSkew)) || now.After(a.amzDate.Add(a.expires)) {
+			return "", errExpiredPresignRequest
+		}
+	} else if now.Sub(a.amzDate) > maxClockSkew || a.amzDate.Sub(now) > maxClockSkew {
+		return "", errRequestTimeTooSkewed
+	}
+	if a.payloadHash == streamingPayload {
+		return "", errNotImplemented
+	}
+	signedHost := false
+	for _, h := range a.signedHeaders {
+		if h == "host" {
+			signedHost = true
+		}
+	}
+	if !signedHost {
+		return "", errAccessDenied
+	}
+
+	expected := signature(creds.secretKey, a, canonicalRequest(r, a.signedHeaders, a.payloadHash))
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(a.signature)) != 1 {
+		return "", errSignatureDoesNotMatch
+	}
+	return a.payloadHash, nil
+}
@@ -4,26 +4,43 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"time"
+
+	"github.com/portworx/kvdb"
+	"github.com/sirupsen/logrus"
 )
 
 // GetClusterConf retrieves cluster level data from kvdb
 func (manager *configManager) GetClusterConf() (*ClusterConfig, error) {
+	config, _, err := manager.getClusterConf()
+	return config, err
+}
+
+// getClusterConf returns the cluster config and the kvdb pair it was read
+// from
+func (manager *configManager) getClusterConf() (*ClusterConfig, *kvdb.KVPair, error) {
 	// get json from kvdb and unmarshal into config
 	kvPair, err := manager.kv.Get(filepath.Join(baseKey, clusterKey))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	config := new(ClusterConfig)
 	if err := json.Unmarshal(kvPair.Value, config); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return config, nil
+	return config, kvPair, nil
 }
 
 // SetClusterConf sets cluster config in kvdb
 func (manager *configManager) SetClusterConf(config *ClusterConfig) error {
+	return manager.SetClusterConfWithInfo(config, nil)
+}
+
+// SetClusterConfWithInfo validates cluster config, records it as a new
+// revision and sets it in kvdb
+func (manager *configManager) SetClusterConfWithInfo(config *ClusterConfig, info *ChangeInfo) error {
 	if config == nil {
 		return fmt.Errorf("input cannot be nil")
 	}
@@ -31,11 +48,105 @@ func (manager *configManager) SetClusterConf(config *ClusterConfig) error {
 	manager.Lock()
 	defer manager.Unlock()
 
+	return manager.setClusterConf(config, info)
+}
+
+// UpdateClusterConfWithInfo changes the cluster config with update, which
+// is given a copy of the current config, or nil if none is set, and returns
+// the new config. update is called again with the latest config if another
+// node changed it concurrently.
+func (manager *configManager) UpdateClusterConfWithInfo(update func(current *ClusterConfig) (*ClusterConfig, error), info *ChangeInfo) error {
+	if update == nil {
+		return fmt.Errorf("input cannot be nil")
+	}
+
+	manager.Lock()
+	defer manager.Unlock()
+
+	var err error
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		if err = manager.updateClusterConf(update, info); err != ErrConfigConflict {
+			return err
+		}
+	}
+	return err
+}
+
+// setClusterConf validates, sets and records cluster config. It must be
+// called with the lock held.
+func (manager *configManager) setClusterConf(config *ClusterConfig, info *ChangeInfo) error {
+	return manager.updateClusterConf(func(*ClusterConfig) (*ClusterConfig, error) {
+		return config, nil
+	}, info)
+}
+
+// updateClusterConf validates, sets and records the cluster config returned
+// by update. It must be called with the lock held.
+func (manager *configManager) updateClusterConf(update func(current *ClusterConfig) (*ClusterConfig, error), info *ChangeInfo) error {
+	current, kvPair, err := manager.getClusterConf()
+	if err == kvdb.ErrNotFound {
+		current = nil
+	} else if err != nil {
+		return err
+	}
+	var copied *ClusterConfig
+	if current != nil {
+		copied = new(ClusterConfig)
+		if err := json.Unmarshal(kvPair.Value, copied); err != nil {
+			return err
+		}
+	}
+	config, err := update(copied)
+	if err != nil {
+		return err
+	} else if config == nil {
+		return fmt.Errorf("input cannot be nil")
+	}
+	if err := Validate(current, config); err != nil {
+		return err
+	}
+
 	// push into kvdb
-	if _, err := manager.kv.Put(filepath.Join(baseKey, clusterKey), config, 0); err != nil {
+	if err := manager.putConf(filepath.Join(baseKey, clusterKey), kvPair, config); err != nil {
 		return err
 	}
 
+	// record the change once it is set
+	revision := newRevision(info)
+	revision.Cluster = config
+	var previous *Revision
+	if current != nil {
+		previous = &Revision{Cluster: current, Timestamp: time.Now().UTC()}
+	}
+	if err := manager.addRevision(getClusterHistoryKey(), revision, previous); err != nil {
+		logrus.Warnf("Cluster config was set but its revision was not recorded: %v", err)
+	}
+
+	return nil
+}
+
+// putConf sets config at key unless it was changed since it was read at
+// kvPair, or created if kvPair is nil, so that the changes made from the
+// other nodes are not overwritten. It returns ErrConfigConflict if it was.
+func (manager *configManager) putConf(key string, kvPair *kvdb.KVPair, config interface{}) error {
+	value, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if kvPair == nil {
+		if _, err := manager.kv.Create(key, value, 0); err == kvdb.ErrExist {
+			return ErrConfigConflict
+		} else if err != nil {
+			return err
+		}
+		return nil
+	}
+	update := &kvdb.KVPair{Key: key, Value: value, ModifiedIndex: kvPair.ModifiedIndex}
+	if _, err := manager.kv.CompareAndSet(update, kvdb.KVModifiedIndex, nil); err == kvdb.ErrValueMismatch {
+		return ErrConfigConflict
+	} else if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -45,22 +156,35 @@ func (manager *configManager) GetNodeConf(nodeID string) (*NodeConfig, error) {
 		return nil, fmt.Errorf("input cannot be nil")
 	}
 
+	config, _, err := manager.getNodeConf(nodeID)
+	return config, err
+}
+
+// getNodeConf returns the node config data and the kvdb pair it was read
+// from
+func (manager *configManager) getNodeConf(nodeID string) (*NodeConfig, *kvdb.KVPair, error) {
 	// get json from kvdb and unmarshal into config
 	kvPair, err := manager.kv.Get(getNodeKeyFromNodeID(nodeID))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	config := new(NodeConfig)
 	if err = json.Unmarshal(kvPair.Value, config); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return config, nil
+	return config, kvPair, nil
 }
 
 // SetNodeConf sets node config data in kvdb
 func (manager *configManager) SetNodeConf(config *NodeConfig) error {
+	return manager.SetNodeConfWithInfo(config, nil)
+}
+
+// SetNodeConfWithInfo validates node config data, records it as a new
+// revision and sets it in kvdb
+func (manager *configManager) SetNodeConfWithInfo(config *NodeConfig, info *ChangeInfo) error {
 	if config == nil {
 		return fmt.Errorf("input cannot be nil")
 	}
@@ -72,11 +196,86 @@ func (manager *configManager) SetNodeConf(config *NodeConfig) error {
 	manager.Lock()
 	defer manager.Unlock()
 
+	return manager.setNodeConf(config, info)
+}
+
+// UpdateNodeConfWithInfo changes the config of a node with update, which
+// is given a copy of the current config, or nil if none is set, and returns
+// the new config. update is called again with the latest config if another
+// node changed it concurrently.
+func (manager *configManager) UpdateNodeConfWithInfo(nodeID string, update func(current *NodeConfig) (*NodeConfig, error), info *ChangeInfo) error {
+	if update == nil {
+		return fmt.Errorf("input cannot be nil")
+	}
+
+	if len(nodeID) == 0 {
+		return fmt.Errorf("node id cannot be nil")
+	}
+
+	manager.Lock()
+	defer manager.Unlock()
+
+	var err error
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		if err = manager.updateNodeConf(nodeID, update, info); err != ErrConfigConflict {
+			return err
+		}
+	}
+	return err
+}
+
+// setNodeConf validates, sets and records node config data. It must be
+// called with the lock held.
+func (manager *configManager) setNodeConf(config *NodeConfig, info *ChangeInfo) error {
+	return manager.updateNodeConf(config.NodeId, func(*NodeConfig) (*NodeConfig, error) {
+		return config, nil
+	}, info)
+}
+
+// updateNodeConf validates, sets and records the node config data returned
+// by update. It must be called with the lock held.
+func (manager *configManager) updateNodeConf(nodeID string, update func(current *NodeConfig) (*NodeConfig, error), info *ChangeInfo) error {
+	current, kvPair, err := manager.getNodeConf(nodeID)
+	if err == kvdb.ErrNotFound {
+		current = nil
+	} else if err != nil {
+		return err
+	}
+	var copied *NodeConfig
+	if current != nil {
+		copied = new(NodeConfig)
+		if err := json.Unmarshal(kvPair.Value, copied); err != nil {
+			return err
+		}
+	}
+	config, err := update(copied)
+	if err != nil {
+		return err
+	} else if config == nil {
+		return fmt.Errorf("input cannot be nil")
+	} else if config.NodeId != nodeID {
+		return fmt.Errorf("node id %s of the config does not match node id %s", config.NodeId, nodeID)
+	}
+	if err := Validate(current, config); err != nil {
+		return err
+	}
+
 	// push node data into kvdb
-	if _, err := manager.kv.Put(getNodeKeyFromNodeID(config.NodeId), config, 0); err != nil {
+	if err := manager.putConf(getNodeKeyFromNodeID(config.NodeId), kvPair, config); err != nil {
 		return err
 	}
 
+	// record the change once it is set
+	revision := newRevision(info)
+	revision.Node = config
+	var previous *Revision
+	if current != nil {
+		previous = &Revision{Node: current, Timestamp: time.Now().UTC()}
+	}
+	if err := manager.addRevision(getNodeHistoryKeyFromNodeID(config.NodeId), revision, previous); err != nil {
+		logrus.Warnf("Config of node %s was set but its revision was not recorded: %v", config.NodeId, err)
+	}
+
 	return nil
 }
 
@@ -94,6 +293,11 @@ func (manager *configManager) DeleteNodeConf(nodeID string) error {
 		return err
 	}
 
+	// the revisions of a removed node are not kept
+	if err := manager.kv.DeleteTree(getNodeHistoryKeyFromNodeID(nodeID)); err != nil && err != kvdb.ErrNotFound {
+		return err
+	}
+
 	return nil
 }
 
@@ -143,3 +347,27 @@ func (manager *configManager) WatchNode(name string, cb func(config *NodeConfig)
 	manager.cbNode[name] = cb
 	return nil
 }
+
+// UnwatchCluster removes a callback registered with WatchCluster
+func (manager *configManager) UnwatchCluster(name string) error {
+	manager.Lock()
+	defer manager.Unlock()
+
+	if _, present := manager.cbCluster[name]; !present {
+		return fmt.Errorf("%s not present", name)
+	}
+	delete(manager.cbCluster, name)
+	return nil
+}
+
+// UnwatchNode removes a callback registered with WatchNode
+func (manager *configManager) UnwatchNode(name string) error {
+	manager.Lock()
+	defer manager.Unlock()
+
+	if _, present := manager.cbNode[name]; !present {
+		return fmt.Errorf("%s not present", name)
+	}
+	delete(manager.cbNode, name)
+	return nil
+}
@@ -187,6 +187,53 @@ func TestCallback(t *testing.T) {
 	}
 }
 
+func TestUnwatch(t *testing.T) {
+	// create in memory kvdb
+	kv, err := newInMemKvdb()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// create new manager
+	manager, err := NewManager(kv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan string, 2)
+	watcher := func(config *ClusterConfig) error {
+		ch <- config.Description
+		return nil
+	}
+	if err := manager.WatchCluster("watcher", watcher); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.WatchCluster("watcher", watcher); err == nil {
+		t.Fatal("expected an error registering the same watcher twice")
+	}
+	if err := manager.SetClusterConf(&ClusterConfig{Description: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	if description := <-ch; description != "first" {
+		t.Fatal("data not as expected")
+	}
+
+	if err := manager.UnwatchCluster("watcher"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.UnwatchCluster("watcher"); err == nil {
+		t.Fatal("expected an error removing a missing watcher")
+	}
+	if err := manager.UnwatchNode("watcher"); err == nil {
+		t.Fatal("expected an error removing a missing watcher")
+	}
+
+	// the name can be reused once removed
+	if err := manager.WatchCluster("watcher", watcher); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // setSomeClusterValues is a helper function to set cluster config values in kvdb
 func setSomeClusterValues(ch chan error, manager ConfigManager) error {
 	// prepare expected cluster config
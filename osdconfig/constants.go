@@ -5,6 +5,7 @@ const (
 	baseKey    = "osdconfig"   // root of the tree in kvdb
 	clusterKey = "clusterConf" // cluster data is managed behind this key
 	nodeKey    = "nodeConf"    // all nodeID's exist behind this key and all node data behind respective node ID
+	historyKey = "history"     // revisions of the cluster and node data are kept behind this key
 )
 
 // these const indicates which type of kvdb changes callback is watching on
@@ -5,6 +5,7 @@ package osdconfig
 type ConfigManager interface {
 	ConfigCaller
 	ConfigWatcher
+	ConfigVersioner
 }
 
 // ConfigCaller interface defines the setters/getters for osdconfig
@@ -41,4 +42,59 @@ type ConfigWatcher interface {
 	// WatchNode registers a user defined function as callback watching for changes
 	// in the node configuration
 	WatchNode(name string, cb func(config *NodeConfig) error) error
+
+	// UnwatchCluster removes a callback registered with WatchCluster
+	UnwatchCluster(name string) error
+
+	// UnwatchNode removes a callback registered with WatchNode
+	UnwatchNode(name string) error
+}
+
+// ConfigVersioner defines the revisions of the cluster and node configurations.
+// Every change is recorded as a new revision once it is set, after being
+// validated against the schema defined by the struct tags of the configuration.
+// A change fails with ErrConfigConflict if another node changed the
+// configuration while it was being set.
+type ConfigVersioner interface {
+	// SetClusterConfWithInfo pushes cluster configuration data to the backend
+	// recording who changed it and why
+	SetClusterConfWithInfo(config *ClusterConfig, info *ChangeInfo) error
+
+	// SetNodeConfWithInfo pushes node configuration data to the backend
+	// recording who changed it and why
+	SetNodeConfWithInfo(config *NodeConfig, info *ChangeInfo) error
+
+	// UpdateClusterConfWithInfo changes the cluster configuration with
+	// update, which is given the current configuration, or nil if none is
+	// set, and returns the new one. update is called again with the latest
+	// configuration if it was changed concurrently.
+	UpdateClusterConfWithInfo(update func(current *ClusterConfig) (*ClusterConfig, error), info *ChangeInfo) error
+
+	// UpdateNodeConfWithInfo changes the configuration of a node with
+	// update, as UpdateClusterConfWithInfo does
+	UpdateNodeConfWithInfo(nodeID string, update func(current *NodeConfig) (*NodeConfig, error), info *ChangeInfo) error
+
+	// EnumerateClusterConfRevisions returns the revisions of the cluster
+	// configuration, oldest first
+	EnumerateClusterConfRevisions() ([]*Revision, error)
+
+	// EnumerateNodeConfRevisions returns the revisions of the configuration
+	// of a node, oldest first
+	EnumerateNodeConfRevisions(nodeID string) ([]*Revision, error)
+
+	// DiffClusterConf returns the differences between two revisions of the
+	// cluster configuration
+	DiffClusterConf(from, to uint64) ([]*Difference, error)
+
+	// DiffNodeConf returns the differences between two revisions of the
+	// configuration of a node
+	DiffNodeConf(nodeID string, from, to uint64) ([]*Difference, error)
+
+	// RollbackClusterConf sets the cluster configuration of a previous
+	// revision, recorded as a new revision
+	RollbackClusterConf(version uint64, info *ChangeInfo) error
+
+	// RollbackNodeConf sets the configuration of a node of a previous
+	// revision, recorded as a new revision
+	RollbackNodeConf(nodeID string, version uint64, info *ChangeInfo) error
 }
@@ -45,3 +45,86 @@ func (n *NullConfigCaller) SetNodeConf(config *NodeConfig) error {
 func (n *NullConfigCaller) DeleteNodeConf(nodeID string) error {
 	return ErrNotImplemented
 }
+
+// NullConfigManager is a NULL implementation of the ConfigManager interface
+type NullConfigManager struct {
+	NullConfigCaller
+}
+
+// WatchCluster registers a user defined function as callback watching for changes
+// in the cluster configuration
+func (n *NullConfigManager) WatchCluster(name string, cb func(config *ClusterConfig) error) error {
+	return ErrNotImplemented
+}
+
+// WatchNode registers a user defined function as callback watching for changes
+// in the node configuration
+func (n *NullConfigManager) WatchNode(name string, cb func(config *NodeConfig) error) error {
+	return ErrNotImplemented
+}
+
+// UnwatchCluster removes a callback registered with WatchCluster
+func (n *NullConfigManager) UnwatchCluster(name string) error {
+	return ErrNotImplemented
+}
+
+// UnwatchNode removes a callback registered with WatchNode
+func (n *NullConfigManager) UnwatchNode(name string) error {
+	return ErrNotImplemented
+}
+
+// SetClusterConfWithInfo pushes cluster configuration data to the backend
+// recording who changed it and why
+func (n *NullConfigManager) SetClusterConfWithInfo(config *ClusterConfig, info *ChangeInfo) error {
+	return ErrNotImplemented
+}
+
+// SetNodeConfWithInfo pushes node configuration data to the backend
+// recording who changed it and why
+func (n *NullConfigManager) SetNodeConfWithInfo(config *NodeConfig, info *ChangeInfo) error {
+	return ErrNotImplemented
+}
+
+// UpdateClusterConfWithInfo changes the cluster configuration with update
+func (n *NullConfigManager) UpdateClusterConfWithInfo(update func(current *ClusterConfig) (*ClusterConfig, error), info *ChangeInfo) error {
+	return ErrNotImplemented
+}
+
+// UpdateNodeConfWithInfo changes the configuration of a node with update
+func (n *NullConfigManager) UpdateNodeConfWithInfo(nodeID string, update func(current *NodeConfig) (*NodeConfig, error), info *ChangeInfo) error {
+	return ErrNotImplemented
+}
+
+// EnumerateClusterConfRevisions returns the revisions of the cluster
+// configuration
+func (n *NullConfigManager) EnumerateClusterConfRevisions() ([]*Revision, error) {
+	return nil, ErrNotImplemented
+}
+
+// EnumerateNodeConfRevisions returns the revisions of the configuration of
+// a node
+func (n *NullConfigManager) EnumerateNodeConfRevisions(nodeID string) ([]*Revision, error) {
+	return nil, ErrNotImplemented
+}
+
+// DiffClusterConf returns the differences between two revisions of the
+// cluster configuration
+func (n *NullConfigManager) DiffClusterConf(from, to uint64) ([]*Difference, error) {
+	return nil, ErrNotImplemented
+}
+
+// DiffNodeConf returns the differences between two revisions of the
+// configuration of a node
+func (n *NullConfigManager) DiffNodeConf(nodeID string, from, to uint64) ([]*Difference, error) {
+	return nil, ErrNotImplemented
+}
+
+// RollbackClusterConf sets the cluster configuration of a previous revision
+func (n *NullConfigManager) RollbackClusterConf(version uint64, info *ChangeInfo) error {
+	return ErrNotImplemented
+}
+
+// RollbackNodeConf sets the configuration of a node of a previous revision
+func (n *NullConfigManager) RollbackNodeConf(nodeID string, version uint64, info *ChangeInfo) error {
+	return ErrNotImplemented
+}
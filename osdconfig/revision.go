@@ -0,0 +1,247 @@
+package osdconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/portworx/kvdb"
+)
+
+const (
+	// maxRevisions is the number of revisions kept for each configuration
+	maxRevisions = 64
+	// maxRevisionAttempts is the number of times adding a revision is
+	// attempted when other nodes add revisions concurrently
+	maxRevisionAttempts = 8
+	// maxUpdateAttempts is the number of times a configuration update is
+	// attempted when other nodes change the configuration concurrently
+	maxUpdateAttempts = 8
+	// initialRevisionReason is the reason of the revision recording a
+	// configuration set before revisions were kept
+	initialRevisionReason = "configuration before revisions were kept"
+)
+
+var (
+	// ErrRevisionNotFound is returned for an unknown revision
+	ErrRevisionNotFound = errors.New("configuration revision not found")
+	// ErrConfigConflict is returned when a configuration was changed by
+	// another writer since it was read
+	ErrConfigConflict = errors.New("configuration was changed concurrently, retry the change")
+)
+
+// ChangeInfo describes who changed a configuration and why
+type ChangeInfo struct {
+	// Author of the change
+	Author string `json:"author,omitempty"`
+	// Reason of the change
+	Reason string `json:"reason,omitempty"`
+}
+
+// Revision is a version of the cluster configuration or of the
+// configuration of a node
+type Revision struct {
+	// Version of the configuration, starting at 1
+	Version uint64 `json:"version"`
+	// Author of the change
+	Author string `json:"author,omitempty"`
+	// Timestamp of the change
+	Timestamp time.Time `json:"timestamp"`
+	// Reason of the change
+	Reason string `json:"reason,omitempty"`
+	// Cluster is the cluster configuration of the revision
+	Cluster *ClusterConfig `json:"cluster,omitempty"`
+	// Node is the node configuration of the revision
+	Node *NodeConfig `json:"node,omitempty"`
+}
+
+// helper function to obtain the kvdb prefix of the revisions of the
+// cluster configuration
+func getClusterHistoryKey() string {
+	return filepath.Join(baseKey, historyKey, clusterKey) + "/"
+}
+
+// helper function to obtain the kvdb prefix of the revisions of a node
+// configuration
+func getNodeHistoryKeyFromNodeID(nodeID string) string {
+	return filepath.Join(baseKey, historyKey, nodeKey, nodeID) + "/"
+}
+
+// revisions returns the revisions stored under prefix, oldest first
+func (manager *configManager) revisions(prefix string) ([]*Revision, error) {
+	kvps, err := manager.kv.Enumerate(prefix)
+	if err != nil {
+		return nil, err
+	}
+	revisions := make([]*Revision, 0, len(kvps))
+	for _, kvp := range kvps {
+		revision := new(Revision)
+		if err := json.Unmarshal(kvp.Value, revision); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Version < revisions[j].Version
+	})
+	return revisions, nil
+}
+
+// revision returns a revision stored under prefix
+func (manager *configManager) revision(prefix string, version uint64) (*Revision, error) {
+	revision := new(Revision)
+	if _, err := manager.kv.GetVal(prefix+strconv.FormatUint(version, 10), revision); err == kvdb.ErrNotFound {
+		return nil, ErrRevisionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// addRevision stores a new revision under prefix and prunes the oldest
+// ones. previous is the configuration before the change, recorded as the
+// first revision if none were kept yet.
+func (manager *configManager) addRevision(prefix string, revision, previous *Revision) error {
+	revisions, err := manager.revisions(prefix)
+	if err != nil {
+		return err
+	}
+	var last uint64
+	if len(revisions) != 0 {
+		last = revisions[len(revisions)-1].Version
+	} else if previous != nil {
+		previous.Version = 1
+		previous.Reason = initialRevisionReason
+		if _, err := manager.kv.Create(prefix+"1", previous, 0); err != nil && err != kvdb.ErrExist {
+			return err
+		}
+		last = 1
+	}
+
+	revision.Timestamp = time.Now().UTC()
+	for attempt := 0; attempt < maxRevisionAttempts; attempt++ {
+		revision.Version = last + 1
+		_, err = manager.kv.Create(prefix+strconv.FormatUint(revision.Version, 10), revision, 0)
+		if err == kvdb.ErrExist {
+			// Another node added a revision
+			last++
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		for _, old := range revisions {
+			if old.Version+maxRevisions > revision.Version {
+				break
+			}
+			manager.kv.Delete(prefix + strconv.FormatUint(old.Version, 10))
+		}
+		return nil
+	}
+	return fmt.Errorf("failed to add a configuration revision: %v", err)
+}
+
+// newRevision returns a revision for a change described by info
+func newRevision(info *ChangeInfo) *Revision {
+	revision := new(Revision)
+	if info != nil {
+		revision.Author = info.Author
+		revision.Reason = info.Reason
+	}
+	return revision
+}
+
+// EnumerateClusterConfRevisions returns the revisions of the cluster
+// configuration, oldest first
+func (manager *configManager) EnumerateClusterConfRevisions() ([]*Revision, error) {
+	return manager.revisions(getClusterHistoryKey())
+}
+
+// EnumerateNodeConfRevisions returns the revisions of the configuration of
+// a node, oldest first
+func (manager *configManager) EnumerateNodeConfRevisions(nodeID string) ([]*Revision, error) {
+	if len(nodeID) == 0 {
+		return nil, fmt.Errorf("node id cannot be nil")
+	}
+	return manager.revisions(getNodeHistoryKeyFromNodeID(nodeID))
+}
+
+// DiffClusterConf returns the differences between two revisions of the
+// cluster configuration
+func (manager *configManager) DiffClusterConf(from, to uint64) ([]*Difference, error) {
+	fromRevision, err := manager.revision(getClusterHistoryKey(), from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := manager.revision(getClusterHistoryKey(), to)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(fromRevision.Cluster, toRevision.Cluster), nil
+}
+
+// DiffNodeConf returns the differences between two revisions of the
+// configuration of a node
+func (manager *configManager) DiffNodeConf(nodeID string, from, to uint64) ([]*Difference, error) {
+	if len(nodeID) == 0 {
+		return nil, fmt.Errorf("node id cannot be nil")
+	}
+	fromRevision, err := manager.revision(getNodeHistoryKeyFromNodeID(nodeID), from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := manager.revision(getNodeHistoryKeyFromNodeID(nodeID), to)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(fromRevision.Node, toRevision.Node), nil
+}
+
+// RollbackClusterConf sets the cluster configuration of a previous
+// revision, which is recorded as a new revision
+func (manager *configManager) RollbackClusterConf(version uint64, info *ChangeInfo) error {
+	manager.Lock()
+	defer manager.Unlock()
+
+	revision, err := manager.revision(getClusterHistoryKey(), version)
+	if err != nil {
+		return err
+	}
+	return manager.setClusterConf(revision.Cluster, rollbackInfo(version, info))
+}
+
+// RollbackNodeConf sets the configuration of a node of a previous
+// revision, which is recorded as a new revision
+func (manager *configManager) RollbackNodeConf(nodeID string, version uint64, info *ChangeInfo) error {
+	if len(nodeID) == 0 {
+		return fmt.Errorf("node id cannot be nil")
+	}
+
+	manager.Lock()
+	defer manager.Unlock()
+
+	revision, err := manager.revision(getNodeHistoryKeyFromNodeID(nodeID), version)
+	if err != nil {
+		return err
+	}
+	return manager.setNodeConf(revision.Node, rollbackInfo(version, info))
+}
+
+// rollbackInfo describes a rollback to version, keeping the reason given by
+// the caller if any
+func rollbackInfo(version uint64, info *ChangeInfo) *ChangeInfo {
+	rollback := &ChangeInfo{
+		Reason: fmt.Sprintf("rollback to version %d", version),
+	}
+	if info != nil {
+		rollback.Author = info.Author
+		if len(info.Reason) != 0 {
+			rollback.Reason += ": " + info.Reason
+		}
+	}
+	return rollback
+}
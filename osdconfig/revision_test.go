@@ -0,0 +1,324 @@
+package osdconfig
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/portworx/kvdb"
+)
+
+func TestClusterConfRevisions(t *testing.T) {
+	// create in memory kvdb
+	kv, err := newInMemKvdb()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// get new config manager using handle to kvdb
+	manager, err := NewManager(kv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := new(ClusterConfig)
+	conf.ClusterId = "myClusterID"
+	conf.Kvdb = &KvdbConfig{Discovery: []string{"http://etcd1:2379"}}
+	if err := manager.SetClusterConf(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	conf.Description = "myDescription"
+	if err := manager.SetClusterConfWithInfo(conf, &ChangeInfo{Author: "admin", Reason: "describe"}); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := manager.EnumerateClusterConfRevisions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 {
+		t.Fatal("expected 2 revisions, got", len(revisions))
+	}
+	if revisions[0].Version != 1 || revisions[1].Version != 2 {
+		t.Fatal("unexpected versions", revisions[0].Version, revisions[1].Version)
+	}
+	if revisions[1].Author != "admin" || revisions[1].Reason != "describe" || revisions[1].Timestamp.IsZero() {
+		t.Fatal("change info not recorded", revisions[1])
+	}
+	if revisions[1].Cluster.Description != "myDescription" {
+		t.Fatal("configuration not recorded", revisions[1].Cluster)
+	}
+
+	diffs, err := manager.DiffClusterConf(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || diffs[0].Path != "description" || diffs[0].Old != `""` || diffs[0].New != `"myDescription"` {
+		t.Fatal("unexpected differences", diffs)
+	}
+	if _, err := manager.DiffClusterConf(1, 3); err != ErrRevisionNotFound {
+		t.Fatal("expected ErrRevisionNotFound, got", err)
+	}
+
+	// kvdb cannot be changed once set
+	conf.Kvdb = &KvdbConfig{Discovery: []string{"http://wrong:2379"}}
+	err = manager.SetClusterConf(conf)
+	if _, ok := err.(*ValidationError); !ok {
+		t.Fatal("expected a validation error, got", err)
+	}
+
+	if err := manager.RollbackClusterConf(1, &ChangeInfo{Author: "admin", Reason: "undo"}); err != nil {
+		t.Fatal(err)
+	}
+	received, err := manager.GetClusterConf()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received.Description != "" || received.ClusterId != "myClusterID" {
+		t.Fatal("configuration not rolled back", received)
+	}
+	revisions, err = manager.EnumerateClusterConfRevisions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 3 || revisions[2].Reason != "rollback to version 1: undo" {
+		t.Fatal("rollback not recorded", revisions)
+	}
+	if err := manager.RollbackClusterConf(10, nil); err != ErrRevisionNotFound {
+		t.Fatal("expected ErrRevisionNotFound, got", err)
+	}
+}
+
+func TestNodeConfRevisions(t *testing.T) {
+	// create in memory kvdb
+	kv, err := newInMemKvdb()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// get new config manager using handle to kvdb
+	manager, err := NewManager(kv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := new(NodeConfig)
+	conf.NodeId = "myNodeID"
+	for i := 0; i < maxRevisions+1; i++ {
+		conf.Geo = &GeoConfig{Zone: "zone" + strconv.Itoa(i)}
+		if err := manager.SetNodeConf(conf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	revisions, err := manager.EnumerateNodeConfRevisions(conf.NodeId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != maxRevisions {
+		t.Fatal("expected old revisions to be pruned, got", len(revisions))
+	}
+	if revisions[len(revisions)-1].Version != maxRevisions+1 {
+		t.Fatal("unexpected last version", revisions[len(revisions)-1].Version)
+	}
+
+	// the node configurations only hold nodes
+	confs, err := manager.EnumerateNodeConf()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*confs) != 1 {
+		t.Fatal("expected 1 node configuration, got", len(*confs))
+	}
+
+	if err := manager.DeleteNodeConf(conf.NodeId); err != nil {
+		t.Fatal(err)
+	}
+	revisions, err = manager.EnumerateNodeConfRevisions(conf.NodeId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 0 {
+		t.Fatal("expected the revisions of the node to be removed")
+	}
+}
+
+func TestFirstNodeConfRevision(t *testing.T) {
+	// create in memory kvdb
+	kv, err := newInMemKvdb()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a configuration set before revisions were kept
+	conf := new(NodeConfig)
+	conf.NodeId = "myNodeID"
+	conf.Geo = &GeoConfig{Rack: "rack1"}
+	if _, err := kv.Put(getNodeKeyFromNodeID(conf.NodeId), conf, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := NewCaller(kv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.Geo = &GeoConfig{Rack: "rack2"}
+	if err := manager.SetNodeConf(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	diffs, err := manager.(ConfigVersioner).DiffNodeConf(conf.NodeId, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || diffs[0].Path != "geo.rack" || diffs[0].New != `"rack2"` {
+		t.Fatal("unexpected differences", diffs)
+	}
+}
+
+// historyFailingKvdb fails the creation of the revisions
+type historyFailingKvdb struct {
+	kvdb.Kvdb
+}
+
+func (kv *historyFailingKvdb) Create(key string, value interface{}, ttl uint64) (*kvdb.KVPair, error) {
+	if strings.HasPrefix(key, filepath.Join(baseKey, historyKey)) {
+		return nil, errors.New("history is not writable")
+	}
+	return kv.Kvdb.Create(key, value, ttl)
+}
+
+func TestConfSetBeforeRevision(t *testing.T) {
+	// create in memory kvdb
+	kv, err := newInMemKvdb()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := newCaller(&historyFailingKvdb{Kvdb: kv})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the config is set first, so it is kept when its revision fails
+	conf := new(NodeConfig)
+	conf.NodeId = "myNodeID"
+	conf.Geo = &GeoConfig{Rack: "rack1"}
+	if err := manager.SetNodeConf(conf); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := manager.GetNodeConf(conf.NodeId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Geo == nil || stored.Geo.Rack != "rack1" {
+		t.Fatal("config not set when its revision failed", stored.Geo)
+	}
+}
+
+func TestConfConflict(t *testing.T) {
+	// create in memory kvdb
+	kv, err := newInMemKvdb()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := newCaller(kv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := new(ClusterConfig)
+	conf.ClusterId = "myClusterID"
+	if err := manager.SetClusterConf(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	// another node creates or changes the config after it was read
+	key := filepath.Join(baseKey, clusterKey)
+	if err := manager.putConf(key, nil, conf); err != ErrConfigConflict {
+		t.Fatal("expected ErrConfigConflict creating the config, got", err)
+	}
+	_, kvPair, err := manager.getClusterConf()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.Description = "other node"
+	if _, err := kv.Put(key, conf, 0); err != nil {
+		t.Fatal(err)
+	}
+	conf.Description = "this node"
+	if err := manager.putConf(key, kvPair, conf); err != ErrConfigConflict {
+		t.Fatal("expected ErrConfigConflict, got", err)
+	}
+	stored, err := manager.GetClusterConf()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Description != "other node" {
+		t.Fatal("config of the other node was overwritten", stored.Description)
+	}
+}
+
+func TestUpdateClusterConf(t *testing.T) {
+	// create in memory kvdb
+	kv, err := newInMemKvdb()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := newCaller(kv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := new(ClusterConfig)
+	conf.ClusterId = "myClusterID"
+	if err := manager.SetClusterConf(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	// another node changes the config while it is updated, so the update
+	// is applied again to its config
+	calls := 0
+	err = manager.UpdateClusterConfWithInfo(func(current *ClusterConfig) (*ClusterConfig, error) {
+		calls++
+		if calls == 1 {
+			other := *current
+			other.Domain = "otherDomain"
+			if _, err := kv.Put(filepath.Join(baseKey, clusterKey), &other, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+		current.Description = "myDescription"
+		return current, nil
+	}, &ChangeInfo{Reason: "describe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatal("expected the update to be retried once, got", calls)
+	}
+	stored, err := manager.GetClusterConf()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Domain != "otherDomain" || stored.Description != "myDescription" {
+		t.Fatal("concurrent change lost", stored.Domain, stored.Description)
+	}
+	revisions, err := manager.EnumerateClusterConfRevisions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[1].Reason != "describe" {
+		t.Fatal("unexpected revisions", revisions)
+	}
+
+	// the errors of the update are returned without changing the config
+	if err := manager.UpdateClusterConfWithInfo(func(*ClusterConfig) (*ClusterConfig, error) {
+		return nil, errors.New("rejected")
+	}, nil); err == nil || err.Error() != "rejected" {
+		t.Fatal("expected the error of the update, got", err)
+	}
+}
@@ -0,0 +1,256 @@
+package osdconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// The struct tags of the configuration define its schema:
+//   enable: fields with enable:"false" can be set once but not changed
+//   hidden: fields with hidden:"true" hold secrets, which should not be
+//           shown to users without access to the secrets of the cluster
+//   usage:  describes the field in validation errors
+
+// ValidationError is returned when a configuration change does not comply
+// with the schema
+type ValidationError struct {
+	// Field is the path of the field
+	Field string
+	// Usage of the field
+	Usage string
+	// Reason the change was rejected
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid change of %s (%s): %s", e.Field, e.Usage, e.Reason)
+}
+
+// Difference is a field which differs between two configurations
+type Difference struct {
+	// Path of the field, the json names of the fields separated by dots
+	Path string `json:"path"`
+	// Old value of the field, json encoded
+	Old string `json:"old"`
+	// New value of the field, json encoded
+	New string `json:"new"`
+	// Hidden is set for fields holding secrets
+	Hidden bool `json:"hidden,omitempty"`
+}
+
+// Validate checks that the configuration updated can replace current. Both
+// must be pointers to the same configuration struct, current being nil for
+// a new configuration.
+func Validate(current, updated interface{}) error {
+	return validateFields("", structValue(current), structValue(updated))
+}
+
+// Diff returns the fields which differ between two configurations. Both must
+// be pointers to the same configuration struct, a nil pointer being handled
+// as an empty configuration.
+func Diff(from, to interface{}) []*Difference {
+	diffs := make([]*Difference, 0)
+	diffFields("", structValue(from), structValue(to), false, &diffs)
+	return diffs
+}
+
+// Redact returns a copy of a configuration without the values of its
+// hidden fields. config must be a pointer to a configuration struct.
+func Redact(config interface{}) interface{} {
+	v := reflect.ValueOf(config)
+	if v.IsNil() {
+		return config
+	}
+	redacted := reflect.New(v.Type().Elem())
+	redactFields(redacted.Elem(), v.Elem())
+	return redacted.Interface()
+}
+
+// KeepHidden sets the hidden fields of the configuration updated to their
+// values in current, so that a change by a user without access to the
+// secrets keeps them. Both must be pointers to the same configuration
+// struct, current being nil for a new configuration.
+func KeepHidden(updated, current interface{}) {
+	keepHiddenFields(reflect.ValueOf(updated).Elem(), structValue(current))
+}
+
+// structValue returns the struct a pointer points to, or an empty struct
+// for a nil pointer
+func structValue(ptr interface{}) reflect.Value {
+	v := reflect.ValueOf(ptr)
+	if v.IsNil() {
+		return reflect.New(v.Type().Elem()).Elem()
+	}
+	return v.Elem()
+}
+
+// isStructPtr returns true for the fields holding nested configurations
+func isStructPtr(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct
+}
+
+// fieldPath returns the path of a field using its json name
+func fieldPath(parent string, field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if len(name) == 0 {
+		name = field.Name
+	}
+	if len(parent) == 0 {
+		return name
+	}
+	return parent + "." + name
+}
+
+// encode returns the json encoding of a field, in which nil and empty
+// values are the same
+func encode(v reflect.Value) []byte {
+	if isStructPtr(v.Type()) && v.IsNil() {
+		v = reflect.New(v.Type().Elem())
+	} else if (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0 {
+		return []byte("null")
+	}
+	b, _ := json.Marshal(v.Interface())
+	return b
+}
+
+func validateFields(path string, current, updated reflect.Value) error {
+	t := current.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) != 0 {
+			continue
+		}
+		name := fieldPath(path, field)
+		cur, upd := current.Field(i), updated.Field(i)
+
+		if field.Tag.Get("enable") == "false" {
+			empty := encode(reflect.Zero(cur.Type()))
+			if old := encode(cur); !bytes.Equal(old, empty) && !bytes.Equal(old, encode(upd)) {
+				return &ValidationError{
+					Field:  name,
+					Usage:  field.Tag.Get("usage"),
+					Reason: "cannot be changed once set",
+				}
+			}
+			continue
+		}
+
+		if isStructPtr(field.Type) {
+			var next reflect.Value
+			if cur.IsNil() {
+				next = reflect.New(field.Type.Elem()).Elem()
+			} else {
+				next = cur.Elem()
+			}
+			if upd.IsNil() {
+				// Removing a nested configuration changes all its fields
+				upd = reflect.New(field.Type.Elem())
+			}
+			if err := validateFields(name, next, upd.Elem()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func diffFields(path string, from, to reflect.Value, hidden bool, diffs *[]*Difference) {
+	t := from.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) != 0 {
+			continue
+		}
+		name := fieldPath(path, field)
+		fieldHidden := hidden || field.Tag.Get("hidden") == "true"
+		f, tv := from.Field(i), to.Field(i)
+
+		if isStructPtr(field.Type) {
+			if f.IsNil() {
+				f = reflect.New(field.Type.Elem())
+			}
+			if tv.IsNil() {
+				tv = reflect.New(field.Type.Elem())
+			}
+			diffFields(name, f.Elem(), tv.Elem(), fieldHidden, diffs)
+			continue
+		}
+
+		old, updated := encode(f), encode(tv)
+		if bytes.Equal(old, updated) {
+			continue
+		}
+		*diffs = append(*diffs, &Difference{
+			Path:   name,
+			Old:    string(old),
+			New:    string(updated),
+			Hidden: fieldHidden,
+		})
+	}
+}
+
+// redactFields copies the struct src to dst, clearing its hidden fields.
+// The nested configurations are copied too.
+func redactFields(dst, src reflect.Value) {
+	dst.Set(src)
+	t := src.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) != 0 {
+			continue
+		}
+		f := dst.Field(i)
+		if field.Tag.Get("hidden") == "true" {
+			f.Set(reflect.Zero(field.Type))
+		} else if isStructPtr(field.Type) && !f.IsNil() {
+			next := reflect.New(field.Type.Elem())
+			redactFields(next.Elem(), f.Elem())
+			f.Set(next)
+		}
+	}
+}
+
+// keepHiddenFields sets the hidden fields of the struct updated to their
+// values in current. It returns true if any of them is set.
+func keepHiddenFields(updated, current reflect.Value) bool {
+	kept := false
+	t := current.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) != 0 {
+			continue
+		}
+		upd, cur := updated.Field(i), current.Field(i)
+
+		if field.Tag.Get("hidden") == "true" {
+			upd.Set(cur)
+			kept = kept || !cur.IsZero()
+			continue
+		}
+		if !isStructPtr(field.Type) {
+			continue
+		}
+
+		var next reflect.Value
+		if cur.IsNil() {
+			next = reflect.New(field.Type.Elem()).Elem()
+		} else {
+			next = cur.Elem()
+		}
+		if !upd.IsNil() {
+			kept = keepHiddenFields(upd.Elem(), next) || kept
+			continue
+		}
+		// The nested configuration was removed, it is only recreated to
+		// keep its secrets
+		created := reflect.New(field.Type.Elem())
+		if keepHiddenFields(created.Elem(), next) {
+			upd.Set(created)
+			kept = true
+		}
+	}
+	return kept
+}
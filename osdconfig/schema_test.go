@@ -0,0 +1,140 @@
+package osdconfig
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	current := &ClusterConfig{
+		ClusterId: "myClusterID",
+		Kvdb:      &KvdbConfig{Name: "etcd"},
+	}
+
+	// a new configuration can set any field
+	if err := Validate((*ClusterConfig)(nil), current); err != nil {
+		t.Fatal(err)
+	}
+
+	updated := *current
+	updated.Description = "myDescription"
+	updated.Kvdb = &KvdbConfig{Name: "etcd", Discovery: []string{}}
+	if err := Validate(current, &updated); err != nil {
+		t.Fatal(err)
+	}
+
+	updated.Kvdb = nil
+	err := Validate(current, &updated)
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatal("expected a validation error, got", err)
+	}
+	if verr.Field != "kvdb" {
+		t.Fatal("unexpected field", verr.Field)
+	}
+
+	// disabled fields can be set while empty
+	if err := Validate(&ClusterConfig{}, current); err != nil {
+		t.Fatal(err)
+	}
+
+	node := &NodeConfig{NodeId: "node", Private: "private"}
+	if err := Validate(node, &NodeConfig{NodeId: "node"}); err == nil {
+		t.Fatal("expected private data to be read only")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	from := &ClusterConfig{
+		Secrets: &SecretsConfig{
+			Vault: &VaultConfig{Token: "token1", Address: "http://vault"},
+		},
+	}
+	to := &ClusterConfig{
+		Secrets: &SecretsConfig{
+			Vault: &VaultConfig{Token: "token2", Address: "http://vault"},
+		},
+		Kvdb: &KvdbConfig{Discovery: []string{"http://etcd:2379"}},
+	}
+
+	diffs := Diff(from, to)
+	if len(diffs) != 2 {
+		t.Fatal("expected 2 differences, got", diffs)
+	}
+	if diffs[0].Path != "secrets.vault.token" || !diffs[0].Hidden {
+		t.Fatal("unexpected difference", diffs[0])
+	}
+	if diffs[1].Path != "kvdb.discovery" || diffs[1].Old != "null" || diffs[1].New != `["http://etcd:2379"]` || diffs[1].Hidden {
+		t.Fatal("unexpected difference", diffs[1])
+	}
+
+	if diffs := Diff((*ClusterConfig)(nil), &ClusterConfig{}); len(diffs) != 0 {
+		t.Fatal("expected no differences, got", diffs)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	config := &ClusterConfig{
+		Description: "cluster",
+		Secrets: &SecretsConfig{
+			SecretType:       "vault",
+			ClusterSecretKey: "key",
+			Vault:            &VaultConfig{Token: "token", Address: "http://vault"},
+		},
+	}
+
+	redacted := Redact(config).(*ClusterConfig)
+	if redacted.Description != "cluster" || redacted.Secrets.SecretType != "vault" ||
+		redacted.Secrets.Vault.Address != "http://vault" {
+		t.Fatal("unexpected redacted configuration", redacted)
+	}
+	if redacted.Secrets.ClusterSecretKey != "" || redacted.Secrets.Vault.Token != "" {
+		t.Fatal("expected the secrets to be cleared", redacted.Secrets)
+	}
+	// the configuration is not changed
+	if config.Secrets.ClusterSecretKey != "key" || config.Secrets.Vault.Token != "token" {
+		t.Fatal("unexpected change of the configuration", config.Secrets)
+	}
+
+	if Redact((*ClusterConfig)(nil)).(*ClusterConfig) != nil {
+		t.Fatal("expected a nil configuration")
+	}
+}
+
+func TestKeepHidden(t *testing.T) {
+	current := &ClusterConfig{
+		Secrets: &SecretsConfig{
+			ClusterSecretKey: "key",
+			Vault:            &VaultConfig{Token: "token", Address: "http://vault"},
+		},
+	}
+
+	updated := &ClusterConfig{
+		Description: "cluster",
+		Secrets: &SecretsConfig{
+			ClusterSecretKey: "other",
+			Vault:            &VaultConfig{Address: "http://vault2"},
+		},
+	}
+	KeepHidden(updated, current)
+	if updated.Description != "cluster" || updated.Secrets.ClusterSecretKey != "key" ||
+		updated.Secrets.Vault.Token != "token" || updated.Secrets.Vault.Address != "http://vault2" {
+		t.Fatal("unexpected updated configuration", updated.Secrets)
+	}
+
+	// removed configurations are recreated with their secrets only
+	updated = &ClusterConfig{Secrets: &SecretsConfig{}}
+	KeepHidden(updated, current)
+	if updated.Secrets.Vault == nil || updated.Secrets.Vault.Token != "token" ||
+		updated.Secrets.Vault.Address != "" {
+		t.Fatal("unexpected updated configuration", updated.Secrets)
+	}
+	if updated.Kvdb != nil || updated.Secrets.Aws != nil {
+		t.Fatal("unexpected configurations without secrets", updated)
+	}
+
+	updated = &ClusterConfig{Secrets: &SecretsConfig{ClusterSecretKey: "key"}}
+	KeepHidden(updated, (*ClusterConfig)(nil))
+	if updated.Secrets.ClusterSecretKey != "" {
+		t.Fatal("expected the new secrets to be cleared", updated.Secrets)
+	}
+}
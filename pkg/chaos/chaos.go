@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
-	"sync/atomic"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -16,6 +18,8 @@ const (
 	Once When = 1 << iota
 	// Random chaos at a random number of calls to ChaosNow.
 	Random
+	// Always chaos at every call to ChaosNow.
+	Always
 )
 
 // Action to take when a chaos point is triggered.
@@ -26,6 +30,16 @@ const (
 	Crash Action = 1 << iota
 	// Error out at calll to Now();
 	Error
+	// Delay the caller of Now().
+	Delay
+)
+
+const (
+	// DefaultProbability is the probability of a Random chaos point
+	// triggering when none is given.
+	DefaultProbability = 0.5
+	// DefaultDelay is how long a Delay action lasts when no delay is given.
+	DefaultDelay = time.Second
 )
 
 // ID identifies a chaos point.
@@ -40,14 +54,48 @@ type Chaos struct {
 	Enabled bool
 	What    Action
 	When    When
-	Count   int
+	// Probability of triggering at each call when When is Random
+	Probability float64
+	// Count is how many times the point triggers before being disabled,
+	// 0 for no limit
+	Count int
+	// Delay is how long the caller is delayed by a Delay action
+	Delay time.Duration
+	// Calls is the number of calls to Now since the point was enabled
+	Calls int
+	// Triggered is the number of times the point triggered since it was
+	// enabled
+	Triggered int
+}
+
+// Name returns the name of the chaos point, made of its package and function.
+func (c *Chaos) Name() string {
+	return c.Pkg + "/" + c.Fn
+}
+
+// Options configure an enabled chaos point.
+type Options struct {
+	// When the point triggers
+	When When
+	// What happens when the point triggers
+	What Action
+	// Probability of triggering at each call when When is Random. The
+	// DefaultProbability is used if not set.
+	Probability float64
+	// Count is how many times the point triggers before being disabled,
+	// 0 for no limit
+	Count int
+	// Delay is how long a Delay action lasts. The DefaultDelay is used if
+	// not set.
+	Delay time.Duration
 }
 
 var (
+	lock      sync.Mutex
 	activated bool
-	chaos     map[ID]*Chaos
-	count     int32
-	r         *rand.Rand
+	chaos     = make(map[ID]*Chaos)
+	count     ID
+	r         = rand.New(rand.NewSource(time.Now().UnixNano()))
 	// ErrNoEnt is generated on an unknown chaos ID.
 	ErrNoEnt = errors.New("ID does not exist")
 	// ErrChaos is generated when Action is set to Error
@@ -56,19 +104,65 @@ var (
 
 // Activate activates chaos points in the system.
 func Activate(activate bool) {
+	lock.Lock()
+	defer lock.Unlock()
 	activated = activate
 }
 
+// Activated returns true if the chaos points are activated.
+func Activated() bool {
+	lock.Lock()
+	defer lock.Unlock()
+	return activated
+}
+
 // Add new chaos point. The ID returned can be used to perform operations on this Chaos Point.
+// Adding a chaos point again returns the ID it was given the first time.
 func Add(pkg string, fn string, desc string) ID {
-	id := ID(atomic.AddInt32(&count, 1))
-	chaos[id] = &Chaos{ID: id, Pkg: pkg, Fn: fn, Desc: desc, Enabled: false}
-	return id
+	lock.Lock()
+	defer lock.Unlock()
+
+	for id, v := range chaos {
+		if v.Pkg == pkg && v.Fn == fn {
+			return id
+		}
+	}
+	count++
+	chaos[count] = &Chaos{ID: count, Pkg: pkg, Fn: fn, Desc: desc, Enabled: false}
+	return count
+}
+
+// Find returns the ID of the chaos point with the given name, made of its
+// package and function separated by a '/'.
+func Find(name string) (ID, error) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	for id, v := range chaos {
+		if v.Name() == name {
+			return id, nil
+		}
+	}
+	return 0, ErrNoEnt
+}
+
+// Get returns the chaos point identified by ID.
+func Get(id ID) (Chaos, error) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	v, ok := chaos[id]
+	if !ok {
+		return Chaos{}, ErrNoEnt
+	}
+	return *v, nil
 }
 
 // Enumerate all chaos points in the system for specified package.
-// If the pkg is "" enumerate all chaos points.
+// If the pkg is "" enumerate all chaos points. The points are sorted by name.
 func Enumerate(pkg string) []Chaos {
+	lock.Lock()
+	defer lock.Unlock()
 
 	ko := make([]Chaos, 0, 10)
 	for _, v := range chaos {
@@ -76,23 +170,75 @@ func Enumerate(pkg string) []Chaos {
 			ko = append(ko, *v)
 		}
 	}
+	sort.Slice(ko, func(i, j int) bool {
+		return ko[i].Name() < ko[j].Name()
+	})
 	return ko
 }
 
 // Enable chaos point identified by ID.
 func Enable(id ID, when When, what Action) error {
-	if v, ok := chaos[id]; !ok {
+	return EnableWithOptions(id, Options{When: when, What: what})
+}
+
+// EnableWithOptions enables chaos point identified by ID with the given
+// options.
+func EnableWithOptions(id ID, opts Options) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if opts.When == Random && opts.Probability == 0 {
+		opts.Probability = DefaultProbability
+	}
+	if opts.What&Delay != 0 && opts.Delay == 0 {
+		opts.Delay = DefaultDelay
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	v, ok := chaos[id]
+	if !ok {
 		return ErrNoEnt
-	} else {
-		v.Enabled = true
-		v.When = when
-		v.What = what
+	}
+	v.Enabled = true
+	v.When = opts.When
+	v.What = opts.What
+	v.Probability = opts.Probability
+	v.Count = opts.Count
+	v.Delay = opts.Delay
+	v.Calls = 0
+	v.Triggered = 0
+	return nil
+}
+
+func (opts *Options) validate() error {
+	switch opts.When {
+	case Once, Random, Always:
+	default:
+		return fmt.Errorf("Invalid chaos trigger %d", opts.When)
+	}
+	if opts.What == 0 || opts.What&^(Crash|Error|Delay) != 0 {
+		return fmt.Errorf("Invalid chaos action %d", opts.What)
+	}
+	if opts.Probability < 0 || opts.Probability > 1 {
+		return fmt.Errorf("Chaos probability %v is not between 0 and 1",
+			opts.Probability)
+	}
+	if opts.Count < 0 {
+		return fmt.Errorf("Chaos count %d is negative", opts.Count)
+	}
+	if opts.Delay < 0 {
+		return fmt.Errorf("Chaos delay %v is negative", opts.Delay)
 	}
 	return nil
 }
 
 // Disable chaos point identified by ID.
 func Disable(id ID) error {
+	lock.Lock()
+	defer lock.Unlock()
+
 	if v, ok := chaos[id]; !ok {
 		return ErrNoEnt
 	} else {
@@ -101,28 +247,110 @@ func Disable(id ID) error {
 	return nil
 }
 
+// DisableAll disables all the chaos points.
+func DisableAll() {
+	lock.Lock()
+	defer lock.Unlock()
+
+	for _, v := range chaos {
+		v.Enabled = false
+	}
+}
+
+// trigger returns the action to take for the chaos point identified by ID
+// and how long to delay the caller, the action is 0 if the point does not
+// trigger.
+func trigger(id ID) (Action, time.Duration) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if !activated {
+		return 0, 0
+	}
+	v, ok := chaos[id]
+	if !ok || !v.Enabled {
+		return 0, 0
+	}
+	v.Calls++
+	if v.When == Random && r.Float64() >= v.Probability {
+		return 0, 0
+	}
+	v.Triggered++
+	if v.When == Once || (v.Count != 0 && v.Triggered >= v.Count) {
+		v.Enabled = false
+	}
+	return v.What, v.Delay
+}
+
 // Now will trigger chaos point if it is enabled.
 func Now(id ID) error {
-	if !activated {
-		return nil
-	}
-	if v, ok := chaos[id]; ok && v.Enabled {
-		v.Count++
-		if v.When == Once ||
-			(v.Count%(r.Int()%10)) == 0 {
-			if v.What == Error {
-				return ErrChaos
-			}
-		} else {
-			panic(fmt.Sprintf("Chaos triggered panic"))
-		}
+	what, delay := trigger(id)
+	if what&Delay != 0 {
+		time.Sleep(delay)
+	}
+	if what&Crash != 0 {
+		panic(fmt.Sprintf("Chaos triggered panic"))
+	}
+	if what&Error != 0 {
+		return ErrChaos
 	}
 	return nil
 }
 
-func init() {
-	r = rand.New(rand.NewSource(time.Now().UnixNano()))
-	activated = false
-	chaos = make(map[ID]*Chaos)
-	count = -1
+// ParseAction parses the names of actions separated by '|', e.g.
+// "delay|error".
+func ParseAction(s string) (Action, error) {
+	var what Action
+	for _, name := range strings.Split(s, "|") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "crash", "panic":
+			what |= Crash
+		case "error":
+			what |= Error
+		case "delay":
+			what |= Delay
+		default:
+			return 0, fmt.Errorf("Unknown chaos action %q", name)
+		}
+	}
+	return what, nil
+}
+
+func (a Action) String() string {
+	names := make([]string, 0, 3)
+	if a&Delay != 0 {
+		names = append(names, "delay")
+	}
+	if a&Error != 0 {
+		names = append(names, "error")
+	}
+	if a&Crash != 0 {
+		names = append(names, "crash")
+	}
+	return strings.Join(names, "|")
+}
+
+// ParseWhen parses the name of a trigger.
+func ParseWhen(s string) (When, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "once":
+		return Once, nil
+	case "random":
+		return Random, nil
+	case "always":
+		return Always, nil
+	}
+	return 0, fmt.Errorf("Unknown chaos trigger %q", s)
+}
+
+func (w When) String() string {
+	switch w {
+	case Once:
+		return "once"
+	case Random:
+		return "random"
+	case Always:
+		return "always"
+	}
+	return ""
 }
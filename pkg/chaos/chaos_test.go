@@ -0,0 +1,238 @@
+package chaos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/portworx/kvdb"
+	"github.com/portworx/kvdb/mem"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	koTestError = Add("test", "error", "test error point")
+	koTestDelay = Add("test", "delay", "test delay point")
+)
+
+func TestAdd(t *testing.T) {
+	id := Add("test", "error", "again")
+	assert.Equal(t, koTestError, id)
+
+	id, err := Find("test/error")
+	assert.NoError(t, err)
+	assert.Equal(t, koTestError, id)
+	_, err = Find("test/missing")
+	assert.Equal(t, ErrNoEnt, err)
+
+	points := Enumerate("test")
+	require.Len(t, points, 2)
+	assert.Equal(t, "test/delay", points[0].Name())
+	assert.Equal(t, "test/error", points[1].Name())
+}
+
+func TestNow(t *testing.T) {
+	defer DisableAll()
+
+	// Nothing happens until chaos is activated
+	assert.NoError(t, Enable(koTestError, Once, Error))
+	assert.NoError(t, Now(koTestError))
+	Activate(true)
+	defer Activate(false)
+
+	// Once
+	assert.NoError(t, Enable(koTestError, Once, Error))
+	assert.Equal(t, ErrChaos, Now(koTestError))
+	assert.NoError(t, Now(koTestError))
+	c, err := Get(koTestError)
+	assert.NoError(t, err)
+	assert.False(t, c.Enabled)
+	assert.Equal(t, 1, c.Triggered)
+
+	// Count
+	err = EnableWithOptions(koTestError, Options{When: Always, What: Error, Count: 3})
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, ErrChaos, Now(koTestError))
+	}
+	assert.NoError(t, Now(koTestError))
+
+	// Probability
+	err = EnableWithOptions(koTestError, Options{When: Random, What: Error, Probability: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, ErrChaos, Now(koTestError))
+	err = EnableWithOptions(koTestError, Options{When: Random, What: Error, Probability: 0.01})
+	assert.NoError(t, err)
+	errors := 0
+	for i := 0; i < 100; i++ {
+		if Now(koTestError) != nil {
+			errors++
+		}
+	}
+	assert.True(t, errors < 20, "%d errors", errors)
+	c, err = Get(koTestError)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, c.Calls)
+	assert.Equal(t, errors, c.Triggered)
+
+	// Delay
+	err = EnableWithOptions(koTestDelay, Options{
+		When:  Always,
+		What:  Delay | Error,
+		Delay: 50 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+	start := time.Now()
+	assert.Equal(t, ErrChaos, Now(koTestDelay))
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+
+	// Crash
+	assert.NoError(t, Enable(koTestError, Once, Crash))
+	assert.Panics(t, func() { Now(koTestError) })
+
+	// Disable
+	assert.NoError(t, Enable(koTestError, Always, Error))
+	assert.NoError(t, Disable(koTestError))
+	assert.NoError(t, Now(koTestError))
+	assert.Equal(t, ErrNoEnt, Disable(ID(10000)))
+}
+
+func TestEnableInvalid(t *testing.T) {
+	for _, opts := range []Options{
+		{What: Error},
+		{When: Always},
+		{When: Always, What: 64},
+		{When: Random, What: Error, Probability: 2},
+		{When: Always, What: Error, Count: -1},
+		{When: Always, What: Delay, Delay: -time.Second},
+	} {
+		assert.Error(t, EnableWithOptions(koTestError, opts), "%+v", opts)
+	}
+	assert.Equal(t, ErrNoEnt, EnableWithOptions(ID(10000), Options{When: Once, What: Error}))
+}
+
+func TestParse(t *testing.T) {
+	what, err := ParseAction("delay|error")
+	assert.NoError(t, err)
+	assert.Equal(t, Delay|Error, what)
+	assert.Equal(t, "delay|error", what.String())
+	_, err = ParseAction("explode")
+	assert.Error(t, err)
+
+	when, err := ParseWhen("random")
+	assert.NoError(t, err)
+	assert.Equal(t, Random, when)
+	assert.Equal(t, "random", when.String())
+	_, err = ParseWhen("sometimes")
+	assert.Error(t, err)
+}
+
+func TestScenario(t *testing.T) {
+	defer DisableAll()
+
+	s, err := ParseScenario([]byte(`
+name: test
+description: errors and delays
+points:
+- point: test/error
+  action: error
+  probability: 0.5
+- point: test/delay
+  action: delay
+  delay: 10ms
+  count: 2
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "test", s.Name)
+	require.Len(t, s.Points, 2)
+	opts, err := s.Points[0].Options()
+	assert.NoError(t, err)
+	assert.Equal(t, Options{When: Random, What: Error, Probability: 0.5}, opts)
+	opts, err = s.Points[1].Options()
+	assert.NoError(t, err)
+	assert.Equal(t, Options{When: Always, What: Delay, Count: 2, Delay: 10 * time.Millisecond}, opts)
+
+	assert.NoError(t, s.Apply())
+	c, err := Get(koTestDelay)
+	assert.NoError(t, err)
+	assert.True(t, c.Enabled)
+	assert.Equal(t, 10*time.Millisecond, c.Delay)
+	s.Revert()
+	c, err = Get(koTestDelay)
+	assert.NoError(t, err)
+	assert.False(t, c.Enabled)
+
+	// Unknown points are not applied
+	s.Points = append(s.Points, ScenarioPoint{Point: "test/missing", Action: "error"})
+	assert.Error(t, s.Apply())
+	c, err = Get(koTestError)
+	assert.NoError(t, err)
+	assert.False(t, c.Enabled)
+
+	for _, data := range []string{
+		"name: empty",
+		"points:\n- action: error",
+		"points:\n- point: test/error\n  action: explode",
+		"points:\n- point: test/error\n  action: delay\n  delay: soon",
+		"points: [",
+	} {
+		_, err := ParseScenario([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
+
+func TestScenarioSdkEnableRequest(t *testing.T) {
+	p := ScenarioPoint{
+		Point:  "fake/create",
+		Action: "delay|error",
+		When:   "once",
+		Delay:  "1500ms",
+	}
+	req, err := p.SdkEnableRequest()
+	assert.NoError(t, err)
+	assert.Equal(t, &api.SdkChaosEnableRequest{
+		Point: "fake/create",
+		Actions: []api.SdkChaosAction{
+			api.SdkChaosAction_SdkChaosActionError,
+			api.SdkChaosAction_SdkChaosActionDelay,
+		},
+		When:    api.SdkChaosWhen_SdkChaosWhenOnce,
+		DelayMs: 1500,
+	}, req)
+
+	p.When = "never"
+	_, err = p.SdkEnableRequest()
+	assert.Error(t, err)
+}
+
+func TestWrapKvdb(t *testing.T) {
+	defer DisableAll()
+	Activate(true)
+	defer Activate(false)
+
+	mkv, err := kvdb.New(mem.Name, "chaos_test", []string{}, nil, logrus.Panicf)
+	require.NoError(t, err)
+	kv := WrapKvdb(mkv)
+
+	_, err = kv.Put("key", "value", 0)
+	assert.NoError(t, err)
+
+	id, err := Find("kvdb/put")
+	require.NoError(t, err)
+	assert.NoError(t, Enable(id, Once, Error))
+	_, err = kv.Put("key", "other", 0)
+	assert.Equal(t, ErrChaos, err)
+	kvp, err := kv.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", string(kvp.Value))
+
+	id, err = Find("kvdb/delete")
+	require.NoError(t, err)
+	assert.NoError(t, Enable(id, Once, Error))
+	_, err = kv.Delete("key")
+	assert.Equal(t, ErrChaos, err)
+	_, err = kv.Delete("key")
+	assert.NoError(t, err)
+}
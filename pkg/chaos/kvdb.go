@@ -0,0 +1,81 @@
+package chaos
+
+import (
+	"github.com/portworx/kvdb"
+)
+
+var (
+	koKvdbPut              = Add("kvdb", "put", "write of a key")
+	koKvdbCreate           = Add("kvdb", "create", "creation of a key")
+	koKvdbUpdate           = Add("kvdb", "update", "update of a key")
+	koKvdbDelete           = Add("kvdb", "delete", "deletion of a key")
+	koKvdbDeleteTree       = Add("kvdb", "delete-tree", "deletion of a tree of keys")
+	koKvdbCompareAndSet    = Add("kvdb", "compare-and-set", "conditional write of a key")
+	koKvdbCompareAndDelete = Add("kvdb", "compare-and-delete", "conditional deletion of a key")
+)
+
+// chaosKvdb has chaos points in front of the writes to a kvdb.
+type chaosKvdb struct {
+	kvdb.Kvdb
+}
+
+// WrapKvdb returns a kvdb with chaos points in front of the writes to kv.
+func WrapKvdb(kv kvdb.Kvdb) kvdb.Kvdb {
+	return &chaosKvdb{Kvdb: kv}
+}
+
+func (kv *chaosKvdb) Put(key string, value interface{}, ttl uint64) (*kvdb.KVPair, error) {
+	if err := Now(koKvdbPut); err != nil {
+		return nil, err
+	}
+	return kv.Kvdb.Put(key, value, ttl)
+}
+
+func (kv *chaosKvdb) Create(key string, value interface{}, ttl uint64) (*kvdb.KVPair, error) {
+	if err := Now(koKvdbCreate); err != nil {
+		return nil, err
+	}
+	return kv.Kvdb.Create(key, value, ttl)
+}
+
+func (kv *chaosKvdb) Update(key string, value interface{}, ttl uint64) (*kvdb.KVPair, error) {
+	if err := Now(koKvdbUpdate); err != nil {
+		return nil, err
+	}
+	return kv.Kvdb.Update(key, value, ttl)
+}
+
+func (kv *chaosKvdb) Delete(key string) (*kvdb.KVPair, error) {
+	if err := Now(koKvdbDelete); err != nil {
+		return nil, err
+	}
+	return kv.Kvdb.Delete(key)
+}
+
+func (kv *chaosKvdb) DeleteTree(prefix string) error {
+	if err := Now(koKvdbDeleteTree); err != nil {
+		return err
+	}
+	return kv.Kvdb.DeleteTree(prefix)
+}
+
+func (kv *chaosKvdb) CompareAndSet(
+	kvp *kvdb.KVPair,
+	flags kvdb.KVFlags,
+	prevValue []byte,
+) (*kvdb.KVPair, error) {
+	if err := Now(koKvdbCompareAndSet); err != nil {
+		return nil, err
+	}
+	return kv.Kvdb.CompareAndSet(kvp, flags, prevValue)
+}
+
+func (kv *chaosKvdb) CompareAndDelete(
+	kvp *kvdb.KVPair,
+	flags kvdb.KVFlags,
+) (*kvdb.KVPair, error) {
+	if err := Now(koKvdbCompareAndDelete); err != nil {
+		return nil, err
+	}
+	return kv.Kvdb.CompareAndDelete(kvp, flags)
+}
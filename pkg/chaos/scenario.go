@@ -0,0 +1,145 @@
+package chaos
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Scenario is a set of chaos points enabled together to run a fault
+// injection test. Scenarios are declared in YAML or JSON files, e.g.:
+//
+//	name: flaky-volumes
+//	description: Volume creation fails now and then and kvdb is slow
+//	points:
+//	- point: fake/create
+//	  action: error
+//	  when: random
+//	  probability: 0.2
+//	- point: kvdb/put
+//	  action: delay
+//	  delay: 200ms
+//	  count: 10
+type Scenario struct {
+	// Name of the scenario
+	Name string `yaml:"name"`
+	// Description of the scenario
+	Description string `yaml:"description,omitempty"`
+	// Points are the chaos points enabled by the scenario
+	Points []ScenarioPoint `yaml:"points"`
+}
+
+// ScenarioPoint is a chaos point enabled by a scenario.
+type ScenarioPoint struct {
+	// Point is the name of the chaos point, its package and function
+	// separated by a '/'
+	Point string `yaml:"point"`
+	// Action is what happens when the point triggers: error, crash or delay.
+	// Actions can be combined with a '|', e.g. "delay|error".
+	Action string `yaml:"action"`
+	// When the point triggers: once, random or always. It defaults to
+	// random if a probability is given, always otherwise.
+	When string `yaml:"when,omitempty"`
+	// Probability of triggering at each call when random
+	Probability float64 `yaml:"probability,omitempty"`
+	// Count is how many times the point triggers, 0 for no limit
+	Count int `yaml:"count,omitempty"`
+	// Delay is how long a delay action lasts, e.g. "500ms"
+	Delay string `yaml:"delay,omitempty"`
+}
+
+// Options returns the options to enable the chaos point with.
+func (p *ScenarioPoint) Options() (Options, error) {
+	var (
+		opts Options
+		err  error
+	)
+	if len(p.When) != 0 {
+		if opts.When, err = ParseWhen(p.When); err != nil {
+			return opts, err
+		}
+	} else if p.Probability != 0 {
+		opts.When = Random
+	} else {
+		opts.When = Always
+	}
+	if opts.What, err = ParseAction(p.Action); err != nil {
+		return opts, err
+	}
+	if len(p.Delay) != 0 {
+		if opts.Delay, err = time.ParseDuration(p.Delay); err != nil {
+			return opts, fmt.Errorf("Invalid chaos delay %q: %v", p.Delay, err)
+		}
+	}
+	opts.Probability = p.Probability
+	opts.Count = p.Count
+	return opts, opts.validate()
+}
+
+// ParseScenario parses and validates a scenario.
+func ParseScenario(data []byte) (*Scenario, error) {
+	s := &Scenario{}
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("Unable to parse chaos scenario: %v", err)
+	}
+	if len(s.Points) == 0 {
+		return nil, fmt.Errorf("Chaos scenario %s has no points", s.Name)
+	}
+	for i, p := range s.Points {
+		if len(p.Point) == 0 {
+			return nil, fmt.Errorf("Chaos scenario %s: point %d has no name",
+				s.Name, i)
+		}
+		if _, err := p.Options(); err != nil {
+			return nil, fmt.Errorf("Chaos scenario %s: point %s: %v",
+				s.Name, p.Point, err)
+		}
+	}
+	return s, nil
+}
+
+// LoadScenario reads and validates the scenario in a file.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read chaos scenario %s: %v", path, err)
+	}
+	return ParseScenario(data)
+}
+
+// Apply enables the chaos points of the scenario in this process. Either
+// all the points are enabled or none is.
+func (s *Scenario) Apply() error {
+	ids := make([]ID, len(s.Points))
+	for i, p := range s.Points {
+		id, err := Find(p.Point)
+		if err != nil {
+			return fmt.Errorf("Chaos point %s: %v", p.Point, err)
+		}
+		ids[i] = id
+	}
+	for i, p := range s.Points {
+		opts, err := p.Options()
+		if err == nil {
+			err = EnableWithOptions(ids[i], opts)
+		}
+		if err != nil {
+			for _, id := range ids[:i] {
+				Disable(id)
+			}
+			return fmt.Errorf("Chaos point %s: %v", p.Point, err)
+		}
+	}
+	return nil
+}
+
+// Revert disables the chaos points of the scenario in this process.
+func (s *Scenario) Revert() {
+	for _, p := range s.Points {
+		if id, err := Find(p.Point); err == nil {
+			Disable(id)
+		}
+	}
+}
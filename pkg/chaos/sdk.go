@@ -0,0 +1,81 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libopenstorage/openstorage/api"
+)
+
+// SdkEnableRequest returns the request enabling the chaos point with the
+// SDK chaos service.
+func (p *ScenarioPoint) SdkEnableRequest() (*api.SdkChaosEnableRequest, error) {
+	opts, err := p.Options()
+	if err != nil {
+		return nil, err
+	}
+	req := &api.SdkChaosEnableRequest{
+		Point:       p.Point,
+		Probability: opts.Probability,
+		Count:       int64(opts.Count),
+		DelayMs:     int64(opts.Delay / time.Millisecond),
+	}
+	switch opts.When {
+	case Once:
+		req.When = api.SdkChaosWhen_SdkChaosWhenOnce
+	case Random:
+		req.When = api.SdkChaosWhen_SdkChaosWhenRandom
+	case Always:
+		req.When = api.SdkChaosWhen_SdkChaosWhenAlways
+	}
+	if opts.What&Error != 0 {
+		req.Actions = append(req.Actions, api.SdkChaosAction_SdkChaosActionError)
+	}
+	if opts.What&Crash != 0 {
+		req.Actions = append(req.Actions, api.SdkChaosAction_SdkChaosActionPanic)
+	}
+	if opts.What&Delay != 0 {
+		req.Actions = append(req.Actions, api.SdkChaosAction_SdkChaosActionDelay)
+	}
+	return req, nil
+}
+
+// ApplySdk enables the chaos points of the scenario in a server with its SDK
+// chaos service. Either all the points are enabled or none is.
+func (s *Scenario) ApplySdk(ctx context.Context, client api.OpenStorageChaosClient) error {
+	for i, p := range s.Points {
+		req, err := p.SdkEnableRequest()
+		if err == nil {
+			_, err = client.Enable(ctx, req)
+		}
+		if err != nil {
+			s.revertSdk(ctx, client, s.Points[:i])
+			return fmt.Errorf("Chaos point %s: %v", p.Point, err)
+		}
+	}
+	return nil
+}
+
+// RevertSdk disables the chaos points of the scenario in a server with its
+// SDK chaos service.
+func (s *Scenario) RevertSdk(ctx context.Context, client api.OpenStorageChaosClient) error {
+	return s.revertSdk(ctx, client, s.Points)
+}
+
+func (s *Scenario) revertSdk(
+	ctx context.Context,
+	client api.OpenStorageChaosClient,
+	points []ScenarioPoint,
+) error {
+	var lastErr error
+	for _, p := range points {
+		_, err := client.Disable(ctx, &api.SdkChaosDisableRequest{
+			Point: p.Point,
+		})
+		if err != nil {
+			lastErr = fmt.Errorf("Chaos point %s: %v", p.Point, err)
+		}
+	}
+	return lastErr
+}
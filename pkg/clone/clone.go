@@ -0,0 +1,316 @@
+// Package clone clones directory trees, with reflinks where the file system
+// supports them.
+package clone
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// Mode selects how the files of a tree are cloned.
+type Mode int
+
+const (
+	// Copy reflinks the files, and copies them if the file system does
+	// not support reflinks
+	Copy Mode = iota
+	// Link reflinks the files, and hard links them if the file system
+	// does not support reflinks. Hard linked files share their data and
+	// attributes, so this is only suitable for read only clones.
+	Link
+)
+
+// Method is how files were cloned.
+type Method int
+
+const (
+	// Reflinked files share their blocks until they are written
+	Reflinked Method = iota
+	// Copied files have their own blocks
+	Copied
+	// Linked files are hard links to the source files, whose writes they see
+	Linked
+)
+
+// String returns the name of the method.
+func (m Method) String() string {
+	switch m {
+	case Reflinked:
+		return "reflink"
+	case Copied:
+		return "copy"
+	case Linked:
+		return "link"
+	}
+	return fmt.Sprintf("Method(%d)", int(m))
+}
+
+const (
+	// ficlone is FICLONE of <linux/fs.h>
+	ficlone = 0x40049409
+	// copyBufferSize is the size of the blocks copied, which are skipped
+	// when they are zero to keep the copies sparse
+	copyBufferSize = 64 * 1024
+	// LostFound is the directory created at the root of ext4 file systems
+	LostFound = "lost+found"
+)
+
+// ErrCanceled is returned by Tree when it is canceled.
+var ErrCanceled = errors.New("Clone was canceled")
+
+// cloner clones the files of a tree.
+type cloner struct {
+	mode Mode
+	// method is the weakest method used to clone the files, linking being
+	// weaker than copying and copying weaker than reflinking
+	method Method
+	// links maps the inodes of the files of the source with several links
+	// to their first clone, to link their other names to it
+	links map[[2]uint64]string
+}
+
+// Tree clones the files of src into dest, which may exist. Ownership,
+// permissions, times, extended attributes, symlinks, special files and the
+// hard links between the files of src are preserved. It returns Linked if
+// any file was hard linked, Copied if any was copied and Reflinked otherwise.
+func Tree(src, dest string, mode Mode, cancel <-chan struct{}) (Method, error) {
+	c := &cloner{mode: mode, links: make(map[[2]uint64]string)}
+	// The times of the directories are set once their content is cloned.
+	var dirs [][2]string
+	err := filepath.Walk(src, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		select {
+		case <-cancel:
+			return ErrCanceled
+		default:
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		if fi.IsDir() {
+			dirs = append(dirs, [2]string{p, target})
+		}
+		return c.clone(p, target, fi)
+	})
+	if err != nil {
+		return c.method, err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		fi, err := os.Lstat(dirs[i][0])
+		if err != nil {
+			return c.method, err
+		}
+		if err := setTimes(dirs[i][1], fi); err != nil {
+			return c.method, err
+		}
+	}
+	return c.method, nil
+}
+
+// clone clones a file without its times, or the times of a directory.
+func (c *cloner) clone(src, dest string, fi os.FileInfo) error {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("Cannot get the owner of %s", src)
+	}
+	if !fi.IsDir() && st.Nlink > 1 {
+		inode := [2]uint64{st.Dev, st.Ino}
+		if first, ok := c.links[inode]; ok {
+			return os.Link(first, dest)
+		}
+		c.links[inode] = dest
+	}
+
+	switch {
+	case fi.IsDir():
+		if err := os.Mkdir(dest, fi.Mode().Perm()); err != nil && !os.IsExist(err) {
+			return err
+		}
+	case fi.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		if err := os.Symlink(link, dest); err != nil {
+			return err
+		}
+	case fi.Mode().IsRegular():
+		method, err := File(src, dest, c.mode)
+		if method > c.method {
+			c.method = method
+		}
+		if err != nil || method == Linked {
+			return err
+		}
+	case fi.Mode()&os.ModeSocket != 0:
+		// Sockets are recreated by the programs listening on them.
+		return nil
+	default:
+		if err := unix.Mknod(dest, st.Mode, int(st.Rdev)); err != nil {
+			return err
+		}
+	}
+	if err := setAttributes(src, dest, fi, st); err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return nil
+	}
+	return setTimes(dest, fi)
+}
+
+// File reflinks a regular file, or hard links or copies it depending
+// on the mode. It returns how the file was cloned.
+func File(src, dest string, mode Mode) (Method, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return Copied, err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return Copied, err
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, out.Fd(), ficlone, in.Fd())
+	if errno == 0 {
+		return Reflinked, out.Close()
+	}
+	if mode == Link {
+		out.Close()
+		os.Remove(dest)
+		if err := os.Link(src, dest); err == nil {
+			return Linked, nil
+		}
+		// Files of different file systems or projects cannot be linked.
+		if out, err = os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600); err != nil {
+			return Copied, err
+		}
+	}
+	err = copySparse(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return Copied, err
+}
+
+// copySparse copies a file, skipping the zero blocks to leave holes.
+func copySparse(out, in *os.File) error {
+	buf := make([]byte, copyBufferSize)
+	zero := make([]byte, copyBufferSize)
+	size := int64(0)
+	for {
+		n, err := in.Read(buf)
+		if n > 0 {
+			if bytes.Equal(buf[:n], zero[:n]) {
+				if _, err := out.Seek(int64(n), io.SeekCurrent); err != nil {
+					return err
+				}
+			} else if _, err := out.Write(buf[:n]); err != nil {
+				return err
+			}
+			size += int64(n)
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	return out.Truncate(size)
+}
+
+// setAttributes sets the owner, the permissions and the extended
+// attributes of a clone.
+func setAttributes(src, dest string, fi os.FileInfo, st *syscall.Stat_t) error {
+	if err := os.Lchown(dest, int(st.Uid), int(st.Gid)); err != nil {
+		return err
+	}
+	// The permissions are set after the owner, which clears the setuid and
+	// setgid bits.
+	if fi.Mode()&os.ModeSymlink == 0 {
+		if err := syscall.Chmod(dest, st.Mode&07777); err != nil {
+			return err
+		}
+	}
+	return copyXattrs(src, dest)
+}
+
+// copyXattrs copies the extended attributes of a file, which are ignored if
+// the file system of the clone does not support them.
+func copyXattrs(src, dest string) error {
+	size, err := unix.Llistxattr(src, nil)
+	if err == unix.ENOTSUP || size <= 0 {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	buf := make([]byte, size)
+	size, err = unix.Llistxattr(src, buf)
+	if err != nil {
+		return err
+	}
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		n, err := unix.Lgetxattr(src, string(name), nil)
+		if err != nil {
+			return err
+		}
+		value := make([]byte, n)
+		if n, err = unix.Lgetxattr(src, string(name), value); err != nil {
+			return err
+		}
+		err = unix.Lsetxattr(dest, string(name), value[:n], 0)
+		if err == unix.ENOTSUP {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("Failed to set %s on %s: %v", name, dest, err)
+		}
+	}
+	return nil
+}
+
+// setTimes sets the access and modification times of a clone.
+func setTimes(dest string, fi os.FileInfo) error {
+	st := fi.Sys().(*syscall.Stat_t)
+	ts := []unix.Timespec{
+		unix.NsecToTimespec(syscall.TimespecToNsec(st.Atim)),
+		unix.NsecToTimespec(syscall.TimespecToNsec(st.Mtim)),
+	}
+	return unix.UtimesNanoAt(unix.AT_FDCWD, dest, ts, unix.AT_SYMLINK_NOFOLLOW)
+}
+
+// ClearDir removes the content of a directory, except its lost+found
+// directory if it is the root of a loopback image.
+func ClearDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	names, err := f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if name == LostFound {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
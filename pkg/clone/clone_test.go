@@ -0,0 +1,163 @@
+package clone
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func testTree(t *testing.T) string {
+	src, err := ioutil.TempDir("", "clone")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "dir", "sub"), 0750))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(src, "dir", "file"), []byte("data"), 0640))
+	require.NoError(t, os.Link(filepath.Join(src, "dir", "file"), filepath.Join(src, "link")))
+	require.NoError(t, os.Symlink("dir/file", filepath.Join(src, "symlink")))
+
+	// A sparse file with data after a hole
+	f, err := os.Create(filepath.Join(src, "sparse"))
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte("end"), 4*copyBufferSize)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	if os.Getuid() == 0 {
+		require.NoError(t, os.Lchown(filepath.Join(src, "dir", "file"), 1234, 5678))
+		require.NoError(t, os.Lchown(filepath.Join(src, "symlink"), 1234, 5678))
+	}
+	if err := unix.Lsetxattr(filepath.Join(src, "dir", "file"), "user.test", []byte("value"), 0); err != nil {
+		t.Logf("Extended attributes are not supported: %v", err)
+	}
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(filepath.Join(src, "dir", "file"), old, old))
+	require.NoError(t, os.Chtimes(filepath.Join(src, "dir"), old, old))
+	return src
+}
+
+func TestTree(t *testing.T) {
+	src := testTree(t)
+	defer os.RemoveAll(src)
+	dest, err := ioutil.TempDir("", "clone")
+	require.NoError(t, err)
+	defer os.RemoveAll(dest)
+
+	method, err := Tree(src, dest, Copy, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, Linked, method)
+
+	b, err := ioutil.ReadFile(filepath.Join(dest, "dir", "file"))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(b))
+	fi, err := os.Stat(filepath.Join(dest, "dir", "file"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), fi.Mode().Perm())
+	srcFi, err := os.Stat(filepath.Join(src, "dir", "file"))
+	require.NoError(t, err)
+	assert.Equal(t, srcFi.ModTime(), fi.ModTime())
+	assert.False(t, os.SameFile(srcFi, fi))
+
+	// Directories keep their times after their content is cloned
+	fi, err = os.Stat(filepath.Join(dest, "dir"))
+	require.NoError(t, err)
+	srcFi, err = os.Stat(filepath.Join(src, "dir"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), fi.Mode().Perm())
+	assert.Equal(t, srcFi.ModTime(), fi.ModTime())
+
+	// Hard links and symlinks are preserved
+	fi, err = os.Stat(filepath.Join(dest, "dir", "file"))
+	require.NoError(t, err)
+	linkFi, err := os.Stat(filepath.Join(dest, "link"))
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(fi, linkFi))
+	target, err := os.Readlink(filepath.Join(dest, "symlink"))
+	require.NoError(t, err)
+	assert.Equal(t, "dir/file", target)
+
+	// Sparse files stay sparse
+	b, err = ioutil.ReadFile(filepath.Join(dest, "sparse"))
+	require.NoError(t, err)
+	assert.Equal(t, 4*copyBufferSize+3, len(b))
+	assert.Equal(t, "end", string(b[4*copyBufferSize:]))
+	fi, err = os.Stat(filepath.Join(dest, "sparse"))
+	require.NoError(t, err)
+	assert.True(t, fi.Sys().(*syscall.Stat_t).Blocks*512 < 4*copyBufferSize)
+
+	if os.Getuid() == 0 {
+		for _, name := range []string{"dir/file", "symlink"} {
+			fi, err = os.Lstat(filepath.Join(dest, name))
+			require.NoError(t, err)
+			st := fi.Sys().(*syscall.Stat_t)
+			assert.Equal(t, uint32(1234), st.Uid, name)
+			assert.Equal(t, uint32(5678), st.Gid, name)
+		}
+	}
+	if n, err := unix.Lgetxattr(filepath.Join(src, "dir", "file"), "user.test", nil); err == nil && n > 0 {
+		value := make([]byte, 16)
+		n, err := unix.Lgetxattr(filepath.Join(dest, "dir", "file"), "user.test", value)
+		require.NoError(t, err)
+		assert.Equal(t, "value", string(value[:n]))
+	}
+}
+
+func TestTreeLinks(t *testing.T) {
+	src := testTree(t)
+	defer os.RemoveAll(src)
+	dest, err := ioutil.TempDir("", "clone")
+	require.NoError(t, err)
+	defer os.RemoveAll(dest)
+
+	method, err := Tree(src, dest, Link, nil)
+	require.NoError(t, err)
+
+	// Files are reflinked or hard linked on the same file system
+	srcFi, err := os.Stat(filepath.Join(src, "dir", "file"))
+	require.NoError(t, err)
+	fi, err := os.Stat(filepath.Join(dest, "dir", "file"))
+	require.NoError(t, err)
+	b, err := ioutil.ReadFile(filepath.Join(dest, "dir", "file"))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(b))
+	linked := os.SameFile(srcFi, fi)
+	if !linked {
+		t.Log("Files were reflinked")
+		assert.Equal(t, Reflinked, method)
+	} else {
+		assert.Equal(t, Linked, method)
+	}
+	linkFi, err := os.Stat(filepath.Join(dest, "link"))
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(fi, linkFi))
+}
+
+func TestTreeCancel(t *testing.T) {
+	src := testTree(t)
+	defer os.RemoveAll(src)
+	dest, err := ioutil.TempDir("", "clone")
+	require.NoError(t, err)
+	defer os.RemoveAll(dest)
+
+	cancel := make(chan struct{})
+	close(cancel)
+	_, err = Tree(src, dest, Copy, cancel)
+	assert.Equal(t, ErrCanceled, err)
+}
+
+func TestClearDir(t *testing.T) {
+	dir := testTree(t)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, LostFound), 0700))
+
+	require.NoError(t, ClearDir(dir))
+	names, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, names, 1)
+	assert.Equal(t, LostFound, names[0].Name())
+}
@@ -0,0 +1,124 @@
+package quota
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+)
+
+// run runs a command and returns its output in the error if it fails.
+func run(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %v: %s", name, strings.Join(args, " "), err,
+			strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// createImage creates an ext4 image of a size and mounts it on a directory.
+func createImage(image, dir string, size uint64) error {
+	f, err := os.OpenFile(image, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	err = f.Truncate(int64(size))
+	f.Close()
+	if err == nil {
+		err = run("mkfs.ext4", "-q", "-F", "-m", "0", image)
+	}
+	if err == nil {
+		err = activateImage(image, dir)
+	}
+	if err != nil {
+		os.Remove(image)
+	}
+	return err
+}
+
+// mountSource returns the source of the mount on a directory, which is
+// empty if the directory is not a mount point.
+func mountSource(dir string) (string, error) {
+	dir = filepath.Clean(dir)
+	entries, err := mounts()
+	if err != nil {
+		return "", err
+	}
+	source := ""
+	for _, e := range entries {
+		// The last mount on a directory hides the others.
+		if e.MountPoint == dir {
+			source = e.Source
+		}
+	}
+	return source, nil
+}
+
+// activateImage mounts an image on its directory if it is not mounted.
+func activateImage(image, dir string) error {
+	source, err := mountSource(dir)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(source, "/dev/loop") {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0744); err != nil {
+		return err
+	}
+	logrus.Infof("Mounting loopback quota image %s on %s", image, dir)
+	return run("mount", "-o", "loop", image, dir)
+}
+
+// resizeImage grows an image and the file system mounted from it.
+func resizeImage(image, dir string, size uint64) error {
+	fi, err := os.Stat(image)
+	if err != nil {
+		return err
+	}
+	if size < uint64(fi.Size()) {
+		return ErrShrink
+	}
+	if size == uint64(fi.Size()) {
+		return nil
+	}
+	if err := activateImage(image, dir); err != nil {
+		return err
+	}
+	loop, err := mountSource(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.Truncate(image, int64(size)); err != nil {
+		return err
+	}
+	err = run("losetup", "-c", loop)
+	if err == nil {
+		err = run("resize2fs", loop)
+	}
+	if err != nil {
+		// The file system was not grown, so the image keeps its size.
+		os.Truncate(image, fi.Size())
+		run("losetup", "-c", loop)
+	}
+	return err
+}
+
+// removeImage unmounts an image and deletes it.
+func removeImage(image, dir string) error {
+	source, err := mountSource(dir)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(source, "/dev/loop") {
+		if err := syscall.Unmount(dir, 0); err != nil {
+			return fmt.Errorf("Failed to unmount %s: %v", dir, err)
+		}
+	}
+	return os.Remove(image)
+}
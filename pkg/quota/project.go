@@ -0,0 +1,234 @@
+package quota
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// Defined in <linux/fs.h>:
+	fsIocFsgetxattr    = 0x801c581f
+	fsIocFssetxattr    = 0x401c5820
+	fsXflagProjinherit = 0x200
+	// Defined in <linux/quota.h>:
+	qGetquota  = 0x800007
+	qSetquota  = 0x800008
+	prjQuota   = 2
+	qifBlimits = 1
+	// quotaBlockSize is the unit of the block limits of quotactl
+	quotaBlockSize = 1024
+)
+
+// fsxattr is struct fsxattr of <linux/fs.h>.
+type fsxattr struct {
+	Xflags     uint32
+	Extsize    uint32
+	Nextents   uint32
+	Projid     uint32
+	Cowextsize uint32
+	Pad        [8]byte
+}
+
+// dqblk is struct if_dqblk of <linux/quota.h>.
+type dqblk struct {
+	Bhardlimit uint64
+	Bsoftlimit uint64
+	Curspace   uint64
+	Ihardlimit uint64
+	Isoftlimit uint64
+	Curinodes  uint64
+	Btime      uint64
+	Itime      uint64
+	Valid      uint32
+	_          uint32
+}
+
+func qcmd(cmd, typ int) int {
+	return cmd<<8 | typ&0xff
+}
+
+func quotactl(cmd int, device string, id uint32, dq *dqblk) error {
+	dev, err := syscall.BytePtrFromString(device)
+	if err != nil {
+		return err
+	}
+	_, _, errno := syscall.Syscall6(syscall.SYS_QUOTACTL,
+		uintptr(qcmd(cmd, prjQuota)),
+		uintptr(unsafe.Pointer(dev)),
+		uintptr(id),
+		uintptr(unsafe.Pointer(dq)),
+		0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// setProjectLimit sets the hard and soft limits of a project, which is not
+// limited if size is 0.
+func setProjectLimit(device string, id uint32, size uint64) error {
+	blocks := (size + quotaBlockSize - 1) / quotaBlockSize
+	dq := &dqblk{
+		Bhardlimit: blocks,
+		Bsoftlimit: blocks,
+		Valid:      qifBlimits,
+	}
+	if err := quotactl(qSetquota, device, id, dq); err != nil {
+		return fmt.Errorf("Failed to set the quota of project %d: %v", id, err)
+	}
+	return nil
+}
+
+// projectUsage returns the space used by a project and its limit.
+func projectUsage(device string, id uint32) (uint64, uint64, error) {
+	dq := &dqblk{}
+	if err := quotactl(qGetquota, device, id, dq); err != nil {
+		return 0, 0, fmt.Errorf("Failed to get the quota of project %d: %v", id, err)
+	}
+	return dq.Curspace, dq.Bhardlimit * quotaBlockSize, nil
+}
+
+// setProjectID sets the project of a directory and of its files. New files
+// inherit the project of the directory.
+func setProjectID(dir string, id uint32) error {
+	return filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() && !fi.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		var attr fsxattr
+		if err := ioctl(f.Fd(), fsIocFsgetxattr, unsafe.Pointer(&attr)); err != nil {
+			return fmt.Errorf("Failed to get the attributes of %s: %v", p, err)
+		}
+		attr.Projid = id
+		if fi.IsDir() {
+			attr.Xflags |= fsXflagProjinherit
+		}
+		if err := ioctl(f.Fd(), fsIocFssetxattr, unsafe.Pointer(&attr)); err != nil {
+			return fmt.Errorf("Failed to set the project of %s: %v", p, err)
+		}
+		return nil
+	})
+}
+
+func ioctl(fd uintptr, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// projectQuotaDevice returns the block device of the file system of a
+// directory if project quotas are enabled on it.
+func projectQuotaDevice(dir string) (string, error) {
+	var st syscall.Stat_t
+	if err := syscall.Stat(dir, &st); err != nil {
+		return "", err
+	}
+	entries, err := mounts()
+	if err != nil {
+		return "", err
+	}
+	dev := fmt.Sprintf("%d:%d", major(st.Dev), minor(st.Dev))
+	for _, e := range entries {
+		if e.Device != dev {
+			continue
+		}
+		if e.FSType != "xfs" && e.FSType != "ext4" {
+			return "", fmt.Errorf("%s file system does not support project quotas", e.FSType)
+		}
+		if err := quotactl(qGetquota, e.Source, 0, &dqblk{}); err != nil {
+			return "", fmt.Errorf("project quotas are not enabled on %s: %v", e.Source, err)
+		}
+		return e.Source, nil
+	}
+	return "", fmt.Errorf("no mount found for device %s", dev)
+}
+
+func major(dev uint64) uint64 {
+	return (dev>>8)&0xfff | (dev>>32)&^0xfff
+}
+
+func minor(dev uint64) uint64 {
+	return dev&0xff | (dev>>12)&^0xff
+}
+
+// mountEntry is a line of /proc/self/mountinfo.
+type mountEntry struct {
+	// Device is the major:minor number of the mounted device
+	Device     string
+	MountPoint string
+	FSType     string
+	Source     string
+}
+
+func mounts() ([]mountEntry, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseMountInfo(f)
+}
+
+func parseMountInfo(r io.Reader) ([]mountEntry, error) {
+	var entries []mountEntry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// The optional fields end with a separator before the file system
+		// type and the source.
+		sep := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "-" {
+				sep = i
+				break
+			}
+		}
+		if len(fields) < 5 || sep < 0 || sep+2 >= len(fields) {
+			return nil, fmt.Errorf("Invalid mountinfo line %q", scanner.Text())
+		}
+		entries = append(entries, mountEntry{
+			Device:     fields[2],
+			MountPoint: unescape(fields[4]),
+			FSType:     fields[sep+1],
+			Source:     unescape(fields[sep+2]),
+		})
+	}
+	return entries, scanner.Err()
+}
+
+// unescape decodes the octal escapes of the spaces, tabs, newlines and
+// backslashes of mountinfo.
+func unescape(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,353 @@
+// Package quota limits the size of the directories of a base directory,
+// with project quotas where the file system of the base directory supports
+// them and with loopback images mounted on the directories otherwise. The
+// directories of a base directory shared by several nodes are only limited
+// with project quotas.
+package quota
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Type is the mechanism limiting the size of a directory.
+type Type string
+
+const (
+	// TypeNone is the type of the directories without a limit
+	TypeNone = Type("none")
+	// TypeProject limits directories with XFS or ext4 project quotas
+	TypeProject = Type("project")
+	// TypeLoopback limits directories with ext4 images mounted on them
+	TypeLoopback = Type("loopback")
+
+	// projectsFile records the project ids of the directories of a base
+	// directory, like /etc/projects
+	projectsFile = ".projects"
+	// imageSuffix is the suffix of the loopback images of the directories
+	imageSuffix = ".img"
+	// firstProjectID is the first project id allocated to directories
+	firstProjectID = 1000
+)
+
+var (
+	// ErrShrink is returned when a loopback image would be shrunk
+	ErrShrink = errors.New("Loopback quotas cannot be shrunk")
+	// ErrNotLimited is returned by Resize for directories which were created
+	// without a limit and cannot be limited afterwards
+	ErrNotLimited = errors.New("Directory was created without a size limit")
+	// ErrSharedImage is returned for the loopback images found in a base
+	// directory shared by several nodes, which must not be mounted
+	ErrSharedImage = errors.New("Loopback images cannot be mounted from shared storage")
+)
+
+// Usage is the space used by a directory and its limit, which is 0 if the
+// directory is not limited.
+type Usage struct {
+	Type  Type
+	Used  uint64
+	Limit uint64
+}
+
+// Manager limits the size of the directories of a base directory.
+type Manager struct {
+	sync.Mutex
+	base string
+	// device is the block device of the base directory if its file system
+	// supports project quotas
+	device string
+	// shared is set if the base directory is shared by several nodes. Its
+	// directories are then not limited without project quotas, since an
+	// ext4 image mounted by several nodes would be corrupted.
+	shared bool
+}
+
+// New returns the manager of the directories of base, which uses project
+// quotas if they are enabled on the file system of base.
+func New(base string) (*Manager, error) {
+	return newManager(base, false)
+}
+
+// NewShared returns the manager of the directories of base, a directory
+// shared by several nodes such as an nfs export. The directories are only
+// limited if project quotas are enabled on the file system of base, which
+// is never the case for nfs mounts.
+func NewShared(base string) (*Manager, error) {
+	return newManager(base, true)
+}
+
+func newManager(base string, shared bool) (*Manager, error) {
+	if err := os.MkdirAll(base, 0744); err != nil {
+		return nil, err
+	}
+	m := &Manager{base: base, shared: shared}
+	device, err := projectQuotaDevice(base)
+	if err == nil {
+		logrus.Infof("Using project quotas of %s on %s", device, base)
+		m.device = device
+	} else if shared {
+		logrus.Warnf("Project quotas are not available on %s, the size of its "+
+			"directories is not enforced: %v", base, err)
+	} else {
+		logrus.Infof("Project quotas are not available on %s, using loopback images: %v",
+			base, err)
+	}
+	return m, nil
+}
+
+// Type returns the mechanism limiting the new directories.
+func (m *Manager) Type() Type {
+	if len(m.device) != 0 {
+		return TypeProject
+	} else if m.shared {
+		return TypeNone
+	}
+	return TypeLoopback
+}
+
+func (m *Manager) dir(name string) string {
+	return path.Join(m.base, name)
+}
+
+func (m *Manager) image(name string) string {
+	return path.Join(m.base, name+imageSuffix)
+}
+
+// typeOf returns the mechanism limiting a directory and its project id.
+func (m *Manager) typeOf(name string) (Type, uint32, error) {
+	if _, err := os.Stat(m.image(name)); err == nil {
+		return TypeLoopback, 0, nil
+	}
+	projects, err := m.loadProjects()
+	if err != nil {
+		return TypeNone, 0, err
+	}
+	if id, ok := projects[name]; ok {
+		return TypeProject, id, nil
+	}
+	return TypeNone, 0, nil
+}
+
+// Create limits a new empty directory of the base directory to size bytes.
+// The directory is not limited if the manager has no way to limit it.
+func (m *Manager) Create(name string, size uint64) error {
+	m.Lock()
+	defer m.Unlock()
+	switch m.Type() {
+	case TypeLoopback:
+		return createImage(m.image(name), m.dir(name), size)
+	case TypeProject:
+		return m.createProject(name, size)
+	}
+	return nil
+}
+
+func (m *Manager) createProject(name string, size uint64) error {
+	projects, err := m.loadProjects()
+	if err != nil {
+		return err
+	}
+	id, ok := projects[name]
+	if !ok {
+		id = firstProjectID
+		for _, used := range projects {
+			if used >= id {
+				id = used + 1
+			}
+		}
+		projects[name] = id
+		if err := m.saveProjects(projects); err != nil {
+			return err
+		}
+	}
+	if err := setProjectID(m.dir(name), id); err != nil {
+		return err
+	}
+	return setProjectLimit(m.device, id, size)
+}
+
+// Activate mounts the loopback image of a directory if it is not mounted,
+// as after a restart.
+func (m *Manager) Activate(name string) error {
+	m.Lock()
+	defer m.Unlock()
+	t, _, err := m.typeOf(name)
+	if err != nil || t != TypeLoopback {
+		return err
+	}
+	if m.shared {
+		return ErrSharedImage
+	}
+	return activateImage(m.image(name), m.dir(name))
+}
+
+// Resize changes the limit of a directory. Directories created without a
+// limit are limited if project quotas are available, and left unlimited if
+// the manager has no way to limit them.
+func (m *Manager) Resize(name string, size uint64) error {
+	m.Lock()
+	defer m.Unlock()
+	t, id, err := m.typeOf(name)
+	if err != nil {
+		return err
+	}
+	switch t {
+	case TypeLoopback:
+		if m.shared {
+			return ErrSharedImage
+		}
+		return resizeImage(m.image(name), m.dir(name), size)
+	case TypeProject:
+		return setProjectLimit(m.device, id, size)
+	}
+	switch m.Type() {
+	case TypeProject:
+		return m.createProject(name, size)
+	case TypeNone:
+		return nil
+	}
+	return ErrNotLimited
+}
+
+// Usage returns the space used by a directory. The space used by the
+// directories without a limit is computed by walking them.
+func (m *Manager) Usage(name string) (*Usage, error) {
+	m.Lock()
+	defer m.Unlock()
+	t, id, err := m.typeOf(name)
+	if err != nil {
+		return nil, err
+	}
+	switch t {
+	case TypeLoopback:
+		if m.shared {
+			return nil, ErrSharedImage
+		}
+		if err := activateImage(m.image(name), m.dir(name)); err != nil {
+			return nil, err
+		}
+		var st syscall.Statfs_t
+		if err := syscall.Statfs(m.dir(name), &st); err != nil {
+			return nil, err
+		}
+		fi, err := os.Stat(m.image(name))
+		if err != nil {
+			return nil, err
+		}
+		return &Usage{
+			Type:  t,
+			Used:  (st.Blocks - st.Bfree) * uint64(st.Bsize),
+			Limit: uint64(fi.Size()),
+		}, nil
+	case TypeProject:
+		used, limit, err := projectUsage(m.device, id)
+		if err != nil {
+			return nil, err
+		}
+		return &Usage{Type: t, Used: used, Limit: limit}, nil
+	}
+	used, err := walkUsage(m.dir(name))
+	if err != nil {
+		return nil, err
+	}
+	return &Usage{Type: t, Used: used}, nil
+}
+
+// Remove removes the limit of a directory before it is deleted. The
+// loopback image of the directory is unmounted and deleted.
+func (m *Manager) Remove(name string) error {
+	m.Lock()
+	defer m.Unlock()
+	t, id, err := m.typeOf(name)
+	if err != nil {
+		return err
+	}
+	switch t {
+	case TypeLoopback:
+		return removeImage(m.image(name), m.dir(name))
+	case TypeProject:
+		if err := setProjectLimit(m.device, id, 0); err != nil {
+			logrus.Warnf("Failed to clear the quota of project %d: %v", id, err)
+		}
+		projects, err := m.loadProjects()
+		if err != nil {
+			return err
+		}
+		delete(projects, name)
+		return m.saveProjects(projects)
+	}
+	return nil
+}
+
+// loadProjects reads the project ids of the directories, which are saved
+// as id:name lines.
+func (m *Manager) loadProjects() (map[string]uint32, error) {
+	projects := make(map[string]uint32)
+	f, err := os.Open(path.Join(m.base, projectsFile))
+	if os.IsNotExist(err) {
+		return projects, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(strings.TrimSpace(scanner.Text()), ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		id, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid project id in %s: %v", projectsFile, err)
+		}
+		projects[fields[1]] = uint32(id)
+	}
+	return projects, scanner.Err()
+}
+
+func (m *Manager) saveProjects(projects map[string]uint32) error {
+	names := make([]string, 0, len(projects))
+	for name := range projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%d:%s\n", projects[name], name)
+	}
+	p := path.Join(m.base, projectsFile)
+	if err := ioutil.WriteFile(p+".tmp", []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(p+".tmp", p)
+}
+
+// walkUsage returns the space allocated to the files of a directory.
+func walkUsage(dir string) (uint64, error) {
+	used := uint64(0)
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+			used += uint64(st.Blocks) * 512
+		}
+		return nil
+	})
+	return used, err
+}
@@ -0,0 +1,164 @@
+package quota
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructSizes(t *testing.T) {
+	assert.Equal(t, uintptr(28), unsafe.Sizeof(fsxattr{}))
+	assert.Equal(t, uintptr(72), unsafe.Sizeof(dqblk{}))
+	assert.Equal(t, 0x80000702, qcmd(qGetquota, prjQuota))
+}
+
+func TestParseMountInfo(t *testing.T) {
+	info := `22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw,prjquota
+45 22 0:40 / /var/lib/with\040space rw,relatime - xfs /dev/mapper/vg-data rw
+46 22 7:0 / /mnt/vol rw,relatime shared:2 master:1 - ext4 /dev/loop0 rw
+`
+	entries, err := parseMountInfo(strings.NewReader(info))
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, mountEntry{Device: "8:1", MountPoint: "/", FSType: "ext4", Source: "/dev/sda1"}, entries[0])
+	assert.Equal(t, "/var/lib/with space", entries[1].MountPoint)
+	assert.Equal(t, "xfs", entries[1].FSType)
+	assert.Equal(t, "/dev/loop0", entries[2].Source)
+
+	_, err = parseMountInfo(strings.NewReader("22 1 8:1 / /\n"))
+	assert.Error(t, err)
+}
+
+func TestProjects(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quota")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m := &Manager{base: dir}
+	projects, err := m.loadProjects()
+	require.NoError(t, err)
+	assert.Empty(t, projects)
+	projects["vol1"] = firstProjectID
+	projects["vol2"] = firstProjectID + 5
+	require.NoError(t, m.saveProjects(projects))
+	loaded, err := m.loadProjects()
+	require.NoError(t, err)
+	assert.Equal(t, projects, loaded)
+
+	typ, id, err := m.typeOf("vol2")
+	require.NoError(t, err)
+	assert.Equal(t, TypeProject, typ)
+	assert.Equal(t, uint32(firstProjectID+5), id)
+	typ, _, err = m.typeOf("vol3")
+	require.NoError(t, err)
+	assert.Equal(t, TypeNone, typ)
+}
+
+func TestUsageWithoutLimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quota")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m := &Manager{base: dir}
+	require.NoError(t, os.MkdirAll(path.Join(dir, "vol", "sub"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "vol", "sub", "data"), make([]byte, 64*1024), 0644))
+	u, err := m.Usage("vol")
+	require.NoError(t, err)
+	assert.Equal(t, TypeNone, u.Type)
+	assert.True(t, u.Used >= 64*1024)
+	assert.Equal(t, uint64(0), u.Limit)
+	assert.Equal(t, ErrNotLimited, m.Resize("vol", 1024*1024))
+	assert.NoError(t, m.Remove("vol"))
+}
+
+func TestShared(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quota")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Without project quotas, the directories of shared storage are not
+	// limited rather than backed by loopback images
+	m := &Manager{base: dir, shared: true}
+	assert.Equal(t, TypeNone, m.Type())
+	require.NoError(t, os.Mkdir(path.Join(dir, "vol"), 0755))
+	require.NoError(t, m.Create("vol", 16*1024*1024))
+	_, err = os.Stat(path.Join(dir, "vol"+imageSuffix))
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, m.Activate("vol"))
+	assert.NoError(t, m.Resize("vol", 32*1024*1024))
+	u, err := m.Usage("vol")
+	require.NoError(t, err)
+	assert.Equal(t, TypeNone, u.Type)
+	assert.Equal(t, uint64(0), u.Limit)
+
+	// Images are never mounted from shared storage
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "old"+imageSuffix), nil, 0644))
+	assert.Equal(t, ErrSharedImage, m.Activate("old"))
+	_, err = m.Usage("old")
+	assert.Equal(t, ErrSharedImage, err)
+	assert.NoError(t, m.Remove("vol"))
+}
+
+func TestLoopback(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("Loopback quotas need root")
+	}
+	if err := exec.Command("losetup", "-f").Run(); err != nil {
+		t.Skip("No loop device available")
+	}
+	dir, err := ioutil.TempDir("", "quota")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m := &Manager{base: dir}
+	require.Equal(t, TypeLoopback, m.Type())
+	require.NoError(t, os.Mkdir(path.Join(dir, "vol"), 0755))
+	require.NoError(t, m.Create("vol", 16*1024*1024))
+	defer m.Remove("vol")
+
+	u, err := m.Usage("vol")
+	require.NoError(t, err)
+	assert.Equal(t, TypeLoopback, u.Type)
+	assert.Equal(t, uint64(16*1024*1024), u.Limit)
+
+	// Writes beyond the limit fail
+	err = ioutil.WriteFile(path.Join(dir, "vol", "big"), make([]byte, 32*1024*1024), 0644)
+	assert.Error(t, err)
+	os.Remove(path.Join(dir, "vol", "big"))
+
+	assert.Equal(t, ErrShrink, m.Resize("vol", 8*1024*1024))
+	err = m.Resize("vol", 32*1024*1024)
+	if err != nil && strings.Contains(err.Error(), "Permission denied") {
+		// Containers may not be allowed to resize mounted file systems.
+		t.Logf("Skipping online resize: %v", err)
+		u, err = m.Usage("vol")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(16*1024*1024), u.Limit)
+	} else {
+		require.NoError(t, err)
+		u, err = m.Usage("vol")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(32*1024*1024), u.Limit)
+	}
+
+	// The image is mounted again after a restart
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "vol", "data"), []byte("data"), 0644))
+	require.NoError(t, exec.Command("umount", path.Join(dir, "vol")).Run())
+	_, err = os.Stat(path.Join(dir, "vol", "data"))
+	require.True(t, os.IsNotExist(err))
+	require.NoError(t, m.Activate("vol"))
+	b, err := ioutil.ReadFile(path.Join(dir, "vol", "data"))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(b))
+
+	require.NoError(t, m.Remove("vol"))
+	_, err = os.Stat(path.Join(dir, "vol"+imageSuffix))
+	assert.True(t, os.IsNotExist(err))
+}
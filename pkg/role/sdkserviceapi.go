@@ -46,7 +46,9 @@ var (
 			},
 		},
 
-		// system:view role can only run read-only commands
+		// system:view role can only run read-only commands. The APIs of
+		// the secrets service are named so that none of them match, since
+		// reading a secret returns its value.
 		"system.view": {
 			&api.SdkRule{
 				Services: []string{"*"},
@@ -57,6 +59,8 @@ var (
 					"status",
 					"validate",
 					"capacityusage",
+					"watch",
+					"diff*",
 				},
 			},
 			&api.SdkRule{
@@ -602,3 +602,63 @@ func TestSdkRoleVerifyRules(t *testing.T) {
 		}
 	}
 }
+
+func TestSdkRoleVerifySecrets(t *testing.T) {
+	kv, err := kvdb.New(mem.Name, "role", []string{}, nil, logrus.Panicf)
+	assert.NoError(t, err)
+
+	s, err := NewSdkRoleManager(kv)
+	assert.NoError(t, err)
+
+	apis := []string{
+		"Login",
+		"CheckLogin",
+		"Set",
+		"Get",
+		"Delete",
+		"SetDefaultSecretKey",
+		"GetDefaultSecretKey",
+		"RotateMasterKey",
+	}
+	for _, api := range apis {
+		fullmethod := "/openstorage.api.OpenStorageSecrets/" + api
+		assert.NoError(t, s.Verify(context.Background(), "system.admin", fullmethod), fullmethod)
+		assert.Error(t, s.Verify(context.Background(), "system.view", fullmethod), fullmethod)
+		assert.Error(t, s.Verify(context.Background(), "system.user", fullmethod), fullmethod)
+	}
+}
+
+func TestSdkRoleVerifyConfig(t *testing.T) {
+	kv, err := kvdb.New(mem.Name, "role", []string{}, nil, logrus.Panicf)
+	assert.NoError(t, err)
+
+	s, err := NewSdkRoleManager(kv)
+	assert.NoError(t, err)
+
+	apis := map[string]bool{
+		"InspectCluster": true,
+		"InspectNode":    true,
+		"EnumerateNodes": true,
+		"Watch":          true,
+		"UpdateCluster":  false,
+		"UpdateNode":     false,
+		"DeleteNode":     false,
+
+		"EnumerateClusterRevisions": true,
+		"EnumerateNodeRevisions":    true,
+		"DiffCluster":               true,
+		"DiffNode":                  true,
+		"RollbackCluster":           false,
+		"RollbackNode":              false,
+	}
+	for api, readOnly := range apis {
+		fullmethod := "/openstorage.api.OpenStorageConfig/" + api
+		assert.NoError(t, s.Verify(context.Background(), "system.admin", fullmethod), fullmethod)
+		if readOnly {
+			assert.NoError(t, s.Verify(context.Background(), "system.view", fullmethod), fullmethod)
+		} else {
+			assert.Error(t, s.Verify(context.Background(), "system.view", fullmethod), fullmethod)
+		}
+		assert.Error(t, s.Verify(context.Background(), "system.user", fullmethod), fullmethod)
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package sanity
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"sync"
@@ -24,7 +25,12 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
 	yaml "gopkg.in/yaml.v2"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/chaos"
+	"github.com/libopenstorage/openstorage/pkg/grpcserver"
 )
 
 var (
@@ -41,11 +47,39 @@ type CloudBackupConfig struct {
 	CloudProviders map[string]map[string]string
 }
 
+// ChaosConfig configures the fault injection while the tests run
+type ChaosConfig struct {
+	// SdkAddress is the SDK gRPC endpoint of the OSD server, which must
+	// have been started with chaos activated
+	SdkAddress string
+	// ScenarioPath is the file of the chaos scenario enabled in the OSD
+	// server during the tests
+	ScenarioPath string
+}
+
 // Test will test the CSI driver at the specified address
 func Test(t *testing.T, address, driver, cloudBackupConfigPath string) {
+	TestWithChaos(t, address, driver, cloudBackupConfigPath, nil)
+}
+
+// TestWithChaos will test the driver at the specified address while the
+// chaos scenario of chaosConfig is enabled in the OSD server
+func TestWithChaos(
+	t *testing.T,
+	address, driver, cloudBackupConfigPath string,
+	chaosConfig *ChaosConfig,
+) {
 	lock.Lock()
 	defer lock.Unlock()
 
+	if chaosConfig != nil && len(chaosConfig.ScenarioPath) != 0 {
+		revert, err := applyChaosScenario(chaosConfig)
+		if err != nil {
+			t.Fatalf("Unable to apply the chaos scenario: %v", err)
+		}
+		defer revert()
+	}
+
 	if len(cloudBackupConfigPath) != 0 {
 		cfg, err := CloudProviderConfigParse(cloudBackupConfigPath)
 		if err != nil {
@@ -75,3 +109,32 @@ func CloudProviderConfigParse(filePath string) (*CloudBackupConfig, error) {
 	return config, nil
 
 }
+
+// applyChaosScenario enables the chaos scenario in the OSD server and
+// returns the function disabling it
+func applyChaosScenario(chaosConfig *ChaosConfig) (func(), error) {
+	scenario, err := chaos.LoadScenario(chaosConfig.ScenarioPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(chaosConfig.SdkAddress) == 0 {
+		return nil, fmt.Errorf("The SDK endpoint of the OSD server is needed to apply a chaos scenario")
+	}
+	conn, err := grpcserver.Connect(
+		chaosConfig.SdkAddress,
+		[]grpc.DialOption{grpc.WithInsecure()})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to connect to the SDK: %v", err)
+	}
+	client := api.NewOpenStorageChaosClient(conn)
+	if err := scenario.ApplySdk(context.Background(), client); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return func() {
+		if err := scenario.RevertSdk(context.Background(), client); err != nil {
+			fmt.Printf("Unable to revert the chaos scenario %s: %v\n", scenario.Name, err)
+		}
+		conn.Close()
+	}, nil
+}
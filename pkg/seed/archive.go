@@ -0,0 +1,236 @@
+package seed
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// whiteoutPrefix marks a file deleted by a layer of an image.
+	whiteoutPrefix = ".wh."
+	// whiteoutOpaque marks a directory whose content in the lower layers
+	// of an image is hidden.
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// securePath returns the path of name inside dest. It fails if one of the
+// parents of the path is a symbolic link resolving outside of dest, or a
+// link which does not resolve, which a later MkdirAll would follow.
+func securePath(dest, name string) (string, error) {
+	rel := filepath.Clean("/" + name)
+	p := filepath.Join(dest, rel)
+	if p == dest {
+		return p, nil
+	}
+	root, err := filepath.EvalSymlinks(dest)
+	if err != nil {
+		return "", err
+	}
+	// The parents are walked one by one, since the links extracted earlier
+	// may point to each other.
+	dir := root
+	for _, part := range strings.Split(filepath.Dir(rel), string(filepath.Separator)) {
+		if len(part) == 0 {
+			continue
+		}
+		next := filepath.Join(dir, part)
+		fi, err := os.Lstat(next)
+		if os.IsNotExist(err) {
+			// The remaining parents are created by MkdirAll inside dir.
+			break
+		} else if err != nil {
+			return "", err
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if next, err = filepath.EvalSymlinks(next); err != nil || !insideDir(root, next) {
+				return "", fmt.Errorf("Path %q is outside of %s", name, dest)
+			}
+		}
+		dir = next
+	}
+	return p, nil
+}
+
+// insideDir returns true if p is dir or one of its descendants.
+func insideDir(dir, p string) bool {
+	return p == dir || strings.HasPrefix(p, dir+string(filepath.Separator))
+}
+
+// extractTar extracts a tar stream into dest, replacing the files that
+// exist. If whiteouts is true, the whiteout files of image layers delete
+// the files they mark instead of being extracted.
+func extractTar(r io.Reader, dest string, whiteouts bool) error {
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		p, err := securePath(dest, hdr.Name)
+		if err != nil {
+			return err
+		}
+		base := filepath.Base(p)
+		if whiteouts && strings.HasPrefix(base, whiteoutPrefix) {
+			if err := whiteout(p); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if fi, err := os.Lstat(p); err == nil && !fi.IsDir() {
+				os.Remove(p)
+			}
+			if err := os.MkdirAll(p, 0755); err != nil {
+				return err
+			}
+			if err := os.Chmod(p, os.FileMode(hdr.Mode).Perm()); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := writeFile(p, tr, os.FileMode(hdr.Mode).Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			os.RemoveAll(p)
+			if err := os.Symlink(hdr.Linkname, p); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			target, err := securePath(dest, hdr.Linkname)
+			if err != nil {
+				return err
+			}
+			os.RemoveAll(p)
+			if err := os.Link(target, p); err != nil {
+				return err
+			}
+		default:
+			// Devices and fifos are not seeded.
+			continue
+		}
+		if os.Geteuid() == 0 {
+			os.Lchown(p, hdr.Uid, hdr.Gid)
+		}
+		if hdr.Typeflag != tar.TypeSymlink {
+			os.Chtimes(p, hdr.ModTime, hdr.ModTime)
+		}
+	}
+}
+
+// whiteout applies the whiteout file p of an image layer.
+func whiteout(p string) error {
+	dir, base := filepath.Split(p)
+	if base == whiteoutOpaque {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for _, e := range entries {
+			if err := os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return os.RemoveAll(filepath.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)))
+}
+
+// extractZip extracts a zip file into dest, replacing the files that exist.
+func extractZip(file, dest string) error {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		p, err := securePath(dest, f.Name)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return err
+		}
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(p, 0755); err != nil {
+				return err
+			}
+			if err := os.Chmod(p, mode.Perm()); err != nil {
+				return err
+			}
+		case mode&os.ModeSymlink != 0:
+			target, err := readZipFile(f)
+			if err != nil {
+				return err
+			}
+			os.RemoveAll(p)
+			if err := os.Symlink(string(target), p); err != nil {
+				return err
+			}
+			continue
+		default:
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			err = writeFile(p, rc, mode.Perm())
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+		os.Chtimes(p, f.Modified, f.Modified)
+	}
+	return nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+// writeFile replaces the file p with the content of r.
+func writeFile(p string, r io.Reader, perm os.FileMode) error {
+	if fi, err := os.Lstat(p); err == nil && !fi.Mode().IsRegular() {
+		if err := os.RemoveAll(p); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(p, perm)
+}
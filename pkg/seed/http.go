@@ -0,0 +1,184 @@
+package seed
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	// HTTPChecksum is the option with the checksum of an archive, as
+	// sha256:<hex>, sha512:<hex> or a sha256 hex digest.
+	HTTPChecksum = "checksum"
+	// HTTPFormat is the option with the format of an archive, tar, tgz or
+	// zip. It defaults to the extension of the URL.
+	HTTPFormat = "format"
+
+	formatTar = "tar"
+	formatTgz = "tgz"
+	formatZip = "zip"
+)
+
+// HTTP is a tar, gzipped tar or zip archive downloaded over http or https.
+type HTTP struct {
+	url      string
+	format   string
+	checksum string
+	// loaded is the checksum of the archive already loaded into dest
+	loaded string
+	// version is the checksum of the archive last loaded
+	version string
+}
+
+// String representation of this source
+func (h *HTTP) String() string {
+	return h.url
+}
+
+// Load downloads the archive and extracts it into dest, unless its
+// checksum matches the archive already loaded.
+func (h *HTTP) Load(dest string) error {
+	if len(h.checksum) != 0 && h.checksum == h.loaded {
+		h.version = h.checksum
+		return nil
+	}
+	f, err := ioutil.TempFile("", "seed")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	version, err := h.download(f)
+	if err != nil {
+		return err
+	}
+	if len(h.checksum) != 0 && version != h.checksum {
+		return fmt.Errorf("%v: %s has %s, expected %s", ErrChecksum, h.url, version, h.checksum)
+	}
+	h.version = version
+	if version == h.loaded {
+		return nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	switch h.format {
+	case formatTar:
+		return extractTar(f, dest, false)
+	case formatTgz:
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		return extractTar(zr, dest, false)
+	default:
+		return extractZip(f.Name(), dest)
+	}
+}
+
+// download writes the archive to w and returns its checksum.
+func (h *HTTP) download(w io.Writer) (string, error) {
+	resp, err := http.Get(h.url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Failed to download %s: %s", h.url, resp.Status)
+	}
+	algo, hasher := "sha256", hash.Hash(sha256.New())
+	if strings.HasPrefix(h.checksum, "sha512:") {
+		algo, hasher = "sha512", sha512.New()
+	}
+	if _, err := io.Copy(io.MultiWriter(w, hasher), resp.Body); err != nil {
+		return "", fmt.Errorf("Failed to download %s: %v", h.url, err)
+	}
+	return algo + ":" + hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// MetadataRead returns the checksum of the archive already loaded.
+func (h *HTTP) MetadataRead(mdDir string) (string, error) {
+	loaded, err := readMetadata(mdDir, h.String())
+	if err != nil {
+		return "", err
+	}
+	h.loaded = loaded
+	return loaded, nil
+}
+
+// MetadataWrite records the checksum of the archive loaded.
+func (h *HTTP) MetadataWrite(mdDir string) error {
+	if len(h.version) == 0 {
+		return fmt.Errorf("%s was not loaded", h.url)
+	}
+	return writeMetadata(mdDir, h.String(), h.version)
+}
+
+// NewHTTPSource returns a source for the archive at uri.
+func NewHTTPSource(uri string, options map[string]string) (Source, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, ErrUnsupported
+	}
+	format := options[HTTPFormat]
+	if len(format) == 0 {
+		switch p := strings.ToLower(u.Path); {
+		case strings.HasSuffix(p, ".tar"):
+			format = formatTar
+		case strings.HasSuffix(p, ".tar.gz"), strings.HasSuffix(p, ".tgz"):
+			format = formatTgz
+		case strings.HasSuffix(p, ".zip"):
+			format = formatZip
+		}
+	}
+	switch format {
+	case formatTar, formatTgz, formatZip:
+	case "":
+		return nil, fmt.Errorf("Cannot tell the archive format of %s, set the %q option", uri, HTTPFormat)
+	default:
+		return nil, fmt.Errorf("Unsupported archive format %q", format)
+	}
+	checksum, err := parseChecksum(options[HTTPChecksum])
+	if err != nil {
+		return nil, err
+	}
+	return &HTTP{
+		url:      uri,
+		format:   format,
+		checksum: checksum,
+	}, nil
+}
+
+// parseChecksum returns the checksum as <algorithm>:<lowercase hex>.
+func parseChecksum(checksum string) (string, error) {
+	if len(checksum) == 0 {
+		return "", nil
+	}
+	algo, digest := "sha256", checksum
+	if i := strings.Index(checksum, ":"); i >= 0 {
+		algo, digest = checksum[:i], checksum[i+1:]
+	}
+	size := map[string]int{"sha256": sha256.Size, "sha512": sha512.Size}[algo]
+	if size == 0 {
+		return "", fmt.Errorf("Unsupported checksum algorithm %q", algo)
+	}
+	b, err := hex.DecodeString(digest)
+	if err != nil || len(b) != size {
+		return "", fmt.Errorf("Invalid %s checksum %q", algo, digest)
+	}
+	return algo + ":" + hex.EncodeToString(b), nil
+}
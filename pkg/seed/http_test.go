@@ -0,0 +1,228 @@
+package seed
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testFile struct {
+	name    string
+	content string
+	link    string
+}
+
+func tarball(t *testing.T, files []testFile) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.content))}
+		if len(f.link) != 0 {
+			hdr.Typeflag = tar.TypeSymlink
+			hdr.Linkname = f.link
+			hdr.Size = 0
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		if len(f.link) == 0 {
+			_, err := tw.Write([]byte(f.content))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func gzipped(t *testing.T, b []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func zipped(t *testing.T, files []testFile) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		hdr := &zip.FileHeader{Name: f.name, Method: zip.Deflate}
+		content := f.content
+		if len(f.link) != 0 {
+			hdr.SetMode(os.ModeSymlink | 0777)
+			content = f.link
+		}
+		w, err := zw.CreateHeader(hdr)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func sha256sum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func readFile(t *testing.T, p string) string {
+	b, err := ioutil.ReadFile(p)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestHTTPSource(t *testing.T) {
+	files := []testFile{{name: "dir/a", content: "a"}, {name: "b", content: "bb"}}
+	archives := map[string][]byte{
+		"/seed.tar":    tarball(t, files),
+		"/seed.tar.gz": gzipped(t, tarball(t, files)),
+		"/seed.zip":    zipped(t, files),
+	}
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, ok := archives[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		downloads++
+		w.Write(b)
+	}))
+	defer server.Close()
+
+	for p := range archives {
+		dest, err := ioutil.TempDir("", "seed-http")
+		require.NoError(t, err)
+		defer os.RemoveAll(dest)
+
+		s, err := New(server.URL+p, nil)
+		require.NoError(t, err)
+		loaded, err := s.MetadataRead(dest)
+		require.NoError(t, err)
+		assert.Empty(t, loaded)
+		require.NoError(t, s.Load(dest), p)
+		require.NoError(t, s.MetadataWrite(dest))
+		assert.Equal(t, "a", readFile(t, filepath.Join(dest, "dir", "a")))
+		assert.Equal(t, "bb", readFile(t, filepath.Join(dest, "b")))
+
+		loaded, err = s.MetadataRead(dest)
+		require.NoError(t, err)
+		assert.Equal(t, "sha256:"+sha256sum(archives[p]), loaded)
+	}
+
+	// A source whose checksum was loaded is not downloaded again.
+	dest, err := ioutil.TempDir("", "seed-http")
+	require.NoError(t, err)
+	defer os.RemoveAll(dest)
+	checksum := map[string]string{HTTPChecksum: sha256sum(archives["/seed.zip"])}
+	s, err := New(server.URL+"/seed.zip", checksum)
+	require.NoError(t, err)
+	require.NoError(t, s.Load(dest))
+	require.NoError(t, s.MetadataWrite(dest))
+	downloads = 0
+	s, err = New(server.URL+"/seed.zip", checksum)
+	require.NoError(t, err)
+	_, err = s.MetadataRead(dest)
+	require.NoError(t, err)
+	require.NoError(t, s.Load(dest))
+	assert.Equal(t, 0, downloads)
+
+	s, err = New(server.URL+"/seed.tar", map[string]string{HTTPChecksum: "sha256:" + sha256sum([]byte("x"))})
+	require.NoError(t, err)
+	assert.Error(t, s.Load(dest))
+
+	s, err = New(server.URL+"/missing.tar", nil)
+	require.NoError(t, err)
+	assert.Error(t, s.Load(dest))
+
+	_, err = New(server.URL+"/seed", nil)
+	assert.Error(t, err)
+	_, err = New(server.URL+"/seed", map[string]string{HTTPFormat: "tgz"})
+	assert.NoError(t, err)
+	_, err = New(server.URL+"/seed.tar", map[string]string{HTTPChecksum: "md5:abcd"})
+	assert.Error(t, err)
+}
+
+func TestExtractTarStaysInDest(t *testing.T) {
+	dest, err := ioutil.TempDir("", "seed-tar")
+	require.NoError(t, err)
+	defer os.RemoveAll(dest)
+	outside, err := ioutil.TempDir("", "seed-outside")
+	require.NoError(t, err)
+	defer os.RemoveAll(outside)
+
+	b := tarball(t, []testFile{{name: "../../escape", content: "x"}})
+	require.NoError(t, extractTar(bytes.NewReader(b), dest, false))
+	assert.Equal(t, "x", readFile(t, filepath.Join(dest, "escape")))
+
+	b = tarball(t, []testFile{
+		{name: "link", link: outside},
+		{name: "link/escape", content: "x"},
+	})
+	assert.Error(t, extractTar(bytes.NewReader(b), dest, false))
+	_, err = os.Stat(filepath.Join(outside, "escape"))
+	assert.True(t, os.IsNotExist(err))
+
+	// A link above a parent which does not exist yet
+	b = tarball(t, []testFile{
+		{name: "a", link: outside},
+		{name: "a/b/c", content: "x"},
+	})
+	assert.Error(t, extractTar(bytes.NewReader(b), dest, false))
+	_, err = os.Stat(filepath.Join(outside, "b"))
+	assert.True(t, os.IsNotExist(err))
+
+	// A link which does not resolve yet
+	missing := filepath.Join(outside, "missing")
+	b = tarball(t, []testFile{
+		{name: "dangling", link: missing},
+		{name: "dangling/c", content: "x"},
+	})
+	assert.Error(t, extractTar(bytes.NewReader(b), dest, false))
+	_, err = os.Stat(missing)
+	assert.True(t, os.IsNotExist(err))
+
+	// Links inside dest are followed
+	b = tarball(t, []testFile{
+		{name: "real/file", content: "x"},
+		{name: "inside", link: "real"},
+		{name: "inside/sub/file", content: "y"},
+	})
+	require.NoError(t, extractTar(bytes.NewReader(b), dest, false))
+	assert.Equal(t, "y", readFile(t, filepath.Join(dest, "real", "sub", "file")))
+}
+
+func TestExtractZipStaysInDest(t *testing.T) {
+	dest, err := ioutil.TempDir("", "seed-zip")
+	require.NoError(t, err)
+	defer os.RemoveAll(dest)
+	outside, err := ioutil.TempDir("", "seed-outside")
+	require.NoError(t, err)
+	defer os.RemoveAll(outside)
+
+	f, err := ioutil.TempFile("", "seed-zip")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.Write(zipped(t, []testFile{
+		{name: "a", link: outside},
+		{name: "a/b/c", content: "x"},
+	}))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	assert.Error(t, extractZip(f.Name(), dest))
+	_, err = os.Stat(filepath.Join(outside, "b"))
+	assert.True(t, os.IsNotExist(err))
+}
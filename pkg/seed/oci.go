@@ -0,0 +1,245 @@
+package seed
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	// OCILayout is the option with the directory of the OCI image layout
+	// the image is read from. Images are not pulled from registries.
+	OCILayout = "oci_layout"
+
+	ociRefName            = "org.opencontainers.image.ref.name"
+	ociIndexMediaType     = "application/vnd.oci.image.index.v1+json"
+	dockerListMediaType   = "application/vnd.docker.distribution.manifest.list.v2+json"
+	ociLayerMediaType     = "application/vnd.oci.image.layer.v1.tar"
+	ociLayerGzipMediaType = "application/vnd.oci.image.layer.v1.tar+gzip"
+	dockerLayerMediaType  = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+)
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+	} `json:"platform,omitempty"`
+}
+
+type ociIndex struct {
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+// OCI is a container image whose layers are applied in order.
+type OCI struct {
+	ref    string
+	name   string
+	tag    string
+	digest string
+	layout string
+	// loaded is the digest of the manifest already loaded into dest
+	loaded string
+	// version is the digest of the manifest last loaded
+	version string
+}
+
+// String representation of this source
+func (o *OCI) String() string {
+	return "oci://" + o.ref
+}
+
+// Load applies the layers of the image to dest, unless the image was
+// already loaded.
+func (o *OCI) Load(dest string) error {
+	manifest, err := o.resolve()
+	if err != nil {
+		return err
+	}
+	if manifest.Digest == o.loaded {
+		o.version = manifest.Digest
+		return nil
+	}
+	var m ociManifest
+	if err := o.readJSON(manifest, &m); err != nil {
+		return err
+	}
+	for _, layer := range m.Layers {
+		if err := o.applyLayer(layer, dest); err != nil {
+			return fmt.Errorf("Failed to apply layer %s of %s: %v", layer.Digest, o, err)
+		}
+	}
+	o.version = manifest.Digest
+	return nil
+}
+
+// resolve returns the descriptor of the image manifest of the reference.
+func (o *OCI) resolve() (*ociDescriptor, error) {
+	var index ociIndex
+	b, err := ioutil.ReadFile(filepath.Join(o.layout, "index.json"))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &index); err != nil {
+		return nil, fmt.Errorf("Invalid index of OCI layout %s: %v", o.layout, err)
+	}
+	for i := range index.Manifests {
+		d := &index.Manifests[i]
+		name := d.Annotations[ociRefName]
+		if (len(o.digest) != 0 && d.Digest == o.digest) ||
+			(len(o.digest) == 0 && (name == o.tag || name == o.name+":"+o.tag)) {
+			return o.platformManifest(d)
+		}
+	}
+	return nil, fmt.Errorf("Image %s not found in OCI layout %s", o.ref, o.layout)
+}
+
+// platformManifest returns the manifest of d for the platform of this
+// node if d is an image index.
+func (o *OCI) platformManifest(d *ociDescriptor) (*ociDescriptor, error) {
+	if d.MediaType != ociIndexMediaType && d.MediaType != dockerListMediaType {
+		return d, nil
+	}
+	var index ociIndex
+	if err := o.readJSON(d, &index); err != nil {
+		return nil, err
+	}
+	for i := range index.Manifests {
+		m := &index.Manifests[i]
+		if m.Platform == nil ||
+			(m.Platform.OS == runtime.GOOS && m.Platform.Architecture == runtime.GOARCH) {
+			return o.platformManifest(m)
+		}
+	}
+	return nil, fmt.Errorf("Image %s has no manifest for %s/%s", o.ref, runtime.GOOS, runtime.GOARCH)
+}
+
+// blob opens a blob of the layout and verifies its digest when it is read
+// to the end.
+func (o *OCI) blob(d *ociDescriptor) (io.ReadCloser, error) {
+	parts := strings.SplitN(d.Digest, ":", 2)
+	if len(parts) != 2 || parts[0] != "sha256" || strings.ContainsAny(parts[1], "/.") {
+		return nil, fmt.Errorf("Unsupported digest %q", d.Digest)
+	}
+	f, err := os.Open(filepath.Join(o.layout, "blobs", parts[0], parts[1]))
+	if err != nil {
+		return nil, err
+	}
+	return &verifiedReader{f: f, hasher: sha256.New(), digest: parts[1]}, nil
+}
+
+func (o *OCI) readJSON(d *ociDescriptor, v interface{}) error {
+	r, err := o.blob(d)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func (o *OCI) applyLayer(d ociDescriptor, dest string) error {
+	r, err := o.blob(&d)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	var layer io.Reader = r
+	switch d.MediaType {
+	case ociLayerMediaType:
+	case ociLayerGzipMediaType, dockerLayerMediaType:
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		layer = zr
+	default:
+		return fmt.Errorf("Unsupported layer media type %q", d.MediaType)
+	}
+	if err := extractTar(layer, dest, true); err != nil {
+		return err
+	}
+	// Read the padding after the tar stream so the digest is verified.
+	_, err = io.Copy(ioutil.Discard, r)
+	return err
+}
+
+// MetadataRead returns the digest of the manifest already loaded.
+func (o *OCI) MetadataRead(mdDir string) (string, error) {
+	loaded, err := readMetadata(mdDir, o.String())
+	if err != nil {
+		return "", err
+	}
+	o.loaded = loaded
+	return loaded, nil
+}
+
+// MetadataWrite records the digest of the manifest loaded.
+func (o *OCI) MetadataWrite(mdDir string) error {
+	if len(o.version) == 0 {
+		return fmt.Errorf("%s was not loaded", o)
+	}
+	return writeMetadata(mdDir, o.String(), o.version)
+}
+
+// NewOCISource returns a source for the image reference of uri, as
+// oci://<name>[:<tag>|@<digest>], read from the OCI layout in options.
+func NewOCISource(uri string, options map[string]string) (Source, error) {
+	if !strings.HasPrefix(uri, "oci://") {
+		return nil, ErrUnsupported
+	}
+	layout := options[OCILayout]
+	if len(layout) == 0 {
+		return nil, fmt.Errorf("Pulling images from registries is not supported, set the %q option", OCILayout)
+	}
+	ref := strings.TrimPrefix(uri, "oci://")
+	o := &OCI{ref: ref, name: ref, tag: "latest", layout: layout}
+	if i := strings.Index(ref, "@"); i >= 0 {
+		o.name, o.digest = ref[:i], ref[i+1:]
+	} else if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		o.name, o.tag = ref[:i], ref[i+1:]
+	}
+	if len(o.name) == 0 {
+		return nil, fmt.Errorf("Invalid image reference %q", ref)
+	}
+	return o, nil
+}
+
+type verifiedReader struct {
+	f      *os.File
+	hasher hash.Hash
+	digest string
+}
+
+func (v *verifiedReader) Read(p []byte) (int, error) {
+	n, err := v.f.Read(p)
+	v.hasher.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(v.hasher.Sum(nil)) != v.digest {
+		return n, fmt.Errorf("%v: blob %s", ErrChecksum, v.digest)
+	}
+	return n, err
+}
+
+func (v *verifiedReader) Close() error {
+	return v.f.Close()
+}
@@ -0,0 +1,98 @@
+package seed
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeBlob adds b to the OCI layout and returns its descriptor.
+func writeBlob(t *testing.T, layout, mediaType string, b []byte) ociDescriptor {
+	dir := filepath.Join(layout, "blobs", "sha256")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	digest := sha256sum(b)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, digest), b, 0644))
+	return ociDescriptor{MediaType: mediaType, Digest: "sha256:" + digest, Size: int64(len(b))}
+}
+
+func writeJSONBlob(t *testing.T, layout, mediaType string, v interface{}) ociDescriptor {
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return writeBlob(t, layout, mediaType, b)
+}
+
+func TestOCISource(t *testing.T) {
+	layout, err := ioutil.TempDir("", "seed-oci")
+	require.NoError(t, err)
+	defer os.RemoveAll(layout)
+
+	base := writeBlob(t, layout, ociLayerGzipMediaType, gzipped(t, tarball(t, []testFile{
+		{name: "etc/config", content: "base"},
+		{name: "etc/removed", content: "gone"},
+		{name: "var/cache/old", content: "old"},
+	})))
+	top := writeBlob(t, layout, ociLayerMediaType, tarball(t, []testFile{
+		{name: "etc/config", content: "top"},
+		{name: "etc/.wh.removed"},
+		{name: "var/cache/.wh..wh..opq"},
+		{name: "var/cache/new", content: "new"},
+	}))
+	manifest := writeJSONBlob(t, layout, "application/vnd.oci.image.manifest.v1+json",
+		&ociManifest{Layers: []ociDescriptor{base, top}})
+	manifest.Annotations = map[string]string{ociRefName: "v1"}
+	b, err := json.Marshal(&ociIndex{Manifests: []ociDescriptor{manifest}})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(layout, "index.json"), b, 0644))
+
+	dest, err := ioutil.TempDir("", "seed-oci-dest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dest)
+
+	_, err = New("oci://example.com/app:v1", nil)
+	assert.Error(t, err, "registries are not supported")
+
+	s, err := New("oci://example.com/app:v1", map[string]string{OCILayout: layout})
+	require.NoError(t, err)
+	_, err = s.MetadataRead(dest)
+	require.NoError(t, err)
+	require.NoError(t, s.Load(dest))
+	require.NoError(t, s.MetadataWrite(dest))
+	assert.Equal(t, "top", readFile(t, filepath.Join(dest, "etc", "config")))
+	assert.Equal(t, "new", readFile(t, filepath.Join(dest, "var", "cache", "new")))
+	for _, p := range []string{"etc/removed", "var/cache/old", "etc/.wh.removed"} {
+		_, err := os.Stat(filepath.Join(dest, p))
+		assert.True(t, os.IsNotExist(err), p)
+	}
+
+	// The same image is not applied again.
+	require.NoError(t, os.Remove(filepath.Join(dest, "etc", "config")))
+	s, err = New("oci://example.com/app@"+manifest.Digest, map[string]string{OCILayout: layout})
+	require.NoError(t, err)
+	loaded, err := s.MetadataRead(dest)
+	require.NoError(t, err)
+	assert.Empty(t, loaded, "another reference was loaded")
+	s, err = New("oci://example.com/app:v1", map[string]string{OCILayout: layout})
+	require.NoError(t, err)
+	loaded, err = s.MetadataRead(dest)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Digest, loaded)
+	require.NoError(t, s.Load(dest))
+	_, err = os.Stat(filepath.Join(dest, "etc", "config"))
+	assert.True(t, os.IsNotExist(err))
+
+	s, err = New("oci://example.com/app:v2", map[string]string{OCILayout: layout})
+	require.NoError(t, err)
+	assert.Error(t, s.Load(dest))
+
+	// Corrupted blobs are detected.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(layout, "blobs", "sha256", top.Digest[7:]),
+		tarball(t, []testFile{{name: "evil", content: "x"}}), 0644))
+	s, err = New("oci://example.com/app:v1", map[string]string{OCILayout: layout})
+	require.NoError(t, err)
+	assert.Error(t, s.Load(dest))
+}
@@ -1,11 +1,18 @@
 package seed
 
 import (
+	"encoding/json"
 	"errors"
+	"io/ioutil"
 	"net/url"
+	"os"
+	"path/filepath"
 )
 
 // Source defines the interface for keep track of volume driver mounts.
+// To seed a volume idempotently, MetadataRead is called before Load so
+// that a source already loaded into dest is not loaded again, and
+// MetadataWrite is called after Load to record what was loaded.
 type Source interface {
 	// String representation of this source
 	String() string
@@ -17,11 +24,63 @@ type Source interface {
 	MetadataWrite(mdDir string) error
 }
 
+const (
+	// metadataFile is the file in the metadata directory recording the
+	// last source loaded.
+	metadataFile = ".seed"
+)
+
 var (
 	// ErrUnsupported is returned for an unsupported seed source.
 	ErrUnsupported = errors.New("Not supported")
+	// ErrChecksum is returned when the content of a source does not match
+	// its checksum.
+	ErrChecksum = errors.New("Checksum mismatch")
 )
 
+// metadata records the version of the content loaded from a source.
+type metadata struct {
+	Source  string `json:"source"`
+	Version string `json:"version"`
+}
+
+// readMetadata returns the version of source loaded according to the
+// metadata in mdDir, or an empty string if another source or none was
+// loaded.
+func readMetadata(mdDir, source string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(mdDir, metadataFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var md metadata
+	if err := json.Unmarshal(b, &md); err != nil {
+		return "", err
+	}
+	if md.Source != source {
+		return "", nil
+	}
+	return md.Version, nil
+}
+
+// writeMetadata records in mdDir the version of source that was loaded.
+func writeMetadata(mdDir, source, version string) error {
+	b, err := json.Marshal(&metadata{Source: source, Version: version})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(mdDir, 0755); err != nil {
+		return err
+	}
+	tmp := filepath.Join(mdDir, metadataFile+".tmp")
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(mdDir, metadataFile))
+}
+
 // New returns a new instance of Source
 func New(uri string, options map[string]string) (Source, error) {
 	u, err := url.Parse(uri)
@@ -31,6 +90,12 @@ func New(uri string, options map[string]string) (Source, error) {
 	switch u.Scheme {
 	case "github":
 		return NewGitSource(uri, options)
+	case "http", "https":
+		return NewHTTPSource(uri, options)
+	case "oci":
+		return NewOCISource(uri, options)
+	case "volume":
+		return NewVolumeSource(uri, options)
 	}
 	return nil, ErrUnsupported
 }
@@ -0,0 +1,97 @@
+package seed
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/libopenstorage/openstorage/pkg/clone"
+)
+
+// VolumeResolver returns the directory with the files of a snapshot of a
+// volume.
+type VolumeResolver func(volumeID, snapshotID string) (string, error)
+
+var (
+	resolverLock sync.Mutex
+	resolver     VolumeResolver
+)
+
+// SetVolumeResolver sets how the volume sources find their snapshot. It
+// is set by the volume driver that seeds volumes from other volumes.
+func SetVolumeResolver(r VolumeResolver) {
+	resolverLock.Lock()
+	defer resolverLock.Unlock()
+	resolver = r
+}
+
+func getVolumeResolver() VolumeResolver {
+	resolverLock.Lock()
+	defer resolverLock.Unlock()
+	return resolver
+}
+
+// Volume is a snapshot of a volume copied into dest.
+type Volume struct {
+	volumeID   string
+	snapshotID string
+	// loaded is true if the snapshot was already loaded into dest
+	loaded bool
+}
+
+// String representation of this source
+func (v *Volume) String() string {
+	return "volume://" + v.volumeID + "@" + v.snapshotID
+}
+
+// Load copies the files of the snapshot into dest, unless they were
+// already copied. Snapshots do not change, so they are only copied once.
+func (v *Volume) Load(dest string) error {
+	if v.loaded {
+		return nil
+	}
+	r := getVolumeResolver()
+	if r == nil {
+		return fmt.Errorf("Cannot seed from %s: %v", v, ErrUnsupported)
+	}
+	dir, err := r(v.volumeID, v.snapshotID)
+	if err != nil {
+		return err
+	}
+	if _, err := clone.Tree(dir, dest, clone.Copy, nil); err != nil {
+		return err
+	}
+	v.loaded = true
+	return nil
+}
+
+// MetadataRead returns the snapshot already loaded.
+func (v *Volume) MetadataRead(mdDir string) (string, error) {
+	loaded, err := readMetadata(mdDir, v.String())
+	if err != nil {
+		return "", err
+	}
+	v.loaded = loaded == v.snapshotID
+	return loaded, nil
+}
+
+// MetadataWrite records the snapshot loaded.
+func (v *Volume) MetadataWrite(mdDir string) error {
+	if !v.loaded {
+		return fmt.Errorf("%s was not loaded", v)
+	}
+	return writeMetadata(mdDir, v.String(), v.snapshotID)
+}
+
+// NewVolumeSource returns a source for the snapshot of uri, as
+// volume://<volume id>@<snapshot id>.
+func NewVolumeSource(uri string, options map[string]string) (Source, error) {
+	if !strings.HasPrefix(uri, "volume://") {
+		return nil, ErrUnsupported
+	}
+	parts := strings.Split(strings.TrimPrefix(uri, "volume://"), "@")
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return nil, fmt.Errorf("Invalid volume source %q, expected volume://<volume id>@<snapshot id>", uri)
+	}
+	return &Volume{volumeID: parts[0], snapshotID: parts[1]}, nil
+}
@@ -0,0 +1,58 @@
+package seed
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVolumeSource(t *testing.T) {
+	snap, err := ioutil.TempDir("", "seed-snap")
+	require.NoError(t, err)
+	defer os.RemoveAll(snap)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(snap, "data"), []byte("snap"), 0644))
+	dest, err := ioutil.TempDir("", "seed-volume")
+	require.NoError(t, err)
+	defer os.RemoveAll(dest)
+
+	_, err = New("volume://vol", nil)
+	assert.Error(t, err)
+
+	s, err := New("volume://vol@snap", nil)
+	require.NoError(t, err)
+	SetVolumeResolver(nil)
+	assert.Error(t, s.Load(dest))
+
+	resolved := 0
+	SetVolumeResolver(func(volumeID, snapID string) (string, error) {
+		resolved++
+		if volumeID != "vol" || snapID != "snap" {
+			return "", fmt.Errorf("no snapshot %s of %s", snapID, volumeID)
+		}
+		return snap, nil
+	})
+	defer SetVolumeResolver(nil)
+
+	_, err = s.MetadataRead(dest)
+	require.NoError(t, err)
+	require.NoError(t, s.Load(dest))
+	require.NoError(t, s.MetadataWrite(dest))
+	assert.Equal(t, "snap", readFile(t, filepath.Join(dest, "data")))
+
+	s, err = New("volume://vol@snap", nil)
+	require.NoError(t, err)
+	loaded, err := s.MetadataRead(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "snap", loaded)
+	require.NoError(t, s.Load(dest))
+	assert.Equal(t, 1, resolved)
+
+	s, err = New("volume://vol@other", nil)
+	require.NoError(t, err)
+	assert.Error(t, s.Load(dest))
+}
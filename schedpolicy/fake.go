@@ -28,6 +28,7 @@ const (
 )
 
 type fakeSchedMgr struct {
+	NullSchedMgr
 	kv kvdb.Kvdb
 }
 
@@ -59,6 +60,10 @@ func (s *fakeSchedMgr) SchedPolicyDelete(name string) error {
 	return nil
 }
 
+func (s *fakeSchedMgr) SchedPolicyForceDelete(name string) error {
+	return s.SchedPolicyDelete(name)
+}
+
 func (s *fakeSchedMgr) SchedPolicyEnumerate() ([]*SchedPolicy, error) {
 	kvp, err := s.kv.Enumerate(fakeSchedPrefix)
 	if err != nil {
@@ -0,0 +1,281 @@
+/*
+Package schedpolicy provides the schedule policy managers.
+Copyright 2026 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package schedpolicy
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/libopenstorage/openstorage/pkg/sched"
+	"github.com/portworx/kvdb"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	kvdbPoliciesPrefix   = "schedpolicy/policies/"
+	kvdbReferencesPrefix = "schedpolicy/references/"
+	kvdbUsersPrefix      = "schedpolicy/users/"
+	// kvdbLockKey serializes the deletion of the policies with the
+	// tracking of their users across the cluster
+	kvdbLockKey = "schedpolicy/lock"
+)
+
+// policyNameRegex matches the names which can be used in the policy tags
+// of a schedule. Names made of dots only, such as "..", are not valid kvdb
+// key components.
+var policyNameRegex = regexp.MustCompile("^[A-Za-z0-9_.-]*[A-Za-z0-9_-][A-Za-z0-9_.-]*$")
+
+// KvdbSchedMgr is an implementation of SchedulePolicyProvider storing the
+// policies in kvdb. It also keeps track of the volumes and cloud backup
+// schedules using each policy.
+//
+// A policy is stored under schedpolicy/policies/<name>, and each of its
+// users under schedpolicy/references/<name>/<type>/<id>. The names of the
+// policies used by an object are kept under schedpolicy/users/<type>/<id>
+// so that they can be replaced.
+//
+// Deleting a policy and tracking a user of it are done under a kvdb lock,
+// so that a policy cannot be deleted while an object starts using it.
+type KvdbSchedMgr struct {
+	kv kvdb.Kvdb
+}
+
+// Interface check
+var _ SchedulePolicyProvider = &KvdbSchedMgr{}
+
+// NewKvdbSchedulePolicy returns a schedule policy manager storing the
+// policies in kv.
+func NewKvdbSchedulePolicy(kv kvdb.Kvdb) (*KvdbSchedMgr, error) {
+	if kv == nil {
+		return nil, fmt.Errorf("Kvdb is not initialized")
+	}
+	return &KvdbSchedMgr{kv: kv}, nil
+}
+
+func policyKey(name string) string {
+	return kvdbPoliciesPrefix + name
+}
+
+func referencesKey(name string) string {
+	return kvdbReferencesPrefix + name
+}
+
+func referenceKey(name string, ref Reference) string {
+	return path.Join(referencesKey(name), string(ref.Type), ref.ID)
+}
+
+func usersKey(ref Reference) string {
+	return path.Join(kvdbUsersPrefix, string(ref.Type), ref.ID)
+}
+
+// validate checks that name can be used in policy tags and that schedule
+// has at least one valid interval.
+func validate(name, schedule string) error {
+	if !policyNameRegex.MatchString(name) {
+		return &InvalidPolicyError{
+			Name:   name,
+			Reason: "name must only have letters, digits, '_', '.' and '-', and not only '.'",
+		}
+	}
+	intervals, err := sched.ParseSchedule(schedule)
+	if err != nil {
+		return &InvalidPolicyError{Name: name, Reason: err.Error()}
+	}
+	if len(intervals) == 0 {
+		return &InvalidPolicyError{Name: name, Reason: "schedule has no intervals"}
+	}
+	return nil
+}
+
+// SchedPolicyCreate creates a policy with given name and schedule.
+func (s *KvdbSchedMgr) SchedPolicyCreate(name, schedule string) error {
+	if err := validate(name, schedule); err != nil {
+		return err
+	}
+	_, err := s.kv.Create(policyKey(name), schedule, 0)
+	return err
+}
+
+// SchedPolicyUpdate updates a policy with given name and schedule.
+func (s *KvdbSchedMgr) SchedPolicyUpdate(name, schedule string) error {
+	if err := validate(name, schedule); err != nil {
+		return err
+	}
+	_, err := s.kv.Update(policyKey(name), schedule, 0)
+	return err
+}
+
+// SchedPolicyDelete deletes a policy with given name. It fails with a
+// PolicyInUseError if any volume or cloud backup schedule uses the policy.
+func (s *KvdbSchedMgr) SchedPolicyDelete(name string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	refs, err := s.SchedPolicyReferences(name)
+	if err != nil {
+		return err
+	}
+	if len(refs) != 0 {
+		return &PolicyInUseError{Name: name, References: refs}
+	}
+	_, err = s.kv.Delete(policyKey(name))
+	return err
+}
+
+// SchedPolicyForceDelete deletes a policy with given name even if it is in
+// use. The objects using it keep their policy tags but are not tracked as
+// users of the policy anymore.
+func (s *KvdbSchedMgr) SchedPolicyForceDelete(name string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := s.kv.Delete(policyKey(name)); err != nil {
+		return err
+	}
+	if err := s.kv.DeleteTree(referencesKey(name)); err != nil {
+		logrus.Warnf("Failed to delete the references to schedule policy %s: %v",
+			name, err)
+	}
+	return nil
+}
+
+// SchedPolicyEnumerate enumerates all configured policies.
+func (s *KvdbSchedMgr) SchedPolicyEnumerate() ([]*SchedPolicy, error) {
+	kvp, err := s.kv.Enumerate(kvdbPoliciesPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*SchedPolicy, len(kvp))
+	for i, kv := range kvp {
+		list[i] = &SchedPolicy{
+			Name:     path.Base(kv.Key),
+			Schedule: string(kv.Value),
+		}
+	}
+	return list, nil
+}
+
+// SchedPolicyGet returns schedule policy matching given name.
+func (s *KvdbSchedMgr) SchedPolicyGet(name string) (*SchedPolicy, error) {
+	kvp, err := s.kv.Get(policyKey(name))
+	if err != nil {
+		return nil, err
+	}
+	return &SchedPolicy{
+		Name:     name,
+		Schedule: string(kvp.Value),
+	}, nil
+}
+
+// lock takes the kvdb lock of the policies and returns the function
+// releasing it
+func (s *KvdbSchedMgr) lock() (func(), error) {
+	kvp, err := s.kv.Lock(kvdbLockKey)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to lock the schedule policies: %v", err)
+	}
+	return func() {
+		if err := s.kv.Unlock(kvp); err != nil {
+			logrus.Warnf("Failed to unlock the schedule policies: %v", err)
+		}
+	}, nil
+}
+
+// SchedPolicyTrack records ref as using the policies with given names,
+// replacing the policies recorded before. No names forget about ref. It
+// fails with a PolicyNotFoundError if any of the policies does not exist.
+func (s *KvdbSchedMgr) SchedPolicyTrack(ref Reference, names []string) error {
+	if len(ref.Type) == 0 || len(ref.ID) == 0 {
+		return fmt.Errorf("Reference must have a type and an id")
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, name := range names {
+		if _, err := s.kv.Get(policyKey(name)); err == kvdb.ErrNotFound {
+			return &PolicyNotFoundError{Name: name}
+		} else if err != nil {
+			return err
+		}
+	}
+
+	var previous []string
+	if _, err := s.kv.GetVal(usersKey(ref), &previous); err != nil &&
+		err != kvdb.ErrNotFound {
+		return err
+	}
+
+	used := make(map[string]bool, len(names))
+	for _, name := range names {
+		used[name] = true
+		if _, err := s.kv.Put(referenceKey(name, ref), &ref, 0); err != nil {
+			return err
+		}
+	}
+	for _, name := range previous {
+		if used[name] {
+			continue
+		}
+		_, err := s.kv.Delete(referenceKey(name, ref))
+		if err != nil && err != kvdb.ErrNotFound {
+			return err
+		}
+	}
+
+	if len(names) == 0 {
+		_, err := s.kv.Delete(usersKey(ref))
+		if err == kvdb.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	_, err = s.kv.Put(usersKey(ref), names, 0)
+	return err
+}
+
+// SchedPolicyReferences returns the objects using policy with given name.
+func (s *KvdbSchedMgr) SchedPolicyReferences(name string) ([]Reference, error) {
+	kvp, err := s.kv.Enumerate(referencesKey(name))
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]Reference, 0, len(kvp))
+	for _, kv := range kvp {
+		// Skip the policies whose name is a prefix of this one
+		if !strings.HasPrefix(strings.TrimPrefix(kv.Key, "/"), referencesKey(name)+"/") {
+			continue
+		}
+		refs = append(refs, Reference{
+			Type: ReferenceType(path.Base(path.Dir(kv.Key))),
+			ID:   path.Base(kv.Key),
+		})
+	}
+	return refs, nil
+}
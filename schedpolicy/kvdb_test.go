@@ -0,0 +1,179 @@
+/*
+Package schedpolicy provides the schedule policy managers.
+Copyright 2026 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedpolicy
+
+import (
+	"testing"
+
+	"github.com/portworx/kvdb"
+	"github.com/portworx/kvdb/mem"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testDaily  = "daily=10:00,4"
+	testHourly = "periodic=60,5"
+)
+
+func newTestKvdbSchedMgr(t *testing.T) *KvdbSchedMgr {
+	kv, err := kvdb.New(mem.Name, "test_sched", []string{}, nil, logrus.Panicf)
+	assert.NoError(t, err)
+	s, err := NewKvdbSchedulePolicy(kv)
+	assert.NoError(t, err)
+	return s
+}
+
+func TestKvdbSchedPolicy(t *testing.T) {
+	s := newTestKvdbSchedMgr(t)
+
+	err := s.SchedPolicyCreate("daily", testDaily)
+	assert.NoError(t, err)
+	err = s.SchedPolicyCreate("daily", testDaily)
+	assert.Equal(t, kvdb.ErrExist, err)
+	err = s.SchedPolicyCreate("hourly", testHourly)
+	assert.NoError(t, err)
+
+	// Update
+	err = s.SchedPolicyUpdate("hourly", testDaily)
+	assert.NoError(t, err)
+	err = s.SchedPolicyUpdate("missing", testDaily)
+	assert.Equal(t, kvdb.ErrNotFound, err)
+
+	// Enumerate
+	list, err := s.SchedPolicyEnumerate()
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Contains(t, list, &SchedPolicy{Name: "daily", Schedule: testDaily})
+	assert.Contains(t, list, &SchedPolicy{Name: "hourly", Schedule: testDaily})
+
+	// Inspect
+	policy, err := s.SchedPolicyGet("daily")
+	assert.NoError(t, err)
+	assert.Equal(t, &SchedPolicy{Name: "daily", Schedule: testDaily}, policy)
+	_, err = s.SchedPolicyGet("missing")
+	assert.Equal(t, kvdb.ErrNotFound, err)
+
+	// Delete
+	err = s.SchedPolicyDelete("daily")
+	assert.NoError(t, err)
+	err = s.SchedPolicyDelete("daily")
+	assert.Equal(t, kvdb.ErrNotFound, err)
+	list, err = s.SchedPolicyEnumerate()
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestKvdbSchedPolicyValidation(t *testing.T) {
+	s := newTestKvdbSchedMgr(t)
+
+	for _, name := range []string{"", ".", "..", "a/b", "a,b", "a=b"} {
+		err := s.SchedPolicyCreate(name, testDaily)
+		assert.IsType(t, &InvalidPolicyError{}, err, name)
+	}
+	for _, schedule := range []string{"", "world", "daily=25:00"} {
+		err := s.SchedPolicyCreate("name", schedule)
+		assert.IsType(t, &InvalidPolicyError{}, err, schedule)
+	}
+
+	err := s.SchedPolicyCreate("name", testDaily)
+	assert.NoError(t, err)
+	assert.NoError(t, s.SchedPolicyCreate(".name", testDaily))
+	err = s.SchedPolicyUpdate("name", "world")
+	assert.IsType(t, &InvalidPolicyError{}, err)
+	policy, err := s.SchedPolicyGet("name")
+	assert.NoError(t, err)
+	assert.Equal(t, testDaily, policy.Schedule)
+}
+
+func TestKvdbSchedPolicyReferences(t *testing.T) {
+	s := newTestKvdbSchedMgr(t)
+	assert.NoError(t, s.SchedPolicyCreate("daily", testDaily))
+	assert.NoError(t, s.SchedPolicyCreate("daily2", testDaily))
+	assert.NoError(t, s.SchedPolicyCreate("hourly", testHourly))
+
+	vol := Reference{Type: ReferenceVolume, ID: "vol1"}
+	backup := Reference{Type: ReferenceCloudBackupSchedule, ID: "sched1"}
+	err := s.SchedPolicyTrack(vol, []string{"daily", "hourly"})
+	assert.NoError(t, err)
+	err = s.SchedPolicyTrack(backup, []string{"daily"})
+	assert.NoError(t, err)
+
+	refs, err := s.SchedPolicyReferences("daily")
+	assert.NoError(t, err)
+	assert.Len(t, refs, 2)
+	assert.Contains(t, refs, vol)
+	assert.Contains(t, refs, backup)
+	refs, err = s.SchedPolicyReferences("daily2")
+	assert.NoError(t, err)
+	assert.Empty(t, refs)
+
+	// Policies in use cannot be deleted
+	err = s.SchedPolicyDelete("hourly")
+	assert.Equal(t, &PolicyInUseError{Name: "hourly", References: []Reference{vol}}, err)
+	assert.Contains(t, err.Error(), "volume vol1")
+	assert.NoError(t, s.SchedPolicyDelete("daily2"))
+
+	// Replace the policies of the volume
+	err = s.SchedPolicyTrack(vol, []string{"daily"})
+	assert.NoError(t, err)
+	refs, err = s.SchedPolicyReferences("hourly")
+	assert.NoError(t, err)
+	assert.Empty(t, refs)
+	assert.NoError(t, s.SchedPolicyDelete("hourly"))
+
+	// Forget the volume
+	err = s.SchedPolicyTrack(vol, nil)
+	assert.NoError(t, err)
+	err = s.SchedPolicyTrack(vol, nil)
+	assert.NoError(t, err)
+	refs, err = s.SchedPolicyReferences("daily")
+	assert.NoError(t, err)
+	assert.Equal(t, []Reference{backup}, refs)
+
+	// Forced delete
+	err = s.SchedPolicyDelete("daily")
+	assert.IsType(t, &PolicyInUseError{}, err)
+	err = s.SchedPolicyForceDelete("daily")
+	assert.NoError(t, err)
+	_, err = s.SchedPolicyGet("daily")
+	assert.Equal(t, kvdb.ErrNotFound, err)
+	refs, err = s.SchedPolicyReferences("daily")
+	assert.NoError(t, err)
+	assert.Empty(t, refs)
+
+	err = s.SchedPolicyTrack(Reference{Type: ReferenceVolume}, nil)
+	assert.Error(t, err)
+}
+
+func TestKvdbSchedPolicyTrackMissing(t *testing.T) {
+	s := newTestKvdbSchedMgr(t)
+	assert.NoError(t, s.SchedPolicyCreate("daily", testDaily))
+
+	// Objects cannot start using a deleted policy
+	vol := Reference{Type: ReferenceVolume, ID: "vol1"}
+	assert.NoError(t, s.SchedPolicyDelete("daily"))
+	err := s.SchedPolicyTrack(vol, []string{"daily"})
+	assert.Equal(t, &PolicyNotFoundError{Name: "daily"}, err)
+	refs, err := s.SchedPolicyReferences("daily")
+	assert.NoError(t, err)
+	assert.Empty(t, refs)
+
+	// Forgetting about an object does not need its policies
+	assert.NoError(t, s.SchedPolicyTrack(vol, nil))
+}
@@ -1,11 +1,70 @@
 package schedpolicy
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
 	ErrNotImplemented = errors.New("Not Implemented")
 )
 
+// ReferenceType is the kind of object using a schedule policy.
+type ReferenceType string
+
+const (
+	// ReferenceVolume is a volume whose snapshot schedule has policy tags
+	ReferenceVolume ReferenceType = "volume"
+	// ReferenceCloudBackupSchedule is a schedule of backups to a cloud provider
+	ReferenceCloudBackupSchedule ReferenceType = "cloudbackup_schedule"
+)
+
+// Reference identifies an object using a schedule policy.
+type Reference struct {
+	Type ReferenceType
+	ID   string
+}
+
+func (r Reference) String() string {
+	return fmt.Sprintf("%s %s", r.Type, r.ID)
+}
+
+// InvalidPolicyError is returned when a policy has an invalid name or schedule.
+type InvalidPolicyError struct {
+	Name   string
+	Reason string
+}
+
+func (e *InvalidPolicyError) Error() string {
+	return fmt.Sprintf("Invalid schedule policy %s: %s", e.Name, e.Reason)
+}
+
+// PolicyNotFoundError is returned when tracking an object using a policy
+// which does not exist.
+type PolicyNotFoundError struct {
+	Name string
+}
+
+func (e *PolicyNotFoundError) Error() string {
+	return fmt.Sprintf("Schedule policy %s not found", e.Name)
+}
+
+// PolicyInUseError is returned when deleting a policy still in use.
+type PolicyInUseError struct {
+	Name       string
+	References []Reference
+}
+
+func (e *PolicyInUseError) Error() string {
+	refs := make([]string, len(e.References))
+	for i, ref := range e.References {
+		refs[i] = ref.String()
+	}
+	return fmt.Sprintf("Schedule policy %s is used by %s",
+		e.Name, strings.Join(refs, ", "))
+}
+
 type SchedulePolicyProvider interface {
 	// SchedPolicyCreate creates a policy with given name and schedule.
 	SchedPolicyCreate(name, sched string) error
@@ -17,6 +76,18 @@ type SchedulePolicyProvider interface {
 	SchedPolicyEnumerate() ([]*SchedPolicy, error)
 	// SchedPolicyGet returns schedule policy matching given name.
 	SchedPolicyGet(name string) (*SchedPolicy, error)
+	// SchedPolicyForceDelete deletes a policy with given name even if it is in use.
+	SchedPolicyForceDelete(name string) error
+	SchedulePolicyTracker
+}
+
+// SchedulePolicyTracker keeps track of the objects using the policies.
+type SchedulePolicyTracker interface {
+	// SchedPolicyTrack records ref as using the policies with given names,
+	// replacing the policies recorded before. No names forget about ref.
+	SchedPolicyTrack(ref Reference, names []string) error
+	// SchedPolicyReferences returns the objects using policy with given name.
+	SchedPolicyReferences(name string) ([]Reference, error)
 }
 
 func NewDefaultSchedulePolicy() SchedulePolicyProvider {
@@ -45,3 +116,15 @@ func (sp *NullSchedMgr) SchedPolicyEnumerate() ([]*SchedPolicy, error) {
 func (sp *NullSchedMgr) SchedPolicyGet(name string) (*SchedPolicy, error) {
 	return nil, ErrNotImplemented
 }
+
+func (sp *NullSchedMgr) SchedPolicyForceDelete(name string) error {
+	return ErrNotImplemented
+}
+
+func (sp *NullSchedMgr) SchedPolicyTrack(ref Reference, names []string) error {
+	return ErrNotImplemented
+}
+
+func (sp *NullSchedMgr) SchedPolicyReferences(name string) ([]Reference, error) {
+	return nil, ErrNotImplemented
+}
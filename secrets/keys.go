@@ -0,0 +1,270 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pborman/uuid"
+	"github.com/portworx/kvdb"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// masterKeySize is the size of the master keys, for AES-256
+	masterKeySize = 32
+
+	kvdbMasterKeysPrefix = "/secrets/masterkeys/"
+	kvdbCurrentKey       = "/secrets/masterkey"
+
+	fileKeySuffix    = ".key"
+	fileCurrentKeyID = "current"
+
+	// EnvMasterKeyDir is the environment variable with the directory of the
+	// master keys, see NewFileKeyProvider
+	EnvMasterKeyDir = "OPENSTORAGE_SECRETS_MASTER_KEY_DIR"
+	// EnvMasterKey is the environment variable with a base64 encoded 32 byte
+	// master key, see NewEnvKeyProvider
+	EnvMasterKey = "OPENSTORAGE_SECRETS_MASTER_KEY"
+)
+
+var (
+	// ErrMasterKeyNotFound is returned when a secret was wrapped with a
+	// master key the key provider does not have
+	ErrMasterKeyNotFound = errors.New("Master key not found")
+	// ErrNoKeyProvider is returned when no master key source is configured
+	ErrNoKeyProvider = fmt.Errorf("No master key source is configured, set %s or %s",
+		EnvMasterKeyDir, EnvMasterKey)
+	// ErrMasterKeyNotShared is returned when rotating master keys which are
+	// not shared across the nodes, which would leave the other nodes unable
+	// to unwrap the secrets
+	ErrMasterKeyNotShared = errors.New("The master keys are not shared across the nodes, " +
+		"install the new key on every node instead")
+)
+
+// KeyProvider provides the master keys the keys of the secrets are
+// wrapped with.
+type KeyProvider interface {
+	// CurrentKey returns the id and the value of the master key new
+	// secrets are wrapped with. The providers sharing their keys across
+	// the nodes create it if there is none.
+	CurrentKey() (string, []byte, error)
+	// Key returns the value of the master key with id.
+	Key(id string) ([]byte, error)
+	// Rotate creates a new master key and makes it the current key. The
+	// previous keys are kept to unwrap the secrets wrapped with them. The
+	// providers whose keys are not shared across the nodes return
+	// ErrMasterKeyNotShared.
+	Rotate() (string, error)
+}
+
+func newMasterKey() ([]byte, error) {
+	key := make([]byte, masterKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("Failed to generate a master key: %v", err)
+	}
+	return key, nil
+}
+
+func decodeMasterKey(id string, encoded []byte) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil || len(key) != masterKeySize {
+		return nil, fmt.Errorf("Master key %s is not a base64 encoded %d byte key", id, masterKeySize)
+	}
+	return key, nil
+}
+
+// NewKeyProviderFromEnv returns the key provider configured with the
+// EnvMasterKeyDir or EnvMasterKey environment variables, or
+// ErrNoKeyProvider if neither is set.
+func NewKeyProviderFromEnv() (KeyProvider, error) {
+	if dir := os.Getenv(EnvMasterKeyDir); len(dir) != 0 {
+		return NewFileKeyProvider(dir), nil
+	}
+	if key := os.Getenv(EnvMasterKey); len(key) != 0 {
+		return NewEnvKeyProvider(key)
+	}
+	return nil, ErrNoKeyProvider
+}
+
+// kvdbKeyProvider keeps the master keys in kvdb, so they are available on
+// every node of the cluster.
+//
+// WARNING: the master keys are stored next to the secrets they protect, so
+// anyone who can read kvdb can decrypt every secret. This only obfuscates
+// the secrets and is meant for tests and development clusters.
+type kvdbKeyProvider struct {
+	kv kvdb.Kvdb
+}
+
+// NewKvdbKeyProvider returns a key provider keeping the master keys in kvdb.
+// It does not protect the secrets from the readers of kvdb.
+func NewKvdbKeyProvider(kv kvdb.Kvdb) KeyProvider {
+	logrus.Warnf("The master keys of the secrets are stored in kvdb, " +
+		"the secrets are only obfuscated from the readers of kvdb")
+	return &kvdbKeyProvider{kv: kv}
+}
+
+func (p *kvdbKeyProvider) CurrentKey() (string, []byte, error) {
+	kvp, err := p.kv.Get(kvdbCurrentKey)
+	if err == kvdb.ErrNotFound {
+		id, err := p.create(false)
+		if err != nil {
+			return "", nil, err
+		}
+		key, err := p.Key(id)
+		return id, key, err
+	} else if err != nil {
+		return "", nil, err
+	}
+	id := string(kvp.Value)
+	key, err := p.Key(id)
+	return id, key, err
+}
+
+func (p *kvdbKeyProvider) Key(id string) ([]byte, error) {
+	kvp, err := p.kv.Get(kvdbMasterKeysPrefix + id)
+	if err == kvdb.ErrNotFound {
+		return nil, ErrMasterKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return decodeMasterKey(id, kvp.Value)
+}
+
+func (p *kvdbKeyProvider) Rotate() (string, error) {
+	return p.create(true)
+}
+
+// create saves a new master key and makes it the current key. If replace
+// is false and another node created a current key first, its key is kept.
+func (p *kvdbKeyProvider) create(replace bool) (string, error) {
+	key, err := newMasterKey()
+	if err != nil {
+		return "", err
+	}
+	id := uuid.New()
+	encoded := base64.StdEncoding.EncodeToString(key)
+	if _, err := p.kv.Create(kvdbMasterKeysPrefix+id, []byte(encoded), 0); err != nil {
+		return "", err
+	}
+	if replace {
+		_, err = p.kv.Put(kvdbCurrentKey, []byte(id), 0)
+		return id, err
+	}
+	if _, err := p.kv.Create(kvdbCurrentKey, []byte(id), 0); err == kvdb.ErrExist {
+		p.kv.Delete(kvdbMasterKeysPrefix + id)
+		kvp, err := p.kv.Get(kvdbCurrentKey)
+		if err != nil {
+			return "", err
+		}
+		return string(kvp.Value), nil
+	} else if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// fileKeyProvider keeps the master keys in files of a directory, for
+// installs where the keys must not be stored with the secrets. Each key is
+// a base64 encoded 32 byte key in <id>.key, and the file named current
+// has the id of the current key. The directory must have the same keys on
+// every node, so the keys are installed by the administrator and never
+// created or rotated by the provider: a key created on one node would wrap
+// secrets the other nodes cannot read. To rotate the master key, install a
+// new key and point the current file to it on every node. New secrets are
+// wrapped with it, and the secrets wrapped with the previous keys are
+// unwrapped as long as their key files are kept.
+type fileKeyProvider struct {
+	lock sync.Mutex
+	dir  string
+}
+
+// NewFileKeyProvider returns a key provider reading the master keys from
+// the files of dir.
+func NewFileKeyProvider(dir string) KeyProvider {
+	return &fileKeyProvider{dir: dir}
+}
+
+func (p *fileKeyProvider) CurrentKey() (string, []byte, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	b, err := ioutil.ReadFile(filepath.Join(p.dir, fileCurrentKeyID))
+	if os.IsNotExist(err) {
+		return "", nil, fmt.Errorf("No current master key in %s, install a base64 encoded %d byte "+
+			"key in <id>%s and its id in %s on every node", p.dir, masterKeySize, fileKeySuffix, fileCurrentKeyID)
+	} else if err != nil {
+		return "", nil, err
+	}
+	id := strings.TrimSpace(string(b))
+	key, err := p.key(id)
+	return id, key, err
+}
+
+func (p *fileKeyProvider) Key(id string) ([]byte, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.key(id)
+}
+
+func (p *fileKeyProvider) key(id string) ([]byte, error) {
+	if len(id) == 0 || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return nil, fmt.Errorf("Invalid master key id %q", id)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(p.dir, id+fileKeySuffix))
+	if os.IsNotExist(err) {
+		return nil, ErrMasterKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return decodeMasterKey(id, b)
+}
+
+func (p *fileKeyProvider) Rotate() (string, error) {
+	return "", ErrMasterKeyNotShared
+}
+
+// envKeyProvider has a single master key, given in an environment variable.
+// Its key cannot be rotated in place: secrets wrapped with it must be
+// rotated to a provider with more keys before the variable is changed.
+type envKeyProvider struct {
+	id  string
+	key []byte
+}
+
+// NewEnvKeyProvider returns a key provider with the base64 encoded 32 byte
+// master key encoded. The id of the key is derived from its value.
+func NewEnvKeyProvider(encoded string) (KeyProvider, error) {
+	key, err := decodeMasterKey(EnvMasterKey, []byte(encoded))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(key)
+	return &envKeyProvider{
+		id:  "env-" + hex.EncodeToString(sum[:8]),
+		key: key,
+	}, nil
+}
+
+func (p *envKeyProvider) CurrentKey() (string, []byte, error) {
+	return p.id, p.key, nil
+}
+
+func (p *envKeyProvider) Key(id string) ([]byte, error) {
+	if id != p.id {
+		return nil, ErrMasterKeyNotFound
+	}
+	return p.key, nil
+}
+
+func (p *envKeyProvider) Rotate() (string, error) {
+	return "", ErrNotImplemented
+}
@@ -0,0 +1,343 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/portworx/kvdb"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	kvdbSecretsPrefix    = "/secrets/values/"
+	kvdbDefaultSecretKey = "/secrets/defaultsecretkey"
+
+	// rewrapRetries is how many times the rewrapping of a secret modified
+	// concurrently is retried
+	rewrapRetries = 3
+)
+
+var (
+	// ErrDecrypt is returned when a secret cannot be decrypted
+	ErrDecrypt = errors.New("Failed to decrypt secret")
+)
+
+// kvdbSecret is a secret stored in kvdb. The value is encrypted with a
+// data key of its own, which is wrapped with a master key.
+type kvdbSecret struct {
+	// MasterKeyID is the id of the master key wrapping the data key
+	MasterKeyID string `json:"master_key_id"`
+	// WrappedKey is the data key encrypted with the master key
+	WrappedKey []byte `json:"wrapped_key"`
+	// Ciphertext is the JSON encoded value encrypted with the data key
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// KvdbSecrets is an implementation of Secrets storing the secrets in kvdb,
+// envelope encrypted with the master keys of a KeyProvider.
+type KvdbSecrets struct {
+	kv   kvdb.Kvdb
+	keys KeyProvider
+}
+
+// Interface check
+var _ Secrets = &KvdbSecrets{}
+
+// NewKvdbSecrets returns a secrets implementation storing the secrets in kv
+// and wrapping their keys with the master keys of keys. Without keys the
+// master keys are stored in kv too, which only obfuscates the secrets.
+func NewKvdbSecrets(kv kvdb.Kvdb, keys KeyProvider) (*KvdbSecrets, error) {
+	if kv == nil {
+		return nil, fmt.Errorf("Kvdb is not initialized")
+	}
+	if keys == nil {
+		keys = NewKvdbKeyProvider(kv)
+	}
+	// Create the master key now so that a broken key provider is reported
+	// at startup.
+	if _, _, err := keys.CurrentKey(); err != nil {
+		return nil, err
+	}
+	return &KvdbSecrets{kv: kv, keys: keys}, nil
+}
+
+// seal encrypts plaintext with AES-256-GCM, authenticating aad, and
+// prepends the nonce.
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// open decrypts the output of seal.
+func open(key, ciphertext, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// encrypt returns the record of value, bound to the kvdb key it is
+// stored at.
+func (s *KvdbSecrets) encrypt(kvKey string, value interface{}) (*kvdbSecret, error) {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := newMasterKey()
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := seal(dataKey, plaintext, []byte(kvKey))
+	if err != nil {
+		return nil, err
+	}
+	id, masterKey, err := s.keys.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := seal(masterKey, dataKey, []byte(kvKey))
+	if err != nil {
+		return nil, err
+	}
+	return &kvdbSecret{
+		MasterKeyID: id,
+		WrappedKey:  wrapped,
+		Ciphertext:  ciphertext,
+	}, nil
+}
+
+// unwrap returns the data key of a secret
+func (s *KvdbSecrets) unwrap(kvKey string, secret *kvdbSecret) ([]byte, error) {
+	masterKey, err := s.keys.Key(secret.MasterKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot get master key %s: %v", secret.MasterKeyID, err)
+	}
+	return open(masterKey, secret.WrappedKey, []byte(kvKey))
+}
+
+func (s *KvdbSecrets) decrypt(kvKey string, secret *kvdbSecret) (interface{}, error) {
+	dataKey, err := s.unwrap(kvKey, secret)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(dataKey, secret.Ciphertext, []byte(kvKey))
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(plaintext, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (s *KvdbSecrets) get(kvKey string) (interface{}, error) {
+	var secret kvdbSecret
+	if _, err := s.kv.GetVal(kvKey, &secret); err == kvdb.ErrNotFound {
+		return nil, ErrInvalidSecretId
+	} else if err != nil {
+		return nil, err
+	}
+	return s.decrypt(kvKey, &secret)
+}
+
+func secretKvKey(secretID string) string {
+	return kvdbSecretsPrefix + url.PathEscape(secretID)
+}
+
+func emptySecret(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return len(v) == 0
+	case []byte:
+		return len(v) == 0
+	}
+	return false
+}
+
+// SecretLogin succeeds for the kvdb secret store, which needs no session
+func (s *KvdbSecrets) SecretLogin(secretType string, secretConfig map[string]string) error {
+	if secretType != TypeKvdb {
+		return ErrNotImplemented
+	}
+	return nil
+}
+
+// SecretCheckLogin always succeeds, the kvdb secret store needs no session
+func (s *KvdbSecrets) SecretCheckLogin() error {
+	return nil
+}
+
+// SecretSetDefaultSecretKey sets the cluster wide secret key
+func (s *KvdbSecrets) SecretSetDefaultSecretKey(secretKey string, override bool) error {
+	if len(secretKey) == 0 {
+		return ErrEmptySecretData
+	}
+	secret, err := s.encrypt(kvdbDefaultSecretKey, secretKey)
+	if err != nil {
+		return err
+	}
+	if override {
+		_, err = s.kv.Put(kvdbDefaultSecretKey, secret, 0)
+		return err
+	}
+	if _, err := s.kv.Create(kvdbDefaultSecretKey, secret, 0); err == kvdb.ErrExist {
+		return ErrSecretExists
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// SecretGetDefaultSecretKey returns the cluster wide secret key
+func (s *KvdbSecrets) SecretGetDefaultSecretKey() (interface{}, error) {
+	return s.get(kvdbDefaultSecretKey)
+}
+
+// SecretSet encrypts and stores the value of a secret
+func (s *KvdbSecrets) SecretSet(secretID string, value interface{}) error {
+	if len(secretID) == 0 {
+		return ErrKeyEmpty
+	}
+	if emptySecret(value) {
+		return ErrEmptySecretData
+	}
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+	kvKey := secretKvKey(secretID)
+	secret, err := s.encrypt(kvKey, value)
+	if err != nil {
+		return err
+	}
+	_, err = s.kv.Put(kvKey, secret, 0)
+	return err
+}
+
+// SecretGet returns the decrypted value of a secret
+func (s *KvdbSecrets) SecretGet(secretID string) (interface{}, error) {
+	if len(secretID) == 0 {
+		return nil, ErrKeyEmpty
+	}
+	return s.get(secretKvKey(secretID))
+}
+
+// SecretDelete removes a secret
+func (s *KvdbSecrets) SecretDelete(secretID string) error {
+	if len(secretID) == 0 {
+		return ErrKeyEmpty
+	}
+	if _, err := s.kv.Delete(secretKvKey(secretID)); err == kvdb.ErrNotFound {
+		return ErrInvalidSecretId
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// SecretRotateMasterKey creates a new master key and wraps the data keys of
+// all the secrets with it. The values of the secrets are not re-encrypted.
+// It returns the id of the new master key.
+func (s *KvdbSecrets) SecretRotateMasterKey() (string, error) {
+	id, err := s.keys.Rotate()
+	if err != nil {
+		return "", err
+	}
+	masterKey, err := s.keys.Key(id)
+	if err != nil {
+		return "", err
+	}
+	kvps, err := s.kv.Enumerate(kvdbSecretsPrefix)
+	if err != nil {
+		return "", err
+	}
+	if kvp, err := s.kv.Get(kvdbDefaultSecretKey); err == nil {
+		kvps = append(kvps, kvp)
+	} else if err != kvdb.ErrNotFound {
+		return "", err
+	}
+	rewrapped := 0
+	for _, kvp := range kvps {
+		done, err := s.rewrap(kvp, id, masterKey)
+		if err != nil {
+			return id, fmt.Errorf("Failed to rewrap secret %s: %v", kvp.Key, err)
+		}
+		if done {
+			rewrapped++
+		}
+	}
+	logrus.Infof("Rotated the master key of the secrets to %s, rewrapped %d secrets", id, rewrapped)
+	return id, nil
+}
+
+// rewrap wraps the data key of the secret at kvp with the master key id.
+// It returns false if the secret was already wrapped with it or deleted.
+func (s *KvdbSecrets) rewrap(kvp *kvdb.KVPair, id string, masterKey []byte) (bool, error) {
+	kvKey := kvp.Key
+	if len(kvKey) == 0 || kvKey[0] != '/' {
+		kvKey = "/" + kvKey
+	}
+	for i := 0; ; i++ {
+		var secret kvdbSecret
+		if err := json.Unmarshal(kvp.Value, &secret); err != nil {
+			return false, err
+		}
+		if secret.MasterKeyID == id {
+			return false, nil
+		}
+		dataKey, err := s.unwrap(kvKey, &secret)
+		if err != nil {
+			return false, err
+		}
+		if secret.WrappedKey, err = seal(masterKey, dataKey, []byte(kvKey)); err != nil {
+			return false, err
+		}
+		secret.MasterKeyID = id
+		if kvp.Value, err = json.Marshal(&secret); err != nil {
+			return false, err
+		}
+		_, err = s.kv.CompareAndSet(kvp, kvdb.KVModifiedIndex, nil)
+		if err == nil {
+			return true, nil
+		}
+		if err != kvdb.ErrModified || i == rewrapRetries {
+			return false, err
+		}
+		// The secret was set concurrently, rewrap its new value.
+		if kvp, err = s.kv.Get(kvKey); err == kvdb.ErrNotFound {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+	}
+}
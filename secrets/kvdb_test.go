@@ -0,0 +1,234 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pborman/uuid"
+	"github.com/portworx/kvdb"
+	"github.com/portworx/kvdb/mem"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKvdb(t *testing.T) kvdb.Kvdb {
+	kv, err := kvdb.New(mem.Name, "secrets_test", []string{}, nil, logrus.Panicf)
+	require.NoError(t, err)
+	return kv
+}
+
+// installFileKey installs a new master key in dir and makes it the current
+// key, as the administrator does for NewFileKeyProvider.
+func installFileKey(t *testing.T, dir string) string {
+	key, err := newMasterKey()
+	require.NoError(t, err)
+	id := uuid.New()
+	encoded := base64.StdEncoding.EncodeToString(key) + "\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, id+fileKeySuffix), []byte(encoded), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, fileCurrentKeyID), []byte(id+"\n"), 0600))
+	return id
+}
+
+func TestKvdbSecretsSetGetDelete(t *testing.T) {
+	kv := newTestKvdb(t)
+	s, err := NewKvdbSecrets(kv, nil)
+	require.NoError(t, err)
+
+	assert.NoError(t, s.SecretLogin(TypeKvdb, nil))
+	assert.Equal(t, ErrNotImplemented, s.SecretLogin(TypeVault, nil))
+	assert.NoError(t, s.SecretCheckLogin())
+
+	assert.Equal(t, ErrKeyEmpty, s.SecretSet("", "value"))
+	assert.Equal(t, ErrEmptySecretData, s.SecretSet("mysecret", ""))
+	assert.Equal(t, ErrEmptySecretData, s.SecretSet("mysecret", nil))
+
+	require.NoError(t, s.SecretSet("mysecret", "topsecretvalue"))
+	value, err := s.SecretGet("mysecret")
+	assert.NoError(t, err)
+	assert.Equal(t, "topsecretvalue", value)
+
+	require.NoError(t, s.SecretSet("a/b", map[string]interface{}{"key": "value"}))
+	value, err = s.SecretGet("a/b")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"key": "value"}, value)
+
+	// Values are not stored in the clear
+	kvps, err := kv.Enumerate(kvdbSecretsPrefix)
+	require.NoError(t, err)
+	assert.NotEmpty(t, kvps)
+	for _, kvp := range kvps {
+		assert.False(t, strings.Contains(string(kvp.Value), "topsecretvalue"))
+	}
+
+	assert.NoError(t, s.SecretDelete("mysecret"))
+	_, err = s.SecretGet("mysecret")
+	assert.Equal(t, ErrInvalidSecretId, err)
+	assert.Equal(t, ErrInvalidSecretId, s.SecretDelete("mysecret"))
+}
+
+func TestKvdbSecretsDefaultSecretKey(t *testing.T) {
+	s, err := NewKvdbSecrets(newTestKvdb(t), nil)
+	require.NoError(t, err)
+
+	_, err = s.SecretGetDefaultSecretKey()
+	assert.Equal(t, ErrInvalidSecretId, err)
+
+	assert.NoError(t, s.SecretSetDefaultSecretKey("clusterkey", false))
+	assert.Equal(t, ErrSecretExists, s.SecretSetDefaultSecretKey("otherkey", false))
+	key, err := s.SecretGetDefaultSecretKey()
+	assert.NoError(t, err)
+	assert.Equal(t, "clusterkey", key)
+
+	assert.NoError(t, s.SecretSetDefaultSecretKey("otherkey", true))
+	key, err = s.SecretGetDefaultSecretKey()
+	assert.NoError(t, err)
+	assert.Equal(t, "otherkey", key)
+}
+
+func TestKvdbSecretsRotateMasterKey(t *testing.T) {
+	kv := newTestKvdb(t)
+	keys := NewKvdbKeyProvider(kv)
+	s, err := NewKvdbSecrets(kv, keys)
+	require.NoError(t, err)
+	oldID, _, err := keys.CurrentKey()
+	require.NoError(t, err)
+
+	require.NoError(t, s.SecretSet("one", "value1"))
+	require.NoError(t, s.SecretSet("two", "value2"))
+	require.NoError(t, s.SecretSetDefaultSecretKey("clusterkey", false))
+
+	id, err := s.SecretRotateMasterKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, oldID, id)
+	currentID, _, err := keys.CurrentKey()
+	assert.NoError(t, err)
+	assert.Equal(t, id, currentID)
+
+	for _, kvKey := range []string{secretKvKey("one"), secretKvKey("two"), kvdbDefaultSecretKey} {
+		var secret kvdbSecret
+		_, err := kv.GetVal(kvKey, &secret)
+		require.NoError(t, err)
+		assert.Equal(t, id, secret.MasterKeyID)
+	}
+
+	// The secrets are readable without the old master key
+	_, err = kv.Delete(kvdbMasterKeysPrefix + oldID)
+	require.NoError(t, err)
+	value, err := s.SecretGet("one")
+	assert.NoError(t, err)
+	assert.Equal(t, "value1", value)
+	value, err = s.SecretGet("two")
+	assert.NoError(t, err)
+	assert.Equal(t, "value2", value)
+	key, err := s.SecretGetDefaultSecretKey()
+	assert.NoError(t, err)
+	assert.Equal(t, "clusterkey", key)
+}
+
+func TestKvdbSecretsFileKeyProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	kv := newTestKvdb(t)
+	// The provider does not create the master keys, so a node without its
+	// keys fails at startup
+	_, err = NewKvdbSecrets(kv, NewFileKeyProvider(dir))
+	assert.Error(t, err)
+	entries, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	oldID := installFileKey(t, dir)
+	s, err := NewKvdbSecrets(kv, NewFileKeyProvider(dir))
+	require.NoError(t, err)
+	require.NoError(t, s.SecretSet("mysecret", "topsecretvalue"))
+
+	// No master key is kept in kvdb
+	kvps, err := kv.Enumerate(kvdbMasterKeysPrefix)
+	assert.NoError(t, err)
+	assert.Empty(t, kvps)
+
+	// Another node with the same key directory reads the secret
+	other, err := NewKvdbSecrets(kv, NewFileKeyProvider(dir))
+	require.NoError(t, err)
+	value, err := other.SecretGet("mysecret")
+	assert.NoError(t, err)
+	assert.Equal(t, "topsecretvalue", value)
+
+	// The keys of a node are not shared, so they are not rotated in place
+	_, err = s.SecretRotateMasterKey()
+	assert.Equal(t, ErrMasterKeyNotShared, err)
+	current, err := ioutil.ReadFile(filepath.Join(dir, fileCurrentKeyID))
+	require.NoError(t, err)
+	assert.Equal(t, oldID, strings.TrimSpace(string(current)))
+
+	// The secrets wrapped with the previous keys are read after a new key
+	// is installed
+	installFileKey(t, dir)
+	require.NoError(t, s.SecretSet("newsecret", "newvalue"))
+	for id, expected := range map[string]string{"mysecret": "topsecretvalue", "newsecret": "newvalue"} {
+		value, err = other.SecretGet(id)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, value)
+	}
+}
+
+func TestKvdbSecretsMissingMasterKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	installFileKey(t, dir)
+	kv := newTestKvdb(t)
+	s, err := NewKvdbSecrets(kv, NewFileKeyProvider(dir))
+	require.NoError(t, err)
+	require.NoError(t, s.SecretSet("mysecret", "topsecretvalue"))
+
+	// A node with other master keys cannot read the secret
+	otherDir, err := ioutil.TempDir("", "secrets_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(otherDir)
+	installFileKey(t, otherDir)
+	other, err := NewKvdbSecrets(kv, NewFileKeyProvider(otherDir))
+	require.NoError(t, err)
+	_, err = other.SecretGet("mysecret")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMasterKeyNotFound.Error())
+}
+
+func TestKvdbSecretsEnvKeyProvider(t *testing.T) {
+	os.Unsetenv(EnvMasterKeyDir)
+	os.Unsetenv(EnvMasterKey)
+	_, err := NewKeyProviderFromEnv()
+	assert.Equal(t, ErrNoKeyProvider, err)
+
+	os.Setenv(EnvMasterKey, base64.StdEncoding.EncodeToString([]byte("tooshort")))
+	defer os.Unsetenv(EnvMasterKey)
+	_, err = NewKeyProviderFromEnv()
+	assert.Error(t, err)
+
+	os.Setenv(EnvMasterKey, base64.StdEncoding.EncodeToString(make([]byte, masterKeySize)))
+	keys, err := NewKeyProviderFromEnv()
+	require.NoError(t, err)
+	kv := newTestKvdb(t)
+	s, err := NewKvdbSecrets(kv, keys)
+	require.NoError(t, err)
+	require.NoError(t, s.SecretSet("mysecret", "topsecretvalue"))
+	value, err := s.SecretGet("mysecret")
+	assert.NoError(t, err)
+	assert.Equal(t, "topsecretvalue", value)
+
+	// No master key is kept in kvdb
+	kvps, err := kv.Enumerate(kvdbMasterKeysPrefix)
+	assert.NoError(t, err)
+	assert.Empty(t, kvps)
+
+	_, err = keys.Rotate()
+	assert.Equal(t, ErrNotImplemented, err)
+}
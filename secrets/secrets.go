@@ -17,6 +17,15 @@ var (
 	ErrEmptySecretData = errors.New("Secret data cannot be empty")
 	// ErrSecretExists returned when a secret for the given secret id already exists
 	ErrSecretExists = errors.New("Secret ID already exists")
+	// ErrSecretStoreConfigured returned when logging in to a secret store other
+	// than the one already configured
+	ErrSecretStoreConfigured = errors.New("A different secret store is already configured")
+	// ErrInvalidSecretPath returned when a secret id has empty, . or .. path elements
+	ErrInvalidSecretPath = errors.New("Secret ID cannot have empty, . or .. path elements")
+	// ErrNodeLocalLogin returned when logging in through the cluster to a secret
+	// store whose session would only be kept on one node
+	ErrNodeLocalLogin = errors.New("Logging in would only configure the secret store of this node, " +
+		"configure it on every node at startup instead")
 )
 
 type Secrets interface {
@@ -32,6 +41,11 @@ type Secrets interface {
 	SecretSet(key string, value interface{}) error
 	// SecretGet retrieves the value/data for given key
 	SecretGet(key string) (interface{}, error)
+	// SecretDelete removes the value/data for given key
+	SecretDelete(key string) error
+	// SecretRotateMasterKey creates a new master key, wraps the keys of the
+	// secrets with it and returns its id
+	SecretRotateMasterKey() (string, error)
 }
 
 type NullSecrets struct {
@@ -42,6 +56,16 @@ func NewDefaultSecrets() Secrets {
 	return &NullSecrets{}
 }
 
+// New returns the secrets of an external secret store logged in with
+// secretConfig
+func New(secretType string, secretConfig map[string]string) (Secrets, error) {
+	switch secretType {
+	case TypeVault:
+		return NewVaultSecrets(secretConfig)
+	}
+	return nil, ErrNotImplemented
+}
+
 func (f *NullSecrets) SecretLogin(secretType string, secretConfig map[string]string) error {
 	return ErrNotImplemented
 }
@@ -65,3 +89,11 @@ func (f *NullSecrets) SecretSet(secretKey string, secretValue interface{}) error
 func (f *NullSecrets) SecretGet(secretKey string) (interface{}, error) {
 	return nil, ErrNotImplemented
 }
+
+func (f *NullSecrets) SecretDelete(secretKey string) error {
+	return ErrNotImplemented
+}
+
+func (f *NullSecrets) SecretRotateMasterKey() (string, error) {
+	return "", ErrNotImplemented
+}
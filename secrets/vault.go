@@ -0,0 +1,642 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Configuration keys of the vault secrets, named like the environment
+// variables of the vault CLI.
+const (
+	// VaultAddress is the address of the vault server
+	VaultAddress = "VAULT_ADDR"
+	// VaultToken is the token to authenticate with
+	VaultToken = "VAULT_TOKEN"
+	// VaultNamespace is the vault enterprise namespace of the secrets
+	VaultNamespace = "VAULT_NAMESPACE"
+	// VaultRoleID is the role id to authenticate with AppRole
+	VaultRoleID = "VAULT_ROLE_ID"
+	// VaultSecretID is the secret id to authenticate with AppRole
+	VaultSecretID = "VAULT_SECRET_ID"
+	// VaultAppRolePath is the mount path of the AppRole auth method
+	VaultAppRolePath = "VAULT_APPROLE_PATH"
+	// VaultBackendPath is the mount path of the KV secrets engine
+	VaultBackendPath = "VAULT_BACKEND_PATH"
+	// VaultBasePath is the path under the KV mount the secrets are kept
+	// at, so that clusters sharing a vault keep their secrets apart
+	VaultBasePath = "VAULT_BASE_PATH"
+	// VaultKVVersion is the version of the KV secrets engine, 1 or 2. It
+	// is read from vault if not set.
+	VaultKVVersion = "VAULT_KV_VERSION"
+	// VaultCACert is the file of the CA certificate of the vault server
+	VaultCACert = "VAULT_CACERT"
+	// VaultCAPath is a directory of CA certificates of the vault server
+	VaultCAPath = "VAULT_CAPATH"
+	// VaultClientCert is the file of the client certificate
+	VaultClientCert = "VAULT_CLIENT_CERT"
+	// VaultClientKey is the file of the key of the client certificate
+	VaultClientKey = "VAULT_CLIENT_KEY"
+	// VaultSkipVerify disables the verification of the server certificate
+	VaultSkipVerify = "VAULT_SKIP_VERIFY"
+	// VaultTLSServerName is the name to verify the server certificate with
+	VaultTLSServerName = "VAULT_TLS_SERVER_NAME"
+)
+
+// vaultConfigKeys are the configuration keys read by VaultConfigFromEnv
+var vaultConfigKeys = []string{
+	VaultAddress, VaultToken, VaultNamespace, VaultRoleID, VaultSecretID,
+	VaultAppRolePath, VaultBackendPath, VaultBasePath, VaultKVVersion,
+	VaultCACert, VaultCAPath, VaultClientCert, VaultClientKey,
+	VaultSkipVerify, VaultTLSServerName,
+}
+
+const (
+	vaultDefaultBackendPath = "secret"
+	vaultDefaultBasePath    = "openstorage"
+	vaultDefaultAppRolePath = "approle"
+	vaultNamespaceHeader    = "X-Vault-Namespace"
+	vaultTokenHeader        = "X-Vault-Token"
+	vaultRequestTimeout     = 30 * time.Second
+	// vaultMinRenewInterval bounds how often the token is renewed
+	vaultMinRenewInterval = time.Second
+)
+
+// vaultResponse is the part of the responses of vault used here
+type vaultResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []string        `json:"errors"`
+	Auth   *vaultAuth      `json:"auth"`
+}
+
+type vaultAuth struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int64  `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+}
+
+// vaultError is an error response of vault
+type vaultError struct {
+	status int
+	errors []string
+}
+
+func (e *vaultError) Error() string {
+	return fmt.Sprintf("Vault returned %d: %s", e.status, strings.Join(e.errors, ", "))
+}
+
+// VaultSecrets is an implementation of Secrets keeping the secrets in the
+// KV secrets engine of a vault server.
+type VaultSecrets struct {
+	lock sync.Mutex
+	vaultSession
+	// stopRenew stops the renewal of the token of the current session
+	stopRenew chan struct{}
+}
+
+// vaultSession is the server, the credentials and the secrets engine of a
+// login to vault
+type vaultSession struct {
+	client      *http.Client
+	address     string
+	namespace   string
+	token       string
+	roleID      string
+	secretID    string
+	appRolePath string
+	backendPath string
+	basePath    string
+	kvVersion   int
+}
+
+// Interface check
+var _ Secrets = &VaultSecrets{}
+
+// NewVaultSecrets returns vault secrets logged in with config.
+func NewVaultSecrets(config map[string]string) (*VaultSecrets, error) {
+	v := &VaultSecrets{}
+	if err := v.SecretLogin(TypeVault, config); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// VaultConfigFromEnv returns the configuration of the vault secrets from
+// the environment variables named like its keys, or nil if VaultAddress is
+// not set.
+func VaultConfigFromEnv() map[string]string {
+	if len(os.Getenv(VaultAddress)) == 0 {
+		return nil
+	}
+	config := make(map[string]string)
+	for _, key := range vaultConfigKeys {
+		if value := os.Getenv(key); len(value) != 0 {
+			config[key] = value
+		}
+	}
+	return config
+}
+
+func vaultTLSConfig(config map[string]string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName: config[VaultTLSServerName],
+	}
+	if skip := config[VaultSkipVerify]; len(skip) != 0 {
+		b, err := strconv.ParseBool(skip)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s %q: %v", VaultSkipVerify, skip, err)
+		}
+		tlsConfig.InsecureSkipVerify = b
+	}
+	var caFiles []string
+	if f := config[VaultCACert]; len(f) != 0 {
+		caFiles = append(caFiles, f)
+	}
+	if dir := config[VaultCAPath]; len(dir) != 0 {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if !f.IsDir() {
+				caFiles = append(caFiles, filepath.Join(dir, f.Name()))
+			}
+		}
+	}
+	if len(caFiles) != 0 {
+		pool := x509.NewCertPool()
+		for _, f := range caFiles {
+			pem, err := ioutil.ReadFile(f)
+			if err != nil {
+				return nil, err
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("No certificate found in %s", f)
+			}
+		}
+		tlsConfig.RootCAs = pool
+	}
+	cert, key := config[VaultClientCert], config[VaultClientKey]
+	if len(cert) != 0 || len(key) != 0 {
+		c, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{c}
+	}
+	return tlsConfig, nil
+}
+
+// SecretLogin authenticates with the vault server of config, with a token
+// or with AppRole, and starts renewing the token. The current session is
+// kept if the login fails.
+func (v *VaultSecrets) SecretLogin(secretType string, config map[string]string) error {
+	if secretType != TypeVault {
+		return ErrNotImplemented
+	}
+	if len(config[VaultAddress]) == 0 {
+		return fmt.Errorf("Missing %s", VaultAddress)
+	}
+	token := config[VaultToken]
+	roleID, secretID := config[VaultRoleID], config[VaultSecretID]
+	if len(token) == 0 && len(roleID) == 0 {
+		return fmt.Errorf("Missing %s or %s", VaultToken, VaultRoleID)
+	}
+	kvVersion := 0
+	switch version := config[VaultKVVersion]; version {
+	case "":
+	case "1", "2":
+		kvVersion, _ = strconv.Atoi(version)
+	default:
+		return fmt.Errorf("Invalid %s %q", VaultKVVersion, version)
+	}
+	tlsConfig, err := vaultTLSConfig(config)
+	if err != nil {
+		return err
+	}
+
+	// The current session is kept until the new one is logged in.
+	session := &vaultSession{
+		client: &http.Client{
+			Timeout: vaultRequestTimeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+		},
+		address:     strings.TrimSuffix(config[VaultAddress], "/"),
+		namespace:   config[VaultNamespace],
+		token:       token,
+		roleID:      roleID,
+		secretID:    secretID,
+		appRolePath: configValue(config, VaultAppRolePath, vaultDefaultAppRolePath),
+		backendPath: strings.Trim(configValue(config, VaultBackendPath, vaultDefaultBackendPath), "/"),
+		basePath:    strings.Trim(configValue(config, VaultBasePath, vaultDefaultBasePath), "/"),
+		kvVersion:   kvVersion,
+	}
+	var auth *vaultAuth
+	if len(session.token) != 0 {
+		auth, err = session.lookupSelf()
+	} else {
+		auth, err = session.appRoleLogin()
+	}
+	if err != nil {
+		return err
+	}
+	if session.kvVersion == 0 {
+		if session.kvVersion, err = session.readKVVersion(); err != nil {
+			return err
+		}
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.logout()
+	v.vaultSession = *session
+	if auth.Renewable && auth.LeaseDuration > 0 {
+		v.stopRenew = make(chan struct{})
+		go v.renew(v.stopRenew, time.Duration(auth.LeaseDuration)*time.Second)
+	}
+	logrus.Infof("Logged in to vault %s, secrets are kept at %s/%s (KV version %d)",
+		v.address, v.backendPath, v.basePath, v.kvVersion)
+	return nil
+}
+
+func configValue(config map[string]string, key, def string) string {
+	if value := config[key]; len(value) != 0 {
+		return value
+	}
+	return def
+}
+
+// logout stops the renewal of the token of the current session
+func (v *VaultSecrets) logout() {
+	if v.stopRenew != nil {
+		close(v.stopRenew)
+		v.stopRenew = nil
+	}
+}
+
+// Logout ends the session with vault
+func (v *VaultSecrets) Logout() {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.logout()
+	v.token = ""
+}
+
+// request sends a request to vault and decodes its response. A nil
+// response is returned for 204 No Content.
+func (v *vaultSession) request(method, apiPath string, body interface{}) (*vaultResponse, error) {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, v.address+"/v1/"+apiPath, reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(v.token) != 0 {
+		req.Header.Set(vaultTokenHeader, v.token)
+	}
+	if len(v.namespace) != 0 {
+		req.Header.Set(vaultNamespaceHeader, v.namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var r vaultResponse
+	if resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(&r); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("Invalid response from vault: %v", err)
+		}
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrInvalidSecretId
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, ErrNotAuthenticated
+	case resp.StatusCode >= 300:
+		return nil, &vaultError{status: resp.StatusCode, errors: r.Errors}
+	case resp.StatusCode == http.StatusNoContent:
+		return nil, nil
+	}
+	return &r, nil
+}
+
+// lookupSelf validates the token and returns its lease
+func (v *vaultSession) lookupSelf() (*vaultAuth, error) {
+	r, err := v.request(http.MethodGet, "auth/token/lookup-self", nil)
+	if err == ErrInvalidSecretId {
+		return nil, ErrNotAuthenticated
+	} else if err != nil {
+		return nil, err
+	}
+	var data struct {
+		TTL       int64 `json:"ttl"`
+		Renewable bool  `json:"renewable"`
+	}
+	if r == nil || json.Unmarshal(r.Data, &data) != nil {
+		return nil, fmt.Errorf("Invalid token lookup response from vault")
+	}
+	return &vaultAuth{
+		ClientToken:   v.token,
+		LeaseDuration: data.TTL,
+		Renewable:     data.Renewable,
+	}, nil
+}
+
+// appRoleLogin gets a token with the role id and secret id
+func (v *vaultSession) appRoleLogin() (*vaultAuth, error) {
+	v.token = ""
+	r, err := v.request(http.MethodPost, path.Join("auth", v.appRolePath, "login"), map[string]string{
+		"role_id":   v.roleID,
+		"secret_id": v.secretID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if r == nil || r.Auth == nil || len(r.Auth.ClientToken) == 0 {
+		return nil, fmt.Errorf("No token in the AppRole login response from vault")
+	}
+	v.token = r.Auth.ClientToken
+	return r.Auth, nil
+}
+
+// readKVVersion returns the version of the KV secrets engine mounted at
+// the backend path. Version 1 is assumed if the token cannot read it.
+func (v *vaultSession) readKVVersion() (int, error) {
+	r, err := v.request(http.MethodGet, "sys/internal/ui/mounts/"+v.backendPath, nil)
+	if err == ErrNotAuthenticated || err == ErrInvalidSecretId {
+		return 1, nil
+	} else if err != nil {
+		return 0, err
+	}
+	var data struct {
+		Options map[string]string `json:"options"`
+	}
+	if r == nil || json.Unmarshal(r.Data, &data) != nil {
+		return 1, nil
+	}
+	if data.Options["version"] == "2" {
+		return 2, nil
+	}
+	return 1, nil
+}
+
+// renew renews the token half way through its lease until stop is
+// closed. Tokens from AppRole are replaced by a new login once they
+// cannot be renewed.
+func (v *VaultSecrets) renew(stop chan struct{}, lease time.Duration) {
+	for {
+		wait := lease / 2
+		if wait < vaultMinRenewInterval {
+			wait = vaultMinRenewInterval
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(wait):
+		}
+
+		v.lock.Lock()
+		select {
+		case <-stop:
+			v.lock.Unlock()
+			return
+		default:
+		}
+		r, err := v.request(http.MethodPost, "auth/token/renew-self", map[string]string{})
+		var auth *vaultAuth
+		if err == nil && r != nil && r.Auth != nil {
+			auth = r.Auth
+		} else if len(v.roleID) != 0 {
+			logrus.Warnf("Failed to renew the vault token, logging in again: %v", err)
+			auth, err = v.appRoleLogin()
+		}
+		v.lock.Unlock()
+
+		if err != nil || auth == nil {
+			logrus.Errorf("Failed to renew the vault token: %v", err)
+			return
+		}
+		if !auth.Renewable || auth.LeaseDuration <= 0 {
+			return
+		}
+		lease = time.Duration(auth.LeaseDuration) * time.Second
+	}
+}
+
+// checkSecretID returns an error if a secret id could address a path
+// outside of the base path
+func checkSecretID(secretID string) error {
+	if len(secretID) == 0 {
+		return ErrKeyEmpty
+	}
+	for _, elem := range strings.Split(secretID, "/") {
+		if elem == "" || elem == "." || elem == ".." {
+			return ErrInvalidSecretPath
+		}
+	}
+	return nil
+}
+
+// escapeSecretID escapes each path element of a secret id checked with
+// checkSecretID
+func escapeSecretID(secretID string) string {
+	elems := strings.Split(secretID, "/")
+	for i, elem := range elems {
+		elems[i] = url.PathEscape(elem)
+	}
+	return strings.Join(elems, "/")
+}
+
+// secretPath returns the API path of the data of a secret
+func (v *vaultSession) secretPath(secretID string) string {
+	if v.kvVersion == 2 {
+		return path.Join(v.backendPath, "data", v.basePath, escapeSecretID(secretID))
+	}
+	return path.Join(v.backendPath, v.basePath, escapeSecretID(secretID))
+}
+
+// metadataPath returns the API path of the metadata and all the versions
+// of a secret in a KV version 2 engine
+func (v *vaultSession) metadataPath(secretID string) string {
+	return path.Join(v.backendPath, "metadata", v.basePath, escapeSecretID(secretID))
+}
+
+// loggedIn is called with the lock held
+func (v *vaultSession) loggedIn() error {
+	if v.client == nil || len(v.token) == 0 {
+		return ErrNotAuthenticated
+	}
+	return nil
+}
+
+func (v *vaultSession) write(secretID string, value interface{}) error {
+	data, ok := value.(map[string]interface{})
+	if !ok {
+		if b, isBytes := value.([]byte); isBytes {
+			value = string(b)
+		}
+		data = map[string]interface{}{SecretValue: value}
+	}
+	var body interface{} = data
+	if v.kvVersion == 2 {
+		body = map[string]interface{}{"data": data}
+	}
+	_, err := v.request(http.MethodPut, v.secretPath(secretID), body)
+	return err
+}
+
+func (v *vaultSession) read(secretID string) (interface{}, error) {
+	r, err := v.request(http.MethodGet, v.secretPath(secretID), nil)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, ErrInvalidSecretId
+	}
+	raw := r.Data
+	if v.kvVersion == 2 {
+		var versioned struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(raw, &versioned); err != nil {
+			return nil, err
+		}
+		raw = versioned.Data
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	// A deleted version of a KV version 2 secret has no data
+	if data == nil {
+		return nil, ErrInvalidSecretId
+	}
+	if value, ok := data[SecretValue]; ok && len(data) == 1 {
+		return value, nil
+	}
+	return data, nil
+}
+
+// SecretCheckLogin returns an error if the token is not valid
+func (v *VaultSecrets) SecretCheckLogin() error {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if err := v.loggedIn(); err != nil {
+		return err
+	}
+	_, err := v.lookupSelf()
+	return err
+}
+
+// SecretSetDefaultSecretKey sets the cluster wide secret key
+func (v *VaultSecrets) SecretSetDefaultSecretKey(secretKey string, override bool) error {
+	if len(secretKey) == 0 {
+		return ErrEmptySecretData
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if err := v.loggedIn(); err != nil {
+		return err
+	}
+	if !override {
+		if _, err := v.read(DefaultSecretKey); err == nil {
+			return ErrSecretExists
+		} else if err != ErrInvalidSecretId {
+			return err
+		}
+	}
+	return v.write(DefaultSecretKey, secretKey)
+}
+
+// SecretGetDefaultSecretKey returns the cluster wide secret key
+func (v *VaultSecrets) SecretGetDefaultSecretKey() (interface{}, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if err := v.loggedIn(); err != nil {
+		return nil, err
+	}
+	return v.read(DefaultSecretKey)
+}
+
+// SecretSet stores the value of a secret. Maps are stored as the data of
+// the secret, other values under the key secretvalue.
+func (v *VaultSecrets) SecretSet(secretID string, value interface{}) error {
+	if err := checkSecretID(secretID); err != nil {
+		return err
+	}
+	if emptySecret(value) {
+		return ErrEmptySecretData
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if err := v.loggedIn(); err != nil {
+		return err
+	}
+	return v.write(secretID, value)
+}
+
+// SecretGet returns the value of a secret
+func (v *VaultSecrets) SecretGet(secretID string) (interface{}, error) {
+	if err := checkSecretID(secretID); err != nil {
+		return nil, err
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if err := v.loggedIn(); err != nil {
+		return nil, err
+	}
+	return v.read(secretID)
+}
+
+// SecretDelete removes a secret, with all its versions in a KV version 2
+// engine
+func (v *VaultSecrets) SecretDelete(secretID string) error {
+	if err := checkSecretID(secretID); err != nil {
+		return err
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if err := v.loggedIn(); err != nil {
+		return err
+	}
+	apiPath := v.secretPath(secretID)
+	if v.kvVersion == 2 {
+		apiPath = v.metadataPath(secretID)
+	}
+	_, err := v.request(http.MethodDelete, apiPath, nil)
+	return err
+}
+
+// SecretRotateMasterKey is not supported, vault manages its own keys
+func (v *VaultSecrets) SecretRotateMasterKey() (string, error) {
+	return "", ErrNotImplemented
+}
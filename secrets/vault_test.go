@@ -0,0 +1,399 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVault is an in-process stand-in for the token, AppRole, mounts and
+// KV APIs of vault.
+type fakeVault struct {
+	lock      sync.Mutex
+	namespace string
+	// tokens maps the valid tokens to their ttl in seconds
+	tokens    map[string]int64
+	roleID    string
+	secretID  string
+	kvVersion string
+	// data maps the paths under the KV mount to the data of the secrets
+	data     map[string]map[string]interface{}
+	renewals int
+	logins   int
+}
+
+func newFakeVault(kvVersion string) *fakeVault {
+	return &fakeVault{
+		tokens:    map[string]int64{"roottoken": 0},
+		roleID:    "myrole",
+		secretID:  "mysecretid",
+		kvVersion: kvVersion,
+		data:      make(map[string]map[string]interface{}),
+	}
+}
+
+func (f *fakeVault) reply(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if body != nil {
+		json.NewEncoder(w).Encode(body)
+	}
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if r.Header.Get(vaultNamespaceHeader) != f.namespace {
+		f.reply(w, http.StatusNotFound, map[string]interface{}{"errors": []string{"no handler for route"}})
+		return
+	}
+	p := strings.TrimPrefix(r.URL.Path, "/v1/")
+	if p == "auth/approle/login" && r.Method == http.MethodPost {
+		var login map[string]string
+		json.NewDecoder(r.Body).Decode(&login)
+		if login["role_id"] != f.roleID || login["secret_id"] != f.secretID {
+			f.reply(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{"invalid secret id"}})
+			return
+		}
+		f.logins++
+		token := fmt.Sprintf("approletoken%d", f.logins)
+		f.tokens[token] = 2
+		f.reply(w, http.StatusOK, map[string]interface{}{
+			"auth": map[string]interface{}{
+				"client_token":   token,
+				"lease_duration": 2,
+				"renewable":      true,
+			},
+		})
+		return
+	}
+
+	ttl, ok := f.tokens[r.Header.Get(vaultTokenHeader)]
+	if !ok {
+		f.reply(w, http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+	switch {
+	case p == "auth/token/lookup-self" && r.Method == http.MethodGet:
+		f.reply(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{"ttl": ttl, "renewable": ttl > 0},
+		})
+		return
+	case p == "auth/token/renew-self" && r.Method == http.MethodPost:
+		f.renewals++
+		f.reply(w, http.StatusOK, map[string]interface{}{
+			"auth": map[string]interface{}{
+				"client_token":   r.Header.Get(vaultTokenHeader),
+				"lease_duration": ttl,
+				"renewable":      true,
+			},
+		})
+		return
+	case p == "sys/internal/ui/mounts/secret" && r.Method == http.MethodGet:
+		f.reply(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{
+				"type":    "kv",
+				"options": map[string]string{"version": f.kvVersion},
+			},
+		})
+		return
+	}
+
+	if !strings.HasPrefix(p, "secret/") {
+		f.reply(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+		return
+	}
+	p = strings.TrimPrefix(p, "secret/")
+	if f.kvVersion == "2" {
+		switch {
+		case strings.HasPrefix(p, "data/"):
+			p = strings.TrimPrefix(p, "data/")
+		case strings.HasPrefix(p, "metadata/") && r.Method == http.MethodDelete:
+			delete(f.data, strings.TrimPrefix(p, "metadata/"))
+			f.reply(w, http.StatusNoContent, nil)
+			return
+		default:
+			f.reply(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := f.data[p]
+		if !ok {
+			f.reply(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+			return
+		}
+		if f.kvVersion == "2" {
+			f.reply(w, http.StatusOK, map[string]interface{}{
+				"data": map[string]interface{}{
+					"data":     data,
+					"metadata": map[string]interface{}{"version": 1},
+				},
+			})
+			return
+		}
+		f.reply(w, http.StatusOK, map[string]interface{}{"data": data})
+	case http.MethodPut, http.MethodPost:
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			f.reply(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{err.Error()}})
+			return
+		}
+		if f.kvVersion == "2" {
+			data, ok := body["data"].(map[string]interface{})
+			if !ok {
+				f.reply(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{"no data provided"}})
+				return
+			}
+			body = data
+		}
+		f.data[p] = body
+		f.reply(w, http.StatusNoContent, nil)
+	case http.MethodDelete:
+		delete(f.data, p)
+		f.reply(w, http.StatusNoContent, nil)
+	default:
+		f.reply(w, http.StatusMethodNotAllowed, nil)
+	}
+}
+
+func (f *fakeVault) counts() (int, int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.renewals, f.logins
+}
+
+func testVaultSecrets(t *testing.T, kvVersion string) {
+	fake := newFakeVault(kvVersion)
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	v, err := NewVaultSecrets(map[string]string{
+		VaultAddress:  server.URL,
+		VaultToken:    "roottoken",
+		VaultBasePath: "openstorage/cluster1",
+	})
+	require.NoError(t, err)
+	defer v.Logout()
+	assert.Equal(t, kvVersion, strconv.Itoa(v.kvVersion))
+	assert.NoError(t, v.SecretCheckLogin())
+
+	_, err = v.SecretGet("mysecret")
+	assert.Equal(t, ErrInvalidSecretId, err)
+	assert.Equal(t, ErrEmptySecretData, v.SecretSet("mysecret", ""))
+
+	require.NoError(t, v.SecretSet("mysecret", "topsecretvalue"))
+	assert.Equal(t, map[string]interface{}{SecretValue: "topsecretvalue"}, fake.data["openstorage/cluster1/mysecret"])
+	value, err := v.SecretGet("mysecret")
+	assert.NoError(t, err)
+	assert.Equal(t, "topsecretvalue", value)
+
+	require.NoError(t, v.SecretSet("mymap", map[string]interface{}{"user": "admin", "password": "pass"}))
+	value, err = v.SecretGet("mymap")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"user": "admin", "password": "pass"}, value)
+
+	assert.NoError(t, v.SecretSetDefaultSecretKey("clusterkey", false))
+	assert.Equal(t, ErrSecretExists, v.SecretSetDefaultSecretKey("otherkey", false))
+	assert.NoError(t, v.SecretSetDefaultSecretKey("otherkey", true))
+	key, err := v.SecretGetDefaultSecretKey()
+	assert.NoError(t, err)
+	assert.Equal(t, "otherkey", key)
+
+	assert.NoError(t, v.SecretDelete("mysecret"))
+	_, err = v.SecretGet("mysecret")
+	assert.Equal(t, ErrInvalidSecretId, err)
+
+	v.Logout()
+	assert.Equal(t, ErrNotAuthenticated, v.SecretCheckLogin())
+	_, err = v.SecretGet("mymap")
+	assert.Equal(t, ErrNotAuthenticated, err)
+}
+
+func TestVaultSecretsKVv1(t *testing.T) {
+	testVaultSecrets(t, "1")
+}
+
+func TestVaultSecretsKVv2(t *testing.T) {
+	testVaultSecrets(t, "2")
+}
+
+func TestVaultSecretsPathTraversal(t *testing.T) {
+	fake := newFakeVault("1")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	fake.data["openstorage/cluster2/othersecret"] = map[string]interface{}{SecretValue: "othervalue"}
+
+	v, err := NewVaultSecrets(map[string]string{
+		VaultAddress:  server.URL,
+		VaultToken:    "roottoken",
+		VaultBasePath: "openstorage/cluster1",
+	})
+	require.NoError(t, err)
+	defer v.Logout()
+
+	for _, id := range []string{
+		"../cluster2/othersecret",
+		"../../openstorage/cluster2/othersecret",
+		"app/../../cluster2/othersecret",
+		"/othersecret",
+		"app//othersecret",
+		"app/./othersecret",
+		"..",
+	} {
+		_, err := v.SecretGet(id)
+		assert.Equal(t, ErrInvalidSecretPath, err, id)
+		assert.Equal(t, ErrInvalidSecretPath, v.SecretSet(id, "value"), id)
+		assert.Equal(t, ErrInvalidSecretPath, v.SecretDelete(id), id)
+	}
+	assert.Equal(t, map[string]interface{}{SecretValue: "othervalue"},
+		fake.data["openstorage/cluster2/othersecret"])
+
+	// Nested ids stay under the base path and are escaped
+	require.NoError(t, v.SecretSet("app/db?version=2", "value"))
+	assert.Contains(t, fake.data, "openstorage/cluster1/app/db?version=2")
+	value, err := v.SecretGet("app/db?version=2")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+}
+
+func TestVaultSecretsLogin(t *testing.T) {
+	fake := newFakeVault("1")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	v := &VaultSecrets{}
+	assert.Equal(t, ErrNotImplemented, v.SecretLogin(TypeKvdb, nil))
+	assert.Error(t, v.SecretLogin(TypeVault, map[string]string{VaultToken: "roottoken"}))
+	assert.Error(t, v.SecretLogin(TypeVault, map[string]string{VaultAddress: server.URL}))
+	assert.Equal(t, ErrNotAuthenticated, v.SecretLogin(TypeVault, map[string]string{
+		VaultAddress: server.URL,
+		VaultToken:   "badtoken",
+	}))
+	assert.Equal(t, ErrNotAuthenticated, v.SecretCheckLogin())
+	assert.Error(t, v.SecretLogin(TypeVault, map[string]string{
+		VaultAddress:   server.URL,
+		VaultToken:     "roottoken",
+		VaultKVVersion: "3",
+	}))
+
+	// A failed login keeps the current session
+	require.NoError(t, v.SecretLogin(TypeVault, map[string]string{
+		VaultAddress: server.URL,
+		VaultToken:   "roottoken",
+	}))
+	defer v.Logout()
+	require.NoError(t, v.SecretSet("mysecret", "value"))
+	assert.Equal(t, ErrNotAuthenticated, v.SecretLogin(TypeVault, map[string]string{
+		VaultAddress: server.URL,
+		VaultToken:   "badtoken",
+	}))
+	assert.Error(t, v.SecretLogin(TypeVault, map[string]string{
+		VaultAddress:  "http://127.0.0.1:1",
+		VaultToken:    "roottoken",
+		VaultBasePath: "other",
+	}))
+	assert.NoError(t, v.SecretCheckLogin())
+	value, err := v.SecretGet("mysecret")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+}
+
+func TestVaultConfigFromEnv(t *testing.T) {
+	for _, key := range vaultConfigKeys {
+		os.Unsetenv(key)
+	}
+	assert.Nil(t, VaultConfigFromEnv())
+
+	os.Setenv(VaultAddress, "https://vault.example.com:8200")
+	defer os.Unsetenv(VaultAddress)
+	os.Setenv(VaultRoleID, "myrole")
+	defer os.Unsetenv(VaultRoleID)
+	assert.Equal(t, map[string]string{
+		VaultAddress: "https://vault.example.com:8200",
+		VaultRoleID:  "myrole",
+	}, VaultConfigFromEnv())
+}
+
+func TestVaultSecretsNamespace(t *testing.T) {
+	fake := newFakeVault("2")
+	fake.namespace = "team1"
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	_, err := NewVaultSecrets(map[string]string{
+		VaultAddress: server.URL,
+		VaultToken:   "roottoken",
+	})
+	assert.Error(t, err)
+
+	v, err := NewVaultSecrets(map[string]string{
+		VaultAddress:   server.URL,
+		VaultToken:     "roottoken",
+		VaultNamespace: "team1",
+	})
+	require.NoError(t, err)
+	defer v.Logout()
+	assert.NoError(t, v.SecretSet("mysecret", "value"))
+	assert.Contains(t, fake.data, vaultDefaultBasePath+"/mysecret")
+}
+
+func TestVaultSecretsAppRoleRenewal(t *testing.T) {
+	fake := newFakeVault("1")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	_, err := NewVaultSecrets(map[string]string{
+		VaultAddress:  server.URL,
+		VaultRoleID:   "myrole",
+		VaultSecretID: "wrong",
+	})
+	assert.Error(t, err)
+
+	v, err := NewVaultSecrets(map[string]string{
+		VaultAddress:   server.URL,
+		VaultRoleID:    "myrole",
+		VaultSecretID:  "mysecretid",
+		VaultKVVersion: "1",
+	})
+	require.NoError(t, err)
+	defer v.Logout()
+	assert.NoError(t, v.SecretSet("mysecret", "value"))
+
+	// The token has a lease of 2 seconds and is renewed every second
+	time.Sleep(2500 * time.Millisecond)
+	renewals, logins := fake.counts()
+	assert.True(t, renewals >= 1, "renewals: %d", renewals)
+	assert.Equal(t, 1, logins)
+
+	// Once the token cannot be renewed, AppRole logs in again
+	fake.lock.Lock()
+	fake.tokens = map[string]int64{}
+	fake.lock.Unlock()
+	time.Sleep(1500 * time.Millisecond)
+	_, logins = fake.counts()
+	assert.Equal(t, 2, logins)
+	value, err := v.SecretGet("mysecret")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	// Renewal stops with the session
+	v.Logout()
+	renewals, _ = fake.counts()
+	time.Sleep(1500 * time.Millisecond)
+	after, _ := fake.counts()
+	assert.Equal(t, renewals, after)
+}
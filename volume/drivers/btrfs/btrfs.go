@@ -4,19 +4,24 @@ package btrfs
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
-
-	"go.pedge.io/proto/time"
+	"time"
 
 	"github.com/docker/docker/daemon/graphdriver"
 	"github.com/docker/docker/daemon/graphdriver/btrfs"
+	"github.com/golang/protobuf/proto"
+	"github.com/pborman/uuid"
+	"github.com/portworx/kvdb"
+	"github.com/sirupsen/logrus"
+
 	"github.com/libopenstorage/openstorage/api"
 	"github.com/libopenstorage/openstorage/pkg/chaos"
 	"github.com/libopenstorage/openstorage/volume"
 	"github.com/libopenstorage/openstorage/volume/drivers/common"
-	"github.com/pborman/uuid"
-	"github.com/portworx/kvdb"
 )
 
 const (
@@ -29,14 +34,34 @@ const (
 var (
 	koStrayCreate chaos.ID
 	koStrayDelete chaos.ID
+
+	// mountFlags are the mount options accepted by Mount. An option is set
+	// if its value is empty or true.
+	mountFlags = map[string]uintptr{
+		"ro":      syscall.MS_RDONLY,
+		"nosuid":  syscall.MS_NOSUID,
+		"nodev":   syscall.MS_NODEV,
+		"noexec":  syscall.MS_NOEXEC,
+		"noatime": syscall.MS_NOATIME,
+	}
 )
 
 type driver struct {
 	volume.StoreEnumerator
 	volume.IODriver
 	volume.BlockDriver
-	btrfs graphdriver.Driver
-	root  string
+	volume.QuiesceDriver
+	volume.StatsDriver
+	volume.CredsDriver
+	volume.CloudBackupDriver
+	volume.CloudMigrateDriver
+	cloudBackups common.CloudBackupProvider
+	btrfs        graphdriver.Driver
+	root         string
+	home         string
+	// quota is true if qgroup accounting is enabled, which is needed to
+	// limit the size of the volumes and report their usage.
+	quota bool
 }
 
 func Init(params map[string]string) (volume.VolumeDriver, error) {
@@ -44,18 +69,32 @@ func Init(params map[string]string) (volume.VolumeDriver, error) {
 	if !ok {
 		return nil, fmt.Errorf("Root directory should be specified with key %q", RootParam)
 	}
-	home := filepath.Join(root, "volumes")
+	home := filepath.Join(root, Volumes)
 	d, err := btrfs.Init(home, nil, nil, nil)
 	if err != nil {
 		return nil, err
 	}
-	return &driver{
-		common.NewDefaultStoreEnumerator(Name, kvdb.Instance()),
-		common.IONotSupported,
-		common.BlockNotSupported,
-		d,
-		root,
-	}, nil
+	inst := &driver{
+		StoreEnumerator:    common.NewDefaultStoreEnumerator(Name, kvdb.Instance()),
+		IODriver:           volume.IONotSupported,
+		BlockDriver:        volume.BlockNotSupported,
+		QuiesceDriver:      volume.QuiesceNotSupported,
+		StatsDriver:        volume.StatsNotSupported,
+		CloudMigrateDriver: volume.CloudMigrateNotSupported,
+		btrfs:              d,
+		root:               root,
+		home:               home,
+	}
+	if err := quotaEnable(home); err != nil {
+		logrus.Warnf("Volume sizes will not be enforced: %v", err)
+	} else {
+		inst.quota = true
+	}
+	cloudBackups := common.NewCloudBackupProvider(Name, kvdb.Instance(), inst)
+	inst.cloudBackups = cloudBackups
+	inst.CredsDriver = cloudBackups
+	inst.CloudBackupDriver = cloudBackups
+	return inst, nil
 }
 
 func (d *driver) Name() string {
@@ -63,7 +102,8 @@ func (d *driver) Name() string {
 }
 
 func (d *driver) Status() [][2]string {
-	return d.btrfs.Status()
+	status := d.btrfs.Status()
+	return append(status, [2]string{"Quota", strconv.FormatBool(d.quota)})
 }
 
 func (d *driver) Type() api.DriverType {
@@ -77,7 +117,8 @@ func (d *driver) Version() (*api.StorageVersion, error) {
 	}, nil
 }
 
-// Create a new subvolume. The volume spec is not taken into account.
+// Create a new subvolume, or a snapshot of the subvolume of the parent of
+// the source. The subvolume is limited to the size of the spec.
 func (d *driver) Create(
 	locator *api.VolumeLocator,
 	source *api.Source,
@@ -86,6 +127,12 @@ func (d *driver) Create(
 	if spec.Format != api.FSType_FS_TYPE_BTRFS && spec.Format != api.FSType_FS_TYPE_NONE {
 		return "", fmt.Errorf("Filesystem format (%v) must be %v", spec.Format.SimpleString(), api.FSType_FS_TYPE_BTRFS.SimpleString())
 	}
+	parent := source.GetParent()
+	if len(parent) != 0 {
+		if _, err := d.GetVol(parent); err != nil {
+			return "", fmt.Errorf("Cannot find parent volume %s: %v", parent, err)
+		}
+	}
 	volume := common.NewVolume(
 		uuid.New(),
 		api.FSType_FS_TYPE_BTRFS,
@@ -96,7 +143,9 @@ func (d *driver) Create(
 	if err := d.CreateVol(volume); err != nil {
 		return "", err
 	}
-	if err := d.btrfs.Create(volume.Id, "", "", nil); err != nil {
+	chaos.Now(koStrayCreate)
+	if err := d.btrfs.Create(volume.Id, parent, "", nil); err != nil {
+		d.DeleteVol(volume.Id)
 		return "", err
 	}
 	devicePath, err := d.btrfs.Get(volume.Id, "")
@@ -104,94 +153,363 @@ func (d *driver) Create(
 		return volume.Id, err
 	}
 	volume.DevicePath = devicePath
+	if err := d.limit(devicePath, spec.GetSize()); err != nil {
+		d.btrfs.Remove(volume.Id)
+		d.DeleteVol(volume.Id)
+		return "", err
+	}
 	return volume.Id, d.UpdateVol(volume)
 }
 
 func (d *driver) Delete(volumeID string) error {
+	v, err := d.GetVol(volumeID)
+	if err != nil {
+		return err
+	}
+	if len(v.AttachPath) != 0 {
+		return fmt.Errorf("Volume %s is mounted at %v", volumeID, v.AttachPath)
+	}
+	var qgroup string
+	if d.quota {
+		if qgroup, err = qgroupID(v.DevicePath); err != nil {
+			logrus.Warnf("Cannot find the qgroup of volume %s: %v", volumeID, err)
+		}
+	}
 	if err := d.DeleteVol(volumeID); err != nil {
 		return err
 	}
 	chaos.Now(koStrayDelete)
-	return d.btrfs.Remove(volumeID)
+	if err := d.btrfs.Remove(volumeID); err != nil {
+		return err
+	}
+	if len(qgroup) != 0 {
+		if err := qgroupDestroy(d.home, qgroup); err != nil {
+			logrus.Warnf("Failed to remove qgroup %s of volume %s: %v", qgroup, volumeID, err)
+		}
+	}
+	return nil
 }
 
-func (d *driver) Mount(volumeID string, mountpath string) error {
+func (d *driver) MountedAt(mountpath string) string {
+	return ""
+}
+
+// Mount bind mounts the subvolume of a volume at mountpath. The options
+// ro, nosuid, nodev, noexec and noatime are applied to the mount, and
+// readonly volumes are always mounted readonly.
+func (d *driver) Mount(volumeID string, mountpath string, options map[string]string) error {
 	v, err := d.GetVol(volumeID)
 	if err != nil {
 		return err
 	}
-	if err := syscall.Mount(v.DevicePath, mountpath, v.Format.SimpleString(), syscall.MS_BIND, ""); err != nil {
+	for _, p := range v.AttachPath {
+		if p == mountpath {
+			return fmt.Errorf("Volume %s already mounted at %s", volumeID, mountpath)
+		}
+	}
+	flags, err := parseMountOptions(options)
+	if err != nil {
+		return err
+	}
+	if v.Readonly {
+		flags |= syscall.MS_RDONLY
+	}
+	if err := syscall.Mount(v.DevicePath, mountpath, "", syscall.MS_BIND, ""); err != nil {
 		return fmt.Errorf("Failed to mount %v at %v: %v", v.DevicePath, mountpath, err)
 	}
-	v.AttachPath = mountpath
+	// The flags of a bind mount can only be changed by remounting it.
+	if flags != 0 {
+		if err := syscall.Mount("", mountpath, "", syscall.MS_REMOUNT|syscall.MS_BIND|flags, ""); err != nil {
+			syscall.Unmount(mountpath, 0)
+			return fmt.Errorf("Failed to apply mount options to %v: %v", mountpath, err)
+		}
+	}
+	v.AttachPath = append(v.AttachPath, mountpath)
 	return d.UpdateVol(v)
 }
 
-func (d *driver) Unmount(volumeID string, mountpath string) error {
+// Unmount removes the mount of a volume at mountpath.
+func (d *driver) Unmount(volumeID string, mountpath string, options map[string]string) error {
 	v, err := d.GetVol(volumeID)
 	if err != nil {
 		return err
 	}
-	if v.AttachPath == "" {
+	if len(v.AttachPath) == 0 {
 		return fmt.Errorf("Device %v not mounted", volumeID)
 	}
-	if err := syscall.Unmount(v.AttachPath, 0); err != nil {
+	attachPath := make([]string, 0, len(v.AttachPath))
+	for _, p := range v.AttachPath {
+		if p != mountpath {
+			attachPath = append(attachPath, p)
+		}
+	}
+	if len(attachPath) == len(v.AttachPath) {
+		return fmt.Errorf("Device %v not mounted at %v", volumeID, mountpath)
+	}
+	if err := syscall.Unmount(mountpath, 0); err != nil {
 		return err
 	}
-	v.AttachPath = ""
+	v.AttachPath = attachPath
 	return d.UpdateVol(v)
 }
 
+// Set updates the locator of a volume and changes the qgroup limit of its
+// subvolume to the size of spec. Fields of spec with their zero value are left
+// unchanged, and changing any field other than the size returns
+// volume.ErrNotSupported.
 func (d *driver) Set(volumeID string, locator *api.VolumeLocator, spec *api.VolumeSpec) error {
-	if spec != nil {
-		return volume.ErrNotSupported
-	}
 	v, err := d.GetVol(volumeID)
 	if err != nil {
 		return err
 	}
+	if fields := common.ChangedSpecFields(v.Spec, spec); len(fields) != 0 {
+		logrus.Warnf("Cannot change %s of btrfs volume %s", strings.Join(fields, ", "), volumeID)
+		return volume.ErrNotSupported
+	}
 	if locator != nil {
 		v.Locator = locator
 	}
+	if spec.GetSize() != 0 && spec.Size != v.Spec.Size {
+		if !d.quota {
+			return volume.ErrNotSupported
+		}
+		if err := qgroupLimit(v.DevicePath, spec.Size); err != nil {
+			return err
+		}
+		v.Spec.Size = spec.Size
+	}
 	return d.UpdateVol(v)
 }
 
-// Snapshot create new subvolume from volume
+// Snapshot creates a snapshot of the subvolume of a volume.
 func (d *driver) Snapshot(volumeID string, readonly bool, locator *api.VolumeLocator, noRetry bool) (string, error) {
-	vols, err := d.Inspect([]string{volumeID})
+	v, err := d.GetVol(volumeID)
 	if err != nil {
 		return "", err
 	}
-	if len(vols) != 1 {
-		return "", fmt.Errorf("Failed to inspect %v len %v", volumeID, len(vols))
+	if locator == nil {
+		locator = &api.VolumeLocator{}
+	} else {
+		locator = proto.Clone(locator).(*api.VolumeLocator)
 	}
-	snapID := uuid.New()
-	vols[0].Id = snapID
-	vols[0].Source = &api.Source{Parent: volumeID}
-	vols[0].Locator = locator
-	vols[0].Ctime = prototime.Now()
-
-	if err := d.CreateVol(vols[0]); err != nil {
+	if len(locator.Name) == 0 {
+		locator.Name = volumeID + "-" + strconv.FormatInt(time.Now().Unix(), 10)
+	}
+	spec := proto.Clone(v.Spec).(*api.VolumeSpec)
+	snapID, err := d.Create(locator, &api.Source{Parent: volumeID}, spec)
+	if err != nil {
 		return "", err
 	}
-	chaos.Now(koStrayCreate)
-	err = d.btrfs.Create(snapID, volumeID, "", nil)
+	if readonly {
+		snap, err := d.GetVol(snapID)
+		if err != nil {
+			return "", err
+		}
+		snap.Readonly = true
+		if err := d.UpdateVol(snap); err != nil {
+			return "", err
+		}
+	}
+	logrus.Infof("Created snapshot %s of volume %s", snapID, volumeID)
+	return snapID, nil
+}
+
+// Restore replaces the subvolume of a volume with a snapshot of the
+// subvolume of snapID. The volume must not be mounted.
+func (d *driver) Restore(volumeID string, snapID string) error {
+	v, err := d.GetVol(volumeID)
+	if err != nil {
+		return err
+	}
+	if _, err := d.GetVol(snapID); err != nil {
+		return err
+	}
+	if len(v.AttachPath) != 0 {
+		return fmt.Errorf("Volume %s must be unmounted to be restored", volumeID)
+	}
+	// Snapshot first so that the volume is left untouched if it fails.
+	restoreID := volumeID + "-restore-" + uuid.New()
+	if err := d.btrfs.Create(restoreID, snapID, "", nil); err != nil {
+		return err
+	}
+	restorePath, err := d.btrfs.Get(restoreID, "")
 	if err != nil {
+		d.btrfs.Remove(restoreID)
+		return err
+	}
+	var qgroup string
+	if d.quota {
+		if qgroup, err = qgroupID(v.DevicePath); err != nil {
+			logrus.Warnf("Cannot find the qgroup of volume %s: %v", volumeID, err)
+		}
+	}
+	// Move the subvolume of the volume aside until the restored subvolume
+	// is in place, so that the volume keeps its data if the swap fails.
+	asideID := volumeID + "-old-" + uuid.New()
+	asidePath := filepath.Join(filepath.Dir(v.DevicePath), asideID)
+	if err := os.Rename(v.DevicePath, asidePath); err != nil {
+		d.btrfs.Remove(restoreID)
+		return fmt.Errorf("Failed to move the subvolume %s aside: %v", v.DevicePath, err)
+	}
+	if err := os.Rename(restorePath, v.DevicePath); err != nil {
+		if rerr := os.Rename(asidePath, v.DevicePath); rerr != nil {
+			logrus.Warnf("Failed to move the subvolume of volume %s back from %s: %v", volumeID, asidePath, rerr)
+		} else {
+			d.btrfs.Remove(restoreID)
+		}
+		return fmt.Errorf("Failed to move the restored subvolume %s to %s: %v", restorePath, v.DevicePath, err)
+	}
+	if err := d.btrfs.Remove(asideID); err != nil {
+		logrus.Warnf("Failed to remove the old subvolume %s of volume %s: %v", asidePath, volumeID, err)
+	}
+	if len(qgroup) != 0 {
+		if err := qgroupDestroy(d.home, qgroup); err != nil {
+			logrus.Warnf("Failed to remove qgroup %s of volume %s: %v", qgroup, volumeID, err)
+		}
+	}
+	return d.limit(v.DevicePath, v.Spec.GetSize())
+}
+
+// SnapshotGroup snapshots the volumes in volumeIDs, or else the volumes of
+// the group groupID whose labels match labels. The subvolumes are
+// snapshotted one after the other, so the snapshots are not crash
+// consistent with each other.
+func (d *driver) SnapshotGroup(groupID string, labels map[string]string, volumeIDs []string) (*api.GroupSnapCreateResponse, error) {
+	if len(volumeIDs) == 0 {
+		if len(groupID) == 0 && len(labels) == 0 {
+			return nil, fmt.Errorf("Must provide a group id, labels or volume ids")
+		}
+		vols, err := d.Enumerate(&api.VolumeLocator{VolumeLabels: labels}, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vols {
+			if v.Readonly {
+				continue
+			}
+			if len(groupID) != 0 && v.GetGroup().GetId() != groupID &&
+				v.GetSpec().GetGroup().GetId() != groupID {
+				continue
+			}
+			volumeIDs = append(volumeIDs, v.Id)
+		}
+		if len(volumeIDs) == 0 {
+			return nil, fmt.Errorf("No volumes found in group %q with labels %v", groupID, labels)
+		}
+	}
+	resp := &api.GroupSnapCreateResponse{
+		Snapshots: make(map[string]*api.SnapCreateResponse),
+	}
+	for _, volumeID := range volumeIDs {
+		snapID, err := d.Snapshot(volumeID, true, nil, false)
+		if err != nil {
+			resp.Error = fmt.Sprintf("Failed to snapshot volume %s: %v", volumeID, err)
+			return resp, err
+		}
+		resp.Snapshots[volumeID] = &api.SnapCreateResponse{
+			VolumeCreateResponse: &api.VolumeCreateResponse{
+				Id:             snapID,
+				VolumeResponse: &api.VolumeResponse{},
+			},
+		}
+	}
+	return resp, nil
+}
+
+// CloudBackupPath returns the directory of the subvolume of the volume.
+func (d *driver) CloudBackupPath(volumeID string) (string, error) {
+	if _, err := d.GetVol(volumeID); err != nil {
 		return "", err
 	}
-	return vols[0].Id, nil
+	return d.btrfs.Get(volumeID, "")
 }
 
-func (d *driver) Stats(volumeID string) (*api.Stats, error) {
-	return nil, nil
+// usage returns the qgroup accounting of the subvolume of a volume.
+func (d *driver) usage(volumeID string) (*qgroupInfo, error) {
+	v, err := d.GetVol(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	if !d.quota {
+		return nil, volume.ErrNotSupported
+	}
+	return qgroupUsage(v.DevicePath)
+}
+
+// UsedSize returns the space referenced by the subvolume of a volume.
+func (d *driver) UsedSize(volumeID string) (uint64, error) {
+	info, err := d.usage(volumeID)
+	if err != nil {
+		return 0, err
+	}
+	return info.referenced, nil
+}
+
+// Stats returns the space used by a volume. IO stats are not tracked.
+func (d *driver) Stats(volumeID string, cumulative bool) (*api.Stats, error) {
+	used, err := d.UsedSize(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	return &api.Stats{BytesUsed: used}, nil
 }
 
-func (d *driver) Alerts(volumeID string) (*api.Alerts, error) {
-	return nil, nil
+// CapacityUsage returns the space referenced by the subvolume of a volume,
+// split between the extents only it references and the extents it shares
+// with its snapshots and clones.
+func (d *driver) CapacityUsage(volumeID string) (*api.CapacityUsageResponse, error) {
+	info, err := d.usage(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	return &api.CapacityUsageResponse{
+		CapacityUsageInfo: &api.CapacityUsageInfo{
+			ExclusiveBytes: int64(info.exclusive),
+			SharedBytes:    int64(info.referenced - info.exclusive),
+			TotalBytes:     int64(info.referenced),
+		},
+	}, nil
 }
 
-func (d *driver) Shutdown() {}
+func (d *driver) Shutdown() {
+	d.cloudBackups.Stop()
+}
 
+// Catalog lists the files of a directory of a volume, down to depth
+// levels of subdirectories or all of them if depth is 0.
 func (d *driver) Catalog(volumeID, path, depth string) (api.CatalogResponse, error) {
-	return api.CatalogResponse{}, volume.ErrNotSupported
+	v, err := d.GetVol(volumeID)
+	if err != nil {
+		return api.CatalogResponse{}, err
+	}
+	return common.Catalog(v.DevicePath, path, depth)
+}
+
+// limit sets the qgroup limit of a subvolume if qgroups are enabled.
+func (d *driver) limit(path string, size uint64) error {
+	if !d.quota || size == 0 {
+		return nil
+	}
+	return qgroupLimit(path, size)
+}
+
+func parseMountOptions(options map[string]string) (uintptr, error) {
+	var flags uintptr
+	for k, v := range options {
+		flag, ok := mountFlags[k]
+		if !ok {
+			continue
+		}
+		set := true
+		if len(v) != 0 {
+			var err error
+			if set, err = strconv.ParseBool(v); err != nil {
+				return 0, fmt.Errorf("Invalid value %q for mount option %s", v, k)
+			}
+		}
+		if set {
+			flags |= flag
+		}
+	}
+	return flags, nil
 }
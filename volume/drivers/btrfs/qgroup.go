@@ -0,0 +1,76 @@
+// +build linux,have_btrfs
+
+package btrfs
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const (
+	btrfsBin = "btrfs"
+)
+
+func btrfsCmd(args ...string) (string, error) {
+	out, err := exec.Command(btrfsBin, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("btrfs %s failed: %v: %s",
+			strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+// quotaEnable turns on qgroup accounting for the file system of home.
+func quotaEnable(home string) error {
+	_, err := btrfsCmd("quota", "enable", home)
+	return err
+}
+
+// qgroupID returns the level 0 qgroup id of the subvolume at path.
+func qgroupID(path string) (string, error) {
+	out, err := btrfsCmd("inspect-internal", "rootid", path)
+	if err != nil {
+		return "", err
+	}
+	return parseRootID(out)
+}
+
+// qgroupLimit limits the space referenced by the subvolume at path to
+// size bytes, or removes its limit if size is 0.
+func qgroupLimit(path string, size uint64) error {
+	limit := "none"
+	if size != 0 {
+		limit = strconv.FormatUint(size, 10)
+	}
+	_, err := btrfsCmd("qgroup", "limit", limit, path)
+	return err
+}
+
+// qgroupUsage returns the accounting of the subvolume at path.
+func qgroupUsage(path string) (*qgroupInfo, error) {
+	id, err := qgroupID(path)
+	if err != nil {
+		return nil, err
+	}
+	out, err := btrfsCmd("qgroup", "show", "--raw", "-r", path)
+	if err != nil {
+		return nil, err
+	}
+	qgroups, err := parseQgroupShow(out)
+	if err != nil {
+		return nil, err
+	}
+	info, ok := qgroups[id]
+	if !ok {
+		return nil, fmt.Errorf("No qgroup %s for %s", id, path)
+	}
+	return &info, nil
+}
+
+// qgroupDestroy removes the qgroup left behind by a deleted subvolume.
+func qgroupDestroy(home, id string) error {
+	_, err := btrfsCmd("qgroup", "destroy", id, home)
+	return err
+}
@@ -0,0 +1,63 @@
+package btrfs
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// qgroupInfo is the accounting of a btrfs qgroup.
+type qgroupInfo struct {
+	// referenced is the space referenced by the subvolume, including
+	// extents shared with its snapshots
+	referenced uint64
+	// exclusive is the space only referenced by the subvolume
+	exclusive uint64
+	// maxReferenced is the limit of the referenced space, 0 if unlimited
+	maxReferenced uint64
+}
+
+// parseQgroupShow parses the output of `btrfs qgroup show --raw -r`,
+// keyed by qgroup id such as 0/257.
+func parseQgroupShow(output string) (map[string]qgroupInfo, error) {
+	qgroups := make(map[string]qgroupInfo)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || !strings.Contains(fields[0], "/") {
+			// Header, separator or blank line.
+			continue
+		}
+		if strings.HasPrefix(fields[0], "-") {
+			continue
+		}
+		var (
+			info qgroupInfo
+			err  error
+		)
+		if info.referenced, err = strconv.ParseUint(fields[1], 10, 64); err != nil {
+			return nil, fmt.Errorf("Invalid referenced size for qgroup %s: %v", fields[0], err)
+		}
+		if info.exclusive, err = strconv.ParseUint(fields[2], 10, 64); err != nil {
+			return nil, fmt.Errorf("Invalid exclusive size for qgroup %s: %v", fields[0], err)
+		}
+		if len(fields) > 3 && fields[3] != "none" {
+			if info.maxReferenced, err = strconv.ParseUint(fields[3], 10, 64); err != nil {
+				return nil, fmt.Errorf("Invalid limit for qgroup %s: %v", fields[0], err)
+			}
+		}
+		qgroups[fields[0]] = info
+	}
+	return qgroups, scanner.Err()
+}
+
+// parseRootID parses the output of `btrfs inspect-internal rootid` into
+// the level 0 qgroup id of the subvolume.
+func parseRootID(output string) (string, error) {
+	id, err := strconv.ParseUint(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("Invalid subvolume id %q: %v", strings.TrimSpace(output), err)
+	}
+	return "0/" + strconv.FormatUint(id, 10), nil
+}
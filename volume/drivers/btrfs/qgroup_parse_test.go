@@ -0,0 +1,38 @@
+package btrfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQgroupShow(t *testing.T) {
+	output := `qgroupid         rfer         excl     max_rfer
+--------         ----         ----     --------
+0/5             16384        16384         none
+0/257        10502144      4210688   1073741824
+0/258         6307840        16384         none
+`
+	qgroups, err := parseQgroupShow(output)
+	require.NoError(t, err)
+	require.Len(t, qgroups, 3)
+	assert.Equal(t, qgroupInfo{referenced: 10502144, exclusive: 4210688, maxReferenced: 1073741824}, qgroups["0/257"])
+	assert.Equal(t, qgroupInfo{referenced: 6307840, exclusive: 16384}, qgroups["0/258"])
+
+	_, err = parseQgroupShow("0/257 lots 16384 none\n")
+	assert.Error(t, err)
+
+	qgroups, err = parseQgroupShow("")
+	require.NoError(t, err)
+	assert.Empty(t, qgroups)
+}
+
+func TestParseRootID(t *testing.T) {
+	id, err := parseRootID("257\n")
+	require.NoError(t, err)
+	assert.Equal(t, "0/257", id)
+
+	_, err = parseRootID("ERROR: not a btrfs filesystem\n")
+	assert.Error(t, err)
+}
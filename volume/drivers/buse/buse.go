@@ -1,12 +1,12 @@
 package buse
 
 import (
+	"crypto/tls"
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
 	"path"
 	"strings"
+	"sync"
 	"syscall"
 
 	"github.com/sirupsen/logrus"
@@ -40,8 +40,23 @@ type driver struct {
 	volume.CredsDriver
 	volume.CloudBackupDriver
 	volume.CloudMigrateDriver
+	cloudBackups common.CloudBackupProvider
+	// buseDevices are the connected devices by volume id
 	buseDevices map[string]*buseDev
-	cl          cluster.ClusterListener
+	devLock     sync.Mutex
+	// layerLock serializes the changes to the layers of the volumes
+	layerLock sync.Mutex
+	devices   *deviceStore
+	// remoteDevices are the NBD devices connected to the exports of volumes
+	// owned by other nodes, by volume id
+	remoteDevices map[string]*remoteDevice
+	// replicaFiles are the open replicas of volumes owned by other nodes
+	replicaFiles map[string]*replicaFile
+	// exports serves the volumes connected on this node to the other nodes
+	exports    *NBDServer
+	exportPort string
+	clientTLS  *tls.Config
+	cl         cluster.ClusterListener
 }
 
 type clusterListener struct {
@@ -50,44 +65,35 @@ type clusterListener struct {
 
 // Implements the Device interface.
 type buseDev struct {
-	file string
-	f    *os.File
-	nbd  *NBD
+	cow *cowDevice
+	nbd *NBD
+	// rep mirrors the writes to the replicas of the volume, if any
+	rep *replication
 }
 
 func (d *buseDev) ReadAt(b []byte, off int64) (n int, err error) {
-	return d.f.ReadAt(b, off)
+	return d.cow.ReadAt(b, off)
 }
 
 func (d *buseDev) WriteAt(b []byte, off int64) (n int, err error) {
-	return d.f.WriteAt(b, off)
-}
-
-func copyFile(source string, dest string) (err error) {
-	sourcefile, err := os.Open(source)
-	if err != nil {
-		return err
+	if d.rep != nil {
+		return d.rep.writeAt(d.cow, b, off)
 	}
+	return d.cow.WriteAt(b, off)
+}
 
-	defer sourcefile.Close()
-
-	destfile, err := os.Create(dest)
-	if err != nil {
-		return err
+func (d *buseDev) Sync() error {
+	if d.rep != nil {
+		return d.rep.sync(d.cow)
 	}
+	return d.cow.Sync()
+}
 
-	defer destfile.Close()
-
-	_, err = io.Copy(destfile, sourcefile)
-	if err == nil {
-		sourceinfo, err := os.Stat(source)
-		if err != nil {
-			err = os.Chmod(dest, sourceinfo.Mode())
-		}
-
+func (d *buseDev) Trim(off, length int64) error {
+	if d.rep != nil {
+		return d.rep.trim(d.cow, off, length)
 	}
-
-	return
+	return d.cow.Trim(off, length)
 }
 
 // Init intialized the buse driver
@@ -100,28 +106,32 @@ func Init(params map[string]string) (volume.VolumeDriver, error) {
 			kvdb.Instance()),
 		StatsDriver:        volume.StatsNotSupported,
 		QuiesceDriver:      volume.QuiesceNotSupported,
-		CredsDriver:        volume.CredsNotSupported,
-		CloudBackupDriver:  volume.CloudBackupNotSupported,
 		CloudMigrateDriver: volume.CloudMigrateNotSupported,
 	}
+	cloudBackups := common.NewCloudBackupProvider(Name, kvdb.Instance(), inst)
+	inst.cloudBackups = cloudBackups
+	inst.CredsDriver = cloudBackups
+	inst.CloudBackupDriver = cloudBackups
 	inst.buseDevices = make(map[string]*buseDev)
+	inst.remoteDevices = make(map[string]*remoteDevice)
+	inst.replicaFiles = make(map[string]*replicaFile)
 	if err := os.MkdirAll(BuseMountPath, 0744); err != nil {
 		return nil, err
 	}
-	volumeInfo, err := inst.StoreEnumerator.Enumerate(
-		&api.VolumeLocator{},
-		nil,
-	)
-	if err == nil {
-		for _, info := range volumeInfo {
-			if info.Status == api.VolumeStatus_VOLUME_STATUS_NONE {
-				info.Status = api.VolumeStatus_VOLUME_STATUS_UP
-				inst.UpdateVol(info)
-			}
-		}
-	} else {
+	devices, err := newDeviceStore(path.Join(BuseMountPath, buseDevicesFile))
+	if err != nil {
+		return nil, err
+	}
+	inst.devices = devices
+	if err := inst.reconnect(); err != nil {
 		logrus.Println("Could not enumerate Volumes, ", err)
 	}
+	if err := inst.startExports(params); err != nil {
+		return nil, err
+	}
+	if inst.exports != nil {
+		go inst.replicaLoop()
+	}
 
 	inst.cl = &clusterListener{}
 	c, err := clustermanager.Inst()
@@ -164,6 +174,8 @@ func (d *driver) Status() [][2]string {
 	return [][2]string{}
 }
 
+// Create creates a volume, or a clone of the volume given as the parent of
+// the source which shares the data of the parent until it is written.
 func (d *driver) Create(
 	locator *api.VolumeLocator,
 	source *api.Source,
@@ -177,44 +189,61 @@ func (d *driver) Create(
 	if spec.Format == api.FSType_FS_TYPE_NONE {
 		return "", fmt.Errorf("Missing volume format: buse")
 	}
-	// Create a file on the local buse path with this UUID.
-	buseFile := path.Join(BuseMountPath, volumeID)
-	f, err := os.Create(buseFile)
+
+	replicas, err := d.placeReplicas(spec)
 	if err != nil {
-		logrus.Println(err)
 		return "", err
 	}
 
-	if err := f.Truncate(int64(spec.Size)); err != nil {
-		logrus.Println(err)
-		return "", err
-	}
+	d.layerLock.Lock()
+	defer d.layerLock.Unlock()
 
-	bd := &buseDev{
-		file: buseFile,
-		f:    f,
+	var l *layer
+	if parent := source.GetParent(); len(parent) != 0 {
+		pm, ok := d.devices.get(parent)
+		if !ok {
+			return "", fmt.Errorf("Cannot locate a BUSE device for volume %s", parent)
+		}
+		if pm.Size != int64(spec.Size) {
+			return "", fmt.Errorf("Size of a clone must be the size of volume %s", parent)
+		}
+		var err error
+		if l, err = d.fork(parent, ""); err != nil {
+			return "", err
+		}
+	} else {
+		l = newLayer("", int64(spec.Size))
+		if err := l.create(); err != nil {
+			logrus.Println(err)
+			return "", err
+		}
 	}
-	nbd := Create(bd, volumeID, int64(spec.Size))
-	bd.nbd = nbd
 
-	logrus.Infof("Connecting to NBD...")
-	dev, err := bd.nbd.Connect()
+	// The mapping is saved before the volume so that the layers of a create
+	// interrupted by a crash are cleaned up on restart.
+	m := &deviceMapping{
+		VolumeID:  volumeID,
+		Layer:     l.ID,
+		Size:      int64(spec.Size),
+		Formatted: len(source.GetParent()) != 0,
+		Replicas:  replicas,
+	}
+	err = d.devices.update(func(st *deviceState) error {
+		st.Layers[l.ID] = l
+		st.Devices[volumeID] = m
+		return nil
+	})
 	if err != nil {
-		logrus.Println(err)
+		l.remove()
 		return "", err
 	}
-
-	logrus.Infof("Formatting %s with %v", dev, spec.Format)
-	cmd := "/sbin/mkfs." + spec.Format.SimpleString()
-	o, err := exec.Command(cmd, dev).Output()
+	bd, err := d.connect(m, spec.Format)
 	if err != nil {
-		logrus.Warnf("Failed to run command %v %v: %v", cmd, dev, o)
+		logrus.Println(err)
+		d.removeDevice(volumeID)
 		return "", err
 	}
 
-	logrus.Infof("BUSE mapped NBD device %s (size=%v) to block file %s", dev,
-		spec.Size, buseFile)
-
 	v := common.NewVolume(
 		volumeID,
 		spec.Format,
@@ -222,35 +251,168 @@ func (d *driver) Create(
 		source,
 		spec,
 	)
-	v.DevicePath = dev
-
-	d.buseDevices[dev] = bd
+	v.DevicePath = m.DevicePath
+	if nodeID := selfNodeID(); len(nodeID) != 0 {
+		v.ReplicaSets = []*api.ReplicaSet{{Nodes: append([]string{nodeID}, replicas...)}}
+	}
+	if bd.rep != nil {
+		// The replicas are created empty and get the data of the volume,
+		// including the data of the parent of a clone, by a resync.
+		if err := bd.rep.markRegions(bd.cow.regions(dirtyRegionSize)); err != nil {
+			bd.close()
+			d.removeDevice(volumeID)
+			return "", err
+		}
+		v.Status = bd.rep.status()
+	}
 
 	err = d.CreateVol(v)
 	if err != nil {
+		bd.close()
+		d.removeDevice(volumeID)
 		return "", err
 	}
+	if bd.rep != nil {
+		go bd.rep.resync(bd.cow)
+	}
 	return v.Id, err
 }
 
+// fork freezes the top layer of a volume, which becomes the parent of a new
+// top layer for the volume and of a new layer returned for a snapshot or a
+// clone. If target is set, the new layer replaces the top layer of the
+// target volume instead, which restores the target to the contents of the
+// volume.
+func (d *driver) fork(volumeID string, target string) (*layer, error) {
+	st := d.devices.snapshot()
+	m, ok := st.Devices[volumeID]
+	if !ok {
+		return nil, fmt.Errorf("Cannot locate a BUSE device for volume %s", volumeID)
+	}
+	if len(target) != 0 {
+		if _, ok := st.Devices[target]; !ok {
+			return nil, fmt.Errorf("Cannot locate a BUSE device for volume %s", target)
+		}
+	}
+
+	// The new chains read the allocation map of the frozen layer from disk.
+	if bd, ok := d.device(volumeID); ok {
+		if err := bd.cow.Sync(); err != nil {
+			return nil, err
+		}
+	}
+
+	top := newLayer(m.Layer, m.Size)
+	fork := newLayer(m.Layer, m.Size)
+	if err := top.create(); err != nil {
+		return nil, err
+	}
+	if err := fork.create(); err != nil {
+		top.remove()
+		return nil, err
+	}
+	tops := map[string]*layer{volumeID: top}
+	if len(target) != 0 {
+		tops[target] = fork
+	}
+
+	// Open the new layers of the connected devices before switching to them.
+	st.Layers[top.ID] = top
+	st.Layers[fork.ID] = fork
+	chains := make(map[string][]*layerFile)
+	abort := func() {
+		for _, chain := range chains {
+			for _, lf := range chain {
+				lf.close()
+			}
+		}
+		top.remove()
+		fork.remove()
+	}
+	for id, l := range tops {
+		if _, ok := d.device(id); !ok {
+			continue
+		}
+		chain, err := openChain(st.chain(l.ID))
+		if err != nil {
+			abort()
+			return nil, err
+		}
+		chains[id] = chain
+	}
+
+	var released []*layer
+	err := d.devices.update(func(st *deviceState) error {
+		st.Layers[top.ID] = top
+		st.Layers[fork.ID] = fork
+		for id, l := range tops {
+			prev := st.Devices[id].Layer
+			st.Devices[id].Layer = l.ID
+			released = append(released, st.release(prev)...)
+		}
+		return nil
+	})
+	if err != nil {
+		abort()
+		return nil, err
+	}
+	for id, chain := range chains {
+		bd, _ := d.device(id)
+		bd.cow.swap(chain)
+	}
+	removeLayers(released)
+
+	if len(target) != 0 {
+		return nil, nil
+	}
+	return fork, nil
+}
+
+func (d *driver) device(volumeID string) (*buseDev, bool) {
+	d.devLock.Lock()
+	defer d.devLock.Unlock()
+	bd, ok := d.buseDevices[volumeID]
+	return bd, ok
+}
+
+// removeDevice removes the mapping of a volume and the layers which are no
+// longer used.
+func (d *driver) removeDevice(volumeID string) {
+	d.devLock.Lock()
+	delete(d.buseDevices, volumeID)
+	d.devLock.Unlock()
+	released, err := d.devices.remove(volumeID)
+	if err != nil {
+		logrus.Warnf("Failed to remove the mapping of BUSE volume %s: %v", volumeID, err)
+		return
+	}
+	removeLayers(released)
+}
+
 func (d *driver) Delete(volumeID string) error {
 	v, err := d.GetVol(volumeID)
 	if err != nil {
 		logrus.Println(err)
 		return err
 	}
-
-	bd, ok := d.buseDevices[v.DevicePath]
-	if !ok {
-		err = fmt.Errorf("Cannot locate a BUSE device for %s", v.DevicePath)
-		logrus.Println(err)
-		return err
+	if owner := volumeOwner(v); len(owner) != 0 && owner != selfNodeID() {
+		return fmt.Errorf("Volume %s must be deleted on node %s", volumeID, owner)
 	}
 
-	// Clean up buse block file and close the NBD connection.
-	os.Remove(bd.file)
-	bd.f.Close()
-	bd.nbd.Disconnect()
+	d.layerLock.Lock()
+	defer d.layerLock.Unlock()
+
+	if bd, ok := d.device(volumeID); ok {
+		// Close the NBD connection.
+		bd.close()
+		if bd.rep != nil {
+			bd.rep.remove()
+		}
+	} else {
+		logrus.Warnf("Cannot locate a BUSE device for %s", v.DevicePath)
+	}
+	// Clean up the layers which are not shared with snapshots or clones.
+	d.removeDevice(volumeID)
 
 	logrus.Infof("BUSE deleted volume %v at NBD device %s", volumeID,
 		v.DevicePath)
@@ -272,14 +434,15 @@ func (d *driver) Mount(volumeID string, mountpath string, options map[string]str
 	if err != nil {
 		return fmt.Errorf("Failed to locate volume %q", volumeID)
 	}
-	if len(v.AttachPath) > 0 && len(v.AttachPath) > 0 {
+	if len(v.AttachPath) > 0 && len(v.AttachPath[0]) > 0 {
 		return fmt.Errorf("Volume %q already mounted at %q", volumeID, v.AttachPath[0])
 	}
-	if err := syscall.Mount(v.DevicePath, mountpath, v.Spec.Format.SimpleString(), 0, ""); err != nil {
-		return fmt.Errorf("Failed to mount %v at %v: %v", v.DevicePath, mountpath, err)
+	dev := attachedDevice(v)
+	if err := syscall.Mount(dev, mountpath, v.Spec.Format.SimpleString(), 0, ""); err != nil {
+		return fmt.Errorf("Failed to mount %v at %v: %v", dev, mountpath, err)
 	}
 
-	logrus.Infof("BUSE mounted NBD device %s at %s", v.DevicePath, mountpath)
+	logrus.Infof("BUSE mounted NBD device %s at %s", dev, mountpath)
 
 	if v.AttachPath == nil {
 		v.AttachPath = make([]string, 1)
@@ -303,37 +466,50 @@ func (d *driver) Unmount(volumeID string, mountpath string, options map[string]s
 	return d.UpdateVol(v)
 }
 
+// Snapshot creates a clone of the volume sharing its data. Snapshots are
+// instant since only the chunks written afterwards are copied.
 func (d *driver) Snapshot(volumeID string, readonly bool, locator *api.VolumeLocator, noRetry bool) (string, error) {
 	volIDs := make([]string, 1)
 	volIDs[0] = volumeID
 	vols, err := d.Inspect(volIDs)
 	if err != nil {
-		return "", nil
-	}
-
-	source := &api.Source{Parent: volumeID}
-	newVolumeID, err := d.Create(locator, source, vols[0].Spec)
-	if err != nil {
-		return "", nil
+		return "", err
 	}
-
-	// BUSE does not support snapshots, so just copy the block files.
-	err = copyFile(BuseMountPath+volumeID, BuseMountPath+newVolumeID)
-	if err != nil {
-		d.Delete(newVolumeID)
-		return "", nil
+	if len(vols) == 0 {
+		return "", fmt.Errorf("Failed to locate volume %q", volumeID)
 	}
 
-	return newVolumeID, nil
+	source := &api.Source{Parent: volumeID}
+	return d.Create(locator, source, vols[0].Spec)
 }
 
+// Restore replaces the top layer of the volume by a new layer on top of the
+// frozen layers of the snapshot.
 func (d *driver) Restore(volumeID string, snapID string) error {
-	if _, err := d.Inspect([]string{volumeID, snapID}); err != nil {
+	vols, err := d.Inspect([]string{volumeID, snapID})
+	if err != nil {
 		return err
 	}
+	for _, v := range vols {
+		if v.Id == volumeID && len(v.AttachPath) > 0 && len(v.AttachPath[0]) > 0 {
+			return fmt.Errorf("Volume %q must be unmounted to be restored", volumeID)
+		}
+	}
 
-	// BUSE does not support restore, so just copy the block files.
-	return copyFile(BuseMountPath+snapID, BuseMountPath+volumeID)
+	d.layerLock.Lock()
+	defer d.layerLock.Unlock()
+	if _, err = d.fork(snapID, volumeID); err != nil {
+		return err
+	}
+	// The replicas get the restored data by a full resync.
+	if bd, ok := d.device(volumeID); ok && bd.rep != nil {
+		if err := bd.rep.markAll(); err != nil {
+			return err
+		}
+		go d.updateReplicaStatus(volumeID)
+		go bd.rep.resync(bd.cow)
+	}
+	return nil
 }
 
 func (d *driver) SnapshotGroup(groupID string, labels map[string]string, volumeIDs []string) (*api.GroupSnapCreateResponse, error) {
@@ -355,18 +531,109 @@ func (d *driver) Set(volumeID string, locator *api.VolumeLocator, spec *api.Volu
 	return d.UpdateVol(v)
 }
 
+// Attach returns the NBD device of a volume owned by this node. A volume
+// owned by another node is attached through a local NBD device connected to
+// the export of the volume on its owner.
 func (d *driver) Attach(volumeID string, attachOptions map[string]string) (string, error) {
-	// Nothing to do on attach.
-	return path.Join(BuseMountPath, volumeID), nil
+	v, err := d.GetVol(volumeID)
+	if err != nil {
+		return "", err
+	}
+	nodeID := selfNodeID()
+	if len(v.AttachedOn) != 0 && v.AttachedOn != nodeID {
+		return "", fmt.Errorf("Volume %s is attached on node %s", volumeID, v.AttachedOn)
+	}
+
+	var dev string
+	if owner := volumeOwner(v); len(owner) == 0 || owner == nodeID {
+		if _, ok := d.device(volumeID); !ok || len(v.DevicePath) == 0 {
+			return "", fmt.Errorf("Volume %s is not connected to a NBD device", volumeID)
+		}
+		dev = v.DevicePath
+		v.AttachInfo = nil
+	} else {
+		var address string
+		if dev, address, err = d.attachRemote(v, owner); err != nil {
+			return "", err
+		}
+		v.AttachInfo = map[string]string{
+			AttachInfoExport: address,
+			AttachInfoDevice: dev,
+		}
+	}
+	v.AttachedOn = nodeID
+	v.State = api.VolumeState_VOLUME_STATE_ATTACHED
+	if err := d.UpdateVol(v); err != nil {
+		d.detachRemote(volumeID)
+		return "", err
+	}
+	return dev, nil
 }
 
+// Detach disconnects the NBD device of a volume owned by another node.
 func (d *driver) Detach(volumeID string, options map[string]string) error {
-	// Nothing to do on detach.
-	return nil
+	v, err := d.GetVol(volumeID)
+	if err != nil {
+		return err
+	}
+	if len(v.AttachPath) > 0 && len(v.AttachPath[0]) > 0 {
+		return fmt.Errorf("Volume %q must be unmounted to be detached", volumeID)
+	}
+	d.detachRemote(volumeID)
+	v.AttachedOn = ""
+	v.AttachInfo = nil
+	v.State = api.VolumeState_VOLUME_STATE_DETACHED
+	return d.UpdateVol(v)
+}
+
+// CloudBackupPath returns the NBD device of the volume.
+func (d *driver) CloudBackupPath(volumeID string) (string, error) {
+	v, err := d.GetVol(volumeID)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := d.device(volumeID); !ok || len(v.DevicePath) == 0 {
+		return "", fmt.Errorf("Volume %s is not connected to a NBD device", volumeID)
+	}
+	return v.DevicePath, nil
+}
+
+// CapacityUsage returns the bytes of the layers of a volume. The layers
+// only read by the volume are exclusive to it, while the others are shared
+// with its snapshots and clones.
+func (d *driver) CapacityUsage(volumeID string) (*api.CapacityUsageResponse, error) {
+	if _, err := d.GetVol(volumeID); err != nil {
+		return nil, err
+	}
+	st := d.devices.snapshot()
+	m, ok := st.Devices[volumeID]
+	if !ok {
+		return nil, fmt.Errorf("Cannot locate a BUSE device for volume %s", volumeID)
+	}
+
+	users := st.users()
+	info := &api.CapacityUsageInfo{}
+	for _, l := range st.chain(m.Layer) {
+		used, err := l.usage()
+		if err != nil {
+			return nil, err
+		}
+		if len(users[l.ID]) == 1 {
+			info.ExclusiveBytes += int64(used)
+		} else {
+			info.SharedBytes += int64(used)
+		}
+	}
+	info.TotalBytes = info.ExclusiveBytes + info.SharedBytes
+	return &api.CapacityUsageResponse{CapacityUsageInfo: info}, nil
 }
 
 func (d *driver) Shutdown() {
 	logrus.Printf("%s Shutting down", Name)
+	d.cloudBackups.Stop()
+	if d.exports != nil {
+		d.exports.Close()
+	}
 	syscall.Unmount(BuseMountPath, 0)
 }
 
@@ -0,0 +1,461 @@
+package buse
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"syscall"
+
+	"github.com/pborman/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// cowChunkSize is the unit of allocation of the layers. The first write
+	// to a chunk of a layer copies the chunk up from the layers below.
+	cowChunkSize = 64 * 1024
+	// layersDir is the directory under BuseMountPath holding the layers
+	layersDir = "layers"
+	// layerMapSuffix is the suffix of the allocation map of a layer
+	layerMapSuffix = ".map"
+)
+
+// layer is a sparse file holding the chunks written to a volume since its
+// parent layer was frozen. A layer with children is never written again, so
+// it can be shared by snapshots and clones. Its allocation map has a bit set
+// for each chunk held by the layer.
+type layer struct {
+	ID     string
+	Parent string `json:",omitempty"`
+	File   string
+	Size   int64
+	// Full is set on the block files of volumes created before copy on
+	// write, which hold all their chunks and have no allocation map.
+	Full bool `json:",omitempty"`
+}
+
+func newLayer(parent string, size int64) *layer {
+	id := uuid.New()
+	return &layer{
+		ID:     id,
+		Parent: parent,
+		File:   path.Join(BuseMountPath, layersDir, id),
+		Size:   size,
+	}
+}
+
+func (l *layer) mapFile() string {
+	return l.File + layerMapSuffix
+}
+
+func (l *layer) chunks() int64 {
+	return (l.Size + cowChunkSize - 1) / cowChunkSize
+}
+
+// create creates the empty data file and allocation map of the layer.
+func (l *layer) create() error {
+	if err := os.MkdirAll(path.Dir(l.File), 0744); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.File, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	err = f.Truncate(l.Size)
+	f.Close()
+	if err == nil {
+		err = ioutil.WriteFile(l.mapFile(), make([]byte, (l.chunks()+7)/8), 0644)
+	}
+	if err != nil {
+		l.remove()
+	}
+	return err
+}
+
+// remove deletes the files of the layer.
+func (l *layer) remove() {
+	os.Remove(l.File)
+	os.Remove(l.mapFile())
+}
+
+// usage returns the number of bytes held by the layer.
+func (l *layer) usage() (uint64, error) {
+	if l.Full {
+		return uint64(l.Size), nil
+	}
+	alloc, err := ioutil.ReadFile(l.mapFile())
+	if err != nil {
+		return 0, err
+	}
+	used := int64(0)
+	for chunk := int64(0); chunk < l.chunks(); chunk++ {
+		if allocated(alloc, chunk) {
+			used += chunkLength(l.Size, chunk)
+		}
+	}
+	return uint64(used), nil
+}
+
+func allocated(alloc []byte, chunk int64) bool {
+	return int(chunk/8) < len(alloc) && alloc[chunk/8]&(1<<uint(chunk%8)) != 0
+}
+
+func chunkLength(size, chunk int64) int64 {
+	if length := size - chunk*cowChunkSize; length < cowChunkSize {
+		return length
+	}
+	return cowChunkSize
+}
+
+// layerFile is an open layer.
+type layerFile struct {
+	layer *layer
+	data  *os.File
+	// mapf is the allocation map of the top layer of a device
+	mapf  *os.File
+	alloc []byte
+	// dirtyStart and dirtyEnd are the range of the bytes of alloc changed
+	// since the map was last flushed
+	dirtyStart, dirtyEnd int64
+}
+
+func openLayer(l *layer, writable bool) (*layerFile, error) {
+	flags := os.O_RDONLY
+	if writable {
+		flags = os.O_RDWR
+	}
+	data, err := os.OpenFile(l.File, flags, 0)
+	if err != nil {
+		return nil, err
+	}
+	lf := &layerFile{
+		layer: l,
+		data:  data,
+	}
+	if l.Full {
+		return lf, nil
+	}
+	if lf.alloc, err = ioutil.ReadFile(l.mapFile()); err != nil {
+		data.Close()
+		return nil, err
+	}
+	if writable {
+		if lf.mapf, err = os.OpenFile(l.mapFile(), os.O_RDWR, 0); err != nil {
+			data.Close()
+			return nil, err
+		}
+	}
+	return lf, nil
+}
+
+func (lf *layerFile) allocated(chunk int64) bool {
+	return lf.layer.Full || allocated(lf.alloc, chunk)
+}
+
+// allocate marks a chunk as held by the layer. The allocation map is only
+// written by flush, once the data of the chunk is on disk.
+func (lf *layerFile) allocate(chunk int64) {
+	lf.alloc[chunk/8] |= 1 << uint(chunk%8)
+	lf.markDirty(chunk / 8)
+}
+
+// deallocate marks a chunk as no longer held by the layer.
+func (lf *layerFile) deallocate(chunk int64) {
+	lf.alloc[chunk/8] &^= 1 << uint(chunk%8)
+	lf.markDirty(chunk / 8)
+}
+
+func (lf *layerFile) markDirty(i int64) {
+	if lf.dirtyStart == lf.dirtyEnd {
+		lf.dirtyStart, lf.dirtyEnd = i, i+1
+		return
+	}
+	if i < lf.dirtyStart {
+		lf.dirtyStart = i
+	}
+	if i >= lf.dirtyEnd {
+		lf.dirtyEnd = i + 1
+	}
+}
+
+// flush syncs the data of the layer, then writes and syncs the changes of
+// its allocation map, so that the map never points to chunks whose data was
+// lost. A single sync of the data covers all the chunks allocated since the
+// last flush.
+func (lf *layerFile) flush() error {
+	if err := lf.data.Sync(); err != nil {
+		return err
+	}
+	if lf.mapf == nil {
+		return nil
+	}
+	if lf.dirtyStart != lf.dirtyEnd {
+		_, err := lf.mapf.WriteAt(lf.alloc[lf.dirtyStart:lf.dirtyEnd], lf.dirtyStart)
+		if err != nil {
+			return err
+		}
+		lf.dirtyStart, lf.dirtyEnd = 0, 0
+	}
+	return lf.mapf.Sync()
+}
+
+func (lf *layerFile) close() {
+	if lf.mapf != nil {
+		if err := lf.flush(); err != nil {
+			logrus.Warnf("Failed to flush layer %s: %v", lf.layer.ID, err)
+		}
+		lf.mapf.Close()
+	}
+	lf.data.Close()
+}
+
+// cowDevice is a block device reading through a chain of layers and writing
+// to the top layer.
+type cowDevice struct {
+	sync.Mutex
+	size int64
+	// chain is the list of layers from the top layer to the base layer
+	chain []*layerFile
+}
+
+// openCowDevice opens the chain of layers, the first being the top layer.
+func openCowDevice(chain []*layer) (*cowDevice, error) {
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("Device has no layers")
+	}
+	files, err := openChain(chain)
+	if err != nil {
+		return nil, err
+	}
+	return &cowDevice{
+		size:  chain[0].Size,
+		chain: files,
+	}, nil
+}
+
+func openChain(chain []*layer) ([]*layerFile, error) {
+	files := make([]*layerFile, 0, len(chain))
+	for i, l := range chain {
+		lf, err := openLayer(l, i == 0)
+		if err != nil {
+			for _, lf := range files {
+				lf.close()
+			}
+			return nil, err
+		}
+		files = append(files, lf)
+	}
+	return files, nil
+}
+
+// swap replaces the layers of the device. The new chain gets the allocation
+// map of the current top layer if it holds it, since the chunks allocated
+// after the new chain was opened are not in the map it read.
+func (c *cowDevice) swap(chain []*layerFile) {
+	c.Lock()
+	defer c.Unlock()
+	if len(c.chain) != 0 {
+		top := c.chain[0]
+		for _, lf := range chain {
+			if lf.layer.ID == top.layer.ID && !lf.layer.Full {
+				lf.alloc = append([]byte{}, top.alloc...)
+			}
+		}
+	}
+	for _, lf := range c.chain {
+		lf.close()
+	}
+	c.chain = chain
+}
+
+func (c *cowDevice) close() {
+	c.swap(nil)
+}
+
+// ReadAt reads each chunk from the topmost layer holding it. Chunks which
+// were never written read as zeros.
+func (c *cowDevice) ReadAt(b []byte, off int64) (int, error) {
+	c.Lock()
+	defer c.Unlock()
+	return c.readAt(c.chain, b, off)
+}
+
+func (c *cowDevice) readAt(chain []*layerFile, b []byte, off int64) (int, error) {
+	done := 0
+	for done < len(b) {
+		pos := off + int64(done)
+		if pos >= c.size {
+			return done, io.EOF
+		}
+		chunk := pos / cowChunkSize
+		n := int(cowChunkSize - pos%cowChunkSize)
+		if n > len(b)-done {
+			n = len(b) - done
+		}
+		if rest := c.size - pos; int64(n) > rest {
+			n = int(rest)
+		}
+		buf := b[done : done+n]
+
+		found := false
+		for _, lf := range chain {
+			if !lf.allocated(chunk) {
+				continue
+			}
+			if _, err := lf.data.ReadAt(buf, pos); err != nil && err != io.EOF {
+				return done, err
+			}
+			found = true
+			break
+		}
+		if !found {
+			for i := range buf {
+				buf[i] = 0
+			}
+		}
+		done += n
+	}
+	return done, nil
+}
+
+// WriteAt writes to the top layer, first copying up the chunks which are
+// not yet held by it.
+func (c *cowDevice) WriteAt(b []byte, off int64) (int, error) {
+	c.Lock()
+	defer c.Unlock()
+	if len(c.chain) == 0 {
+		return 0, fmt.Errorf("Device is closed")
+	}
+	top := c.chain[0]
+	done := 0
+	for done < len(b) {
+		pos := off + int64(done)
+		if pos >= c.size {
+			return done, io.ErrShortWrite
+		}
+		chunk := pos / cowChunkSize
+		start := chunk * cowChunkSize
+		n := int(start + cowChunkSize - pos)
+		if n > len(b)-done {
+			n = len(b) - done
+		}
+		if rest := c.size - pos; int64(n) > rest {
+			n = int(rest)
+		}
+
+		if top.allocated(chunk) {
+			if _, err := top.data.WriteAt(b[done:done+n], pos); err != nil {
+				return done, err
+			}
+		} else {
+			buf := make([]byte, chunkLength(c.size, chunk))
+			if _, err := c.readAt(c.chain[1:], buf, start); err != nil && err != io.EOF {
+				return done, err
+			}
+			copy(buf[pos-start:], b[done:done+n])
+			if _, err := top.data.WriteAt(buf, start); err != nil {
+				return done, err
+			}
+			top.allocate(chunk)
+		}
+		done += n
+	}
+	return done, nil
+}
+
+// Sync flushes the top layer to disk.
+func (c *cowDevice) Sync() error {
+	c.Lock()
+	defer c.Unlock()
+	if len(c.chain) == 0 {
+		return fmt.Errorf("Device is closed")
+	}
+	return c.chain[0].flush()
+}
+
+// Trim discards the chunks entirely within a range which are only held by
+// the top layer. Chunks also held by the layers below are kept, so that a
+// trimmed range never reads the older data of a snapshot.
+func (c *cowDevice) Trim(off, length int64) error {
+	_, err := c.trim(off, length)
+	return err
+}
+
+// trim discards the chunks of a range and returns the discarded ranges as
+// offset and length pairs.
+func (c *cowDevice) trim(off, length int64) ([][2]int64, error) {
+	c.Lock()
+	defer c.Unlock()
+	if len(c.chain) == 0 {
+		return nil, fmt.Errorf("Device is closed")
+	}
+	top := c.chain[0]
+	if top.layer.Full {
+		return nil, nil
+	}
+	end := off + length
+	if end > c.size {
+		end = c.size
+	}
+	var discarded [][2]int64
+	for chunk := (off + cowChunkSize - 1) / cowChunkSize; ; chunk++ {
+		start := chunk * cowChunkSize
+		if start >= end || start+chunkLength(c.size, chunk) > end {
+			break
+		}
+		if !top.allocated(chunk) || c.heldBelow(chunk) {
+			continue
+		}
+		top.deallocate(chunk)
+		if err := punchHole(top.data, start, chunkLength(c.size, chunk)); err != nil {
+			logrus.Warnf("Failed to free chunk %d of layer %s: %v", chunk, top.layer.ID, err)
+		}
+		if n := len(discarded); n > 0 && discarded[n-1][0]+discarded[n-1][1] == start {
+			discarded[n-1][1] += chunkLength(c.size, chunk)
+		} else {
+			discarded = append(discarded, [2]int64{start, chunkLength(c.size, chunk)})
+		}
+	}
+	return discarded, nil
+}
+
+// regions returns the regions of a size holding chunks in any layer of the
+// device.
+func (c *cowDevice) regions(regionSize int64) map[int64]bool {
+	c.Lock()
+	defer c.Unlock()
+	regions := make(map[int64]bool)
+	for chunk := int64(0); chunk*cowChunkSize < c.size; chunk++ {
+		for _, lf := range c.chain {
+			if lf.allocated(chunk) {
+				regions[chunk*cowChunkSize/regionSize] = true
+				break
+			}
+		}
+	}
+	return regions
+}
+
+func (c *cowDevice) heldBelow(chunk int64) bool {
+	for _, lf := range c.chain[1:] {
+		if lf.allocated(chunk) {
+			return true
+		}
+	}
+	return false
+}
+
+// punchHole frees the blocks of a range of a file. The blocks are kept if
+// the file system does not support it, which is not an error since the
+// chunk is no longer read from the layer.
+func punchHole(f *os.File, off, length int64) error {
+	const fallocPunchHole = 0x01 | 0x02 // FALLOC_FL_KEEP_SIZE | FALLOC_FL_PUNCH_HOLE
+	err := syscall.Fallocate(int(f.Fd()), fallocPunchHole, off, length)
+	if err == syscall.EOPNOTSUPP {
+		return nil
+	}
+	return err
+}
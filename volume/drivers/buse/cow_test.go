@@ -0,0 +1,168 @@
+package buse
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLayer(t *testing.T, dir, id, parent string, size int64) *layer {
+	l := &layer{
+		ID:     id,
+		Parent: parent,
+		File:   filepath.Join(dir, id),
+		Size:   size,
+	}
+	require.NoError(t, l.create())
+	return l
+}
+
+func readAll(t *testing.T, c *cowDevice) []byte {
+	buf := make([]byte, c.size)
+	n, err := c.ReadAt(buf, 0)
+	require.NoError(t, err)
+	require.Equal(t, len(buf), n)
+	return buf
+}
+
+func TestCowDevice(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buse-cow")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// The last chunk is partial
+	size := int64(3*cowChunkSize + 100)
+	st := newDeviceState()
+	base := testLayer(t, dir, "base", "", size)
+	st.Layers[base.ID] = base
+	st.Devices["vol"] = &deviceMapping{VolumeID: "vol", Layer: base.ID}
+
+	c, err := openCowDevice(st.chain(base.ID))
+	require.NoError(t, err)
+	assert.Equal(t, make([]byte, size), readAll(t, c))
+
+	// Write across the first two chunks and into the last one
+	expected := make([]byte, size)
+	first := bytes.Repeat([]byte{1}, cowChunkSize)
+	copy(expected[100:], first)
+	_, err = c.WriteAt(first, 100)
+	require.NoError(t, err)
+	_, err = c.WriteAt([]byte{2, 2}, size-2)
+	require.NoError(t, err)
+	copy(expected[size-2:], []byte{2, 2})
+	assert.Equal(t, expected, readAll(t, c))
+	// The allocation map is written by Sync
+	used, err := base.usage()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), used)
+	require.NoError(t, c.Sync())
+	used, err = base.usage()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2*cowChunkSize+100), used)
+
+	// Writes beyond the device fail
+	_, err = c.WriteAt([]byte{1, 1}, size-1)
+	assert.Error(t, err)
+	expected[size-1] = 1
+	c.close()
+
+	// Snapshot: the base layer is frozen and shared by two new layers
+	top := testLayer(t, dir, "top", base.ID, size)
+	snap := testLayer(t, dir, "snap", base.ID, size)
+	st.Layers[top.ID] = top
+	st.Layers[snap.ID] = snap
+	st.Devices["vol"].Layer = top.ID
+	st.Devices["snap"] = &deviceMapping{VolumeID: "snap", Layer: snap.ID}
+
+	vol, err := openCowDevice(st.chain(top.ID))
+	require.NoError(t, err)
+	defer vol.close()
+	snapshot, err := openCowDevice(st.chain(snap.ID))
+	require.NoError(t, err)
+	defer snapshot.close()
+	assert.Equal(t, expected, readAll(t, vol))
+
+	// Writes to the volume are copied up, leaving the snapshot unchanged
+	before := append([]byte{}, expected...)
+	_, err = vol.WriteAt([]byte{3, 3, 3}, cowChunkSize-1)
+	require.NoError(t, err)
+	copy(expected[cowChunkSize-1:], []byte{3, 3, 3})
+	assert.Equal(t, expected, readAll(t, vol))
+	assert.Equal(t, before, readAll(t, snapshot))
+	require.NoError(t, vol.Sync())
+	used, err = top.usage()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2*cowChunkSize), used)
+	used, err = snap.usage()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), used)
+
+	// The allocation map survives reopening the layers
+	reopened, err := openCowDevice(st.chain(top.ID))
+	require.NoError(t, err)
+	assert.Equal(t, expected, readAll(t, reopened))
+	reopened.close()
+
+	users := st.users()
+	assert.Len(t, users[base.ID], 2)
+	assert.Equal(t, []string{"vol"}, users[top.ID])
+
+	// Restore: the volume switches to a new layer on top of the snapshot
+	restored := testLayer(t, dir, "restored", snap.ID, size)
+	snapTop := testLayer(t, dir, "snaptop", snap.ID, size)
+	st.Layers[restored.ID] = restored
+	st.Layers[snapTop.ID] = snapTop
+	st.Devices["snap"].Layer = snapTop.ID
+	st.Devices["vol"].Layer = restored.ID
+	released := st.release(top.ID)
+	require.Len(t, released, 1)
+	assert.Equal(t, top.ID, released[0].ID)
+	chain, err := openChain(st.chain(restored.ID))
+	require.NoError(t, err)
+	vol.swap(chain)
+	assert.Equal(t, before, readAll(t, vol))
+
+	// Deleting the snapshot keeps the layers read by the volume
+	delete(st.Devices, "snap")
+	released = st.release(snapTop.ID)
+	require.Len(t, released, 1)
+	assert.Equal(t, snapTop.ID, released[0].ID)
+	assert.Len(t, st.chain(restored.ID), 3)
+}
+
+func TestCowDeviceSwapKeepsAllocations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buse-cow")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	size := int64(2 * cowChunkSize)
+	st := newDeviceState()
+	base := testLayer(t, dir, "base", "", size)
+	top := testLayer(t, dir, "top", base.ID, size)
+	st.Layers[base.ID] = base
+	st.Layers[top.ID] = top
+	c, err := openCowDevice(st.chain(base.ID))
+	require.NoError(t, err)
+	defer c.close()
+
+	// A chunk written after the new chain read the map of the frozen layer
+	// is still read through the new chain
+	chain, err := openChain(st.chain(top.ID))
+	require.NoError(t, err)
+	_, err = c.WriteAt([]byte{1}, cowChunkSize)
+	require.NoError(t, err)
+	c.swap(chain)
+	expected := make([]byte, size)
+	expected[cowChunkSize] = 1
+	assert.Equal(t, expected, readAll(t, c))
+
+	// The frozen layer was flushed when it was closed
+	used, err := base.usage()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(cowChunkSize), used)
+}
@@ -0,0 +1,511 @@
+package buse
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/libopenstorage/openstorage/api"
+)
+
+const (
+	// buseDevicesFile is the file under BuseMountPath recording the layers
+	// and the NBD device of each volume.
+	buseDevicesFile = "devices.json"
+	// deviceStateVersion is the version of the format of buseDevicesFile
+	deviceStateVersion = 1
+)
+
+// deviceMapping records the top layer of a volume and the NBD device it is
+// connected to.
+type deviceMapping struct {
+	VolumeID string
+	// File is the block file of volumes created before copy on write
+	File       string `json:",omitempty"`
+	Layer      string
+	DevicePath string
+	Size       int64
+	// Formatted is set once the file system has been created on the device,
+	// so that a volume is formatted when first created and never when its
+	// device is reconnected.
+	Formatted bool
+	// Replicas are the nodes holding the replicas of the volume
+	Replicas []string `json:",omitempty"`
+}
+
+// deviceState is the persisted state of the devices and layers of a node.
+type deviceState struct {
+	Version int
+	Devices map[string]*deviceMapping
+	Layers  map[string]*layer
+}
+
+func newDeviceState() *deviceState {
+	return &deviceState{
+		Version: deviceStateVersion,
+		Devices: make(map[string]*deviceMapping),
+		Layers:  make(map[string]*layer),
+	}
+}
+
+func (st *deviceState) copy() *deviceState {
+	c := newDeviceState()
+	for id, m := range st.Devices {
+		copy := *m
+		copy.Replicas = append([]string(nil), m.Replicas...)
+		c.Devices[id] = &copy
+	}
+	for id, l := range st.Layers {
+		copy := *l
+		c.Layers[id] = &copy
+	}
+	return c
+}
+
+// chain returns the layers from a layer down to its base layer.
+func (st *deviceState) chain(layerID string) []*layer {
+	chain := make([]*layer, 0)
+	for id := layerID; len(id) != 0; {
+		l, ok := st.Layers[id]
+		if !ok {
+			break
+		}
+		chain = append(chain, l)
+		id = l.Parent
+	}
+	return chain
+}
+
+// referenced returns true if a layer is the top layer of a device or the
+// parent of another layer.
+func (st *deviceState) referenced(layerID string) bool {
+	for _, m := range st.Devices {
+		if m.Layer == layerID {
+			return true
+		}
+	}
+	for _, l := range st.Layers {
+		if l.Parent == layerID {
+			return true
+		}
+	}
+	return false
+}
+
+// release removes a layer and its parents which are no longer referenced
+// and returns the removed layers, whose files can be deleted once the state
+// is saved.
+func (st *deviceState) release(layerID string) []*layer {
+	released := make([]*layer, 0)
+	for id := layerID; len(id) != 0 && !st.referenced(id); {
+		l, ok := st.Layers[id]
+		if !ok {
+			break
+		}
+		delete(st.Layers, id)
+		released = append(released, l)
+		id = l.Parent
+	}
+	return released
+}
+
+// users returns the volumes reading from each layer.
+func (st *deviceState) users() map[string][]string {
+	users := make(map[string][]string)
+	for volumeID, m := range st.Devices {
+		for _, l := range st.chain(m.Layer) {
+			users[l.ID] = append(users[l.ID], volumeID)
+		}
+	}
+	return users
+}
+
+// deviceStore persists the devices and layers of this node in a local file.
+// The file is replaced atomically so that it survives crashes.
+type deviceStore struct {
+	sync.Mutex
+	path  string
+	state *deviceState
+}
+
+// newDeviceStore loads the state saved at p. The block files of volumes
+// created before copy on write become their base layers.
+func newDeviceStore(p string) (*deviceStore, error) {
+	s := &deviceStore{
+		path:  p,
+		state: newDeviceState(),
+	}
+	data, err := ioutil.ReadFile(p)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	state := newDeviceState()
+	state.Version = 0
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("Failed to load BUSE device mappings from %s: %v", p, err)
+	}
+	if state.Version == 0 {
+		// Device mappings by volume id, saved before copy on write
+		state = newDeviceState()
+		if err := json.Unmarshal(data, &state.Devices); err != nil {
+			return nil, fmt.Errorf("Failed to load BUSE device mappings from %s: %v", p, err)
+		}
+	}
+	for _, m := range state.Devices {
+		if len(m.Layer) == 0 && len(m.File) != 0 {
+			state.Layers[m.VolumeID] = &layer{
+				ID:   m.VolumeID,
+				File: m.File,
+				Size: m.Size,
+				Full: true,
+			}
+			m.Layer = m.VolumeID
+		}
+	}
+	s.state = state
+	return s, nil
+}
+
+// get returns a copy of the mapping of a volume.
+func (s *deviceStore) get(volumeID string) (*deviceMapping, bool) {
+	s.Lock()
+	defer s.Unlock()
+	m, ok := s.state.Devices[volumeID]
+	if !ok {
+		return nil, false
+	}
+	copy := *m
+	return &copy, true
+}
+
+// list returns a copy of all the mappings.
+func (s *deviceStore) list() []*deviceMapping {
+	s.Lock()
+	defer s.Unlock()
+	mappings := make([]*deviceMapping, 0, len(s.state.Devices))
+	for _, m := range s.state.Devices {
+		copy := *m
+		mappings = append(mappings, &copy)
+	}
+	return mappings
+}
+
+// snapshot returns a copy of the state.
+func (s *deviceStore) snapshot() *deviceState {
+	s.Lock()
+	defer s.Unlock()
+	return s.state.copy()
+}
+
+// update applies changes to a copy of the state and saves it. The state is
+// left unchanged if fn fails or the state cannot be saved.
+func (s *deviceStore) update(fn func(st *deviceState) error) error {
+	s.Lock()
+	defer s.Unlock()
+	st := s.state.copy()
+	if err := fn(st); err != nil {
+		return err
+	}
+	if err := s.save(st); err != nil {
+		return err
+	}
+	s.state = st
+	return nil
+}
+
+// put saves the mapping of a volume.
+func (s *deviceStore) put(m *deviceMapping) error {
+	return s.update(func(st *deviceState) error {
+		copy := *m
+		st.Devices[m.VolumeID] = &copy
+		return nil
+	})
+}
+
+// remove deletes the mapping of a volume and releases its layers, returning
+// the layers which are no longer used.
+func (s *deviceStore) remove(volumeID string) ([]*layer, error) {
+	var released []*layer
+	err := s.update(func(st *deviceState) error {
+		m, ok := st.Devices[volumeID]
+		if !ok {
+			return nil
+		}
+		delete(st.Devices, volumeID)
+		released = st.release(m.Layer)
+		return nil
+	})
+	return released, err
+}
+
+func (s *deviceStore) save(st *deviceState) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	if dir, err := os.Open(filepath.Dir(s.path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+// connect opens the layers of a mapping and connects them to a free NBD
+// device, creating the file system on the device if it was never formatted.
+// The mapping is updated with the device path and saved.
+func (d *driver) connect(m *deviceMapping, format api.FSType) (*buseDev, error) {
+	chain := d.devices.snapshot().chain(m.Layer)
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("Volume %s has no layers", m.VolumeID)
+	}
+	cow, err := openCowDevice(chain)
+	if err != nil {
+		return nil, err
+	}
+	bd := &buseDev{
+		cow: cow,
+	}
+	if len(m.Replicas) != 0 {
+		volumeID := m.VolumeID
+		bd.rep, err = newReplication(
+			path.Join(BuseMountPath, dirtyDir, volumeID), volumeID, m.Size, m.Replicas,
+			func(node string) (*NBDClient, error) {
+				return d.dialReplica(node, volumeID)
+			},
+			func() {
+				go d.updateReplicaStatus(volumeID)
+			})
+		if err != nil {
+			cow.close()
+			return nil, err
+		}
+	}
+	bd.nbd = Create(bd, m.VolumeID, m.Size)
+	if bd.nbd == nil {
+		bd.closeReplicas()
+		cow.close()
+		return nil, fmt.Errorf("Failed to create NBD device for volume %s", m.VolumeID)
+	}
+
+	logrus.Infof("Connecting to NBD...")
+	dev, err := bd.nbd.Connect()
+	if err != nil {
+		bd.closeReplicas()
+		cow.close()
+		return nil, err
+	}
+
+	if !m.Formatted {
+		if err := formatDevice(dev, format); err != nil {
+			bd.close()
+			return nil, err
+		}
+		m.Formatted = true
+	}
+	m.DevicePath = dev
+	if err := d.devices.put(m); err != nil {
+		bd.close()
+		return nil, err
+	}
+
+	d.devLock.Lock()
+	d.buseDevices[m.VolumeID] = bd
+	d.devLock.Unlock()
+	logrus.Infof("BUSE mapped NBD device %s (size=%v) to layer %s", dev,
+		m.Size, m.Layer)
+	return bd, nil
+}
+
+// close disconnects the NBD device and the replicas and closes the layers.
+func (bd *buseDev) close() {
+	bd.nbd.Disconnect()
+	bd.closeReplicas()
+	bd.cow.close()
+}
+
+func (bd *buseDev) closeReplicas() {
+	if bd.rep != nil {
+		bd.rep.close()
+	}
+}
+
+func formatDevice(dev string, format api.FSType) error {
+	logrus.Infof("Formatting %s with %v", dev, format)
+	cmd := "/sbin/mkfs." + format.SimpleString()
+	o, err := exec.Command(cmd, dev).Output()
+	if err != nil {
+		logrus.Warnf("Failed to run command %v %v: %v", cmd, dev, o)
+		return err
+	}
+	return nil
+}
+
+// removeLayers deletes the files of the released layers.
+func removeLayers(released []*layer) {
+	for _, l := range released {
+		logrus.Infof("Removing BUSE layer %s", l.ID)
+		l.remove()
+	}
+}
+
+// reconnect re-establishes the NBD devices of the volumes after a restart.
+// The device paths recorded in the volumes are repaired since devices may
+// be assigned differently, and volumes which were mounted are mounted
+// again. Volumes whose layers are lost are marked down.
+func (d *driver) reconnect() error {
+	vols, err := d.StoreEnumerator.Enumerate(&api.VolumeLocator{}, nil)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]bool)
+	nodeID := selfNodeID()
+	for _, v := range vols {
+		known[v.Id] = true
+		if owner := volumeOwner(v); len(owner) != 0 && owner != nodeID {
+			// Volumes owned by other nodes are attached through their exports.
+			if v.AttachedOn == nodeID && len(nodeID) != 0 {
+				v.AttachedOn = ""
+				v.AttachInfo = nil
+				v.AttachPath = nil
+				v.State = api.VolumeState_VOLUME_STATE_DETACHED
+				if err := d.UpdateVol(v); err != nil {
+					logrus.Warnf("Failed to update BUSE volume %s: %v", v.Id, err)
+				}
+			}
+			continue
+		}
+		if err := d.reconnectVolume(v); err != nil {
+			logrus.Warnf("Failed to reconnect BUSE volume %s: %v", v.Id, err)
+			v.Status = api.VolumeStatus_VOLUME_STATUS_DOWN
+		} else if bd, ok := d.device(v.Id); ok && bd.rep != nil {
+			// The replicas are connected by the first resync.
+			v.Status = api.VolumeStatus_VOLUME_STATUS_DEGRADED
+		} else {
+			v.Status = api.VolumeStatus_VOLUME_STATUS_UP
+		}
+		if err := d.UpdateVol(v); err != nil {
+			logrus.Warnf("Failed to update BUSE volume %s: %v", v.Id, err)
+		}
+	}
+
+	// Mappings without a volume and unused layers were left by operations
+	// interrupted by a crash.
+	for _, m := range d.devices.list() {
+		if known[m.VolumeID] {
+			continue
+		}
+		logrus.Infof("Removing incomplete BUSE volume %s", m.VolumeID)
+		released, err := d.devices.remove(m.VolumeID)
+		if err != nil {
+			logrus.Warnf("Failed to remove the mapping of BUSE volume %s: %v", m.VolumeID, err)
+			continue
+		}
+		removeLayers(released)
+	}
+	var released []*layer
+	err = d.devices.update(func(st *deviceState) error {
+		for id := range st.Layers {
+			released = append(released, st.release(id)...)
+		}
+		return nil
+	})
+	if err != nil {
+		logrus.Warnf("Failed to release unused BUSE layers: %v", err)
+		return nil
+	}
+	removeLayers(released)
+
+	st := d.devices.snapshot()
+	files, _ := ioutil.ReadDir(path.Join(BuseMountPath, layersDir))
+	for _, f := range files {
+		id := strings.TrimSuffix(f.Name(), layerMapSuffix)
+		if _, ok := st.Layers[id]; !ok {
+			logrus.Infof("Removing unknown BUSE layer file %s", f.Name())
+			os.Remove(path.Join(BuseMountPath, layersDir, f.Name()))
+		}
+	}
+	return nil
+}
+
+func (d *driver) reconnectVolume(v *api.Volume) error {
+	m, ok := d.devices.get(v.Id)
+	if !ok {
+		// Volumes created before the mappings were saved are formatted and
+		// their block file becomes their base layer.
+		m = &deviceMapping{
+			VolumeID:  v.Id,
+			File:      path.Join(BuseMountPath, v.Id),
+			Layer:     v.Id,
+			Size:      int64(v.GetSpec().GetSize()),
+			Formatted: true,
+		}
+		if _, err := os.Stat(m.File); err != nil {
+			return fmt.Errorf("Block file %s is not available: %v", m.File, err)
+		}
+		err := d.devices.update(func(st *deviceState) error {
+			st.Layers[m.Layer] = &layer{
+				ID:   m.Layer,
+				File: m.File,
+				Size: m.Size,
+				Full: true,
+			}
+			st.Devices[m.VolumeID] = m
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := d.connect(m, v.GetSpec().GetFormat()); err != nil {
+		return err
+	}
+
+	if v.DevicePath != m.DevicePath {
+		logrus.Infof("BUSE volume %s moved from stale NBD device %s to %s",
+			v.Id, v.DevicePath, m.DevicePath)
+		v.DevicePath = m.DevicePath
+	}
+	if len(v.AttachPath) > 0 && len(v.AttachPath[0]) > 0 {
+		mountpath := v.AttachPath[0]
+		syscall.Unmount(mountpath, syscall.MNT_DETACH)
+		err := syscall.Mount(m.DevicePath, mountpath, v.GetSpec().GetFormat().SimpleString(), 0, "")
+		if err != nil {
+			logrus.Warnf("Failed to mount %v at %v again: %v", m.DevicePath, mountpath, err)
+			v.AttachPath = nil
+		} else {
+			logrus.Infof("BUSE mounted NBD device %s at %s again", m.DevicePath, mountpath)
+		}
+	}
+	return nil
+}
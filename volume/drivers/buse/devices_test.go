@@ -0,0 +1,110 @@
+package buse
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buse")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, buseDevicesFile)
+
+	s, err := newDeviceStore(p)
+	require.NoError(t, err)
+	assert.Empty(t, s.list())
+
+	m := &deviceMapping{
+		VolumeID:   "vol1",
+		Layer:      "base",
+		DevicePath: "/dev/nbd0",
+		Size:       1024,
+		Formatted:  true,
+	}
+	require.NoError(t, s.update(func(st *deviceState) error {
+		st.Layers["base"] = &layer{ID: "base", Size: 1024}
+		st.Layers["top"] = &layer{ID: "top", Parent: "base", Size: 1024}
+		st.Devices["vol1"] = m
+		return nil
+	}))
+	require.NoError(t, s.put(&deviceMapping{VolumeID: "vol2", Layer: "top"}))
+
+	// Mappings are copied
+	m.DevicePath = "/dev/nbd1"
+	got, ok := s.get("vol1")
+	require.True(t, ok)
+	assert.Equal(t, "/dev/nbd0", got.DevicePath)
+
+	// Mappings survive a restart
+	s, err = newDeviceStore(p)
+	require.NoError(t, err)
+	assert.Len(t, s.list(), 2)
+	got, ok = s.get("vol1")
+	require.True(t, ok)
+	assert.Equal(t, int64(1024), got.Size)
+	assert.True(t, got.Formatted)
+	assert.Len(t, s.snapshot().chain("top"), 2)
+
+	// Layers are released once unused
+	released, err := s.remove("vol2")
+	require.NoError(t, err)
+	require.Len(t, released, 1)
+	assert.Equal(t, "top", released[0].ID)
+	released, err = s.remove("vol1")
+	require.NoError(t, err)
+	require.Len(t, released, 1)
+	assert.Equal(t, "base", released[0].ID)
+	s, err = newDeviceStore(p)
+	require.NoError(t, err)
+	assert.Empty(t, s.list())
+	assert.Empty(t, s.snapshot().Layers)
+
+	// Failed updates leave the state unchanged
+	assert.Error(t, s.update(func(st *deviceState) error {
+		st.Devices["vol3"] = &deviceMapping{VolumeID: "vol3"}
+		return os.ErrInvalid
+	}))
+	_, ok = s.get("vol3")
+	assert.False(t, ok)
+
+	// A corrupted file is reported
+	require.NoError(t, ioutil.WriteFile(p, []byte("{"), 0644))
+	_, err = newDeviceStore(p)
+	assert.Error(t, err)
+}
+
+func TestDeviceStoreUpgrade(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buse")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, buseDevicesFile)
+
+	// Block files of volumes created before copy on write become layers
+	data, err := json.Marshal(map[string]*deviceMapping{
+		"vol1": {
+			VolumeID:  "vol1",
+			File:      filepath.Join(dir, "vol1"),
+			Size:      1024,
+			Formatted: true,
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(p, data, 0644))
+
+	s, err := newDeviceStore(p)
+	require.NoError(t, err)
+	m, ok := s.get("vol1")
+	require.True(t, ok)
+	assert.Equal(t, "vol1", m.Layer)
+	chain := s.snapshot().chain(m.Layer)
+	require.Len(t, chain, 1)
+	assert.True(t, chain[0].Full)
+	assert.Equal(t, filepath.Join(dir, "vol1"), chain[0].File)
+}
@@ -0,0 +1,243 @@
+package buse
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/libopenstorage/openstorage/api"
+	clustermanager "github.com/libopenstorage/openstorage/cluster/manager"
+)
+
+const (
+	// ExportAddressParam is the address on which the volumes of this node
+	// are exported to the other nodes. Volumes are not exported if it is
+	// not set. Nodes connect to the data address of the owner of a volume
+	// and the port of this address. The exports require ExportCertParam,
+	// ExportKeyParam and ExportCAParam to authenticate the nodes.
+	ExportAddressParam = "export_address"
+	// ExportCertParam is the certificate file of the exports
+	ExportCertParam = "export_cert"
+	// ExportKeyParam is the private key file of the exports
+	ExportKeyParam = "export_key"
+	// ExportCAParam is the CA file verifying the certificates of the nodes.
+	// Clients must present a certificate signed by this CA.
+	ExportCAParam = "export_ca"
+
+	// AttachInfoExport is the address of the NBD export of a volume attached
+	// on a node which does not own it
+	AttachInfoExport = "nbd_export"
+	// AttachInfoDevice is the NBD device of a volume attached on a node
+	// which does not own it
+	AttachInfoDevice = "nbd_device"
+)
+
+// remoteDevice is a local NBD device connected to the export of a volume on
+// the node owning it.
+type remoteDevice struct {
+	client *NBDClient
+	nbd    *NBD
+}
+
+// startExports starts the NBD server exporting the volumes connected on
+// this node. The exports give read and write access to the volumes and
+// their replicas, so they are only served to the nodes authenticated with
+// mutual TLS.
+func (d *driver) startExports(params map[string]string) error {
+	address := params[ExportAddressParam]
+	if len(address) == 0 {
+		return nil
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = address, strconv.Itoa(NBDDefaultPort)
+		address = net.JoinHostPort(host, port)
+	}
+	serverTLS, clientTLS, err := exportTLSConfig(params)
+	if err != nil {
+		return err
+	}
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	d.exportPort = port
+	d.clientTLS = clientTLS
+	d.exports = NewNBDServer(serverTLS, d.lookupExport, d.listExports)
+	go func() {
+		if err := d.exports.Serve(l); err != nil {
+			logrus.Errorf("BUSE exports on %s stopped: %v", address, err)
+		}
+	}()
+	logrus.Infof("BUSE exporting volumes on %s", address)
+	return nil
+}
+
+// exportTLSConfig returns the TLS configurations of the server and of the
+// clients of the exports, which authenticate each other with certificates
+// signed by the export CA. Nodes use the same certificate as server and
+// client.
+func exportTLSConfig(params map[string]string) (*tls.Config, *tls.Config, error) {
+	certFile, keyFile, caFile := params[ExportCertParam], params[ExportKeyParam], params[ExportCAParam]
+	if len(certFile) == 0 || len(keyFile) == 0 || len(caFile) == 0 {
+		return nil, nil, fmt.Errorf("BUSE exports need %s, %s and %s to authenticate the nodes",
+			ExportCertParam, ExportKeyParam, ExportCAParam)
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to load the export certificate: %v", err)
+	}
+	server := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	client := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	ca, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to read the export CA: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, nil, fmt.Errorf("No certificate found in %s", caFile)
+	}
+	server.ClientCAs = pool
+	server.ClientAuth = tls.RequireAndVerifyClientCert
+	client.RootCAs = pool
+	return server, client, nil
+}
+
+// lookupExport returns the device of a volume connected on this node, or
+// the replica of a volume held by this node.
+func (d *driver) lookupExport(name string) (ExportDevice, int64, error) {
+	if strings.HasPrefix(name, replicaExportPrefix) {
+		return d.replicaDevice(strings.TrimPrefix(name, replicaExportPrefix))
+	}
+	bd, ok := d.device(name)
+	if !ok {
+		return nil, 0, fmt.Errorf("Volume %s is not connected on this node", name)
+	}
+	return bd, bd.cow.size, nil
+}
+
+func (d *driver) listExports() []string {
+	d.devLock.Lock()
+	defer d.devLock.Unlock()
+	names := make([]string, 0, len(d.buseDevices))
+	for id := range d.buseDevices {
+		names = append(names, id)
+	}
+	return names
+}
+
+// selfNodeID returns the id of this node, which is empty if the cluster
+// manager is not running.
+func selfNodeID() string {
+	c, err := clustermanager.Inst()
+	if err != nil {
+		return ""
+	}
+	cl, err := c.Enumerate()
+	if err != nil {
+		return ""
+	}
+	return cl.NodeId
+}
+
+// volumeOwner returns the node holding the layers of a volume, which is
+// empty for volumes created in single node mode.
+func volumeOwner(v *api.Volume) string {
+	if len(v.ReplicaSets) == 0 || len(v.ReplicaSets[0].Nodes) == 0 {
+		return ""
+	}
+	return v.ReplicaSets[0].Nodes[0]
+}
+
+// exportAddress returns the address of the exports of a node.
+func (d *driver) exportAddress(nodeID string) (string, error) {
+	if d.exports == nil {
+		return "", fmt.Errorf("BUSE exports are not enabled, set %s", ExportAddressParam)
+	}
+	c, err := clustermanager.Inst()
+	if err != nil {
+		return "", err
+	}
+	node, err := c.Inspect(nodeID)
+	if err != nil {
+		return "", err
+	}
+	host := node.DataIp
+	if len(host) == 0 {
+		host = node.MgmtIp
+	}
+	if len(host) == 0 {
+		return "", fmt.Errorf("Node %s has no address", nodeID)
+	}
+	return net.JoinHostPort(host, d.exportPort), nil
+}
+
+// attachRemote connects a local NBD device to the export of a volume on the
+// node owning it.
+func (d *driver) attachRemote(v *api.Volume, owner string) (string, string, error) {
+	d.devLock.Lock()
+	defer d.devLock.Unlock()
+	if rd, ok := d.remoteDevices[v.Id]; ok {
+		return rd.nbd.devicePath, v.AttachInfo[AttachInfoExport], nil
+	}
+	address, err := d.exportAddress(owner)
+	if err != nil {
+		return "", "", err
+	}
+	client, err := DialNBD(address, d.clientTLS, v.Id)
+	if err != nil {
+		return "", "", fmt.Errorf("Failed to connect to the export of volume %s on node %s: %v",
+			v.Id, owner, err)
+	}
+	nbd := Create(client, v.Id, client.Size())
+	if nbd == nil {
+		client.Close()
+		return "", "", fmt.Errorf("Failed to create NBD device for volume %s", v.Id)
+	}
+	dev, err := nbd.Connect()
+	if err != nil {
+		client.Close()
+		return "", "", err
+	}
+	d.remoteDevices[v.Id] = &remoteDevice{
+		client: client,
+		nbd:    nbd,
+	}
+	logrus.Infof("BUSE connected NBD device %s to the export of volume %s at %s",
+		dev, v.Id, address)
+	return dev, address, nil
+}
+
+// detachRemote disconnects the local NBD device of a volume owned by
+// another node.
+func (d *driver) detachRemote(volumeID string) {
+	d.devLock.Lock()
+	defer d.devLock.Unlock()
+	rd, ok := d.remoteDevices[volumeID]
+	if !ok {
+		return
+	}
+	rd.nbd.Disconnect()
+	rd.client.Close()
+	delete(d.remoteDevices, volumeID)
+}
+
+// attachedDevice returns the device of a volume on this node.
+func attachedDevice(v *api.Volume) string {
+	if dev, ok := v.AttachInfo[AttachInfoDevice]; ok && v.AttachedOn == selfNodeID() {
+		return dev
+	}
+	return v.DevicePath
+}
@@ -185,7 +185,7 @@ func (nbd *NBD) Connect() (dev string, err error) {
 	// Setup.
 	if err = nbd.Size(nbd.size); err != nil {
 		// Already set by nbd.Size().
-	} else if err = ioctl(nbd.deviceFile.Fd(), NBD_SET_FLAGS, 1); err != nil {
+	} else if err = ioctl(nbd.deviceFile.Fd(), NBD_SET_FLAGS, nbd.flags()); err != nil {
 		err = &os.PathError{
 			Op:   nbd.deviceFile.Name(),
 			Path: "ioctl NBD_SET_FLAGS",
@@ -201,6 +201,15 @@ func (nbd *NBD) Connect() (dev string, err error) {
 	return dev, err
 }
 
+// flags returns the flags of the device, which supports flush and trim if
+// the device implements them.
+func (nbd *NBD) flags() uintptr {
+	if _, ok := nbd.device.(ExportDevice); ok {
+		return NBD_FLAG_HAS_FLAGS | NBD_FLAG_SEND_FLUSH | NBD_FLAG_SEND_TRIM
+	}
+	return NBD_FLAG_HAS_FLAGS
+}
+
 // Disconnect disconnects the network block device
 func (nbd *NBD) Disconnect() {
 	nbd.mutex.Lock()
@@ -288,11 +297,21 @@ func (nbd *NBD) handle() {
 				logrus.Infof("Disconnecting device %s", nbd.devicePath)
 				nbd.Disconnect()
 				return
-			case NBD_CMD_FLUSH:
-				fallthrough
-			case NBD_CMD_TRIM:
+			case NBD_CMD_FLUSH, NBD_CMD_TRIM:
+				errno := uint32(1)
+				if dev, ok := nbd.device.(ExportDevice); ok {
+					var err error
+					if x.typus == NBD_CMD_FLUSH {
+						err = dev.Sync()
+					} else {
+						err = dev.Trim(int64(x.from), int64(x.len))
+					}
+					if err == nil {
+						errno = 0
+					}
+				}
 				binary.BigEndian.PutUint32(buf[0:4], NBD_REPLY_MAGIC)
-				binary.BigEndian.PutUint32(buf[4:8], 1)
+				binary.BigEndian.PutUint32(buf[4:8], errno)
 				syscall.Write(nbd.socket, buf[0:16])
 			default:
 				logrus.Errorf("Unknown command received on device %s", nbd.devicePath)
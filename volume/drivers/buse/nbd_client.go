@@ -0,0 +1,324 @@
+package buse
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// nbdDialTimeout bounds the connection to a NBD server
+const nbdDialTimeout = 10 * time.Second
+
+// NBDClient is a device reading and writing an export of a NBD server. The
+// requests are sent one at a time.
+type NBDClient struct {
+	sync.Mutex
+	conn       net.Conn
+	size       int64
+	flags      uint16
+	structured bool
+	handle     uint64
+}
+
+// DialNBD connects to the export of a NBD server. TLS is started before
+// selecting the export if tlsConfig is set.
+func DialNBD(address string, tlsConfig *tls.Config, name string) (*NBDClient, error) {
+	conn, err := net.DialTimeout("tcp", address, nbdDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil && len(tlsConfig.ServerName) == 0 {
+		// The nodes are verified by their address.
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.ServerName, _, _ = net.SplitHostPort(address)
+	}
+	c, err := NewNBDClient(conn, tlsConfig, name)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewNBDClient negotiates an export over a connection to a NBD server.
+func NewNBDClient(conn net.Conn, tlsConfig *tls.Config, name string) (*NBDClient, error) {
+	c := &NBDClient{conn: conn}
+	hdr := make([]byte, 18)
+	if _, err := io.ReadFull(conn, hdr); err != nil {
+		return nil, err
+	}
+	if binary.BigEndian.Uint64(hdr) != NBD_MAGIC ||
+		binary.BigEndian.Uint64(hdr[8:]) != NBD_OPTS_MAGIC {
+		return nil, errors.New("Server does not support the newstyle negotiation")
+	}
+	serverFlags := binary.BigEndian.Uint16(hdr[16:])
+	if serverFlags&NBD_FLAG_FIXED_NEWSTYLE == 0 {
+		return nil, errors.New("Server does not support the fixed newstyle negotiation")
+	}
+	clientFlags := uint32(NBD_FLAG_FIXED_NEWSTYLE | serverFlags&NBD_FLAG_NO_ZEROES)
+	if err := binary.Write(conn, binary.BigEndian, clientFlags); err != nil {
+		return nil, err
+	}
+
+	if tlsConfig != nil {
+		if err := c.option(NBD_OPT_STARTTLS, nil); err != nil {
+			return nil, err
+		}
+		if _, _, err := c.optionReply(NBD_OPT_STARTTLS); err != nil {
+			return nil, err
+		}
+		tc := tls.Client(conn, tlsConfig)
+		if err := tc.Handshake(); err != nil {
+			return nil, err
+		}
+		c.conn = tc
+	}
+
+	if err := c.option(NBD_OPT_STRUCTURED_REPLY, nil); err != nil {
+		return nil, err
+	}
+	if _, _, err := c.optionReply(NBD_OPT_STRUCTURED_REPLY); err == nil {
+		c.structured = true
+	} else if _, ok := err.(*nbdOptionError); !ok {
+		return nil, err
+	}
+
+	data := make([]byte, 6+len(name))
+	binary.BigEndian.PutUint32(data, uint32(len(name)))
+	copy(data[4:], name)
+	if err := c.option(NBD_OPT_GO, data); err != nil {
+		return nil, err
+	}
+	for {
+		typ, data, err := c.optionReply(NBD_OPT_GO)
+		if err != nil {
+			return nil, err
+		}
+		if typ == NBD_REP_ACK {
+			break
+		}
+		if typ == NBD_REP_INFO && len(data) >= 12 &&
+			binary.BigEndian.Uint16(data) == NBD_INFO_EXPORT {
+			c.size = int64(binary.BigEndian.Uint64(data[2:]))
+			c.flags = binary.BigEndian.Uint16(data[10:])
+		}
+	}
+	return c, nil
+}
+
+// nbdOptionError is an error reply of the server to an option.
+type nbdOptionError struct {
+	opt     uint32
+	typ     uint32
+	message string
+}
+
+func (e *nbdOptionError) Error() string {
+	return fmt.Sprintf("NBD server refused option %d with error %x: %s",
+		e.opt, e.typ, e.message)
+}
+
+func (c *NBDClient) option(opt uint32, data []byte) error {
+	b := make([]byte, 16+len(data))
+	binary.BigEndian.PutUint64(b, NBD_OPTS_MAGIC)
+	binary.BigEndian.PutUint32(b[8:], opt)
+	binary.BigEndian.PutUint32(b[12:], uint32(len(data)))
+	copy(b[16:], data)
+	_, err := c.conn.Write(b)
+	return err
+}
+
+func (c *NBDClient) optionReply(opt uint32) (uint32, []byte, error) {
+	hdr := make([]byte, 20)
+	if _, err := io.ReadFull(c.conn, hdr); err != nil {
+		return 0, nil, err
+	}
+	if binary.BigEndian.Uint64(hdr) != NBD_OPT_REPLY_MAGIC ||
+		binary.BigEndian.Uint32(hdr[8:]) != opt {
+		return 0, nil, fmt.Errorf("Invalid reply to NBD option %d", opt)
+	}
+	typ := binary.BigEndian.Uint32(hdr[12:])
+	length := binary.BigEndian.Uint32(hdr[16:])
+	if length > nbdMaxOptionLength {
+		return 0, nil, fmt.Errorf("Reply to NBD option %d is too long", opt)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(c.conn, data); err != nil {
+		return 0, nil, err
+	}
+	if typ&NBD_REP_FLAG_ERROR != 0 {
+		return 0, nil, &nbdOptionError{opt: opt, typ: typ, message: string(data)}
+	}
+	return typ, data, nil
+}
+
+// Size returns the size of the export.
+func (c *NBDClient) Size() int64 {
+	return c.size
+}
+
+// ReadAt reads from the export.
+func (c *NBDClient) ReadAt(b []byte, off int64) (int, error) {
+	for done := 0; done < len(b); {
+		n := len(b) - done
+		if n > nbdMaxRequestLength {
+			n = nbdMaxRequestLength
+		}
+		if err := c.request(NBD_CMD_READ, 0, off+int64(done), uint32(n), nil, b[done:done+n]); err != nil {
+			return done, err
+		}
+		done += n
+	}
+	return len(b), nil
+}
+
+// WriteAt writes to the export.
+func (c *NBDClient) WriteAt(b []byte, off int64) (int, error) {
+	for done := 0; done < len(b); {
+		n := len(b) - done
+		if n > nbdMaxRequestLength {
+			n = nbdMaxRequestLength
+		}
+		if err := c.request(NBD_CMD_WRITE, 0, off+int64(done), uint32(n), b[done:done+n], nil); err != nil {
+			return done, err
+		}
+		done += n
+	}
+	return len(b), nil
+}
+
+// Sync flushes the writes of the export.
+func (c *NBDClient) Sync() error {
+	return c.request(NBD_CMD_FLUSH, 0, 0, 0, nil, nil)
+}
+
+// Trim discards a range of the export.
+func (c *NBDClient) Trim(off, length int64) error {
+	return c.request(NBD_CMD_TRIM, 0, off, uint32(length), nil, nil)
+}
+
+// Close disconnects from the server.
+func (c *NBDClient) Close() error {
+	c.Lock()
+	defer c.Unlock()
+	hdr := make([]byte, 28)
+	binary.BigEndian.PutUint32(hdr, NBD_REQUEST_MAGIC)
+	binary.BigEndian.PutUint16(hdr[6:], NBD_CMD_DISC)
+	c.conn.Write(hdr)
+	return c.conn.Close()
+}
+
+// request sends a request and reads its reply. The data read is copied to
+// buf.
+func (c *NBDClient) request(
+	typ uint16,
+	flags uint16,
+	off int64,
+	length uint32,
+	data []byte,
+	buf []byte,
+) error {
+	c.Lock()
+	defer c.Unlock()
+	c.handle++
+	b := make([]byte, 28+len(data))
+	binary.BigEndian.PutUint32(b, NBD_REQUEST_MAGIC)
+	binary.BigEndian.PutUint16(b[4:], flags)
+	binary.BigEndian.PutUint16(b[6:], typ)
+	binary.BigEndian.PutUint64(b[8:], c.handle)
+	binary.BigEndian.PutUint64(b[16:], uint64(off))
+	binary.BigEndian.PutUint32(b[24:], length)
+	copy(b[28:], data)
+	if _, err := c.conn.Write(b); err != nil {
+		return err
+	}
+
+	hdr := make([]byte, 4)
+	if _, err := io.ReadFull(c.conn, hdr); err != nil {
+		return err
+	}
+	switch binary.BigEndian.Uint32(hdr) {
+	case NBD_REPLY_MAGIC:
+		return c.simpleReply(buf)
+	case NBD_STRUCTURED_REPLY_MAGIC:
+		if !c.structured {
+			return errors.New("Unexpected NBD structured reply")
+		}
+		return c.structuredReply(off, buf)
+	default:
+		return errors.New("Invalid NBD reply magic")
+	}
+}
+
+func (c *NBDClient) simpleReply(buf []byte) error {
+	hdr := make([]byte, 12)
+	if _, err := io.ReadFull(c.conn, hdr); err != nil {
+		return err
+	}
+	if binary.BigEndian.Uint64(hdr[4:]) != c.handle {
+		return errors.New("NBD reply to an unknown request")
+	}
+	if errno := binary.BigEndian.Uint32(hdr); errno != 0 {
+		return fmt.Errorf("NBD request failed: %v", syscall.Errno(errno))
+	}
+	_, err := io.ReadFull(c.conn, buf)
+	return err
+}
+
+// structuredReply reads the chunks of a structured reply until the last
+// chunk. Holes read as zeros.
+func (c *NBDClient) structuredReply(off int64, buf []byte) error {
+	var replyErr error
+	hdr := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(c.conn, hdr); err != nil {
+			return err
+		}
+		flags := binary.BigEndian.Uint16(hdr)
+		typ := binary.BigEndian.Uint16(hdr[2:])
+		if binary.BigEndian.Uint64(hdr[4:]) != c.handle {
+			return errors.New("NBD reply to an unknown request")
+		}
+		length := binary.BigEndian.Uint32(hdr[12:])
+		if length > nbdMaxRequestLength+8 {
+			return errors.New("NBD reply chunk is too long")
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(c.conn, data); err != nil {
+			return err
+		}
+
+		switch {
+		case typ == NBD_REPLY_TYPE_NONE:
+		case typ == NBD_REPLY_TYPE_OFFSET_DATA && length >= 8:
+			start := int64(binary.BigEndian.Uint64(data)) - off
+			if start < 0 || start+int64(length-8) > int64(len(buf)) {
+				return errors.New("NBD reply data is out of range")
+			}
+			copy(buf[start:], data[8:])
+		case typ == NBD_REPLY_TYPE_OFFSET_HOLE && length == 12:
+			start := int64(binary.BigEndian.Uint64(data)) - off
+			end := start + int64(binary.BigEndian.Uint32(data[8:]))
+			if start < 0 || end > int64(len(buf)) {
+				return errors.New("NBD reply hole is out of range")
+			}
+			for i := start; i < end; i++ {
+				buf[i] = 0
+			}
+		case typ&(1<<15) != 0 && length >= 6:
+			replyErr = fmt.Errorf("NBD request failed: %v: %s",
+				syscall.Errno(binary.BigEndian.Uint32(data)), data[6:])
+		default:
+			return fmt.Errorf("Invalid NBD reply chunk type %d", typ)
+		}
+		if flags&NBD_REPLY_FLAG_DONE != 0 {
+			return replyErr
+		}
+	}
+}
@@ -0,0 +1,450 @@
+package buse
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// NBDDefaultPort is the port registered for the NBD protocol
+	NBDDefaultPort = 10809
+
+	// Newstyle negotiation
+	NBD_MAGIC               = 0x4e42444d41474943 // "NBDMAGIC"
+	NBD_OPTS_MAGIC          = 0x49484156454f5054 // "IHAVEOPT"
+	NBD_OPT_REPLY_MAGIC     = 0x3e889045565a9
+	NBD_FLAG_FIXED_NEWSTYLE = (1 << 0)
+	NBD_FLAG_NO_ZEROES      = (1 << 1)
+	// options
+	NBD_OPT_EXPORT_NAME      = 1
+	NBD_OPT_ABORT            = 2
+	NBD_OPT_LIST             = 3
+	NBD_OPT_STARTTLS         = 5
+	NBD_OPT_INFO             = 6
+	NBD_OPT_GO               = 7
+	NBD_OPT_STRUCTURED_REPLY = 8
+	// option replies
+	NBD_REP_ACK          = 1
+	NBD_REP_SERVER       = 2
+	NBD_REP_INFO         = 3
+	NBD_REP_FLAG_ERROR   = (1 << 31)
+	NBD_REP_ERR_UNSUP    = NBD_REP_FLAG_ERROR | 1
+	NBD_REP_ERR_POLICY   = NBD_REP_FLAG_ERROR | 2
+	NBD_REP_ERR_INVALID  = NBD_REP_FLAG_ERROR | 3
+	NBD_REP_ERR_TLS_REQD = NBD_REP_FLAG_ERROR | 5
+	NBD_REP_ERR_UNKNOWN  = NBD_REP_FLAG_ERROR | 6
+	NBD_INFO_EXPORT      = 0
+
+	// Transmission
+	NBD_CMD_WRITE_ZEROES       = 6
+	NBD_CMD_FLAG_FUA           = (1 << 0)
+	NBD_FLAG_SEND_WRITE_ZEROES = (1 << 6)
+	NBD_STRUCTURED_REPLY_MAGIC = 0x668e33ef
+	NBD_REPLY_FLAG_DONE        = (1 << 0)
+	NBD_REPLY_TYPE_NONE        = 0
+	NBD_REPLY_TYPE_OFFSET_DATA = 1
+	NBD_REPLY_TYPE_OFFSET_HOLE = 2
+	NBD_REPLY_TYPE_ERROR       = (1 << 15) | 1
+
+	// nbdMaxOptionLength bounds the data of the options sent by clients
+	nbdMaxOptionLength = 64 * 1024
+	// nbdMaxRequestLength bounds the data of reads and writes
+	nbdMaxRequestLength = 32 * 1024 * 1024
+)
+
+// ExportDevice is a device exported by the NBD server. Sync makes the
+// writes durable and Trim discards a range of the device.
+type ExportDevice interface {
+	Device
+	Sync() error
+	Trim(off, length int64) error
+}
+
+// ExportLookup returns the device and the size of an export, or an error if
+// the export is not available on this node.
+type ExportLookup func(name string) (ExportDevice, int64, error)
+
+// NBDServer serves exports to NBD clients over the newstyle protocol.
+// If it has a TLS configuration, clients must start TLS before selecting
+// an export.
+type NBDServer struct {
+	tlsConfig *tls.Config
+	lookup    ExportLookup
+	list      func() []string
+
+	sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]bool
+	closed   bool
+}
+
+// NewNBDServer creates a server of the exports returned by lookup. list
+// returns the names of the exports for NBD_OPT_LIST.
+func NewNBDServer(
+	tlsConfig *tls.Config,
+	lookup ExportLookup,
+	list func() []string,
+) *NBDServer {
+	return &NBDServer{
+		tlsConfig: tlsConfig,
+		lookup:    lookup,
+		list:      list,
+		conns:     make(map[net.Conn]bool),
+	}
+}
+
+// Serve accepts the connections of the clients until the server is closed.
+func (s *NBDServer) Serve(l net.Listener) error {
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		return errors.New("NBD server is closed")
+	}
+	s.listener = l
+	s.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.Lock()
+			closed := s.closed
+			s.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		s.Lock()
+		s.conns[conn] = true
+		s.Unlock()
+		go func() {
+			err := s.serveConn(conn)
+			s.Lock()
+			closed := s.closed
+			delete(s.conns, conn)
+			s.Unlock()
+			if err != nil && err != io.EOF && !closed {
+				logrus.Warnf("NBD connection from %v failed: %v", conn.RemoteAddr(), err)
+			}
+			conn.Close()
+		}()
+	}
+}
+
+// Close stops accepting clients and closes the connections.
+func (s *NBDServer) Close() error {
+	s.Lock()
+	defer s.Unlock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+	return nil
+}
+
+// nbdConn is a server connection, which is replaced by a TLS connection
+// once the client starts TLS.
+type nbdConn struct {
+	net.Conn
+	structured bool
+	tls        bool
+}
+
+func (s *NBDServer) serveConn(conn net.Conn) error {
+	c := &nbdConn{Conn: conn}
+	hdr := make([]byte, 18)
+	binary.BigEndian.PutUint64(hdr[0:8], NBD_MAGIC)
+	binary.BigEndian.PutUint64(hdr[8:16], NBD_OPTS_MAGIC)
+	binary.BigEndian.PutUint16(hdr[16:18], NBD_FLAG_FIXED_NEWSTYLE|NBD_FLAG_NO_ZEROES)
+	if _, err := c.Write(hdr); err != nil {
+		return err
+	}
+	var clientFlags uint32
+	if err := binary.Read(c, binary.BigEndian, &clientFlags); err != nil {
+		return err
+	}
+	if clientFlags&NBD_FLAG_FIXED_NEWSTYLE == 0 {
+		return errors.New("client does not support the fixed newstyle negotiation")
+	}
+	noZeroes := clientFlags&NBD_FLAG_NO_ZEROES != 0
+
+	for {
+		opt, data, err := readOption(c)
+		if err != nil {
+			return err
+		}
+		if s.tlsConfig != nil && !c.tls && opt != NBD_OPT_STARTTLS && opt != NBD_OPT_ABORT {
+			if opt == NBD_OPT_EXPORT_NAME {
+				// NBD_OPT_EXPORT_NAME has no error reply.
+				return errors.New("client selected an export without TLS")
+			}
+			if err := c.reply(opt, NBD_REP_ERR_TLS_REQD, nil); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch opt {
+		case NBD_OPT_STARTTLS:
+			if s.tlsConfig == nil {
+				err = c.reply(opt, NBD_REP_ERR_POLICY, nil)
+				break
+			}
+			if c.tls || len(data) != 0 {
+				err = c.reply(opt, NBD_REP_ERR_INVALID, nil)
+				break
+			}
+			if err := c.reply(opt, NBD_REP_ACK, nil); err != nil {
+				return err
+			}
+			tc := tls.Server(c.Conn, s.tlsConfig)
+			if err := tc.Handshake(); err != nil {
+				return err
+			}
+			c.Conn = tc
+			c.tls = true
+		case NBD_OPT_ABORT:
+			c.reply(opt, NBD_REP_ACK, nil)
+			return nil
+		case NBD_OPT_LIST:
+			for _, name := range s.list() {
+				b := make([]byte, 4+len(name))
+				binary.BigEndian.PutUint32(b, uint32(len(name)))
+				copy(b[4:], name)
+				if err := c.reply(opt, NBD_REP_SERVER, b); err != nil {
+					return err
+				}
+			}
+			err = c.reply(opt, NBD_REP_ACK, nil)
+		case NBD_OPT_STRUCTURED_REPLY:
+			if len(data) != 0 {
+				err = c.reply(opt, NBD_REP_ERR_INVALID, nil)
+				break
+			}
+			c.structured = true
+			err = c.reply(opt, NBD_REP_ACK, nil)
+		case NBD_OPT_EXPORT_NAME:
+			dev, size, err := s.lookup(string(data))
+			if err != nil {
+				return err
+			}
+			b := make([]byte, 10, 134)
+			binary.BigEndian.PutUint64(b, uint64(size))
+			binary.BigEndian.PutUint16(b[8:], exportFlags)
+			if !noZeroes {
+				b = b[:134]
+			}
+			if _, err := c.Write(b); err != nil {
+				return err
+			}
+			return c.transmit(dev, size)
+		case NBD_OPT_INFO, NBD_OPT_GO:
+			if len(data) < 6 {
+				err = c.reply(opt, NBD_REP_ERR_INVALID, nil)
+				break
+			}
+			nameLength := binary.BigEndian.Uint32(data)
+			if uint64(len(data)) < 6+uint64(nameLength) {
+				err = c.reply(opt, NBD_REP_ERR_INVALID, nil)
+				break
+			}
+			dev, size, lookupErr := s.lookup(string(data[4 : 4+nameLength]))
+			if lookupErr != nil {
+				err = c.reply(opt, NBD_REP_ERR_UNKNOWN, []byte(lookupErr.Error()))
+				break
+			}
+			b := make([]byte, 12)
+			binary.BigEndian.PutUint16(b, NBD_INFO_EXPORT)
+			binary.BigEndian.PutUint64(b[2:], uint64(size))
+			binary.BigEndian.PutUint16(b[10:], exportFlags)
+			if err := c.reply(opt, NBD_REP_INFO, b); err != nil {
+				return err
+			}
+			if err := c.reply(opt, NBD_REP_ACK, nil); err != nil {
+				return err
+			}
+			if opt == NBD_OPT_GO {
+				return c.transmit(dev, size)
+			}
+		default:
+			err = c.reply(opt, NBD_REP_ERR_UNSUP, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// exportFlags are the transmission flags of the exports.
+const exportFlags = NBD_FLAG_HAS_FLAGS | NBD_FLAG_SEND_FLUSH | NBD_FLAG_SEND_FUA |
+	NBD_FLAG_SEND_TRIM | NBD_FLAG_SEND_WRITE_ZEROES
+
+func readOption(r io.Reader) (uint32, []byte, error) {
+	hdr := make([]byte, 16)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return 0, nil, err
+	}
+	if magic := binary.BigEndian.Uint64(hdr); magic != NBD_OPTS_MAGIC {
+		return 0, nil, fmt.Errorf("invalid option magic %x", magic)
+	}
+	opt := binary.BigEndian.Uint32(hdr[8:12])
+	length := binary.BigEndian.Uint32(hdr[12:16])
+	if length > nbdMaxOptionLength {
+		return 0, nil, fmt.Errorf("option %d is too long (%d bytes)", opt, length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+	return opt, data, nil
+}
+
+func (c *nbdConn) reply(opt uint32, typ uint32, data []byte) error {
+	b := make([]byte, 20+len(data))
+	binary.BigEndian.PutUint64(b, NBD_OPT_REPLY_MAGIC)
+	binary.BigEndian.PutUint32(b[8:], opt)
+	binary.BigEndian.PutUint32(b[12:], typ)
+	binary.BigEndian.PutUint32(b[16:], uint32(len(data)))
+	copy(b[20:], data)
+	_, err := c.Write(b)
+	return err
+}
+
+// transmit serves the requests of the client on an export until it
+// disconnects. Requests are served in order.
+func (c *nbdConn) transmit(dev ExportDevice, size int64) error {
+	hdr := make([]byte, 28)
+	for {
+		if _, err := io.ReadFull(c, hdr); err != nil {
+			return err
+		}
+		if magic := binary.BigEndian.Uint32(hdr); magic != NBD_REQUEST_MAGIC {
+			return fmt.Errorf("invalid request magic %x", magic)
+		}
+		flags := binary.BigEndian.Uint16(hdr[4:6])
+		typ := binary.BigEndian.Uint16(hdr[6:8])
+		handle := binary.BigEndian.Uint64(hdr[8:16])
+		off := int64(binary.BigEndian.Uint64(hdr[16:24]))
+		length := binary.BigEndian.Uint32(hdr[24:28])
+
+		var data []byte
+		if typ == NBD_CMD_WRITE {
+			if length > nbdMaxRequestLength {
+				return fmt.Errorf("write of %d bytes is too long", length)
+			}
+			data = make([]byte, length)
+			if _, err := io.ReadFull(c, data); err != nil {
+				return err
+			}
+		}
+		inRange := off >= 0 && off+int64(length) <= size
+
+		var err error
+		switch typ {
+		case NBD_CMD_READ:
+			if !inRange || length > nbdMaxRequestLength {
+				err = c.replyError(handle, syscall.EINVAL)
+				break
+			}
+			b := make([]byte, length)
+			if _, readErr := dev.ReadAt(b, off); readErr != nil && readErr != io.EOF {
+				logrus.Warnf("NBD read of %d bytes at %d failed: %v", length, off, readErr)
+				err = c.replyError(handle, syscall.EIO)
+				break
+			}
+			err = c.replyData(handle, off, b)
+		case NBD_CMD_WRITE, NBD_CMD_WRITE_ZEROES:
+			if !inRange {
+				err = c.replyError(handle, syscall.ENOSPC)
+				break
+			}
+			if data == nil {
+				data = make([]byte, length)
+			}
+			_, ioErr := dev.WriteAt(data, off)
+			if ioErr == nil && flags&NBD_CMD_FLAG_FUA != 0 {
+				ioErr = dev.Sync()
+			}
+			err = c.replyResult(handle, ioErr)
+		case NBD_CMD_FLUSH:
+			err = c.replyResult(handle, dev.Sync())
+		case NBD_CMD_TRIM:
+			if !inRange {
+				err = c.replyError(handle, syscall.EINVAL)
+				break
+			}
+			err = c.replyResult(handle, dev.Trim(off, int64(length)))
+		case NBD_CMD_DISC:
+			return nil
+		default:
+			err = c.replyError(handle, syscall.EINVAL)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *nbdConn) replyResult(handle uint64, err error) error {
+	if err != nil {
+		logrus.Warnf("NBD request failed: %v", err)
+		return c.replyError(handle, syscall.EIO)
+	}
+	if c.structured {
+		return c.replyChunk(handle, NBD_REPLY_TYPE_NONE, nil)
+	}
+	return c.replySimple(handle, 0, nil)
+}
+
+func (c *nbdConn) replyError(handle uint64, errno syscall.Errno) error {
+	if c.structured {
+		b := make([]byte, 6)
+		binary.BigEndian.PutUint32(b, uint32(errno))
+		return c.replyChunk(handle, NBD_REPLY_TYPE_ERROR, b)
+	}
+	return c.replySimple(handle, uint32(errno), nil)
+}
+
+func (c *nbdConn) replyData(handle uint64, off int64, data []byte) error {
+	if c.structured {
+		b := make([]byte, 8+len(data))
+		binary.BigEndian.PutUint64(b, uint64(off))
+		copy(b[8:], data)
+		return c.replyChunk(handle, NBD_REPLY_TYPE_OFFSET_DATA, b)
+	}
+	return c.replySimple(handle, 0, data)
+}
+
+func (c *nbdConn) replySimple(handle uint64, errno uint32, data []byte) error {
+	b := make([]byte, 16+len(data))
+	binary.BigEndian.PutUint32(b, NBD_REPLY_MAGIC)
+	binary.BigEndian.PutUint32(b[4:], errno)
+	binary.BigEndian.PutUint64(b[8:], handle)
+	copy(b[16:], data)
+	_, err := c.Write(b)
+	return err
+}
+
+// replyChunk sends the single chunk of a structured reply.
+func (c *nbdConn) replyChunk(handle uint64, typ uint16, data []byte) error {
+	b := make([]byte, 20+len(data))
+	binary.BigEndian.PutUint32(b, NBD_STRUCTURED_REPLY_MAGIC)
+	binary.BigEndian.PutUint16(b[4:], NBD_REPLY_FLAG_DONE)
+	binary.BigEndian.PutUint16(b[6:], typ)
+	binary.BigEndian.PutUint64(b[8:], handle)
+	binary.BigEndian.PutUint32(b[16:], uint32(len(data)))
+	copy(b[20:], data)
+	_, err := c.Write(b)
+	return err
+}
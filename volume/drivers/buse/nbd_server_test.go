@@ -0,0 +1,269 @@
+package buse
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCertificate returns a certificate for 127.0.0.1 signed by parent, or
+// self signed if parent is nil.
+func testCertificate(t *testing.T, parent *tls.Certificate, ca bool) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "buse"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  ca,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := template, interface{}(key)
+	if parent != nil {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// startTestServer exports a cow device as "vol" on a loopback port.
+func startTestServer(t *testing.T, tlsConfig *tls.Config, c *cowDevice) (*NBDServer, string) {
+	lookup := func(name string) (ExportDevice, int64, error) {
+		if name != "vol" {
+			return nil, 0, fmt.Errorf("Volume %s is not connected on this node", name)
+		}
+		return c, c.size, nil
+	}
+	s := NewNBDServer(tlsConfig, lookup, func() []string { return []string{"vol"} })
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go s.Serve(l)
+	return s, l.Addr().String()
+}
+
+func TestNBDExport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buse-nbd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	size := int64(4 * cowChunkSize)
+	st := newDeviceState()
+	base := testLayer(t, dir, "base", "", size)
+	st.Layers[base.ID] = base
+	c, err := openCowDevice(st.chain(base.ID))
+	require.NoError(t, err)
+	defer c.close()
+
+	ca := testCertificate(t, nil, true)
+	cert := testCertificate(t, &ca, false)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+	serverTLS := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	clientTLS := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+	}
+	s, address := startTestServer(t, serverTLS, c)
+	defer s.Close()
+
+	client, err := DialNBD(address, clientTLS, "vol")
+	require.NoError(t, err)
+	defer client.Close()
+	assert.Equal(t, size, client.Size())
+	assert.True(t, client.structured)
+	assert.Equal(t, uint16(exportFlags), client.flags)
+
+	// Reads and writes go to the exported device
+	data := bytes.Repeat([]byte{7}, 3*cowChunkSize)
+	n, err := client.WriteAt(data, 100)
+	require.NoError(t, err)
+	assert.Equal(t, len(data), n)
+	require.NoError(t, client.Sync())
+	buf := make([]byte, len(data))
+	_, err = c.ReadAt(buf, 100)
+	require.NoError(t, err)
+	assert.Equal(t, data, buf)
+	buf = make([]byte, size)
+	_, err = client.ReadAt(buf, 0)
+	require.NoError(t, err)
+	expected := make([]byte, size)
+	copy(expected[100:], data)
+	assert.Equal(t, expected, buf)
+
+	// Trim discards the chunks entirely within the range
+	require.NoError(t, client.Trim(cowChunkSize, 2*cowChunkSize))
+	require.NoError(t, client.Sync())
+	used, err := base.usage()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2*cowChunkSize), used)
+	_, err = client.ReadAt(buf, 0)
+	require.NoError(t, err)
+	copy(expected[cowChunkSize:], make([]byte, 2*cowChunkSize))
+	assert.Equal(t, expected, buf)
+
+	// Requests out of the export fail without closing the connection
+	_, err = client.WriteAt([]byte{1}, size)
+	assert.Error(t, err)
+	_, err = client.ReadAt(buf[:1], size)
+	assert.Error(t, err)
+	_, err = client.ReadAt(buf[:1], 100)
+	require.NoError(t, err)
+	assert.Equal(t, byte(7), buf[0])
+
+	// Unknown exports are refused
+	_, err = DialNBD(address, clientTLS, "other")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not connected on this node")
+
+	// TLS is required
+	_, err = DialNBD(address, nil, "vol")
+	require.Error(t, err)
+	optErr, ok := err.(*nbdOptionError)
+	require.True(t, ok, err.Error())
+	assert.Equal(t, uint32(NBD_REP_ERR_TLS_REQD), optErr.typ)
+
+	// Clients must present a certificate signed by the CA
+	other := testCertificate(t, nil, false)
+	_, err = DialNBD(address, &tls.Config{
+		Certificates: []tls.Certificate{other},
+		RootCAs:      pool,
+	}, "vol")
+	assert.Error(t, err)
+}
+
+func TestNBDExportSimpleReplies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buse-nbd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	size := int64(cowChunkSize)
+	st := newDeviceState()
+	base := testLayer(t, dir, "base", "", size)
+	st.Layers[base.ID] = base
+	c, err := openCowDevice(st.chain(base.ID))
+	require.NoError(t, err)
+	defer c.close()
+	_, err = c.WriteAt([]byte("hello"), 10)
+	require.NoError(t, err)
+
+	s, address := startTestServer(t, nil, c)
+	defer s.Close()
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Old clients select the export by name and get simple replies
+	hdr := make([]byte, 18)
+	_, err = io.ReadFull(conn, hdr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(NBD_MAGIC), binary.BigEndian.Uint64(hdr))
+	require.NoError(t, binary.Write(conn, binary.BigEndian, uint32(NBD_FLAG_FIXED_NEWSTYLE)))
+	opt := make([]byte, 16+3)
+	binary.BigEndian.PutUint64(opt, NBD_OPTS_MAGIC)
+	binary.BigEndian.PutUint32(opt[8:], NBD_OPT_EXPORT_NAME)
+	binary.BigEndian.PutUint32(opt[12:], 3)
+	copy(opt[16:], "vol")
+	_, err = conn.Write(opt)
+	require.NoError(t, err)
+	export := make([]byte, 134)
+	_, err = io.ReadFull(conn, export)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(size), binary.BigEndian.Uint64(export))
+	assert.Equal(t, uint16(exportFlags), binary.BigEndian.Uint16(export[8:]))
+
+	req := make([]byte, 28)
+	binary.BigEndian.PutUint32(req, NBD_REQUEST_MAGIC)
+	binary.BigEndian.PutUint16(req[6:], NBD_CMD_READ)
+	binary.BigEndian.PutUint64(req[8:], 42)
+	binary.BigEndian.PutUint64(req[16:], 10)
+	binary.BigEndian.PutUint32(req[24:], 5)
+	_, err = conn.Write(req)
+	require.NoError(t, err)
+	reply := make([]byte, 16+5)
+	_, err = io.ReadFull(conn, reply)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(NBD_REPLY_MAGIC), binary.BigEndian.Uint32(reply))
+	assert.Equal(t, uint32(0), binary.BigEndian.Uint32(reply[4:]))
+	assert.Equal(t, uint64(42), binary.BigEndian.Uint64(reply[8:]))
+	assert.Equal(t, "hello", string(reply[16:]))
+
+	binary.BigEndian.PutUint16(req[6:], NBD_CMD_FLUSH)
+	_, err = conn.Write(req)
+	require.NoError(t, err)
+	_, err = io.ReadFull(conn, reply[:16])
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), binary.BigEndian.Uint32(reply[4:]))
+}
+
+func TestExportTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buse-tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	ca := testCertificate(t, nil, true)
+	cert := testCertificate(t, &ca, false)
+	key, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	require.NoError(t, err)
+	files := map[string]*pem.Block{
+		"ca.crt":   {Type: "CERTIFICATE", Bytes: ca.Certificate[0]},
+		"node.crt": {Type: "CERTIFICATE", Bytes: cert.Certificate[0]},
+		"node.key": {Type: "EC PRIVATE KEY", Bytes: key},
+	}
+	for name, block := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(block), 0600))
+	}
+	params := map[string]string{
+		ExportCertParam: filepath.Join(dir, "node.crt"),
+		ExportKeyParam:  filepath.Join(dir, "node.key"),
+		ExportCAParam:   filepath.Join(dir, "ca.crt"),
+	}
+
+	// The exports are only served with mutual TLS
+	for _, missing := range []string{ExportCertParam, ExportKeyParam, ExportCAParam} {
+		partial := make(map[string]string)
+		for k, v := range params {
+			if k != missing {
+				partial[k] = v
+			}
+		}
+		_, _, err := exportTLSConfig(partial)
+		assert.Error(t, err, missing)
+	}
+	_, _, err = exportTLSConfig(nil)
+	assert.Error(t, err)
+
+	server, client, err := exportTLSConfig(params)
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, server.ClientAuth)
+	assert.NotNil(t, server.ClientCAs)
+	assert.NotNil(t, client.RootCAs)
+	require.Len(t, client.Certificates, 1)
+}
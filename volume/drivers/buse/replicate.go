@@ -0,0 +1,802 @@
+package buse
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/portworx/kvdb"
+	"github.com/sirupsen/logrus"
+
+	"github.com/libopenstorage/openstorage/api"
+	clustermanager "github.com/libopenstorage/openstorage/cluster/manager"
+)
+
+const (
+	// dirtyRegionSize is the unit of the dirty region logs. A replica which
+	// missed a write is resynchronized by copying the regions it missed.
+	dirtyRegionSize = 1024 * 1024
+	// dirtyDir is the directory under BuseMountPath holding the dirty region
+	// logs of the replicas of the volumes owned by this node
+	dirtyDir = "dirty"
+	// replicasDir is the directory under BuseMountPath holding the replicas
+	// of the volumes owned by other nodes
+	replicasDir = "replicas"
+	// replicaExportPrefix prefixes the export names of replicas
+	replicaExportPrefix = "replica/"
+	// replicaResyncInterval is the interval of the resync of lost replicas
+	replicaResyncInterval = 10 * time.Second
+)
+
+// dirtyLog is the persisted bitmap of the regions of a volume a replica
+// missed. Regions are marked on disk before the replica is considered up to
+// date with them, and cleared once copied to the replica.
+type dirtyLog struct {
+	path    string
+	regions int64
+	bits    []byte
+	file    *os.File
+}
+
+// openDirtyLog loads the dirty region log of a replica, which is empty if
+// the replica is in sync.
+func openDirtyLog(p string, size int64) (*dirtyLog, error) {
+	l := &dirtyLog{
+		path:    p,
+		regions: (size + dirtyRegionSize - 1) / dirtyRegionSize,
+	}
+	l.bits = make([]byte, (l.regions+7)/8)
+	b, err := ioutil.ReadFile(p)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	copy(l.bits, b)
+	return l, nil
+}
+
+// mark sets the regions covering a range and saves the log.
+func (l *dirtyLog) mark(off, length int64) error {
+	if length <= 0 {
+		return nil
+	}
+	changed := false
+	for region := off / dirtyRegionSize; region <= (off+length-1)/dirtyRegionSize && region < l.regions; region++ {
+		if !l.isDirty(region) {
+			l.bits[region/8] |= 1 << uint(region%8)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return l.save()
+}
+
+// markRegions sets a list of regions and saves the log.
+func (l *dirtyLog) markRegions(regions map[int64]bool) error {
+	for region := range regions {
+		if region < l.regions {
+			l.bits[region/8] |= 1 << uint(region%8)
+		}
+	}
+	return l.save()
+}
+
+func (l *dirtyLog) save() error {
+	if l.file == nil {
+		if err := os.MkdirAll(path.Dir(l.path), 0744); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return err
+		}
+		l.file = f
+	}
+	if _, err := l.file.WriteAt(l.bits, 0); err != nil {
+		return err
+	}
+	return l.file.Sync()
+}
+
+func (l *dirtyLog) isDirty(region int64) bool {
+	return allocated(l.bits, region)
+}
+
+// next returns the first dirty region.
+func (l *dirtyLog) next() (int64, bool) {
+	for i, b := range l.bits {
+		if b == 0 {
+			continue
+		}
+		for bit := uint(0); bit < 8; bit++ {
+			if b&(1<<bit) != 0 {
+				return int64(i)*8 + int64(bit), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// clear resets a region once copied to the replica. Clearing is not synced
+// since a region left marked is only copied again.
+func (l *dirtyLog) clear(region int64) error {
+	l.bits[region/8] &^= 1 << uint(region%8)
+	if l.file == nil {
+		return nil
+	}
+	_, err := l.file.WriteAt(l.bits[region/8:region/8+1], region/8)
+	return err
+}
+
+func (l *dirtyLog) empty() bool {
+	_, dirty := l.next()
+	return !dirty
+}
+
+// remove deletes the log of a replica in sync.
+func (l *dirtyLog) remove() {
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+	os.Remove(l.path)
+}
+
+// replica is the copy of a volume on another node. The client is nil while
+// the replica is lost.
+type replica struct {
+	node   string
+	client *NBDClient
+	dirty  *dirtyLog
+	// unsynced are the regions written to the replica since its last flush,
+	// which are marked dirty if the replica is lost before the next flush.
+	unsynced map[int64]bool
+	// flushing are the regions written before the flush in progress
+	flushing map[int64]bool
+}
+
+// target is a replica a request is sent to, with the client it was
+// connected with when the request was sent.
+type target struct {
+	rep    *replica
+	client *NBDClient
+}
+
+// replication mirrors the writes to a volume to its replicas. Writes are
+// completed once acknowledged by the replicas in sync. A replica failing a
+// request is dropped, and the regions it misses are recorded in its dirty
+// region log until it is resynchronized.
+//
+// The lock guards the replicas and is not held during I/O. Requests on the
+// same regions wait for each other instead, so that the replicas see them
+// in the order of the local device.
+type replication struct {
+	sync.Mutex
+	// dir holds the dirty region logs of the replicas
+	dir      string
+	volumeID string
+	size     int64
+	replicas []*replica
+	dial     func(node string) (*NBDClient, error)
+	// changed is called when the status of the replicas changes
+	changed func()
+	// busy are the regions with a request in progress
+	busy map[int64]bool
+	// released is signalled when busy regions are released
+	released *sync.Cond
+	// syncLock serializes the flushes
+	syncLock sync.Mutex
+}
+
+// newReplication loads the dirty region logs of the replicas of a volume.
+// The replicas are connected by resync.
+func newReplication(
+	dir string,
+	volumeID string,
+	size int64,
+	nodes []string,
+	dial func(node string) (*NBDClient, error),
+	changed func(),
+) (*replication, error) {
+	r := &replication{
+		dir:      dir,
+		volumeID: volumeID,
+		size:     size,
+		dial:     dial,
+		changed:  changed,
+		busy:     make(map[int64]bool),
+	}
+	r.released = sync.NewCond(&r.Mutex)
+	for _, node := range nodes {
+		dirty, err := openDirtyLog(path.Join(dir, node), size)
+		if err != nil {
+			return nil, err
+		}
+		r.replicas = append(r.replicas, &replica{
+			node:     node,
+			dirty:    dirty,
+			unsynced: make(map[int64]bool),
+		})
+	}
+	return r, nil
+}
+
+// status returns VOLUME_STATUS_DEGRADED if a replica is lost or out of sync.
+func (r *replication) status() api.VolumeStatus {
+	r.Lock()
+	defer r.Unlock()
+	for _, rep := range r.replicas {
+		if rep.client == nil || !rep.dirty.empty() {
+			return api.VolumeStatus_VOLUME_STATUS_DEGRADED
+		}
+	}
+	return api.VolumeStatus_VOLUME_STATUS_UP
+}
+
+// lost drops a replica after a failure and marks the regions it may have
+// missed.
+func (r *replication) lost(rep *replica, off, length int64, err error) {
+	logrus.Warnf("Lost replica of BUSE volume %s on node %s: %v", r.volumeID, rep.node, err)
+	if rep.client != nil {
+		rep.client.Close()
+		rep.client = nil
+	}
+	for region := range rep.flushing {
+		rep.unsynced[region] = true
+	}
+	if err := rep.dirty.markRegions(rep.unsynced); err != nil {
+		logrus.Errorf("Failed to save the dirty regions of volume %s on node %s: %v",
+			r.volumeID, rep.node, err)
+	}
+	rep.unsynced = make(map[int64]bool)
+	rep.flushing = nil
+	r.markDirty(rep, off, length)
+	if r.changed != nil {
+		r.changed()
+	}
+}
+
+func (r *replication) markDirty(rep *replica, off, length int64) {
+	if err := rep.dirty.mark(off, length); err != nil {
+		logrus.Errorf("Failed to save the dirty regions of volume %s on node %s: %v",
+			r.volumeID, rep.node, err)
+	}
+}
+
+// regions returns the first and last regions of a range, last is lower than
+// first for an empty range.
+func regions(off, length int64) (int64, int64) {
+	if length <= 0 {
+		return 0, -1
+	}
+	return off / dirtyRegionSize, (off + length - 1) / dirtyRegionSize
+}
+
+// acquire waits for the requests on the regions of a range to complete and
+// marks them busy.
+func (r *replication) acquire(off, length int64) {
+	first, last := regions(off, length)
+	r.Lock()
+	defer r.Unlock()
+	for {
+		busy := false
+		for region := first; region <= last && !busy; region++ {
+			busy = r.busy[region]
+		}
+		if !busy {
+			break
+		}
+		r.released.Wait()
+	}
+	for region := first; region <= last; region++ {
+		r.busy[region] = true
+	}
+}
+
+// release marks the regions of a range as no longer busy.
+func (r *replication) release(off, length int64) {
+	first, last := regions(off, length)
+	r.Lock()
+	defer r.Unlock()
+	for region := first; region <= last; region++ {
+		delete(r.busy, region)
+	}
+	r.released.Broadcast()
+}
+
+// targets returns the connected replicas and marks a range as missed by the
+// lost ones. Called with r locked.
+func (r *replication) targets(off, length int64) []target {
+	targets := make([]target, 0, len(r.replicas))
+	for _, rep := range r.replicas {
+		if rep.client == nil {
+			r.markDirty(rep, off, length)
+			continue
+		}
+		targets = append(targets, target{rep: rep, client: rep.client})
+	}
+	return targets
+}
+
+// send sends a request to replicas in parallel and returns their errors.
+func send(targets []target, fn func(*NBDClient) error) []error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(targets))
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, client *NBDClient) {
+			defer wg.Done()
+			errs[i] = fn(client)
+		}(i, t.client)
+	}
+	wg.Wait()
+	return errs
+}
+
+// failed drops the replicas which failed a request with the range of the
+// request marked. A replica lost since the request was sent only gets the
+// range marked. Called with r locked.
+func (r *replication) failed(targets []target, errs []error, off, length int64) {
+	for i, t := range targets {
+		if errs[i] == nil {
+			continue
+		}
+		if t.rep.client == t.client {
+			r.lost(t.rep, off, length, errs[i])
+		} else {
+			r.markDirty(t.rep, off, length)
+		}
+	}
+}
+
+// mirror sends a request on a range of the volume to the connected replicas.
+// The replicas failing it are dropped with the range marked, the others
+// have it unsynced until the next flush.
+func (r *replication) mirror(off, length int64, fn func(*NBDClient) error) {
+	r.Lock()
+	targets := r.targets(off, length)
+	r.Unlock()
+	errs := send(targets, fn)
+
+	r.Lock()
+	defer r.Unlock()
+	r.failed(targets, errs, off, length)
+	first, last := regions(off, length)
+	for i, t := range targets {
+		if errs[i] != nil {
+			continue
+		}
+		if t.rep.client != t.client {
+			// The request may not have been flushed before the replica was lost
+			r.markDirty(t.rep, off, length)
+			continue
+		}
+		for region := first; region <= last; region++ {
+			t.rep.unsynced[region] = true
+		}
+	}
+}
+
+// writeAt writes to the local device and then to the replicas. Writes to
+// other regions proceed meanwhile.
+func (r *replication) writeAt(dev ExportDevice, b []byte, off int64) (int, error) {
+	r.acquire(off, int64(len(b)))
+	defer r.release(off, int64(len(b)))
+	n, err := dev.WriteAt(b, off)
+	if err != nil || n == 0 {
+		return n, err
+	}
+	r.mirror(off, int64(n), func(client *NBDClient) error {
+		_, err := client.WriteAt(b[:n], off)
+		return err
+	})
+	return n, nil
+}
+
+// sync flushes the local device and the replicas. The regions written to a
+// replica before the flush are in sync once it succeeds.
+func (r *replication) sync(dev ExportDevice) error {
+	r.syncLock.Lock()
+	defer r.syncLock.Unlock()
+	if err := dev.Sync(); err != nil {
+		return err
+	}
+
+	r.Lock()
+	targets := r.targets(0, 0)
+	for _, t := range targets {
+		t.rep.flushing = t.rep.unsynced
+		t.rep.unsynced = make(map[int64]bool)
+	}
+	r.Unlock()
+	errs := send(targets, func(client *NBDClient) error {
+		return client.Sync()
+	})
+
+	r.Lock()
+	defer r.Unlock()
+	r.failed(targets, errs, 0, 0)
+	for _, t := range targets {
+		if t.rep.client == t.client {
+			t.rep.flushing = nil
+		}
+	}
+	return nil
+}
+
+// trim discards the ranges discarded by the local device on the replicas.
+func (r *replication) trim(dev *cowDevice, off, length int64) error {
+	r.acquire(off, length)
+	defer r.release(off, length)
+	discarded, err := dev.trim(off, length)
+	for _, rng := range discarded {
+		r.mirror(rng[0], rng[1], func(client *NBDClient) error {
+			return client.Trim(rng[0], rng[1])
+		})
+	}
+	return err
+}
+
+// markRegions marks regions of the volume as missed by the replicas, which
+// are copied by the next resync.
+func (r *replication) markRegions(regions map[int64]bool) error {
+	r.Lock()
+	defer r.Unlock()
+	for _, rep := range r.replicas {
+		if err := rep.dirty.markRegions(regions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markAll marks the whole volume as missed by the replicas.
+func (r *replication) markAll() error {
+	r.Lock()
+	defer r.Unlock()
+	for _, rep := range r.replicas {
+		if err := rep.dirty.mark(0, r.size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resync connects the lost replicas and copies the regions they missed.
+func (r *replication) resync(dev ExportDevice) {
+	changed := false
+	for _, rep := range r.replicas {
+		r.Lock()
+		connected := rep.client != nil
+		r.Unlock()
+		if !connected {
+			client, err := r.dial(rep.node)
+			if err != nil {
+				logrus.Debugf("Failed to connect to the replica of BUSE volume %s on node %s: %v",
+					r.volumeID, rep.node, err)
+				continue
+			}
+			if client.Size() != r.size {
+				client.Close()
+				logrus.Warnf("Replica of BUSE volume %s on node %s has size %d instead of %d",
+					r.volumeID, rep.node, client.Size(), r.size)
+				continue
+			}
+			r.Lock()
+			rep.client = client
+			r.Unlock()
+			logrus.Infof("Connected to the replica of BUSE volume %s on node %s", r.volumeID, rep.node)
+		}
+		if r.copyDirty(dev, rep) {
+			changed = true
+		}
+	}
+	if changed && r.changed != nil {
+		r.changed()
+	}
+}
+
+// copyDirty copies the dirty regions to a replica and returns true once the
+// replica is in sync. Writes to a region are held while it is copied.
+func (r *replication) copyDirty(dev ExportDevice, rep *replica) bool {
+	buf := make([]byte, dirtyRegionSize)
+	wasDirty := false
+	for {
+		r.Lock()
+		region, dirty := rep.dirty.next()
+		client := rep.client
+		r.Unlock()
+		if !dirty || client == nil {
+			break
+		}
+		wasDirty = true
+		off := region * dirtyRegionSize
+		b := buf
+		if rest := r.size - off; rest < int64(len(b)) {
+			b = b[:rest]
+		}
+		if !r.copyRegion(dev, target{rep: rep, client: client}, region, b) {
+			return false
+		}
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	if rep.client == nil || !rep.dirty.empty() {
+		return false
+	}
+	if wasDirty {
+		rep.dirty.remove()
+		logrus.Infof("Replica of BUSE volume %s on node %s is in sync", r.volumeID, rep.node)
+	}
+	return wasDirty
+}
+
+// copyRegion copies a region to a replica and clears it from its dirty
+// region log. It returns false if the region could not be copied.
+func (r *replication) copyRegion(dev ExportDevice, t target, region int64, b []byte) bool {
+	off := region * dirtyRegionSize
+	r.acquire(off, int64(len(b)))
+	defer r.release(off, int64(len(b)))
+	if _, err := dev.ReadAt(b, off); err != nil && err != io.EOF {
+		logrus.Errorf("Failed to read region %d of BUSE volume %s: %v", region, r.volumeID, err)
+		return false
+	}
+	var err error
+	if isZero(b) {
+		err = t.client.Trim(off, int64(len(b)))
+	} else {
+		_, err = t.client.WriteAt(b, off)
+	}
+	if err == nil {
+		err = t.client.Sync()
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	if err != nil {
+		r.failed([]target{t}, []error{err}, off, int64(len(b)))
+		return false
+	}
+	if err := t.rep.dirty.clear(region); err != nil {
+		logrus.Warnf("Failed to clear region %d of replica of BUSE volume %s on node %s: %v",
+			region, r.volumeID, t.rep.node, err)
+	}
+	return true
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// close disconnects the replicas.
+func (r *replication) close() {
+	r.Lock()
+	defer r.Unlock()
+	for _, rep := range r.replicas {
+		if rep.client != nil {
+			rep.client.Close()
+			rep.client = nil
+		}
+		if rep.dirty.file != nil {
+			rep.dirty.file.Close()
+			rep.dirty.file = nil
+		}
+	}
+}
+
+// remove deletes the dirty region logs of a deleted volume.
+func (r *replication) remove() {
+	r.close()
+	os.RemoveAll(r.dir)
+}
+
+// replicaFile is the copy on this node of a volume owned by another node.
+type replicaFile struct {
+	*os.File
+}
+
+// Trim discards a range of the replica, which then reads as zeros.
+func (f *replicaFile) Trim(off, length int64) error {
+	const fallocPunchHole = 0x01 | 0x02 // FALLOC_FL_KEEP_SIZE | FALLOC_FL_PUNCH_HOLE
+	if err := syscall.Fallocate(int(f.Fd()), fallocPunchHole, off, length); err != nil {
+		_, err = f.WriteAt(make([]byte, length), off)
+		return err
+	}
+	return nil
+}
+
+func replicaPath(volumeID string) string {
+	return path.Join(BuseMountPath, replicasDir, volumeID)
+}
+
+// replicaNodes returns the nodes holding the replicas of a volume.
+func replicaNodes(v *api.Volume) []string {
+	if len(v.ReplicaSets) == 0 || len(v.ReplicaSets[0].Nodes) < 2 {
+		return nil
+	}
+	return v.ReplicaSets[0].Nodes[1:]
+}
+
+func hasReplica(v *api.Volume, nodeID string) bool {
+	for _, node := range replicaNodes(v) {
+		if node == nodeID {
+			return true
+		}
+	}
+	return false
+}
+
+// replicaDevice opens the replica of a volume held by this node, creating
+// it on the first connection of the owner.
+func (d *driver) replicaDevice(volumeID string) (ExportDevice, int64, error) {
+	v, err := d.GetVol(volumeID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !hasReplica(v, selfNodeID()) {
+		return nil, 0, fmt.Errorf("Volume %s has no replica on this node", volumeID)
+	}
+	size := int64(v.GetSpec().GetSize())
+
+	d.devLock.Lock()
+	defer d.devLock.Unlock()
+	if f, ok := d.replicaFiles[volumeID]; ok {
+		return f, size, nil
+	}
+	if err := os.MkdirAll(path.Join(BuseMountPath, replicasDir), 0744); err != nil {
+		return nil, 0, err
+	}
+	f, err := os.OpenFile(replicaPath(volumeID), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	rf := &replicaFile{File: f}
+	d.replicaFiles[volumeID] = rf
+	return rf, size, nil
+}
+
+// placeReplicas returns the nodes holding the ha_level - 1 replicas of a new
+// volume. The nodes of the replica set of the spec are used first, then
+// other online nodes at random.
+func (d *driver) placeReplicas(spec *api.VolumeSpec) ([]string, error) {
+	count := int(spec.GetHaLevel()) - 1
+	if count <= 0 {
+		return nil, nil
+	}
+	if d.exports == nil {
+		return nil, fmt.Errorf("Volumes with ha_level %d need %s to be set",
+			spec.GetHaLevel(), ExportAddressParam)
+	}
+	c, err := clustermanager.Inst()
+	if err != nil {
+		return nil, err
+	}
+	cl, err := c.Enumerate()
+	if err != nil {
+		return nil, err
+	}
+	online := make(map[string]bool)
+	candidates := make([]string, 0, len(cl.Nodes))
+	for _, n := range cl.Nodes {
+		if n.Id != cl.NodeId && n.Status == api.Status_STATUS_OK {
+			online[n.Id] = true
+			candidates = append(candidates, n.Id)
+		}
+	}
+
+	nodes := make([]string, 0, count)
+	placed := make(map[string]bool)
+	for _, node := range spec.GetReplicaSet().GetNodes() {
+		if node == cl.NodeId || placed[node] || len(nodes) == count {
+			continue
+		}
+		if !online[node] {
+			return nil, fmt.Errorf("Replica node %s is not online", node)
+		}
+		nodes = append(nodes, node)
+		placed[node] = true
+	}
+	for _, i := range rand.Perm(len(candidates)) {
+		if len(nodes) == count {
+			break
+		}
+		if !placed[candidates[i]] {
+			nodes = append(nodes, candidates[i])
+			placed[candidates[i]] = true
+		}
+	}
+	if len(nodes) < count {
+		return nil, fmt.Errorf("Not enough online nodes for ha_level %d: %d other nodes available",
+			spec.GetHaLevel(), len(candidates))
+	}
+	return nodes, nil
+}
+
+// dialReplica connects to the replica of a volume on a node.
+func (d *driver) dialReplica(node, volumeID string) (*NBDClient, error) {
+	address, err := d.exportAddress(node)
+	if err != nil {
+		return nil, err
+	}
+	return DialNBD(address, d.clientTLS, replicaExportPrefix+volumeID)
+}
+
+// updateReplicaStatus records whether a volume is degraded.
+func (d *driver) updateReplicaStatus(volumeID string) {
+	bd, ok := d.device(volumeID)
+	if !ok || bd.rep == nil {
+		return
+	}
+	status := bd.rep.status()
+	v, err := d.GetVol(volumeID)
+	if err != nil || v.Status == status {
+		return
+	}
+	logrus.Infof("BUSE volume %s is %v", volumeID, status)
+	v.Status = status
+	if err := d.UpdateVol(v); err != nil {
+		logrus.Warnf("Failed to update BUSE volume %s: %v", volumeID, err)
+	}
+}
+
+// replicaLoop periodically resynchronizes the replicas of the volumes owned
+// by this node and removes the replicas of deleted volumes.
+func (d *driver) replicaLoop() {
+	for {
+		d.devLock.Lock()
+		devices := make([]*buseDev, 0, len(d.buseDevices))
+		for _, bd := range d.buseDevices {
+			if bd.rep != nil {
+				devices = append(devices, bd)
+			}
+		}
+		d.devLock.Unlock()
+		for _, bd := range devices {
+			bd.rep.resync(bd.cow)
+		}
+		d.collectReplicas()
+		time.Sleep(replicaResyncInterval)
+	}
+}
+
+// collectReplicas removes the replicas of the volumes which were deleted or
+// moved to other nodes.
+func (d *driver) collectReplicas() {
+	files, _ := ioutil.ReadDir(path.Join(BuseMountPath, replicasDir))
+	nodeID := selfNodeID()
+	for _, f := range files {
+		volumeID := f.Name()
+		v, err := d.GetVol(volumeID)
+		if err == nil && hasReplica(v, nodeID) {
+			continue
+		}
+		if err != nil && err != kvdb.ErrNotFound {
+			continue
+		}
+		logrus.Infof("Removing replica of BUSE volume %s", volumeID)
+		d.devLock.Lock()
+		if rf, ok := d.replicaFiles[volumeID]; ok {
+			rf.Close()
+			delete(d.replicaFiles, volumeID)
+		}
+		d.devLock.Unlock()
+		os.Remove(replicaPath(volumeID))
+	}
+}
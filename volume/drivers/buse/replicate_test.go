@@ -0,0 +1,206 @@
+package buse
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libopenstorage/openstorage/api"
+)
+
+// testReplicaServer exports a replica file over a loopback port.
+type testReplicaServer struct {
+	file    *replicaFile
+	server  *NBDServer
+	address string
+}
+
+func startReplicaServer(t *testing.T, file *replicaFile, size int64) *testReplicaServer {
+	lookup := func(name string) (ExportDevice, int64, error) {
+		if name != replicaExportPrefix+"vol" {
+			return nil, 0, fmt.Errorf("Volume %s has no replica on this node", name)
+		}
+		return file, size, nil
+	}
+	s := NewNBDServer(nil, lookup, func() []string { return nil })
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go s.Serve(l)
+	return &testReplicaServer{file: file, server: s, address: l.Addr().String()}
+}
+
+func readReplica(t *testing.T, rs *testReplicaServer) []byte {
+	b, err := ioutil.ReadFile(rs.file.Name())
+	require.NoError(t, err)
+	return b
+}
+
+func TestReplication(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buse-replication")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	size := int64(3*dirtyRegionSize + 100)
+	st := newDeviceState()
+	base := testLayer(t, dir, "base", "", size)
+	st.Layers[base.ID] = base
+	c, err := openCowDevice(st.chain(base.ID))
+	require.NoError(t, err)
+	defer c.close()
+	_, err = c.WriteAt([]byte("formatted"), dirtyRegionSize)
+	require.NoError(t, err)
+
+	var lock sync.Mutex
+	servers := make(map[string]*testReplicaServer)
+	for _, node := range []string{"node1", "node2"} {
+		f, err := os.OpenFile(filepath.Join(dir, node), os.O_RDWR|os.O_CREATE, 0644)
+		require.NoError(t, err)
+		defer f.Close()
+		require.NoError(t, f.Truncate(size))
+		servers[node] = startReplicaServer(t, &replicaFile{File: f}, size)
+		defer func(node string) {
+			lock.Lock()
+			defer lock.Unlock()
+			servers[node].server.Close()
+		}(node)
+	}
+	dial := func(node string) (*NBDClient, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		return DialNBD(servers[node].address, nil, replicaExportPrefix+"vol")
+	}
+	dirtyDir := filepath.Join(dir, "dirty")
+	r, err := newReplication(dirtyDir, "vol", size, []string{"node1", "node2"}, dial, nil)
+	require.NoError(t, err)
+	defer r.close()
+
+	// New replicas get the data of the volume by a resync
+	assert.Equal(t, api.VolumeStatus_VOLUME_STATUS_DEGRADED, r.status())
+	require.NoError(t, r.markRegions(c.regions(dirtyRegionSize)))
+	r.resync(c)
+	assert.Equal(t, api.VolumeStatus_VOLUME_STATUS_UP, r.status())
+	for _, rs := range servers {
+		assert.Equal(t, readAll(t, c), readReplica(t, rs))
+	}
+	_, err = os.Stat(filepath.Join(dirtyDir, "node1"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Writes are mirrored before completing
+	data := bytes.Repeat([]byte{1}, 2*cowChunkSize)
+	n, err := r.writeAt(c, data, 10)
+	require.NoError(t, err)
+	assert.Equal(t, len(data), n)
+	require.NoError(t, r.sync(c))
+	for _, rs := range servers {
+		assert.Equal(t, readAll(t, c), readReplica(t, rs))
+	}
+
+	// A lost replica degrades the volume and its missed regions are logged
+	lock.Lock()
+	servers["node2"].server.Close()
+	lock.Unlock()
+	_, err = r.writeAt(c, data, 2*dirtyRegionSize)
+	require.NoError(t, err)
+	assert.Equal(t, api.VolumeStatus_VOLUME_STATUS_DEGRADED, r.status())
+	assert.Equal(t, readAll(t, c), readReplica(t, servers["node1"]))
+	assert.NotEqual(t, readAll(t, c), readReplica(t, servers["node2"]))
+	dirty, err := openDirtyLog(filepath.Join(dirtyDir, "node2"), size)
+	require.NoError(t, err)
+	region, ok := dirty.next()
+	require.True(t, ok)
+	assert.Equal(t, int64(2), region)
+
+	// Writes while the replica is lost are logged too
+	_, err = r.writeAt(c, data[:cowChunkSize+100], 3*dirtyRegionSize-cowChunkSize)
+	require.NoError(t, err)
+	dirty, err = openDirtyLog(filepath.Join(dirtyDir, "node2"), size)
+	require.NoError(t, err)
+	assert.True(t, dirty.isDirty(3))
+
+	// The replica is resynchronized once it is back
+	lock.Lock()
+	servers["node2"] = startReplicaServer(t, servers["node2"].file, size)
+	lock.Unlock()
+	r.resync(c)
+	assert.Equal(t, api.VolumeStatus_VOLUME_STATUS_UP, r.status())
+	for _, rs := range servers {
+		assert.Equal(t, readAll(t, c), readReplica(t, rs))
+	}
+	_, err = os.Stat(filepath.Join(dirtyDir, "node2"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Discarded chunks are discarded on the replicas
+	require.NoError(t, r.trim(c, 0, 2*cowChunkSize))
+	expected := readAll(t, c)
+	assert.Equal(t, make([]byte, cowChunkSize), expected[cowChunkSize:2*cowChunkSize])
+	for _, rs := range servers {
+		assert.Equal(t, expected, readReplica(t, rs))
+	}
+
+	// A full resync after a restore copies the whole volume
+	require.NoError(t, r.markAll())
+	assert.Equal(t, api.VolumeStatus_VOLUME_STATUS_DEGRADED, r.status())
+	r.resync(c)
+	assert.Equal(t, api.VolumeStatus_VOLUME_STATUS_UP, r.status())
+}
+
+func TestReplicationConcurrentWrites(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buse-replication")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	size := int64(4 * dirtyRegionSize)
+	st := newDeviceState()
+	base := testLayer(t, dir, "base", "", size)
+	st.Layers[base.ID] = base
+	c, err := openCowDevice(st.chain(base.ID))
+	require.NoError(t, err)
+	defer c.close()
+
+	f, err := os.OpenFile(filepath.Join(dir, "node1"), os.O_RDWR|os.O_CREATE, 0644)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, f.Truncate(size))
+	rs := startReplicaServer(t, &replicaFile{File: f}, size)
+	defer rs.server.Close()
+	dial := func(node string) (*NBDClient, error) {
+		return DialNBD(rs.address, nil, replicaExportPrefix+"vol")
+	}
+	r, err := newReplication(filepath.Join(dir, "dirty"), "vol", size, []string{"node1"}, dial, nil)
+	require.NoError(t, err)
+	defer r.close()
+	r.resync(c)
+	require.Equal(t, api.VolumeStatus_VOLUME_STATUS_UP, r.status())
+
+	// Writes to the same and to other regions, and a resync, run at the
+	// same time and leave the replica identical to the volume
+	require.NoError(t, r.markRegions(map[int64]bool{3: true}))
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			data := bytes.Repeat([]byte{byte(i + 1)}, cowChunkSize)
+			off := int64(i%4)*dirtyRegionSize + int64(i%2)*cowChunkSize/2
+			_, err := r.writeAt(c, data, off)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.resync(c)
+	}()
+	wg.Wait()
+	require.NoError(t, r.sync(c))
+	assert.Equal(t, api.VolumeStatus_VOLUME_STATUS_UP, r.status())
+	assert.Equal(t, readAll(t, c), readReplica(t, rs))
+}
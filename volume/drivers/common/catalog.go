@@ -0,0 +1,79 @@
+package common
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/proto/time"
+)
+
+const (
+	// CatalogDirectory is the type of the catalog of a directory
+	CatalogDirectory = "Directory"
+	// CatalogFile is the type of the catalog of a file
+	CatalogFile = "File"
+)
+
+// Catalog lists the files of a subdirectory of root, down to depth levels
+// of subdirectories or all of them if depth is empty or 0.
+func Catalog(root, subdir, depth string) (api.CatalogResponse, error) {
+	maxDepth := 0
+	if len(depth) != 0 {
+		var err error
+		if maxDepth, err = strconv.Atoi(depth); err != nil || maxDepth < 0 {
+			return api.CatalogResponse{}, fmt.Errorf("Invalid catalog depth %q", depth)
+		}
+	}
+	// The subdirectory cannot be outside of root.
+	dir := filepath.Join(root, filepath.Clean("/"+subdir))
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return api.CatalogResponse{}, err
+	}
+	report := &api.Report{}
+	c, err := catalogEntry(dir, fi, 1, maxDepth, report)
+	if err != nil {
+		return api.CatalogResponse{}, err
+	}
+	return api.CatalogResponse{Root: c, Report: report}, nil
+}
+
+// catalogEntry returns the catalog of a file, with the children of a
+// directory if level is not beyond maxDepth.
+func catalogEntry(p string, fi os.FileInfo, level, maxDepth int, report *api.Report) (*api.Catalog, error) {
+	c := &api.Catalog{
+		Name:         fi.Name(),
+		Path:         p,
+		Type:         CatalogFile,
+		Size:         uint64(fi.Size()),
+		LastModified: prototime.TimeToTimestamp(fi.ModTime()),
+	}
+	if !fi.IsDir() {
+		return c, nil
+	}
+	c.Type = CatalogDirectory
+	if maxDepth != 0 && level > maxDepth {
+		return c, nil
+	}
+	children, err := ioutil.ReadDir(p)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		if child.IsDir() {
+			report.Directories++
+		} else {
+			report.Files++
+		}
+		cc, err := catalogEntry(filepath.Join(p, child.Name()), child, level+1, maxDepth, report)
+		if err != nil {
+			return nil, err
+		}
+		c.Children = append(c.Children, cc)
+	}
+	return c, nil
+}
@@ -0,0 +1,48 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog(t *testing.T) {
+	root, err := ioutil.TempDir("", "catalog")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "a", "b"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "a", "b", "deep"), []byte("1"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "a", "file"), []byte("12"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "top"), []byte("123"), 0644))
+
+	resp, err := Catalog(root, "", "0")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), resp.Report.Directories)
+	assert.Equal(t, int64(3), resp.Report.Files)
+	assert.Equal(t, CatalogDirectory, resp.Root.Type)
+	require.Len(t, resp.Root.Children, 2)
+	assert.Equal(t, "top", resp.Root.Children[1].Name)
+	assert.Equal(t, uint64(3), resp.Root.Children[1].Size)
+
+	resp, err = Catalog(root, "", "1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Report.Directories)
+	assert.Equal(t, int64(1), resp.Report.Files)
+	assert.Empty(t, resp.Root.Children[0].Children)
+
+	resp, err = Catalog(root, "a", "")
+	require.NoError(t, err)
+	assert.Equal(t, "a", resp.Root.Name)
+	assert.Equal(t, int64(2), resp.Report.Files)
+
+	// Paths cannot escape the volume
+	resp, err = Catalog(filepath.Join(root, "a"), "../top", "")
+	assert.Error(t, err)
+
+	_, err = Catalog(root, "", "deep")
+	assert.Error(t, err)
+}
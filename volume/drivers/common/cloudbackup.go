@@ -0,0 +1,1030 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/portworx/kvdb"
+	"github.com/sirupsen/logrus"
+
+	"github.com/libopenstorage/openstorage/alerts"
+	"github.com/libopenstorage/openstorage/api"
+	clustermanager "github.com/libopenstorage/openstorage/cluster/manager"
+	"github.com/libopenstorage/openstorage/pkg/sched"
+	"github.com/libopenstorage/openstorage/volume"
+)
+
+const (
+	// Metadata keys of the backups returned by CloudBackupEnumerate
+	backupMetaType     = "type"
+	backupMetaParent   = "parent"
+	backupMetaKind     = "kind"
+	backupMetaSize     = "size"
+	backupMetaUploaded = "uploaded"
+
+	backupTypeFull        = "full"
+	backupTypeIncremental = "incremental"
+
+	// taskSaveInterval is how often the progress of a task is saved
+	taskSaveInterval = time.Second
+)
+
+var (
+	errTaskStopped = errors.New("Task was stopped")
+)
+
+// CloudBackupVolumes is the set of volume operations needed by the cloud
+// backup provider returned by NewCloudBackupProvider.
+type CloudBackupVolumes interface {
+	Inspect(volumeIDs []string) ([]*api.Volume, error)
+	Enumerate(locator *api.VolumeLocator, labels map[string]string) ([]*api.Volume, error)
+	Create(locator *api.VolumeLocator, source *api.Source, spec *api.VolumeSpec) (string, error)
+	Delete(volumeID string) error
+	// CloudBackupPath returns the directory holding the files of the volume,
+	// or the file or block device holding its data.
+	CloudBackupPath(volumeID string) (string, error)
+}
+
+// CloudBackupProvider manages cloud credentials and backs up volumes to the
+// targets they describe.
+type CloudBackupProvider interface {
+	volume.CredsDriver
+	volume.CloudBackupDriver
+	// Stop stops enforcing the retention policies and running the scrubs
+	// in the background.
+	Stop()
+}
+
+// cloudCred is a credential as stored in kvdb.
+type cloudCred struct {
+	Id     string
+	Params map[string]interface{}
+}
+
+// cloudBackupTask is a backup or restore operation as stored in kvdb.
+type cloudBackupTask struct {
+	Name string
+	// BackupID is the id of the backup being created or restored
+	BackupID  string
+	ClusterID string
+	Status    api.CloudBackupStatus
+}
+
+// cloudBackupJob tracks a running task.
+type cloudBackupJob struct {
+	sync.Mutex
+	cond     *sync.Cond
+	provider *cloudBackupProvider
+	task     cloudBackupTask
+	stopped  bool
+	lastSave time.Time
+}
+
+type cloudBackupProvider struct {
+	sync.Mutex
+	driver  string
+	kv      kvdb.Kvdb
+	volumes CloudBackupVolumes
+	jobs    map[string]*cloudBackupJob
+	// backups is the number of backups writing chunks, during which chunks
+	// must not be garbage collected.
+	backups int
+	// collecting is set while unreferenced chunks are garbage collected.
+	collecting bool
+	cond       *sync.Cond
+	// retentionLock serializes the enforcement of retention policies.
+	retentionLock sync.Mutex
+	alerts        alerts.Manager
+	// scrubber runs the scheduled verifications of the backups, whose tasks
+	// are tracked by credential in scrubTasks.
+	scrubber   sched.Scheduler
+	scrubTasks map[string][]sched.TaskID
+	// stop is closed by Stop to end the background tasks
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewCloudBackupProvider returns a cloud backup provider storing its state in
+// kvdb under the name of the driver. Backups are chunked, deduplicated,
+// compressed and, if the credential has an encryption key, encrypted before
+// being written to a local directory or an S3 compatible object store. The
+// retention policies of the credentials are enforced in the background.
+func NewCloudBackupProvider(
+	driver string,
+	kv kvdb.Kvdb,
+	volumes CloudBackupVolumes,
+) CloudBackupProvider {
+	p := &cloudBackupProvider{
+		driver:  driver,
+		kv:      kv,
+		volumes: volumes,
+		jobs:    make(map[string]*cloudBackupJob),
+		// scrubTasks by credential
+		scrubTasks: make(map[string][]sched.TaskID),
+		stop:       make(chan struct{}),
+	}
+	p.cond = sync.NewCond(&p.Mutex)
+	if manager, err := alerts.NewManager(kv); err != nil {
+		logrus.Warnf("Failed to create the alerts manager of %s cloud backups: %v", driver, err)
+	} else {
+		p.alerts = manager
+	}
+	p.failInterruptedTasks()
+	p.loadScrubs()
+	go p.reapBackups()
+	return p
+}
+
+// Stop stops the background tasks of the provider
+func (p *cloudBackupProvider) Stop() {
+	p.stopOnce.Do(func() { close(p.stop) })
+
+	p.Lock()
+	defer p.Unlock()
+	if p.scrubber != nil {
+		p.scrubber.Stop()
+	}
+}
+
+func (p *cloudBackupProvider) credsKeyPrefix() string {
+	return fmt.Sprintf("%s/%s/credentials/", keyBase, p.driver)
+}
+
+func (p *cloudBackupProvider) tasksKeyPrefix() string {
+	return fmt.Sprintf("%s/%s/cloudbackup/tasks/", keyBase, p.driver)
+}
+
+// clusterInfo returns the ids of the cluster and of this node, which are
+// empty if the cluster manager is not running.
+func (p *cloudBackupProvider) clusterInfo() (string, string) {
+	c, err := clustermanager.Inst()
+	if err != nil {
+		return "", ""
+	}
+	cl, err := c.Enumerate()
+	if err != nil {
+		return "", ""
+	}
+	return cl.Id, cl.NodeId
+}
+
+// failInterruptedTasks marks the tasks which were running on this node when
+// it stopped as failed.
+func (p *cloudBackupProvider) failInterruptedTasks() {
+	_, nodeID := p.clusterInfo()
+	tasks, err := p.enumerateTasks()
+	if err != nil {
+		logrus.Warnf("Failed to enumerate %s cloud backup tasks: %v", p.driver, err)
+		return
+	}
+	for _, task := range tasks {
+		switch task.Status.Status {
+		case api.CloudBackupStatusActive, api.CloudBackupStatusPaused,
+			api.CloudBackupStatusNotStarted:
+		default:
+			continue
+		}
+		if task.Status.NodeID != nodeID {
+			continue
+		}
+		task.Status.Status = api.CloudBackupStatusFailed
+		task.Status.CompletedTime = time.Now()
+		task.Status.Info = append(task.Status.Info, "Interrupted by a restart")
+		if _, err := p.kv.Put(p.tasksKeyPrefix()+task.Name, task, 0); err != nil {
+			logrus.Warnf("Failed to update cloud backup task %s: %v", task.Name, err)
+		}
+	}
+}
+
+// CredsCreate creates a credential for a local directory or an S3
+// compatible object store.
+func (p *cloudBackupProvider) CredsCreate(params map[string]string) (string, error) {
+	converted := make(map[string]interface{})
+	for k, v := range params {
+		converted[k] = v
+	}
+	id := uuid.New()
+	if _, err := newBackupTarget(id, converted); err != nil {
+		return "", err
+	}
+	_, err := p.kv.Create(p.credsKeyPrefix()+id, &cloudCred{
+		Id:     id,
+		Params: converted,
+	}, 0)
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// CredsEnumerate lists the configured credentials.
+func (p *cloudBackupProvider) CredsEnumerate() (map[string]interface{}, error) {
+	kvp, err := p.kv.Enumerate(p.credsKeyPrefix())
+	if err != nil {
+		return nil, err
+	}
+	creds := make(map[string]interface{}, len(kvp))
+	for _, v := range kvp {
+		elem := &cloudCred{}
+		if err := json.Unmarshal(v.Value, elem); err != nil {
+			return nil, err
+		}
+		creds[elem.Id] = elem.Params
+	}
+	return creds, nil
+}
+
+// CredsDelete deletes a credential.
+func (p *cloudBackupProvider) CredsDelete(credUUID string) error {
+	if _, err := p.kv.Delete(p.credsKeyPrefix() + credUUID); err != nil && err != kvdb.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+// CredsValidate checks that the target of the credential can be reached,
+// creating its bucket if needed.
+func (p *cloudBackupProvider) CredsValidate(credUUID string) error {
+	target, _, err := p.target(credUUID)
+	if err != nil {
+		return err
+	}
+	return target.validate()
+}
+
+// target returns the backup target and codec of a credential.
+func (p *cloudBackupProvider) target(credUUID string) (backupTarget, *objectCodec, error) {
+	var cred cloudCred
+	if _, err := p.kv.GetVal(p.credsKeyPrefix()+credUUID, &cred); err != nil {
+		return nil, nil, fmt.Errorf("Credential id %s not found", credUUID)
+	}
+	target, err := newBackupTarget(credUUID, cred.Params)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, _ := cred.Params[api.OptCredEncrKey].(string)
+	codec, err := newObjectCodec(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return target, codec, nil
+}
+
+func (p *cloudBackupProvider) volume(volumeID string) (*api.Volume, error) {
+	vols, err := p.volumes.Inspect([]string{volumeID})
+	if err != nil {
+		return nil, err
+	}
+	if len(vols) != 1 {
+		return nil, fmt.Errorf("Volume id %s not found", volumeID)
+	}
+	return vols[0], nil
+}
+
+// CloudBackupCreate starts a backup of a volume. Unless a full backup is
+// requested, the backup is incremental to the most recent backup of the
+// volume on the same target.
+func (p *cloudBackupProvider) CloudBackupCreate(
+	input *api.CloudBackupCreateRequest,
+) (*api.CloudBackupCreateResponse, error) {
+	target, codec, err := p.target(input.CredentialUUID)
+	if err != nil {
+		return nil, err
+	}
+	vol, err := p.volume(input.VolumeID)
+	if err != nil {
+		return nil, err
+	}
+	dataPath, err := p.volumes.CloudBackupPath(vol.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	clusterID, nodeID := p.clusterInfo()
+	job, err := p.startJob(input.Name, cloudBackupTask{
+		BackupID:  uuid.New(),
+		ClusterID: clusterID,
+		Status: api.CloudBackupStatus{
+			OpType:         api.CloudBackupOp,
+			Status:         api.CloudBackupStatusActive,
+			StartTime:      time.Now(),
+			NodeID:         nodeID,
+			SrcVolumeID:    vol.GetId(),
+			CredentialUUID: input.CredentialUUID,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		err := p.backup(job, target, codec, vol, dataPath, input)
+		if err == nil {
+			p.enforceRetention(input.CredentialUUID)
+		}
+		p.finishJob(job, err)
+	}()
+	return &api.CloudBackupCreateResponse{Name: job.task.Name}, nil
+}
+
+func (p *cloudBackupProvider) backup(
+	job *cloudBackupJob,
+	target backupTarget,
+	codec *objectCodec,
+	vol *api.Volume,
+	dataPath string,
+	input *api.CloudBackupCreateRequest,
+) error {
+	p.beginBackup()
+	defer p.endBackup()
+
+	total, err := dataSize(dataPath)
+	if err != nil {
+		return err
+	}
+	job.Lock()
+	job.task.Status.BytesTotal = total
+	job.Unlock()
+
+	var parent *backupManifest
+	info := backupInfo{
+		ID:            job.task.BackupID,
+		ClusterID:     job.task.ClusterID,
+		SrcVolumeID:   vol.GetId(),
+		SrcVolumeName: vol.GetLocator().GetName(),
+		Labels:        input.Labels,
+	}
+	if !input.Full {
+		if latest, err := p.latestBackup(target, codec, vol.GetId()); err != nil {
+			return err
+		} else if latest != nil {
+			parent = &backupManifest{}
+			if err := getObject(target, codec, backupManifestKey(latest.ID), parent); err != nil {
+				return err
+			}
+			info.Parent = latest.ID
+		}
+	}
+
+	w, err := newChunkWriter(target, codec, input.Full, job.progress)
+	if err != nil {
+		return err
+	}
+	m := &backupManifest{
+		ID:     info.ID,
+		Volume: vol,
+	}
+	if err := w.backup(dataPath, m, parent); err != nil {
+		return err
+	}
+
+	info.Kind = m.Kind
+	info.Size = total
+	info.Uploaded = w.uploaded
+	info.Timestamp = time.Now()
+	if err := putObject(target, codec, backupManifestKey(info.ID), m); err != nil {
+		return err
+	}
+	// The info is written last since its presence makes the backup visible
+	return putObject(target, codec, backupInfoKey(info.ID), &info)
+}
+
+// latestBackup returns the most recent backup of a volume, or nil if the
+// volume has no backups.
+func (p *cloudBackupProvider) latestBackup(
+	target backupTarget,
+	codec *objectCodec,
+	volumeID string,
+) (*backupInfo, error) {
+	infos, err := p.backupInfos(target, codec)
+	if err != nil {
+		return nil, err
+	}
+	var latest *backupInfo
+	for _, info := range infos {
+		if info.SrcVolumeID != volumeID {
+			continue
+		}
+		if latest == nil || info.Timestamp.After(latest.Timestamp) {
+			latest = info
+		}
+	}
+	return latest, nil
+}
+
+// backupInfos returns the infos of all the backups on the target, sorted by
+// time.
+func (p *cloudBackupProvider) backupInfos(
+	target backupTarget,
+	codec *objectCodec,
+) ([]*backupInfo, error) {
+	keys, err := target.list(backupsPrefix)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]*backupInfo, 0, len(keys))
+	for _, key := range keys {
+		if !strings.HasSuffix(key, "/info") {
+			continue
+		}
+		info := &backupInfo{}
+		if err := getObject(target, codec, key, info); err != nil {
+			if err == errObjectNotFound {
+				// Deleted while listing
+				continue
+			}
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Timestamp.Before(infos[j].Timestamp)
+	})
+	return infos, nil
+}
+
+// CloudBackupGroupCreate starts a backup of each of the volumes matching
+// the request.
+func (p *cloudBackupProvider) CloudBackupGroupCreate(
+	input *api.CloudBackupGroupCreateRequest,
+) error {
+	vols, err := p.volumes.Enumerate(nil, nil)
+	if err != nil {
+		return err
+	}
+	selected := make([]*api.Volume, 0)
+	for _, v := range vols {
+		if len(input.GroupID) != 0 && v.GetSpec().GetGroup().GetId() != input.GroupID {
+			continue
+		}
+		if !hasSubset(v.GetLocator().GetVolumeLabels(), input.Labels) {
+			continue
+		}
+		if !contains(v.GetId(), input.VolumeIDs) {
+			continue
+		}
+		selected = append(selected, v)
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("No volumes found matching the group backup request")
+	}
+
+	for _, v := range selected {
+		labels := make(map[string]string)
+		if len(input.GroupID) != 0 {
+			labels["group"] = input.GroupID
+		}
+		_, err := p.CloudBackupCreate(&api.CloudBackupCreateRequest{
+			VolumeID:       v.GetId(),
+			CredentialUUID: input.CredentialUUID,
+			Full:           input.Full,
+			Labels:         labels,
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to start backup of volume %s: %v", v.GetId(), err)
+		}
+	}
+	return nil
+}
+
+// CloudBackupRestore creates a volume and starts restoring a backup to it.
+// The volume is deleted if the restore fails.
+func (p *cloudBackupProvider) CloudBackupRestore(
+	input *api.CloudBackupRestoreRequest,
+) (*api.CloudBackupRestoreResponse, error) {
+	target, codec, err := p.target(input.CredentialUUID)
+	if err != nil {
+		return nil, err
+	}
+	m := &backupManifest{}
+	if err := getObject(target, codec, backupManifestKey(input.ID), m); err == errObjectNotFound {
+		return nil, fmt.Errorf("Backup %s not found", input.ID)
+	} else if err != nil {
+		return nil, err
+	}
+
+	name := input.RestoreVolumeName
+	if len(name) == 0 {
+		name = "restore-" + input.ID
+	}
+	spec := m.Volume.GetSpec()
+	if spec == nil {
+		return nil, fmt.Errorf("Backup %s has no volume specification", input.ID)
+	}
+	volumeID, err := p.volumes.Create(&api.VolumeLocator{Name: name}, &api.Source{}, spec)
+	if err != nil {
+		return nil, err
+	}
+	dataPath, err := p.volumes.CloudBackupPath(volumeID)
+	if err != nil {
+		p.volumes.Delete(volumeID)
+		return nil, err
+	}
+
+	clusterID, nodeID := p.clusterInfo()
+	job, err := p.startJob(input.Name, cloudBackupTask{
+		BackupID:  input.ID,
+		ClusterID: clusterID,
+		Status: api.CloudBackupStatus{
+			OpType:         api.CloudRestoreOp,
+			Status:         api.CloudBackupStatusActive,
+			StartTime:      time.Now(),
+			NodeID:         nodeID,
+			SrcVolumeID:    volumeID,
+			CredentialUUID: input.CredentialUUID,
+		},
+	})
+	if err != nil {
+		p.volumes.Delete(volumeID)
+		return nil, err
+	}
+
+	go func() {
+		r := &chunkReader{
+			target:   target,
+			codec:    codec,
+			progress: job.progress,
+		}
+		err := r.restore(dataPath, m)
+		if err != nil {
+			if err := p.volumes.Delete(volumeID); err != nil {
+				logrus.Warnf("Failed to delete volume %s of failed restore: %v",
+					volumeID, err)
+			}
+		}
+		p.finishJob(job, err)
+	}()
+
+	return &api.CloudBackupRestoreResponse{
+		RestoreVolumeID: volumeID,
+		Name:            job.task.Name,
+	}, nil
+}
+
+// CloudBackupEnumerate lists the backups on the target of the credential.
+// Unless All is set, only backups of this cluster are returned if no
+// cluster is given.
+func (p *cloudBackupProvider) CloudBackupEnumerate(
+	input *api.CloudBackupEnumerateRequest,
+) (*api.CloudBackupEnumerateResponse, error) {
+	target, codec, err := p.target(input.CredentialUUID)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := p.matchingBackups(target, codec, &input.CloudBackupGenericRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]api.CloudBackupInfo, 0, len(infos))
+	for _, info := range infos {
+		backup := info.cloudBackupInfo()
+		v := &api.CloudBackupVerification{}
+		if err := getObject(target, codec, backupVerificationKey(info.ID), v); err == nil {
+			backup.Verification = v
+		} else if err != errObjectNotFound {
+			return nil, err
+		}
+		backups = append(backups, backup)
+	}
+	return &api.CloudBackupEnumerateResponse{
+		Backups: backups,
+	}, nil
+}
+
+func (p *cloudBackupProvider) matchingBackups(
+	target backupTarget,
+	codec *objectCodec,
+	input *api.CloudBackupGenericRequest,
+) ([]*backupInfo, error) {
+	infos, err := p.backupInfos(target, codec)
+	if err != nil {
+		return nil, err
+	}
+	clusterID := input.ClusterID
+	if len(clusterID) == 0 && !input.All {
+		clusterID, _ = p.clusterInfo()
+	}
+
+	matching := make([]*backupInfo, 0, len(infos))
+	for _, info := range infos {
+		if len(input.SrcVolumeID) != 0 && info.SrcVolumeID != input.SrcVolumeID {
+			continue
+		}
+		if len(clusterID) != 0 && info.ClusterID != clusterID {
+			continue
+		}
+		matching = append(matching, info)
+	}
+	return matching, nil
+}
+
+func (info *backupInfo) cloudBackupInfo() api.CloudBackupInfo {
+	metadata := make(map[string]string)
+	for k, v := range info.Labels {
+		metadata[k] = v
+	}
+	metadata[backupMetaType] = backupTypeFull
+	if len(info.Parent) != 0 {
+		metadata[backupMetaType] = backupTypeIncremental
+		metadata[backupMetaParent] = info.Parent
+	}
+	metadata[backupMetaKind] = info.Kind
+	metadata[backupMetaSize] = fmt.Sprintf("%d", info.Size)
+	metadata[backupMetaUploaded] = fmt.Sprintf("%d", info.Uploaded)
+
+	return api.CloudBackupInfo{
+		ID:            info.ID,
+		SrcVolumeID:   info.SrcVolumeID,
+		SrcVolumeName: info.SrcVolumeName,
+		Timestamp:     info.Timestamp,
+		Metadata:      metadata,
+		Status:        string(api.CloudBackupStatusDone),
+	}
+}
+
+// CloudBackupDelete deletes a backup unless it is on hold. Backups never
+// depend on each other, so Force is not needed to delete a backup other
+// backups are based on.
+func (p *cloudBackupProvider) CloudBackupDelete(input *api.CloudBackupDeleteRequest) error {
+	target, codec, err := p.target(input.CredentialUUID)
+	if err != nil {
+		return err
+	}
+	info := &backupInfo{}
+	if err := getObject(target, codec, backupInfoKey(input.ID), info); err == errObjectNotFound {
+		return fmt.Errorf("Backup %s not found", input.ID)
+	} else if err != nil {
+		return err
+	}
+	if err := p.checkHold(input.CredentialUUID, info); err != nil {
+		return err
+	}
+	if err := p.deleteBackup(target, input.ID); err != nil {
+		return err
+	}
+	return p.collectChunks(target, codec)
+}
+
+// CloudBackupDeleteAll deletes the backups matching the request, except the
+// backups on hold.
+func (p *cloudBackupProvider) CloudBackupDeleteAll(input *api.CloudBackupDeleteAllRequest) error {
+	target, codec, err := p.target(input.CredentialUUID)
+	if err != nil {
+		return err
+	}
+	infos, err := p.matchingBackups(target, codec, &input.CloudBackupGenericRequest)
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		if err := p.checkHold(input.CredentialUUID, info); err != nil {
+			logrus.Infof("Not deleting cloud backup: %v", err)
+			continue
+		}
+		if err := p.deleteBackup(target, info.ID); err != nil {
+			return err
+		}
+	}
+	return p.collectChunks(target, codec)
+}
+
+func (p *cloudBackupProvider) deleteBackup(target backupTarget, backupID string) error {
+	// The info is deleted first so that the backup disappears at once
+	if err := target.delete(backupInfoKey(backupID)); err != nil {
+		return err
+	}
+	if err := target.delete(backupManifestKey(backupID)); err != nil {
+		return err
+	}
+	return target.delete(backupVerificationKey(backupID))
+}
+
+// beginBackup waits for any garbage collection to finish, and prevents new
+// ones until endBackup is called.
+func (p *cloudBackupProvider) beginBackup() {
+	p.Lock()
+	defer p.Unlock()
+	for p.collecting {
+		p.cond.Wait()
+	}
+	p.backups++
+}
+
+func (p *cloudBackupProvider) endBackup() {
+	p.Lock()
+	defer p.Unlock()
+	p.backups--
+	p.cond.Broadcast()
+}
+
+// collectChunks deletes the chunks not referenced by any backup. Since the
+// chunks written by running backups are not referenced yet, nothing is
+// collected while backups are running; the chunks will be collected by a
+// later deletion.
+func (p *cloudBackupProvider) collectChunks(target backupTarget, codec *objectCodec) error {
+	p.Lock()
+	if p.backups != 0 || p.collecting {
+		p.Unlock()
+		return nil
+	}
+	p.collecting = true
+	p.Unlock()
+	defer func() {
+		p.Lock()
+		p.collecting = false
+		p.cond.Broadcast()
+		p.Unlock()
+	}()
+
+	keys, err := target.list(backupsPrefix)
+	if err != nil {
+		return err
+	}
+	referenced := make(map[string]bool)
+	for _, key := range keys {
+		if !strings.HasSuffix(key, "/manifest") {
+			continue
+		}
+		m := &backupManifest{}
+		if err := getObject(target, codec, key, m); err != nil {
+			if err == errObjectNotFound {
+				continue
+			}
+			// Never delete chunks which may be referenced
+			return fmt.Errorf("Failed to read %s: %v", key, err)
+		}
+		for _, id := range m.chunks() {
+			referenced[id] = true
+		}
+	}
+
+	chunks, err := target.list(chunksPrefix)
+	if err != nil {
+		return err
+	}
+	for _, key := range chunks {
+		if referenced[key[strings.LastIndex(key, "/")+1:]] {
+			continue
+		}
+		if err := target.delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CloudBackupStatus returns the status of the backup and restore tasks.
+func (p *cloudBackupProvider) CloudBackupStatus(
+	input *api.CloudBackupStatusRequest,
+) (*api.CloudBackupStatusResponse, error) {
+	tasks, err := p.enumerateTasks()
+	if err != nil {
+		return nil, err
+	}
+	_, nodeID := p.clusterInfo()
+
+	statuses := make(map[string]api.CloudBackupStatus)
+	for _, task := range tasks {
+		if len(input.Name) != 0 {
+			if task.Name == input.Name {
+				statuses[task.Name] = task.Status
+			}
+			continue
+		}
+		if len(input.SrcVolumeID) != 0 && task.Status.SrcVolumeID != input.SrcVolumeID {
+			continue
+		}
+		if input.Local && task.Status.NodeID != nodeID {
+			continue
+		}
+		statuses[task.Name] = task.Status
+	}
+	return &api.CloudBackupStatusResponse{
+		Statuses: statuses,
+	}, nil
+}
+
+// CloudBackupCatalog lists the files of a backup. Backups of block devices
+// have no listing.
+func (p *cloudBackupProvider) CloudBackupCatalog(
+	input *api.CloudBackupCatalogRequest,
+) (*api.CloudBackupCatalogResponse, error) {
+	target, codec, err := p.target(input.CredentialUUID)
+	if err != nil {
+		return nil, err
+	}
+	m := &backupManifest{}
+	if err := getObject(target, codec, backupManifestKey(input.ID), m); err == errObjectNotFound {
+		return nil, fmt.Errorf("Backup %s not found", input.ID)
+	} else if err != nil {
+		return nil, err
+	}
+	return &api.CloudBackupCatalogResponse{
+		Contents: m.contents(),
+	}, nil
+}
+
+// CloudBackupHistory lists the past backups of a volume and the deletions of
+// its backups by retention policies.
+func (p *cloudBackupProvider) CloudBackupHistory(
+	input *api.CloudBackupHistoryRequest,
+) (*api.CloudBackupHistoryResponse, error) {
+	tasks, err := p.enumerateTasks()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]api.CloudBackupHistoryItem, 0)
+	for _, task := range tasks {
+		if task.Status.OpType != api.CloudBackupOp &&
+			task.Status.OpType != api.CloudBackupDeleteOp {
+			continue
+		}
+		if len(input.SrcVolumeID) != 0 && task.Status.SrcVolumeID != input.SrcVolumeID {
+			continue
+		}
+		items = append(items, api.CloudBackupHistoryItem{
+			SrcVolumeID: task.Status.SrcVolumeID,
+			Timestamp:   task.Status.CompletedTime,
+			Status:      string(task.Status.Status),
+			ID:          task.BackupID,
+			OpType:      task.Status.OpType,
+			Info:        task.Status.Info,
+		})
+	}
+	return &api.CloudBackupHistoryResponse{
+		HistoryList: items,
+	}, nil
+}
+
+// CloudBackupStateChange pauses, resumes or stops a running task.
+func (p *cloudBackupProvider) CloudBackupStateChange(
+	input *api.CloudBackupStateChangeRequest,
+) error {
+	if len(input.Name) == 0 {
+		return fmt.Errorf("Name of the task must be provided")
+	}
+	p.Lock()
+	job, ok := p.jobs[input.Name]
+	p.Unlock()
+	if !ok {
+		return fmt.Errorf("Task %s is not running", input.Name)
+	}
+
+	job.Lock()
+	defer job.Unlock()
+	switch input.RequestedState {
+	case api.CloudBackupRequestedStatePause:
+		if job.task.Status.Status == api.CloudBackupStatusActive {
+			job.task.Status.Status = api.CloudBackupStatusPaused
+		}
+	case api.CloudBackupRequestedStateResume:
+		if job.task.Status.Status == api.CloudBackupStatusPaused {
+			job.task.Status.Status = api.CloudBackupStatusActive
+		}
+	case api.CloudBackupRequestedStateStop:
+		job.stopped = true
+	default:
+		return fmt.Errorf("Invalid requested state %q", input.RequestedState)
+	}
+	job.cond.Broadcast()
+	return job.save()
+}
+
+// CloudBackupSchedCreate is not supported, backups are scheduled by the
+// caller.
+func (p *cloudBackupProvider) CloudBackupSchedCreate(
+	input *api.CloudBackupSchedCreateRequest,
+) (*api.CloudBackupSchedCreateResponse, error) {
+	return nil, volume.ErrNotSupported
+}
+
+// CloudBackupGroupSchedCreate is not supported.
+func (p *cloudBackupProvider) CloudBackupGroupSchedCreate(
+	input *api.CloudBackupGroupSchedCreateRequest,
+) (*api.CloudBackupSchedCreateResponse, error) {
+	return nil, volume.ErrNotSupported
+}
+
+// CloudBackupSchedDelete is not supported.
+func (p *cloudBackupProvider) CloudBackupSchedDelete(
+	input *api.CloudBackupSchedDeleteRequest,
+) error {
+	return volume.ErrNotSupported
+}
+
+// CloudBackupSchedEnumerate is not supported.
+func (p *cloudBackupProvider) CloudBackupSchedEnumerate() (*api.CloudBackupSchedEnumerateResponse, error) {
+	return nil, volume.ErrNotSupported
+}
+
+func (p *cloudBackupProvider) enumerateTasks() ([]*cloudBackupTask, error) {
+	kvp, err := p.kv.Enumerate(p.tasksKeyPrefix())
+	if err != nil {
+		return nil, err
+	}
+	tasks := make([]*cloudBackupTask, 0, len(kvp))
+	for _, v := range kvp {
+		task := &cloudBackupTask{}
+		if err := json.Unmarshal(v.Value, task); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// startJob saves a new task and starts tracking it. A name is generated for
+// the task if none is given.
+func (p *cloudBackupProvider) startJob(name string, task cloudBackupTask) (*cloudBackupJob, error) {
+	if len(name) == 0 {
+		name = uuid.New()
+	}
+	task.Name = name
+	task.Status.ID = task.BackupID
+	if _, err := p.kv.Create(p.tasksKeyPrefix()+name, &task, 0); err == kvdb.ErrExist {
+		return nil, fmt.Errorf("Task %s already exists", name)
+	} else if err != nil {
+		return nil, err
+	}
+
+	job := &cloudBackupJob{
+		provider: p,
+		task:     task,
+		lastSave: time.Now(),
+	}
+	job.cond = sync.NewCond(&job.Mutex)
+	p.Lock()
+	p.jobs[name] = job
+	p.Unlock()
+	return job, nil
+}
+
+// finishJob saves the final status of a task and stops tracking it.
+func (p *cloudBackupProvider) finishJob(job *cloudBackupJob, err error) {
+	p.Lock()
+	delete(p.jobs, job.task.Name)
+	p.Unlock()
+
+	job.Lock()
+	defer job.Unlock()
+	switch {
+	case err == nil:
+		job.task.Status.Status = api.CloudBackupStatusDone
+	case err == errTaskStopped:
+		job.task.Status.Status = api.CloudBackupStatusStopped
+	default:
+		logrus.Warnf("Cloud %s %s of volume %s failed: %v",
+			strings.ToLower(string(job.task.Status.OpType)), job.task.Name,
+			job.task.Status.SrcVolumeID, err)
+		job.task.Status.Status = api.CloudBackupStatusFailed
+		job.task.Status.Info = append(job.task.Status.Info, err.Error())
+	}
+	job.task.Status.EtaSeconds = 0
+	job.task.Status.CompletedTime = time.Now()
+	if err := job.save(); err != nil {
+		logrus.Warnf("Failed to save cloud backup task %s: %v", job.task.Name, err)
+	}
+}
+
+// progress accounts for transferred bytes, waiting while the job is paused.
+// It returns errTaskStopped once the job is stopped.
+func (job *cloudBackupJob) progress(bytes uint64) error {
+	job.Lock()
+	defer job.Unlock()
+
+	job.task.Status.BytesDone += bytes
+	for job.task.Status.Status == api.CloudBackupStatusPaused && !job.stopped {
+		job.cond.Wait()
+	}
+	if job.stopped {
+		return errTaskStopped
+	}
+
+	if time.Since(job.lastSave) < taskSaveInterval {
+		return nil
+	}
+	status := &job.task.Status
+	if elapsed := time.Since(status.StartTime).Seconds(); status.BytesDone > 0 &&
+		status.BytesTotal > status.BytesDone {
+		rate := float64(status.BytesDone) / elapsed
+		status.EtaSeconds = int64(float64(status.BytesTotal-status.BytesDone) / rate)
+	}
+	if err := job.save(); err != nil {
+		logrus.Warnf("Failed to save cloud backup task %s: %v", job.task.Name, err)
+	}
+	return nil
+}
+
+func (job *cloudBackupJob) save() error {
+	p := job.provider
+	job.lastSave = time.Now()
+	_, err := p.kv.Put(p.tasksKeyPrefix()+job.task.Name, &job.task, 0)
+	return err
+}
@@ -0,0 +1,124 @@
+package common
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+const (
+	// objectMagic starts every object written to a backup target.
+	objectMagic = "OSDCB1"
+
+	objectPlain     = byte(0)
+	objectEncrypted = byte(1)
+)
+
+// objectCodec compresses and, if the credential has an encryption key,
+// encrypts the objects stored on a backup target with AES-256-GCM.
+type objectCodec struct {
+	key  []byte
+	aead cipher.AEAD
+}
+
+func newObjectCodec(encryptionKey string) (*objectCodec, error) {
+	if len(encryptionKey) == 0 {
+		return &objectCodec{}, nil
+	}
+	key := sha256.Sum256([]byte(encryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &objectCodec{
+		key:  key[:],
+		aead: aead,
+	}, nil
+}
+
+// chunkID returns the content address of a chunk of data. When encrypting,
+// the address is keyed so that it does not reveal the contents of the chunk.
+func (c *objectCodec) chunkID(data []byte) string {
+	if c.aead == nil {
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:])
+	}
+	h := hmac.New(sha256.New, c.key)
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *objectCodec) encode(data []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	out := bytes.NewBufferString(objectMagic)
+	if c.aead == nil {
+		out.WriteByte(objectPlain)
+		out.Write(compressed.Bytes())
+		return out.Bytes(), nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	out.WriteByte(objectEncrypted)
+	out.Write(nonce)
+	out.Write(c.aead.Seal(nil, nonce, compressed.Bytes(), []byte(objectMagic)))
+	return out.Bytes(), nil
+}
+
+func (c *objectCodec) decode(object []byte) ([]byte, error) {
+	if len(object) < len(objectMagic)+1 || string(object[:len(objectMagic)]) != objectMagic {
+		return nil, fmt.Errorf("Object is not part of a cloud backup")
+	}
+	flags := object[len(objectMagic)]
+	payload := object[len(objectMagic)+1:]
+
+	switch flags {
+	case objectPlain:
+	case objectEncrypted:
+		if c.aead == nil {
+			return nil, fmt.Errorf("Backup is encrypted but the credential " +
+				"has no encryption key")
+		}
+		if len(payload) < c.aead.NonceSize() {
+			return nil, fmt.Errorf("Encrypted object is truncated")
+		}
+		nonce := payload[:c.aead.NonceSize()]
+		plain, err := c.aead.Open(nil, nonce, payload[c.aead.NonceSize():], []byte(objectMagic))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decrypt object, the encryption " +
+				"key may be incorrect")
+		}
+		payload = plain
+	default:
+		return nil, fmt.Errorf("Unknown object format %d", flags)
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
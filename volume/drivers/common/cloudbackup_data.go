@@ -0,0 +1,568 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/libopenstorage/openstorage/api"
+)
+
+const (
+	// chunkSize is the size of the chunks the data of a volume is split
+	// into. Chunks are deduplicated by content across all the backups in a
+	// bucket.
+	chunkSize = 1024 * 1024
+
+	chunksPrefix  = "chunks/"
+	backupsPrefix = "backups/"
+
+	// Kinds of backups
+	backupKindBlock = "block"
+	backupKindFiles = "files"
+)
+
+// backupInfo is the summary of a backup stored next to its manifest so that
+// backups can be enumerated without reading their full manifests.
+type backupInfo struct {
+	ID            string
+	ClusterID     string
+	SrcVolumeID   string
+	SrcVolumeName string
+	Timestamp     time.Time
+	// Parent is the backup this incremental backup was based on
+	Parent string
+	Kind   string
+	// Size is the number of bytes of data of the volume in the backup
+	Size uint64
+	// Uploaded is the number of bytes written to the target by the backup
+	Uploaded uint64
+	Labels   map[string]string
+}
+
+// backupManifest lists the chunks making up a backup. Manifests are always
+// complete, so restoring an incremental backup never needs its parents.
+type backupManifest struct {
+	ID     string
+	Volume *api.Volume
+	Kind   string
+	// DeviceSize is the size of the device of a block backup
+	DeviceSize int64
+	Blocks     []backupBlock
+	Files      []backupFile
+}
+
+// backupBlock is a non zero chunk of a block device at Offset.
+type backupBlock struct {
+	Offset int64
+	Length int
+	Chunk  string
+}
+
+// backupFile is an entry of a directory tree. Path is relative to the root
+// of the volume, which itself is recorded as ".".
+type backupFile struct {
+	Path    string
+	Mode    os.FileMode
+	Uid     int
+	Gid     int
+	ModTime time.Time
+	Size    int64
+	Link    string   `json:",omitempty"`
+	Chunks  []string `json:",omitempty"`
+}
+
+func chunkKey(id string) string {
+	return chunksPrefix + id[:2] + "/" + id
+}
+
+func backupInfoKey(backupID string) string {
+	return backupsPrefix + backupID + "/info"
+}
+
+func backupManifestKey(backupID string) string {
+	return backupsPrefix + backupID + "/manifest"
+}
+
+func backupVerificationKey(backupID string) string {
+	return backupsPrefix + backupID + "/verification"
+}
+
+// chunks returns the ids of all the chunks referenced by the manifest.
+func (m *backupManifest) chunks() []string {
+	ids := make([]string, 0, len(m.Blocks))
+	for _, b := range m.Blocks {
+		ids = append(ids, b.Chunk)
+	}
+	for _, f := range m.Files {
+		ids = append(ids, f.Chunks...)
+	}
+	return ids
+}
+
+// contents returns the listing of the files in the backup.
+func (m *backupManifest) contents() []string {
+	contents := make([]string, 0, len(m.Files))
+	for _, f := range m.Files {
+		if f.Path == "." {
+			continue
+		}
+		name := "/" + f.Path
+		if f.Mode.IsDir() {
+			name += "/"
+		}
+		contents = append(contents, name)
+	}
+	return contents
+}
+
+// putObject encodes and writes a json object to the target.
+func putObject(t backupTarget, c *objectCodec, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	object, err := c.encode(data)
+	if err != nil {
+		return err
+	}
+	return t.put(key, object)
+}
+
+// getObject reads and decodes a json object from the target.
+func getObject(t backupTarget, c *objectCodec, key string, v interface{}) error {
+	object, err := t.get(key)
+	if err != nil {
+		return err
+	}
+	data, err := c.decode(object)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// dataSize returns the number of bytes to transfer to back up the volume
+// data at p.
+func dataSize(p string) (uint64, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return 0, err
+	}
+	if !info.IsDir() {
+		size, err := deviceSize(p)
+		return uint64(size), err
+	}
+
+	var total uint64
+	err = filepath.Walk(p, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			total += uint64(info.Size())
+		}
+		return nil
+	})
+	return total, err
+}
+
+// deviceSize returns the size of a block device or of a regular file.
+func deviceSize(p string) (int64, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return f.Seek(0, io.SeekEnd)
+}
+
+// chunkWriter uploads the chunks of a backup, skipping those already stored
+// on the target.
+type chunkWriter struct {
+	target backupTarget
+	codec  *objectCodec
+	// stored is the set of chunks known to be on the target
+	stored map[string]bool
+	// uploaded is the number of bytes written to the target
+	uploaded uint64
+	progress func(bytes uint64) error
+}
+
+// newChunkWriter returns a chunk writer. Unless full is set, chunks already
+// stored on the target are not uploaded again.
+func newChunkWriter(
+	t backupTarget,
+	c *objectCodec,
+	full bool,
+	progress func(bytes uint64) error,
+) (*chunkWriter, error) {
+	w := &chunkWriter{
+		target:   t,
+		codec:    c,
+		stored:   make(map[string]bool),
+		progress: progress,
+	}
+	if full {
+		return w, nil
+	}
+	keys, err := t.list(chunksPrefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		w.stored[path.Base(key)] = true
+	}
+	return w, nil
+}
+
+func (w *chunkWriter) write(data []byte) (string, error) {
+	id := w.codec.chunkID(data)
+	if !w.stored[id] {
+		object, err := w.codec.encode(data)
+		if err != nil {
+			return "", err
+		}
+		if err := w.target.put(chunkKey(id), object); err != nil {
+			return "", err
+		}
+		w.stored[id] = true
+		w.uploaded += uint64(len(object))
+	}
+	return id, w.progress(uint64(len(data)))
+}
+
+// backup adds the volume data at p to the manifest. If the data is a
+// directory tree, unchanged files of the parent backup are not read again.
+func (w *chunkWriter) backup(p string, m *backupManifest, parent *backupManifest) error {
+	info, err := os.Stat(p)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		m.Kind = backupKindFiles
+		return w.backupFiles(p, m, parent)
+	}
+	m.Kind = backupKindBlock
+	return w.backupBlocks(p, m)
+}
+
+func (w *chunkWriter) backupBlocks(p string, m *backupManifest) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	m.DeviceSize = size
+
+	buf := make([]byte, chunkSize)
+	for offset := int64(0); offset < size; offset += chunkSize {
+		n, err := f.ReadAt(buf, offset)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		data := buf[:n]
+		if isZero(data) {
+			// Holes are not stored
+			if err := w.progress(uint64(n)); err != nil {
+				return err
+			}
+			continue
+		}
+		id, err := w.write(data)
+		if err != nil {
+			return err
+		}
+		m.Blocks = append(m.Blocks, backupBlock{
+			Offset: offset,
+			Length: n,
+			Chunk:  id,
+		})
+	}
+	return nil
+}
+
+func (w *chunkWriter) backupFiles(root string, m *backupManifest, parent *backupManifest) error {
+	previous := make(map[string]*backupFile)
+	if parent != nil {
+		for i := range parent.Files {
+			previous[parent.Files[i].Path] = &parent.Files[i]
+		}
+	}
+
+	return filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		entry := backupFile{
+			Path:    filepath.ToSlash(rel),
+			Mode:    info.Mode(),
+			ModTime: info.ModTime(),
+		}
+		if st, ok := info.Sys().(*syscall.Stat_t); ok {
+			entry.Uid = int(st.Uid)
+			entry.Gid = int(st.Gid)
+		}
+
+		switch {
+		case info.IsDir():
+		case info.Mode()&os.ModeSymlink != 0:
+			if entry.Link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			entry.Size = info.Size()
+			if prev, ok := previous[entry.Path]; ok && w.unchanged(prev, &entry) {
+				entry.Chunks = prev.Chunks
+				if err := w.progress(uint64(entry.Size)); err != nil {
+					return err
+				}
+			} else if entry.Chunks, err = w.backupFile(p); err != nil {
+				return err
+			}
+		default:
+			// Devices, sockets and pipes cannot be restored to a volume
+			return nil
+		}
+		m.Files = append(m.Files, entry)
+		return nil
+	})
+}
+
+// unchanged returns true if the file has not been modified since the
+// previous backup and all its chunks are still on the target.
+func (w *chunkWriter) unchanged(prev, entry *backupFile) bool {
+	if prev.Size != entry.Size || prev.Mode != entry.Mode ||
+		!prev.ModTime.Equal(entry.ModTime) {
+		return false
+	}
+	for _, id := range prev.Chunks {
+		if !w.stored[id] {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *chunkWriter) backupFile(p string) ([]string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	chunks := make([]string, 0)
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 {
+			id, err := w.write(buf[:n])
+			if err != nil {
+				return nil, err
+			}
+			chunks = append(chunks, id)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return chunks, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// chunkReader downloads and verifies the chunks of a backup.
+type chunkReader struct {
+	target   backupTarget
+	codec    *objectCodec
+	progress func(bytes uint64) error
+}
+
+func (r *chunkReader) read(id string) ([]byte, error) {
+	object, err := r.target.get(chunkKey(id))
+	if err == errObjectNotFound {
+		return nil, fmt.Errorf("Chunk %s of the backup is missing", id)
+	} else if err != nil {
+		return nil, err
+	}
+	data, err := r.codec.decode(object)
+	if err != nil {
+		return nil, fmt.Errorf("Chunk %s of the backup is corrupted: %v", id, err)
+	}
+	if r.codec.chunkID(data) != id {
+		return nil, fmt.Errorf("Chunk %s of the backup is corrupted: "+
+			"checksum mismatch", id)
+	}
+	return data, nil
+}
+
+// restore writes the data of the backup to the volume data at p.
+func (r *chunkReader) restore(p string, m *backupManifest) error {
+	if m.Kind == backupKindFiles {
+		return r.restoreFiles(p, m)
+	}
+	return r.restoreBlocks(p, m)
+}
+
+func (r *chunkReader) restoreBlocks(p string, m *backupManifest) error {
+	f, err := os.OpenFile(p, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	regular := info.Mode().IsRegular()
+	if regular {
+		// Discard the previous contents of the file, keeping it sparse.
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		if err := f.Truncate(m.DeviceSize); err != nil {
+			return err
+		}
+	}
+
+	blocks := append([]backupBlock{}, m.Blocks...)
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].Offset < blocks[j].Offset })
+
+	zeros := make([]byte, chunkSize)
+	offset := int64(0)
+	for _, b := range blocks {
+		if !regular {
+			if err := writeZeros(f, zeros, offset, b.Offset); err != nil {
+				return err
+			}
+		}
+		data, err := r.read(b.Chunk)
+		if err != nil {
+			return err
+		}
+		if _, err := f.WriteAt(data, b.Offset); err != nil {
+			return err
+		}
+		offset = b.Offset + int64(len(data))
+		if err := r.progress(uint64(len(data))); err != nil {
+			return err
+		}
+	}
+	if !regular {
+		if err := writeZeros(f, zeros, offset, m.DeviceSize); err != nil {
+			return err
+		}
+	}
+	return f.Sync()
+}
+
+func writeZeros(f *os.File, zeros []byte, from, to int64) error {
+	for from < to {
+		n := int64(len(zeros))
+		if to-from < n {
+			n = to - from
+		}
+		if _, err := f.WriteAt(zeros[:n], from); err != nil {
+			return err
+		}
+		from += n
+	}
+	return nil
+}
+
+func (r *chunkReader) restoreFiles(root string, m *backupManifest) error {
+	for _, entry := range m.Files {
+		p := filepath.Join(root, filepath.Clean("/"+entry.Path))
+		switch {
+		case entry.Mode.IsDir():
+			if err := os.MkdirAll(p, 0700); err != nil {
+				return err
+			}
+		case entry.Mode&os.ModeSymlink != 0:
+			os.Remove(p)
+			if err := os.Symlink(entry.Link, p); err != nil {
+				return err
+			}
+		default:
+			if err := r.restoreFile(p, &entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Set the attributes once all the entries exist, children first so
+	// that the modification times of directories are preserved.
+	for i := len(m.Files) - 1; i >= 0; i-- {
+		entry := &m.Files[i]
+		p := filepath.Join(root, filepath.Clean("/"+entry.Path))
+		if os.Geteuid() == 0 {
+			if err := os.Lchown(p, entry.Uid, entry.Gid); err != nil {
+				return err
+			}
+		}
+		if entry.Mode&os.ModeSymlink != 0 {
+			continue
+		}
+		if err := os.Chmod(p, entry.Mode&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky)); err != nil {
+			return err
+		}
+		if err := os.Chtimes(p, entry.ModTime, entry.ModTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *chunkReader) restoreFile(p string, entry *backupFile) error {
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for _, id := range entry.Chunks {
+		data, err := r.read(id)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			return err
+		}
+		if err := r.progress(uint64(len(data))); err != nil {
+			return err
+		}
+	}
+	return f.Sync()
+}
+
+func isZero(data []byte) bool {
+	for len(data) > 0 {
+		n := len(data)
+		if n > len(zeroBlock) {
+			n = len(zeroBlock)
+		}
+		if !bytes.Equal(data[:n], zeroBlock[:n]) {
+			return false
+		}
+		data = data[n:]
+	}
+	return true
+}
+
+var zeroBlock = make([]byte, 64*1024)
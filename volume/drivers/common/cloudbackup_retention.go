@@ -0,0 +1,269 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/portworx/kvdb"
+	"github.com/sirupsen/logrus"
+
+	"github.com/libopenstorage/openstorage/api"
+)
+
+var (
+	// retentionInterval is how often the retention policies are enforced
+	retentionInterval = time.Hour
+)
+
+// CloudBackupOnHold returns true if the backup has one of the hold labels of
+// the policy. An empty label value matches any value.
+func CloudBackupOnHold(policy *api.CloudBackupRetentionPolicy, backup *api.CloudBackupInfo) bool {
+	if policy == nil {
+		return false
+	}
+	for k, v := range policy.HoldLabels {
+		if value, ok := backup.Metadata[k]; ok && (len(v) == 0 || v == value) {
+			return true
+		}
+	}
+	return false
+}
+
+// CloudBackupExpired returns the backups which are not retained by the policy
+// at the given time, excluding the backups on hold. The rules of the policy
+// apply to the backups of each volume separately.
+func CloudBackupExpired(
+	policy *api.CloudBackupRetentionPolicy,
+	backups []api.CloudBackupInfo,
+	now time.Time,
+) []api.CloudBackupInfo {
+	expired := make([]api.CloudBackupInfo, 0)
+	if policy == nil {
+		return expired
+	}
+
+	volumes := make(map[string][]api.CloudBackupInfo)
+	for _, b := range backups {
+		volumes[b.SrcVolumeID] = append(volumes[b.SrcVolumeID], b)
+	}
+	for _, volumeBackups := range volumes {
+		// Most recent first
+		sort.Slice(volumeBackups, func(i, j int) bool {
+			return volumeBackups[i].Timestamp.After(volumeBackups[j].Timestamp)
+		})
+
+		keep := make([]bool, len(volumeBackups))
+		if policy.KeepLast == 0 && policy.KeepDaily == 0 &&
+			policy.KeepWeekly == 0 && policy.KeepMonthly == 0 {
+			for i := range keep {
+				keep[i] = true
+			}
+		}
+		keepPeriods(keep, volumeBackups, policy.KeepLast, func(t time.Time) string {
+			return t.Format(time.RFC3339Nano)
+		})
+		keepPeriods(keep, volumeBackups, policy.KeepDaily, func(t time.Time) string {
+			return t.UTC().Format("2006-01-02")
+		})
+		keepPeriods(keep, volumeBackups, policy.KeepWeekly, func(t time.Time) string {
+			year, week := t.UTC().ISOWeek()
+			return fmt.Sprintf("%d-%d", year, week)
+		})
+		keepPeriods(keep, volumeBackups, policy.KeepMonthly, func(t time.Time) string {
+			return t.UTC().Format("2006-01")
+		})
+
+		maxAge := time.Duration(policy.ExpireDays) * 24 * time.Hour
+		for i, b := range volumeBackups {
+			if policy.ExpireDays != 0 && now.Sub(b.Timestamp) > maxAge {
+				keep[i] = false
+			}
+			if !keep[i] && !CloudBackupOnHold(policy, &b) {
+				expired = append(expired, b)
+			}
+		}
+	}
+	return expired
+}
+
+// keepPeriods marks the most recent backup of each of the n most recent
+// periods having backups. The backups are sorted most recent first.
+func keepPeriods(
+	keep []bool,
+	backups []api.CloudBackupInfo,
+	n uint,
+	period func(time.Time) string,
+) {
+	last := ""
+	count := uint(0)
+	for i, b := range backups {
+		p := period(b.Timestamp)
+		if i != 0 && p == last {
+			continue
+		}
+		if count == n {
+			return
+		}
+		keep[i] = true
+		last = p
+		count++
+	}
+}
+
+func (p *cloudBackupProvider) retentionKeyPrefix() string {
+	return fmt.Sprintf("%s/%s/cloudbackup/retention/", keyBase, p.driver)
+}
+
+// CloudBackupRetentionUpdate sets the retention policy of the backups stored
+// with a credential and applies it.
+func (p *cloudBackupProvider) CloudBackupRetentionUpdate(
+	input *api.CloudBackupRetentionUpdateRequest,
+) error {
+	if _, _, err := p.target(input.CredentialUUID); err != nil {
+		return err
+	}
+	key := p.retentionKeyPrefix() + input.CredentialUUID
+	if input.Retention == nil {
+		if _, err := p.kv.Delete(key); err != nil && err != kvdb.ErrNotFound {
+			return err
+		}
+		return nil
+	}
+	if _, err := p.kv.Put(key, input.Retention, 0); err != nil {
+		return err
+	}
+	p.enforceRetention(input.CredentialUUID)
+	return nil
+}
+
+// CloudBackupRetentionInspect returns the retention policy of a credential.
+func (p *cloudBackupProvider) CloudBackupRetentionInspect(
+	input *api.CloudBackupRetentionInspectRequest,
+) (*api.CloudBackupRetentionInspectResponse, error) {
+	if _, _, err := p.target(input.CredentialUUID); err != nil {
+		return nil, err
+	}
+	policy, err := p.retention(input.CredentialUUID)
+	if err != nil {
+		return nil, err
+	}
+	return &api.CloudBackupRetentionInspectResponse{
+		Retention: policy,
+	}, nil
+}
+
+// retention returns the retention policy of a credential, or nil if it has
+// none.
+func (p *cloudBackupProvider) retention(credUUID string) (*api.CloudBackupRetentionPolicy, error) {
+	policy := &api.CloudBackupRetentionPolicy{}
+	if _, err := p.kv.GetVal(p.retentionKeyPrefix()+credUUID, policy); err == kvdb.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// reapBackups periodically enforces the retention policies of all the
+// credentials until the provider is stopped.
+func (p *cloudBackupProvider) reapBackups() {
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-p.stop:
+			return
+		}
+		kvp, err := p.kv.Enumerate(p.retentionKeyPrefix())
+		if err != nil {
+			logrus.Warnf("Failed to enumerate %s cloud backup retention policies: %v",
+				p.driver, err)
+			continue
+		}
+		for _, v := range kvp {
+			p.enforceRetention(v.Key[len(p.retentionKeyPrefix()):])
+		}
+	}
+}
+
+// enforceRetention deletes the backups of this cluster which are no longer
+// retained by the policy of the credential, recording the deletions in the
+// history.
+func (p *cloudBackupProvider) enforceRetention(credUUID string) {
+	if err := p.applyRetention(credUUID, time.Now()); err != nil {
+		logrus.Warnf("Failed to apply the retention policy of credential %s: %v",
+			credUUID, err)
+	}
+}
+
+func (p *cloudBackupProvider) applyRetention(credUUID string, now time.Time) error {
+	p.retentionLock.Lock()
+	defer p.retentionLock.Unlock()
+
+	policy, err := p.retention(credUUID)
+	if err != nil || policy == nil {
+		return err
+	}
+	target, codec, err := p.target(credUUID)
+	if err != nil {
+		return err
+	}
+	infos, err := p.matchingBackups(target, codec, &api.CloudBackupGenericRequest{})
+	if err != nil {
+		return err
+	}
+	backups := make([]api.CloudBackupInfo, 0, len(infos))
+	for _, info := range infos {
+		backups = append(backups, info.cloudBackupInfo())
+	}
+	expired := CloudBackupExpired(policy, backups, now)
+	if len(expired) == 0 {
+		return nil
+	}
+
+	clusterID, nodeID := p.clusterInfo()
+	for _, b := range expired {
+		if err := p.deleteBackup(target, b.ID); err != nil {
+			return err
+		}
+		logrus.Infof("Deleted cloud backup %s of volume %s by the retention policy "+
+			"of credential %s", b.ID, b.SrcVolumeID, credUUID)
+		task := &cloudBackupTask{
+			Name:      uuid.New(),
+			BackupID:  b.ID,
+			ClusterID: clusterID,
+			Status: api.CloudBackupStatus{
+				ID:             b.ID,
+				OpType:         api.CloudBackupDeleteOp,
+				Status:         api.CloudBackupStatusDone,
+				StartTime:      now,
+				CompletedTime:  time.Now(),
+				NodeID:         nodeID,
+				SrcVolumeID:    b.SrcVolumeID,
+				CredentialUUID: credUUID,
+				Info: []string{fmt.Sprintf("Backup of %s is not retained by the "+
+					"retention policy", b.Timestamp.Format(time.RFC3339))},
+			},
+		}
+		if _, err := p.kv.Put(p.tasksKeyPrefix()+task.Name, task, 0); err != nil {
+			return err
+		}
+	}
+	return p.collectChunks(target, codec)
+}
+
+// checkHold returns an error if the backup is on hold.
+func (p *cloudBackupProvider) checkHold(credUUID string, info *backupInfo) error {
+	policy, err := p.retention(credUUID)
+	if err != nil {
+		return err
+	}
+	backup := info.cloudBackupInfo()
+	if CloudBackupOnHold(policy, &backup) {
+		return fmt.Errorf("Backup %s is on legal hold", info.ID)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package common
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libopenstorage/openstorage/api"
+)
+
+func expiredIDs(policy *api.CloudBackupRetentionPolicy, backups []api.CloudBackupInfo, now time.Time) []string {
+	ids := make([]string, 0)
+	for _, b := range CloudBackupExpired(policy, backups, now) {
+		ids = append(ids, b.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestCloudBackupExpired(t *testing.T) {
+	// A backup every 12 hours from Monday 2018-12-31 to Friday 2019-02-01
+	start := time.Date(2018, 12, 31, 6, 0, 0, 0, time.UTC)
+	backups := make([]api.CloudBackupInfo, 0)
+	for i := 0; i < 66; i++ {
+		backups = append(backups, api.CloudBackupInfo{
+			ID:          start.Add(time.Duration(i) * 12 * time.Hour).Format("01-02T15"),
+			SrcVolumeID: "vol",
+			Timestamp:   start.Add(time.Duration(i) * 12 * time.Hour),
+		})
+	}
+	now := backups[len(backups)-1].Timestamp
+	kept := func(policy *api.CloudBackupRetentionPolicy) []string {
+		expired := make(map[string]bool)
+		for _, id := range expiredIDs(policy, backups, now) {
+			expired[id] = true
+		}
+		ids := make([]string, 0)
+		for _, b := range backups {
+			if !expired[b.ID] {
+				ids = append(ids, b.ID)
+			}
+		}
+		return ids
+	}
+
+	assert.Empty(t, expiredIDs(nil, backups, now))
+	assert.Empty(t, expiredIDs(&api.CloudBackupRetentionPolicy{}, backups, now))
+
+	assert.Equal(t, []string{"02-01T06", "02-01T18"},
+		kept(&api.CloudBackupRetentionPolicy{KeepLast: 2}))
+	assert.Equal(t, []string{"01-30T18", "01-31T18", "02-01T18"},
+		kept(&api.CloudBackupRetentionPolicy{KeepDaily: 3}))
+	// Weeks start on Monday
+	assert.Equal(t, []string{"01-20T18", "01-27T18", "02-01T18"},
+		kept(&api.CloudBackupRetentionPolicy{KeepWeekly: 3}))
+	assert.Equal(t, []string{"12-31T18", "01-31T18", "02-01T18"},
+		kept(&api.CloudBackupRetentionPolicy{KeepMonthly: 5}))
+	assert.Equal(t, []string{"01-31T18", "02-01T06", "02-01T18"},
+		kept(&api.CloudBackupRetentionPolicy{KeepLast: 2, KeepDaily: 2}))
+
+	// Expiry applies to the backups selected by the keep rules too
+	assert.Equal(t, []string{"01-31T18", "02-01T06", "02-01T18"},
+		kept(&api.CloudBackupRetentionPolicy{ExpireDays: 1}))
+	assert.Equal(t, []string{"01-31T18", "02-01T18"},
+		kept(&api.CloudBackupRetentionPolicy{KeepMonthly: 5, ExpireDays: 1}))
+
+	// Each volume has its own backups retained
+	other := append(backups, api.CloudBackupInfo{
+		ID:          "other",
+		SrcVolumeID: "other",
+		Timestamp:   start,
+	})
+	assert.NotContains(t, expiredIDs(&api.CloudBackupRetentionPolicy{KeepLast: 1}, other, now), "other")
+
+	// Backups on hold are never expired
+	held := append([]api.CloudBackupInfo{}, backups...)
+	held[0].Metadata = map[string]string{"legal": "case-1"}
+	held[1].Metadata = map[string]string{"legal": "case-2"}
+	policy := &api.CloudBackupRetentionPolicy{
+		KeepLast:   1,
+		HoldLabels: map[string]string{"legal": "case-1"},
+	}
+	expired := expiredIDs(policy, held, now)
+	assert.Len(t, expired, 64)
+	assert.NotContains(t, expired, held[0].ID)
+	assert.Contains(t, expired, held[1].ID)
+
+	policy.HoldLabels["legal"] = ""
+	assert.True(t, CloudBackupOnHold(policy, &held[1]))
+	assert.False(t, CloudBackupOnHold(policy, &held[2]))
+	assert.False(t, CloudBackupOnHold(nil, &held[1]))
+}
+
+func TestCloudBackupRetention(t *testing.T) {
+	c := newCloudBackupTest(t, "")
+	defer c.cleanup()
+
+	volumeID, err := c.volumes.Create(&api.VolumeLocator{Name: "files"}, nil,
+		&api.VolumeSpec{Size: 1024, Format: api.FSType_FS_TYPE_VFS})
+	require.NoError(t, err)
+	root, _ := c.volumes.CloudBackupPath(volumeID)
+
+	writeTestFile(t, filepath.Join(root, "held"), testData(chunkSize, 1))
+	resp, err := c.provider.CloudBackupCreate(&api.CloudBackupCreateRequest{
+		VolumeID:       volumeID,
+		CredentialUUID: c.credID,
+		Labels:         map[string]string{"legal": "case-1"},
+	})
+	require.NoError(t, err)
+	held := c.wait(t, resp.Name)
+	require.Equal(t, api.CloudBackupStatusDone, held.Status, "%v", held.Info)
+	writeTestFile(t, filepath.Join(root, "expired"), testData(chunkSize, 2))
+	expired := c.backup(t, volumeID, false)
+	writeTestFile(t, filepath.Join(root, "latest"), testData(chunkSize, 3))
+	latest := c.backup(t, volumeID, false)
+
+	policy := &api.CloudBackupRetentionPolicy{
+		KeepLast:   1,
+		HoldLabels: map[string]string{"legal": ""},
+	}
+	require.NoError(t, c.provider.CloudBackupRetentionUpdate(&api.CloudBackupRetentionUpdateRequest{
+		CredentialUUID: c.credID,
+		Retention:      policy,
+	}))
+	inspect, err := c.provider.CloudBackupRetentionInspect(&api.CloudBackupRetentionInspectRequest{
+		CredentialUUID: c.credID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, policy, inspect.Retention)
+
+	enumerate := func() []string {
+		resp, err := c.provider.CloudBackupEnumerate(&api.CloudBackupEnumerateRequest{
+			CloudBackupGenericRequest: api.CloudBackupGenericRequest{
+				CredentialUUID: c.credID,
+			},
+		})
+		require.NoError(t, err)
+		ids := make([]string, 0)
+		for _, b := range resp.Backups {
+			ids = append(ids, b.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{held.ID, latest.ID}, enumerate())
+
+	history, err := c.provider.CloudBackupHistory(&api.CloudBackupHistoryRequest{
+		SrcVolumeID: volumeID,
+	})
+	require.NoError(t, err)
+	deletions := make([]api.CloudBackupHistoryItem, 0)
+	for _, item := range history.HistoryList {
+		if item.OpType == api.CloudBackupDeleteOp {
+			deletions = append(deletions, item)
+		}
+	}
+	require.Len(t, deletions, 1)
+	assert.Equal(t, expired.ID, deletions[0].ID)
+	assert.NotEmpty(t, deletions[0].Info)
+
+	// Backups on hold cannot be deleted
+	err = c.provider.CloudBackupDelete(&api.CloudBackupDeleteRequest{
+		ID:             held.ID,
+		CredentialUUID: c.credID,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "legal hold")
+	require.NoError(t, c.provider.CloudBackupDeleteAll(&api.CloudBackupDeleteAllRequest{
+		CloudBackupGenericRequest: api.CloudBackupGenericRequest{
+			CredentialUUID: c.credID,
+		},
+	}))
+	assert.Equal(t, []string{held.ID}, enumerate())
+
+	// Expire the backups once the hold is released
+	p := c.provider.(*cloudBackupProvider)
+	require.NoError(t, c.provider.CloudBackupRetentionUpdate(&api.CloudBackupRetentionUpdateRequest{
+		CredentialUUID: c.credID,
+		Retention:      &api.CloudBackupRetentionPolicy{ExpireDays: 1},
+	}))
+	assert.Equal(t, []string{held.ID}, enumerate())
+	require.NoError(t, p.applyRetention(c.credID, time.Now().Add(48*time.Hour)))
+	assert.Empty(t, enumerate())
+	assert.Equal(t, 0, c.chunks(t))
+
+	require.NoError(t, c.provider.CloudBackupRetentionUpdate(&api.CloudBackupRetentionUpdateRequest{
+		CredentialUUID: c.credID,
+	}))
+	inspect, err = c.provider.CloudBackupRetentionInspect(&api.CloudBackupRetentionInspectRequest{
+		CredentialUUID: c.credID,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, inspect.Retention)
+}
@@ -0,0 +1,322 @@
+package common
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3DefaultRegion = "us-east-1"
+	s3TimeFormat    = "20060102T150405Z"
+	s3DateFormat    = "20060102"
+	s3Timeout       = 5 * time.Minute
+)
+
+// s3Target stores the objects in a bucket of an S3 compatible object store.
+// Requests use path style addressing and are signed with AWS signature
+// version 4 so that they are accepted by AWS as well as by local stores
+// like minio.
+type s3Target struct {
+	client    *http.Client
+	endpoint  *url.URL
+	region    string
+	accessKey string
+	secretKey string
+	bucket    string
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+type s3ListResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func newS3Target(
+	endpoint, region, accessKey, secretKey, bucket string,
+	disableSSL bool,
+) (*s3Target, error) {
+	if len(endpoint) == 0 {
+		return nil, fmt.Errorf("Endpoint of the s3 credential must be provided")
+	}
+	if !strings.Contains(endpoint, "://") {
+		if disableSSL {
+			endpoint = "http://" + endpoint
+		} else {
+			endpoint = "https://" + endpoint
+		}
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid s3 endpoint %q: %v", endpoint, err)
+	}
+	if len(region) == 0 {
+		region = s3DefaultRegion
+	}
+	return &s3Target{
+		client:    &http.Client{Timeout: s3Timeout},
+		endpoint:  u,
+		region:    region,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		bucket:    bucket,
+	}, nil
+}
+
+func (t *s3Target) validate() error {
+	resp, err := t.do("HEAD", "", nil, nil)
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		resp, err = t.do("PUT", "", nil, nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusOK {
+			return nil
+		}
+	}
+	return t.error("bucket "+t.bucket, resp)
+}
+
+func (t *s3Target) put(key string, data []byte) error {
+	resp, err := t.do("PUT", key, nil, data)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return t.error(key, resp)
+	}
+	return nil
+}
+
+func (t *s3Target) get(key string) ([]byte, error) {
+	resp, err := t.do("GET", key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.body, nil
+	case http.StatusNotFound:
+		return nil, errObjectNotFound
+	}
+	return nil, t.error(key, resp)
+}
+
+func (t *s3Target) delete(key string) error {
+	resp, err := t.do("DELETE", key, nil, nil)
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return t.error(key, resp)
+}
+
+func (t *s3Target) list(prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if len(token) != 0 {
+			query.Set("continuation-token", token)
+		}
+		resp, err := t.do("GET", "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, t.error("bucket "+t.bucket, resp)
+		}
+		var result s3ListResult
+		if err := xml.Unmarshal(resp.body, &result); err != nil {
+			return nil, fmt.Errorf("Failed to parse listing of bucket %s: %v",
+				t.bucket, err)
+		}
+		for _, c := range result.Contents {
+			keys = append(keys, c.Key)
+		}
+		if !result.IsTruncated || len(result.NextContinuationToken) == 0 {
+			return keys, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// s3Response is an http response whose body has already been read.
+type s3Response struct {
+	StatusCode int
+	body       []byte
+}
+
+func (t *s3Target) error(what string, resp *s3Response) error {
+	var e s3Error
+	if err := xml.Unmarshal(resp.body, &e); err == nil && len(e.Code) != 0 {
+		return fmt.Errorf("Request for %s failed: %s: %s", what, e.Code, e.Message)
+	}
+	return fmt.Errorf("Request for %s failed with status %d", what, resp.StatusCode)
+}
+
+// do sends a signed request for the object key, or for the bucket itself
+// if key is empty.
+func (t *s3Target) do(method, key string, query url.Values, body []byte) (*s3Response, error) {
+	u := *t.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + t.bucket
+	if len(key) != 0 {
+		u.Path += "/" + key
+	}
+	u.RawPath = s3EscapePath(u.Path)
+	u.RawQuery = s3CanonicalQuery(query)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	s3Sign(req, body, t.accessKey, t.secretKey, t.region, time.Now())
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Response{StatusCode: resp.StatusCode, body: data}, nil
+}
+
+// s3Sign adds the AWS signature version 4 headers to the request.
+func s3Sign(req *http.Request, body []byte, accessKey, secretKey, region string, now time.Time) {
+	now = now.UTC()
+	payloadHash := sha256.Sum256(body)
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	signedHeaders, canonicalHeaders := s3CanonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := strings.Join([]string{now.Format(s3DateFormat), region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format(s3TimeFormat),
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := []byte("AWS4" + secretKey)
+	for _, s := range []string{now.Format(s3DateFormat), region, "s3", "aws4_request"} {
+		key = s3HMAC(key, s)
+	}
+	signature := hex.EncodeToString(s3HMAC(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func s3CanonicalHeaders(req *http.Request) (string, string) {
+	names := []string{"host"}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "host" || lower == "authorization" {
+			continue
+		}
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "content-md5" {
+			names = append(names, lower)
+		}
+	}
+	sort.Strings(names)
+
+	var canonical bytes.Buffer
+	for _, name := range names {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonical.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	return strings.Join(names, ";"), canonical.String()
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(query))
+	for _, k := range keys {
+		values := append([]string{}, query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func s3EscapePath(p string) string {
+	if len(p) == 0 {
+		return "/"
+	}
+	return s3Escape(p, false)
+}
+
+// s3Escape percent encodes all the characters of s except for the
+// unreserved characters of RFC 3986 and, unless encodeSlash is set, '/'.
+func s3Escape(s string, encodeSlash bool) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			buf.WriteByte(c)
+		} else {
+			fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,228 @@
+package common
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libopenstorage/openstorage/api"
+)
+
+const (
+	testS3AccessKey = "access"
+	testS3SecretKey = "secret"
+	// testS3PageSize is small to exercise paginated listings
+	testS3PageSize = 2
+)
+
+// testS3Server is a minimal in memory S3 compatible object store checking
+// the signatures of the requests.
+type testS3Server struct {
+	sync.Mutex
+	buckets map[string]map[string][]byte
+}
+
+func newTestS3Server() *httptest.Server {
+	return httptest.NewServer(&testS3Server{
+		buckets: make(map[string]map[string][]byte),
+	})
+}
+
+func (s *testS3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	if !s.validSignature(r, body) {
+		s.error(w, http.StatusForbidden, "SignatureDoesNotMatch")
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	bucket, key := parts[0], ""
+	if len(parts) == 2 {
+		key = parts[1]
+	}
+	objects, exists := s.buckets[bucket]
+
+	switch {
+	case len(key) == 0 && r.Method == "PUT":
+		if !exists {
+			s.buckets[bucket] = make(map[string][]byte)
+		}
+	case !exists:
+		s.error(w, http.StatusNotFound, "NoSuchBucket")
+	case len(key) == 0 && r.Method == "HEAD":
+	case len(key) == 0 && r.Method == "GET":
+		s.list(w, objects, r.URL.Query())
+	case r.Method == "PUT":
+		objects[key] = body
+	case r.Method == "GET":
+		data, ok := objects[key]
+		if !ok {
+			s.error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Write(data)
+	case r.Method == "DELETE":
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s.error(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (s *testS3Server) list(w http.ResponseWriter, objects map[string][]byte, query url.Values) {
+	keys := make([]string, 0)
+	for key := range objects {
+		if strings.HasPrefix(key, query.Get("prefix")) && key > query.Get("continuation-token") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var result s3ListResult
+	if len(keys) > testS3PageSize {
+		keys = keys[:testS3PageSize]
+		result.IsTruncated = true
+		result.NextContinuationToken = keys[len(keys)-1]
+	}
+	for _, key := range keys {
+		result.Contents = append(result.Contents, struct {
+			Key string `xml:"Key"`
+		}{key})
+	}
+	data, _ := xml.Marshal(struct {
+		XMLName xml.Name `xml:"ListBucketResult"`
+		s3ListResult
+	}{s3ListResult: result})
+	w.Write(data)
+}
+
+func (s *testS3Server) error(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	data, _ := xml.Marshal(struct {
+		XMLName xml.Name `xml:"Error"`
+		s3Error
+	}{s3Error: s3Error{Code: code, Message: code}})
+	w.Write(data)
+}
+
+// validSignature signs the request again and compares the signatures.
+func (s *testS3Server) validSignature(r *http.Request, body []byte) bool {
+	date, err := time.Parse(s3TimeFormat, r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return false
+	}
+	u := *r.URL
+	u.Host = r.Host
+	req, err := http.NewRequest(r.Method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	s3Sign(req, body, testS3AccessKey, testS3SecretKey, s3DefaultRegion, date)
+	return req.Header.Get("Authorization") == r.Header.Get("Authorization")
+}
+
+func TestS3Target(t *testing.T) {
+	server := newTestS3Server()
+	defer server.Close()
+
+	target, err := newS3Target(server.URL, "", testS3AccessKey, testS3SecretKey, "bucket", false)
+	require.NoError(t, err)
+
+	require.NoError(t, target.validate())
+	require.NoError(t, target.validate())
+
+	_, err = target.get("missing")
+	assert.Equal(t, errObjectNotFound, err)
+
+	keys := []string{"a/1", "a/2", "a/3 with space", "b/1"}
+	for _, key := range keys {
+		require.NoError(t, target.put(key, []byte(key)))
+	}
+	data, err := target.get("a/3 with space")
+	require.NoError(t, err)
+	assert.Equal(t, "a/3 with space", string(data))
+
+	listed, err := target.list("a/")
+	require.NoError(t, err)
+	assert.Equal(t, keys[:3], listed)
+
+	require.NoError(t, target.delete("a/1"))
+	require.NoError(t, target.delete("a/1"))
+	listed, err = target.list("")
+	require.NoError(t, err)
+	assert.Equal(t, keys[1:], listed)
+
+	bad, err := newS3Target(strings.TrimPrefix(server.URL, "http://"), "",
+		testS3AccessKey, "wrong", "bucket", true)
+	require.NoError(t, err)
+	err = bad.put("key", []byte("data"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SignatureDoesNotMatch")
+}
+
+func TestCloudBackupS3(t *testing.T) {
+	server := newTestS3Server()
+	defer server.Close()
+
+	c := newCloudBackupTest(t, "")
+	defer c.cleanup()
+
+	credID, err := c.provider.CredsCreate(map[string]string{
+		api.OptCredType:      CredTypeS3,
+		api.OptCredEndpoint:  server.URL,
+		api.OptCredAccessKey: testS3AccessKey,
+		api.OptCredSecretKey: testS3SecretKey,
+		api.OptCredEncrKey:   "key",
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.provider.CredsValidate(credID))
+	c.credID = credID
+
+	volumeID, err := c.volumes.Create(&api.VolumeLocator{Name: "files"}, nil,
+		&api.VolumeSpec{Size: 1024, Format: api.FSType_FS_TYPE_VFS})
+	require.NoError(t, err)
+	root, _ := c.volumes.CloudBackupPath(volumeID)
+	data := testData(2*chunkSize+10, 5)
+	writeTestFile(t, filepath.Join(root, "file"), data)
+
+	status := c.backup(t, volumeID, false)
+	restoredID := c.restore(t, status.ID)
+	restored, _ := c.volumes.CloudBackupPath(restoredID)
+	restoredData, err := ioutil.ReadFile(filepath.Join(restored, "file"))
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(data, restoredData))
+
+	err = c.provider.CloudBackupDelete(&api.CloudBackupDeleteRequest{
+		ID:             status.ID,
+		CredentialUUID: credID,
+	})
+	require.NoError(t, err)
+	enumerated, err := c.provider.CloudBackupEnumerate(&api.CloudBackupEnumerateRequest{
+		CloudBackupGenericRequest: api.CloudBackupGenericRequest{
+			CredentialUUID: credID,
+		},
+	})
+	require.NoError(t, err)
+	assert.Len(t, enumerated.Backups, 0)
+
+	target, _, err := c.provider.(*cloudBackupProvider).target(credID)
+	require.NoError(t, err)
+	chunks, err := target.list(chunksPrefix)
+	require.NoError(t, err)
+	assert.Len(t, chunks, 0)
+}
@@ -0,0 +1,155 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/libopenstorage/openstorage/api"
+)
+
+const (
+	// CredTypeLocal is the credential type for backups stored in a local
+	// directory. The directory is given by the api.OptCredEndpoint parameter.
+	CredTypeLocal = "local"
+	// CredTypeS3 is the credential type for backups stored on an S3
+	// compatible object store.
+	CredTypeS3 = "s3"
+)
+
+var (
+	// errObjectNotFound is returned by a backup target when an object
+	// does not exist.
+	errObjectNotFound = errors.New("Object not found")
+)
+
+// backupTarget is the store holding the objects of cloud backups. Keys
+// are slash separated paths relative to the bucket of the target.
+type backupTarget interface {
+	// validate checks that the target is reachable, creating the bucket
+	// if it does not exist yet.
+	validate() error
+	put(key string, data []byte) error
+	// get returns errObjectNotFound if the object does not exist.
+	get(key string) ([]byte, error)
+	// delete does not fail if the object does not exist.
+	delete(key string) error
+	// list returns the keys of all the objects starting with prefix.
+	list(prefix string) ([]string, error)
+}
+
+// newBackupTarget returns the target described by the credential params.
+// The bucket name provided with the credential is used if available,
+// otherwise the credential id is used as the name.
+func newBackupTarget(credID string, params map[string]interface{}) (backupTarget, error) {
+	str := func(key string) string {
+		v, _ := params[key].(string)
+		return v
+	}
+
+	bucket := str(api.OptCredBucket)
+	if len(bucket) == 0 {
+		bucket = credID
+	}
+	switch str(api.OptCredType) {
+	case CredTypeLocal:
+		if len(str(api.OptCredEndpoint)) == 0 {
+			return nil, fmt.Errorf("Directory of the local credential must be "+
+				"provided with %s", api.OptCredEndpoint)
+		}
+		return &localTarget{
+			path: filepath.Join(str(api.OptCredEndpoint), filepath.Base(bucket)),
+		}, nil
+	case CredTypeS3:
+		return newS3Target(
+			str(api.OptCredEndpoint),
+			str(api.OptCredRegion),
+			str(api.OptCredAccessKey),
+			str(api.OptCredSecretKey),
+			bucket,
+			str(api.OptCredDisableSSL) == "true",
+		)
+	default:
+		return nil, fmt.Errorf("Credential type %q is not supported for cloud backups",
+			str(api.OptCredType))
+	}
+}
+
+// localTarget stores the objects as files under a local directory.
+type localTarget struct {
+	path string
+}
+
+func (t *localTarget) objectPath(key string) string {
+	return filepath.Join(t.path, filepath.FromSlash(key))
+}
+
+func (t *localTarget) validate() error {
+	return os.MkdirAll(t.path, 0755)
+}
+
+func (t *localTarget) put(key string, data []byte) error {
+	p := t.objectPath(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that readers never see a
+	// partially written object.
+	f, err := ioutil.TempFile(filepath.Dir(p), ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), p)
+}
+
+func (t *localTarget) get(key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(t.objectPath(key))
+	if os.IsNotExist(err) {
+		return nil, errObjectNotFound
+	}
+	return data, err
+}
+
+func (t *localTarget) delete(key string) error {
+	if err := os.Remove(t.objectPath(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (t *localTarget) list(prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	err := filepath.Walk(t.path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".tmp-") {
+			return nil
+		}
+		rel, err := filepath.Rel(t.path, p)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	return keys, err
+}
@@ -0,0 +1,437 @@
+package common
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/portworx/kvdb"
+	"github.com/portworx/kvdb/mem"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/volume"
+)
+
+// testBackupVolumes keeps volumes as directories or block files under a
+// temporary directory.
+type testBackupVolumes struct {
+	volume.StoreEnumerator
+	dir string
+}
+
+func newTestBackupVolumes(t *testing.T, kv kvdb.Kvdb) *testBackupVolumes {
+	dir, err := ioutil.TempDir("", "cloudbackup-volumes")
+	require.NoError(t, err)
+	return &testBackupVolumes{
+		StoreEnumerator: NewDefaultStoreEnumerator("cloudbackup_test", kv),
+		dir:             dir,
+	}
+}
+
+func (v *testBackupVolumes) Create(
+	locator *api.VolumeLocator,
+	source *api.Source,
+	spec *api.VolumeSpec,
+) (string, error) {
+	vol := NewVolume(uuid.New(), spec.Format, locator, source, spec)
+	p := filepath.Join(v.dir, vol.Id)
+	if spec.Format == api.FSType_FS_TYPE_NONE {
+		f, err := os.Create(p)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		if err := f.Truncate(int64(spec.Size)); err != nil {
+			return "", err
+		}
+	} else if err := os.MkdirAll(p, 0755); err != nil {
+		return "", err
+	}
+	return vol.Id, v.CreateVol(vol)
+}
+
+func (v *testBackupVolumes) Delete(volumeID string) error {
+	os.RemoveAll(filepath.Join(v.dir, volumeID))
+	return v.DeleteVol(volumeID)
+}
+
+func (v *testBackupVolumes) CloudBackupPath(volumeID string) (string, error) {
+	return filepath.Join(v.dir, volumeID), nil
+}
+
+type cloudBackupTest struct {
+	provider CloudBackupProvider
+	volumes  *testBackupVolumes
+	credID   string
+	bucket   string
+}
+
+func newCloudBackupTest(t *testing.T, encryptionKey string) *cloudBackupTest {
+	kv, err := kvdb.New(mem.Name, "cloudbackup_test", []string{}, nil, logrus.Panicf)
+	require.NoError(t, err)
+
+	volumes := newTestBackupVolumes(t, kv)
+	provider := NewCloudBackupProvider("cloudbackup_test", kv, volumes)
+
+	dir, err := ioutil.TempDir("", "cloudbackup-target")
+	require.NoError(t, err)
+	credID, err := provider.CredsCreate(map[string]string{
+		api.OptCredType:     CredTypeLocal,
+		api.OptCredEndpoint: dir,
+		api.OptCredBucket:   "bucket",
+		api.OptCredEncrKey:  encryptionKey,
+	})
+	require.NoError(t, err)
+	require.NoError(t, provider.CredsValidate(credID))
+
+	return &cloudBackupTest{
+		provider: provider,
+		volumes:  volumes,
+		credID:   credID,
+		bucket:   filepath.Join(dir, "bucket"),
+	}
+}
+
+func (c *cloudBackupTest) cleanup() {
+	os.RemoveAll(c.volumes.dir)
+	os.RemoveAll(filepath.Dir(c.bucket))
+}
+
+// wait waits for a task to complete and returns its final status.
+func (c *cloudBackupTest) wait(t *testing.T, name string) api.CloudBackupStatus {
+	for i := 0; i < 500; i++ {
+		resp, err := c.provider.CloudBackupStatus(&api.CloudBackupStatusRequest{Name: name})
+		require.NoError(t, err)
+		status, ok := resp.Statuses[name]
+		require.True(t, ok, "Task %s not found", name)
+		if status.Status != api.CloudBackupStatusActive &&
+			status.Status != api.CloudBackupStatusPaused {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Task %s did not complete", name)
+	return api.CloudBackupStatus{}
+}
+
+func (c *cloudBackupTest) backup(t *testing.T, volumeID string, full bool) api.CloudBackupStatus {
+	resp, err := c.provider.CloudBackupCreate(&api.CloudBackupCreateRequest{
+		VolumeID:       volumeID,
+		CredentialUUID: c.credID,
+		Full:           full,
+	})
+	require.NoError(t, err)
+	status := c.wait(t, resp.Name)
+	require.Equal(t, api.CloudBackupStatusDone, status.Status, "%v", status.Info)
+	return status
+}
+
+func (c *cloudBackupTest) restore(t *testing.T, backupID string) string {
+	resp, err := c.provider.CloudBackupRestore(&api.CloudBackupRestoreRequest{
+		ID:             backupID,
+		CredentialUUID: c.credID,
+	})
+	require.NoError(t, err)
+	status := c.wait(t, resp.Name)
+	require.Equal(t, api.CloudBackupStatusDone, status.Status, "%v", status.Info)
+	return resp.RestoreVolumeID
+}
+
+func (c *cloudBackupTest) chunks(t *testing.T) int {
+	count := 0
+	filepath.Walk(filepath.Join(c.bucket, "chunks"), func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			count++
+		}
+		return nil
+	})
+	return count
+}
+
+func writeTestFile(t *testing.T, p string, data []byte) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+	require.NoError(t, ioutil.WriteFile(p, data, 0640))
+}
+
+// testData returns compressible data whose chunks are all different.
+func testData(size int, seed byte) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		block := i / 4096
+		data[i] = seed ^ byte(block) ^ byte(block>>8)
+	}
+	return data
+}
+
+func TestCloudBackupFilesIncrementalRestore(t *testing.T) {
+	c := newCloudBackupTest(t, "")
+	defer c.cleanup()
+
+	volumeID, err := c.volumes.Create(&api.VolumeLocator{Name: "files"}, nil,
+		&api.VolumeSpec{Size: 1024 * 1024 * 1024, Format: api.FSType_FS_TYPE_VFS})
+	require.NoError(t, err)
+	root, _ := c.volumes.CloudBackupPath(volumeID)
+
+	big := testData(3*chunkSize+100, 1)
+	writeTestFile(t, filepath.Join(root, "big"), big)
+	writeTestFile(t, filepath.Join(root, "dir", "small"), []byte("hello"))
+	writeTestFile(t, filepath.Join(root, "dir", "copy"), big)
+	require.NoError(t, os.Symlink("dir/small", filepath.Join(root, "link")))
+	require.NoError(t, os.Chmod(filepath.Join(root, "dir"), 0750))
+
+	first := c.backup(t, volumeID, false)
+	assert.Equal(t, uint64(2*len(big)+5), first.BytesTotal)
+	assert.Equal(t, first.BytesTotal, first.BytesDone)
+	// Identical files are stored once
+	assert.Equal(t, 5, c.chunks(t))
+
+	// Only the modified chunk of the files is uploaded again
+	big[chunkSize+1] = 0xff
+	writeTestFile(t, filepath.Join(root, "big"), big)
+	writeTestFile(t, filepath.Join(root, "dir", "copy"), big)
+	second := c.backup(t, volumeID, false)
+	assert.Equal(t, 6, c.chunks(t))
+
+	enumerated, err := c.provider.CloudBackupEnumerate(&api.CloudBackupEnumerateRequest{
+		CloudBackupGenericRequest: api.CloudBackupGenericRequest{
+			SrcVolumeID:    volumeID,
+			CredentialUUID: c.credID,
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, enumerated.Backups, 2)
+	assert.Equal(t, first.ID, enumerated.Backups[0].ID)
+	assert.Equal(t, backupTypeFull, enumerated.Backups[0].Metadata[backupMetaType])
+	assert.Equal(t, second.ID, enumerated.Backups[1].ID)
+	assert.Equal(t, backupTypeIncremental, enumerated.Backups[1].Metadata[backupMetaType])
+	assert.Equal(t, first.ID, enumerated.Backups[1].Metadata[backupMetaParent])
+	assert.Equal(t, "files", enumerated.Backups[1].SrcVolumeName)
+
+	catalog, err := c.provider.CloudBackupCatalog(&api.CloudBackupCatalogRequest{
+		ID:             second.ID,
+		CredentialUUID: c.credID,
+	})
+	require.NoError(t, err)
+	sort.Strings(catalog.Contents)
+	assert.Equal(t, []string{"/big", "/dir/", "/dir/copy", "/dir/small", "/link"}, catalog.Contents)
+
+	// Deleting the first backup only removes the chunk replaced in the
+	// second one
+	err = c.provider.CloudBackupDelete(&api.CloudBackupDeleteRequest{
+		ID:             first.ID,
+		CredentialUUID: c.credID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5, c.chunks(t))
+
+	restoredID := c.restore(t, second.ID)
+	restored, _ := c.volumes.CloudBackupPath(restoredID)
+	data, err := ioutil.ReadFile(filepath.Join(restored, "big"))
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(big, data))
+	data, err = ioutil.ReadFile(filepath.Join(restored, "link"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+	link, err := os.Readlink(filepath.Join(restored, "link"))
+	require.NoError(t, err)
+	assert.Equal(t, "dir/small", link)
+	info, err := os.Stat(filepath.Join(restored, "dir"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), info.Mode().Perm())
+	info, err = os.Stat(filepath.Join(restored, "dir", "small"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	vols, err := c.volumes.Inspect([]string{restoredID})
+	require.NoError(t, err)
+	require.Len(t, vols, 1)
+	assert.Equal(t, "restore-"+second.ID, vols[0].GetLocator().GetName())
+
+	// Deleting all the backups removes all the chunks
+	err = c.provider.CloudBackupDeleteAll(&api.CloudBackupDeleteAllRequest{
+		CloudBackupGenericRequest: api.CloudBackupGenericRequest{
+			CredentialUUID: c.credID,
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, c.chunks(t))
+
+	history, err := c.provider.CloudBackupHistory(&api.CloudBackupHistoryRequest{
+		SrcVolumeID: volumeID,
+	})
+	require.NoError(t, err)
+	assert.Len(t, history.HistoryList, 2)
+}
+
+func TestCloudBackupBlockEncrypted(t *testing.T) {
+	c := newCloudBackupTest(t, "secret")
+	defer c.cleanup()
+
+	size := uint64(8 * chunkSize)
+	volumeID, err := c.volumes.Create(&api.VolumeLocator{Name: "block"}, nil,
+		&api.VolumeSpec{Size: size, Format: api.FSType_FS_TYPE_NONE})
+	require.NoError(t, err)
+	devicePath, _ := c.volumes.CloudBackupPath(volumeID)
+
+	data := testData(chunkSize+chunkSize/2, 7)
+	f, err := os.OpenFile(devicePath, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteAt(data, 3*chunkSize)
+	require.NoError(t, err)
+	f.Close()
+
+	status := c.backup(t, volumeID, false)
+	assert.Equal(t, size, status.BytesTotal)
+	// Holes are not stored
+	assert.Equal(t, 2, c.chunks(t))
+
+	// Chunks are encrypted
+	filepath.Walk(filepath.Join(c.bucket, "chunks"), func(p string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			object, err := ioutil.ReadFile(p)
+			require.NoError(t, err)
+			assert.Equal(t, objectEncrypted, object[len(objectMagic)])
+		}
+		return nil
+	})
+
+	restoredID := c.restore(t, status.ID)
+	restoredPath, _ := c.volumes.CloudBackupPath(restoredID)
+	restored, err := ioutil.ReadFile(restoredPath)
+	require.NoError(t, err)
+	require.Len(t, restored, int(size))
+	assert.True(t, isZero(restored[:3*chunkSize]))
+	assert.True(t, bytes.Equal(data, restored[3*chunkSize:3*chunkSize+len(data)]))
+	assert.True(t, isZero(restored[3*chunkSize+len(data):]))
+
+	// A corrupted chunk fails the restore and deletes the restored volume
+	filepath.Walk(filepath.Join(c.bucket, "chunks"), func(p string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			object, _ := ioutil.ReadFile(p)
+			object[len(object)-1] ^= 0xff
+			ioutil.WriteFile(p, object, 0644)
+		}
+		return nil
+	})
+	resp, err := c.provider.CloudBackupRestore(&api.CloudBackupRestoreRequest{
+		ID:                status.ID,
+		CredentialUUID:    c.credID,
+		RestoreVolumeName: "corrupted",
+	})
+	require.NoError(t, err)
+	failed := c.wait(t, resp.Name)
+	assert.Equal(t, api.CloudBackupStatusFailed, failed.Status)
+	vols, err := c.volumes.Inspect([]string{resp.RestoreVolumeID})
+	require.NoError(t, err)
+	assert.Len(t, vols, 0)
+}
+
+func TestCloudBackupWrongKey(t *testing.T) {
+	c := newCloudBackupTest(t, "secret")
+	defer c.cleanup()
+
+	volumeID, err := c.volumes.Create(&api.VolumeLocator{Name: "files"}, nil,
+		&api.VolumeSpec{Size: 1024, Format: api.FSType_FS_TYPE_VFS})
+	require.NoError(t, err)
+	root, _ := c.volumes.CloudBackupPath(volumeID)
+	writeTestFile(t, filepath.Join(root, "file"), []byte("data"))
+	status := c.backup(t, volumeID, false)
+
+	credID, err := c.provider.CredsCreate(map[string]string{
+		api.OptCredType:     CredTypeLocal,
+		api.OptCredEndpoint: filepath.Dir(c.bucket),
+		api.OptCredBucket:   "bucket",
+		api.OptCredEncrKey:  "wrong",
+	})
+	require.NoError(t, err)
+	_, err = c.provider.CloudBackupCatalog(&api.CloudBackupCatalogRequest{
+		ID:             status.ID,
+		CredentialUUID: credID,
+	})
+	assert.Error(t, err)
+}
+
+func TestCloudBackupStateChange(t *testing.T) {
+	c := newCloudBackupTest(t, "")
+	defer c.cleanup()
+
+	volumeID, err := c.volumes.Create(&api.VolumeLocator{Name: "block"}, nil,
+		&api.VolumeSpec{Size: 4 * chunkSize, Format: api.FSType_FS_TYPE_NONE})
+	require.NoError(t, err)
+
+	p := c.provider.(*cloudBackupProvider)
+	job, err := p.startJob("paused", cloudBackupTask{
+		BackupID: "backup",
+		Status: api.CloudBackupStatus{
+			OpType:      api.CloudBackupOp,
+			Status:      api.CloudBackupStatusActive,
+			SrcVolumeID: volumeID,
+		},
+	})
+	require.NoError(t, err)
+
+	err = c.provider.CloudBackupStateChange(&api.CloudBackupStateChangeRequest{
+		Name:           "paused",
+		RequestedState: api.CloudBackupRequestedStatePause,
+	})
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		done <- job.progress(10)
+	}()
+	select {
+	case <-done:
+		t.Fatalf("Paused task made progress")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	err = c.provider.CloudBackupStateChange(&api.CloudBackupStateChangeRequest{
+		Name:           "paused",
+		RequestedState: api.CloudBackupRequestedStateStop,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, errTaskStopped, <-done)
+	p.finishJob(job, errTaskStopped)
+	assert.Equal(t, api.CloudBackupStatusStopped, c.wait(t, "paused").Status)
+
+	err = c.provider.CloudBackupStateChange(&api.CloudBackupStateChangeRequest{
+		Name:           "paused",
+		RequestedState: api.CloudBackupRequestedStateResume,
+	})
+	assert.Error(t, err)
+}
+
+func TestObjectCodec(t *testing.T) {
+	plain, err := newObjectCodec("")
+	require.NoError(t, err)
+	encrypted, err := newObjectCodec("key")
+	require.NoError(t, err)
+
+	data := testData(10000, 3)
+	assert.NotEqual(t, plain.chunkID(data), encrypted.chunkID(data))
+
+	for _, c := range []*objectCodec{plain, encrypted} {
+		object, err := c.encode(data)
+		require.NoError(t, err)
+		assert.True(t, len(object) < len(data), "Object was not compressed")
+		decoded, err := c.decode(object)
+		require.NoError(t, err)
+		assert.True(t, bytes.Equal(data, decoded))
+	}
+
+	object, err := encrypted.encode(data)
+	require.NoError(t, err)
+	_, err = plain.decode(object)
+	assert.Error(t, err)
+	_, err = plain.decode([]byte("garbage"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,420 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/portworx/kvdb"
+	"github.com/sirupsen/logrus"
+
+	"github.com/libopenstorage/openstorage/alerts"
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/sched"
+)
+
+const (
+	// maxVerifyProblems is the number of problems recorded by a verification
+	maxVerifyProblems = 10
+	// verifyVolumePrefix prefixes the names of the volumes of test restores
+	verifyVolumePrefix = "verify-"
+)
+
+// CloudBackupVerify starts a task checking that all the chunks of a backup
+// are present and intact, and optionally restoring it to a temporary volume.
+// The result is saved next to the backup and an alert is raised on the
+// source volume if the backup is corrupted.
+func (p *cloudBackupProvider) CloudBackupVerify(
+	input *api.CloudBackupVerifyRequest,
+) (*api.CloudBackupVerifyResponse, error) {
+	target, codec, err := p.target(input.CredentialUUID)
+	if err != nil {
+		return nil, err
+	}
+	info := &backupInfo{}
+	if err := getObject(target, codec, backupInfoKey(input.ID), info); err == errObjectNotFound {
+		return nil, fmt.Errorf("Backup %s not found", input.ID)
+	} else if err != nil {
+		return nil, err
+	}
+
+	total := info.Size
+	if input.TestRestore {
+		total *= 2
+	}
+	clusterID, nodeID := p.clusterInfo()
+	job, err := p.startJob(input.Name, cloudBackupTask{
+		BackupID:  input.ID,
+		ClusterID: clusterID,
+		Status: api.CloudBackupStatus{
+			OpType:         api.CloudBackupVerifyOp,
+			Status:         api.CloudBackupStatusActive,
+			StartTime:      time.Now(),
+			NodeID:         nodeID,
+			SrcVolumeID:    info.SrcVolumeID,
+			CredentialUUID: input.CredentialUUID,
+			BytesTotal:     total,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		p.finishJob(job, p.verify(target, codec, info, input.TestRestore, job.progress))
+	}()
+	return &api.CloudBackupVerifyResponse{Name: job.task.Name}, nil
+}
+
+// verify verifies a backup and records the result. An error is returned if
+// the backup is corrupted or could not be verified.
+func (p *cloudBackupProvider) verify(
+	target backupTarget,
+	codec *objectCodec,
+	info *backupInfo,
+	testRestore bool,
+	progress func(bytes uint64) error,
+) error {
+	m, problems, err := checkBackup(target, codec, info.ID, progress)
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 && testRestore {
+		if err := p.testRestore(target, codec, m, progress); err == errTaskStopped {
+			return err
+		} else if err != nil {
+			problems = append(problems, fmt.Sprintf("Test restore failed: %v", err))
+		}
+	}
+
+	v := &api.CloudBackupVerification{
+		Timestamp:   time.Now(),
+		Status:      api.CloudBackupStatusDone,
+		TestRestore: testRestore,
+		Info:        problems,
+	}
+	if len(problems) != 0 {
+		v.Status = api.CloudBackupStatusFailed
+	}
+	if err := putObject(target, codec, backupVerificationKey(info.ID), v); err != nil {
+		return err
+	}
+	p.raiseVerifyAlert(info, v)
+	if len(problems) != 0 {
+		return fmt.Errorf("Backup %s is corrupted: %s", info.ID, problems[0])
+	}
+	return nil
+}
+
+// checkBackup reads back the manifest and the chunks of a backup, checking
+// their checksums and lengths. Problems with the backup are returned as
+// descriptions while failures to read the target are returned as errors.
+func checkBackup(
+	target backupTarget,
+	codec *objectCodec,
+	backupID string,
+	progress func(bytes uint64) error,
+) (*backupManifest, []string, error) {
+	problems := make([]string, 0)
+	report := func(format string, args ...interface{}) {
+		if len(problems) < maxVerifyProblems {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	m := &backupManifest{}
+	object, err := target.get(backupManifestKey(backupID))
+	if err == errObjectNotFound {
+		report("Manifest of the backup is missing")
+		return nil, problems, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	data, err := codec.decode(object)
+	if err == nil {
+		err = json.Unmarshal(data, m)
+	}
+	if err != nil {
+		report("Manifest of the backup is corrupted: %v", err)
+		return nil, problems, nil
+	}
+
+	// Expected length of each chunk
+	lengths := make(map[string]int)
+	for _, b := range m.Blocks {
+		lengths[b.Chunk] = b.Length
+	}
+	for _, f := range m.Files {
+		for i, id := range f.Chunks {
+			length := chunkSize
+			if i == len(f.Chunks)-1 {
+				length = int(f.Size) - i*chunkSize
+			}
+			lengths[id] = length
+		}
+	}
+
+	for _, id := range m.chunks() {
+		length, ok := lengths[id]
+		if !ok {
+			// Already checked
+			continue
+		}
+		delete(lengths, id)
+
+		object, err := target.get(chunkKey(id))
+		if err == errObjectNotFound {
+			report("Chunk %s is missing", id)
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		data, err := codec.decode(object)
+		if err != nil {
+			report("Chunk %s is corrupted: %v", id, err)
+			continue
+		}
+		if codec.chunkID(data) != id {
+			report("Chunk %s is corrupted: checksum mismatch", id)
+		} else if len(data) != length {
+			report("Chunk %s has %d bytes instead of %d", id, len(data), length)
+		}
+		if err := progress(uint64(len(data))); err != nil {
+			return nil, nil, err
+		}
+	}
+	return m, problems, nil
+}
+
+// testRestore restores a backup to a temporary volume, which is deleted
+// once done.
+func (p *cloudBackupProvider) testRestore(
+	target backupTarget,
+	codec *objectCodec,
+	m *backupManifest,
+	progress func(bytes uint64) error,
+) error {
+	spec := m.Volume.GetSpec()
+	if spec == nil {
+		return fmt.Errorf("Backup has no volume specification")
+	}
+	volumeID, err := p.volumes.Create(&api.VolumeLocator{
+		Name: verifyVolumePrefix + m.ID,
+	}, &api.Source{}, spec)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := p.volumes.Delete(volumeID); err != nil {
+			logrus.Warnf("Failed to delete volume %s of the test restore of backup %s: %v",
+				volumeID, m.ID, err)
+		}
+	}()
+
+	dataPath, err := p.volumes.CloudBackupPath(volumeID)
+	if err != nil {
+		return err
+	}
+	r := &chunkReader{
+		target:   target,
+		codec:    codec,
+		progress: progress,
+	}
+	return r.restore(dataPath, m)
+}
+
+// raiseVerifyAlert raises an alert on the source volume of a corrupted
+// backup, or clears the alert raised for the backup once it is verified.
+func (p *cloudBackupProvider) raiseVerifyAlert(info *backupInfo, v *api.CloudBackupVerification) {
+	if p.alerts == nil {
+		return
+	}
+	RaiseCloudBackupVerifyAlert(p.alerts, info.ID, info.SrcVolumeID, v)
+}
+
+// RaiseCloudBackupVerifyAlert raises an AlertTypeCloudBackupCorrupted alert
+// on the source volume of a backup whose verification failed, or clears the
+// alert raised for the backup once it is verified.
+func RaiseCloudBackupVerifyAlert(
+	manager alerts.Manager,
+	backupID string,
+	srcVolumeID string,
+	v *api.CloudBackupVerification,
+) {
+	if v.Status == api.CloudBackupStatusFailed {
+		problem := "verification failed"
+		if len(v.Info) != 0 {
+			problem = v.Info[0]
+		}
+		err := manager.Raise(&api.Alert{
+			Severity:  api.SeverityType_SEVERITY_TYPE_ALARM,
+			AlertType: api.AlertTypeCloudBackupCorrupted,
+			Message: fmt.Sprintf("Cloud backup %s of volume %s is corrupted: %s",
+				backupID, srcVolumeID, problem),
+			ResourceId: srcVolumeID,
+			Resource:   api.ResourceType_RESOURCE_TYPE_VOLUME,
+			UniqueTag:  backupID,
+		})
+		if err != nil {
+			logrus.Warnf("Failed to raise alert for corrupted backup %s: %v", backupID, err)
+		}
+		return
+	}
+
+	raised, err := manager.Enumerate(alerts.NewResourceIDFilter(srcVolumeID,
+		api.AlertTypeCloudBackupCorrupted, api.ResourceType_RESOURCE_TYPE_VOLUME))
+	if err != nil {
+		logrus.Warnf("Failed to enumerate alerts of volume %s: %v", srcVolumeID, err)
+		return
+	}
+	for _, alert := range raised {
+		if alert.UniqueTag != backupID || alert.Cleared {
+			continue
+		}
+		alert.Cleared = true
+		if err := manager.Raise(alert); err != nil {
+			logrus.Warnf("Failed to clear alert for backup %s: %v", backupID, err)
+		}
+	}
+}
+
+func (p *cloudBackupProvider) scrubKeyPrefix() string {
+	return fmt.Sprintf("%s/%s/cloudbackup/scrub/", keyBase, p.driver)
+}
+
+// CloudBackupScrubUpdate sets the schedule at which the backups stored with
+// a credential are verified.
+func (p *cloudBackupProvider) CloudBackupScrubUpdate(
+	input *api.CloudBackupScrubUpdateRequest,
+) error {
+	if _, _, err := p.target(input.CredentialUUID); err != nil {
+		return err
+	}
+	key := p.scrubKeyPrefix() + input.CredentialUUID
+	if input.Scrub == nil {
+		if _, err := p.kv.Delete(key); err != nil && err != kvdb.ErrNotFound {
+			return err
+		}
+		return p.scheduleScrub(input.CredentialUUID, nil)
+	}
+
+	if err := p.scheduleScrub(input.CredentialUUID, input.Scrub); err != nil {
+		return err
+	}
+	_, err := p.kv.Put(key, input.Scrub, 0)
+	return err
+}
+
+// CloudBackupScrubInspect returns the scrub schedule of a credential.
+func (p *cloudBackupProvider) CloudBackupScrubInspect(
+	input *api.CloudBackupScrubInspectRequest,
+) (*api.CloudBackupScrubInspectResponse, error) {
+	if _, _, err := p.target(input.CredentialUUID); err != nil {
+		return nil, err
+	}
+	policy, err := p.scrubPolicy(input.CredentialUUID)
+	if err != nil {
+		return nil, err
+	}
+	return &api.CloudBackupScrubInspectResponse{
+		Scrub: policy,
+	}, nil
+}
+
+func (p *cloudBackupProvider) scrubPolicy(credUUID string) (*api.CloudBackupScrubPolicy, error) {
+	policy := &api.CloudBackupScrubPolicy{}
+	if _, err := p.kv.GetVal(p.scrubKeyPrefix()+credUUID, policy); err == kvdb.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// loadScrubs schedules the scrubs of all the credentials.
+func (p *cloudBackupProvider) loadScrubs() {
+	kvp, err := p.kv.Enumerate(p.scrubKeyPrefix())
+	if err != nil {
+		logrus.Warnf("Failed to enumerate %s cloud backup scrubs: %v", p.driver, err)
+		return
+	}
+	for _, v := range kvp {
+		credUUID := v.Key[len(p.scrubKeyPrefix()):]
+		policy, err := p.scrubPolicy(credUUID)
+		if err == nil && policy != nil {
+			err = p.scheduleScrub(credUUID, policy)
+		}
+		if err != nil {
+			logrus.Warnf("Failed to schedule the scrub of credential %s: %v", credUUID, err)
+		}
+	}
+}
+
+// scheduleScrub replaces the scheduled scrubs of a credential.
+func (p *cloudBackupProvider) scheduleScrub(credUUID string, policy *api.CloudBackupScrubPolicy) error {
+	var intervals []sched.RetainInterval
+	if policy != nil {
+		var err error
+		if intervals, err = sched.ParseSchedule(policy.Schedule); err != nil {
+			return fmt.Errorf("Invalid scrub schedule: %v", err)
+		}
+		if len(intervals) == 0 {
+			return fmt.Errorf("Scrub schedule must be provided")
+		}
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	if p.scrubber == nil {
+		if len(intervals) == 0 {
+			return nil
+		}
+		p.scrubber = sched.New(time.Minute)
+	}
+	for _, id := range p.scrubTasks[credUUID] {
+		p.scrubber.Cancel(id)
+	}
+	delete(p.scrubTasks, credUUID)
+
+	for _, interval := range intervals {
+		id, err := p.scrubber.Schedule(func(sched.Interval) {
+			p.scrub(credUUID)
+		}, interval, time.Now(), false)
+		if err != nil {
+			return err
+		}
+		p.scrubTasks[credUUID] = append(p.scrubTasks[credUUID], id)
+	}
+	return nil
+}
+
+// scrub verifies the backups of this cluster stored with a credential.
+func (p *cloudBackupProvider) scrub(credUUID string) {
+	policy, err := p.scrubPolicy(credUUID)
+	if err != nil || policy == nil {
+		return
+	}
+	target, codec, err := p.target(credUUID)
+	if err != nil {
+		logrus.Warnf("Failed to scrub the backups of credential %s: %v", credUUID, err)
+		return
+	}
+	infos, err := p.matchingBackups(target, codec, &api.CloudBackupGenericRequest{})
+	if err != nil {
+		logrus.Warnf("Failed to scrub the backups of credential %s: %v", credUUID, err)
+		return
+	}
+
+	corrupted := 0
+	for _, info := range infos {
+		err := p.verify(target, codec, info, policy.TestRestore, func(uint64) error {
+			return nil
+		})
+		if err != nil {
+			logrus.Warnf("Scrub of cloud backup %s failed: %v", info.ID, err)
+			corrupted++
+		}
+	}
+	logrus.Infof("Scrubbed %d cloud backups of credential %s, %d failed",
+		len(infos), credUUID, corrupted)
+}
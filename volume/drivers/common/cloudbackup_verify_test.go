@@ -0,0 +1,144 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libopenstorage/openstorage/alerts"
+	"github.com/libopenstorage/openstorage/api"
+)
+
+func TestCloudBackupVerify(t *testing.T) {
+	c := newCloudBackupTest(t, "key")
+	defer c.cleanup()
+	p := c.provider.(*cloudBackupProvider)
+
+	volumeID, err := c.volumes.Create(&api.VolumeLocator{Name: "files"}, nil,
+		&api.VolumeSpec{Size: 1024, Format: api.FSType_FS_TYPE_VFS})
+	require.NoError(t, err)
+	root, _ := c.volumes.CloudBackupPath(volumeID)
+	writeTestFile(t, filepath.Join(root, "file"), testData(2*chunkSize+10, 1))
+	backup := c.backup(t, volumeID, false)
+
+	verify := func(testRestore bool) api.CloudBackupStatus {
+		resp, err := c.provider.CloudBackupVerify(&api.CloudBackupVerifyRequest{
+			ID:             backup.ID,
+			CredentialUUID: c.credID,
+			TestRestore:    testRestore,
+		})
+		require.NoError(t, err)
+		return c.wait(t, resp.Name)
+	}
+	verification := func() *api.CloudBackupVerification {
+		resp, err := c.provider.CloudBackupEnumerate(&api.CloudBackupEnumerateRequest{
+			CloudBackupGenericRequest: api.CloudBackupGenericRequest{
+				CredentialUUID: c.credID,
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Backups, 1)
+		return resp.Backups[0].Verification
+	}
+	raised := func() []*api.Alert {
+		list, err := p.alerts.Enumerate(alerts.NewResourceIDFilter(volumeID,
+			api.AlertTypeCloudBackupCorrupted, api.ResourceType_RESOURCE_TYPE_VOLUME))
+		require.NoError(t, err)
+		active := make([]*api.Alert, 0)
+		for _, alert := range list {
+			if !alert.Cleared {
+				active = append(active, alert)
+			}
+		}
+		return active
+	}
+
+	assert.Nil(t, verification())
+	status := verify(true)
+	require.Equal(t, api.CloudBackupStatusDone, status.Status, "%v", status.Info)
+	assert.Equal(t, api.CloudBackupVerifyOp, status.OpType)
+	v := verification()
+	require.NotNil(t, v)
+	assert.Equal(t, api.CloudBackupStatusDone, v.Status)
+	assert.True(t, v.TestRestore)
+	assert.Empty(t, raised())
+
+	// The volume of the test restore is deleted
+	volumes, err := c.volumes.Enumerate(&api.VolumeLocator{}, nil)
+	require.NoError(t, err)
+	assert.Len(t, volumes, 1)
+
+	// Corrupt a chunk
+	chunks := make([]string, 0)
+	filepath.Walk(filepath.Join(c.bucket, "chunks"), func(p string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			chunks = append(chunks, p)
+		}
+		return nil
+	})
+	require.NotEmpty(t, chunks)
+	require.NoError(t, ioutil.WriteFile(chunks[0], []byte("corrupted"), 0644))
+
+	status = verify(false)
+	assert.Equal(t, api.CloudBackupStatusFailed, status.Status)
+	v = verification()
+	require.NotNil(t, v)
+	assert.Equal(t, api.CloudBackupStatusFailed, v.Status)
+	require.Len(t, v.Info, 1)
+	assert.Contains(t, v.Info[0], filepath.Base(chunks[0]))
+	alert := raised()
+	require.Len(t, alert, 1)
+	assert.Equal(t, backup.ID, alert[0].UniqueTag)
+
+	// Scrubs verify the backups again and clear the alert once fixed
+	require.NoError(t, os.Remove(chunks[0]))
+	status = verify(false)
+	assert.Equal(t, api.CloudBackupStatusFailed, status.Status)
+	assert.Contains(t, verification().Info[0], "missing")
+
+	err = c.provider.CloudBackupScrubUpdate(&api.CloudBackupScrubUpdateRequest{
+		CredentialUUID: c.credID,
+		Scrub:          &api.CloudBackupScrubPolicy{Schedule: "bad"},
+	})
+	assert.Error(t, err)
+	policy := &api.CloudBackupScrubPolicy{
+		Schedule: "- freq: daily\n  hour: 1\n  minute: 0\n",
+	}
+	require.NoError(t, c.provider.CloudBackupScrubUpdate(&api.CloudBackupScrubUpdateRequest{
+		CredentialUUID: c.credID,
+		Scrub:          policy,
+	}))
+	inspect, err := c.provider.CloudBackupScrubInspect(&api.CloudBackupScrubInspectRequest{
+		CredentialUUID: c.credID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, policy, inspect.Scrub)
+
+	writeTestFile(t, filepath.Join(root, "file"), testData(2*chunkSize+10, 1))
+	c.backup(t, volumeID, true)
+	require.NoError(t, c.provider.CloudBackupDelete(&api.CloudBackupDeleteRequest{
+		ID:             backup.ID,
+		CredentialUUID: c.credID,
+	}))
+	p.scrub(c.credID)
+	v = verification()
+	require.NotNil(t, v)
+	assert.Equal(t, api.CloudBackupStatusDone, v.Status)
+	assert.False(t, v.TestRestore)
+
+	require.NoError(t, c.provider.CloudBackupScrubUpdate(&api.CloudBackupScrubUpdateRequest{
+		CredentialUUID: c.credID,
+	}))
+	inspect, err = c.provider.CloudBackupScrubInspect(&api.CloudBackupScrubInspectRequest{
+		CredentialUUID: c.credID,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, inspect.Scrub)
+	p.Lock()
+	assert.Empty(t, p.scrubTasks)
+	p.Unlock()
+}
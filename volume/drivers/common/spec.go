@@ -0,0 +1,43 @@
+package common
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/libopenstorage/openstorage/api"
+)
+
+// ChangedSpecFields returns the names of the fields other than the size
+// which are set in the update of a spec and differ from the current spec.
+// Fields with their zero value are not changes, so drivers which can only
+// resize their volumes can reject the other changes.
+func ChangedSpecFields(current, update *api.VolumeSpec) []string {
+	if update == nil {
+		return nil
+	}
+	if current == nil {
+		current = &api.VolumeSpec{}
+	}
+	cur, upd := reflect.ValueOf(current).Elem(), reflect.ValueOf(update).Elem()
+	var fields []string
+	for i := 0; i < upd.NumField(); i++ {
+		field := upd.Type().Field(i)
+		if field.Name == "Size" || strings.HasPrefix(field.Name, "XXX_") {
+			continue
+		}
+		f, c := upd.Field(i), cur.Field(i)
+		if f.IsZero() || ((f.Kind() == reflect.Map || f.Kind() == reflect.Slice) && f.Len() == 0) {
+			continue
+		}
+		if m, ok := f.Interface().(proto.Message); ok {
+			if !proto.Equal(m, c.Interface().(proto.Message)) {
+				fields = append(fields, field.Name)
+			}
+		} else if !reflect.DeepEqual(f.Interface(), c.Interface()) {
+			fields = append(fields, field.Name)
+		}
+	}
+	return fields
+}
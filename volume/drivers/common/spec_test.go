@@ -0,0 +1,38 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/libopenstorage/openstorage/api"
+)
+
+func TestChangedSpecFields(t *testing.T) {
+	current := &api.VolumeSpec{
+		Size:         1024,
+		HaLevel:      1,
+		Format:       api.FSType_FS_TYPE_VFS,
+		VolumeLabels: map[string]string{"app": "db"},
+		ReplicaSet:   &api.ReplicaSet{Nodes: []string{"node1"}},
+	}
+	assert.Empty(t, ChangedSpecFields(current, nil))
+
+	// The size and the unset fields are not changes
+	assert.Empty(t, ChangedSpecFields(current, &api.VolumeSpec{Size: 2048}))
+	assert.Empty(t, ChangedSpecFields(current, &api.VolumeSpec{
+		Size:         2048,
+		HaLevel:      1,
+		VolumeLabels: map[string]string{},
+		ReplicaSet:   &api.ReplicaSet{Nodes: []string{"node1"}},
+	}))
+
+	assert.Equal(t, []string{"HaLevel", "VolumeLabels", "ReplicaSet"},
+		ChangedSpecFields(current, &api.VolumeSpec{
+			HaLevel:      2,
+			VolumeLabels: map[string]string{"app": "web"},
+			ReplicaSet:   &api.ReplicaSet{Nodes: []string{"node2"}},
+		}))
+	assert.Equal(t, []string{"Shared"},
+		ChangedSpecFields(nil, &api.VolumeSpec{Shared: true}))
+}
@@ -0,0 +1,153 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/portworx/kvdb"
+	"github.com/sirupsen/logrus"
+
+	"github.com/libopenstorage/openstorage/alerts"
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/volume"
+)
+
+const (
+	// UsageAlertThresholdParam is the driver parameter setting the percentage
+	// of the size of a volume above which an alert is raised
+	UsageAlertThresholdParam = "usage_alert_threshold"
+	// DefaultUsageAlertThreshold is the default usage alert threshold
+	DefaultUsageAlertThreshold = 90
+	// usageAlertInterval is the interval between the checks of the usage
+	usageAlertInterval = time.Minute
+)
+
+// UsageAlerts raises an alert on the volumes of a driver using more than a
+// percentage of their size, and clears it once they are back under it.
+type UsageAlerts struct {
+	driver     string
+	enumerator volume.StoreEnumerator
+	usedSize   func(volumeID string) (uint64, error)
+	alerts     alerts.Manager
+	threshold  uint64
+	stop       chan struct{}
+	stopOnce   sync.Once
+}
+
+// NewUsageAlerts returns the usage alerts of the volumes of a driver, with
+// the threshold of the driver parameters.
+func NewUsageAlerts(
+	driver string,
+	kv kvdb.Kvdb,
+	enumerator volume.StoreEnumerator,
+	usedSize func(volumeID string) (uint64, error),
+	params map[string]string,
+) (*UsageAlerts, error) {
+	threshold := uint64(DefaultUsageAlertThreshold)
+	if v, ok := params[UsageAlertThresholdParam]; ok {
+		t, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || t == 0 || t > 100 {
+			return nil, fmt.Errorf("Invalid %s %q, must be a percentage",
+				UsageAlertThresholdParam, v)
+		}
+		threshold = t
+	}
+	manager, err := alerts.NewManager(kv)
+	if err != nil {
+		return nil, err
+	}
+	return &UsageAlerts{
+		driver:     driver,
+		enumerator: enumerator,
+		usedSize:   usedSize,
+		alerts:     manager,
+		threshold:  threshold,
+		stop:       make(chan struct{}),
+	}, nil
+}
+
+// Start checks the usage of the volumes periodically until Stop is called.
+func (u *UsageAlerts) Start() {
+	go func() {
+		ticker := time.NewTicker(usageAlertInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				u.Check()
+			case <-u.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic checks.
+func (u *UsageAlerts) Stop() {
+	u.stopOnce.Do(func() { close(u.stop) })
+}
+
+// Check raises or clears the usage alerts of the volumes of the driver.
+func (u *UsageAlerts) Check() {
+	vols, err := u.enumerator.Enumerate(&api.VolumeLocator{}, nil)
+	if err != nil {
+		logrus.Warnf("Failed to enumerate %s volumes for usage alerts: %v", u.driver, err)
+		return
+	}
+	for _, v := range vols {
+		if v.Readonly || v.GetSpec().GetSize() == 0 {
+			continue
+		}
+		used, err := u.usedSize(v.Id)
+		if err != nil {
+			logrus.Debugf("Failed to get the usage of volume %s: %v", v.Id, err)
+			continue
+		}
+		u.update(v, used)
+	}
+}
+
+// update raises an alert on a volume using more than the threshold, or
+// clears its alert.
+func (u *UsageAlerts) update(v *api.Volume, used uint64) {
+	raised, err := u.alerts.Enumerate(alerts.NewResourceIDFilter(v.Id,
+		api.AlertTypeVolumeUsageHigh, api.ResourceType_RESOURCE_TYPE_VOLUME))
+	if err != nil {
+		logrus.Warnf("Failed to enumerate alerts of volume %s: %v", v.Id, err)
+		return
+	}
+	var active *api.Alert
+	for _, alert := range raised {
+		if !alert.Cleared {
+			active = alert
+		}
+	}
+
+	percent := used * 100 / v.Spec.Size
+	if percent >= u.threshold {
+		if active != nil {
+			return
+		}
+		err := u.alerts.Raise(&api.Alert{
+			Severity:  api.SeverityType_SEVERITY_TYPE_WARNING,
+			AlertType: api.AlertTypeVolumeUsageHigh,
+			Message: fmt.Sprintf("Volume %s uses %d%% of its size of %d bytes",
+				v.GetLocator().GetName(), percent, v.Spec.Size),
+			ResourceId: v.Id,
+			Resource:   api.ResourceType_RESOURCE_TYPE_VOLUME,
+			UniqueTag:  v.Id,
+		})
+		if err != nil {
+			logrus.Warnf("Failed to raise usage alert of volume %s: %v", v.Id, err)
+		}
+		return
+	}
+	if active != nil {
+		active.Cleared = true
+		if err := u.alerts.Raise(active); err != nil {
+			logrus.Warnf("Failed to clear usage alert of volume %s: %v", v.Id, err)
+		}
+	}
+}
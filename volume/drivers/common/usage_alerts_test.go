@@ -0,0 +1,66 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/portworx/kvdb"
+	"github.com/portworx/kvdb/mem"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libopenstorage/openstorage/alerts"
+	"github.com/libopenstorage/openstorage/api"
+)
+
+func TestUsageAlerts(t *testing.T) {
+	kv, err := kvdb.New(mem.Name, "usage_alerts_test", []string{}, nil, logrus.Panicf)
+	require.NoError(t, err)
+	enumerator := NewDefaultStoreEnumerator("usage_alerts_test", kv)
+	v := NewVolume("vol", api.FSType_FS_TYPE_VFS, &api.VolumeLocator{Name: "vol"}, nil,
+		&api.VolumeSpec{Size: 1000})
+	require.NoError(t, enumerator.CreateVol(v))
+
+	used := uint64(0)
+	usedSize := func(volumeID string) (uint64, error) {
+		return used, nil
+	}
+	_, err = NewUsageAlerts("usage_alerts_test", kv, enumerator, usedSize,
+		map[string]string{UsageAlertThresholdParam: "120"})
+	assert.Error(t, err)
+	u, err := NewUsageAlerts("usage_alerts_test", kv, enumerator, usedSize,
+		map[string]string{UsageAlertThresholdParam: "80"})
+	require.NoError(t, err)
+
+	active := func() []*api.Alert {
+		list, err := u.alerts.Enumerate(alerts.NewResourceIDFilter("vol",
+			api.AlertTypeVolumeUsageHigh, api.ResourceType_RESOURCE_TYPE_VOLUME))
+		require.NoError(t, err)
+		var raised []*api.Alert
+		for _, alert := range list {
+			if !alert.Cleared {
+				raised = append(raised, alert)
+			}
+		}
+		return raised
+	}
+
+	used = 500
+	u.Check()
+	assert.Empty(t, active())
+
+	used = 850
+	u.Check()
+	raised := active()
+	require.Len(t, raised, 1)
+	assert.Equal(t, api.SeverityType_SEVERITY_TYPE_WARNING, raised[0].Severity)
+	assert.Contains(t, raised[0].Message, "85%")
+
+	// The alert is raised once while the volume stays above the threshold
+	u.Check()
+	assert.Len(t, active(), 1)
+
+	used = 100
+	u.Check()
+	assert.Empty(t, active())
+}
@@ -0,0 +1,150 @@
+/*
+Package fake provides an in-memory fake driver implementation
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fake
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/libopenstorage/openstorage/api"
+)
+
+const (
+	// BucketPathParam is the driver parameter used to set the local
+	// directory under which cloud backups are stored.
+	BucketPathParam = "bucket_path"
+
+	backupImageSuffix = ".img"
+)
+
+var (
+	// defaultBucketPath is used when the driver is not given a bucket path
+	defaultBucketPath = filepath.Join(os.TempDir(), "openstorage-fake-cloud")
+)
+
+// bucket stores cloud backups as sparse image files in a local directory.
+type bucket struct {
+	path string
+}
+
+// bucketForCred returns the bucket of the credential. The bucket name
+// provided when creating the credential is used if available, otherwise the
+// credential id is used as the name.
+func (d *driver) bucketForCred(credID string) (*bucket, error) {
+	var cred fakeCred
+	if _, err := d.kv.GetVal(credsKeyPrefix+"/"+credID, &cred); err != nil {
+		return nil, fmt.Errorf("Credential id %s not found", credID)
+	}
+
+	name := credID
+	if b, ok := cred.Params[api.OptCredBucket].(string); ok && len(b) != 0 {
+		name = b
+	}
+	return &bucket{
+		path: filepath.Join(d.bucketPath, filepath.Base(name)),
+	}, nil
+}
+
+func (b *bucket) imagePath(backupID string) string {
+	return filepath.Join(b.path, filepath.Base(backupID)+backupImageSuffix)
+}
+
+// upload saves the data of the volume in the bucket and returns the number
+// of bytes transferred.
+func (b *bucket) upload(s *dataStore, volumeID, backupID string, size uint64) (uint64, error) {
+	if err := os.MkdirAll(b.path, 0755); err != nil {
+		return 0, err
+	}
+	file, err := os.Create(b.imagePath(backupID))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	if err := file.Truncate(int64(size)); err != nil {
+		return 0, err
+	}
+	done, err := s.export(volumeID, func(offset int64, data []byte) error {
+		_, err := file.WriteAt(data, offset)
+		return err
+	})
+	if err != nil {
+		return done, err
+	}
+	return done, file.Sync()
+}
+
+// download loads the data of a backup into an existing volume and returns
+// the number of bytes transferred. Blocks of zeros are skipped so that the
+// volume stays sparse.
+func (b *bucket) download(s *dataStore, backupID, volumeID string) (uint64, error) {
+	file, err := os.Open(b.imagePath(backupID))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var (
+		done   uint64
+		offset int64
+		zeros  = make([]byte, blockSize)
+		buf    = make([]byte, blockSize)
+	)
+	for {
+		n, err := io.ReadFull(file, buf)
+		if n > 0 && !bytes.Equal(buf[:n], zeros[:n]) {
+			if _, err := s.write(volumeID, buf[:n], offset); err != nil {
+				return done, err
+			}
+			done += uint64(n)
+		}
+		offset += int64(n)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return done, nil
+		} else if err != nil {
+			return done, err
+		}
+	}
+}
+
+// checksum returns the sha256 checksum of the image of a backup
+func (b *bucket) checksum(backupID string) (string, error) {
+	file, err := os.Open(b.imagePath(backupID))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// remove deletes the backup from the bucket.
+func (b *bucket) remove(backupID string) error {
+	if err := os.Remove(b.imagePath(backupID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+/*
+Package fake provides an in-memory fake driver implementation
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fake
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/libopenstorage/openstorage/volume"
+)
+
+const (
+	// blockSize is the granularity at which data is shared between a volume
+	// and its snapshots and clones.
+	blockSize = 64 * 1024
+)
+
+// volumeData holds the contents of a single volume. Blocks are never
+// modified in place once stored, which allows snapshots and clones to share
+// them; a write always replaces the affected blocks with new copies.
+// Blocks which have never been written are not allocated and read as zeros.
+type volumeData struct {
+	size   uint64
+	blocks map[uint64][]byte
+}
+
+// dataStore keeps the data of all the volumes of a fake driver in memory.
+type dataStore struct {
+	sync.RWMutex
+	vols map[string]*volumeData
+}
+
+func newDataStore() *dataStore {
+	return &dataStore{
+		vols: make(map[string]*volumeData),
+	}
+}
+
+// create allocates an empty volume of the requested size.
+func (s *dataStore) create(volumeID string, size uint64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.vols[volumeID]; ok {
+		return volume.ErrExist
+	}
+	s.vols[volumeID] = &volumeData{
+		size:   size,
+		blocks: make(map[uint64][]byte),
+	}
+	return nil
+}
+
+// clone creates a volume which shares all the blocks of the source volume.
+func (s *dataStore) clone(sourceID, volumeID string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	src, ok := s.vols[sourceID]
+	if !ok {
+		return volume.ErrEnoEnt
+	}
+	if _, ok := s.vols[volumeID]; ok {
+		return volume.ErrExist
+	}
+	s.vols[volumeID] = src.copy()
+	return nil
+}
+
+// restore replaces the contents of a volume with those of a snapshot.
+func (s *dataStore) restore(volumeID, snapID string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	snap, ok := s.vols[snapID]
+	if !ok {
+		return volume.ErrEnoEnt
+	}
+	if _, ok := s.vols[volumeID]; !ok {
+		return volume.ErrEnoEnt
+	}
+	s.vols[volumeID] = snap.copy()
+	return nil
+}
+
+func (s *dataStore) delete(volumeID string) {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.vols, volumeID)
+}
+
+// resize changes the size of the volume. Blocks past the new end of the
+// volume are released.
+func (s *dataStore) resize(volumeID string, size uint64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	v, ok := s.vols[volumeID]
+	if !ok {
+		return volume.ErrEnoEnt
+	}
+	if size < v.size {
+		for index, data := range v.blocks {
+			start := index * blockSize
+			if start >= size {
+				delete(v.blocks, index)
+			} else if start+uint64(len(data)) > size {
+				trimmed := make([]byte, size-start)
+				copy(trimmed, data)
+				v.blocks[index] = trimmed
+			}
+		}
+	}
+	v.size = size
+	return nil
+}
+
+func (s *dataStore) read(volumeID string, buf []byte, offset int64) (int64, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	v, ok := s.vols[volumeID]
+	if !ok {
+		return 0, volume.ErrEnoEnt
+	}
+	if offset < 0 {
+		return 0, volume.ErrEinval
+	}
+	if uint64(offset) >= v.size {
+		return 0, io.EOF
+	}
+	if remaining := v.size - uint64(offset); uint64(len(buf)) > remaining {
+		buf = buf[:remaining]
+	}
+
+	done := 0
+	for done < len(buf) {
+		pos := uint64(offset) + uint64(done)
+		index, off := pos/blockSize, pos%blockSize
+		n := int(blockSize - off)
+		if n > len(buf)-done {
+			n = len(buf) - done
+		}
+		chunk := buf[done : done+n]
+		data := v.blocks[index]
+		copied := 0
+		if off < uint64(len(data)) {
+			copied = copy(chunk, data[off:])
+		}
+		for i := copied; i < n; i++ {
+			chunk[i] = 0
+		}
+		done += n
+	}
+	return int64(done), nil
+}
+
+func (s *dataStore) write(volumeID string, buf []byte, offset int64) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	v, ok := s.vols[volumeID]
+	if !ok {
+		return 0, volume.ErrEnoEnt
+	}
+	if offset < 0 || uint64(offset)+uint64(len(buf)) > v.size {
+		return 0, fmt.Errorf("Write of %d bytes at offset %d is beyond "+
+			"the size %d of volume %s", len(buf), offset, v.size, volumeID)
+	}
+
+	done := 0
+	for done < len(buf) {
+		pos := uint64(offset) + uint64(done)
+		index, off := pos/blockSize, pos%blockSize
+		n := int(blockSize - off)
+		if n > len(buf)-done {
+			n = len(buf) - done
+		}
+		// Never modify a block in place since it may be shared
+		length := blockSize
+		if end := (index + 1) * blockSize; end > v.size {
+			length = int(v.size - index*blockSize)
+		}
+		block := make([]byte, length)
+		copy(block, v.blocks[index])
+		copy(block[off:], buf[done:done+n])
+		v.blocks[index] = block
+		done += n
+	}
+	return int64(done), nil
+}
+
+// usage returns the number of bytes allocated by the volume, and how many of
+// those are shared with other volumes.
+func (s *dataStore) usage(volumeID string) (uint64, uint64, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	v, ok := s.vols[volumeID]
+	if !ok {
+		return 0, 0, volume.ErrEnoEnt
+	}
+
+	var total, shared uint64
+	for index, data := range v.blocks {
+		total += uint64(len(data))
+		for id, other := range s.vols {
+			if id == volumeID {
+				continue
+			}
+			if o, ok := other.blocks[index]; ok && len(o) > 0 && &o[0] == &data[0] {
+				shared += uint64(len(data))
+				break
+			}
+		}
+	}
+	return total, shared, nil
+}
+
+// export calls fn for each allocated block of the volume in offset order
+// from a consistent point in time view of the volume.
+func (s *dataStore) export(volumeID string, fn func(offset int64, data []byte) error) (uint64, error) {
+	s.RLock()
+	v, ok := s.vols[volumeID]
+	var view *volumeData
+	if ok {
+		view = v.copy()
+	}
+	s.RUnlock()
+	if !ok {
+		return 0, volume.ErrEnoEnt
+	}
+
+	indexes := make([]uint64, 0, len(view.blocks))
+	for index := range view.blocks {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+
+	var bytes uint64
+	for _, index := range indexes {
+		data := view.blocks[index]
+		if err := fn(int64(index*blockSize), data); err != nil {
+			return bytes, err
+		}
+		bytes += uint64(len(data))
+	}
+	return bytes, nil
+}
+
+func (v *volumeData) copy() *volumeData {
+	blocks := make(map[uint64][]byte, len(v.blocks))
+	for index, data := range v.blocks {
+		blocks[index] = data
+	}
+	return &volumeData{
+		size:   v.size,
+		blocks: blocks,
+	}
+}
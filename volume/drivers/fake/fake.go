@@ -19,16 +19,21 @@ package fake
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"strings"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/libopenstorage/openstorage/alerts"
 	"github.com/libopenstorage/openstorage/api"
 	"github.com/libopenstorage/openstorage/cluster"
 	clustermanager "github.com/libopenstorage/openstorage/cluster/manager"
+	"github.com/libopenstorage/openstorage/pkg/chaos"
+	"github.com/libopenstorage/openstorage/pkg/sched"
 	"github.com/libopenstorage/openstorage/volume"
 	"github.com/libopenstorage/openstorage/volume/drivers/common"
 	"github.com/pborman/uuid"
@@ -44,17 +49,45 @@ const (
 	Type             = api.DriverType_DRIVER_TYPE_BLOCK
 )
 
+var (
+	koCreate   = chaos.Add("fake", "create", "creation of a volume")
+	koDelete   = chaos.Add("fake", "delete", "deletion of a volume")
+	koMount    = chaos.Add("fake", "mount", "mount of a volume")
+	koUnmount  = chaos.Add("fake", "unmount", "unmount of a volume")
+	koAttach   = chaos.Add("fake", "attach", "attachment of a volume")
+	koDetach   = chaos.Add("fake", "detach", "detachment of a volume")
+	koSet      = chaos.Add("fake", "set", "update of a volume")
+	koSnapshot = chaos.Add("fake", "snapshot", "snapshot of a volume")
+	koRestore  = chaos.Add("fake", "restore", "restore of a volume from a snapshot")
+)
+
 // Implements the open storage volume interface.
 type driver struct {
-	volume.IODriver
 	volume.StoreEnumerator
 	volume.StatsDriver
 	volume.QuiesceDriver
 	volume.CredsDriver
 	volume.CloudBackupDriver
-	volume.CloudMigrateDriver
 	kv          kvdb.Kvdb
 	thisCluster cluster.Cluster
+	data        *dataStore
+	bucketPath  string
+	pairToken   string
+	migrateLock sync.Mutex
+	migrations  map[string]*migrateTask
+	// retentionLock serializes the enforcement of retention policies
+	retentionLock sync.Mutex
+	// alerts raises the alerts of the corrupted backups, it is nil if the
+	// alerts manager could not be created
+	alerts alerts.Manager
+	// scrubber runs the scheduled verifications of the backups, whose tasks
+	// are tracked by credential in scrubTasks
+	scrubLock  sync.Mutex
+	scrubber   sched.Scheduler
+	scrubTasks map[string][]sched.TaskID
+	// stop is closed on shutdown to stop the background tasks
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
 type fakeCred struct {
@@ -67,6 +100,8 @@ type fakeBackups struct {
 	Info      api.CloudBackupInfo
 	Status    api.CloudBackupStatus
 	ClusterId string
+	// Checksum of the image of the backup, recorded once uploaded
+	Checksum string
 }
 
 type fakeSchedules struct {
@@ -86,13 +121,26 @@ func newFakeDriver(params map[string]string) (*driver, error) {
 	if err != nil {
 		return nil, err
 	}
+	bucketPath, ok := params[BucketPathParam]
+	if !ok || len(bucketPath) == 0 {
+		bucketPath = defaultBucketPath
+	}
 	inst := &driver{
-		IODriver:           volume.IONotSupported,
-		StoreEnumerator:    common.NewDefaultStoreEnumerator(Name, kv),
-		StatsDriver:        volume.StatsNotSupported,
-		QuiesceDriver:      volume.QuiesceNotSupported,
-		CloudMigrateDriver: volume.CloudMigrateNotSupported,
-		kv:                 kv,
+		StoreEnumerator: common.NewDefaultStoreEnumerator(Name, kv),
+		StatsDriver:     volume.StatsNotSupported,
+		QuiesceDriver:   volume.QuiesceNotSupported,
+		kv:              kv,
+		data:            newDataStore(),
+		bucketPath:      bucketPath,
+		pairToken:       params[PairTokenParam],
+		migrations:      make(map[string]*migrateTask),
+		scrubTasks:      make(map[string][]sched.TaskID),
+		stop:            make(chan struct{}),
+	}
+	if manager, err := alerts.NewManager(kv); err != nil {
+		logrus.Warnf("Failed to create the alerts manager of the fake driver: %v", err)
+	} else {
+		inst.alerts = manager
 	}
 
 	inst.thisCluster, err = clustermanager.Inst()
@@ -110,6 +158,11 @@ func newFakeDriver(params map[string]string) (*driver, error) {
 		}
 	}
 
+	if len(inst.pairToken) != 0 {
+		registerPairedDriver(inst.pairToken, inst)
+	}
+	go inst.reapBackups()
+
 	logrus.Println("Fake driver initialized")
 	return inst, nil
 }
@@ -157,6 +210,9 @@ func (d *driver) Create(
 	source *api.Source,
 	spec *api.VolumeSpec) (string, error) {
 
+	if err := chaos.Now(koCreate); err != nil {
+		return "", err
+	}
 	if spec.Size == 0 {
 		return "", fmt.Errorf("Volume size cannot be zero")
 	} else if spec.GetHaLevel() == 0 {
@@ -182,13 +238,29 @@ func (d *driver) Create(
 		spec,
 	)
 
+	if parent := source.GetParent(); len(parent) != 0 {
+		if err := d.data.clone(parent, volumeID); err != nil {
+			return "", err
+		}
+		if err := d.data.resize(volumeID, spec.Size); err != nil {
+			d.data.delete(volumeID)
+			return "", err
+		}
+	} else if err := d.data.create(volumeID, spec.Size); err != nil {
+		return "", err
+	}
+
 	if err := d.CreateVol(v); err != nil {
+		d.data.delete(volumeID)
 		return "", err
 	}
 	return v.Id, nil
 }
 
 func (d *driver) Delete(volumeID string) error {
+	if err := chaos.Now(koDelete); err != nil {
+		return err
+	}
 	_, err := d.GetVol(volumeID)
 	if err != nil {
 		logrus.Println(err)
@@ -200,15 +272,50 @@ func (d *driver) Delete(volumeID string) error {
 		logrus.Println(err)
 		return err
 	}
+	d.data.delete(volumeID)
 
 	return nil
 }
 
+// Read reads from the volume backing data
+func (d *driver) Read(volumeID string, buf []byte, sz uint64, offset int64) (int64, error) {
+	if sz > uint64(len(buf)) {
+		return 0, volume.ErrEinval
+	}
+	return d.data.read(volumeID, buf[:sz], offset)
+}
+
+// Write writes to the volume backing data
+func (d *driver) Write(volumeID string, buf []byte, sz uint64, offset int64) (int64, error) {
+	if sz > uint64(len(buf)) {
+		return 0, volume.ErrEinval
+	}
+	v, err := d.GetVol(volumeID)
+	if err != nil {
+		return 0, err
+	}
+	if v.Readonly {
+		return 0, fmt.Errorf("Volume %s is read only", volumeID)
+	}
+	return d.data.write(volumeID, buf[:sz], offset)
+}
+
+// Flush is a no-op since all writes are done in memory
+func (d *driver) Flush(volumeID string) error {
+	if _, err := d.GetVol(volumeID); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (d *driver) MountedAt(mountpath string) string {
 	return ""
 }
 
 func (d *driver) Mount(volumeID string, mountpath string, options map[string]string) error {
+	if err := chaos.Now(koMount); err != nil {
+		return err
+	}
 	v, err := d.GetVol(volumeID)
 	if err != nil {
 		logrus.Println(err)
@@ -220,6 +327,9 @@ func (d *driver) Mount(volumeID string, mountpath string, options map[string]str
 }
 
 func (d *driver) Unmount(volumeID string, mountpath string, options map[string]string) error {
+	if err := chaos.Now(koUnmount); err != nil {
+		return err
+	}
 	v, err := d.GetVol(volumeID)
 	if err != nil {
 		return err
@@ -234,6 +344,9 @@ func (d *driver) Unmount(volumeID string, mountpath string, options map[string]s
 
 func (d *driver) Snapshot(volumeID string, readonly bool, locator *api.VolumeLocator, noRetry bool) (string, error) {
 
+	if err := chaos.Now(koSnapshot); err != nil {
+		return "", err
+	}
 	if len(locator.GetName()) == 0 {
 		return "", fmt.Errorf("Name for snapshot must be provided")
 	}
@@ -241,24 +354,41 @@ func (d *driver) Snapshot(volumeID string, readonly bool, locator *api.VolumeLoc
 	volIDs := []string{volumeID}
 	vols, err := d.Inspect(volIDs)
 	if err != nil {
-		return "", nil
+		return "", err
+	} else if len(vols) == 0 {
+		return "", volume.ErrEnoEnt
 	}
+	locator = proto.Clone(locator).(*api.VolumeLocator)
 	source := &api.Source{Parent: volumeID}
 	logrus.Infof("Creating snap %s for vol %s", locator.Name, volumeID)
 	newVolumeID, err := d.Create(locator, source, vols[0].Spec)
 	if err != nil {
-		return "", nil
+		return "", err
+	}
+	if readonly {
+		snap, err := d.GetVol(newVolumeID)
+		if err == nil {
+			snap.Readonly = true
+			err = d.UpdateVol(snap)
+		}
+		if err != nil {
+			d.Delete(newVolumeID)
+			return "", err
+		}
 	}
 
 	return newVolumeID, nil
 }
 
 func (d *driver) Restore(volumeID string, snapID string) error {
+	if err := chaos.Now(koRestore); err != nil {
+		return err
+	}
 	if _, err := d.Inspect([]string{volumeID, snapID}); err != nil {
 		return err
 	}
 
-	return nil
+	return d.data.restore(volumeID, snapID)
 }
 
 func (d *driver) SnapshotGroup(groupID string, labels map[string]string, volumeIDs []string) (*api.GroupSnapCreateResponse, error) {
@@ -268,18 +398,30 @@ func (d *driver) SnapshotGroup(groupID string, labels map[string]string, volumeI
 }
 
 func (d *driver) Attach(volumeID string, attachOptions map[string]string) (string, error) {
+	if err := chaos.Now(koAttach); err != nil {
+		return "", err
+	}
 	return "/dev/fake/" + volumeID, nil
 }
 
 func (d *driver) Detach(volumeID string, options map[string]string) error {
+	if err := chaos.Now(koDetach); err != nil {
+		return err
+	}
 	return nil
 }
 
 func (d *driver) Set(volumeID string, locator *api.VolumeLocator, spec *api.VolumeSpec) error {
+	if err := chaos.Now(koSet); err != nil {
+		return err
+	}
 	v, err := d.GetVol(volumeID)
 	if err != nil {
 		return err
 	}
+	if spec.GetHaLevel() < 0 || spec.GetHaLevel() > 3 {
+		return fmt.Errorf("HA level %d must be between 1 and 3", spec.GetHaLevel())
+	}
 
 	// Set locator
 	if locator != nil {
@@ -305,11 +447,12 @@ func (d *driver) Set(volumeID string, locator *api.VolumeLocator, spec *api.Volu
 	}
 
 	// Set Spec
+	size := v.Spec.Size
 	if spec != nil {
 		if spec.Size != 0 {
 			v.Spec.Size = spec.Size
 		}
-		if spec.HaLevel > 0 && spec.HaLevel < 4 {
+		if spec.HaLevel > 0 {
 			v.Spec.HaLevel = spec.HaLevel
 		}
 		if spec.GetReplicaSet() != nil {
@@ -325,10 +468,33 @@ func (d *driver) Set(volumeID string, locator *api.VolumeLocator, spec *api.Volu
 		v.Spec.SnapshotSchedule = spec.SnapshotSchedule
 	}
 
-	return d.UpdateVol(v)
+	// Resize the data last, and back if the volume cannot be updated
+	if v.Spec.Size != size {
+		if err := d.data.resize(volumeID, v.Spec.Size); err != nil {
+			return err
+		}
+	}
+	if err := d.UpdateVol(v); err != nil {
+		if v.Spec.Size != size {
+			d.data.resize(volumeID, size)
+		}
+		return err
+	}
+	return nil
 }
 
-func (d *driver) Shutdown() {}
+func (d *driver) Shutdown() {
+	if len(d.pairToken) != 0 {
+		unregisterPairedDriver(d.pairToken)
+	}
+	d.stopOnce.Do(func() { close(d.stop) })
+
+	d.scrubLock.Lock()
+	defer d.scrubLock.Unlock()
+	if d.scrubber != nil {
+		d.scrubber.Stop()
+	}
+}
 func (d *driver) Stats(volumeID string, cumulative bool) (*api.Stats, error) {
 
 	vols, err := d.Inspect([]string{volumeID})
@@ -436,6 +602,10 @@ func (d *driver) CloudBackupCreate(
 ) (*api.CloudBackupCreateResponse, error) {
 	name, _, err := d.cloudBackupCreate(input)
 	if err == nil {
+		if err := d.applyRetention(input.CredentialUUID); err != nil {
+			logrus.Warnf("Failed to apply the retention policy of credential %s: %v",
+				input.CredentialUUID, err)
+		}
 		resp := &api.CloudBackupCreateResponse{Name: name}
 		return resp, err
 	}
@@ -471,16 +641,42 @@ func (d *driver) cloudBackupCreate(input *api.CloudBackupCreateRequest) (string,
 	if err != nil {
 		return "", "", err
 	}
+
+	// Upload the data to the bucket
+	b, err := d.bucketForCred(input.CredentialUUID)
+	if err != nil {
+		return "", "", err
+	}
+	start := time.Now()
+	bytesDone, err := b.upload(d.data, vol.GetId(), cloudId, vol.GetSpec().GetSize())
+	if err != nil {
+		b.remove(cloudId)
+		return "", "", fmt.Errorf("Failed to upload volume %s: %v", vol.GetId(), err)
+	}
+	checksum, err := b.checksum(cloudId)
+	if err != nil {
+		b.remove(cloudId)
+		return "", "", fmt.Errorf("Failed to checksum the backup of volume %s: %v", vol.GetId(), err)
+	}
+
+	metadata := map[string]string{
+		"fake": "backup",
+	}
+	for k, v := range input.Labels {
+		metadata[k] = v
+	}
 	_, err = d.kv.Put(backupsKeyPrefix+"/"+taskId, &fakeBackups{
 		Volume:    *vol,
 		ClusterId: clusterInfo.Id,
+		Checksum:  checksum,
 		Status: api.CloudBackupStatus{
 			ID:             cloudId,
 			OpType:         api.CloudBackupOp,
 			Status:         api.CloudBackupStatusDone,
-			BytesDone:      vol.GetSpec().GetSize(),
-			StartTime:      time.Now(),
-			CompletedTime:  time.Now().Local().Add(1 * time.Second),
+			BytesDone:      bytesDone,
+			BytesTotal:     bytesDone,
+			StartTime:      start,
+			CompletedTime:  time.Now(),
 			NodeID:         clusterInfo.NodeId,
 			CredentialUUID: input.CredentialUUID,
 			SrcVolumeID:    input.VolumeID,
@@ -490,13 +686,12 @@ func (d *driver) cloudBackupCreate(input *api.CloudBackupCreateRequest) (string,
 			SrcVolumeID:   input.VolumeID,
 			SrcVolumeName: vol.GetLocator().GetName(),
 			Timestamp:     time.Now(),
-			Metadata: map[string]string{
-				"fake": "backup",
-			},
-			Status: string(api.CloudBackupStatusDone),
+			Metadata:      metadata,
+			Status:        string(api.CloudBackupStatusDone),
 		},
 	}, 0)
 	if err != nil {
+		b.remove(cloudId)
 		return "", "", err
 	}
 	return taskId, cloudId, nil
@@ -544,10 +739,21 @@ func (d *driver) CloudBackupRestore(
 		return nil, err
 	}
 
+	b, err := d.bucketForCred(input.CredentialUUID)
+	if err != nil {
+		return nil, err
+	}
+
 	volid, err := d.Create(&api.VolumeLocator{Name: input.RestoreVolumeName}, &api.Source{}, backup.Volume.GetSpec())
 	if err != nil {
 		return nil, err
 	}
+	start := time.Now()
+	bytesDone, err := b.download(d.data, input.ID, volid)
+	if err != nil {
+		d.Delete(volid)
+		return nil, fmt.Errorf("Failed to download backup %s: %v", input.ID, err)
+	}
 	vols, err := d.Inspect([]string{volid})
 	if err != nil {
 		return nil, fmt.Errorf("Volume id not found")
@@ -572,9 +778,10 @@ func (d *driver) CloudBackupRestore(
 			ID:             cloudId,
 			OpType:         api.CloudRestoreOp,
 			Status:         api.CloudBackupStatusDone,
-			BytesDone:      vol.GetSpec().GetSize(),
-			StartTime:      time.Now(),
-			CompletedTime:  time.Now().Local().Add(1 * time.Second),
+			BytesDone:      bytesDone,
+			BytesTotal:     bytesDone,
+			StartTime:      start,
+			CompletedTime:  time.Now(),
 			NodeID:         clusterInfo.NodeId,
 			CredentialUUID: input.CredentialUUID,
 			SrcVolumeID:    volid,
@@ -598,11 +805,20 @@ func (d *driver) CloudBackupDelete(input *api.CloudBackupDeleteRequest) error {
 		return err
 	}
 
-	_, id, err := d.backupEntry(input.ID, api.CloudBackupOp)
+	backup, id, err := d.backupEntry(input.ID, api.CloudBackupOp)
+	if err != nil {
+		return err
+	}
+	if err := d.checkHold(input.CredentialUUID, &backup.Info); err != nil {
+		return err
+	}
+	b, err := d.bucketForCred(input.CredentialUUID)
 	if err != nil {
 		return err
 	}
-	//_, err := d.kv.Delete(backupsKeyPrefix + "/" + id)
+	if err := b.remove(input.ID); err != nil {
+		return err
+	}
 	_, err = d.kv.Delete(id)
 	return err
 }
@@ -625,7 +841,7 @@ func (d *driver) CloudBackupEnumerate(input *api.CloudBackupEnumerateRequest) (*
 		if err := json.Unmarshal(v.Value, elem); err != nil {
 			return nil, err
 		}
-		if elem.Status.OpType == api.CloudRestoreOp {
+		if elem.Status.OpType != api.CloudBackupOp {
 			continue
 		}
 
@@ -643,7 +859,8 @@ func (d *driver) CloudBackupEnumerate(input *api.CloudBackupEnumerateRequest) (*
 	}, nil
 }
 
-// CloudBackupDelete deletes all the backups for a given volume in cloud
+// CloudBackupDeleteAll deletes all the backups for a given volume in cloud,
+// except the backups on hold
 func (d *driver) CloudBackupDeleteAll(input *api.CloudBackupDeleteAllRequest) error {
 	// Confirm credential id
 	if err := d.CredsValidate(input.CredentialUUID); err != nil {
@@ -665,6 +882,11 @@ func (d *driver) CloudBackupDeleteAll(input *api.CloudBackupDeleteAllRequest) er
 		}
 	}
 
+	b, err := d.bucketForCred(input.CredentialUUID)
+	if err != nil {
+		return err
+	}
+
 	kvp, err := d.kv.Enumerate(backupsKeyPrefix)
 	if err != nil {
 		return err
@@ -674,18 +896,21 @@ func (d *driver) CloudBackupDeleteAll(input *api.CloudBackupDeleteAllRequest) er
 		if err := json.Unmarshal(v.Value, elem); err != nil {
 			return err
 		}
-		if elem.Status.OpType == api.CloudRestoreOp {
+		if elem.Status.OpType != api.CloudBackupOp {
 			continue
 		}
-		if len(input.SrcVolumeID) == 0 && len(input.ClusterID) == 0 {
-			_, err = d.kv.Delete(v.Key)
-		} else if input.SrcVolumeID == elem.Volume.GetId() {
-			_, err = d.kv.Delete(v.Key)
-		} else if input.ClusterID == elem.ClusterId {
-			_, err = d.kv.Delete(v.Key)
-		}
-		if err != nil {
-			return err
+		if (len(input.SrcVolumeID) == 0 && len(input.ClusterID) == 0) ||
+			input.SrcVolumeID == elem.Volume.GetId() ||
+			input.ClusterID == elem.ClusterId {
+			if err := d.checkHold(input.CredentialUUID, &elem.Info); err != nil {
+				continue
+			}
+			if err := b.remove(elem.Status.ID); err != nil {
+				return err
+			}
+			if _, err := d.kv.Delete(v.Key); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -753,7 +978,8 @@ func (d *driver) CloudBackupCatalog(input *api.CloudBackupCatalogRequest) (*api.
 
 }
 
-// CloudBackupHistory displays past backup/restore operations on a volume
+// CloudBackupHistory displays past backups of a volume and the deletions of
+// its backups by retention policies
 func (d *driver) CloudBackupHistory(input *api.CloudBackupHistoryRequest) (*api.CloudBackupHistoryResponse, error) {
 
 	kvps, err := d.kv.Enumerate(backupsKeyPrefix)
@@ -772,17 +998,14 @@ func (d *driver) CloudBackupHistory(input *api.CloudBackupHistoryRequest) (*api.
 			continue
 		}
 
-		if len(input.SrcVolumeID) == 0 {
-			items = append(items, api.CloudBackupHistoryItem{
-				SrcVolumeID: elem.Info.SrcVolumeID,
-				Timestamp:   elem.Status.CompletedTime,
-				Status:      string(elem.Status.Status),
-			})
-		} else if input.SrcVolumeID == elem.Info.SrcVolumeID {
+		if len(input.SrcVolumeID) == 0 || input.SrcVolumeID == elem.Info.SrcVolumeID {
 			items = append(items, api.CloudBackupHistoryItem{
 				SrcVolumeID: elem.Info.SrcVolumeID,
 				Timestamp:   elem.Status.CompletedTime,
 				Status:      string(elem.Status.Status),
+				ID:          elem.Status.ID,
+				OpType:      elem.Status.OpType,
+				Info:        elem.Status.Info,
 			})
 		}
 	}
@@ -872,6 +1095,8 @@ func (d *driver) CloudBackupSchedCreate(
 			CredentialUUID: input.CredentialUUID,
 			Schedule:       input.Schedule,
 			MaxBackups:     input.MaxBackups,
+			Full:           input.Full,
+			Retention:      input.Retention,
 		},
 	}, 0)
 	if err != nil {
@@ -18,11 +18,18 @@ package fake
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/libopenstorage/openstorage/alerts"
 	"github.com/libopenstorage/openstorage/api"
 	clustermanager "github.com/libopenstorage/openstorage/cluster/manager"
 	"github.com/libopenstorage/openstorage/config"
+	"github.com/libopenstorage/openstorage/pkg/chaos"
 	"github.com/portworx/kvdb"
 	"github.com/portworx/kvdb/mem"
 	"github.com/sirupsen/logrus"
@@ -699,6 +706,139 @@ func TestFakeCloudBackupSchedule(t *testing.T) {
 	assert.Empty(t, schedules.Schedules)
 }
 
+func TestFakeCloudBackupRetention(t *testing.T) {
+	d, err := newFakeDriver(map[string]string{})
+	assert.NoError(t, err)
+
+	_, first, req, vol := testInitForCloudBackups(t, d)
+	_, err = d.CloudBackupCreate(&api.CloudBackupCreateRequest{
+		VolumeID:       vol.GetId(),
+		CredentialUUID: req.CredentialUUID,
+		Labels:         map[string]string{"legal": "case-1"},
+	})
+	assert.NoError(t, err)
+	_, err = d.CloudBackupCreate(req)
+	assert.NoError(t, err)
+
+	enumerate := func() []api.CloudBackupInfo {
+		resp, err := d.CloudBackupEnumerate(&api.CloudBackupEnumerateRequest{
+			CloudBackupGenericRequest: api.CloudBackupGenericRequest{
+				CredentialUUID: req.CredentialUUID,
+			},
+		})
+		assert.NoError(t, err)
+		return resp.Backups
+	}
+	assert.Len(t, enumerate(), 3)
+
+	policy := &api.CloudBackupRetentionPolicy{
+		KeepLast:   1,
+		HoldLabels: map[string]string{"legal": ""},
+	}
+	err = d.CloudBackupRetentionUpdate(&api.CloudBackupRetentionUpdateRequest{
+		CredentialUUID: req.CredentialUUID,
+		Retention:      policy,
+	})
+	assert.NoError(t, err)
+	inspect, err := d.CloudBackupRetentionInspect(&api.CloudBackupRetentionInspectRequest{
+		CredentialUUID: req.CredentialUUID,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, policy, inspect.Retention)
+
+	backups := enumerate()
+	assert.Len(t, backups, 2)
+	var held string
+	for _, b := range backups {
+		assert.NotEqual(t, first, b.ID)
+		if b.Metadata["legal"] == "case-1" {
+			held = b.ID
+		}
+	}
+	assert.NotEmpty(t, held)
+
+	history, err := d.CloudBackupHistory(&api.CloudBackupHistoryRequest{
+		SrcVolumeID: vol.GetId(),
+	})
+	assert.NoError(t, err)
+	deleted := 0
+	for _, item := range history.HistoryList {
+		if item.OpType == api.CloudBackupDeleteOp {
+			deleted++
+			assert.Equal(t, first, item.ID)
+		}
+	}
+	assert.Equal(t, 1, deleted)
+
+	err = d.CloudBackupDelete(&api.CloudBackupDeleteRequest{
+		ID:             held,
+		CredentialUUID: req.CredentialUUID,
+	})
+	assert.Error(t, err)
+
+	// The schedule of the volume takes precedence, but the backups on hold
+	// by the credential are kept
+	_, err = d.CloudBackupSchedCreate(&api.CloudBackupSchedCreateRequest{
+		CloudBackupScheduleInfo: api.CloudBackupScheduleInfo{
+			SrcVolumeID:    vol.GetId(),
+			CredentialUUID: req.CredentialUUID,
+			Schedule:       "yaml",
+			MaxBackups:     2,
+		},
+	})
+	assert.NoError(t, err)
+	_, err = d.CloudBackupCreate(req)
+	assert.NoError(t, err)
+	_, err = d.CloudBackupCreate(req)
+	assert.NoError(t, err)
+	assert.Len(t, enumerate(), 3)
+
+	err = d.CloudBackupDeleteAll(&api.CloudBackupDeleteAllRequest{
+		CloudBackupGenericRequest: api.CloudBackupGenericRequest{
+			CredentialUUID: req.CredentialUUID,
+		},
+	})
+	assert.NoError(t, err)
+	backups = enumerate()
+	assert.Len(t, backups, 1)
+	assert.Equal(t, held, backups[0].ID)
+}
+
+func TestFakeCloudBackupReapBackups(t *testing.T) {
+	interval := retentionInterval
+	retentionInterval = 10 * time.Millisecond
+	defer func() {
+		retentionInterval = interval
+	}()
+
+	d, err := newFakeDriver(map[string]string{})
+	assert.NoError(t, err)
+	defer d.Shutdown()
+
+	_, _, req, _ := testInitForCloudBackups(t, d)
+	_, err = d.CloudBackupCreate(req)
+	assert.NoError(t, err)
+
+	// The policy is enforced in the background without new backups
+	_, err = d.kv.Put(retentionKeyPrefix+"/"+req.CredentialUUID,
+		&api.CloudBackupRetentionPolicy{KeepLast: 1}, 0)
+	assert.NoError(t, err)
+	enumerateReq := &api.CloudBackupEnumerateRequest{
+		CloudBackupGenericRequest: api.CloudBackupGenericRequest{
+			CredentialUUID: req.CredentialUUID,
+		},
+	}
+	for i := 0; i < 100; i++ {
+		resp, err := d.CloudBackupEnumerate(enumerateReq)
+		assert.NoError(t, err)
+		if len(resp.Backups) == 1 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Backups were not reaped by the retention policy")
+}
+
 func TestFakeSet(t *testing.T) {
 	d, err := newFakeDriver(map[string]string{})
 	assert.NoError(t, err)
@@ -741,4 +881,522 @@ func TestFakeSet(t *testing.T) {
 	assert.Equal(t, spec.Size, uint64(9876))
 	assert.Equal(t, spec.HaLevel, int64(1))
 	assert.Equal(t, spec.Journal, true)
+
+	// An invalid spec changes nothing
+	err = d.Set(volid, nil, &api.VolumeSpec{
+		Size:    size,
+		HaLevel: 4,
+	})
+	assert.Error(t, err)
+	vols, err = d.Inspect([]string{volid})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(9876), vols[0].GetSpec().GetSize())
+	assert.Equal(t, uint64(9876), d.data.vols[volid].size)
+}
+
+func TestFakeReadWrite(t *testing.T) {
+	d, err := newFakeDriver(map[string]string{})
+	assert.NoError(t, err)
+
+	size := uint64(3 * blockSize)
+	volid, err := d.Create(&api.VolumeLocator{Name: "myvol"}, &api.Source{}, &api.VolumeSpec{
+		Size:    size,
+		HaLevel: 1,
+	})
+	assert.NoError(t, err)
+
+	// Unwritten data reads as zeros
+	buf := make([]byte, 100)
+	n, err := d.Read(volid, buf, uint64(len(buf)), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(buf)), n)
+	assert.Equal(t, make([]byte, len(buf)), buf)
+
+	// Write across a block boundary
+	data := []byte("hello world")
+	offset := int64(blockSize - 5)
+	n, err = d.Write(volid, data, uint64(len(data)), offset)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(data)), n)
+
+	buf = make([]byte, len(data))
+	n, err = d.Read(volid, buf, uint64(len(buf)), offset)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(data)), n)
+	assert.Equal(t, data, buf)
+	assert.NoError(t, d.Flush(volid))
+
+	// Write past the end of the volume
+	_, err = d.Write(volid, data, uint64(len(data)), int64(size)-2)
+	assert.Error(t, err)
+
+	// Read is truncated at the end of the volume
+	n, err = d.Read(volid, buf, uint64(len(buf)), int64(size)-2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	_, err = d.Read(volid, buf, uint64(len(buf)), int64(size))
+	assert.Equal(t, io.EOF, err)
+
+	// Deleted volumes have no data
+	assert.NoError(t, d.Delete(volid))
+	_, err = d.Read(volid, buf, uint64(len(buf)), 0)
+	assert.Error(t, err)
+}
+
+func TestFakeCloudBackupVerify(t *testing.T) {
+	d, err := newFakeDriver(map[string]string{})
+	assert.NoError(t, err)
+
+	_, backupId, req, vol := testInitForCloudBackups(t, d)
+	verify := func() *api.CloudBackupVerification {
+		resp, err := d.CloudBackupVerify(&api.CloudBackupVerifyRequest{
+			ID:             backupId,
+			CredentialUUID: req.CredentialUUID,
+			TestRestore:    true,
+		})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, resp.Name)
+
+		status, err := d.CloudBackupStatus(&api.CloudBackupStatusRequest{Name: resp.Name})
+		assert.NoError(t, err)
+		assert.Equal(t, api.CloudBackupVerifyOp, status.Statuses[resp.Name].OpType)
+
+		backups, err := d.CloudBackupEnumerate(&api.CloudBackupEnumerateRequest{
+			CloudBackupGenericRequest: api.CloudBackupGenericRequest{
+				CredentialUUID: req.CredentialUUID,
+			},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, backups.Backups, 1)
+		return backups.Backups[0].Verification
+	}
+
+	v := verify()
+	assert.NotNil(t, v)
+	assert.Equal(t, api.CloudBackupStatusDone, v.Status)
+	assert.True(t, v.TestRestore)
+	vols, err := d.Enumerate(&api.VolumeLocator{}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, vols, 1)
+	assert.Equal(t, vol.GetId(), vols[0].GetId())
+
+	// Truncate the image of the backup
+	b, err := d.bucketForCred(req.CredentialUUID)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Truncate(b.imagePath(backupId), 10))
+	v = verify()
+	assert.Equal(t, api.CloudBackupStatusFailed, v.Status)
+	assert.Len(t, v.Info, 1)
+
+	// Scrub schedule
+	err = d.CloudBackupScrubUpdate(&api.CloudBackupScrubUpdateRequest{
+		CredentialUUID: req.CredentialUUID,
+		Scrub:          &api.CloudBackupScrubPolicy{Schedule: "bad"},
+	})
+	assert.Error(t, err)
+	policy := &api.CloudBackupScrubPolicy{
+		Schedule:    "- freq: daily\n  hour: 1\n",
+		TestRestore: true,
+	}
+	err = d.CloudBackupScrubUpdate(&api.CloudBackupScrubUpdateRequest{
+		CredentialUUID: req.CredentialUUID,
+		Scrub:          policy,
+	})
+	assert.NoError(t, err)
+	inspect, err := d.CloudBackupScrubInspect(&api.CloudBackupScrubInspectRequest{
+		CredentialUUID: req.CredentialUUID,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, policy, inspect.Scrub)
+
+	err = d.CloudBackupScrubUpdate(&api.CloudBackupScrubUpdateRequest{
+		CredentialUUID: req.CredentialUUID,
+	})
+	assert.NoError(t, err)
+	inspect, err = d.CloudBackupScrubInspect(&api.CloudBackupScrubInspectRequest{
+		CredentialUUID: req.CredentialUUID,
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, inspect.Scrub)
+}
+
+func TestFakeCloudBackupVerifyChecksum(t *testing.T) {
+	d, err := newFakeDriver(map[string]string{})
+	assert.NoError(t, err)
+	defer d.Shutdown()
+
+	_, backupId, req, vol := testInitForCloudBackups(t, d)
+	verification := func() *api.CloudBackupVerification {
+		backups, err := d.CloudBackupEnumerate(&api.CloudBackupEnumerateRequest{
+			CloudBackupGenericRequest: api.CloudBackupGenericRequest{
+				CredentialUUID: req.CredentialUUID,
+			},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, backups.Backups, 1)
+		return backups.Backups[0].Verification
+	}
+	raised := func() []*api.Alert {
+		list, err := d.alerts.Enumerate(alerts.NewResourceIDFilter(vol.GetId(),
+			api.AlertTypeCloudBackupCorrupted, api.ResourceType_RESOURCE_TYPE_VOLUME))
+		assert.NoError(t, err)
+		active := make([]*api.Alert, 0)
+		for _, alert := range list {
+			if !alert.Cleared {
+				active = append(active, alert)
+			}
+		}
+		return active
+	}
+	corrupt := func(b byte) {
+		bucket, err := d.bucketForCred(req.CredentialUUID)
+		assert.NoError(t, err)
+		file, err := os.OpenFile(bucket.imagePath(backupId), os.O_WRONLY, 0)
+		assert.NoError(t, err)
+		_, err = file.WriteAt([]byte{b}, 0)
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+	}
+
+	// Schedule the scrubs of the credential
+	err = d.CloudBackupScrubUpdate(&api.CloudBackupScrubUpdateRequest{
+		CredentialUUID: req.CredentialUUID,
+		Scrub:          &api.CloudBackupScrubPolicy{Schedule: "- freq: daily\n  hour: 1\n"},
+	})
+	assert.NoError(t, err)
+	d.scrubLock.Lock()
+	assert.Len(t, d.scrubTasks[req.CredentialUUID], 1)
+	d.scrubLock.Unlock()
+
+	d.scrub(req.CredentialUUID)
+	v := verification()
+	assert.NotNil(t, v)
+	assert.Equal(t, api.CloudBackupStatusDone, v.Status)
+	assert.Empty(t, raised())
+
+	// A change of the image keeping its size is detected by its checksum
+	corrupt('x')
+	d.scrub(req.CredentialUUID)
+	v = verification()
+	assert.Equal(t, api.CloudBackupStatusFailed, v.Status)
+	assert.Equal(t, []string{"Image of the backup is corrupted: checksum mismatch"}, v.Info)
+	active := raised()
+	assert.Len(t, active, 1)
+	assert.Equal(t, backupId, active[0].UniqueTag)
+
+	// The alert is cleared once the backup is verified again
+	corrupt(0)
+	resp, err := d.CloudBackupVerify(&api.CloudBackupVerifyRequest{
+		ID:             backupId,
+		CredentialUUID: req.CredentialUUID,
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.Name)
+	assert.Equal(t, api.CloudBackupStatusDone, verification().Status)
+	assert.Empty(t, raised())
+
+	// Removing the scrub policy cancels the scrubs
+	err = d.CloudBackupScrubUpdate(&api.CloudBackupScrubUpdateRequest{
+		CredentialUUID: req.CredentialUUID,
+	})
+	assert.NoError(t, err)
+	d.scrubLock.Lock()
+	assert.Empty(t, d.scrubTasks[req.CredentialUUID])
+	d.scrubLock.Unlock()
+}
+
+func TestFakeSnapshotRestoreClone(t *testing.T) {
+	d, err := newFakeDriver(map[string]string{})
+	assert.NoError(t, err)
+
+	volid, err := d.Create(&api.VolumeLocator{Name: "myvol"}, &api.Source{}, &api.VolumeSpec{
+		Size:    2 * blockSize,
+		HaLevel: 1,
+	})
+	assert.NoError(t, err)
+
+	original := []byte("original")
+	_, err = d.Write(volid, original, uint64(len(original)), 0)
+	assert.NoError(t, err)
+
+	snapid, err := d.Snapshot(volid, true, &api.VolumeLocator{Name: "mysnap"}, false)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, snapid)
+	_, err = d.Snapshot("missing", true, &api.VolumeLocator{Name: "nosnap"}, false)
+	assert.Error(t, err)
+
+	// The snapshot is read only
+	vols, err := d.Inspect([]string{snapid})
+	assert.NoError(t, err)
+	assert.True(t, vols[0].GetReadonly())
+	_, err = d.Write(snapid, original, uint64(len(original)), 0)
+	assert.Error(t, err)
+
+	// Writes to the volume do not change the snapshot
+	changed := []byte("modified")
+	_, err = d.Write(volid, changed, uint64(len(changed)), 0)
+	assert.NoError(t, err)
+
+	buf := make([]byte, len(original))
+	_, err = d.Read(snapid, buf, uint64(len(buf)), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, original, buf)
+
+	// Clone of the snapshot has the snapshot data
+	cloneid, err := d.Create(&api.VolumeLocator{Name: "myclone"}, &api.Source{Parent: snapid}, &api.VolumeSpec{
+		Size:    2 * blockSize,
+		HaLevel: 1,
+	})
+	assert.NoError(t, err)
+	_, err = d.Read(cloneid, buf, uint64(len(buf)), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, original, buf)
+
+	// Restore the volume
+	assert.NoError(t, d.Restore(volid, snapid))
+	_, err = d.Read(volid, buf, uint64(len(buf)), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, original, buf)
+}
+
+func TestFakeCloudBackupRestoreData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fake-bucket")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	d, err := newFakeDriver(map[string]string{
+		BucketPathParam: dir,
+	})
+	assert.NoError(t, err)
+
+	volid, err := d.Create(&api.VolumeLocator{Name: "myvol"}, &api.Source{}, &api.VolumeSpec{
+		Size:    4 * blockSize,
+		HaLevel: 1,
+	})
+	assert.NoError(t, err)
+	data := []byte("data to backup")
+	offset := int64(2*blockSize + 7)
+	_, err = d.Write(volid, data, uint64(len(data)), offset)
+	assert.NoError(t, err)
+
+	credid, err := d.CredsCreate(map[string]string{
+		api.OptCredBucket: "mybucket",
+	})
+	assert.NoError(t, err)
+	resp, err := d.CloudBackupCreate(&api.CloudBackupCreateRequest{
+		VolumeID:       volid,
+		CredentialUUID: credid,
+	})
+	assert.NoError(t, err)
+
+	statuses, err := d.CloudBackupStatus(&api.CloudBackupStatusRequest{Name: resp.Name})
+	assert.NoError(t, err)
+	backupID := statuses.Statuses[resp.Name].ID
+	assert.NotEmpty(t, backupID)
+	_, err = os.Stat(filepath.Join(dir, "mybucket", backupID+backupImageSuffix))
+	assert.NoError(t, err)
+
+	// Changes after the backup are not restored
+	_, err = d.Write(volid, []byte("later"), 5, 0)
+	assert.NoError(t, err)
+
+	restore, err := d.CloudBackupRestore(&api.CloudBackupRestoreRequest{
+		ID:                backupID,
+		CredentialUUID:    credid,
+		RestoreVolumeName: "restored",
+	})
+	assert.NoError(t, err)
+
+	buf := make([]byte, len(data))
+	_, err = d.Read(restore.RestoreVolumeID, buf, uint64(len(buf)), offset)
+	assert.NoError(t, err)
+	assert.Equal(t, data, buf)
+	buf = make([]byte, 5)
+	_, err = d.Read(restore.RestoreVolumeID, buf, uint64(len(buf)), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, make([]byte, 5), buf)
+
+	// Delete removes the data from the bucket
+	err = d.CloudBackupDelete(&api.CloudBackupDeleteRequest{
+		ID:             backupID,
+		CredentialUUID: credid,
+	})
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "mybucket", backupID+backupImageSuffix))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func testPairFakeCluster(t *testing.T, clusterID string) *driver {
+	token := "token-" + clusterID
+	remote, err := newFakeDriver(map[string]string{
+		PairTokenParam: token,
+	})
+	assert.NoError(t, err)
+
+	_, err = kvdb.Instance().Put(clustermanager.ClusterPairKey+"/"+clusterID, &api.ClusterPairInfo{
+		Id:    clusterID,
+		Name:  clusterID,
+		Token: token,
+	}, 0)
+	assert.NoError(t, err)
+	return remote
+}
+
+func testWaitForMigration(t *testing.T, d *driver, taskID string) []*api.CloudMigrateInfo {
+	for i := 0; i < 100; i++ {
+		resp, err := d.CloudMigrateStatus(&api.CloudMigrateStatusRequest{TaskId: taskID})
+		assert.NoError(t, err)
+		done := true
+		var infos []*api.CloudMigrateInfo
+		for _, list := range resp.GetInfo() {
+			for _, info := range list.GetList() {
+				infos = append(infos, info)
+				if info.GetStatus() == api.CloudMigrate_Queued ||
+					info.GetStatus() == api.CloudMigrate_InProgress {
+					done = false
+				}
+			}
+		}
+		if done {
+			return infos
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("Migration %s did not finish", taskID)
+	return nil
+}
+
+func TestFakeCloudMigrate(t *testing.T) {
+	d, err := newFakeDriver(map[string]string{})
+	assert.NoError(t, err)
+	remote := testPairFakeCluster(t, "migrate-remote")
+	defer remote.Shutdown()
+
+	volid, err := d.Create(&api.VolumeLocator{
+		Name:         "migratevol",
+		VolumeLabels: map[string]string{"app": "db"},
+	}, &api.Source{}, &api.VolumeSpec{
+		Size:    2 * blockSize,
+		HaLevel: 1,
+	})
+	assert.NoError(t, err)
+	data := []byte("migrated data")
+	_, err = d.Write(volid, data, uint64(len(data)), blockSize)
+	assert.NoError(t, err)
+
+	// Cluster must be paired
+	_, err = d.CloudMigrateStart(&api.CloudMigrateStartRequest{
+		Operation: api.CloudMigrate_MigrateVolume,
+		ClusterId: "notpaired",
+		TargetId:  volid,
+	})
+	assert.Error(t, err)
+
+	resp, err := d.CloudMigrateStart(&api.CloudMigrateStartRequest{
+		Operation: api.CloudMigrate_MigrateVolume,
+		ClusterId: "migrate-remote",
+		TargetId:  volid,
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.GetTaskId())
+
+	infos := testWaitForMigration(t, d, resp.GetTaskId())
+	assert.Len(t, infos, 1)
+	info := infos[0]
+	assert.Equal(t, api.CloudMigrate_Complete, info.GetStatus())
+	assert.Equal(t, api.CloudMigrate_Done, info.GetCurrentStage())
+	assert.Equal(t, volid, info.GetLocalVolumeId())
+	assert.Equal(t, "migrate-remote", info.GetClusterId())
+	assert.Equal(t, uint64(blockSize), info.GetBytesTotal())
+	assert.Equal(t, info.GetBytesTotal(), info.GetBytesDone())
+
+	// Volume and data are in the remote cluster
+	vols, err := remote.Inspect([]string{info.GetRemoteVolumeId()})
+	assert.NoError(t, err)
+	assert.Equal(t, "migratevol", vols[0].GetLocator().GetName())
+	assert.Equal(t, "db", vols[0].GetLocator().GetVolumeLabels()["app"])
+	buf := make([]byte, len(data))
+	_, err = remote.Read(info.GetRemoteVolumeId(), buf, uint64(len(buf)), blockSize)
+	assert.NoError(t, err)
+	assert.Equal(t, data, buf)
+
+	// Nothing left to cancel
+	err = d.CloudMigrateCancel(&api.CloudMigrateCancelRequest{TaskId: resp.GetTaskId()})
+	assert.Error(t, err)
+}
+
+func TestFakeCloudMigrateCancel(t *testing.T) {
+	d, err := newFakeDriver(map[string]string{})
+	assert.NoError(t, err)
+	remote := testPairFakeCluster(t, "cancel-remote")
+	defer remote.Shutdown()
+
+	migrateBlockDelay = 100 * time.Millisecond
+	defer func() { migrateBlockDelay = 0 }()
+
+	volid, err := d.Create(&api.VolumeLocator{Name: "cancelvol"}, &api.Source{}, &api.VolumeSpec{
+		Size:    8 * blockSize,
+		HaLevel: 1,
+	})
+	assert.NoError(t, err)
+	for i := int64(0); i < 8; i++ {
+		_, err = d.Write(volid, []byte("x"), 1, i*blockSize)
+		assert.NoError(t, err)
+	}
+
+	resp, err := d.CloudMigrateStart(&api.CloudMigrateStartRequest{
+		Operation: api.CloudMigrate_MigrateCluster,
+		ClusterId: "cancel-remote",
+		TaskId:    "canceltask",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "canceltask", resp.GetTaskId())
+
+	err = d.CloudMigrateCancel(&api.CloudMigrateCancelRequest{TaskId: "canceltask"})
+	assert.NoError(t, err)
+
+	infos := testWaitForMigration(t, d, "canceltask")
+	assert.Len(t, infos, 1)
+	assert.Equal(t, api.CloudMigrate_Canceled, infos[0].GetStatus())
+	assert.True(t, infos[0].GetBytesDone() < infos[0].GetBytesTotal())
+
+	vols, err := remote.Enumerate(&api.VolumeLocator{}, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, vols)
+}
+
+func TestFakeCloudMigrateChaos(t *testing.T) {
+	d, err := newFakeDriver(map[string]string{})
+	assert.NoError(t, err)
+	remote := testPairFakeCluster(t, "chaos-remote")
+	defer remote.Shutdown()
+
+	volid, err := d.Create(&api.VolumeLocator{Name: "chaosvol"}, &api.Source{}, &api.VolumeSpec{
+		Size:    blockSize,
+		HaLevel: 1,
+	})
+	assert.NoError(t, err)
+
+	chaos.Activate(true)
+	defer chaos.Activate(false)
+	assert.NoError(t, chaos.Enable(koMigrateVolumeUpdate, chaos.Once, chaos.Error))
+	defer chaos.Disable(koMigrateVolumeUpdate)
+
+	resp, err := d.CloudMigrateStart(&api.CloudMigrateStartRequest{
+		Operation: api.CloudMigrate_MigrateVolume,
+		ClusterId: "chaos-remote",
+		TargetId:  volid,
+	})
+	assert.NoError(t, err)
+
+	infos := testWaitForMigration(t, d, resp.GetTaskId())
+	assert.Len(t, infos, 1)
+	assert.Equal(t, api.CloudMigrate_Failed, infos[0].GetStatus())
+	assert.Equal(t, api.CloudMigrate_VolumeUpdate, infos[0].GetCurrentStage())
+	assert.Equal(t, chaos.ErrChaos.Error(), infos[0].GetErrorReason())
+
+	// Partially migrated volume is removed
+	vols, err := remote.Enumerate(&api.VolumeLocator{}, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, vols)
 }
@@ -0,0 +1,375 @@
+/*
+Package fake provides an in-memory fake driver implementation
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/chaos"
+	"github.com/libopenstorage/openstorage/pkg/proto/time"
+	"github.com/pborman/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// PairTokenParam is the driver parameter used to set the cluster pair
+	// token with which other fake drivers in the same process can reach this
+	// driver as the destination of a migration.
+	PairTokenParam = "pair_token"
+
+	migrateKeyPrefix = "/fake/migrations"
+)
+
+var (
+	// pairedDrivers are the fake drivers in this process which can be the
+	// destination of a migration, indexed by pair token.
+	pairedDrivers = struct {
+		sync.Mutex
+		drivers map[string]*driver
+	}{
+		drivers: make(map[string]*driver),
+	}
+
+	// migrateBlockDelay slows down the transfer of each block of data
+	// so that migrations can be observed while in progress.
+	migrateBlockDelay time.Duration
+
+	koMigrateBackup = chaos.Add("fake", "migrate-backup",
+		"transfer of the volume data to the remote cluster")
+	koMigrateRestore = chaos.Add("fake", "migrate-restore",
+		"creation of the volume in the remote cluster")
+	koMigrateVolumeUpdate = chaos.Add("fake", "migrate-volume-update",
+		"update of the volume in the remote cluster")
+)
+
+// migrateTask tracks a migration while it is running
+type migrateTask struct {
+	lock     sync.Mutex
+	canceled bool
+}
+
+func (t *migrateTask) cancel() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.canceled = true
+}
+
+func (t *migrateTask) isCanceled() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.canceled
+}
+
+func registerPairedDriver(token string, d *driver) {
+	pairedDrivers.Lock()
+	defer pairedDrivers.Unlock()
+	pairedDrivers.drivers[token] = d
+}
+
+func unregisterPairedDriver(token string) {
+	pairedDrivers.Lock()
+	defer pairedDrivers.Unlock()
+	delete(pairedDrivers.drivers, token)
+}
+
+// remoteDriver returns the fake driver of the paired cluster
+func (d *driver) remoteDriver(clusterID string) (*driver, error) {
+	pair, err := d.thisCluster.GetPair(clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("Cluster %s is not paired: %v", clusterID, err)
+	}
+
+	pairedDrivers.Lock()
+	defer pairedDrivers.Unlock()
+	remote, ok := pairedDrivers.drivers[pair.GetPairInfo().GetToken()]
+	if !ok {
+		return nil, fmt.Errorf("Cluster %s is not reachable", clusterID)
+	}
+	return remote, nil
+}
+
+func migrateKey(taskID, volumeID string) string {
+	return migrateKeyPrefix + "/" + taskID + "/" + volumeID
+}
+
+// CloudMigrateStart starts migrating volumes to a paired fake cluster
+func (d *driver) CloudMigrateStart(request *api.CloudMigrateStartRequest) (*api.CloudMigrateStartResponse, error) {
+	if len(request.GetClusterId()) == 0 {
+		return nil, fmt.Errorf("Cluster id must be provided")
+	}
+	remote, err := d.remoteDriver(request.GetClusterId())
+	if err != nil {
+		return nil, err
+	}
+
+	var vols []*api.Volume
+	switch request.GetOperation() {
+	case api.CloudMigrate_MigrateVolume:
+		vols, err = d.Inspect([]string{request.GetTargetId()})
+		if err != nil {
+			return nil, fmt.Errorf("Volume id %s not found", request.GetTargetId())
+		}
+	case api.CloudMigrate_MigrateVolumeGroup:
+		all, err := d.Enumerate(&api.VolumeLocator{}, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range all {
+			if v.GetSpec().GetGroup().GetId() == request.GetTargetId() {
+				vols = append(vols, v)
+			}
+		}
+		if len(vols) == 0 {
+			return nil, fmt.Errorf("No volumes found in group %s", request.GetTargetId())
+		}
+	case api.CloudMigrate_MigrateCluster:
+		vols, err = d.Enumerate(&api.VolumeLocator{}, nil)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Invalid migrate operation %v", request.GetOperation())
+	}
+
+	taskID := request.GetTaskId()
+	if len(taskID) == 0 {
+		taskID = uuid.New()
+	} else {
+		// Starting the same task again is a no-op
+		status, err := d.CloudMigrateStatus(&api.CloudMigrateStatusRequest{
+			TaskId: taskID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(status.GetInfo()) != 0 {
+			return &api.CloudMigrateStartResponse{TaskId: taskID}, nil
+		}
+	}
+
+	infos := make([]*api.CloudMigrateInfo, 0, len(vols))
+	for _, v := range vols {
+		used, _, err := d.data.usage(v.GetId())
+		if err != nil {
+			return nil, err
+		}
+		info := &api.CloudMigrateInfo{
+			TaskId:          taskID,
+			ClusterId:       request.GetClusterId(),
+			LocalVolumeId:   v.GetId(),
+			LocalVolumeName: v.GetLocator().GetName(),
+			CloudbackupId:   uuid.New(),
+			CurrentStage:    api.CloudMigrate_Backup,
+			Status:          api.CloudMigrate_Queued,
+			LastUpdate:      prototime.Now(),
+			BytesTotal:      used,
+		}
+		if _, err := d.kv.Put(migrateKey(taskID, v.GetId()), info, 0); err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+
+	task := &migrateTask{}
+	d.migrateLock.Lock()
+	d.migrations[taskID] = task
+	d.migrateLock.Unlock()
+
+	go d.migrate(taskID, task, remote, infos)
+
+	return &api.CloudMigrateStartResponse{TaskId: taskID}, nil
+}
+
+// migrate migrates the volumes one at a time through all the stages
+func (d *driver) migrate(
+	taskID string,
+	task *migrateTask,
+	remote *driver,
+	infos []*api.CloudMigrateInfo,
+) {
+	for _, info := range infos {
+		if task.isCanceled() {
+			d.migrateEnd(info, api.CloudMigrate_Canceled, "")
+			continue
+		}
+		if err := d.migrateVolume(task, remote, info); err != nil {
+			if task.isCanceled() {
+				d.migrateEnd(info, api.CloudMigrate_Canceled, "")
+			} else {
+				logrus.Warnf("Migration of volume %s failed: %v", info.GetLocalVolumeId(), err)
+				d.migrateEnd(info, api.CloudMigrate_Failed, err.Error())
+			}
+			if len(info.GetRemoteVolumeId()) != 0 {
+				remote.Delete(info.GetRemoteVolumeId())
+			}
+			continue
+		}
+		d.migrateEnd(info, api.CloudMigrate_Complete, "")
+	}
+
+	d.migrateLock.Lock()
+	delete(d.migrations, taskID)
+	d.migrateLock.Unlock()
+}
+
+func (d *driver) migrateVolume(task *migrateTask, remote *driver, info *api.CloudMigrateInfo) error {
+	info.StartTime = prototime.Now()
+
+	// Backup the data of the volume into a staging area
+	d.migrateUpdate(info, api.CloudMigrate_Backup, api.CloudMigrate_InProgress)
+	if err := chaos.Now(koMigrateBackup); err != nil {
+		return err
+	}
+	vols, err := d.Inspect([]string{info.GetLocalVolumeId()})
+	if err != nil {
+		return err
+	}
+	vol := vols[0]
+	staging := newDataStore()
+	if err := staging.create(vol.GetId(), vol.GetSpec().GetSize()); err != nil {
+		return err
+	}
+	_, err = d.data.export(vol.GetId(), func(offset int64, data []byte) error {
+		if task.isCanceled() {
+			return fmt.Errorf("Migration canceled")
+		}
+		if _, err := staging.write(vol.GetId(), data, offset); err != nil {
+			return err
+		}
+		info.BytesDone += uint64(len(data))
+		d.migrateUpdate(info, api.CloudMigrate_Backup, api.CloudMigrate_InProgress)
+		time.Sleep(migrateBlockDelay)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Restore the data into a new volume in the remote cluster
+	d.migrateUpdate(info, api.CloudMigrate_Restore, api.CloudMigrate_InProgress)
+	if err := chaos.Now(koMigrateRestore); err != nil {
+		return err
+	}
+	locator := &api.VolumeLocator{
+		Name:         vol.GetLocator().GetName(),
+		VolumeLabels: make(map[string]string),
+	}
+	remoteID, err := remote.Create(locator, &api.Source{}, vol.GetSpec())
+	if err != nil {
+		return err
+	}
+	info.RemoteVolumeId = remoteID
+	d.migrateUpdate(info, api.CloudMigrate_Restore, api.CloudMigrate_InProgress)
+	_, err = staging.export(vol.GetId(), func(offset int64, data []byte) error {
+		if task.isCanceled() {
+			return fmt.Errorf("Migration canceled")
+		}
+		_, err := remote.data.write(remoteID, data, offset)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Copy the labels of the volume
+	d.migrateUpdate(info, api.CloudMigrate_VolumeUpdate, api.CloudMigrate_InProgress)
+	if err := chaos.Now(koMigrateVolumeUpdate); err != nil {
+		return err
+	}
+	if task.isCanceled() {
+		return fmt.Errorf("Migration canceled")
+	}
+	return remote.Set(remoteID, &api.VolumeLocator{
+		VolumeLabels: vol.GetLocator().GetVolumeLabels(),
+	}, nil)
+}
+
+func (d *driver) migrateUpdate(
+	info *api.CloudMigrateInfo,
+	stage api.CloudMigrate_Stage,
+	status api.CloudMigrate_Status,
+) {
+	info.CurrentStage = stage
+	info.Status = status
+	info.LastUpdate = prototime.Now()
+	if _, err := d.kv.Put(migrateKey(info.GetTaskId(), info.GetLocalVolumeId()), info, 0); err != nil {
+		logrus.Warnf("Failed to update migration status of volume %s: %v",
+			info.GetLocalVolumeId(), err)
+	}
+}
+
+func (d *driver) migrateEnd(info *api.CloudMigrateInfo, status api.CloudMigrate_Status, reason string) {
+	info.ErrorReason = reason
+	info.CompletedTime = prototime.Now()
+	stage := info.GetCurrentStage()
+	if status == api.CloudMigrate_Complete {
+		stage = api.CloudMigrate_Done
+	}
+	d.migrateUpdate(info, stage, status)
+}
+
+// CloudMigrateCancel cancels a running migration
+func (d *driver) CloudMigrateCancel(request *api.CloudMigrateCancelRequest) error {
+	if len(request.GetTaskId()) == 0 {
+		return fmt.Errorf("Task id must be provided")
+	}
+
+	d.migrateLock.Lock()
+	task, ok := d.migrations[request.GetTaskId()]
+	d.migrateLock.Unlock()
+	if !ok {
+		return fmt.Errorf("No running migration found for task %s", request.GetTaskId())
+	}
+	task.cancel()
+	return nil
+}
+
+// CloudMigrateStatus returns the status of the volumes being migrated
+// indexed by the id of the cluster they are being migrated to
+func (d *driver) CloudMigrateStatus(request *api.CloudMigrateStatusRequest) (*api.CloudMigrateStatusResponse, error) {
+	kvp, err := d.kv.Enumerate(migrateKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &api.CloudMigrateStatusResponse{
+		Info: make(map[string]*api.CloudMigrateInfoList),
+	}
+	for _, v := range kvp {
+		info := &api.CloudMigrateInfo{}
+		if err := json.Unmarshal(v.Value, info); err != nil {
+			return nil, err
+		}
+		if len(request.GetTaskId()) != 0 && request.GetTaskId() != info.GetTaskId() {
+			continue
+		}
+		if len(request.GetClusterId()) != 0 && request.GetClusterId() != info.GetClusterId() {
+			continue
+		}
+		list, ok := resp.Info[info.GetClusterId()]
+		if !ok {
+			list = &api.CloudMigrateInfoList{}
+			resp.Info[info.GetClusterId()] = list
+		}
+		list.List = append(list.List, info)
+	}
+	return resp, nil
+}
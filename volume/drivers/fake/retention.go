@@ -0,0 +1,248 @@
+/*
+Package fake provides an in-memory fake driver implementation
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/volume/drivers/common"
+	"github.com/pborman/uuid"
+	"github.com/portworx/kvdb"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	retentionKeyPrefix = "/fake/retention"
+)
+
+var (
+	// retentionInterval is how often the retention policies are enforced
+	retentionInterval = time.Hour
+)
+
+// CloudBackupRetentionUpdate sets the retention policy of the backups stored
+// with a credential and applies it
+func (d *driver) CloudBackupRetentionUpdate(input *api.CloudBackupRetentionUpdateRequest) error {
+	if err := d.CredsValidate(input.CredentialUUID); err != nil {
+		return err
+	}
+
+	key := retentionKeyPrefix + "/" + input.CredentialUUID
+	if input.Retention == nil {
+		if _, err := d.kv.Delete(key); err != nil && err != kvdb.ErrNotFound {
+			return err
+		}
+		return nil
+	}
+	if _, err := d.kv.Put(key, input.Retention, 0); err != nil {
+		return err
+	}
+	return d.applyRetention(input.CredentialUUID)
+}
+
+// CloudBackupRetentionInspect returns the retention policy of the backups
+// stored with a credential
+func (d *driver) CloudBackupRetentionInspect(
+	input *api.CloudBackupRetentionInspectRequest,
+) (*api.CloudBackupRetentionInspectResponse, error) {
+	if err := d.CredsValidate(input.CredentialUUID); err != nil {
+		return nil, err
+	}
+
+	policy, err := d.retention(input.CredentialUUID)
+	if err != nil {
+		return nil, err
+	}
+	return &api.CloudBackupRetentionInspectResponse{
+		Retention: policy,
+	}, nil
+}
+
+func (d *driver) retention(credUUID string) (*api.CloudBackupRetentionPolicy, error) {
+	policy := &api.CloudBackupRetentionPolicy{}
+	_, err := d.kv.GetVal(retentionKeyPrefix+"/"+credUUID, policy)
+	if err == kvdb.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// scheduleRetentions returns the retention policies of the schedules backing
+// up volumes with a credential, by volume id. The MaxBackups of a schedule
+// without a retention policy keep the most recent backups.
+func (d *driver) scheduleRetentions(credUUID string) (map[string]*api.CloudBackupRetentionPolicy, error) {
+	schedules, err := d.CloudBackupSchedEnumerate()
+	if err != nil {
+		return nil, err
+	}
+	policies := make(map[string]*api.CloudBackupRetentionPolicy)
+	for _, sched := range schedules.Schedules {
+		if sched.CredentialUUID != credUUID || len(sched.SrcVolumeID) == 0 {
+			continue
+		}
+		if sched.Retention != nil {
+			policies[sched.SrcVolumeID] = sched.Retention
+		} else if sched.MaxBackups != 0 {
+			policies[sched.SrcVolumeID] = &api.CloudBackupRetentionPolicy{
+				KeepLast: sched.MaxBackups,
+			}
+		}
+	}
+	return policies, nil
+}
+
+// checkHold returns an error if the backup is held by the retention policy
+// of the credential or of the schedule of the volume
+func (d *driver) checkHold(credUUID string, backup *api.CloudBackupInfo) error {
+	policy, err := d.retention(credUUID)
+	if err != nil {
+		return err
+	}
+	schedPolicies, err := d.scheduleRetentions(credUUID)
+	if err != nil {
+		return err
+	}
+	if common.CloudBackupOnHold(policy, backup) ||
+		common.CloudBackupOnHold(schedPolicies[backup.SrcVolumeID], backup) {
+		return fmt.Errorf("Backup %s is on legal hold", backup.ID)
+	}
+	return nil
+}
+
+// applyRetention deletes the backups of a credential which are no longer
+// retained by the policy of their schedule, or else by the policy of the
+// credential. The deletions are saved to be reported by CloudBackupHistory.
+func (d *driver) applyRetention(credUUID string) error {
+	d.retentionLock.Lock()
+	defer d.retentionLock.Unlock()
+
+	credPolicy, err := d.retention(credUUID)
+	if err != nil {
+		return err
+	}
+	schedPolicies, err := d.scheduleRetentions(credUUID)
+	if err != nil {
+		return err
+	}
+
+	kvp, err := d.kv.Enumerate(backupsKeyPrefix)
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]string)
+	backups := make(map[*api.CloudBackupRetentionPolicy][]api.CloudBackupInfo)
+	for _, v := range kvp {
+		elem := &fakeBackups{}
+		if err := json.Unmarshal(v.Value, elem); err != nil {
+			return err
+		}
+		if elem.Status.OpType != api.CloudBackupOp ||
+			elem.Status.CredentialUUID != credUUID {
+			continue
+		}
+		policy := credPolicy
+		if p, ok := schedPolicies[elem.Info.SrcVolumeID]; ok {
+			policy = p
+		}
+		if policy == nil {
+			continue
+		}
+		keys[elem.Info.ID] = v.Key
+		backups[policy] = append(backups[policy], elem.Info)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	b, err := d.bucketForCred(credUUID)
+	if err != nil {
+		return err
+	}
+	clusterInfo, err := d.thisCluster.Enumerate()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for policy, infos := range backups {
+		for _, info := range common.CloudBackupExpired(policy, infos, now) {
+			if common.CloudBackupOnHold(credPolicy, &info) {
+				continue
+			}
+			if err := b.remove(info.ID); err != nil {
+				return err
+			}
+			if _, err := d.kv.Delete(keys[info.ID]); err != nil {
+				return err
+			}
+			_, err = d.kv.Put(backupsKeyPrefix+"/"+uuid.New(), &fakeBackups{
+				ClusterId: clusterInfo.Id,
+				Status: api.CloudBackupStatus{
+					ID:             info.ID,
+					OpType:         api.CloudBackupDeleteOp,
+					Status:         api.CloudBackupStatusDone,
+					StartTime:      now,
+					CompletedTime:  time.Now(),
+					NodeID:         clusterInfo.NodeId,
+					CredentialUUID: credUUID,
+					SrcVolumeID:    info.SrcVolumeID,
+					Info:           []string{"Backup is not retained by the retention policy"},
+				},
+				Info: info,
+			}, 0)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reapBackups periodically enforces the retention policies of all the
+// credentials until the driver is shut down, so that backups expire
+// without new backups being created.
+func (d *driver) reapBackups() {
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.enforceRetention()
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+// enforceRetention applies the retention policies of all the credentials
+func (d *driver) enforceRetention() {
+	creds, err := d.CredsEnumerate()
+	if err != nil {
+		logrus.Warnf("Failed to enumerate the credentials: %v", err)
+		return
+	}
+	for credUUID := range creds {
+		if err := d.applyRetention(credUUID); err != nil {
+			logrus.Warnf("Failed to apply the retention policy of credential %s: %v",
+				credUUID, err)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+/*
+Package fake provides an in-memory fake driver implementation
+Copyright 2018 Portworx
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/sched"
+	"github.com/libopenstorage/openstorage/volume/drivers/common"
+	"github.com/pborman/uuid"
+	"github.com/portworx/kvdb"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	scrubKeyPrefix = "/fake/scrub"
+)
+
+// CloudBackupVerify checks that the image of a backup is present, has the
+// size of the volume and the checksum recorded when it was uploaded,
+// optionally restoring it to a temporary volume. The result is saved with
+// the backup and an alert is raised on the source volume if the backup is
+// corrupted.
+func (d *driver) CloudBackupVerify(
+	input *api.CloudBackupVerifyRequest,
+) (*api.CloudBackupVerifyResponse, error) {
+	if err := d.CredsValidate(input.CredentialUUID); err != nil {
+		return nil, err
+	}
+	backup, key, err := d.backupEntry(input.ID, api.CloudBackupOp)
+	if err != nil {
+		return nil, err
+	}
+	b, err := d.bucketForCred(input.CredentialUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	verification, err := d.verify(b, backup, key, input.TestRestore)
+	if err != nil {
+		return nil, err
+	}
+
+	taskId := input.Name
+	if len(taskId) == 0 {
+		taskId = uuid.New()
+	}
+	clusterInfo, err := d.thisCluster.Enumerate()
+	if err != nil {
+		return nil, err
+	}
+	_, err = d.kv.Put(backupsKeyPrefix+"/"+taskId, &fakeBackups{
+		ClusterId: clusterInfo.Id,
+		Status: api.CloudBackupStatus{
+			ID:             input.ID,
+			OpType:         api.CloudBackupVerifyOp,
+			Status:         verification.Status,
+			BytesTotal:     backup.Volume.GetSpec().GetSize(),
+			StartTime:      start,
+			CompletedTime:  time.Now(),
+			NodeID:         clusterInfo.NodeId,
+			CredentialUUID: input.CredentialUUID,
+			SrcVolumeID:    backup.Info.SrcVolumeID,
+			Info:           verification.Info,
+		},
+		Info: backup.Info,
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &api.CloudBackupVerifyResponse{Name: taskId}, nil
+}
+
+// verify checks the image of a backup, saves the result with the backup
+// stored under key and raises or clears its corruption alert
+func (d *driver) verify(
+	b *bucket,
+	backup *fakeBackups,
+	key string,
+	testRestore bool,
+) (*api.CloudBackupVerification, error) {
+	problems := make([]string, 0)
+	size := backup.Volume.GetSpec().GetSize()
+	if info, err := os.Stat(b.imagePath(backup.Info.ID)); os.IsNotExist(err) {
+		problems = append(problems, "Image of the backup is missing")
+	} else if err != nil {
+		return nil, err
+	} else if uint64(info.Size()) != size {
+		problems = append(problems, fmt.Sprintf("Image of the backup has %d bytes instead of %d",
+			info.Size(), size))
+	} else if len(backup.Checksum) != 0 {
+		// Backups made before the checksums were recorded are only checked
+		// by size
+		checksum, err := b.checksum(backup.Info.ID)
+		if err != nil {
+			return nil, err
+		}
+		if checksum != backup.Checksum {
+			problems = append(problems, "Image of the backup is corrupted: checksum mismatch")
+		}
+	}
+	if len(problems) == 0 && testRestore {
+		if err := d.testRestore(b, backup); err != nil {
+			problems = append(problems, fmt.Sprintf("Test restore failed: %v", err))
+		}
+	}
+
+	verification := &api.CloudBackupVerification{
+		Timestamp:   time.Now(),
+		Status:      api.CloudBackupStatusDone,
+		TestRestore: testRestore,
+		Info:        problems,
+	}
+	if len(problems) != 0 {
+		verification.Status = api.CloudBackupStatusFailed
+	}
+	backup.Info.Verification = verification
+	if _, err := d.kv.Put(key, backup, 0); err != nil {
+		return nil, err
+	}
+	if d.alerts != nil {
+		common.RaiseCloudBackupVerifyAlert(d.alerts, backup.Info.ID, backup.Info.SrcVolumeID,
+			verification)
+	}
+	return verification, nil
+}
+
+// testRestore downloads a backup into a temporary volume which is deleted
+// once done
+func (d *driver) testRestore(b *bucket, backup *fakeBackups) error {
+	volid, err := d.Create(&api.VolumeLocator{Name: "verify-" + backup.Info.ID},
+		&api.Source{}, backup.Volume.GetSpec())
+	if err != nil {
+		return err
+	}
+	defer d.Delete(volid)
+	_, err = b.download(d.data, backup.Info.ID, volid)
+	return err
+}
+
+// CloudBackupScrubUpdate sets the schedule at which the backups stored with a
+// credential are verified.
+func (d *driver) CloudBackupScrubUpdate(input *api.CloudBackupScrubUpdateRequest) error {
+	if err := d.CredsValidate(input.CredentialUUID); err != nil {
+		return err
+	}
+
+	key := scrubKeyPrefix + "/" + input.CredentialUUID
+	if input.Scrub == nil {
+		if _, err := d.kv.Delete(key); err != nil && err != kvdb.ErrNotFound {
+			return err
+		}
+		return d.scheduleScrub(input.CredentialUUID, nil)
+	}
+	if err := d.scheduleScrub(input.CredentialUUID, input.Scrub); err != nil {
+		return err
+	}
+	_, err := d.kv.Put(key, input.Scrub, 0)
+	return err
+}
+
+// CloudBackupScrubInspect returns the scrub schedule of a credential
+func (d *driver) CloudBackupScrubInspect(
+	input *api.CloudBackupScrubInspectRequest,
+) (*api.CloudBackupScrubInspectResponse, error) {
+	if err := d.CredsValidate(input.CredentialUUID); err != nil {
+		return nil, err
+	}
+
+	policy, err := d.scrubPolicy(input.CredentialUUID)
+	if err != nil {
+		return nil, err
+	}
+	return &api.CloudBackupScrubInspectResponse{Scrub: policy}, nil
+}
+
+func (d *driver) scrubPolicy(credUUID string) (*api.CloudBackupScrubPolicy, error) {
+	policy := &api.CloudBackupScrubPolicy{}
+	_, err := d.kv.GetVal(scrubKeyPrefix+"/"+credUUID, policy)
+	if err == kvdb.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// scheduleScrub replaces the scheduled scrubs of a credential
+func (d *driver) scheduleScrub(credUUID string, policy *api.CloudBackupScrubPolicy) error {
+	var intervals []sched.RetainInterval
+	if policy != nil {
+		var err error
+		if intervals, err = sched.ParseSchedule(policy.Schedule); err != nil {
+			return fmt.Errorf("Invalid scrub schedule: %v", err)
+		}
+		if len(intervals) == 0 {
+			return fmt.Errorf("Scrub schedule must be provided")
+		}
+	}
+
+	d.scrubLock.Lock()
+	defer d.scrubLock.Unlock()
+	if d.scrubber == nil {
+		if len(intervals) == 0 {
+			return nil
+		}
+		d.scrubber = sched.New(time.Minute)
+	}
+	for _, id := range d.scrubTasks[credUUID] {
+		d.scrubber.Cancel(id)
+	}
+	delete(d.scrubTasks, credUUID)
+
+	for _, interval := range intervals {
+		id, err := d.scrubber.Schedule(func(sched.Interval) {
+			d.scrub(credUUID)
+		}, interval, time.Now(), false)
+		if err != nil {
+			return err
+		}
+		d.scrubTasks[credUUID] = append(d.scrubTasks[credUUID], id)
+	}
+	return nil
+}
+
+// scrub verifies the backups of this cluster stored with a credential
+func (d *driver) scrub(credUUID string) {
+	policy, err := d.scrubPolicy(credUUID)
+	if err != nil || policy == nil {
+		return
+	}
+	b, err := d.bucketForCred(credUUID)
+	if err != nil {
+		logrus.Warnf("Failed to scrub the backups of credential %s: %v", credUUID, err)
+		return
+	}
+	clusterInfo, err := d.thisCluster.Enumerate()
+	if err != nil {
+		logrus.Warnf("Failed to scrub the backups of credential %s: %v", credUUID, err)
+		return
+	}
+	kvp, err := d.kv.Enumerate(backupsKeyPrefix)
+	if err != nil {
+		logrus.Warnf("Failed to scrub the backups of credential %s: %v", credUUID, err)
+		return
+	}
+
+	scrubbed, corrupted := 0, 0
+	for _, v := range kvp {
+		backup := &fakeBackups{}
+		if err := json.Unmarshal(v.Value, backup); err != nil {
+			continue
+		}
+		if backup.Status.OpType != api.CloudBackupOp ||
+			backup.Status.CredentialUUID != credUUID ||
+			backup.ClusterId != clusterInfo.Id {
+			continue
+		}
+		verification, err := d.verify(b, backup, v.Key, policy.TestRestore)
+		if err != nil {
+			logrus.Warnf("Scrub of cloud backup %s failed: %v", backup.Info.ID, err)
+			corrupted++
+		} else if verification.Status == api.CloudBackupStatusFailed {
+			logrus.Warnf("Scrub of cloud backup %s failed: %s", backup.Info.ID, verification.Info[0])
+			corrupted++
+		}
+		scrubbed++
+	}
+	logrus.Infof("Scrubbed %d cloud backups of credential %s, %d failed",
+		scrubbed, credUUID, corrupted)
+}
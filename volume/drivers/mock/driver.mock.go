@@ -187,6 +187,31 @@ func (mr *MockVolumeDriverMockRecorder) CloudBackupRestore(arg0 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudBackupRestore", reflect.TypeOf((*MockVolumeDriver)(nil).CloudBackupRestore), arg0)
 }
 
+// CloudBackupRetentionInspect mocks base method
+func (m *MockVolumeDriver) CloudBackupRetentionInspect(arg0 *api.CloudBackupRetentionInspectRequest) (*api.CloudBackupRetentionInspectResponse, error) {
+	ret := m.ctrl.Call(m, "CloudBackupRetentionInspect", arg0)
+	ret0, _ := ret[0].(*api.CloudBackupRetentionInspectResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloudBackupRetentionInspect indicates an expected call of CloudBackupRetentionInspect
+func (mr *MockVolumeDriverMockRecorder) CloudBackupRetentionInspect(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudBackupRetentionInspect", reflect.TypeOf((*MockVolumeDriver)(nil).CloudBackupRetentionInspect), arg0)
+}
+
+// CloudBackupRetentionUpdate mocks base method
+func (m *MockVolumeDriver) CloudBackupRetentionUpdate(arg0 *api.CloudBackupRetentionUpdateRequest) error {
+	ret := m.ctrl.Call(m, "CloudBackupRetentionUpdate", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloudBackupRetentionUpdate indicates an expected call of CloudBackupRetentionUpdate
+func (mr *MockVolumeDriverMockRecorder) CloudBackupRetentionUpdate(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudBackupRetentionUpdate", reflect.TypeOf((*MockVolumeDriver)(nil).CloudBackupRetentionUpdate), arg0)
+}
+
 // CloudBackupSchedCreate mocks base method
 func (m *MockVolumeDriver) CloudBackupSchedCreate(arg0 *api.CloudBackupSchedCreateRequest) (*api.CloudBackupSchedCreateResponse, error) {
 	ret := m.ctrl.Call(m, "CloudBackupSchedCreate", arg0)
@@ -225,6 +250,31 @@ func (mr *MockVolumeDriverMockRecorder) CloudBackupSchedEnumerate() *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudBackupSchedEnumerate", reflect.TypeOf((*MockVolumeDriver)(nil).CloudBackupSchedEnumerate))
 }
 
+// CloudBackupScrubInspect mocks base method
+func (m *MockVolumeDriver) CloudBackupScrubInspect(arg0 *api.CloudBackupScrubInspectRequest) (*api.CloudBackupScrubInspectResponse, error) {
+	ret := m.ctrl.Call(m, "CloudBackupScrubInspect", arg0)
+	ret0, _ := ret[0].(*api.CloudBackupScrubInspectResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloudBackupScrubInspect indicates an expected call of CloudBackupScrubInspect
+func (mr *MockVolumeDriverMockRecorder) CloudBackupScrubInspect(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudBackupScrubInspect", reflect.TypeOf((*MockVolumeDriver)(nil).CloudBackupScrubInspect), arg0)
+}
+
+// CloudBackupScrubUpdate mocks base method
+func (m *MockVolumeDriver) CloudBackupScrubUpdate(arg0 *api.CloudBackupScrubUpdateRequest) error {
+	ret := m.ctrl.Call(m, "CloudBackupScrubUpdate", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloudBackupScrubUpdate indicates an expected call of CloudBackupScrubUpdate
+func (mr *MockVolumeDriverMockRecorder) CloudBackupScrubUpdate(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudBackupScrubUpdate", reflect.TypeOf((*MockVolumeDriver)(nil).CloudBackupScrubUpdate), arg0)
+}
+
 // CloudBackupStateChange mocks base method
 func (m *MockVolumeDriver) CloudBackupStateChange(arg0 *api.CloudBackupStateChangeRequest) error {
 	ret := m.ctrl.Call(m, "CloudBackupStateChange", arg0)
@@ -250,6 +300,19 @@ func (mr *MockVolumeDriverMockRecorder) CloudBackupStatus(arg0 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudBackupStatus", reflect.TypeOf((*MockVolumeDriver)(nil).CloudBackupStatus), arg0)
 }
 
+// CloudBackupVerify mocks base method
+func (m *MockVolumeDriver) CloudBackupVerify(arg0 *api.CloudBackupVerifyRequest) (*api.CloudBackupVerifyResponse, error) {
+	ret := m.ctrl.Call(m, "CloudBackupVerify", arg0)
+	ret0, _ := ret[0].(*api.CloudBackupVerifyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloudBackupVerify indicates an expected call of CloudBackupVerify
+func (mr *MockVolumeDriverMockRecorder) CloudBackupVerify(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudBackupVerify", reflect.TypeOf((*MockVolumeDriver)(nil).CloudBackupVerify), arg0)
+}
+
 // CloudMigrateCancel mocks base method
 func (m *MockVolumeDriver) CloudMigrateCancel(arg0 *api.CloudMigrateCancelRequest) error {
 	ret := m.ctrl.Call(m, "CloudMigrateCancel", arg0)
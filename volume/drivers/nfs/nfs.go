@@ -3,21 +3,22 @@ package nfs
 import (
 	"errors"
 	"fmt"
-	"io"
 	"os"
 	"path"
 	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/sirupsen/logrus"
 
-	"math/rand"
 	"strings"
 
 	"github.com/libopenstorage/openstorage/api"
 	"github.com/libopenstorage/openstorage/config"
 	"github.com/libopenstorage/openstorage/pkg/mount"
+	"github.com/libopenstorage/openstorage/pkg/quota"
 	"github.com/libopenstorage/openstorage/pkg/seed"
 	"github.com/libopenstorage/openstorage/volume"
 	"github.com/libopenstorage/openstorage/volume/drivers/common"
@@ -42,9 +43,30 @@ type driver struct {
 	volume.CredsDriver
 	volume.CloudBackupDriver
 	volume.CloudMigrateDriver
-	nfsServers []string
-	nfsPath    string
-	mounter    mount.Manager
+	cloudBackups common.CloudBackupProvider
+	nfsServers   []string
+	nfsPath      string
+	mounter      mount.Manager
+	// quotas limit the size of the volumes of each nfs server, which is only
+	// enforced if the export supports project quotas
+	quotas      map[string]*quota.Manager
+	usageAlerts *common.UsageAlerts
+	placement   *placement
+	// rebalanceThreshold enables the rebalancing of the volumes if it is
+	// not 0
+	rebalanceThreshold uint64
+	stop               chan struct{}
+	stopOnce           sync.Once
+	// pending are the snapshots whose files are being cloned
+	pending     map[string]*pendingSnapshot
+	pendingLock sync.Mutex
+	// moving are the volumes whose files are being moved to another server
+	moving     map[string]struct{}
+	movingLock sync.Mutex
+	// volumeLocks serialize the mounts, snapshots, deletion and moves of
+	// each volume
+	volumeLocks     map[string]*sync.Mutex
+	volumeLocksLock sync.Mutex
 }
 
 func Init(params map[string]string) (volume.VolumeDriver, error) {
@@ -69,18 +91,35 @@ func Init(params map[string]string) (volume.VolumeDriver, error) {
 		logrus.Warnf("Failed to create mount manager for server: %v (%v)", server, err)
 		return nil, err
 	}
+	placement, err := newPlacement(params[PlacementParam], servers)
+	if err != nil {
+		return nil, err
+	}
+	rebalanceThreshold, err := parseRebalanceThreshold(params)
+	if err != nil {
+		return nil, err
+	}
 	inst := &driver{
 		IODriver:           volume.IONotSupported,
 		StoreEnumerator:    common.NewDefaultStoreEnumerator(Name, kvdb.Instance()),
 		StatsDriver:        volume.StatsNotSupported,
 		QuiesceDriver:      volume.QuiesceNotSupported,
 		nfsServers:         servers,
-		CredsDriver:        volume.CredsNotSupported,
 		nfsPath:            path,
 		mounter:            mounter,
-		CloudBackupDriver:  volume.CloudBackupNotSupported,
 		CloudMigrateDriver: volume.CloudMigrateNotSupported,
-	}
+		quotas:             make(map[string]*quota.Manager),
+		placement:          placement,
+		rebalanceThreshold: rebalanceThreshold,
+		stop:               make(chan struct{}),
+		pending:            make(map[string]*pendingSnapshot),
+		moving:             make(map[string]struct{}),
+		volumeLocks:        make(map[string]*sync.Mutex),
+	}
+	cloudBackups := common.NewCloudBackupProvider(Name, kvdb.Instance(), inst)
+	inst.cloudBackups = cloudBackups
+	inst.CredsDriver = cloudBackups
+	inst.CloudBackupDriver = cloudBackups
 
 	//make directory for each nfs server
 	for _, v := range servers {
@@ -120,6 +159,26 @@ func Init(params map[string]string) (volume.VolumeDriver, error) {
 		}
 	}
 
+	for _, v := range inst.nfsServers {
+		// The exports are mounted by every node, so the volumes cannot be
+		// limited with loopback images.
+		quotas, err := quota.NewShared(nfsMountPath + v)
+		if err != nil {
+			return nil, err
+		}
+		inst.quotas[v] = quotas
+	}
+	usageAlerts, err := common.NewUsageAlerts(Name, kvdb.Instance(),
+		inst.StoreEnumerator, inst.UsedSize, params)
+	if err != nil {
+		return nil, err
+	}
+	inst.usageAlerts = usageAlerts
+	inst.usageAlerts.Start()
+	inst.checkServers()
+	go inst.healthLoop()
+	seed.SetVolumeResolver(inst.seedPath)
+
 	volumeInfo, err := inst.StoreEnumerator.Enumerate(&api.VolumeLocator{}, nil)
 	if err == nil {
 		for _, info := range volumeInfo {
@@ -127,6 +186,11 @@ func Init(params map[string]string) (volume.VolumeDriver, error) {
 				info.Status = api.VolumeStatus_VOLUME_STATUS_UP
 				inst.UpdateVol(info)
 			}
+			if info.State == api.VolumeState_VOLUME_STATE_PENDING {
+				info.State = api.VolumeState_VOLUME_STATE_ERROR
+				info.Error = "Snapshot was interrupted by a restart"
+				inst.UpdateVol(info)
+			}
 		}
 	}
 
@@ -149,27 +213,29 @@ func (d *driver) Version() (*api.StorageVersion, error) {
 	}, nil
 }
 
-// Status diagnostic information
+// Status reports the placement policy and the state of each nfs server.
 func (d *driver) Status() [][2]string {
-	return [][2]string{}
+	return d.serverStatus()
 }
 
 //
 //Utility functions
 //
-func (d *driver) getNewVolumeServer() (string, error) {
-	//randomly select one
-	if d.nfsServers != nil && len(d.nfsServers) > 0 {
-		return d.nfsServers[rand.Intn(len(d.nfsServers))], nil
+func (d *driver) getNewVolumeServer(labels map[string]string) (string, error) {
+	if len(d.nfsServers) == 0 {
+		return "", errors.New("No NFS servers found")
 	}
-
-	return "", errors.New("No NFS servers found")
+	vols, err := d.Enumerate(&api.VolumeLocator{}, nil)
+	if err != nil {
+		return "", err
+	}
+	return d.placement.choose(labels, vols)
 }
 
 //get nfsPath for specified volume
 func (d *driver) getNFSPath(v *api.Volume) (string, error) {
 	locator := v.GetLocator()
-	server, ok := locator.VolumeLabels["server"]
+	server, ok := locator.VolumeLabels[serverLabel]
 	if !ok {
 		logrus.Warnf("No server label found on volume")
 		return "", fmt.Errorf("No server label found on volume: " + v.Id)
@@ -188,6 +254,16 @@ func (d *driver) getNFSPathById(volumeID string) (string, error) {
 	return d.getNFSPath(v)
 }
 
+//get the quota manager of the nfs server of a volume
+func (d *driver) getQuota(v *api.Volume) (*quota.Manager, error) {
+	server := v.GetLocator().GetVolumeLabels()[serverLabel]
+	m, ok := d.quotas[server]
+	if !ok {
+		return nil, fmt.Errorf("NFS server %q of volume %s is not configured", server, v.Id)
+	}
+	return m, nil
+}
+
 //get nfsPath plus volume name for specified volume
 func (d *driver) getNFSVolumePath(v *api.Volume) (string, error) {
 	parentPath, err := d.getNFSPath(v)
@@ -208,6 +284,11 @@ func (d *driver) getNFSVolumePathById(volumeID string) (string, error) {
 	return d.getNFSVolumePath(v)
 }
 
+// CloudBackupPath returns the directory of the volume on its nfs server.
+func (d *driver) CloudBackupPath(volumeID string) (string, error) {
+	return d.getNFSVolumePathById(volumeID)
+}
+
 //append unix time to volumeID
 func (d *driver) getNewSnapVolName(volumeID string) string {
 	return volumeID + "-" + strconv.FormatUint(uint64(time.Now().Unix()), 10)
@@ -221,6 +302,16 @@ func (d *driver) Create(
 	locator *api.VolumeLocator,
 	source *api.Source,
 	spec *api.VolumeSpec) (string, error) {
+	return d.create(locator, source, spec, true)
+}
+
+// create creates a volume, whose directory is limited to the size of the
+// volume if limit is set.
+func (d *driver) create(
+	locator *api.VolumeLocator,
+	source *api.Source,
+	spec *api.VolumeSpec,
+	limit bool) (string, error) {
 
 	if len(locator.Name) == 0 {
 		return "", fmt.Errorf("volume name cannot be empty")
@@ -243,41 +334,74 @@ func (d *driver) Create(
 
 	//check if user passed server as option
 	labels := locator.GetVolumeLabels()
-	_, ok := labels["server"]
-	if !ok {
-		server, err := d.getNewVolumeServer()
+	if server, ok := labels[serverLabel]; !ok {
+		server, err := d.getNewVolumeServer(labels)
 		if err != nil {
 			logrus.Infof("no nfs servers found...")
 			return "", err
 		} else {
-			logrus.Infof("Assigning random nfs server: %s to volume: %s", server, volumeID)
+			logrus.Infof("Assigning nfs server: %s to volume: %s by %s placement",
+				server, volumeID, d.placement.policy)
 		}
 
-		labels["server"] = server
+		labels[serverLabel] = server
+	} else if state, ok := d.placement.state(server); !ok {
+		return "", fmt.Errorf("NFS server %q is not configured", server)
+	} else if !state.online {
+		return "", fmt.Errorf("NFS server %q is offline: %v", server, state.err)
 	}
 
 	// Create a directory on the NFS server with this UUID.
-	volPathParent := path.Join(nfsMountPath, labels["server"])
+	volPathParent := path.Join(nfsMountPath, labels[serverLabel])
 	volPath := path.Join(volPathParent, volumeID)
 	err := os.MkdirAll(volPath, 0744)
 	if err != nil {
 		logrus.Println(err)
 		return "", err
 	}
+	// Limit the directory to the size of the volume.
+	if limit && spec.GetSize() != 0 {
+		quotas, ok := d.quotas[labels[serverLabel]]
+		if !ok {
+			os.RemoveAll(volPath)
+			return "", fmt.Errorf("NFS server %q is not configured", labels[serverLabel])
+		}
+		if err := quotas.Create(volumeID, spec.Size); err != nil {
+			logrus.Warnf("Failed to limit the size of volume %s: %v", volumeID, err)
+			os.RemoveAll(volPath)
+			return "", err
+		}
+	}
 	if source != nil {
 		if len(source.Seed) != 0 {
-			seed, err := seed.New(source.Seed, spec.VolumeLabels)
+			src, err := seed.New(source.Seed, spec.VolumeLabels)
 			if err != nil {
 				logrus.Warnf("Failed to initailize seed from %q : %v",
 					source.Seed, err)
 				return "", err
 			}
-			err = seed.Load(path.Join(volPath, config.DataDir))
+			// Volumes are copied whole, the other sources are loaded
+			// into the data directory.
+			dest := path.Join(volPath, config.DataDir)
+			if _, ok := src.(*seed.Volume); ok {
+				dest = volPath
+			}
+			if _, err := src.MetadataRead(volPath); err != nil {
+				logrus.Warnf("Failed to read the seed metadata of %q: %v",
+					volPath, err)
+				return "", err
+			}
+			err = src.Load(dest)
 			if err != nil {
 				logrus.Warnf("Failed to  seed from %q to %q: %v",
 					source.Seed, volPathParent, err)
 				return "", err
 			}
+			if err := src.MetadataWrite(volPath); err != nil {
+				logrus.Warnf("Failed to write the seed metadata of %q: %v",
+					volPath, err)
+				return "", err
+			}
 		}
 	}
 
@@ -309,6 +433,12 @@ func (d *driver) Create(
 }
 
 func (d *driver) Delete(volumeID string) error {
+	d.cancelSnapshot(volumeID)
+	unlock := d.lockVolume(volumeID)
+	defer unlock()
+	if err := d.checkNotMoving(volumeID); err != nil {
+		return err
+	}
 	v, err := d.GetVol(volumeID)
 	if err != nil {
 		logrus.Println(err)
@@ -323,6 +453,14 @@ func (d *driver) Delete(volumeID string) error {
 		return err
 	}
 
+	quotas, err := d.getQuota(v)
+	if err != nil {
+		return err
+	}
+	if err := quotas.Remove(volumeID); err != nil {
+		return err
+	}
+
 	// Delete the directory on the nfs server.
 	os.RemoveAll(nfsVolPath)
 
@@ -332,6 +470,9 @@ func (d *driver) Delete(volumeID string) error {
 		return err
 	}
 
+	d.volumeLocksLock.Lock()
+	delete(d.volumeLocks, volumeID)
+	d.volumeLocksLock.Unlock()
 	return nil
 }
 
@@ -340,18 +481,35 @@ func (d *driver) MountedAt(mountpath string) string {
 }
 
 func (d *driver) Mount(volumeID string, mountpath string, options map[string]string) error {
+	unlock := d.lockVolume(volumeID)
+	defer unlock()
+	if err := d.checkNotMoving(volumeID); err != nil {
+		return err
+	}
 	v, err := d.GetVol(volumeID)
 	if err != nil {
 		logrus.Println(err)
 		return err
 	}
 
+	if err := checkReady(v); err != nil {
+		return err
+	}
+
 	nfsPath, err := d.getNFSPath(v)
 	if err != nil {
 		logrus.Printf("Could not find server for volume: %s", volumeID)
 		return err
 	}
 
+	quotas, err := d.getQuota(v)
+	if err != nil {
+		return err
+	}
+	if err := quotas.Activate(volumeID); err != nil {
+		return err
+	}
+
 	srcPath := path.Join(":", nfsPath, volumeID)
 	mountExists, err := d.mounter.Exists(srcPath, mountpath)
 	if !mountExists {
@@ -379,6 +537,8 @@ func (d *driver) Mount(volumeID string, mountpath string, options map[string]str
 }
 
 func (d *driver) Unmount(volumeID string, mountpath string, options map[string]string) error {
+	unlock := d.lockVolume(volumeID)
+	defer unlock()
 	v, err := d.GetVol(volumeID)
 	if err != nil {
 		return err
@@ -401,61 +561,6 @@ func (d *driver) Unmount(volumeID string, mountpath string, options map[string]s
 	return d.UpdateVol(v)
 }
 
-func (d *driver) Snapshot(volumeID string, readonly bool, locator *api.VolumeLocator, noRetry bool) (string, error) {
-	volIDs := []string{volumeID}
-	vols, err := d.Inspect(volIDs)
-	if err != nil {
-		return "", nil
-	}
-	source := &api.Source{Parent: volumeID}
-	locator.Name = d.getNewSnapVolName(source.Parent)
-
-	logrus.Infof("Creating snap vol name: %s", locator.Name)
-	newVolumeID, err := d.Create(locator, source, vols[0].Spec)
-	if err != nil {
-		return "", nil
-	}
-
-	nfsVolPath, err := d.getNFSVolumePathById(volumeID)
-	if err != nil {
-		return "", err
-	}
-
-	newNfsVolPath, err := d.getNFSVolumePathById(newVolumeID)
-	if err != nil {
-		return "", err
-	}
-
-	// NFS does not support snapshots, so just copy the files.
-	if err := copyDir(nfsVolPath, newNfsVolPath); err != nil {
-		d.Delete(newVolumeID)
-		return "", nil
-	}
-	return newVolumeID, nil
-}
-
-func (d *driver) Restore(volumeID string, snapID string) error {
-	if _, err := d.Inspect([]string{volumeID, snapID}); err != nil {
-		return err
-	}
-
-	nfsVolPath, err := d.getNFSVolumePathById(volumeID)
-	if err != nil {
-		return err
-	}
-
-	snapNfsVolPath, err := d.getNFSVolumePathById(snapID)
-	if err != nil {
-		return err
-	}
-
-	// NFS does not support restore, so just copy the files.
-	if err := copyDir(snapNfsVolPath, nfsVolPath); err != nil {
-		return err
-	}
-	return nil
-}
-
 func (d *driver) SnapshotGroup(groupID string, labels map[string]string, volumeIDs []string) (*api.GroupSnapCreateResponse, error) {
 
 	return nil, volume.ErrNotSupported
@@ -475,96 +580,132 @@ func (d *driver) Detach(volumeID string, options map[string]string) error {
 	return nil
 }
 
+// Set updates the locator of a volume and resizes it to the size of spec.
+// The other fields of spec cannot be changed. Changing the server label of an
+// unmounted volume moves it to that server in the background; the volume
+// keeps its server label and cannot be used until it is moved.
 func (d *driver) Set(volumeID string, locator *api.VolumeLocator, spec *api.VolumeSpec) error {
-	if spec != nil {
-		return volume.ErrNotSupported
+	unlock := d.lockVolume(volumeID)
+	defer unlock()
+	if err := d.checkNotMoving(volumeID); err != nil {
+		return err
 	}
 	v, err := d.GetVol(volumeID)
 	if err != nil {
 		return err
 	}
+	if fields := common.ChangedSpecFields(v.Spec, spec); len(fields) != 0 {
+		logrus.Warnf("Cannot change %s of nfs volume %s", strings.Join(fields, ", "), volumeID)
+		return volume.ErrNotSupported
+	}
+	server := v.Locator.VolumeLabels[serverLabel]
+	moveTo := ""
 	if locator != nil {
-		v.Locator = locator
+		if s, ok := locator.VolumeLabels[serverLabel]; ok && s != server {
+			if err := d.beginMove(v, s); err != nil {
+				return err
+			}
+			moveTo = s
+		}
 	}
-	return d.UpdateVol(v)
+	if err := d.set(v, locator, spec); err != nil {
+		if len(moveTo) != 0 {
+			d.endMove(volumeID)
+		}
+		return err
+	}
+	if len(moveTo) != 0 {
+		moved := proto.Clone(v).(*api.Volume)
+		go func() {
+			if err := d.move(moved, moveTo); err != nil {
+				logrus.Errorf("Failed to move volume %s from NFS server %q to %q: %v",
+					volumeID, server, moveTo, err)
+				return
+			}
+			logrus.Infof("Moved volume %s from NFS server %q to %q", volumeID, server, moveTo)
+		}()
+	}
+	return nil
 }
 
-func (d *driver) Shutdown() {
-	logrus.Printf("%s Shutting down", Name)
-
-	for _, v := range d.nfsServers {
-		logrus.Infof("Umounting: %s", nfsMountPath+v)
-		syscall.Unmount(path.Join(nfsMountPath, v), 0)
+// set resizes a volume and updates its locator, keeping its server label
+func (d *driver) set(v *api.Volume, locator *api.VolumeLocator, spec *api.VolumeSpec) error {
+	if spec.GetSize() != 0 && spec.Size != v.Spec.Size {
+		quotas, err := d.getQuota(v)
+		if err != nil {
+			return err
+		}
+		if err := quotas.Resize(v.Id, spec.Size); err != nil {
+			return err
+		}
+		// Resize the simulated block volume too.
+		if err := os.Truncate(v.DevicePath, int64(spec.Size)); err != nil {
+			logrus.Warnf("Failed to resize %s: %v", v.DevicePath, err)
+		}
+		v.Spec.Size = spec.Size
 	}
+	if locator != nil {
+		locator = proto.Clone(locator).(*api.VolumeLocator)
+		if locator.VolumeLabels == nil {
+			locator.VolumeLabels = make(map[string]string)
+		}
+		locator.VolumeLabels[serverLabel] = v.Locator.VolumeLabels[serverLabel]
+		v.Locator = locator
+	}
+	return d.UpdateVol(v)
 }
 
-func copyFile(source string, dest string) (err error) {
-	sourcefile, err := os.Open(source)
+// UsedSize returns the space used by a volume from its quota.
+func (d *driver) UsedSize(volumeID string) (uint64, error) {
+	v, err := d.GetVol(volumeID)
 	if err != nil {
-		return err
+		return 0, err
 	}
-
-	defer sourcefile.Close()
-
-	destfile, err := os.Create(dest)
+	quotas, err := d.getQuota(v)
 	if err != nil {
-		return err
+		return 0, err
 	}
-
-	defer destfile.Close()
-
-	_, err = io.Copy(destfile, sourcefile)
-	if err == nil {
-		sourceinfo, err := os.Stat(source)
-		if err != nil {
-			err = os.Chmod(dest, sourceinfo.Mode())
-		}
-
+	u, err := quotas.Usage(volumeID)
+	if err != nil {
+		return 0, err
 	}
-
-	return
+	return u.Used, nil
 }
 
-func copyDir(source string, dest string) (err error) {
-	// get properties of source dir
-	sourceinfo, err := os.Stat(source)
+// Stats returns the space used by a volume. IO stats are not tracked.
+func (d *driver) Stats(volumeID string, cumulative bool) (*api.Stats, error) {
+	used, err := d.UsedSize(volumeID)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return &api.Stats{BytesUsed: used}, nil
+}
 
-	// create dest dir
-
-	err = os.MkdirAll(dest, sourceinfo.Mode())
+// CapacityUsage returns the space used by a volume, which is not shared
+// with other volumes.
+func (d *driver) CapacityUsage(volumeID string) (*api.CapacityUsageResponse, error) {
+	used, err := d.UsedSize(volumeID)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return &api.CapacityUsageResponse{
+		CapacityUsageInfo: &api.CapacityUsageInfo{
+			ExclusiveBytes: int64(used),
+			TotalBytes:     int64(used),
+		},
+	}, nil
+}
 
-	directory, _ := os.Open(source)
-
-	objects, err := directory.Readdir(-1)
-
-	for _, obj := range objects {
-
-		sourcefilepointer := source + "/" + obj.Name()
-
-		destinationfilepointer := dest + "/" + obj.Name()
-
-		if obj.IsDir() {
-			// create sub-directories - recursively
-			err = copyDir(sourcefilepointer, destinationfilepointer)
-			if err != nil {
-				fmt.Println(err)
-			}
-		} else {
-			// perform copy
-			err = copyFile(sourcefilepointer, destinationfilepointer)
-			if err != nil {
-				fmt.Println(err)
-			}
-		}
+func (d *driver) Shutdown() {
+	logrus.Printf("%s Shutting down", Name)
+	d.usageAlerts.Stop()
+	d.cloudBackups.Stop()
+	d.stopOnce.Do(func() { close(d.stop) })
 
+	for _, v := range d.nfsServers {
+		logrus.Infof("Umounting: %s", nfsMountPath+v)
+		syscall.Unmount(path.Join(nfsMountPath, v), 0)
 	}
-	return
 }
 
 func (d *driver) Catalog(volumeID, path, depth string) (api.CatalogResponse, error) {
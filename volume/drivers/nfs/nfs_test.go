@@ -1,10 +1,25 @@
 package nfs
 
 import (
+	"io/ioutil"
 	"os"
+	"path"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/portworx/kvdb"
+	"github.com/portworx/kvdb/mem"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/clone"
+	"github.com/libopenstorage/openstorage/pkg/quota"
+	"github.com/libopenstorage/openstorage/volume"
+	"github.com/libopenstorage/openstorage/volume/drivers/common"
 	"github.com/libopenstorage/openstorage/volume/drivers/test"
 )
 
@@ -25,5 +40,88 @@ func TestAll(t *testing.T) {
 	ctx := test.NewContext(d)
 	ctx.Filesystem = api.FSType_FS_TYPE_NFS
 
-	test.RunShort(t, ctx)
+	test.Run(t, ctx)
+}
+
+func TestSet(t *testing.T) {
+	kv, err := kvdb.New(mem.Name, "nfs_set_test", []string{}, nil, logrus.Panicf)
+	require.NoError(t, err)
+	d := &driver{
+		StoreEnumerator: common.NewDefaultStoreEnumerator(Name, kv),
+		moving:          make(map[string]struct{}),
+		volumeLocks:     make(map[string]*sync.Mutex),
+	}
+	v := testVolume("a", nil)
+	v.Id = "vol1"
+	v.Spec = &api.VolumeSpec{Size: 1024, HaLevel: 1}
+	require.NoError(t, d.CreateVol(v))
+
+	// Only the size can change
+	err = d.Set("vol1", nil, &api.VolumeSpec{HaLevel: 2})
+	assert.Equal(t, volume.ErrNotSupported, err)
+	assert.NoError(t, d.Set("vol1", nil, &api.VolumeSpec{HaLevel: 1}))
+
+	// The volume keeps its server label, the locator of the caller is not
+	// changed
+	locator := &api.VolumeLocator{
+		Name:         "vol1",
+		VolumeLabels: map[string]string{"app": "db"},
+	}
+	require.NoError(t, d.Set("vol1", locator, nil))
+	assert.Equal(t, map[string]string{"app": "db"}, locator.VolumeLabels)
+	v, err = d.GetVol("vol1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"app": "db", serverLabel: "a"}, v.Locator.VolumeLabels)
+}
+
+func TestSnapshotSizedVolume(t *testing.T) {
+	server := "test-" + uuid.New()
+	serverPath := path.Join(nfsMountPath, server)
+	defer os.RemoveAll(serverPath)
+	quotas, err := quota.NewShared(serverPath)
+	require.NoError(t, err)
+	p, err := newPlacement("", []string{server})
+	require.NoError(t, err)
+	p.update(map[string]*serverState{server: {name: server, online: true}})
+	kv, err := kvdb.New(mem.Name, "nfs_snapshot_test", []string{}, nil, logrus.Panicf)
+	require.NoError(t, err)
+	d := &driver{
+		StoreEnumerator: common.NewDefaultStoreEnumerator(Name, kv),
+		quotas:          map[string]*quota.Manager{server: quotas},
+		placement:       p,
+		pending:         make(map[string]*pendingSnapshot),
+		moving:          make(map[string]struct{}),
+		volumeLocks:     make(map[string]*sync.Mutex),
+	}
+
+	// The directories of the sized volumes are not loopback images, so their
+	// files are reflinked to the snapshots if the server supports it
+	volumeID, err := d.Create(&api.VolumeLocator{
+		Name:         "vol1",
+		VolumeLabels: map[string]string{serverLabel: server},
+	}, nil, &api.VolumeSpec{Size: 1024 * 1024})
+	require.NoError(t, err)
+	assert.NotEqual(t, quota.TypeLoopback, quotas.Type())
+	file := path.Join(serverPath, volumeID, "file")
+	require.NoError(t, ioutil.WriteFile(file, []byte("data"), 0644))
+	expected, err := clone.File(file, file+".probe", clone.Copy)
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(file+".probe"))
+
+	snapID, err := d.Snapshot(volumeID, false, nil, false)
+	require.NoError(t, err)
+	var snap *api.Volume
+	for i := 0; i < 100; i++ {
+		snap, err = d.GetVol(snapID)
+		require.NoError(t, err)
+		if snap.State != api.VolumeState_VOLUME_STATE_PENDING {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, api.VolumeState_VOLUME_STATE_AVAILABLE, snap.State, snap.Error)
+	assert.Equal(t, expected.String(), snap.Locator.VolumeLabels[CloneMethodLabel])
+	b, err := ioutil.ReadFile(path.Join(serverPath, snapID, "file"))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(b))
 }
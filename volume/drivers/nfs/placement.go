@@ -0,0 +1,507 @@
+package nfs
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/clone"
+	"github.com/libopenstorage/openstorage/pkg/units"
+)
+
+const (
+	// PlacementParam is the driver parameter selecting the policy placing
+	// new volumes on the nfs servers
+	PlacementParam = "placement"
+	// PlacementMostFree places volumes on the server with the most free space
+	PlacementMostFree = "most_free"
+	// PlacementRoundRobin places volumes on the servers in turn
+	PlacementRoundRobin = "round_robin"
+	// PlacementLabelAffinity places volumes on the server holding the most
+	// volumes with the same labels, and on the server with the most free
+	// space if no volume shares their labels
+	PlacementLabelAffinity = "label_affinity"
+	// PlacementRandom places volumes on a random server
+	PlacementRandom = "random"
+	// RebalanceThresholdParam is the driver parameter enabling the
+	// rebalancing of the volumes, which are moved from the server with the
+	// least free space when the free space of the servers differs by more
+	// than this percentage
+	RebalanceThresholdParam = "rebalance_threshold"
+
+	// serverLabel is the label of the volumes naming their nfs server
+	serverLabel = "server"
+
+	healthCheckInterval = 30 * time.Second
+	healthCheckTimeout  = 10 * time.Second
+)
+
+var (
+	// ErrNoServer is returned when no nfs server can hold a new volume
+	ErrNoServer = errors.New("No online NFS servers found")
+)
+
+// serverState is the health and the capacity of an nfs server.
+type serverState struct {
+	name   string
+	online bool
+	// err is the reason the server is offline
+	err         error
+	total       uint64
+	free        uint64
+	volumes     int
+	provisioned uint64
+}
+
+// freePercent returns the percentage of the capacity of a server which is
+// free.
+func (s *serverState) freePercent() uint64 {
+	if s.total == 0 {
+		return 0
+	}
+	return s.free * 100 / s.total
+}
+
+// placement places new volumes on the nfs servers.
+type placement struct {
+	sync.Mutex
+	policy  string
+	order   []string
+	servers map[string]*serverState
+	// next is the index of the next server of the round robin policy
+	next int
+}
+
+func newPlacement(policy string, servers []string) (*placement, error) {
+	switch policy {
+	case "":
+		policy = PlacementMostFree
+	case PlacementMostFree, PlacementRoundRobin, PlacementLabelAffinity, PlacementRandom:
+	default:
+		return nil, fmt.Errorf("Invalid %s policy %q", PlacementParam, policy)
+	}
+	p := &placement{
+		policy:  policy,
+		order:   servers,
+		servers: make(map[string]*serverState),
+	}
+	for _, s := range servers {
+		// Servers are assumed online until they are checked.
+		p.servers[s] = &serverState{name: s, online: true}
+	}
+	return p, nil
+}
+
+// update records the state of the servers.
+func (p *placement) update(states map[string]*serverState) {
+	p.Lock()
+	defer p.Unlock()
+	for name, s := range states {
+		if _, ok := p.servers[name]; ok {
+			p.servers[name] = s
+		}
+	}
+}
+
+// state returns a copy of the state of a server.
+func (p *placement) state(server string) (serverState, bool) {
+	p.Lock()
+	defer p.Unlock()
+	s, ok := p.servers[server]
+	if !ok {
+		return serverState{}, false
+	}
+	return *s, true
+}
+
+// states returns a copy of the state of the servers in their order.
+func (p *placement) states() []serverState {
+	p.Lock()
+	defer p.Unlock()
+	states := make([]serverState, 0, len(p.order))
+	for _, name := range p.order {
+		states = append(states, *p.servers[name])
+	}
+	return states
+}
+
+// choose returns the server of a new volume with labels, given the
+// volumes of the servers.
+func (p *placement) choose(labels map[string]string, vols []*api.Volume) (string, error) {
+	p.Lock()
+	defer p.Unlock()
+	online := make([]*serverState, 0, len(p.order))
+	for _, name := range p.order {
+		if s := p.servers[name]; s.online {
+			online = append(online, s)
+		}
+	}
+	if len(online) == 0 {
+		return "", ErrNoServer
+	}
+
+	switch p.policy {
+	case PlacementRoundRobin:
+		for i := 0; i < len(p.order); i++ {
+			name := p.order[(p.next+i)%len(p.order)]
+			if p.servers[name].online {
+				p.next = (p.next + i + 1) % len(p.order)
+				return name, nil
+			}
+		}
+	case PlacementRandom:
+		return online[rand.Intn(len(online))].name, nil
+	case PlacementLabelAffinity:
+		if name := affinityServer(online, labels, vols); len(name) != 0 {
+			return name, nil
+		}
+	}
+	return mostFree(online).name, nil
+}
+
+// mostFree returns the server with the most free space.
+func mostFree(servers []*serverState) *serverState {
+	best := servers[0]
+	for _, s := range servers[1:] {
+		if s.free > best.free {
+			best = s
+		}
+	}
+	return best
+}
+
+// affinityServer returns the server holding the most volumes sharing
+// labels with a new volume, or "" if no volume shares its labels.
+func affinityServer(servers []*serverState, labels map[string]string, vols []*api.Volume) string {
+	matches := make(map[string]int)
+	for _, v := range vols {
+		server := v.GetLocator().GetVolumeLabels()[serverLabel]
+		for key, value := range labels {
+			if key == serverLabel || key == "name" {
+				continue
+			}
+			if v.GetLocator().GetVolumeLabels()[key] == value {
+				matches[server]++
+			}
+		}
+	}
+	best := ""
+	for _, s := range servers {
+		if matches[s.name] > matches[best] {
+			best = s.name
+		}
+	}
+	return best
+}
+
+// statfs returns the capacity and the free space of a directory, or an
+// error if the file system does not answer within the timeout as with an
+// unreachable nfs server.
+func statfs(dir string, timeout time.Duration) (uint64, uint64, error) {
+	type result struct {
+		st  syscall.Statfs_t
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		r.err = syscall.Statfs(dir, &r.st)
+		done <- r
+	}()
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return 0, 0, r.err
+		}
+		return r.st.Blocks * uint64(r.st.Bsize), r.st.Bavail * uint64(r.st.Bsize), nil
+	case <-time.After(timeout):
+		return 0, 0, fmt.Errorf("%s did not answer within %v", dir, timeout)
+	}
+}
+
+// checkServers checks the health and the capacity of the nfs servers.
+func (d *driver) checkServers() {
+	vols, err := d.Enumerate(&api.VolumeLocator{}, nil)
+	if err != nil {
+		logrus.Warnf("Failed to enumerate volumes: %v", err)
+	}
+	states := make(map[string]*serverState)
+	for _, name := range d.nfsServers {
+		s := &serverState{name: name}
+		s.total, s.free, s.err = statfs(nfsMountPath+name, healthCheckTimeout)
+		s.online = s.err == nil
+		if prev, ok := d.placement.state(name); ok && prev.online != s.online {
+			if s.online {
+				logrus.Infof("NFS server %q is back online", name)
+			} else {
+				logrus.Warnf("NFS server %q is offline: %v", name, s.err)
+			}
+		}
+		states[name] = s
+	}
+	for _, v := range vols {
+		if s, ok := states[v.GetLocator().GetVolumeLabels()[serverLabel]]; ok {
+			s.volumes++
+			s.provisioned += v.GetSpec().GetSize()
+		}
+	}
+	d.placement.update(states)
+}
+
+// healthLoop checks the nfs servers and rebalances their volumes
+// periodically.
+func (d *driver) healthLoop() {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.checkServers()
+			if d.rebalanceThreshold > 0 {
+				if err := d.rebalance(); err != nil {
+					logrus.Warnf("Failed to rebalance NFS volumes: %v", err)
+				}
+			}
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+// serverStatus returns the state of the nfs servers for Status.
+func (d *driver) serverStatus() [][2]string {
+	status := [][2]string{{"Placement", d.placement.policy}}
+	for _, s := range d.placement.states() {
+		value := fmt.Sprintf("online, %s free of %s, %d volumes, %s provisioned",
+			units.String(s.free), units.String(s.total), s.volumes,
+			units.String(s.provisioned))
+		if !s.online {
+			value = fmt.Sprintf("offline: %v", s.err)
+		}
+		name := s.name
+		if len(name) == 0 {
+			name = "local"
+		}
+		status = append(status, [2]string{"Server " + name, value})
+	}
+	return status
+}
+
+// parseRebalanceThreshold parses the rebalance threshold parameter, which
+// disables rebalancing when it is not set.
+func parseRebalanceThreshold(params map[string]string) (uint64, error) {
+	v, ok := params[RebalanceThresholdParam]
+	if !ok {
+		return 0, nil
+	}
+	t, err := strconv.ParseUint(v, 10, 64)
+	if err != nil || t > 100 {
+		return 0, fmt.Errorf("Invalid %s %q, must be a percentage", RebalanceThresholdParam, v)
+	}
+	return t, nil
+}
+
+// rebalance moves a volume from the online server with the least free
+// space to the one with the most free space, when their free space differs
+// by more than the rebalance threshold. Only unmounted volumes are moved,
+// the smallest first.
+func (d *driver) rebalance() error {
+	var online []serverState
+	for _, s := range d.placement.states() {
+		if s.online && s.total != 0 {
+			online = append(online, s)
+		}
+	}
+	if len(online) < 2 {
+		return nil
+	}
+	sort.Slice(online, func(i, j int) bool {
+		return online[i].freePercent() < online[j].freePercent()
+	})
+	from, to := online[0], online[len(online)-1]
+	if to.freePercent()-from.freePercent() <= d.rebalanceThreshold {
+		return nil
+	}
+
+	vols, err := d.Enumerate(&api.VolumeLocator{
+		VolumeLabels: map[string]string{serverLabel: from.name},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	sort.Slice(vols, func(i, j int) bool {
+		return vols[i].GetSpec().GetSize() < vols[j].GetSpec().GetSize()
+	})
+	for _, v := range vols {
+		used, err := d.UsedSize(v.Id)
+		if err != nil || used >= to.free {
+			continue
+		}
+		unlock := d.lockVolume(v.Id)
+		v, err := d.GetVol(v.Id)
+		if err == nil {
+			err = d.beginMove(v, to.name)
+		}
+		unlock()
+		if err != nil {
+			// Mounted or busy volumes are not moved
+			continue
+		}
+		logrus.Infof("Rebalancing volume %s from NFS server %q to %q", v.Id, from.name, to.name)
+		if err := d.move(v, to.name); err != nil {
+			return err
+		}
+		d.checkServers()
+		return nil
+	}
+	return nil
+}
+
+// lockVolume serializes the mounts, snapshots, deletion and moves of a
+// volume. It returns the function unlocking the volume.
+func (d *driver) lockVolume(volumeID string) func() {
+	d.volumeLocksLock.Lock()
+	l, ok := d.volumeLocks[volumeID]
+	if !ok {
+		l = &sync.Mutex{}
+		d.volumeLocks[volumeID] = l
+	}
+	d.volumeLocksLock.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+// checkNotMoving returns an error if the files of a volume are being moved
+// to another nfs server.
+func (d *driver) checkNotMoving(volumeID string) error {
+	d.movingLock.Lock()
+	defer d.movingLock.Unlock()
+	if _, ok := d.moving[volumeID]; ok {
+		return fmt.Errorf("Volume %s is being moved to another NFS server", volumeID)
+	}
+	return nil
+}
+
+// beginMove marks an unmounted volume as being moved to another nfs
+// server, so that it cannot be mounted, snapshotted or deleted until
+// move ends. It is called with the volume locked.
+func (d *driver) beginMove(v *api.Volume, server string) error {
+	if len(v.AttachPath) != 0 {
+		return fmt.Errorf("Volume %s must be unmounted to be moved to another server", v.Id)
+	}
+	if err := checkReady(v); err != nil {
+		return err
+	}
+	if v.Locator.VolumeLabels[serverLabel] == server {
+		return fmt.Errorf("Volume %s is already on NFS server %q", v.Id, server)
+	}
+	state, ok := d.placement.state(server)
+	if !ok {
+		return fmt.Errorf("NFS server %q is not configured", server)
+	}
+	if !state.online {
+		return fmt.Errorf("NFS server %q is offline: %v", server, state.err)
+	}
+
+	// The files of a volume are not moved while they are cloned to a
+	// snapshot.
+	d.pendingLock.Lock()
+	defer d.pendingLock.Unlock()
+	for snapID, p := range d.pending {
+		if p.parent == v.Id {
+			return fmt.Errorf("Volume %s cannot be moved while snapshot %s is being created",
+				v.Id, snapID)
+		}
+	}
+	d.movingLock.Lock()
+	defer d.movingLock.Unlock()
+	if _, ok := d.moving[v.Id]; ok {
+		return fmt.Errorf("Volume %s is being moved to another NFS server", v.Id)
+	}
+	d.moving[v.Id] = struct{}{}
+	return nil
+}
+
+// endMove ends the move of a volume started by beginMove
+func (d *driver) endMove(volumeID string) {
+	d.movingLock.Lock()
+	defer d.movingLock.Unlock()
+	delete(d.moving, volumeID)
+}
+
+// move moves the data of a volume marked by beginMove to another nfs
+// server and ends its move. The volume is left on its server if the copy
+// fails or the driver shuts down.
+func (d *driver) move(v *api.Volume, server string) error {
+	defer d.endMove(v.Id)
+
+	srcQuotas, err := d.getQuota(v)
+	if err != nil {
+		return err
+	}
+	srcPath, err := d.getNFSVolumePath(v)
+	if err != nil {
+		return err
+	}
+	if err := srcQuotas.Activate(v.Id); err != nil {
+		return err
+	}
+
+	destParent := path.Join(nfsMountPath, server)
+	destPath := path.Join(destParent, v.Id)
+	destBlock := path.Join(destParent, v.Id+nfsBlockFile)
+	if err := os.MkdirAll(destPath, 0744); err != nil {
+		return err
+	}
+	destQuotas := d.quotas[server]
+	cleanup := func() {
+		destQuotas.Remove(v.Id)
+		os.RemoveAll(destPath)
+		os.Remove(destBlock)
+	}
+	if v.GetSpec().GetSize() != 0 {
+		if err := destQuotas.Create(v.Id, v.Spec.Size); err != nil {
+			cleanup()
+			return err
+		}
+	}
+	if _, err := clone.Tree(srcPath, destPath, clone.Copy, d.stop); err != nil {
+		cleanup()
+		return err
+	}
+	if _, err := clone.File(v.DevicePath, destBlock, clone.Copy); err != nil {
+		cleanup()
+		return err
+	}
+
+	unlock := d.lockVolume(v.Id)
+	defer unlock()
+	v, err = d.GetVol(v.Id)
+	if err != nil {
+		cleanup()
+		return err
+	}
+	oldBlock := v.DevicePath
+	v.Locator.VolumeLabels[serverLabel] = server
+	v.DevicePath = destBlock
+	if err := d.UpdateVol(v); err != nil {
+		cleanup()
+		return err
+	}
+	if err := srcQuotas.Remove(v.Id); err != nil {
+		logrus.Warnf("Failed to remove the quota of volume %s: %v", v.Id, err)
+	}
+	os.RemoveAll(srcPath)
+	os.Remove(oldBlock)
+	return nil
+}
@@ -0,0 +1,178 @@
+package nfs
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libopenstorage/openstorage/api"
+)
+
+func testPlacement(t *testing.T, policy string) *placement {
+	p, err := newPlacement(policy, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	p.update(map[string]*serverState{
+		"a": {name: "a", online: true, total: 100, free: 10},
+		"b": {name: "b", online: true, total: 100, free: 50},
+		"c": {name: "c", online: true, total: 100, free: 30},
+	})
+	return p
+}
+
+func testVolume(server string, labels map[string]string) *api.Volume {
+	l := map[string]string{serverLabel: server}
+	for k, v := range labels {
+		l[k] = v
+	}
+	return &api.Volume{Locator: &api.VolumeLocator{VolumeLabels: l}}
+}
+
+func TestPlacementPolicies(t *testing.T) {
+	_, err := newPlacement("fastest", []string{"a"})
+	assert.Error(t, err)
+
+	p := testPlacement(t, "")
+	assert.Equal(t, PlacementMostFree, p.policy)
+	server, err := p.choose(nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "b", server)
+
+	p = testPlacement(t, PlacementRoundRobin)
+	var chosen []string
+	for i := 0; i < 4; i++ {
+		server, err := p.choose(nil, nil)
+		require.NoError(t, err)
+		chosen = append(chosen, server)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "a"}, chosen)
+
+	p = testPlacement(t, PlacementLabelAffinity)
+	vols := []*api.Volume{
+		testVolume("a", map[string]string{"app": "db"}),
+		testVolume("a", map[string]string{"app": "db"}),
+		testVolume("c", map[string]string{"app": "web"}),
+	}
+	server, err = p.choose(map[string]string{"app": "db"}, vols)
+	require.NoError(t, err)
+	assert.Equal(t, "a", server)
+	server, err = p.choose(map[string]string{"app": "web"}, vols)
+	require.NoError(t, err)
+	assert.Equal(t, "c", server)
+	// Volumes sharing no label go to the server with the most free space
+	server, err = p.choose(map[string]string{"app": "cache"}, vols)
+	require.NoError(t, err)
+	assert.Equal(t, "b", server)
+}
+
+func TestPlacementSkipsOfflineServers(t *testing.T) {
+	p := testPlacement(t, PlacementRoundRobin)
+	p.update(map[string]*serverState{
+		"b": {name: "b", err: errors.New("timeout")},
+	})
+	var chosen []string
+	for i := 0; i < 3; i++ {
+		server, err := p.choose(nil, nil)
+		require.NoError(t, err)
+		chosen = append(chosen, server)
+	}
+	assert.Equal(t, []string{"a", "c", "a"}, chosen)
+
+	p = testPlacement(t, PlacementMostFree)
+	p.update(map[string]*serverState{
+		"b": {name: "b", err: errors.New("timeout")},
+	})
+	server, err := p.choose(nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "c", server)
+
+	p.update(map[string]*serverState{
+		"a": {name: "a"},
+		"c": {name: "c"},
+	})
+	_, err = p.choose(nil, nil)
+	assert.Equal(t, ErrNoServer, err)
+}
+
+func TestStatfs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nfs-placement")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	total, free, err := statfs(dir, time.Second)
+	require.NoError(t, err)
+	assert.True(t, total > 0)
+	assert.True(t, free <= total)
+
+	_, _, err = statfs("/nonexistent/nfs/server", time.Second)
+	assert.Error(t, err)
+}
+
+func TestParseRebalanceThreshold(t *testing.T) {
+	threshold, err := parseRebalanceThreshold(map[string]string{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), threshold)
+	threshold, err = parseRebalanceThreshold(map[string]string{RebalanceThresholdParam: "20"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(20), threshold)
+	_, err = parseRebalanceThreshold(map[string]string{RebalanceThresholdParam: "200"})
+	assert.Error(t, err)
+}
+
+func TestBeginMove(t *testing.T) {
+	d := &driver{
+		placement:   testPlacement(t, ""),
+		pending:     make(map[string]*pendingSnapshot),
+		moving:      make(map[string]struct{}),
+		volumeLocks: make(map[string]*sync.Mutex),
+	}
+	d.placement.update(map[string]*serverState{
+		"c": {name: "c", err: errors.New("timeout")},
+	})
+	v := testVolume("a", nil)
+	v.Id = "vol1"
+
+	v.AttachPath = []string{"/mnt/vol1"}
+	assert.Error(t, d.beginMove(v, "b"))
+	v.AttachPath = nil
+	assert.Error(t, d.beginMove(v, "a"))
+	assert.Error(t, d.beginMove(v, "c"))
+	assert.Error(t, d.beginMove(v, "d"))
+
+	// The files of a volume are not moved while they are cloned
+	d.pending["snap1"] = &pendingSnapshot{parent: "vol1"}
+	assert.Error(t, d.beginMove(v, "b"))
+	delete(d.pending, "snap1")
+
+	assert.NoError(t, d.checkNotMoving("vol1"))
+	require.NoError(t, d.beginMove(v, "b"))
+	assert.Error(t, d.checkNotMoving("vol1"))
+	assert.Error(t, d.beginMove(v, "b"))
+	d.endMove("vol1")
+	assert.NoError(t, d.checkNotMoving("vol1"))
+}
+
+func TestLockVolume(t *testing.T) {
+	d := &driver{volumeLocks: make(map[string]*sync.Mutex)}
+
+	unlock := d.lockVolume("vol1")
+	locked := make(chan struct{})
+	go func() {
+		d.lockVolume("vol1")()
+		close(locked)
+	}()
+	// Other volumes are not locked
+	d.lockVolume("vol2")()
+	select {
+	case <-locked:
+		t.Fatal("Volume locked twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	<-locked
+}
@@ -0,0 +1,267 @@
+package nfs
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/clone"
+)
+
+const (
+	// HardLinksLabel set to "true" on a read only snapshot allows its files
+	// to be hard linked to the files of its volume when the nfs server does
+	// not support reflinks. Hard linked files see the writes made in place
+	// to the files of the volume.
+	HardLinksLabel = "hard_links"
+	// CloneMethodLabel is set on the snapshots once their files are cloned
+	// to "reflink", "copy" or "link", the latter if any file is hard linked
+	CloneMethodLabel = "clone_method"
+)
+
+// pendingSnapshot is a snapshot whose files are being cloned.
+type pendingSnapshot struct {
+	// parent is the id of the volume whose files are cloned
+	parent     string
+	cancel     chan struct{}
+	cancelOnce sync.Once
+	done       chan struct{}
+}
+
+// checkReady returns an error if the files of a snapshot are not cloned.
+func checkReady(v *api.Volume) error {
+	switch v.State {
+	case api.VolumeState_VOLUME_STATE_PENDING:
+		return fmt.Errorf("Snapshot %s is being created", v.Id)
+	case api.VolumeState_VOLUME_STATE_ERROR:
+		return fmt.Errorf("Snapshot %s failed: %s", v.Id, v.Error)
+	}
+	return nil
+}
+
+// Snapshot creates a snapshot on the nfs server of a volume and clones the
+// files of the volume in the background. The snapshot is pending until its
+// files are cloned. The files are reflinked if the server supports it, and
+// otherwise copied, or hard linked for the read only snapshots which allow it
+// with HardLinksLabel. A hard linked file shares the data of the file of the
+// volume, so it sees the writes made in place to that file; files which are
+// replaced are not affected. How the files were cloned is set in the
+// CloneMethodLabel of the snapshot.
+func (d *driver) Snapshot(volumeID string, readonly bool, locator *api.VolumeLocator, noRetry bool) (string, error) {
+	unlock := d.lockVolume(volumeID)
+	defer unlock()
+	if err := d.checkNotMoving(volumeID); err != nil {
+		return "", err
+	}
+	vols, err := d.Inspect([]string{volumeID})
+	if err != nil {
+		return "", err
+	}
+	if len(vols) == 0 {
+		return "", fmt.Errorf("Volume %s not found", volumeID)
+	}
+	parent := vols[0]
+	if err := checkReady(parent); err != nil {
+		return "", err
+	}
+	quotas, err := d.getQuota(parent)
+	if err != nil {
+		return "", err
+	}
+	if err := quotas.Activate(volumeID); err != nil {
+		return "", err
+	}
+
+	if locator == nil {
+		locator = &api.VolumeLocator{}
+	} else {
+		locator = proto.Clone(locator).(*api.VolumeLocator)
+	}
+	locator.Name = d.getNewSnapVolName(volumeID)
+	if locator.VolumeLabels == nil {
+		locator.VolumeLabels = make(map[string]string)
+	}
+	// The files of a snapshot can only be reflinked or linked on the server
+	// of its volume.
+	locator.VolumeLabels[serverLabel] = parent.Locator.VolumeLabels[serverLabel]
+	delete(locator.VolumeLabels, CloneMethodLabel)
+	spec := proto.Clone(parent.Spec).(*api.VolumeSpec)
+	mode := clone.Copy
+	if readonly && locator.VolumeLabels[HardLinksLabel] == "true" {
+		mode = clone.Link
+	}
+
+	logrus.Infof("Creating snap vol name: %s", locator.Name)
+	// Read only snapshots cannot grow, so they are not limited and their
+	// files can be linked to the files of the volume.
+	snapID, err := d.create(locator, &api.Source{Parent: volumeID}, spec, !readonly)
+	if err != nil {
+		return "", err
+	}
+	snap, err := d.GetVol(snapID)
+	if err != nil {
+		return "", err
+	}
+	snap.Readonly = readonly
+	snap.State = api.VolumeState_VOLUME_STATE_PENDING
+	if err := d.UpdateVol(snap); err != nil {
+		d.Delete(snapID)
+		return "", err
+	}
+
+	p := &pendingSnapshot{
+		parent: volumeID,
+		cancel: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	d.pendingLock.Lock()
+	d.pending[snapID] = p
+	d.pendingLock.Unlock()
+	go d.cloneSnapshot(parent, snap, mode, p)
+	return snapID, nil
+}
+
+// cloneSnapshot clones the files of a volume to its snapshot, which is
+// available once they are cloned.
+func (d *driver) cloneSnapshot(parent, snap *api.Volume, mode clone.Mode, p *pendingSnapshot) {
+	defer func() {
+		d.pendingLock.Lock()
+		delete(d.pending, snap.Id)
+		d.pendingLock.Unlock()
+		close(p.done)
+	}()
+
+	method, err := d.cloneVolume(parent, snap, mode, p.cancel)
+	if err == clone.ErrCanceled {
+		return
+	}
+	snap, gerr := d.GetVol(snap.Id)
+	if gerr != nil {
+		return
+	}
+	if err != nil {
+		logrus.Warnf("Failed to create snapshot %s of volume %s: %v", snap.Id, parent.Id, err)
+		snap.State = api.VolumeState_VOLUME_STATE_ERROR
+		snap.Error = err.Error()
+	} else {
+		logrus.Infof("Created snapshot %s of volume %s with %s", snap.Id, parent.Id, method)
+		snap.State = api.VolumeState_VOLUME_STATE_AVAILABLE
+		snap.Locator.VolumeLabels[CloneMethodLabel] = method.String()
+	}
+	if err := d.UpdateVol(snap); err != nil {
+		logrus.Warnf("Failed to update snapshot %s: %v", snap.Id, err)
+	}
+}
+
+// cloneVolume clones the files and the simulated block volume of a volume
+// to another. It returns the weakest method used to clone them.
+func (d *driver) cloneVolume(src, dest *api.Volume, mode clone.Mode, cancel <-chan struct{}) (clone.Method, error) {
+	srcPath, err := d.getNFSVolumePath(src)
+	if err != nil {
+		return clone.Copied, err
+	}
+	destPath, err := d.getNFSVolumePath(dest)
+	if err != nil {
+		return clone.Copied, err
+	}
+	method, err := clone.Tree(srcPath, destPath, mode, cancel)
+	if err != nil {
+		return method, err
+	}
+	os.Remove(dest.DevicePath)
+	blockMethod, err := clone.File(src.DevicePath, dest.DevicePath, mode)
+	if blockMethod > method {
+		method = blockMethod
+	}
+	return method, err
+}
+
+// cancelSnapshot stops the cloning of the files of a pending snapshot
+// before it is deleted.
+func (d *driver) cancelSnapshot(snapID string) {
+	d.pendingLock.Lock()
+	p, ok := d.pending[snapID]
+	d.pendingLock.Unlock()
+	if !ok {
+		return
+	}
+	p.cancelOnce.Do(func() { close(p.cancel) })
+	<-p.done
+}
+
+// Restore replaces the files of a volume with the files of a snapshot.
+func (d *driver) Restore(volumeID string, snapID string) error {
+	unlock := d.lockVolume(volumeID)
+	defer unlock()
+	for _, id := range []string{volumeID, snapID} {
+		if err := d.checkNotMoving(id); err != nil {
+			return err
+		}
+	}
+	vols, err := d.Inspect([]string{volumeID, snapID})
+	if err != nil {
+		return err
+	}
+	if len(vols) != 2 {
+		return fmt.Errorf("Volume %s or snapshot %s not found", volumeID, snapID)
+	}
+	v, snap := vols[0], vols[1]
+	if v.Id != volumeID {
+		v, snap = snap, v
+	}
+	if err := checkReady(snap); err != nil {
+		return err
+	}
+	for _, vol := range vols {
+		quotas, err := d.getQuota(vol)
+		if err != nil {
+			return err
+		}
+		if err := quotas.Activate(vol.Id); err != nil {
+			return err
+		}
+	}
+
+	volPath, err := d.getNFSVolumePath(v)
+	if err != nil {
+		return err
+	}
+	if err := clone.ClearDir(volPath); err != nil {
+		return err
+	}
+	// The files of the volume must not be linked to the files of the
+	// snapshot, which would see the writes to the volume.
+	snapPath, err := d.getNFSVolumePath(snap)
+	if err != nil {
+		return err
+	}
+	if _, err := clone.Tree(snapPath, volPath, clone.Copy, nil); err != nil {
+		return err
+	}
+	os.Remove(v.DevicePath)
+	_, err = clone.File(snap.DevicePath, v.DevicePath, clone.Copy)
+	return err
+}
+
+// seedPath returns the directory of a snapshot of a volume, to seed new
+// volumes from.
+func (d *driver) seedPath(volumeID, snapID string) (string, error) {
+	snap, err := d.GetVol(snapID)
+	if err != nil {
+		return "", err
+	}
+	if snap.GetSource().GetParent() != volumeID {
+		return "", fmt.Errorf("Volume %s is not a snapshot of volume %s", snapID, volumeID)
+	}
+	if err := checkReady(snap); err != nil {
+		return "", err
+	}
+	if err := d.checkNotMoving(snapID); err != nil {
+		return "", err
+	}
+	return d.getNFSVolumePath(snap)
+}
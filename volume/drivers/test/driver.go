@@ -90,6 +90,7 @@ func RunSnap(t *testing.T, ctx *Context) {
 	snap(t, ctx)
 	snapInspect(t, ctx)
 	snapEnumerate(t, ctx)
+	snapRestore(t, ctx)
 	snapDiff(t, ctx)
 	snapDelete(t, ctx)
 	detach(t, ctx)
@@ -355,10 +356,40 @@ func snapEnumerate(t *testing.T, ctx *Context) {
 	require.Equal(t, snaps[0].Id, ctx.snapID, "Expect snapID %v actual %v", ctx.snapID, snaps[0].Id)
 }
 
+// waitSnapReady waits for the files of a snapshot created in the background
+// to be ready.
+func waitSnapReady(t *testing.T, ctx *Context) {
+	deadline := time.Now().Add(5 * time.Minute)
+	for {
+		snaps, err := ctx.Inspect([]string{ctx.snapID})
+		require.NoError(t, err, "Failed in Inspect")
+		require.Equal(t, 1, len(snaps), "Expect 1 snap actual %v snaps", len(snaps))
+		require.NotEqual(t, api.VolumeState_VOLUME_STATE_ERROR, snaps[0].State,
+			"Snapshot failed: %v", snaps[0].Error)
+		if snaps[0].State != api.VolumeState_VOLUME_STATE_PENDING {
+			return
+		}
+		require.True(t, time.Now().Before(deadline), "Timed out waiting for snapshot %v", ctx.snapID)
+		time.Sleep(time.Second)
+	}
+}
+
+func snapRestore(t *testing.T, ctx *Context) {
+	fmt.Println("snapRestore")
+	waitSnapReady(t, ctx)
+	err := ctx.Restore(ctx.volID, ctx.snapID)
+	if err != volume.ErrNotSupported {
+		require.NoError(t, err, "Failed in Restore")
+	}
+}
+
 func snapDiff(t *testing.T, ctx *Context) {
 	fmt.Println("snapDiff")
 }
 
 func snapDelete(t *testing.T, ctx *Context) {
 	fmt.Println("snapDelete")
+	err := ctx.Delete(ctx.snapID)
+	require.NoError(t, err, "Failed to delete snapshot")
+	ctx.snapID = ""
 }
@@ -0,0 +1,86 @@
+package vfs
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/clone"
+	"github.com/libopenstorage/openstorage/volume"
+)
+
+// cloneParent clones the files of a volume into the directory of a new
+// volume, with reflinks if the file system supports them.
+func (d *driver) cloneParent(parentID, volumeID string) error {
+	if _, err := d.GetVol(parentID); err != nil {
+		return fmt.Errorf("Cannot find parent volume %s: %v", parentID, err)
+	}
+	if err := d.quota.Activate(parentID); err != nil {
+		return err
+	}
+	_, err := clone.Tree(filepath.Join(volume.VolumeBase, parentID),
+		filepath.Join(volume.VolumeBase, volumeID), clone.Copy, nil)
+	return err
+}
+
+// Snapshot creates a clone of a volume with the size of the volume.
+func (d *driver) Snapshot(volumeID string, readonly bool, locator *api.VolumeLocator, noRetry bool) (string, error) {
+	v, err := d.GetVol(volumeID)
+	if err != nil {
+		return "", err
+	}
+	if locator == nil {
+		locator = &api.VolumeLocator{}
+	}
+	if len(locator.Name) == 0 {
+		locator.Name = volumeID + "-" + strconv.FormatInt(time.Now().Unix(), 10)
+	}
+	spec := proto.Clone(v.Spec).(*api.VolumeSpec)
+	snapID, err := d.Create(locator, &api.Source{Parent: volumeID}, spec)
+	if err != nil {
+		return "", err
+	}
+	if readonly {
+		snap, err := d.GetVol(snapID)
+		if err != nil {
+			return "", err
+		}
+		snap.Readonly = true
+		if err := d.UpdateVol(snap); err != nil {
+			return "", err
+		}
+	}
+	logrus.Infof("Created snapshot %s of volume %s", snapID, volumeID)
+	return snapID, nil
+}
+
+// Restore replaces the files of a volume with the files of a snapshot.
+func (d *driver) Restore(volumeID string, snapID string) error {
+	if _, err := d.GetVol(volumeID); err != nil {
+		return err
+	}
+	if _, err := d.GetVol(snapID); err != nil {
+		return err
+	}
+	for _, id := range []string{volumeID, snapID} {
+		if err := d.quota.Activate(id); err != nil {
+			return err
+		}
+	}
+	defer d.usage.invalidate(volumeID)
+	dir := filepath.Join(volume.VolumeBase, volumeID)
+	if err := clone.ClearDir(dir); err != nil {
+		return err
+	}
+	_, err := clone.Tree(filepath.Join(volume.VolumeBase, snapID), dir, clone.Copy, nil)
+	return err
+}
+
+func (d *driver) SnapshotGroup(groupID string, labels map[string]string, volumeIDs []string) (*api.GroupSnapCreateResponse, error) {
+	return nil, volume.ErrNotSupported
+}
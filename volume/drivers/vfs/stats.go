@@ -0,0 +1,94 @@
+package vfs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/quota"
+)
+
+const (
+	// usageCacheTTL is how long the usage of a volume computed by walking
+	// its directory is reused
+	usageCacheTTL = 30 * time.Second
+)
+
+type usageEntry struct {
+	used    uint64
+	expires time.Time
+}
+
+// usageCache caches the usage of the volumes without a quota, which is
+// computed by walking their directory. The usage of the other volumes is
+// read from their quota.
+type usageCache struct {
+	sync.Mutex
+	entries map[string]usageEntry
+	now     func() time.Time
+}
+
+func newUsageCache() *usageCache {
+	return &usageCache{entries: make(map[string]usageEntry), now: time.Now}
+}
+
+// get returns the space used by a volume, from the cache if it was walked
+// recently.
+func (c *usageCache) get(volumeID string, usage func(string) (*quota.Usage, error)) (uint64, error) {
+	c.Lock()
+	e, ok := c.entries[volumeID]
+	c.Unlock()
+	if ok && c.now().Before(e.expires) {
+		return e.used, nil
+	}
+	u, err := usage(volumeID)
+	if err != nil {
+		return 0, err
+	}
+	if u.Type == quota.TypeNone {
+		c.Lock()
+		c.entries[volumeID] = usageEntry{used: u.Used, expires: c.now().Add(usageCacheTTL)}
+		c.Unlock()
+	}
+	return u.Used, nil
+}
+
+// invalidate drops the cached usage of a volume whose files were replaced.
+func (c *usageCache) invalidate(volumeID string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.entries, volumeID)
+}
+
+// UsedSize returns the space used by a volume from its quota, or by walking
+// its directory if it has no quota.
+func (d *driver) UsedSize(volumeID string) (uint64, error) {
+	if _, err := d.GetVol(volumeID); err != nil {
+		return 0, err
+	}
+	return d.usage.get(volumeID, d.quota.Usage)
+}
+
+// Stats returns the space used by a volume. IO stats are not tracked.
+func (d *driver) Stats(volumeID string, cumulative bool) (*api.Stats, error) {
+	used, err := d.UsedSize(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	return &api.Stats{BytesUsed: used}, nil
+}
+
+// CapacityUsage returns the space used by a volume, which is not shared
+// with other volumes.
+func (d *driver) CapacityUsage(volumeID string) (*api.CapacityUsageResponse, error) {
+	used, err := d.UsedSize(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	return &api.CapacityUsageResponse{
+		CapacityUsageInfo: &api.CapacityUsageInfo{
+			ExclusiveBytes: int64(used),
+			TotalBytes:     int64(used),
+		},
+	}, nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/quota"
 	"github.com/libopenstorage/openstorage/volume"
 	"github.com/libopenstorage/openstorage/volume/drivers/common"
 	"github.com/pborman/uuid"
@@ -30,26 +31,45 @@ const (
 type driver struct {
 	volume.IODriver
 	volume.BlockDriver
-	volume.SnapshotDriver
 	volume.StoreEnumerator
 	volume.StatsDriver
 	volume.CredsDriver
 	volume.CloudBackupDriver
 	volume.CloudMigrateDriver
+	cloudBackups common.CloudBackupProvider
+	quota        *quota.Manager
+	usageAlerts  *common.UsageAlerts
+	usage        *usageCache
 }
 
 // Init Driver intialization.
 func Init(params map[string]string) (volume.VolumeDriver, error) {
-	return &driver{
-		volume.IONotSupported,
-		volume.BlockNotSupported,
-		volume.SnapshotNotSupported,
-		common.NewDefaultStoreEnumerator(Name, kvdb.Instance()),
-		volume.StatsNotSupported,
-		volume.CredsNotSupported,
-		volume.CloudBackupNotSupported,
-		volume.CloudMigrateNotSupported,
-	}, nil
+	inst := &driver{
+		IODriver:           volume.IONotSupported,
+		BlockDriver:        volume.BlockNotSupported,
+		StoreEnumerator:    common.NewDefaultStoreEnumerator(Name, kvdb.Instance()),
+		StatsDriver:        volume.StatsNotSupported,
+		CloudMigrateDriver: volume.CloudMigrateNotSupported,
+		usage:              newUsageCache(),
+	}
+	cloudBackups := common.NewCloudBackupProvider(Name, kvdb.Instance(), inst)
+	inst.cloudBackups = cloudBackups
+	inst.CredsDriver = cloudBackups
+	inst.CloudBackupDriver = cloudBackups
+
+	quotas, err := quota.New(volume.VolumeBase)
+	if err != nil {
+		return nil, err
+	}
+	inst.quota = quotas
+	usageAlerts, err := common.NewUsageAlerts(Name, kvdb.Instance(),
+		inst.StoreEnumerator, inst.UsedSize, params)
+	if err != nil {
+		return nil, err
+	}
+	inst.usageAlerts = usageAlerts
+	inst.usageAlerts.Start()
+	return inst, nil
 }
 
 func (d *driver) Name() string {
@@ -73,6 +93,21 @@ func (d *driver) Create(locator *api.VolumeLocator, source *api.Source, spec *ap
 	if err := os.MkdirAll(filepath.Join(volume.VolumeBase, string(volumeID)), 0744); err != nil {
 		return "", err
 	}
+	// Limit the directory to the size of the volume.
+	if spec.GetSize() != 0 {
+		if err := d.quota.Create(volumeID, spec.Size); err != nil {
+			os.RemoveAll(filepath.Join(volume.VolumeBase, volumeID))
+			return "", err
+		}
+	}
+	// A clone starts with the files of its parent.
+	if len(source.GetParent()) != 0 {
+		if err := d.cloneParent(source.Parent, volumeID); err != nil {
+			d.quota.Remove(volumeID)
+			os.RemoveAll(filepath.Join(volume.VolumeBase, volumeID))
+			return "", err
+		}
+	}
 	v := common.NewVolume(
 		volumeID,
 		api.FSType_FS_TYPE_VFS,
@@ -91,7 +126,11 @@ func (d *driver) Delete(volumeID string) error {
 	if _, err := d.GetVol(volumeID); err != nil {
 		return err
 	}
+	if err := d.quota.Remove(volumeID); err != nil {
+		return err
+	}
 	os.RemoveAll(filepath.Join(volume.VolumeBase, string(volumeID)))
+	d.usage.invalidate(volumeID)
 	if err := d.DeleteVol(volumeID); err != nil {
 		return err
 	}
@@ -114,6 +153,9 @@ func (d *driver) Mount(volumeID string, mountpath string, options map[string]str
 	if len(v.AttachPath) > 0 && len(v.AttachPath) > 0 {
 		return fmt.Errorf("Volume %q already mounted at %q", volumeID, v.AttachPath[0])
 	}
+	if err := d.quota.Activate(volumeID); err != nil {
+		return err
+	}
 	syscall.Unmount(mountpath, 0)
 	if err := syscall.Mount(
 		filepath.Join(volume.VolumeBase, string(volumeID)),
@@ -152,25 +194,47 @@ func (d *driver) Unmount(volumeID string, mountpath string, options map[string]s
 	return d.UpdateVol(v)
 }
 
+// Set updates the locator of a volume and resizes it to the size of spec.
+// Fields of spec with their zero value are left unchanged, and changing any
+// field other than the size returns volume.ErrNotSupported.
 func (d *driver) Set(volumeID string, locator *api.VolumeLocator, spec *api.VolumeSpec) error {
-	if spec != nil {
-		return volume.ErrNotSupported
-	}
 	v, err := d.GetVol(volumeID)
 	if err != nil {
 		return err
 	}
+	if fields := common.ChangedSpecFields(v.Spec, spec); len(fields) != 0 {
+		logrus.Warnf("Cannot change %s of vfs volume %s", strings.Join(fields, ", "), volumeID)
+		return volume.ErrNotSupported
+	}
 	if locator != nil {
 		v.Locator = locator
 	}
+	if spec.GetSize() != 0 && spec.Size != v.Spec.Size {
+		if err := d.quota.Resize(volumeID, spec.Size); err != nil {
+			return err
+		}
+		d.usage.invalidate(volumeID)
+		v.Spec.Size = spec.Size
+	}
 	return d.UpdateVol(v)
 }
 
+// CloudBackupPath returns the directory of the volume to back up.
+func (d *driver) CloudBackupPath(volumeID string) (string, error) {
+	if _, err := d.GetVol(volumeID); err != nil {
+		return "", err
+	}
+	return filepath.Join(volume.VolumeBase, volumeID), nil
+}
+
 func (d *driver) Status() [][2]string {
 	return [][2]string{}
 }
 
-func (d *driver) Shutdown() {}
+func (d *driver) Shutdown() {
+	d.usageAlerts.Stop()
+	d.cloudBackups.Stop()
+}
 
 func (d *driver) fsFreeze(volumeID string, freeze bool) error {
 	v, err := d.GetVol(volumeID)
@@ -214,6 +278,14 @@ func (d *driver) Unquiesce(volumeID string) error {
 	return d.fsFreeze(volumeID, false)
 }
 
+// Catalog lists the files of a directory of a volume, down to depth
+// levels of subdirectories or all of them if depth is 0.
 func (d *driver) Catalog(volumeID, path string, depth string) (api.CatalogResponse, error) {
-	return api.CatalogResponse{}, volume.ErrNotSupported
+	if _, err := d.GetVol(volumeID); err != nil {
+		return api.CatalogResponse{}, err
+	}
+	if err := d.quota.Activate(volumeID); err != nil {
+		return api.CatalogResponse{}, err
+	}
+	return common.Catalog(filepath.Join(volume.VolumeBase, volumeID), path, depth)
 }
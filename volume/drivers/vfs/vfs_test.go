@@ -0,0 +1,118 @@
+package vfs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/quota"
+	"github.com/libopenstorage/openstorage/volume"
+	"github.com/libopenstorage/openstorage/volume/drivers/test"
+)
+
+func TestAll(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("The vfs driver needs root to mount volumes")
+	}
+	d, err := Init(map[string]string{})
+	require.NoError(t, err)
+	ctx := test.NewContext(d)
+	ctx.Filesystem = api.FSType_FS_TYPE_VFS
+	test.Run(t, ctx)
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("The vfs driver needs root to limit volumes")
+	}
+	d, err := Init(map[string]string{})
+	require.NoError(t, err)
+	defer d.Shutdown()
+
+	volumeID, err := d.Create(&api.VolumeLocator{Name: "snaps"}, nil, &api.VolumeSpec{})
+	require.NoError(t, err)
+	defer d.Delete(volumeID)
+	dir := filepath.Join(volume.VolumeBase, volumeID)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file"), []byte("v1"), 0644))
+
+	snapID, err := d.Snapshot(volumeID, true, &api.VolumeLocator{Name: "snap"}, false)
+	require.NoError(t, err)
+	defer d.Delete(snapID)
+	snaps, err := d.Inspect([]string{snapID})
+	require.NoError(t, err)
+	require.Len(t, snaps, 1)
+	assert.True(t, snaps[0].Readonly)
+	assert.Equal(t, volumeID, snaps[0].Source.Parent)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file"), []byte("v2"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "new"), []byte("new"), 0644))
+	require.NoError(t, d.Restore(volumeID, snapID))
+	b, err := ioutil.ReadFile(filepath.Join(dir, "file"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(b))
+	_, err = os.Stat(filepath.Join(dir, "new"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Clones start with the files of their parent
+	cloneID, err := d.Create(&api.VolumeLocator{Name: "clone"}, &api.Source{Parent: snapID},
+		&api.VolumeSpec{})
+	require.NoError(t, err)
+	defer d.Delete(cloneID)
+	b, err = ioutil.ReadFile(filepath.Join(volume.VolumeBase, cloneID, "file"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(b))
+
+	_, err = d.Create(&api.VolumeLocator{Name: "orphan"}, &api.Source{Parent: "missing"},
+		&api.VolumeSpec{})
+	assert.Error(t, err)
+
+	used, err := d.UsedSize(volumeID)
+	require.NoError(t, err)
+	assert.True(t, used > 0)
+	resp, err := d.Catalog(volumeID, "", "0")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Report.Files)
+}
+
+func TestUsageCache(t *testing.T) {
+	now := time.Now()
+	c := newUsageCache()
+	c.now = func() time.Time { return now }
+	walks := 0
+	usage := &quota.Usage{Type: quota.TypeNone, Used: 100}
+	walk := func(string) (*quota.Usage, error) {
+		walks++
+		return usage, nil
+	}
+
+	used, err := c.get("vol", walk)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), used)
+	usage.Used = 200
+	used, _ = c.get("vol", walk)
+	assert.Equal(t, uint64(100), used)
+	assert.Equal(t, 1, walks)
+
+	now = now.Add(usageCacheTTL)
+	used, _ = c.get("vol", walk)
+	assert.Equal(t, uint64(200), used)
+	assert.Equal(t, 2, walks)
+
+	usage.Used = 300
+	c.invalidate("vol")
+	used, _ = c.get("vol", walk)
+	assert.Equal(t, uint64(300), used)
+
+	// The usage read from quotas is not cached
+	usage.Type = quota.TypeProject
+	c.invalidate("vol")
+	c.get("vol", walk)
+	c.get("vol", walk)
+	assert.Equal(t, 5, walks)
+}
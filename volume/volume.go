@@ -40,6 +40,9 @@ var (
 	ErrAborted = errors.New("Aborted CapacityUsage request")
 	// ErrInvalidName returned when Cloudbackup Name/request is invalid
 	ErrInvalidName = errors.New("Invalid name for cloud backup/restore request")
+	// ErrNodeInMaintenance returned when volumes cannot be placed on or
+	// attached to a node because it is in maintenance
+	ErrNodeInMaintenance = errors.New("Node is in maintenance")
 )
 
 // Constants used by the VolumeDriver
@@ -172,6 +175,20 @@ type CloudBackupDriver interface {
 	CloudBackupSchedDelete(input *api.CloudBackupSchedDeleteRequest) error
 	// CloudBackupSchedEnumerate enumerates the configured backup schedules in the cluster
 	CloudBackupSchedEnumerate() (*api.CloudBackupSchedEnumerateResponse, error)
+	// CloudBackupRetentionUpdate sets the retention policy of the backups
+	// stored with a credential
+	CloudBackupRetentionUpdate(input *api.CloudBackupRetentionUpdateRequest) error
+	// CloudBackupRetentionInspect returns the retention policy of the backups
+	// stored with a credential
+	CloudBackupRetentionInspect(input *api.CloudBackupRetentionInspectRequest) (*api.CloudBackupRetentionInspectResponse, error)
+	// CloudBackupVerify starts a task verifying that a backup can be restored
+	CloudBackupVerify(input *api.CloudBackupVerifyRequest) (*api.CloudBackupVerifyResponse, error)
+	// CloudBackupScrubUpdate sets the schedule at which the backups stored
+	// with a credential are verified
+	CloudBackupScrubUpdate(input *api.CloudBackupScrubUpdateRequest) error
+	// CloudBackupScrubInspect returns the scrub schedule of the backups
+	// stored with a credential
+	CloudBackupScrubInspect(input *api.CloudBackupScrubInspectRequest) (*api.CloudBackupScrubInspectResponse, error)
 }
 
 // CloudMigrateDriver interface provides Cloud migration features
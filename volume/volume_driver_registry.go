@@ -65,7 +65,7 @@ func (v *volumeDriverRegistry) Register(name string, params map[string]string) e
 	if err != nil {
 		return err
 	}
-	v.nameToVolumeDriver[name] = volumeDriver
+	v.nameToVolumeDriver[name] = newPlacementGuard(newScheduleTracker(volumeDriver))
 	return nil
 }
 
@@ -219,6 +219,36 @@ func (cl *cloudBackupNotSupported) CloudBackupSchedEnumerate() (*api.CloudBackup
 	return nil, ErrNotSupported
 }
 
+func (cl *cloudBackupNotSupported) CloudBackupRetentionUpdate(
+	input *api.CloudBackupRetentionUpdateRequest,
+) error {
+	return ErrNotSupported
+}
+
+func (cl *cloudBackupNotSupported) CloudBackupRetentionInspect(
+	input *api.CloudBackupRetentionInspectRequest,
+) (*api.CloudBackupRetentionInspectResponse, error) {
+	return nil, ErrNotSupported
+}
+
+func (cl *cloudBackupNotSupported) CloudBackupVerify(
+	input *api.CloudBackupVerifyRequest,
+) (*api.CloudBackupVerifyResponse, error) {
+	return nil, ErrNotSupported
+}
+
+func (cl *cloudBackupNotSupported) CloudBackupScrubUpdate(
+	input *api.CloudBackupScrubUpdateRequest,
+) error {
+	return ErrNotSupported
+}
+
+func (cl *cloudBackupNotSupported) CloudBackupScrubInspect(
+	input *api.CloudBackupScrubInspectRequest,
+) (*api.CloudBackupScrubInspectResponse, error) {
+	return nil, ErrNotSupported
+}
+
 type cloudMigrateNotSupported struct{}
 
 func (cl *cloudMigrateNotSupported) CloudMigrateStart(request *api.CloudMigrateStartRequest) (*api.CloudMigrateStartResponse, error) {
@@ -0,0 +1,86 @@
+package volume
+
+import (
+	"sync"
+
+	"github.com/libopenstorage/openstorage/api"
+)
+
+// PlacementCheck returns an error, such as ErrNodeInMaintenance, if volumes
+// cannot be placed on or attached to this node, or placed on nodeIDs.
+type PlacementCheck func(nodeIDs []string) error
+
+var (
+	placementCheck     PlacementCheck
+	placementCheckLock sync.RWMutex
+)
+
+// SetPlacementCheck sets the check the registered drivers run before
+// creating, cloning, attaching or mounting volumes. A nil check allows
+// everything.
+func SetPlacementCheck(check PlacementCheck) {
+	placementCheckLock.Lock()
+	defer placementCheckLock.Unlock()
+	placementCheck = check
+}
+
+func checkPlacement(nodeIDs []string) error {
+	placementCheckLock.RLock()
+	check := placementCheck
+	placementCheckLock.RUnlock()
+	if check == nil {
+		return nil
+	}
+	return check(nodeIDs)
+}
+
+// placementGuard runs the placement check before the calls of a driver
+// which place volumes on or attach volumes to a node, whichever API they
+// come from.
+type placementGuard struct {
+	VolumeDriver
+}
+
+func newPlacementGuard(d VolumeDriver) VolumeDriver {
+	return &placementGuard{VolumeDriver: d}
+}
+
+func (g *placementGuard) Create(
+	locator *api.VolumeLocator,
+	source *api.Source,
+	spec *api.VolumeSpec,
+) (string, error) {
+	if err := checkPlacement(spec.GetReplicaSet().GetNodes()); err != nil {
+		return "", err
+	}
+	return g.VolumeDriver.Create(locator, source, spec)
+}
+
+// Snapshot checks the placement of writable snapshots, which are clones
+func (g *placementGuard) Snapshot(
+	volumeID string,
+	readonly bool,
+	locator *api.VolumeLocator,
+	noRetry bool,
+) (string, error) {
+	if !readonly {
+		if err := checkPlacement(nil); err != nil {
+			return "", err
+		}
+	}
+	return g.VolumeDriver.Snapshot(volumeID, readonly, locator, noRetry)
+}
+
+func (g *placementGuard) Attach(volumeID string, attachOptions map[string]string) (string, error) {
+	if err := checkPlacement(nil); err != nil {
+		return "", err
+	}
+	return g.VolumeDriver.Attach(volumeID, attachOptions)
+}
+
+func (g *placementGuard) Mount(volumeID string, mountPath string, options map[string]string) error {
+	if err := checkPlacement(nil); err != nil {
+		return err
+	}
+	return g.VolumeDriver.Mount(volumeID, mountPath, options)
+}
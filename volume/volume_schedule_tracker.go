@@ -0,0 +1,184 @@
+package volume
+
+import (
+	"sync"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/sched"
+	"github.com/libopenstorage/openstorage/schedpolicy"
+	"github.com/sirupsen/logrus"
+)
+
+// SchedulePolicyTrack records ref as using the schedule policies with the
+// given names, no names forget about ref. It returns an error if any of the
+// policies does not exist.
+type SchedulePolicyTrack func(ref schedpolicy.Reference, names []string) error
+
+var (
+	schedulePolicyTrack     SchedulePolicyTrack
+	schedulePolicyTrackLock sync.RWMutex
+)
+
+// SetSchedulePolicyTrack sets the function the registered drivers record
+// the schedule policies used by the volumes and the cloud backup schedules
+// with. A nil function tracks nothing.
+func SetSchedulePolicyTrack(track SchedulePolicyTrack) {
+	schedulePolicyTrackLock.Lock()
+	defer schedulePolicyTrackLock.Unlock()
+	schedulePolicyTrack = track
+}
+
+func getSchedulePolicyTrack() SchedulePolicyTrack {
+	schedulePolicyTrackLock.RLock()
+	defer schedulePolicyTrackLock.RUnlock()
+	return schedulePolicyTrack
+}
+
+// schedulePolicyNames returns the names in the policy tags of schedule
+func schedulePolicyNames(schedule string) []string {
+	_, policies, err := sched.ParseScheduleAndPolicies(schedule)
+	if err != nil || policies == nil {
+		return nil
+	}
+	return policies.Names
+}
+
+func volumeReference(volumeID string) schedpolicy.Reference {
+	return schedpolicy.Reference{Type: schedpolicy.ReferenceVolume, ID: volumeID}
+}
+
+func cloudBackupScheduleReference(id string) schedpolicy.Reference {
+	return schedpolicy.Reference{Type: schedpolicy.ReferenceCloudBackupSchedule, ID: id}
+}
+
+// scheduleTracker records the schedule policies used by the volumes and
+// the cloud backup schedules of a driver, whichever API creates them, so
+// that policies in use cannot be deleted.
+type scheduleTracker struct {
+	VolumeDriver
+}
+
+func newScheduleTracker(d VolumeDriver) VolumeDriver {
+	return &scheduleTracker{VolumeDriver: d}
+}
+
+// Create deletes the new volume if its policies cannot be tracked, which
+// happens if one of them was deleted meanwhile.
+func (t *scheduleTracker) Create(
+	locator *api.VolumeLocator,
+	source *api.Source,
+	spec *api.VolumeSpec,
+) (string, error) {
+	id, err := t.VolumeDriver.Create(locator, source, spec)
+	track := getSchedulePolicyTrack()
+	if err != nil || track == nil {
+		return id, err
+	}
+	names := schedulePolicyNames(spec.GetSnapshotSchedule())
+	if len(names) == 0 {
+		return id, nil
+	}
+	if err := track(volumeReference(id), names); err != nil {
+		if delErr := t.VolumeDriver.Delete(id); delErr != nil {
+			logrus.Warnf("Failed to delete volume %s whose schedule policies "+
+				"could not be tracked: %v", id, delErr)
+		}
+		return "", err
+	}
+	return id, nil
+}
+
+// Set records the policies of the new snapshot schedule, along with the
+// ones of the current schedule, before updating the volume so that none of
+// them can be deleted meanwhile. The policies of the schedule the volume
+// ends up with are recorded once it is updated.
+func (t *scheduleTracker) Set(volumeID string, locator *api.VolumeLocator, spec *api.VolumeSpec) error {
+	track := getSchedulePolicyTrack()
+	if track == nil || spec == nil {
+		return t.VolumeDriver.Set(volumeID, locator, spec)
+	}
+	var current []string
+	if vols, err := t.VolumeDriver.Inspect([]string{volumeID}); err == nil && len(vols) == 1 {
+		current = schedulePolicyNames(vols[0].GetSpec().GetSnapshotSchedule())
+	}
+	names := schedulePolicyNames(spec.GetSnapshotSchedule())
+	if len(names) != 0 {
+		if err := track(volumeReference(volumeID), append(names, current...)); err != nil {
+			return err
+		}
+	}
+
+	if err := t.VolumeDriver.Set(volumeID, locator, spec); err != nil {
+		if len(names) != 0 {
+			t.track(track, volumeReference(volumeID), current)
+		}
+		return err
+	}
+
+	vols, err := t.VolumeDriver.Inspect([]string{volumeID})
+	if err != nil || len(vols) != 1 {
+		logrus.Warnf("Failed to get the snapshot schedule of volume %s: %v", volumeID, err)
+		return nil
+	}
+	updated := schedulePolicyNames(vols[0].GetSpec().GetSnapshotSchedule())
+	if len(names) != 0 || len(current) != 0 || len(updated) != 0 {
+		t.track(track, volumeReference(volumeID), updated)
+	}
+	return nil
+}
+
+func (t *scheduleTracker) Delete(volumeID string) error {
+	if err := t.VolumeDriver.Delete(volumeID); err != nil {
+		return err
+	}
+	if track := getSchedulePolicyTrack(); track != nil {
+		t.track(track, volumeReference(volumeID), nil)
+	}
+	return nil
+}
+
+// CloudBackupSchedCreate deletes the new schedule if its policies cannot be
+// tracked, which happens if one of them was deleted meanwhile.
+func (t *scheduleTracker) CloudBackupSchedCreate(
+	input *api.CloudBackupSchedCreateRequest,
+) (*api.CloudBackupSchedCreateResponse, error) {
+	resp, err := t.VolumeDriver.CloudBackupSchedCreate(input)
+	track := getSchedulePolicyTrack()
+	if err != nil || track == nil {
+		return resp, err
+	}
+	names := schedulePolicyNames(input.Schedule)
+	if len(names) == 0 {
+		return resp, nil
+	}
+	if err := track(cloudBackupScheduleReference(resp.UUID), names); err != nil {
+		delErr := t.VolumeDriver.CloudBackupSchedDelete(&api.CloudBackupSchedDeleteRequest{
+			UUID: resp.UUID,
+		})
+		if delErr != nil {
+			logrus.Warnf("Failed to delete cloud backup schedule %s whose schedule "+
+				"policies could not be tracked: %v", resp.UUID, delErr)
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *scheduleTracker) CloudBackupSchedDelete(input *api.CloudBackupSchedDeleteRequest) error {
+	if err := t.VolumeDriver.CloudBackupSchedDelete(input); err != nil {
+		return err
+	}
+	if track := getSchedulePolicyTrack(); track != nil {
+		t.track(track, cloudBackupScheduleReference(input.UUID), nil)
+	}
+	return nil
+}
+
+// track records the policies of an object which has already been changed.
+// Failures are only logged, the policies are tracked again when the object
+// is updated.
+func (t *scheduleTracker) track(track SchedulePolicyTrack, ref schedpolicy.Reference, names []string) {
+	if err := track(ref, names); err != nil {
+		logrus.Warnf("Failed to track the schedule policies of %v: %v", ref, err)
+	}
+}